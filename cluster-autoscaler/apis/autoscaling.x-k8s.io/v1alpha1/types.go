@@ -0,0 +1,203 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterAutoscalerStatus is the status of Cluster Autoscaler and the node groups it manages.
+// It's updated by Cluster Autoscaler at the end of each autoscaling iteration.
+type ClusterAutoscalerStatus struct {
+	metav1.TypeMeta `json:",inline"`
+	// Standard object metadata. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+
+	// Status of Cluster Autoscaler.
+	// +optional
+	Status ClusterAutoscalerState `json:"status,omitempty" protobuf:"bytes,2,opt,name=status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterAutoscalerStatusList is a list of ClusterAutoscalerStatus objects.
+type ClusterAutoscalerStatusList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []ClusterAutoscalerStatus `json:"items"`
+}
+
+// ClusterAutoscalerState describes the state of Cluster Autoscaler as seen in its last iteration.
+type ClusterAutoscalerState struct {
+	// LastUpdateTime is the time when the status was last updated.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty" protobuf:"bytes,1,opt,name=lastUpdateTime"`
+
+	// ClusterwideConditions contains conditions that apply to the whole autoscaler.
+	// +optional
+	ClusterwideConditions []ClusterAutoscalerCondition `json:"clusterwideConditions,omitempty" protobuf:"bytes,2,rep,name=clusterwideConditions"`
+
+	// NodeGroupStatuses contains status information of individual node groups on which Cluster Autoscaler works.
+	// +optional
+	NodeGroupStatuses []NodeGroupStatus `json:"nodeGroupStatuses,omitempty" protobuf:"bytes,3,rep,name=nodeGroupStatuses"`
+}
+
+// ClusterAutoscalerConditionType is the type of ClusterAutoscalerCondition.
+type ClusterAutoscalerConditionType string
+
+const (
+	// ClusterAutoscalerHealth is a condition that explains what is the current health
+	// of Cluster Autoscaler or its node groups.
+	ClusterAutoscalerHealth ClusterAutoscalerConditionType = "Health"
+	// ClusterAutoscalerScaleDown is a condition that explains what is the current status
+	// of a node group with regard to scale down activities.
+	ClusterAutoscalerScaleDown ClusterAutoscalerConditionType = "ScaleDown"
+	// ClusterAutoscalerScaleUp is a condition that explains what is the current status
+	// of a node group with regard to scale up activities.
+	ClusterAutoscalerScaleUp ClusterAutoscalerConditionType = "ScaleUp"
+	// ClusterAutoscalerOptions is a condition that explains whether the autoscaling options
+	// defined in the cluster-autoscaler-options ConfigMap are valid.
+	ClusterAutoscalerOptions ClusterAutoscalerConditionType = "Options"
+)
+
+// ClusterAutoscalerConditionStatus is a status of ClusterAutoscalerCondition.
+type ClusterAutoscalerConditionStatus string
+
+const (
+	// ClusterAutoscalerHealthy status means that the cluster is in a good shape.
+	ClusterAutoscalerHealthy ClusterAutoscalerConditionStatus = "Healthy"
+	// ClusterAutoscalerUnhealthy status means that the cluster is in a bad shape.
+	ClusterAutoscalerUnhealthy ClusterAutoscalerConditionStatus = "Unhealthy"
+	// ClusterAutoscalerCandidatesPresent status means that there are candidates for scale down.
+	ClusterAutoscalerCandidatesPresent ClusterAutoscalerConditionStatus = "CandidatesPresent"
+	// ClusterAutoscalerNoCandidates status means that there are no candidates for scale down.
+	ClusterAutoscalerNoCandidates ClusterAutoscalerConditionStatus = "NoCandidates"
+	// ClusterAutoscalerNeeded status means that scale up is needed.
+	ClusterAutoscalerNeeded ClusterAutoscalerConditionStatus = "Needed"
+	// ClusterAutoscalerNotNeeded status means that scale up is not needed.
+	ClusterAutoscalerNotNeeded ClusterAutoscalerConditionStatus = "NotNeeded"
+	// ClusterAutoscalerInProgress status means that scale up is in progress.
+	ClusterAutoscalerInProgress ClusterAutoscalerConditionStatus = "InProgress"
+	// ClusterAutoscalerNoActivity status means that there has been no scale up activity recently.
+	ClusterAutoscalerNoActivity ClusterAutoscalerConditionStatus = "NoActivity"
+	// ClusterAutoscalerBackoff status means that due to a recently failed scale-up no further scale-ups attempts will be made for some time.
+	ClusterAutoscalerBackoff ClusterAutoscalerConditionStatus = "Backoff"
+	// ClusterAutoscalerOptionsValid status means that the autoscaling options are valid and in use.
+	ClusterAutoscalerOptionsValid ClusterAutoscalerConditionStatus = "Valid"
+	// ClusterAutoscalerOptionsInvalid status means that the last version of the autoscaling options was rejected.
+	ClusterAutoscalerOptionsInvalid ClusterAutoscalerConditionStatus = "Invalid"
+)
+
+// ClusterAutoscalerCondition describes some aspect of Cluster Autoscaler work.
+type ClusterAutoscalerCondition struct {
+	// Type defines the aspect that the condition describes. For example, it can be Health or ScaleUp/Down activity.
+	Type ClusterAutoscalerConditionType `json:"type,omitempty" protobuf:"bytes,1,opt,name=type"`
+	// Status of the condition.
+	Status ClusterAutoscalerConditionStatus `json:"status,omitempty" protobuf:"bytes,2,opt,name=status"`
+	// Message is a free text extra information about the condition. It may contain some
+	// extra debugging data, like why the cluster is unhealthy.
+	// +optional
+	Message string `json:"message,omitempty" protobuf:"bytes,3,opt,name=message"`
+	// Reason is a unique, one-word, CamelCase reason for the condition's last transition.
+	// +optional
+	Reason string `json:"reason,omitempty" protobuf:"bytes,4,opt,name=reason"`
+	// LastProbeTime is the last time the condition was probed.
+	// +optional
+	LastProbeTime metav1.Time `json:"lastProbeTime,omitempty" protobuf:"bytes,5,opt,name=lastProbeTime"`
+	// LastTransitionTime is the time since when the condition was in the given state.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty" protobuf:"bytes,6,opt,name=lastTransitionTime"`
+}
+
+// NodeGroupStatus contains status of a group of nodes controlled by Cluster Autoscaler.
+type NodeGroupStatus struct {
+	// ProviderID is the cloud-provider-specific name of the node group. On GCE it will be equal
+	// to MIG url, on AWS it will be ASG name, etc.
+	ProviderID string `json:"providerID,omitempty" protobuf:"bytes,1,opt,name=providerID"`
+	// Conditions is a list of conditions that describe the state of the node group.
+	// +optional
+	Conditions []ClusterAutoscalerCondition `json:"conditions,omitempty" protobuf:"bytes,2,rep,name=conditions"`
+	// UnremovableNodes is a list of nodes of the node group that can't be removed by Cluster Autoscaler.
+	// +optional
+	UnremovableNodes []UnremovableNode `json:"unremovableNodes,omitempty" protobuf:"bytes,3,rep,name=unremovableNodes"`
+	// Backoff describes why and until when scale-up of the node group is backed off. It's not set
+	// if the node group isn't backed off.
+	// +optional
+	Backoff *BackoffStatus `json:"backoff,omitempty" protobuf:"bytes,4,opt,name=backoff"`
+	// RecentScaleEvents is a list of the most recent scale events of the node group, the oldest first.
+	// +optional
+	RecentScaleEvents []ScaleEvent `json:"recentScaleEvents,omitempty" protobuf:"bytes,5,rep,name=recentScaleEvents"`
+}
+
+// UnremovableNode describes why a node can't be removed by Cluster Autoscaler.
+type UnremovableNode struct {
+	// Name of the node.
+	Name string `json:"name,omitempty" protobuf:"bytes,1,opt,name=name"`
+	// Reason is a unique, one-word, CamelCase reason why the node can't be removed, for example
+	// NotUnderutilized or BlockedByPod.
+	Reason string `json:"reason,omitempty" protobuf:"bytes,2,opt,name=reason"`
+	// BlockingPod is the namespace/name of the pod preventing the removal of the node, if there is one.
+	// +optional
+	BlockingPod string `json:"blockingPod,omitempty" protobuf:"bytes,3,opt,name=blockingPod"`
+	// BlockingPodReason is a unique, one-word, CamelCase reason why the blocking pod can't be moved,
+	// for example NotEnoughPdb or LocalStorageRequested.
+	// +optional
+	BlockingPodReason string `json:"blockingPodReason,omitempty" protobuf:"bytes,4,opt,name=blockingPodReason"`
+}
+
+// BackoffStatus describes the scale-up backoff of a node group.
+type BackoffStatus struct {
+	// BackoffUntil is the time until which no scale-up of the node group will be attempted.
+	BackoffUntil metav1.Time `json:"backoffUntil,omitempty" protobuf:"bytes,1,opt,name=backoffUntil"`
+	// ErrorClass is the class of the error which caused the backoff, OutOfResources or Other.
+	ErrorClass string `json:"errorClass,omitempty" protobuf:"bytes,2,opt,name=errorClass"`
+	// ErrorCode is the cloud-provider-specific code of the error which caused the backoff.
+	// +optional
+	ErrorCode string `json:"errorCode,omitempty" protobuf:"bytes,3,opt,name=errorCode"`
+}
+
+// ScaleEventType is the type of ScaleEvent.
+type ScaleEventType string
+
+const (
+	// ScaleUpEvent is an event of a node group scale-up requested by Cluster Autoscaler.
+	ScaleUpEvent ScaleEventType = "ScaleUp"
+	// FailedScaleUpEvent is an event of a failed node group scale-up.
+	FailedScaleUpEvent ScaleEventType = "FailedScaleUp"
+	// ScaleDownEvent is an event of a node deletion requested by Cluster Autoscaler.
+	ScaleDownEvent ScaleEventType = "ScaleDown"
+)
+
+// ScaleEvent describes a scale-up or scale-down of a node group.
+type ScaleEvent struct {
+	// Type of the event.
+	Type ScaleEventType `json:"type,omitempty" protobuf:"bytes,1,opt,name=type"`
+	// Time is the time when the event happened.
+	Time metav1.Time `json:"time,omitempty" protobuf:"bytes,2,opt,name=time"`
+	// Delta is the number of nodes added by a scale-up.
+	// +optional
+	Delta int32 `json:"delta,omitempty" protobuf:"varint,3,opt,name=delta"`
+	// NodeName is the name of the node deleted by a scale-down.
+	// +optional
+	NodeName string `json:"nodeName,omitempty" protobuf:"bytes,4,opt,name=nodeName"`
+	// Reason of a failed scale-up, for example timeout or cloudProviderError.
+	// +optional
+	Reason string `json:"reason,omitempty" protobuf:"bytes,5,opt,name=reason"`
+}
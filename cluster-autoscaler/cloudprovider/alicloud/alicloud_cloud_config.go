@@ -26,6 +26,7 @@ const (
 	accessKeyId    = "ACCESS_KEY_ID"
 	accessKeyScret = "ACCESS_KEY_SECRET"
 	regionId       = "REGION_ID"
+	pricingFile    = "PRICING_FILE"
 )
 
 type cloudConfig struct {
@@ -33,6 +34,7 @@ type cloudConfig struct {
 	AccessKeyID     string
 	AccessKeySecret string
 	STSEnabled      bool
+	PricingFile     string
 }
 
 func (cc *cloudConfig) isValid() bool {
@@ -97,3 +99,10 @@ func (cc *cloudConfig) getRegion() string {
 	}
 	return r
 }
+
+func (cc *cloudConfig) getPricingFile() string {
+	if cc.PricingFile != "" {
+		return cc.PricingFile
+	}
+	return os.Getenv(pricingFile)
+}
@@ -28,6 +28,7 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/config/dynamic"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pricing"
 	"k8s.io/klog"
 )
 
@@ -51,6 +52,7 @@ var (
 type aliCloudProvider struct {
 	manager         *AliCloudManager
 	asgs            []*Asg
+	pricingModel    *AliCloudPriceModel
 	resourceLimiter *cloudprovider.ResourceLimiter
 }
 
@@ -67,9 +69,14 @@ func BuildAliCloudProvider(manager *AliCloudManager, discoveryOpts cloudprovider
 }
 
 func buildStaticallyDiscoveringProvider(manager *AliCloudManager, specs []string, resourceLimiter *cloudprovider.ResourceLimiter) (*aliCloudProvider, error) {
+	pricingModel, err := buildPricingModel(manager.cfg)
+	if err != nil {
+		return nil, err
+	}
 	acp := &aliCloudProvider{
 		manager:         manager,
 		asgs:            make([]*Asg, 0),
+		pricingModel:    pricingModel,
 		resourceLimiter: resourceLimiter,
 	}
 	for _, spec := range specs {
@@ -81,6 +88,23 @@ func buildStaticallyDiscoveringProvider(manager *AliCloudManager, specs []string
 	return acp, nil
 }
 
+// buildPricingModel builds the price model from the pricing file of cfg. There are no bundled
+// AliCloud prices, so it returns nil if the pricing file isn't set.
+func buildPricingModel(cfg *cloudConfig) (*AliCloudPriceModel, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	path := cfg.getPricingFile()
+	if path == "" {
+		return nil, nil
+	}
+	staticPrices, err := pricing.ReadStaticPricesFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewAliCloudPriceModel(cfg.RegionId, staticPrices), nil
+}
+
 // add node group defined in string spec. Format:
 // minNodes:maxNodes:asgName
 func (ali *aliCloudProvider) addNodeGroup(spec string) error {
@@ -133,7 +157,11 @@ func (ali *aliCloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovider.N
 
 // Pricing returns pricing model for this cloud provider or error if not available.
 func (ali *aliCloudProvider) Pricing() (cloudprovider.PricingModel, errors.AutoscalerError) {
-	return nil, cloudprovider.ErrNotImplemented
+	// There are no bundled AliCloud prices, pricing requires a pricing file.
+	if ali.pricingModel == nil {
+		return nil, cloudprovider.ErrNotImplemented
+	}
+	return ali.pricingModel, nil
 }
 
 // GetAvailableMachineTypes get all machine types that can be requested from the cloud provider.
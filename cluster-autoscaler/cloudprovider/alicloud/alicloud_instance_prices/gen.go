@@ -0,0 +1,137 @@
+// +build ignore
+
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"strings"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/alicloud/alibaba-cloud-sdk-go/sdk/requests"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/alicloud/alibaba-cloud-sdk-go/services/ecs"
+	"k8s.io/klog"
+)
+
+var (
+	regions = flag.String("regions", "cn-hangzhou,cn-beijing,cn-shanghai,cn-shenzhen,ap-southeast-1,us-west-1,eu-central-1",
+		"Comma separated list of regions to fetch prices for.")
+	families = flag.String("families", "ecs.c5.,ecs.g5.,ecs.r5.,ecs.c6.,ecs.g6.,ecs.r6.,ecs.gn5-,ecs.gn6i-,ecs.gn6v-",
+		"Comma separated list of instance type prefixes to fetch prices for.")
+	output = flag.String("output", "alicloud-prices.json", "Path of the pricing file to write.")
+)
+
+type describePriceResponse struct {
+	PriceInfo struct {
+		Price struct {
+			OriginalPrice float64 `json:"OriginalPrice"`
+			Currency      string  `json:"Currency"`
+		} `json:"Price"`
+	} `json:"PriceInfo"`
+}
+
+// main fetches prices using the ecs DescribePrice API, which requires credentials
+// passed in ACCESS_KEY_ID and ACCESS_KEY_SECRET environment variables.
+func main() {
+	flag.Parse()
+	defer klog.Flush()
+
+	instancePrices := make(map[string]map[string]float64)
+
+	for _, region := range strings.Split(*regions, ",") {
+		client, err := ecs.NewClientWithAccessKey(region, os.Getenv("ACCESS_KEY_ID"), os.Getenv("ACCESS_KEY_SECRET"))
+		if err != nil {
+			klog.Fatal(err)
+		}
+
+		types, err := client.DescribeInstanceTypes(ecs.CreateDescribeInstanceTypesRequest())
+		if err != nil {
+			klog.Warningf("Error listing instance types in %s skipping...\n", region)
+			continue
+		}
+
+		for _, item := range types.InstanceTypes.InstanceType {
+			if !hasPrefix(item.InstanceTypeId, strings.Split(*families, ",")) {
+				continue
+			}
+			price, found := describePrice(client, region, item.InstanceTypeId)
+			if !found {
+				continue
+			}
+			if instancePrices[region] == nil {
+				instancePrices[region] = make(map[string]float64)
+			}
+			instancePrices[region][item.InstanceTypeId] = price
+		}
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		klog.Fatal(err)
+	}
+
+	defer f.Close()
+
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "    ")
+	if err := encoder.Encode(instancePrices); err != nil {
+		klog.Fatal(err)
+	}
+}
+
+// describePrice returns the hourly pay-as-you-go list price of an instance type in USD.
+func describePrice(client *ecs.Client, region, instanceType string) (float64, bool) {
+	request := requests.NewCommonRequest()
+	request.Method = "POST"
+	request.Domain = "ecs.aliyuncs.com"
+	request.Product = "Ecs"
+	request.Version = "2014-05-26"
+	request.ApiName = "DescribePrice"
+	request.QueryParams["RegionId"] = region
+	request.QueryParams["ResourceType"] = "instance"
+	request.QueryParams["InstanceType"] = instanceType
+	request.QueryParams["PriceUnit"] = "Hour"
+
+	response, err := client.ProcessCommonRequest(request)
+	if err != nil {
+		klog.V(1).Infof("Error fetching price of %s in %s skipping...\n", instanceType, region)
+		return 0, false
+	}
+
+	var unmarshalled = describePriceResponse{}
+	if err := json.Unmarshal(response.GetHttpContentBytes(), &unmarshalled); err != nil {
+		klog.Warningf("Error unmarshalling price of %s in %s skipping...\n", instanceType, region)
+		return 0, false
+	}
+	price := unmarshalled.PriceInfo.Price
+	if price.Currency != "USD" || price.OriginalPrice <= 0 {
+		klog.Warningf("Unexpected price %v %s of %s in %s skipping...\n", price.OriginalPrice, price.Currency, instanceType, region)
+		return 0, false
+	}
+	return price.OriginalPrice, true
+}
+
+func hasPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alicloud
+
+import (
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pricing"
+)
+
+// AliCloudPriceModel implements PriceModel interface for AliCloud.
+type AliCloudPriceModel struct {
+	*pricing.InstancePriceModel
+}
+
+var (
+	// resourcePrices are used for pods and for nodes of instance types missing from the pricing file.
+	// They are rough estimates rather than AliCloud list prices; only their ratios matter when
+	// node groups are compared, so list all instance types in use in the pricing file.
+	resourcePrices = pricing.ResourcePrices{
+		CpuPerHour:         0.032,
+		MemoryPerGbPerHour: 0.00525,
+		GpuPerHour:         1.314,
+	}
+)
+
+// NewAliCloudPriceModel builds a price model using the pay-as-you-go instance prices keyed by region
+// and instance type read from the pricing file. There are no bundled prices. Nodes in regions without
+// a price of their instance type use the price from defaultRegion, if set.
+func NewAliCloudPriceModel(defaultRegion string, staticPrices pricing.InstancePrices) *AliCloudPriceModel {
+	return &AliCloudPriceModel{
+		InstancePriceModel: pricing.NewInstancePriceModel(resourcePrices, []string{defaultRegion}, staticPrices),
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alicloud
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pricing"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/units"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func buildPricedNode(name, instanceType, region string, millicpu int64, mem int64) *apiv1.Node {
+	node := BuildTestNode(name, millicpu, mem)
+	node.Labels = map[string]string{
+		apiv1.LabelInstanceType: instanceType,
+		apiv1.LabelZoneRegion:   region,
+	}
+	return node
+}
+
+func TestGetNodePrice(t *testing.T) {
+	model := NewAliCloudPriceModel("cn-beijing", pricing.InstancePrices{
+		"cn-hangzhou": {"ecs.g5.2xlarge": 0.5},
+		"cn-beijing":  {"ecs.g5.2xlarge": 0.428, "ecs.custom.large": 0.1},
+	})
+	now := time.Now()
+
+	for _, tc := range []struct {
+		instanceType string
+		region       string
+		expected     float64
+	}{
+		{"ecs.g5.2xlarge", "cn-hangzhou", 0.5},
+		{"ecs.g5.2xlarge", "cn-beijing", 0.428},
+		// nodes without a region label or in regions without the price use the default region
+		{"ecs.g5.2xlarge", "", 0.428},
+		{"ecs.custom.large", "cn-hangzhou", 0.1},
+		// instance types missing from the pricing file are priced per resource
+		{"ecs.c5.large", "cn-beijing", 8*resourcePrices.CpuPerHour + 32*resourcePrices.MemoryPerGbPerHour},
+	} {
+		node := buildPricedNode("node", tc.instanceType, tc.region, 8000, 32*units.GiB)
+		price, err := model.NodePrice(node, now, now.Add(time.Hour))
+		assert.NoError(t, err)
+		assert.InDelta(t, tc.expected, price, 0.0001, "%s in %q", tc.instanceType, tc.region)
+	}
+
+	// gpus are priced per resource too
+	node := buildPricedNode("node", "ecs.gn6v-c8g1.2xlarge", "cn-beijing", 8000, 32*units.GiB)
+	node.Status.Capacity[ResourceGPU] = *resource.NewQuantity(1, resource.DecimalSI)
+	price, err := model.NodePrice(node, now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.InDelta(t, 8*resourcePrices.CpuPerHour+32*resourcePrices.MemoryPerGbPerHour+resourcePrices.GpuPerHour, price, 0.0001)
+}
+
+func TestBuildPricingModel(t *testing.T) {
+	file, err := ioutil.TempFile("", "alicloud-prices")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(`{"cn-beijing": {"ecs.g5.large": 0.12}}`)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	model, err := buildPricingModel(&cloudConfig{RegionId: "cn-beijing", PricingFile: file.Name()})
+	assert.NoError(t, err)
+	price, found := model.InstancePrice("cn-hangzhou", "ecs.g5.large")
+	assert.True(t, found)
+	assert.Equal(t, 0.12, price)
+
+	// there are no bundled prices
+	model, err = buildPricingModel(&cloudConfig{RegionId: "cn-beijing"})
+	assert.NoError(t, err)
+	assert.Nil(t, model)
+
+	_, err = buildPricingModel(&cloudConfig{PricingFile: file.Name() + "-missing"})
+	assert.Error(t, err)
+}
+
+func TestGetPodPrice(t *testing.T) {
+	pod1 := BuildTestPod("a1", 100, 500*units.MiB)
+	pod2 := BuildTestPod("a2", 2*100, 2*500*units.MiB)
+
+	model := NewAliCloudPriceModel("", nil)
+	now := time.Now()
+
+	price1, err := model.PodPrice(pod1, now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	price2, err := model.PodPrice(pod2, now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	// 2 times bigger pod should cost twice as much.
+	assert.True(t, math.Abs(price1*2-price2) < 0.001)
+}
@@ -18,16 +18,48 @@ package aws
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/klog"
 )
 
+const (
+	// ErrorCodeInsufficientCapacity is error code used in InstanceErrorInfo if AWS has no capacity
+	// for the requested instance type (or spot market) in the availability zone.
+	ErrorCodeInsufficientCapacity = "InsufficientInstanceCapacity"
+
+	// ErrorCodeQuotaExceeded is error code used in InstanceErrorInfo if an account limit is exceeded.
+	ErrorCodeQuotaExceeded = "QuotaExceeded"
+
+	// ErrorCodeScalingActivityFailed is error code used in InstanceErrorInfo for other scaling activity failures.
+	ErrorCodeScalingActivityFailed = "ScalingActivityFailed"
+)
+
+var (
+	insufficientCapacityMessages = []string{
+		"insufficientinstancecapacity",
+		"do not have sufficient",
+		"no spot capacity available",
+	}
+	quotaExceededMessages = []string{
+		"vcpulimitexceeded",
+		"instancelimitexceeded",
+		"maxspotinstancecountexceeded",
+		"than your current instance limit",
+		"than your current vcpu limit",
+	}
+)
+
 // autoScaling is the interface represents a specific aspect of the auto-scaling service provided by AWS SDK for use in CA
 type autoScaling interface {
+	CreateAutoScalingGroup(input *autoscaling.CreateAutoScalingGroupInput) (*autoscaling.CreateAutoScalingGroupOutput, error)
+	DeleteAutoScalingGroup(input *autoscaling.DeleteAutoScalingGroupInput) (*autoscaling.DeleteAutoScalingGroupOutput, error)
 	DescribeAutoScalingGroupsPages(input *autoscaling.DescribeAutoScalingGroupsInput, fn func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool) error
 	DescribeLaunchConfigurations(*autoscaling.DescribeLaunchConfigurationsInput) (*autoscaling.DescribeLaunchConfigurationsOutput, error)
+	DescribeScalingActivities(*autoscaling.DescribeScalingActivitiesInput) (*autoscaling.DescribeScalingActivitiesOutput, error)
 	DescribeTagsPages(input *autoscaling.DescribeTagsInput, fn func(*autoscaling.DescribeTagsOutput, bool) bool) error
 	SetDesiredCapacity(input *autoscaling.SetDesiredCapacityInput) (*autoscaling.SetDesiredCapacityOutput, error)
 	TerminateInstanceInAutoScalingGroup(input *autoscaling.TerminateInstanceInAutoScalingGroupInput) (*autoscaling.TerminateInstanceInAutoScalingGroupOutput, error)
@@ -62,6 +94,109 @@ func (m autoScalingWrapper) getInstanceTypeByLCName(name string) (string, error)
 	return instanceType, nil
 }
 
+// getScalingActivityErrorInfo returns information about the failure of the most recent scaling
+// activity of the ASG, or nil if it didn't fail.
+func (m *autoScalingWrapper) getScalingActivityErrorInfo(asgName string) (*cloudprovider.InstanceErrorInfo, error) {
+	params := &autoscaling.DescribeScalingActivitiesInput{
+		AutoScalingGroupName: aws.String(asgName),
+		MaxRecords:           aws.Int64(1),
+	}
+	output, err := m.DescribeScalingActivities(params)
+	if err != nil {
+		klog.V(4).Infof("Failed scaling activities request for %s: %v", asgName, err)
+		return nil, err
+	}
+	if len(output.Activities) < 1 {
+		return nil, nil
+	}
+
+	activity := output.Activities[0]
+	switch aws.StringValue(activity.StatusCode) {
+	case autoscaling.ScalingActivityStatusCodeFailed, autoscaling.ScalingActivityStatusCodeCancelled:
+		return buildInstanceErrorInfo(aws.StringValue(activity.StatusMessage)), nil
+	}
+	return nil, nil
+}
+
+// buildInstanceErrorInfo classifies a scaling activity failure by its status message, as AWS
+// doesn't report error codes of failed activities in a separate field.
+func buildInstanceErrorInfo(message string) *cloudprovider.InstanceErrorInfo {
+	errorInfo := &cloudprovider.InstanceErrorInfo{
+		ErrorClass:   cloudprovider.OtherErrorClass,
+		ErrorCode:    ErrorCodeScalingActivityFailed,
+		ErrorMessage: message,
+	}
+	lowerMessage := strings.ToLower(message)
+	if containsAny(lowerMessage, insufficientCapacityMessages) {
+		errorInfo.ErrorClass = cloudprovider.OutOfResourcesErrorClass
+		errorInfo.ErrorCode = ErrorCodeInsufficientCapacity
+	} else if containsAny(lowerMessage, quotaExceededMessages) {
+		errorInfo.ErrorClass = cloudprovider.OutOfResourcesErrorClass
+		errorInfo.ErrorCode = ErrorCodeQuotaExceeded
+	}
+	return errorInfo
+}
+
+func containsAny(s string, substrings []string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(s, substring) {
+			return true
+		}
+	}
+	return false
+}
+
+// createAutoscalingGroup creates an ASG running the instance type override of the given asg in
+// the given subnets, using its launch template and tags.
+func (m *autoScalingWrapper) createAutoscalingGroup(asg *asg, subnets []string) error {
+	overrides := make([]*autoscaling.LaunchTemplateOverrides, 0, len(asg.InstanceTypeOverrides))
+	for _, instanceType := range asg.InstanceTypeOverrides {
+		overrides = append(overrides, &autoscaling.LaunchTemplateOverrides{InstanceType: aws.String(instanceType)})
+	}
+	tags := make([]*autoscaling.Tag, 0, len(asg.Tags))
+	for _, tag := range asg.Tags {
+		tags = append(tags, &autoscaling.Tag{
+			Key:               tag.Key,
+			Value:             tag.Value,
+			PropagateAtLaunch: tag.PropagateAtLaunch,
+			ResourceId:        aws.String(asg.Name),
+			ResourceType:      tag.ResourceType,
+		})
+	}
+
+	params := &autoscaling.CreateAutoScalingGroupInput{
+		AutoScalingGroupName: aws.String(asg.Name),
+		MinSize:              aws.Int64(int64(asg.minSize)),
+		MaxSize:              aws.Int64(int64(asg.maxSize)),
+		DesiredCapacity:      aws.Int64(int64(asg.curSize)),
+		VPCZoneIdentifier:    aws.String(strings.Join(subnets, ",")),
+		MixedInstancesPolicy: &autoscaling.MixedInstancesPolicy{
+			LaunchTemplate: &autoscaling.LaunchTemplate{
+				LaunchTemplateSpecification: &autoscaling.LaunchTemplateSpecification{
+					LaunchTemplateName: aws.String(asg.LaunchTemplateName),
+					Version:            aws.String(asg.LaunchTemplateVersion),
+				},
+				Overrides: overrides,
+			},
+		},
+		Tags: tags,
+	}
+	klog.V(0).Infof("Creating asg %s", asg.Name)
+	_, err := m.CreateAutoScalingGroup(params)
+	return err
+}
+
+// deleteAutoscalingGroup deletes an ASG. It fails if the ASG has any instances.
+func (m *autoScalingWrapper) deleteAutoscalingGroup(name string) error {
+	params := &autoscaling.DeleteAutoScalingGroupInput{
+		AutoScalingGroupName: aws.String(name),
+		ForceDelete:          aws.Bool(false),
+	}
+	klog.V(0).Infof("Deleting asg %s", name)
+	_, err := m.DeleteAutoScalingGroup(params)
+	return err
+}
+
 func (m *autoScalingWrapper) getAutoscalingGroupsByNames(names []string) ([]*autoscaling.Group, error) {
 	if len(names) == 0 {
 		return nil, nil
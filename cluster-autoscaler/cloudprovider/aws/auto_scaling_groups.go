@@ -19,6 +19,7 @@ package aws
 import (
 	"fmt"
 	"reflect"
+	"regexp"
 	"strings"
 	"sync"
 
@@ -30,12 +31,21 @@ import (
 	"k8s.io/klog"
 )
 
-const scaleToZeroSupported = true
+const (
+	scaleToZeroSupported = true
+
+	// placeholderInstanceNamePrefix prefixes names of fake instances standing for instances
+	// which an ASG failed to launch.
+	placeholderInstanceNamePrefix = "i-placeholder-"
+)
+
+var invalidPlaceholderNameChars = regexp.MustCompile(`[^-0-9a-z]`)
 
 type asgCache struct {
 	registeredAsgs []*asg
 	asgToInstances map[AwsRef][]AwsInstanceRef
 	instanceToAsg  map[AwsInstanceRef]*asg
+	instanceStatus map[AwsInstanceRef]*cloudprovider.InstanceStatus
 	mutex          sync.Mutex
 	service        autoScalingWrapper
 	interrupt      chan struct{}
@@ -55,7 +65,9 @@ type asg struct {
 	LaunchTemplateName      string
 	LaunchTemplateVersion   string
 	LaunchConfigurationName string
-	Tags                    []*autoscaling.TagDescription
+	// InstanceTypeOverrides lists instance types of the MixedInstancesPolicy of the ASG, if any.
+	InstanceTypeOverrides []string
+	Tags                  []*autoscaling.TagDescription
 }
 
 func newASGCache(service autoScalingWrapper, explicitSpecs []string, autoDiscoverySpecs []cloudprovider.ASGAutoDiscoveryConfig) (*asgCache, error) {
@@ -64,6 +76,7 @@ func newASGCache(service autoScalingWrapper, explicitSpecs []string, autoDiscove
 		service:               service,
 		asgToInstances:        make(map[AwsRef][]AwsInstanceRef),
 		instanceToAsg:         make(map[AwsInstanceRef]*asg),
+		instanceStatus:        make(map[AwsInstanceRef]*cloudprovider.InstanceStatus),
 		interrupt:             make(chan struct{}),
 		asgAutoDiscoverySpecs: autoDiscoverySpecs,
 		explicitlyConfigured:  make(map[AwsRef]bool),
@@ -116,6 +129,7 @@ func (m *asgCache) register(asg *asg) *asg {
 			existing.LaunchConfigurationName = asg.LaunchConfigurationName
 			existing.LaunchTemplateName = asg.LaunchTemplateName
 			existing.LaunchTemplateVersion = asg.LaunchTemplateVersion
+			existing.InstanceTypeOverrides = asg.InstanceTypeOverrides
 			existing.Tags = asg.Tags
 
 			return existing
@@ -142,6 +156,44 @@ func (m *asgCache) unregister(a *asg) *asg {
 	return changed
 }
 
+// Register registers an ASG created by the autoscaler, without waiting for the next refresh.
+// Returns the registered ASG.
+func (m *asgCache) Register(asg *asg) *asg {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, found := m.asgToInstances[asg.AwsRef]; !found {
+		m.asgToInstances[asg.AwsRef] = []AwsInstanceRef{}
+	}
+	return m.register(asg)
+}
+
+// Unregister unregisters an ASG deleted by the autoscaler, without waiting for the next refresh.
+func (m *asgCache) Unregister(asg *asg) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, instance := range m.asgToInstances[asg.AwsRef] {
+		delete(m.instanceToAsg, instance)
+		delete(m.instanceStatus, instance)
+	}
+	delete(m.asgToInstances, asg.AwsRef)
+	m.unregister(asg)
+}
+
+// IsRegistered returns true if the ASG is registered.
+func (m *asgCache) IsRegistered(ref AwsRef) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, asg := range m.registeredAsgs {
+		if asg.AwsRef == ref {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *asgCache) buildAsgFromSpec(spec string) (*asg, error) {
 	s, err := dynamic.SpecFromString(spec, scaleToZeroSupported)
 	if err != nil {
@@ -191,6 +243,14 @@ func (m *asgCache) InstancesByAsg(ref AwsRef) ([]AwsInstanceRef, error) {
 	return nil, fmt.Errorf("error while looking for instances of ASG: %s", ref)
 }
 
+// InstanceStatus returns the status of an instance, or nil if it's unknown.
+func (m *asgCache) InstanceStatus(ref AwsInstanceRef) *cloudprovider.InstanceStatus {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.instanceStatus[ref]
+}
+
 func (m *asgCache) SetAsgSize(asg *asg, size int) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
@@ -238,7 +298,13 @@ func (m *asgCache) DeleteInstances(instances []*AwsInstanceRef) error {
 		}
 	}
 
+	placeholders := 0
 	for _, instance := range instances {
+		if isPlaceholderInstance(*instance) {
+			placeholders++
+			continue
+		}
+
 		params := &autoscaling.TerminateInstanceInAutoScalingGroupInput{
 			InstanceId:                     aws.String(instance.Name),
 			ShouldDecrementDesiredCapacity: aws.Bool(true),
@@ -254,6 +320,21 @@ func (m *asgCache) DeleteInstances(instances []*AwsInstanceRef) error {
 		klog.V(4).Infof(*resp.Activity.Description)
 	}
 
+	// Placeholders don't exist in AWS, they are removed by lowering the desired capacity
+	if placeholders > 0 {
+		size := commonAsg.curSize - placeholders
+		params := &autoscaling.SetDesiredCapacityInput{
+			AutoScalingGroupName: aws.String(commonAsg.Name),
+			DesiredCapacity:      aws.Int64(int64(size)),
+			HonorCooldown:        aws.Bool(false),
+		}
+		klog.V(0).Infof("Setting asg %s size to %d to remove %d instances that failed to launch", commonAsg.Name, size, placeholders)
+		if _, err := m.service.SetDesiredCapacity(params); err != nil {
+			return err
+		}
+		commonAsg.curSize = size
+	}
+
 	return nil
 }
 
@@ -309,6 +390,7 @@ func (m *asgCache) regenerate() error {
 
 	newInstanceToAsgCache := make(map[AwsInstanceRef]*asg)
 	newAsgToInstancesCache := make(map[AwsRef][]AwsInstanceRef)
+	newInstanceStatusCache := make(map[AwsInstanceRef]*cloudprovider.InstanceStatus)
 
 	// Build list of knowns ASG names
 	refreshNames, err := m.buildAsgNames()
@@ -340,6 +422,25 @@ func (m *asgCache) regenerate() error {
 			ref := m.buildInstanceRefFromAWS(instance)
 			newInstanceToAsgCache[ref] = asg
 			newAsgToInstancesCache[asg.AwsRef][i] = ref
+			newInstanceStatusCache[ref] = buildInstanceStatus(instance)
+		}
+
+		// Instances which the ASG failed to launch are represented by placeholders, so that
+		// the failure is reported to ClusterStateRegistry and the scale-up can be backed off.
+		if missing := asg.curSize - len(group.Instances); missing > 0 {
+			errorInfo, err := m.service.getScalingActivityErrorInfo(asg.Name)
+			if err != nil {
+				klog.Warningf("Failed to check scaling activities of ASG %s: %v", asg.Name, err)
+			} else if errorInfo != nil {
+				for _, ref := range buildPlaceholderInstanceRefs(asg, missing) {
+					newInstanceToAsgCache[ref] = asg
+					newAsgToInstancesCache[asg.AwsRef] = append(newAsgToInstancesCache[asg.AwsRef], ref)
+					newInstanceStatusCache[ref] = &cloudprovider.InstanceStatus{
+						State:     cloudprovider.InstanceCreating,
+						ErrorInfo: errorInfo,
+					}
+				}
+			}
 		}
 	}
 
@@ -352,9 +453,54 @@ func (m *asgCache) regenerate() error {
 
 	m.asgToInstances = newAsgToInstancesCache
 	m.instanceToAsg = newInstanceToAsgCache
+	m.instanceStatus = newInstanceStatusCache
 	return nil
 }
 
+// buildInstanceStatus maps the lifecycle state of an ASG instance to its status.
+func buildInstanceStatus(instance *autoscaling.Instance) *cloudprovider.InstanceStatus {
+	status := &cloudprovider.InstanceStatus{}
+	switch aws.StringValue(instance.LifecycleState) {
+	case autoscaling.LifecycleStatePending,
+		autoscaling.LifecycleStatePendingWait,
+		autoscaling.LifecycleStatePendingProceed:
+		status.State = cloudprovider.InstanceCreating
+	case autoscaling.LifecycleStateTerminating,
+		autoscaling.LifecycleStateTerminatingWait,
+		autoscaling.LifecycleStateTerminatingProceed,
+		autoscaling.LifecycleStateTerminated,
+		autoscaling.LifecycleStateDetaching,
+		autoscaling.LifecycleStateDetached:
+		status.State = cloudprovider.InstanceDeleting
+	default:
+		status.State = cloudprovider.InstanceRunning
+	}
+	return status
+}
+
+// buildPlaceholderInstanceRefs builds references to count placeholder instances of the ASG.
+// Their names are stable, so that failures are not reported again on every refresh.
+func buildPlaceholderInstanceRefs(asg *asg, count int) []AwsInstanceRef {
+	zone := ""
+	if len(asg.AvailabilityZones) > 0 {
+		zone = asg.AvailabilityZones[0]
+	}
+	asgName := invalidPlaceholderNameChars.ReplaceAllString(strings.ToLower(asg.Name), "-")
+	refs := make([]AwsInstanceRef, count)
+	for i := range refs {
+		name := fmt.Sprintf("%s%s-%d", placeholderInstanceNamePrefix, asgName, i)
+		refs[i] = AwsInstanceRef{
+			ProviderID: fmt.Sprintf("aws:///%s/%s", zone, name),
+			Name:       name,
+		}
+	}
+	return refs
+}
+
+func isPlaceholderInstance(ref AwsInstanceRef) bool {
+	return strings.HasPrefix(ref.Name, placeholderInstanceNamePrefix)
+}
+
 func (m *asgCache) buildAsgFromAWS(g *autoscaling.Group) (*asg, error) {
 	spec := dynamic.NodeGroupSpec{
 		Name:               aws.StringValue(g.AutoScalingGroupName),
@@ -379,12 +525,26 @@ func (m *asgCache) buildAsgFromAWS(g *autoscaling.Group) (*asg, error) {
 		LaunchConfigurationName: aws.StringValue(g.LaunchConfigurationName),
 		LaunchTemplateName:      launchTemplateName,
 		LaunchTemplateVersion:   launchTemplateVersion,
+		InstanceTypeOverrides:   m.buildInstanceTypeOverrides(g),
 		Tags:                    g.Tags,
 	}
 
 	return asg, nil
 }
 
+func (m *asgCache) buildInstanceTypeOverrides(g *autoscaling.Group) []string {
+	if g.MixedInstancesPolicy == nil || g.MixedInstancesPolicy.LaunchTemplate == nil {
+		return nil
+	}
+	var instanceTypes []string
+	for _, override := range g.MixedInstancesPolicy.LaunchTemplate.Overrides {
+		if override != nil && aws.StringValue(override.InstanceType) != "" {
+			instanceTypes = append(instanceTypes, aws.StringValue(override.InstanceType))
+		}
+	}
+	return instanceTypes
+}
+
 func (m *asgCache) buildLaunchTemplateParams(g *autoscaling.Group) (string, string) {
 	if g.LaunchTemplate != nil {
 		return aws.StringValue(g.LaunchTemplate.LaunchTemplateName), aws.StringValue(g.LaunchTemplate.Version)
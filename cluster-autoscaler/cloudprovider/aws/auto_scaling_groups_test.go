@@ -19,7 +19,10 @@ package aws
 import (
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/stretchr/testify/assert"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 )
 
 func TestBuildAsg(t *testing.T) {
@@ -41,8 +44,75 @@ func TestBuildAsg(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestBuildAsgFromAWSWithMixedInstancesPolicy(t *testing.T) {
+	asgCache := &asgCache{}
+
+	asg, err := asgCache.buildAsgFromAWS(&autoscaling.Group{
+		AutoScalingGroupName: aws.String("mixed-asg"),
+		MinSize:              aws.Int64(0),
+		MaxSize:              aws.Int64(10),
+		DesiredCapacity:      aws.Int64(0),
+		AvailabilityZones:    aws.StringSlice([]string{"us-east-1a"}),
+		MixedInstancesPolicy: &autoscaling.MixedInstancesPolicy{
+			LaunchTemplate: &autoscaling.LaunchTemplate{
+				LaunchTemplateSpecification: &autoscaling.LaunchTemplateSpecification{
+					LaunchTemplateName: aws.String("memory-opt-2xlarge"),
+					Version:            aws.String("1"),
+				},
+				Overrides: []*autoscaling.LaunchTemplateOverrides{
+					{InstanceType: aws.String("r5.2xlarge")},
+					{InstanceType: aws.String("i3.2xlarge")},
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	validateAsg(t, asg, "mixed-asg", 0, 10)
+	assert.Equal(t, "memory-opt-2xlarge", asg.LaunchTemplateName)
+	assert.Equal(t, "1", asg.LaunchTemplateVersion)
+	assert.Equal(t, []string{"r5.2xlarge", "i3.2xlarge"}, asg.InstanceTypeOverrides)
+}
+
 func validateAsg(t *testing.T, asg *asg, name string, minSize int, maxSize int) {
 	assert.Equal(t, name, asg.Name)
 	assert.Equal(t, minSize, asg.minSize)
 	assert.Equal(t, maxSize, asg.maxSize)
 }
+
+func TestBuildInstanceStatus(t *testing.T) {
+	testCases := map[string]cloudprovider.InstanceState{
+		autoscaling.LifecycleStatePending:            cloudprovider.InstanceCreating,
+		autoscaling.LifecycleStatePendingWait:        cloudprovider.InstanceCreating,
+		autoscaling.LifecycleStateInService:          cloudprovider.InstanceRunning,
+		autoscaling.LifecycleStateStandby:            cloudprovider.InstanceRunning,
+		autoscaling.LifecycleStateTerminating:        cloudprovider.InstanceDeleting,
+		autoscaling.LifecycleStateTerminatingWait:    cloudprovider.InstanceDeleting,
+		autoscaling.LifecycleStateDetaching:          cloudprovider.InstanceDeleting,
+		autoscaling.LifecycleStateEnteringStandby:    cloudprovider.InstanceRunning,
+		autoscaling.LifecycleStateTerminatingProceed: cloudprovider.InstanceDeleting,
+	}
+
+	for lifecycleState, expectedState := range testCases {
+		status := buildInstanceStatus(&autoscaling.Instance{LifecycleState: aws.String(lifecycleState)})
+		assert.Equal(t, expectedState, status.State, lifecycleState)
+		assert.Nil(t, status.ErrorInfo)
+	}
+}
+
+func TestBuildPlaceholderInstanceRefs(t *testing.T) {
+	asg := &asg{
+		AwsRef:            AwsRef{Name: "My_ASG.1"},
+		AvailabilityZones: []string{"us-east-1b"},
+	}
+	refs := buildPlaceholderInstanceRefs(asg, 2)
+	assert.Equal(t, []AwsInstanceRef{
+		{ProviderID: "aws:///us-east-1b/i-placeholder-my-asg-1-0", Name: "i-placeholder-my-asg-1-0"},
+		{ProviderID: "aws:///us-east-1b/i-placeholder-my-asg-1-1", Name: "i-placeholder-my-asg-1-1"},
+	}, refs)
+	for _, ref := range refs {
+		assert.True(t, isPlaceholderInstance(ref))
+		parsed, err := AwsRefFromProviderId(ref.ProviderID)
+		assert.NoError(t, err)
+		assert.Equal(t, ref, *parsed)
+	}
+}
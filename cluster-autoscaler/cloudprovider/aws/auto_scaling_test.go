@@ -24,6 +24,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 )
 
 func TestMoreThen50Groups(t *testing.T) {
@@ -68,3 +69,44 @@ func TestMoreThen50Groups(t *testing.T) {
 	assert.Equal(t, *asgs[0].AutoScalingGroupName, "asg-1")
 	assert.Equal(t, *asgs[1].AutoScalingGroupName, "asg-2")
 }
+
+func TestBuildInstanceErrorInfo(t *testing.T) {
+	testCases := []struct {
+		message       string
+		expectedClass cloudprovider.InstanceErrorClass
+		expectedCode  string
+	}{
+		{
+			message:       "We currently do not have sufficient p3.2xlarge capacity in the Availability Zone you requested (us-east-1a).",
+			expectedClass: cloudprovider.OutOfResourcesErrorClass,
+			expectedCode:  ErrorCodeInsufficientCapacity,
+		},
+		{
+			message:       "Could not launch Spot Instances. InsufficientInstanceCapacity - There is no Spot capacity available that matches your request.",
+			expectedClass: cloudprovider.OutOfResourcesErrorClass,
+			expectedCode:  ErrorCodeInsufficientCapacity,
+		},
+		{
+			message:       "You have requested more vCPU capacity than your current vCPU limit of 32 allows for the instance bucket that the specified instance type belongs to.",
+			expectedClass: cloudprovider.OutOfResourcesErrorClass,
+			expectedCode:  ErrorCodeQuotaExceeded,
+		},
+		{
+			message:       "Could not launch Spot Instances. MaxSpotInstanceCountExceeded - Max spot instance count exceeded.",
+			expectedClass: cloudprovider.OutOfResourcesErrorClass,
+			expectedCode:  ErrorCodeQuotaExceeded,
+		},
+		{
+			message:       "The security group 'sg-12345' does not exist in VPC 'vpc-12345'.",
+			expectedClass: cloudprovider.OtherErrorClass,
+			expectedCode:  ErrorCodeScalingActivityFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		errorInfo := buildInstanceErrorInfo(tc.message)
+		assert.Equal(t, tc.expectedClass, errorInfo.ErrorClass, tc.message)
+		assert.Equal(t, tc.expectedCode, errorInfo.ErrorCode, tc.message)
+		assert.Equal(t, tc.message, errorInfo.ErrorMessage)
+	}
+}
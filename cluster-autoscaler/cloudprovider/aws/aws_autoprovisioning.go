@@ -0,0 +1,185 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+)
+
+const (
+	// autoprovisionedTag marks ASGs created by node autoprovisioning. Its value is the name of the
+	// cluster, so that the ASGs are rediscovered after a restart.
+	autoprovisionedTag = "k8s.io/cluster-autoscaler/autoprovisioned"
+
+	// autoprovisionedAsgMaxSize is the max size of autoprovisioned ASGs. Their growth is bounded
+	// by the resource limits of the cluster instead.
+	autoprovisionedAsgMaxSize = 1000
+
+	defaultLaunchTemplateVersion = "$Default"
+
+	labelTagPrefix     = "k8s.io/cluster-autoscaler/node-template/label/"
+	taintTagPrefix     = "k8s.io/cluster-autoscaler/node-template/taint/"
+	resourcesTagPrefix = "k8s.io/cluster-autoscaler/node-template/resources/"
+)
+
+// autoprovisioningConfig describes how ASGs are created by node autoprovisioning.
+type autoprovisioningConfig struct {
+	clusterName           string
+	launchTemplateName    string
+	launchTemplateVersion string
+	subnets               []string
+	// zones are the availability zones of the subnets, resolved once on startup.
+	zones []string
+}
+
+// buildAutoprovisioningConfig builds the autoprovisioning config from the autoscaling options.
+// It returns nil if node autoprovisioning is disabled.
+func buildAutoprovisioningConfig(opts config.AutoscalingOptions) (*autoprovisioningConfig, error) {
+	if !opts.NodeAutoprovisioningEnabled {
+		return nil, nil
+	}
+	if opts.ClusterName == "" {
+		return nil, errors.New("cluster name is required to tag autoprovisioned ASGs")
+	}
+	if opts.AWSAutoprovisioningLaunchTemplate == "" {
+		return nil, errors.New("launch template of autoprovisioned ASGs is not set")
+	}
+
+	cfg := &autoprovisioningConfig{
+		clusterName:           opts.ClusterName,
+		launchTemplateName:    opts.AWSAutoprovisioningLaunchTemplate,
+		launchTemplateVersion: defaultLaunchTemplateVersion,
+	}
+	if tokens := strings.SplitN(opts.AWSAutoprovisioningLaunchTemplate, ":", 2); len(tokens) == 2 {
+		if tokens[0] == "" || tokens[1] == "" {
+			return nil, fmt.Errorf("invalid launch template %q, expected <name> or <name>:<version>", opts.AWSAutoprovisioningLaunchTemplate)
+		}
+		cfg.launchTemplateName = tokens[0]
+		cfg.launchTemplateVersion = tokens[1]
+	}
+
+	for _, subnet := range strings.Split(opts.AWSAutoprovisioningSubnets, ",") {
+		if subnet = strings.TrimSpace(subnet); subnet != "" {
+			cfg.subnets = append(cfg.subnets, subnet)
+		}
+	}
+	if len(cfg.subnets) == 0 {
+		return nil, errors.New("subnets of autoprovisioned ASGs are not set")
+	}
+	return cfg, nil
+}
+
+// discoverySpec returns the auto-discovery spec matching ASGs autoprovisioned in the cluster.
+func (c *autoprovisioningConfig) discoverySpec() string {
+	return fmt.Sprintf("asg:tag=%s=%s", autoprovisionedTag, c.clusterName)
+}
+
+// buildAsg builds a not yet existing ASG running nodes of the given instance type, labels, taints
+// and extra resources. The name of the ASG is derived from its parameters, so that the same
+// ASG is built for the same parameters.
+func (c *autoprovisioningConfig) buildAsg(machineType string, labels map[string]string, taints []apiv1.Taint,
+	extraResources map[string]resource.Quantity) *asg {
+	tags := []*autoscaling.TagDescription{buildTagDescription(autoprovisionedTag, c.clusterName)}
+	for _, key := range sortedKeys(labels) {
+		tags = append(tags, buildTagDescription(labelTagPrefix+key, labels[key]))
+	}
+	for _, taint := range taints {
+		tags = append(tags, buildTagDescription(taintTagPrefix+taint.Key, fmt.Sprintf("%s:%s", taint.Value, taint.Effect)))
+	}
+	resourceNames := make([]string, 0, len(extraResources))
+	for name := range extraResources {
+		resourceNames = append(resourceNames, name)
+	}
+	sort.Strings(resourceNames)
+	for _, name := range resourceNames {
+		quantity := extraResources[name]
+		tags = append(tags, buildTagDescription(resourcesTagPrefix+name, quantity.String()))
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(machineType))
+	for _, tag := range tags {
+		hash.Write([]byte(fmt.Sprintf("\n%s=%s", aws.StringValue(tag.Key), aws.StringValue(tag.Value))))
+	}
+	name := fmt.Sprintf("%s-nap-%s-%08x", c.clusterName, strings.Replace(machineType, ".", "-", -1), hash.Sum32())
+	for _, tag := range tags {
+		tag.ResourceId = aws.String(name)
+	}
+
+	return &asg{
+		AwsRef:                AwsRef{Name: name},
+		minSize:               0,
+		maxSize:               autoprovisionedAsgMaxSize,
+		curSize:               0,
+		AvailabilityZones:     c.zones,
+		LaunchTemplateName:    c.launchTemplateName,
+		LaunchTemplateVersion: c.launchTemplateVersion,
+		InstanceTypeOverrides: []string{machineType},
+		Tags:                  tags,
+	}
+}
+
+// isAutoprovisioned returns true if the ASG was created by node autoprovisioning.
+func isAutoprovisioned(asg *asg) bool {
+	for _, tag := range asg.Tags {
+		if aws.StringValue(tag.Key) == autoprovisionedTag {
+			return true
+		}
+	}
+	return false
+}
+
+func buildTagDescription(key, value string) *autoscaling.TagDescription {
+	return &autoscaling.TagDescription{
+		Key:               aws.String(key),
+		Value:             aws.String(value),
+		PropagateAtLaunch: aws.Bool(true),
+		ResourceType:      aws.String("auto-scaling-group"),
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// uniqueZones returns the zones without duplicates, keeping their order.
+func uniqueZones(zones []string) []string {
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(zones))
+	for _, zone := range zones {
+		if !seen[zone] {
+			seen[zone] = true
+			result = append(result, zone)
+		}
+	}
+	return result
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+)
+
+func TestBuildAutoprovisioningConfig(t *testing.T) {
+	testCases := []struct {
+		name          string
+		options       config.AutoscalingOptions
+		expected      *autoprovisioningConfig
+		expectedError bool
+	}{
+		{
+			name:    "disabled",
+			options: config.AutoscalingOptions{ClusterName: "test"},
+		},
+		{
+			name: "default version",
+			options: config.AutoscalingOptions{
+				NodeAutoprovisioningEnabled:       true,
+				ClusterName:                       "test",
+				AWSAutoprovisioningLaunchTemplate: "nodes",
+				AWSAutoprovisioningSubnets:        "subnet-1, subnet-2,",
+			},
+			expected: &autoprovisioningConfig{
+				clusterName:           "test",
+				launchTemplateName:    "nodes",
+				launchTemplateVersion: "$Default",
+				subnets:               []string{"subnet-1", "subnet-2"},
+			},
+		},
+		{
+			name: "explicit version",
+			options: config.AutoscalingOptions{
+				NodeAutoprovisioningEnabled:       true,
+				ClusterName:                       "test",
+				AWSAutoprovisioningLaunchTemplate: "nodes:3",
+				AWSAutoprovisioningSubnets:        "subnet-1",
+			},
+			expected: &autoprovisioningConfig{
+				clusterName:           "test",
+				launchTemplateName:    "nodes",
+				launchTemplateVersion: "3",
+				subnets:               []string{"subnet-1"},
+			},
+		},
+		{
+			name: "missing cluster name",
+			options: config.AutoscalingOptions{
+				NodeAutoprovisioningEnabled:       true,
+				AWSAutoprovisioningLaunchTemplate: "nodes",
+				AWSAutoprovisioningSubnets:        "subnet-1",
+			},
+			expectedError: true,
+		},
+		{
+			name: "missing launch template",
+			options: config.AutoscalingOptions{
+				NodeAutoprovisioningEnabled: true,
+				ClusterName:                 "test",
+				AWSAutoprovisioningSubnets:  "subnet-1",
+			},
+			expectedError: true,
+		},
+		{
+			name: "invalid launch template",
+			options: config.AutoscalingOptions{
+				NodeAutoprovisioningEnabled:       true,
+				ClusterName:                       "test",
+				AWSAutoprovisioningLaunchTemplate: "nodes:",
+				AWSAutoprovisioningSubnets:        "subnet-1",
+			},
+			expectedError: true,
+		},
+		{
+			name: "missing subnets",
+			options: config.AutoscalingOptions{
+				NodeAutoprovisioningEnabled:       true,
+				ClusterName:                       "test",
+				AWSAutoprovisioningLaunchTemplate: "nodes",
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		cfg, err := buildAutoprovisioningConfig(tc.options)
+		if tc.expectedError {
+			assert.Error(t, err, tc.name)
+			continue
+		}
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, cfg, tc.name)
+	}
+}
+
+func TestBuildAutoprovisionedAsg(t *testing.T) {
+	cfg := &autoprovisioningConfig{
+		clusterName:           "test",
+		launchTemplateName:    "nodes",
+		launchTemplateVersion: "$Default",
+		subnets:               []string{"subnet-1", "subnet-2"},
+		zones:                 []string{"us-east-1a", "us-east-1b"},
+	}
+	labels := map[string]string{"team": "ml", "app": "training"}
+	taints := []apiv1.Taint{{Key: "dedicated", Value: "ml", Effect: apiv1.TaintEffectNoSchedule}}
+	extraResources := map[string]resource.Quantity{"ephemeral-storage": resource.MustParse("100Gi")}
+
+	asg := cfg.buildAsg("m5.large", labels, taints, extraResources)
+	assert.Regexp(t, "^test-nap-m5-large-[0-9a-f]{8}$", asg.Name)
+	assert.Equal(t, 0, asg.minSize)
+	assert.Equal(t, autoprovisionedAsgMaxSize, asg.maxSize)
+	assert.Equal(t, 0, asg.curSize)
+	assert.Equal(t, []string{"us-east-1a", "us-east-1b"}, asg.AvailabilityZones)
+	assert.Equal(t, "nodes", asg.LaunchTemplateName)
+	assert.Equal(t, "$Default", asg.LaunchTemplateVersion)
+	assert.Equal(t, []string{"m5.large"}, asg.InstanceTypeOverrides)
+	assert.True(t, isAutoprovisioned(asg))
+
+	tags := make(map[string]string)
+	for _, tag := range asg.Tags {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		assert.Equal(t, asg.Name, aws.StringValue(tag.ResourceId))
+		assert.True(t, aws.BoolValue(tag.PropagateAtLaunch))
+	}
+	assert.Equal(t, map[string]string{
+		autoprovisionedTag: "test",
+		"k8s.io/cluster-autoscaler/node-template/label/team":                  "ml",
+		"k8s.io/cluster-autoscaler/node-template/label/app":                   "training",
+		"k8s.io/cluster-autoscaler/node-template/taint/dedicated":             "ml:NoSchedule",
+		"k8s.io/cluster-autoscaler/node-template/resources/ephemeral-storage": "100Gi",
+	}, tags)
+
+	// The same parameters give the same ASG, different ones give a different ASG.
+	assert.Equal(t, asg.Name, cfg.buildAsg("m5.large", labels, taints, extraResources).Name)
+	assert.NotEqual(t, asg.Name, cfg.buildAsg("m5.large", labels, nil, extraResources).Name)
+	assert.NotEqual(t, asg.Name, cfg.buildAsg("m5.xlarge", labels, taints, extraResources).Name)
+
+	// Node templates are built from the tags, as for any other ASG.
+	m := &AwsManager{}
+	template, err := m.getAsgTemplate(asg)
+	assert.NoError(t, err)
+	node, err := m.buildNodeFromTemplate(asg, template)
+	assert.NoError(t, err)
+	assert.Equal(t, "m5.large", node.Labels[apiv1.LabelInstanceType])
+	assert.Equal(t, "ml", node.Labels["team"])
+	assert.Equal(t, "training", node.Labels["app"])
+	assert.Equal(t, taints, node.Spec.Taints)
+	storage := node.Status.Capacity[apiv1.ResourceEphemeralStorage]
+	assert.Equal(t, "100Gi", storage.String())
+}
+
+func TestUniqueZones(t *testing.T) {
+	assert.Equal(t, []string{"us-east-1b", "us-east-1a"}, uniqueZones([]string{"us-east-1b", "us-east-1a", "us-east-1b"}))
+	assert.Equal(t, []string{}, uniqueZones(nil))
+}
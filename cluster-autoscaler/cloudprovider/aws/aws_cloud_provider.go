@@ -21,6 +21,7 @@ import (
 	"io"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
 
 	apiv1 "k8s.io/api/core/v1"
@@ -28,6 +29,7 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pricing"
 	"k8s.io/klog"
 	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
 )
@@ -51,13 +53,15 @@ var (
 // awsCloudProvider implements CloudProvider interface.
 type awsCloudProvider struct {
 	awsManager      *AwsManager
+	pricingModel    *AwsPriceModel
 	resourceLimiter *cloudprovider.ResourceLimiter
 }
 
 // BuildAwsCloudProvider builds CloudProvider implementation for AWS.
-func BuildAwsCloudProvider(awsManager *AwsManager, resourceLimiter *cloudprovider.ResourceLimiter) (cloudprovider.CloudProvider, error) {
+func BuildAwsCloudProvider(awsManager *AwsManager, pricingModel *AwsPriceModel, resourceLimiter *cloudprovider.ResourceLimiter) (cloudprovider.CloudProvider, error) {
 	aws := &awsCloudProvider{
 		awsManager:      awsManager,
+		pricingModel:    pricingModel,
 		resourceLimiter: resourceLimiter,
 	}
 	return aws, nil
@@ -122,19 +126,41 @@ func (aws *awsCloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovider.N
 
 // Pricing returns pricing model for this cloud provider or error if not available.
 func (aws *awsCloudProvider) Pricing() (cloudprovider.PricingModel, errors.AutoscalerError) {
-	return nil, cloudprovider.ErrNotImplemented
+	if aws.pricingModel == nil {
+		return nil, cloudprovider.ErrNotImplemented
+	}
+	return aws.pricingModel, nil
 }
 
 // GetAvailableMachineTypes get all machine types that can be requested from the cloud provider.
 func (aws *awsCloudProvider) GetAvailableMachineTypes() ([]string, error) {
-	return []string{}, nil
+	if aws.awsManager.autoprovisioning == nil {
+		return []string{}, nil
+	}
+	machineTypes := make([]string, 0, len(InstanceTypes))
+	for machineType := range InstanceTypes {
+		machineTypes = append(machineTypes, machineType)
+	}
+	sort.Strings(machineTypes)
+	return machineTypes, nil
 }
 
 // NewNodeGroup builds a theoretical node group based on the node definition provided. The node group is not automatically
 // created on the cloud provider side. The node group is not returned by NodeGroups() until it is created.
 func (aws *awsCloudProvider) NewNodeGroup(machineType string, labels map[string]string, systemLabels map[string]string,
 	taints []apiv1.Taint, extraResources map[string]resource.Quantity) (cloudprovider.NodeGroup, error) {
-	return nil, cloudprovider.ErrNotImplemented
+	if aws.awsManager.autoprovisioning == nil {
+		return nil, cloudprovider.ErrNotImplemented
+	}
+	if _, found := InstanceTypes[machineType]; !found {
+		return nil, fmt.Errorf("unknown EC2 instance type %q", machineType)
+	}
+	// System labels are applied to nodes in the same way as user labels
+	nodeLabels := cloudprovider.JoinStringMaps(labels, systemLabels)
+	return &AwsNodeGroup{
+		asg:        aws.awsManager.autoprovisioning.buildAsg(machineType, nodeLabels, taints, extraResources),
+		awsManager: aws.awsManager,
+	}, nil
 }
 
 // GetResourceLimiter returns struct containing limits (max, min) for resources (cores, memory etc.).
@@ -199,23 +225,45 @@ func (ng *AwsNodeGroup) TargetSize() (int, error) {
 // Exist checks if the node group really exists on the cloud provider side. Allows to tell the
 // theoretical node group from the real one.
 func (ng *AwsNodeGroup) Exist() bool {
-	return true
+	return ng.awsManager.asgCache.IsRegistered(ng.asg.AwsRef)
 }
 
 // Create creates the node group on the cloud provider side.
 func (ng *AwsNodeGroup) Create() (cloudprovider.NodeGroup, error) {
-	return nil, cloudprovider.ErrAlreadyExist
+	if ng.Exist() {
+		return nil, cloudprovider.ErrAlreadyExist
+	}
+	asg, err := ng.awsManager.createAsg(ng.asg)
+	if err != nil {
+		return nil, err
+	}
+	return &AwsNodeGroup{
+		asg:        asg,
+		awsManager: ng.awsManager,
+	}, nil
 }
 
 // Autoprovisioned returns true if the node group is autoprovisioned.
 func (ng *AwsNodeGroup) Autoprovisioned() bool {
-	return false
+	return isAutoprovisioned(ng.asg)
 }
 
 // Delete deletes the node group on the cloud provider side.
 // This will be executed only for autoprovisioned node groups, once their size drops to 0.
 func (ng *AwsNodeGroup) Delete() error {
-	return cloudprovider.ErrNotImplemented
+	if !ng.Autoprovisioned() {
+		return fmt.Errorf("asg %s is not autoprovisioned and won't be deleted", ng.Id())
+	}
+	if ng.asg.curSize > 0 {
+		return fmt.Errorf("asg %s has target size %d and can't be deleted", ng.Id(), ng.asg.curSize)
+	}
+	return ng.awsManager.deleteAsg(ng.asg)
+}
+
+// GetOptions returns NodeGroupAutoscalingOptions that should be used for this particular
+// NodeGroup. Returning a nil result will result in using default options.
+func (ng *AwsNodeGroup) GetOptions(defaults config.NodeGroupAutoscalingOptions) (*config.NodeGroupAutoscalingOptions, error) {
+	return ng.awsManager.GetAsgOptions(*ng.asg, defaults), nil
 }
 
 // IncreaseSize increases Asg size
@@ -245,9 +293,16 @@ func (ng *AwsNodeGroup) DecreaseTargetSize(delta int) error {
 	if err != nil {
 		return err
 	}
-	if int(size)+delta < len(nodes) {
+	existing := 0
+	for _, node := range nodes {
+		// Placeholders stand for instances that failed to launch, so they may be removed this way
+		if !isPlaceholderInstance(node) {
+			existing++
+		}
+	}
+	if int(size)+delta < existing {
 		return fmt.Errorf("attempt to delete existing nodes targetSize:%d delta:%d existingNodes: %d",
-			size, delta, len(nodes))
+			size, delta, existing)
 	}
 	return ng.awsManager.SetAsgSize(ng.asg, size+delta)
 }
@@ -312,7 +367,10 @@ func (ng *AwsNodeGroup) Nodes() ([]cloudprovider.Instance, error) {
 	instances := make([]cloudprovider.Instance, len(asgNodes))
 
 	for i, asgNode := range asgNodes {
-		instances[i] = cloudprovider.Instance{Id: asgNode.ProviderID}
+		instances[i] = cloudprovider.Instance{
+			Id:     asgNode.ProviderID,
+			Status: ng.awsManager.GetInstanceStatus(asgNode),
+		}
 	}
 	return instances, nil
 }
@@ -346,12 +404,37 @@ func BuildAWS(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscover
 		defer config.Close()
 	}
 
+	autoprovisioning, err := buildAutoprovisioningConfig(opts)
+	if err != nil {
+		klog.Fatalf("Failed to configure AWS node autoprovisioning: %v", err)
+	}
+	if autoprovisioning != nil {
+		// Rediscover ASGs autoprovisioned before a restart
+		specs := append([]string{}, do.NodeGroupAutoDiscoverySpecs...)
+		do.NodeGroupAutoDiscoverySpecs = append(specs, autoprovisioning.discoverySpec())
+	}
+
 	manager, err := CreateAwsManager(config, do)
 	if err != nil {
 		klog.Fatalf("Failed to create AWS Manager: %v", err)
 	}
 
-	provider, err := BuildAwsCloudProvider(manager, rl)
+	if autoprovisioning != nil {
+		if err := manager.enableAutoprovisioning(autoprovisioning); err != nil {
+			klog.Fatalf("Failed to enable AWS node autoprovisioning: %v", err)
+		}
+	}
+
+	var staticPrices pricing.InstancePrices
+	if manager.pricingFile != "" {
+		staticPrices, err = pricing.ReadStaticPricesFile(manager.pricingFile)
+		if err != nil {
+			klog.Fatalf("Failed to configure AWS pricing: %v", err)
+		}
+	}
+	pricingModel := NewAwsPriceModel(getRegion(), staticPrices)
+
+	provider, err := BuildAwsCloudProvider(manager, pricingModel, rl)
 	if err != nil {
 		klog.Fatalf("Failed to create AWS cloud provider: %v", err)
 	}
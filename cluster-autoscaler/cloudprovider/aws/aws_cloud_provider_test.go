@@ -25,6 +25,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 )
 
@@ -32,6 +33,16 @@ type AutoScalingMock struct {
 	mock.Mock
 }
 
+func (a *AutoScalingMock) CreateAutoScalingGroup(input *autoscaling.CreateAutoScalingGroupInput) (*autoscaling.CreateAutoScalingGroupOutput, error) {
+	args := a.Called(input)
+	return args.Get(0).(*autoscaling.CreateAutoScalingGroupOutput), nil
+}
+
+func (a *AutoScalingMock) DeleteAutoScalingGroup(input *autoscaling.DeleteAutoScalingGroupInput) (*autoscaling.DeleteAutoScalingGroupOutput, error) {
+	args := a.Called(input)
+	return args.Get(0).(*autoscaling.DeleteAutoScalingGroupOutput), nil
+}
+
 func (a *AutoScalingMock) DescribeAutoScalingGroupsPages(i *autoscaling.DescribeAutoScalingGroupsInput, fn func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool) error {
 	args := a.Called(i, fn)
 	return args.Error(0)
@@ -42,6 +53,11 @@ func (a *AutoScalingMock) DescribeLaunchConfigurations(i *autoscaling.DescribeLa
 	return args.Get(0).(*autoscaling.DescribeLaunchConfigurationsOutput), nil
 }
 
+func (a *AutoScalingMock) DescribeScalingActivities(i *autoscaling.DescribeScalingActivitiesInput) (*autoscaling.DescribeScalingActivitiesOutput, error) {
+	args := a.Called(i)
+	return args.Get(0).(*autoscaling.DescribeScalingActivitiesOutput), nil
+}
+
 func (a *AutoScalingMock) DescribeTagsPages(i *autoscaling.DescribeTagsInput, fn func(*autoscaling.DescribeTagsOutput, bool) bool) error {
 	args := a.Called(i, fn)
 	return args.Error(0)
@@ -66,6 +82,11 @@ func (e *EC2Mock) DescribeLaunchTemplateVersions(i *ec2.DescribeLaunchTemplateVe
 	return args.Get(0).(*ec2.DescribeLaunchTemplateVersionsOutput), nil
 }
 
+func (e *EC2Mock) DescribeSubnets(i *ec2.DescribeSubnetsInput) (*ec2.DescribeSubnetsOutput, error) {
+	args := e.Called(i)
+	return args.Get(0).(*ec2.DescribeSubnetsOutput), nil
+}
+
 var testService = autoScalingWrapper{&AutoScalingMock{}, map[string]string{}}
 
 var testAwsManager = &AwsManager{
@@ -133,7 +154,7 @@ func testProvider(t *testing.T, m *AwsManager) *awsCloudProvider {
 		map[string]int64{cloudprovider.ResourceNameCores: 1, cloudprovider.ResourceNameMemory: 10000000},
 		map[string]int64{cloudprovider.ResourceNameCores: 10, cloudprovider.ResourceNameMemory: 100000000})
 
-	provider, err := BuildAwsCloudProvider(m, resourceLimiter)
+	provider, err := BuildAwsCloudProvider(m, NewAwsPriceModel("", nil), resourceLimiter)
 	assert.NoError(t, err)
 	return provider.(*awsCloudProvider)
 }
@@ -143,7 +164,7 @@ func TestBuildAwsCloudProvider(t *testing.T) {
 		map[string]int64{cloudprovider.ResourceNameCores: 1, cloudprovider.ResourceNameMemory: 10000000},
 		map[string]int64{cloudprovider.ResourceNameCores: 10, cloudprovider.ResourceNameMemory: 100000000})
 
-	_, err := BuildAwsCloudProvider(testAwsManager, resourceLimiter)
+	_, err := BuildAwsCloudProvider(testAwsManager, NewAwsPriceModel("", nil), resourceLimiter)
 	assert.NoError(t, err)
 }
 
@@ -152,6 +173,13 @@ func TestName(t *testing.T) {
 	assert.Equal(t, provider.Name(), ProviderName)
 }
 
+func TestPricing(t *testing.T) {
+	provider := testProvider(t, testAwsManager)
+	pricing, err := provider.Pricing()
+	assert.NoError(t, err)
+	assert.NotNil(t, pricing)
+}
+
 func TestNodeGroups(t *testing.T) {
 	provider := testProvider(t, newTestAwsManagerWithAsgs(t, testService, []string{"1:5:test-asg"}))
 
@@ -239,7 +267,10 @@ func TestNodeGroupForNode(t *testing.T) {
 
 	assert.NoError(t, err)
 
-	assert.Equal(t, []cloudprovider.Instance{{Id: "aws:///us-east-1a/test-instance-id"}}, nodes)
+	assert.Equal(t, []cloudprovider.Instance{{
+		Id:     "aws:///us-east-1a/test-instance-id",
+		Status: &cloudprovider.InstanceStatus{State: cloudprovider.InstanceRunning},
+	}}, nodes)
 	service.AssertNumberOfCalls(t, "DescribeAutoScalingGroupsPages", 1)
 
 	// test node in cluster that is not in a group managed by cluster autoscaler
@@ -430,6 +461,80 @@ func TestDeleteNodes(t *testing.T) {
 	assert.Equal(t, 1, newSize)
 }
 
+func TestNodesWithFailedScaleUp(t *testing.T) {
+	service := &AutoScalingMock{}
+	provider := testProvider(t, newTestAwsManagerWithAsgs(t, service, []string{"1:5:test-asg"}))
+	asgs := provider.NodeGroups()
+
+	service.On("DescribeAutoScalingGroupsPages",
+		&autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: aws.StringSlice([]string{"test-asg"}),
+			MaxRecords:            aws.Int64(maxRecordsReturnedByAPI),
+		},
+		mock.AnythingOfType("func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool"),
+	).Run(func(args mock.Arguments) {
+		fn := args.Get(1).(func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool)
+		output := testNamedDescribeAutoScalingGroupsOutput("test-asg", 3, "test-instance-id", "pending-instance-id")
+		output.AutoScalingGroups[0].AvailabilityZones = aws.StringSlice([]string{"us-east-1a"})
+		output.AutoScalingGroups[0].Instances[0].LifecycleState = aws.String(autoscaling.LifecycleStateInService)
+		output.AutoScalingGroups[0].Instances[1].LifecycleState = aws.String(autoscaling.LifecycleStatePending)
+		fn(output, false)
+	}).Return(nil)
+
+	service.On("DescribeScalingActivities", &autoscaling.DescribeScalingActivitiesInput{
+		AutoScalingGroupName: aws.String("test-asg"),
+		MaxRecords:           aws.Int64(1),
+	}).Return(&autoscaling.DescribeScalingActivitiesOutput{
+		Activities: []*autoscaling.Activity{{
+			StatusCode:    aws.String(autoscaling.ScalingActivityStatusCodeFailed),
+			StatusMessage: aws.String("We currently do not have sufficient m5.large capacity in the Availability Zone you requested (us-east-1a)."),
+		}},
+	})
+
+	provider.Refresh()
+
+	nodes, err := asgs[0].Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(nodes))
+	assert.Equal(t, cloudprovider.Instance{
+		Id:     "aws:///us-east-1a/test-instance-id",
+		Status: &cloudprovider.InstanceStatus{State: cloudprovider.InstanceRunning},
+	}, nodes[0])
+	assert.Equal(t, cloudprovider.Instance{
+		Id:     "aws:///us-east-1a/pending-instance-id",
+		Status: &cloudprovider.InstanceStatus{State: cloudprovider.InstanceCreating},
+	}, nodes[1])
+	assert.Equal(t, "aws:///us-east-1a/i-placeholder-test-asg-0", nodes[2].Id)
+	assert.Equal(t, cloudprovider.InstanceCreating, nodes[2].Status.State)
+	assert.Equal(t, cloudprovider.OutOfResourcesErrorClass, nodes[2].Status.ErrorInfo.ErrorClass)
+	assert.Equal(t, ErrorCodeInsufficientCapacity, nodes[2].Status.ErrorInfo.ErrorCode)
+
+	// Placeholders are mapped back to their node group...
+	placeholderNode := &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: nodes[2].Id},
+		Spec:       apiv1.NodeSpec{ProviderID: nodes[2].Id},
+	}
+	group, err := provider.NodeGroupForNode(placeholderNode)
+	assert.NoError(t, err)
+	assert.Equal(t, "test-asg", group.Id())
+
+	// ...and deleted by decreasing the desired capacity
+	service.On("SetDesiredCapacity", &autoscaling.SetDesiredCapacityInput{
+		AutoScalingGroupName: aws.String("test-asg"),
+		DesiredCapacity:      aws.Int64(2),
+		HonorCooldown:        aws.Bool(false),
+	}).Return(&autoscaling.SetDesiredCapacityOutput{})
+
+	err = group.DeleteNodes([]*apiv1.Node{placeholderNode})
+	assert.NoError(t, err)
+	service.AssertNumberOfCalls(t, "SetDesiredCapacity", 1)
+	service.AssertNumberOfCalls(t, "TerminateInstanceInAutoScalingGroup", 0)
+
+	newSize, err := asgs[0].TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, newSize)
+}
+
 func TestDeleteNodesAfterMultipleRefreshes(t *testing.T) {
 	service := &AutoScalingMock{}
 	manager := newTestAwsManagerWithAsgs(t, service, []string{"1:5:test-asg"})
@@ -468,6 +573,110 @@ func TestDeleteNodesAfterMultipleRefreshes(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestAutoprovisioningDisabled(t *testing.T) {
+	provider := testProvider(t, newTestAwsManagerWithAsgs(t, testService, []string{"1:5:test-asg"}))
+
+	machineTypes, err := provider.GetAvailableMachineTypes()
+	assert.NoError(t, err)
+	assert.Empty(t, machineTypes)
+
+	_, err = provider.NewNodeGroup("m5.large", nil, nil, nil, nil)
+	assert.Equal(t, cloudprovider.ErrNotImplemented, err)
+
+	asgs := provider.NodeGroups()
+	assert.True(t, asgs[0].Exist())
+	assert.False(t, asgs[0].Autoprovisioned())
+	_, err = asgs[0].Create()
+	assert.Equal(t, cloudprovider.ErrAlreadyExist, err)
+	assert.Error(t, asgs[0].Delete())
+}
+
+func TestAutoprovisioning(t *testing.T) {
+	service := &AutoScalingMock{}
+	ec2Service := &EC2Mock{}
+	manager := newTestAwsManagerWithAsgs(t, service, []string{"1:5:test-asg"})
+	manager.ec2Service = ec2Wrapper{ec2Service}
+	provider := testProvider(t, manager)
+
+	ec2Service.On("DescribeSubnets", &ec2.DescribeSubnetsInput{
+		SubnetIds: aws.StringSlice([]string{"subnet-1", "subnet-2", "subnet-3"}),
+	}).Return(&ec2.DescribeSubnetsOutput{
+		Subnets: []*ec2.Subnet{
+			{SubnetId: aws.String("subnet-3"), AvailabilityZone: aws.String("us-east-1a")},
+			{SubnetId: aws.String("subnet-2"), AvailabilityZone: aws.String("us-east-1b")},
+			{SubnetId: aws.String("subnet-1"), AvailabilityZone: aws.String("us-east-1a")},
+		},
+	})
+	err := manager.enableAutoprovisioning(&autoprovisioningConfig{
+		clusterName:           "test",
+		launchTemplateName:    "nodes",
+		launchTemplateVersion: "$Default",
+		subnets:               []string{"subnet-1", "subnet-2", "subnet-3"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"us-east-1a", "us-east-1b"}, manager.autoprovisioning.zones)
+
+	machineTypes, err := provider.GetAvailableMachineTypes()
+	assert.NoError(t, err)
+	assert.Equal(t, len(InstanceTypes), len(machineTypes))
+	assert.Contains(t, machineTypes, "m5.large")
+
+	_, err = provider.NewNodeGroup("m0.unknown", nil, nil, nil, nil)
+	assert.Error(t, err)
+
+	taints := []apiv1.Taint{{Key: "dedicated", Value: "ml", Effect: apiv1.TaintEffectNoSchedule}}
+	nodeGroup, err := provider.NewNodeGroup("p3.2xlarge", map[string]string{"team": "ml"}, map[string]string{"system": "true"}, taints, nil)
+	assert.NoError(t, err)
+	assert.False(t, nodeGroup.Exist())
+	assert.True(t, nodeGroup.Autoprovisioned())
+	assert.Equal(t, 0, nodeGroup.MinSize())
+	assert.Equal(t, 1, len(provider.NodeGroups()))
+
+	nodeInfo, err := nodeGroup.TemplateNodeInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, "p3.2xlarge", nodeInfo.Node().Labels[apiv1.LabelInstanceType])
+	assert.Equal(t, "ml", nodeInfo.Node().Labels["team"])
+	assert.Equal(t, "true", nodeInfo.Node().Labels["system"])
+	assert.Equal(t, taints, nodeInfo.Node().Spec.Taints)
+
+	service.On("CreateAutoScalingGroup", mock.MatchedBy(func(input *autoscaling.CreateAutoScalingGroupInput) bool {
+		lt := input.MixedInstancesPolicy.LaunchTemplate
+		return aws.StringValue(input.AutoScalingGroupName) == nodeGroup.Id() &&
+			aws.Int64Value(input.MinSize) == 0 &&
+			aws.Int64Value(input.DesiredCapacity) == 0 &&
+			aws.StringValue(input.VPCZoneIdentifier) == "subnet-1,subnet-2,subnet-3" &&
+			aws.StringValue(lt.LaunchTemplateSpecification.LaunchTemplateName) == "nodes" &&
+			aws.StringValue(lt.LaunchTemplateSpecification.Version) == "$Default" &&
+			len(lt.Overrides) == 1 && aws.StringValue(lt.Overrides[0].InstanceType) == "p3.2xlarge" &&
+			len(input.Tags) == 4
+	})).Return(&autoscaling.CreateAutoScalingGroupOutput{})
+
+	created, err := nodeGroup.Create()
+	assert.NoError(t, err)
+	service.AssertNumberOfCalls(t, "CreateAutoScalingGroup", 1)
+	assert.Equal(t, nodeGroup.Id(), created.Id())
+	assert.True(t, created.Exist())
+	assert.True(t, nodeGroup.Exist())
+	assert.Equal(t, 2, len(provider.NodeGroups()))
+	nodes, err := created.Nodes()
+	assert.NoError(t, err)
+	assert.Empty(t, nodes)
+
+	_, err = created.Create()
+	assert.Equal(t, cloudprovider.ErrAlreadyExist, err)
+
+	service.On("DeleteAutoScalingGroup", &autoscaling.DeleteAutoScalingGroupInput{
+		AutoScalingGroupName: aws.String(nodeGroup.Id()),
+		ForceDelete:          aws.Bool(false),
+	}).Return(&autoscaling.DeleteAutoScalingGroupOutput{})
+
+	err = created.Delete()
+	assert.NoError(t, err)
+	service.AssertNumberOfCalls(t, "DeleteAutoScalingGroup", 1)
+	assert.False(t, created.Exist())
+	assert.Equal(t, 1, len(provider.NodeGroups()))
+}
+
 func TestGetResourceLimiter(t *testing.T) {
 	service := &AutoScalingMock{}
 	m := newTestAwsManagerWithService(service, nil)
@@ -25,6 +25,7 @@ import (
 	"math/rand"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -39,6 +40,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
 	"k8s.io/klog"
 	provider_aws "k8s.io/kubernetes/pkg/cloudprovider/providers/aws"
@@ -46,11 +48,17 @@ import (
 )
 
 const (
-	operationWaitTimeout    = 5 * time.Second
-	operationPollInterval   = 100 * time.Millisecond
-	maxRecordsReturnedByAPI = 100
-	maxAsgNamesPerDescribe  = 50
-	refreshInterval         = 1 * time.Minute
+	operationWaitTimeout        = 5 * time.Second
+	operationPollInterval       = 100 * time.Millisecond
+	maxRecordsReturnedByAPI     = 100
+	maxAsgNamesPerDescribe      = 50
+	refreshInterval             = 1 * time.Minute
+	autoscalingOptionsTagPrefix = "k8s.io/cluster-autoscaler/node-template/autoscaling-options/"
+	// mixedInstancesTemplateTypeTag selects which of the MixedInstancesPolicy overrides is used
+	// to build the node template of an ASG. The smallest override is used if it's not set.
+	mixedInstancesTemplateTypeTag = "k8s.io/cluster-autoscaler/node-template/mixed-instances-policy/instance-type"
+	// pricingFileEnv is the environment variable with the pricing file, used if it's not set in the cloud config.
+	pricingFileEnv = "AWS_PRICING_FILE"
 )
 
 // AwsManager is handles aws communication and data caching.
@@ -59,6 +67,22 @@ type AwsManager struct {
 	ec2Service         ec2Wrapper
 	asgCache           *asgCache
 	lastRefresh        time.Time
+	// autoprovisioning is nil unless node autoprovisioning is enabled.
+	autoprovisioning *autoprovisioningConfig
+	// pricingFile is the path to a JSON file with ec2 prices, empty if not configured.
+	pricingFile string
+}
+
+// awsCloudConfig is the cloud config of the AWS cloud provider, extended with options used
+// only by cluster autoscaler.
+type awsCloudConfig struct {
+	provider_aws.CloudConfig
+	// [Pricing]
+	//  File = /etc/cluster-autoscaler/ec2-prices.json
+	Pricing struct {
+		// File is the path to a JSON file with ec2 on-demand prices per region and instance type.
+		File string
+	}
 }
 
 type asgTemplate struct {
@@ -175,13 +199,13 @@ func createAWSManagerInternal(
 		return nil, err
 	}
 
-	if err = validateOverrides(cfg); err != nil {
+	if err = validateOverrides(&cfg.CloudConfig); err != nil {
 		klog.Errorf("Unable to validate custom endpoint overrides: %v", err)
 		return nil, err
 	}
 
 	if autoScalingService == nil || ec2Service == nil {
-		awsSdkProvider := newAWSSDKProvider(cfg)
+		awsSdkProvider := newAWSSDKProvider(&cfg.CloudConfig)
 		sess := session.New(aws.NewConfig().WithRegion(getRegion()).
 			WithEndpointResolver(getResolver(awsSdkProvider.cfg)))
 
@@ -208,6 +232,10 @@ func createAWSManagerInternal(
 		autoScalingService: *autoScalingService,
 		ec2Service:         *ec2Service,
 		asgCache:           cache,
+		pricingFile:        strings.TrimSpace(cfg.Pricing.File),
+	}
+	if manager.pricingFile == "" {
+		manager.pricingFile = os.Getenv(pricingFileEnv)
 	}
 
 	if err := manager.forceRefresh(); err != nil {
@@ -218,8 +246,8 @@ func createAWSManagerInternal(
 }
 
 // readAWSCloudConfig reads an instance of AWSCloudConfig from config reader.
-func readAWSCloudConfig(config io.Reader) (*provider_aws.CloudConfig, error) {
-	var cfg provider_aws.CloudConfig
+func readAWSCloudConfig(config io.Reader) (*awsCloudConfig, error) {
+	var cfg awsCloudConfig
 	var err error
 
 	if config != nil {
@@ -285,6 +313,97 @@ func (m *AwsManager) GetAsgNodes(ref AwsRef) ([]AwsInstanceRef, error) {
 	return m.asgCache.InstancesByAsg(ref)
 }
 
+// GetInstanceStatus returns the status of the given instance, or nil if it's unknown.
+func (m *AwsManager) GetInstanceStatus(ref AwsInstanceRef) *cloudprovider.InstanceStatus {
+	return m.asgCache.InstanceStatus(ref)
+}
+
+// enableAutoprovisioning enables creating and deleting ASGs as configured by cfg.
+func (m *AwsManager) enableAutoprovisioning(cfg *autoprovisioningConfig) error {
+	zones, err := m.ec2Service.getSubnetZones(cfg.subnets)
+	if err != nil {
+		return fmt.Errorf("failed to get availability zones of subnets %v: %v", cfg.subnets, err)
+	}
+	cfg.zones = uniqueZones(zones)
+	m.autoprovisioning = cfg
+	return nil
+}
+
+// createAsg creates the given autoprovisioned ASG. Returns the registered ASG.
+func (m *AwsManager) createAsg(asg *asg) (*asg, error) {
+	if m.autoprovisioning == nil {
+		return nil, errors.New("node autoprovisioning is disabled")
+	}
+	if err := m.autoScalingService.createAutoscalingGroup(asg, m.autoprovisioning.subnets); err != nil {
+		return nil, err
+	}
+	return m.asgCache.Register(asg), nil
+}
+
+// deleteAsg deletes the given autoprovisioned ASG.
+func (m *AwsManager) deleteAsg(asg *asg) error {
+	if err := m.autoScalingService.deleteAutoscalingGroup(asg.Name); err != nil {
+		return err
+	}
+	m.asgCache.Unregister(asg)
+	return nil
+}
+
+// GetAsgOptions parses options extracted from ASG tags and merges them with provided defaults
+func (m *AwsManager) GetAsgOptions(asg asg, defaults config.NodeGroupAutoscalingOptions) *config.NodeGroupAutoscalingOptions {
+	options := extractAutoscalingOptionsFromTags(asg.Tags)
+	if len(options) == 0 {
+		return nil
+	}
+
+	if stringOpt, found := options[config.DefaultScaleDownUtilizationThresholdKey]; found {
+		if opt, err := strconv.ParseFloat(stringOpt, 64); err != nil {
+			klog.Warningf("failed to convert asg %s %s tag to float: %v",
+				asg.Name, config.DefaultScaleDownUtilizationThresholdKey, err)
+		} else {
+			defaults.ScaleDownUtilizationThreshold = opt
+		}
+	}
+
+	if stringOpt, found := options[config.DefaultScaleDownGpuUtilizationThresholdKey]; found {
+		if opt, err := strconv.ParseFloat(stringOpt, 64); err != nil {
+			klog.Warningf("failed to convert asg %s %s tag to float: %v",
+				asg.Name, config.DefaultScaleDownGpuUtilizationThresholdKey, err)
+		} else {
+			defaults.ScaleDownGpuUtilizationThreshold = opt
+		}
+	}
+
+	if stringOpt, found := options[config.DefaultScaleDownUnneededTimeKey]; found {
+		if opt, err := time.ParseDuration(stringOpt); err != nil {
+			klog.Warningf("failed to convert asg %s %s tag to duration: %v",
+				asg.Name, config.DefaultScaleDownUnneededTimeKey, err)
+		} else {
+			defaults.ScaleDownUnneededTime = opt
+		}
+	}
+
+	if stringOpt, found := options[config.DefaultScaleDownUnreadyTimeKey]; found {
+		if opt, err := time.ParseDuration(stringOpt); err != nil {
+			klog.Warningf("failed to convert asg %s %s tag to duration: %v",
+				asg.Name, config.DefaultScaleDownUnreadyTimeKey, err)
+		} else {
+			defaults.ScaleDownUnreadyTime = opt
+		}
+	}
+
+	if stringOpt, found := options[config.DefaultScaleDownDisabledKey]; found {
+		if opt, err := strconv.ParseBool(stringOpt); err != nil {
+			klog.Warningf("failed to convert asg %s %s tag to bool: %v",
+				asg.Name, config.DefaultScaleDownDisabledKey, err)
+		} else {
+			defaults.ScaleDownDisabled = opt
+		}
+	}
+
+	return &defaults
+}
+
 func (m *AwsManager) getAsgTemplate(asg *asg) (*asgTemplate, error) {
 	if len(asg.AvailabilityZones) < 1 {
 		return nil, fmt.Errorf("unable to get first AvailabilityZone for ASG %q", asg.Name)
@@ -316,6 +435,8 @@ func (m *AwsManager) getAsgTemplate(asg *asg) (*asgTemplate, error) {
 func (m *AwsManager) buildInstanceType(asg *asg) (string, error) {
 	if asg.LaunchConfigurationName != "" {
 		return m.autoScalingService.getInstanceTypeByLCName(asg.LaunchConfigurationName)
+	} else if len(asg.InstanceTypeOverrides) > 0 {
+		return buildInstanceTypeFromOverrides(asg)
 	} else if asg.LaunchTemplateName != "" && asg.LaunchTemplateVersion != "" {
 		return m.ec2Service.getInstanceTypeByLT(asg.LaunchTemplateName, asg.LaunchTemplateVersion)
 	}
@@ -323,6 +444,55 @@ func (m *AwsManager) buildInstanceType(asg *asg) (string, error) {
 	return "", errors.New("Unable to get instance type from launch config or launch template")
 }
 
+// buildInstanceTypeFromOverrides returns the MixedInstancesPolicy override selected by
+// mixedInstancesTemplateTypeTag or, if the tag is missing, the smallest known override. Using
+// the smallest instance type guarantees that pods fitting the template fit any node of the ASG.
+func buildInstanceTypeFromOverrides(asg *asg) (string, error) {
+	for _, tag := range asg.Tags {
+		if aws.StringValue(tag.Key) != mixedInstancesTemplateTypeTag {
+			continue
+		}
+		configured := strings.TrimSpace(aws.StringValue(tag.Value))
+		for _, override := range asg.InstanceTypeOverrides {
+			if override == configured {
+				return configured, nil
+			}
+		}
+		return "", fmt.Errorf("instance type %q set by %s tag of ASG %q is not one of its overrides %v",
+			configured, mixedInstancesTemplateTypeTag, asg.Name, asg.InstanceTypeOverrides)
+	}
+
+	var smallest *instanceType
+	for _, override := range asg.InstanceTypeOverrides {
+		t, found := InstanceTypes[override]
+		if !found {
+			klog.Warningf("ASG %q overrides the unknown EC2 instance type %q; ignoring it", asg.Name, override)
+			continue
+		}
+		if smallest == nil || isSmallerInstanceType(t, smallest) {
+			smallest = t
+		}
+	}
+	if smallest == nil {
+		return "", fmt.Errorf("ASG %q overrides only unknown EC2 instance types %v", asg.Name, asg.InstanceTypeOverrides)
+	}
+	return smallest.InstanceType, nil
+}
+
+// isSmallerInstanceType orders instance types by cpu, memory and gpu, using names to break ties.
+func isSmallerInstanceType(a, b *instanceType) bool {
+	if a.VCPU != b.VCPU {
+		return a.VCPU < b.VCPU
+	}
+	if a.MemoryMb != b.MemoryMb {
+		return a.MemoryMb < b.MemoryMb
+	}
+	if a.GPU != b.GPU {
+		return a.GPU < b.GPU
+	}
+	return a.InstanceType < b.InstanceType
+}
+
 func (m *AwsManager) buildNodeFromTemplate(asg *asg, template *asgTemplate) (*apiv1.Node, error) {
 	node := apiv1.Node{}
 	nodeName := fmt.Sprintf("%s-asg-%d", asg.Name, rand.Int63())
@@ -394,6 +564,21 @@ func extractLabelsFromAsg(tags []*autoscaling.TagDescription) map[string]string
 	return result
 }
 
+func extractAutoscalingOptionsFromTags(tags []*autoscaling.TagDescription) map[string]string {
+	options := make(map[string]string)
+	for _, tag := range tags {
+		if !strings.HasPrefix(aws.StringValue(tag.Key), autoscalingOptionsTagPrefix) {
+			continue
+		}
+		splits := strings.Split(aws.StringValue(tag.Key), autoscalingOptionsTagPrefix)
+		if len(splits) != 2 || splits[1] == "" {
+			continue
+		}
+		options[splits[1]] = aws.StringValue(tag.Value)
+	}
+	return options
+}
+
 func extractAllocatableResourcesFromAsg(tags []*autoscaling.TagDescription) map[string]*resource.Quantity {
 	result := make(map[string]*resource.Quantity)
 
@@ -26,6 +26,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
@@ -35,6 +36,7 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
 	provider_aws "k8s.io/kubernetes/pkg/cloudprovider/providers/aws"
 	kubeletapis "k8s.io/kubernetes/pkg/kubelet/apis"
 )
@@ -109,6 +111,79 @@ func TestExtractAllocatableResourcesFromAsg(t *testing.T) {
 	assert.Equal(t, (&expectedEphemeralStorage).String(), labels["ephemeral-storage"].String())
 }
 
+func TestGetAsgOptions(t *testing.T) {
+	defaultOptions := config.NodeGroupAutoscalingOptions{
+		ScaleDownUtilizationThreshold:    0.1,
+		ScaleDownGpuUtilizationThreshold: 0.2,
+		ScaleDownUnneededTime:            time.Second,
+		ScaleDownUnreadyTime:             time.Minute,
+	}
+
+	tests := []struct {
+		description string
+		tags        map[string]string
+		expected    *config.NodeGroupAutoscalingOptions
+	}{
+		{
+			description: "use defaults on unspecified tags",
+			tags:        make(map[string]string),
+			expected:    nil,
+		},
+		{
+			description: "keep defaults on invalid tags values",
+			tags: map[string]string{
+				"scaledownutilizationthreshold": "not-a-float",
+				"scaledownunneededtime":         "not-a-duration",
+				"ScaleDownUnreadyTime":          "",
+				"scaledowndisabled":             "not-a-bool",
+			},
+			expected: &defaultOptions,
+		},
+		{
+			description: "use provided tags and fill missing with defaults",
+			tags: map[string]string{
+				"scaledownutilizationthreshold": "0.42",
+				"scaledownunneededtime":         "1h",
+			},
+			expected: &config.NodeGroupAutoscalingOptions{
+				ScaleDownUtilizationThreshold:    0.42,
+				ScaleDownGpuUtilizationThreshold: defaultOptions.ScaleDownGpuUtilizationThreshold,
+				ScaleDownUnneededTime:            time.Hour,
+				ScaleDownUnreadyTime:             defaultOptions.ScaleDownUnreadyTime,
+			},
+		},
+		{
+			description: "disable scale down",
+			tags: map[string]string{
+				"scaledowndisabled": "true",
+			},
+			expected: &config.NodeGroupAutoscalingOptions{
+				ScaleDownUtilizationThreshold:    defaultOptions.ScaleDownUtilizationThreshold,
+				ScaleDownGpuUtilizationThreshold: defaultOptions.ScaleDownGpuUtilizationThreshold,
+				ScaleDownUnneededTime:            defaultOptions.ScaleDownUnneededTime,
+				ScaleDownUnreadyTime:             defaultOptions.ScaleDownUnreadyTime,
+				ScaleDownDisabled:                true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			tags := make([]*autoscaling.TagDescription, 0, len(tt.tags))
+			for k, v := range tt.tags {
+				tags = append(tags, &autoscaling.TagDescription{
+					Key:   aws.String(autoscalingOptionsTagPrefix + k),
+					Value: aws.String(v),
+				})
+			}
+
+			awsManager := &AwsManager{}
+			actual := awsManager.GetAsgOptions(asg{Tags: tags}, defaultOptions)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
 func TestExtractLabelsFromAsg(t *testing.T) {
 	tags := []*autoscaling.TagDescription{
 		{
@@ -268,6 +343,77 @@ func TestBuildInstanceType(t *testing.T) {
 	assert.Equal(t, instanceType, builtInstanceType)
 }
 
+func TestBuildInstanceTypeFromOverrides(t *testing.T) {
+	overrides := []string{"r5.2xlarge", "r5d.2xlarge", "i3.2xlarge", "r5a.2xlarge", "nonexistent.2xlarge"}
+	templateTypeTag := func(instanceType string) []*autoscaling.TagDescription {
+		return []*autoscaling.TagDescription{
+			{
+				Key:   aws.String(mixedInstancesTemplateTypeTag),
+				Value: aws.String(instanceType),
+			},
+		}
+	}
+
+	tests := []struct {
+		description  string
+		overrides    []string
+		tags         []*autoscaling.TagDescription
+		instanceType string
+		error        bool
+	}{
+		{"smallest override",
+			overrides, nil, "i3.2xlarge", false},
+		{"configured override",
+			overrides, templateTypeTag("r5d.2xlarge"), "r5d.2xlarge", false},
+		{"configured type is not an override",
+			overrides, templateTypeTag("m5.large"), "", true},
+		{"only unknown overrides",
+			[]string{"nonexistent.2xlarge", "nonexistent.4xlarge"}, nil, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			m := &AwsManager{}
+			asg := &asg{
+				AwsRef:                AwsRef{Name: "sample"},
+				LaunchTemplateName:    "launcher",
+				LaunchTemplateVersion: "1",
+				InstanceTypeOverrides: test.overrides,
+				Tags:                  test.tags,
+			}
+
+			instanceType, err := m.buildInstanceType(asg)
+			if test.error {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.instanceType, instanceType)
+			}
+		})
+	}
+}
+
+func TestGetASGTemplateForMixedInstancesPolicy(t *testing.T) {
+	m := &AwsManager{}
+	asg := &asg{
+		AwsRef:                AwsRef{Name: "sample"},
+		AvailabilityZones:     []string{"us-east-1a"},
+		LaunchTemplateName:    "launcher",
+		LaunchTemplateVersion: "1",
+		InstanceTypeOverrides: []string{"m5.xlarge", "m5.large", "m5a.large"},
+	}
+
+	template, err := m.getAsgTemplate(asg)
+	assert.NoError(t, err)
+	assert.Equal(t, "m5.large", template.InstanceType.InstanceType)
+
+	node, err := m.buildNodeFromTemplate(asg, template)
+	assert.NoError(t, err)
+	cpu := node.Status.Capacity[apiv1.ResourceCPU]
+	assert.Equal(t, int64(2), cpu.Value())
+	assert.Equal(t, "m5.large", node.Labels[apiv1.LabelInstanceType])
+}
+
 func TestGetASGTemplate(t *testing.T) {
 	const (
 		knownInstanceType = "t3.micro"
@@ -610,7 +756,7 @@ func TestOverridesActiveConfig(t *testing.T) {
 		t.Logf("Running test case %s", test.name)
 		cfg, err := readAWSCloudConfig(test.reader)
 		if err == nil {
-			err = validateOverrides(cfg)
+			err = validateOverrides(&cfg.CloudConfig)
 		}
 		if test.expectError {
 			if err == nil {
@@ -662,7 +808,7 @@ func TestOverridesActiveConfig(t *testing.T) {
 								sd.signingName, found.SigningName, test.name)
 						}
 
-						fn := getResolver(cfg)
+						fn := getResolver(&cfg.CloudConfig)
 						ep1, e := fn(sd.name, sd.region, nil)
 						if e != nil {
 							t.Errorf("Expected a valid endpoint for %s in case %s",
@@ -689,6 +835,28 @@ func TestOverridesActiveConfig(t *testing.T) {
 	}
 }
 
+func TestReadAWSCloudConfigWithPricing(t *testing.T) {
+	cfg, err := readAWSCloudConfig(strings.NewReader(`
+[Global]
+Zone = us-east-1a
+[ServiceOverride "1"]
+Service = s3
+Region = us-east-1
+URL = https://s3.foo.bar
+SigningRegion = us-east-1
+[Pricing]
+File = /etc/cluster-autoscaler/ec2-prices.json
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, "us-east-1a", cfg.Global.Zone)
+	assert.Equal(t, 1, len(cfg.ServiceOverride))
+	assert.Equal(t, "/etc/cluster-autoscaler/ec2-prices.json", cfg.Pricing.File)
+
+	// Unknown options are still rejected.
+	_, err = readAWSCloudConfig(strings.NewReader("[Pricing]\nPath = /etc/prices.json\n"))
+	assert.Error(t, err)
+}
+
 func tagsMatcher(expected *autoscaling.DescribeTagsInput) func(*autoscaling.DescribeTagsInput) bool {
 	return func(actual *autoscaling.DescribeTagsInput) bool {
 		expectedTags := flatTagSlice(expected.Filters)
@@ -0,0 +1,49 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pricing"
+)
+
+// AwsPriceModel implements PriceModel interface for AWS.
+type AwsPriceModel struct {
+	*pricing.InstancePriceModel
+}
+
+var (
+	// resourcePrices are used for pods and for nodes of instance types missing from the price table.
+	// Derived from us-east-1 on-demand prices of c5.large and r5.large (cpu, memory) and p2.xlarge (gpu).
+	resourcePrices = pricing.ResourcePrices{
+		CpuPerHour:         0.035667,
+		MemoryPerGbPerHour: 0.003417,
+		GpuPerHour:         0.549,
+	}
+)
+
+// defaultPricingRegion is used when a region has no prices in the price table. Ratios between
+// instance prices are similar in all regions, which is what matters for comparing node groups.
+const defaultPricingRegion = "us-east-1"
+
+// NewAwsPriceModel builds a price model for nodes running in defaultRegion, unless they are labeled
+// with a different one. Prices from InstancePrices are overridden by staticPrices, which may be nil.
+func NewAwsPriceModel(defaultRegion string, staticPrices pricing.InstancePrices) *AwsPriceModel {
+	return &AwsPriceModel{
+		InstancePriceModel: pricing.NewInstancePriceModel(resourcePrices,
+			[]string{defaultRegion, defaultPricingRegion}, InstancePrices, staticPrices),
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pricing"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/units"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func buildPricedNode(name, instanceType, region string, millicpu int64, mem int64) *apiv1.Node {
+	node := BuildTestNode(name, millicpu, mem)
+	node.Labels = map[string]string{
+		apiv1.LabelInstanceType: instanceType,
+		apiv1.LabelZoneRegion:   region,
+	}
+	return node
+}
+
+func TestGetNodePrice(t *testing.T) {
+	model := NewAwsPriceModel("us-east-1", nil)
+	now := time.Now()
+
+	// known instance type
+	node1 := buildPricedNode("node1", "m5.2xlarge", "us-east-1", 8000, 32*units.GiB)
+	price1, err := model.NodePrice(node1, now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.InDelta(t, InstancePrices["us-east-1"]["m5.2xlarge"], price1, 0.0001)
+
+	// unknown instance type of the same shape is priced per resource, similarly to the known one
+	node2 := buildPricedNode("node2", "unknown.2xlarge", "us-east-1", 8000, 32*units.GiB)
+	price2, err := model.NodePrice(node2, now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, price2 > 0.8*price1)
+	assert.True(t, price2 < 1.2*price1)
+
+	// region without prices falls back to the default region
+	node3 := buildPricedNode("node3", "m5.2xlarge", "ap-east-1", 8000, 32*units.GiB)
+	price3, err := model.NodePrice(node3, now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.InDelta(t, price1, price3, 0.0001)
+
+	// gpu instance types are way more expensive
+	node4 := buildPricedNode("node4", "p3.2xlarge", "us-east-1", 8000, 61*units.GiB)
+	node4.Status.Capacity[gpu.ResourceNvidiaGPU] = *resource.NewQuantity(1, resource.DecimalSI)
+	price4, err := model.NodePrice(node4, now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.InDelta(t, InstancePrices["us-east-1"]["p3.2xlarge"], price4, 0.0001)
+	assert.True(t, price4 > 2*price1)
+}
+
+func TestGetNodePriceWithStaticPrices(t *testing.T) {
+	staticPrices := pricing.InstancePrices{
+		"us-east-1": {"m5.2xlarge": 0.5},
+		"eu-west-1": {"m5.2xlarge": 0.428, "custom.large": 0.1},
+	}
+	model := NewAwsPriceModel("eu-west-1", staticPrices)
+	now := time.Now()
+
+	for _, tc := range []struct {
+		instanceType string
+		region       string
+		expected     float64
+	}{
+		{"m5.2xlarge", "us-east-1", 0.5},
+		{"m5.2xlarge", "eu-west-1", 0.428},
+		{"m5.2xlarge", "", 0.428},
+		{"custom.large", "us-east-1", 0.1},
+		{"c5.large", "eu-west-1", InstancePrices["us-east-1"]["c5.large"]},
+	} {
+		node := buildPricedNode("node", tc.instanceType, tc.region, 2000, 4*units.GiB)
+		price, err := model.NodePrice(node, now, now.Add(time.Hour))
+		assert.NoError(t, err)
+		assert.InDelta(t, tc.expected, price, 0.0001, "%s in %q", tc.instanceType, tc.region)
+	}
+
+	// bundled prices are not modified
+	assert.NotEqual(t, 0.5, InstancePrices["us-east-1"]["m5.2xlarge"])
+}
@@ -25,6 +25,7 @@ import (
 
 type ec2I interface {
 	DescribeLaunchTemplateVersions(input *ec2.DescribeLaunchTemplateVersionsInput) (*ec2.DescribeLaunchTemplateVersionsOutput, error)
+	DescribeSubnets(input *ec2.DescribeSubnetsInput) (*ec2.DescribeSubnetsOutput, error)
 }
 
 type ec2Wrapper struct {
@@ -55,3 +56,30 @@ func (m ec2Wrapper) getInstanceTypeByLT(name string, version string) (string, er
 
 	return aws.StringValue(instanceType), nil
 }
+
+// getSubnetZones returns the availability zones of the given subnets, in the same order.
+func (m ec2Wrapper) getSubnetZones(subnetIds []string) ([]string, error) {
+	params := &ec2.DescribeSubnetsInput{
+		SubnetIds: aws.StringSlice(subnetIds),
+	}
+
+	describeData, err := m.DescribeSubnets(params)
+	if err != nil {
+		return nil, err
+	}
+
+	subnetZones := make(map[string]string)
+	for _, subnet := range describeData.Subnets {
+		subnetZones[aws.StringValue(subnet.SubnetId)] = aws.StringValue(subnet.AvailabilityZone)
+	}
+
+	zones := make([]string, 0, len(subnetIds))
+	for _, id := range subnetIds {
+		zone, found := subnetZones[id]
+		if !found {
+			return nil, fmt.Errorf("unable to find subnet %s", id)
+		}
+		zones = append(zones, zone)
+	}
+	return zones, nil
+}
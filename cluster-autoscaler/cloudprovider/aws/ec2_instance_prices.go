@@ -0,0 +1,398 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file holds a partial snapshot of the table written by ec2_instance_types/gen.go. It only covers
+// common instance types in us-east-1, us-east-2 and us-west-2. Running go generate replaces it with the
+// full table; until then, see AwsPriceModel for how other regions and instance types are priced.
+
+package aws
+
+// InstancePrices is a map of on-demand hourly prices (in USD) of Linux ec2
+// instances with shared tenancy, keyed by region and instance type.
+var InstancePrices = map[string]map[string]float64{
+	"us-east-1": {
+		"a1.2xlarge":    0.204,
+		"a1.4xlarge":    0.408,
+		"a1.large":      0.051,
+		"a1.medium":     0.0255,
+		"a1.xlarge":     0.102,
+		"c4.2xlarge":    0.398,
+		"c4.4xlarge":    0.796,
+		"c4.8xlarge":    1.591,
+		"c4.large":      0.1,
+		"c4.xlarge":     0.199,
+		"c5.18xlarge":   3.06,
+		"c5.2xlarge":    0.34,
+		"c5.4xlarge":    0.68,
+		"c5.9xlarge":    1.53,
+		"c5.large":      0.085,
+		"c5.xlarge":     0.17,
+		"c5d.18xlarge":  3.456,
+		"c5d.2xlarge":   0.384,
+		"c5d.4xlarge":   0.768,
+		"c5d.9xlarge":   1.728,
+		"c5d.large":     0.096,
+		"c5d.xlarge":    0.192,
+		"c5n.18xlarge":  3.888,
+		"c5n.2xlarge":   0.432,
+		"c5n.4xlarge":   0.864,
+		"c5n.9xlarge":   1.944,
+		"c5n.large":     0.108,
+		"c5n.xlarge":    0.216,
+		"g3.16xlarge":   4.56,
+		"g3.4xlarge":    1.14,
+		"g3.8xlarge":    2.28,
+		"g3s.xlarge":    0.75,
+		"i3.16xlarge":   4.992,
+		"i3.2xlarge":    0.624,
+		"i3.4xlarge":    1.248,
+		"i3.8xlarge":    2.496,
+		"i3.large":      0.156,
+		"i3.xlarge":     0.312,
+		"m4.10xlarge":   2.0,
+		"m4.16xlarge":   3.2,
+		"m4.2xlarge":    0.4,
+		"m4.4xlarge":    0.8,
+		"m4.large":      0.1,
+		"m4.xlarge":     0.2,
+		"m5.12xlarge":   2.304,
+		"m5.24xlarge":   4.608,
+		"m5.2xlarge":    0.384,
+		"m5.4xlarge":    0.768,
+		"m5.large":      0.096,
+		"m5.xlarge":     0.192,
+		"m5a.12xlarge":  2.064,
+		"m5a.24xlarge":  4.128,
+		"m5a.2xlarge":   0.344,
+		"m5a.4xlarge":   0.688,
+		"m5a.large":     0.086,
+		"m5a.xlarge":    0.172,
+		"m5d.12xlarge":  2.712,
+		"m5d.24xlarge":  5.424,
+		"m5d.2xlarge":   0.452,
+		"m5d.4xlarge":   0.904,
+		"m5d.large":     0.113,
+		"m5d.xlarge":    0.226,
+		"p2.16xlarge":   14.4,
+		"p2.8xlarge":    7.2,
+		"p2.xlarge":     0.9,
+		"p3.16xlarge":   24.48,
+		"p3.2xlarge":    3.06,
+		"p3.8xlarge":    12.24,
+		"p3dn.24xlarge": 31.212,
+		"r4.16xlarge":   4.256,
+		"r4.2xlarge":    0.532,
+		"r4.4xlarge":    1.064,
+		"r4.8xlarge":    2.128,
+		"r4.large":      0.133,
+		"r4.xlarge":     0.266,
+		"r5.12xlarge":   3.024,
+		"r5.24xlarge":   6.048,
+		"r5.2xlarge":    0.504,
+		"r5.4xlarge":    1.008,
+		"r5.large":      0.126,
+		"r5.xlarge":     0.252,
+		"r5a.12xlarge":  2.712,
+		"r5a.24xlarge":  5.424,
+		"r5a.2xlarge":   0.452,
+		"r5a.4xlarge":   0.904,
+		"r5a.large":     0.113,
+		"r5a.xlarge":    0.226,
+		"r5d.12xlarge":  3.456,
+		"r5d.24xlarge":  6.912,
+		"r5d.2xlarge":   0.576,
+		"r5d.4xlarge":   1.152,
+		"r5d.large":     0.144,
+		"r5d.xlarge":    0.288,
+		"t2.2xlarge":    0.3712,
+		"t2.large":      0.0928,
+		"t2.medium":     0.0464,
+		"t2.micro":      0.0116,
+		"t2.nano":       0.0058,
+		"t2.small":      0.023,
+		"t2.xlarge":     0.1856,
+		"t3.2xlarge":    0.3328,
+		"t3.large":      0.0832,
+		"t3.medium":     0.0416,
+		"t3.micro":      0.0104,
+		"t3.nano":       0.0052,
+		"t3.small":      0.0208,
+		"t3.xlarge":     0.1664,
+		"t3a.2xlarge":   0.3008,
+		"t3a.large":     0.0752,
+		"t3a.medium":    0.0376,
+		"t3a.micro":     0.0094,
+		"t3a.nano":      0.0047,
+		"t3a.small":     0.0188,
+		"t3a.xlarge":    0.1504,
+		"x1.16xlarge":   6.669,
+		"x1.32xlarge":   13.338,
+		"z1d.12xlarge":  4.464,
+		"z1d.2xlarge":   0.744,
+		"z1d.3xlarge":   1.116,
+		"z1d.6xlarge":   2.232,
+		"z1d.large":     0.186,
+		"z1d.xlarge":    0.372,
+	},
+	"us-east-2": {
+		"a1.2xlarge":    0.204,
+		"a1.4xlarge":    0.408,
+		"a1.large":      0.051,
+		"a1.medium":     0.0255,
+		"a1.xlarge":     0.102,
+		"c4.2xlarge":    0.398,
+		"c4.4xlarge":    0.796,
+		"c4.8xlarge":    1.591,
+		"c4.large":      0.1,
+		"c4.xlarge":     0.199,
+		"c5.18xlarge":   3.06,
+		"c5.2xlarge":    0.34,
+		"c5.4xlarge":    0.68,
+		"c5.9xlarge":    1.53,
+		"c5.large":      0.085,
+		"c5.xlarge":     0.17,
+		"c5d.18xlarge":  3.456,
+		"c5d.2xlarge":   0.384,
+		"c5d.4xlarge":   0.768,
+		"c5d.9xlarge":   1.728,
+		"c5d.large":     0.096,
+		"c5d.xlarge":    0.192,
+		"c5n.18xlarge":  3.888,
+		"c5n.2xlarge":   0.432,
+		"c5n.4xlarge":   0.864,
+		"c5n.9xlarge":   1.944,
+		"c5n.large":     0.108,
+		"c5n.xlarge":    0.216,
+		"g3.16xlarge":   4.56,
+		"g3.4xlarge":    1.14,
+		"g3.8xlarge":    2.28,
+		"g3s.xlarge":    0.75,
+		"i3.16xlarge":   4.992,
+		"i3.2xlarge":    0.624,
+		"i3.4xlarge":    1.248,
+		"i3.8xlarge":    2.496,
+		"i3.large":      0.156,
+		"i3.xlarge":     0.312,
+		"m4.10xlarge":   2.0,
+		"m4.16xlarge":   3.2,
+		"m4.2xlarge":    0.4,
+		"m4.4xlarge":    0.8,
+		"m4.large":      0.1,
+		"m4.xlarge":     0.2,
+		"m5.12xlarge":   2.304,
+		"m5.24xlarge":   4.608,
+		"m5.2xlarge":    0.384,
+		"m5.4xlarge":    0.768,
+		"m5.large":      0.096,
+		"m5.xlarge":     0.192,
+		"m5a.12xlarge":  2.064,
+		"m5a.24xlarge":  4.128,
+		"m5a.2xlarge":   0.344,
+		"m5a.4xlarge":   0.688,
+		"m5a.large":     0.086,
+		"m5a.xlarge":    0.172,
+		"m5d.12xlarge":  2.712,
+		"m5d.24xlarge":  5.424,
+		"m5d.2xlarge":   0.452,
+		"m5d.4xlarge":   0.904,
+		"m5d.large":     0.113,
+		"m5d.xlarge":    0.226,
+		"p2.16xlarge":   14.4,
+		"p2.8xlarge":    7.2,
+		"p2.xlarge":     0.9,
+		"p3.16xlarge":   24.48,
+		"p3.2xlarge":    3.06,
+		"p3.8xlarge":    12.24,
+		"p3dn.24xlarge": 31.212,
+		"r4.16xlarge":   4.256,
+		"r4.2xlarge":    0.532,
+		"r4.4xlarge":    1.064,
+		"r4.8xlarge":    2.128,
+		"r4.large":      0.133,
+		"r4.xlarge":     0.266,
+		"r5.12xlarge":   3.024,
+		"r5.24xlarge":   6.048,
+		"r5.2xlarge":    0.504,
+		"r5.4xlarge":    1.008,
+		"r5.large":      0.126,
+		"r5.xlarge":     0.252,
+		"r5a.12xlarge":  2.712,
+		"r5a.24xlarge":  5.424,
+		"r5a.2xlarge":   0.452,
+		"r5a.4xlarge":   0.904,
+		"r5a.large":     0.113,
+		"r5a.xlarge":    0.226,
+		"r5d.12xlarge":  3.456,
+		"r5d.24xlarge":  6.912,
+		"r5d.2xlarge":   0.576,
+		"r5d.4xlarge":   1.152,
+		"r5d.large":     0.144,
+		"r5d.xlarge":    0.288,
+		"t2.2xlarge":    0.3712,
+		"t2.large":      0.0928,
+		"t2.medium":     0.0464,
+		"t2.micro":      0.0116,
+		"t2.nano":       0.0058,
+		"t2.small":      0.023,
+		"t2.xlarge":     0.1856,
+		"t3.2xlarge":    0.3328,
+		"t3.large":      0.0832,
+		"t3.medium":     0.0416,
+		"t3.micro":      0.0104,
+		"t3.nano":       0.0052,
+		"t3.small":      0.0208,
+		"t3.xlarge":     0.1664,
+		"t3a.2xlarge":   0.3008,
+		"t3a.large":     0.0752,
+		"t3a.medium":    0.0376,
+		"t3a.micro":     0.0094,
+		"t3a.nano":      0.0047,
+		"t3a.small":     0.0188,
+		"t3a.xlarge":    0.1504,
+		"x1.16xlarge":   6.669,
+		"x1.32xlarge":   13.338,
+		"z1d.12xlarge":  4.464,
+		"z1d.2xlarge":   0.744,
+		"z1d.3xlarge":   1.116,
+		"z1d.6xlarge":   2.232,
+		"z1d.large":     0.186,
+		"z1d.xlarge":    0.372,
+	},
+	"us-west-2": {
+		"a1.2xlarge":    0.204,
+		"a1.4xlarge":    0.408,
+		"a1.large":      0.051,
+		"a1.medium":     0.0255,
+		"a1.xlarge":     0.102,
+		"c4.2xlarge":    0.398,
+		"c4.4xlarge":    0.796,
+		"c4.8xlarge":    1.591,
+		"c4.large":      0.1,
+		"c4.xlarge":     0.199,
+		"c5.18xlarge":   3.06,
+		"c5.2xlarge":    0.34,
+		"c5.4xlarge":    0.68,
+		"c5.9xlarge":    1.53,
+		"c5.large":      0.085,
+		"c5.xlarge":     0.17,
+		"c5d.18xlarge":  3.456,
+		"c5d.2xlarge":   0.384,
+		"c5d.4xlarge":   0.768,
+		"c5d.9xlarge":   1.728,
+		"c5d.large":     0.096,
+		"c5d.xlarge":    0.192,
+		"c5n.18xlarge":  3.888,
+		"c5n.2xlarge":   0.432,
+		"c5n.4xlarge":   0.864,
+		"c5n.9xlarge":   1.944,
+		"c5n.large":     0.108,
+		"c5n.xlarge":    0.216,
+		"g3.16xlarge":   4.56,
+		"g3.4xlarge":    1.14,
+		"g3.8xlarge":    2.28,
+		"g3s.xlarge":    0.75,
+		"i3.16xlarge":   4.992,
+		"i3.2xlarge":    0.624,
+		"i3.4xlarge":    1.248,
+		"i3.8xlarge":    2.496,
+		"i3.large":      0.156,
+		"i3.xlarge":     0.312,
+		"m4.10xlarge":   2.0,
+		"m4.16xlarge":   3.2,
+		"m4.2xlarge":    0.4,
+		"m4.4xlarge":    0.8,
+		"m4.large":      0.1,
+		"m4.xlarge":     0.2,
+		"m5.12xlarge":   2.304,
+		"m5.24xlarge":   4.608,
+		"m5.2xlarge":    0.384,
+		"m5.4xlarge":    0.768,
+		"m5.large":      0.096,
+		"m5.xlarge":     0.192,
+		"m5a.12xlarge":  2.064,
+		"m5a.24xlarge":  4.128,
+		"m5a.2xlarge":   0.344,
+		"m5a.4xlarge":   0.688,
+		"m5a.large":     0.086,
+		"m5a.xlarge":    0.172,
+		"m5d.12xlarge":  2.712,
+		"m5d.24xlarge":  5.424,
+		"m5d.2xlarge":   0.452,
+		"m5d.4xlarge":   0.904,
+		"m5d.large":     0.113,
+		"m5d.xlarge":    0.226,
+		"p2.16xlarge":   14.4,
+		"p2.8xlarge":    7.2,
+		"p2.xlarge":     0.9,
+		"p3.16xlarge":   24.48,
+		"p3.2xlarge":    3.06,
+		"p3.8xlarge":    12.24,
+		"p3dn.24xlarge": 31.212,
+		"r4.16xlarge":   4.256,
+		"r4.2xlarge":    0.532,
+		"r4.4xlarge":    1.064,
+		"r4.8xlarge":    2.128,
+		"r4.large":      0.133,
+		"r4.xlarge":     0.266,
+		"r5.12xlarge":   3.024,
+		"r5.24xlarge":   6.048,
+		"r5.2xlarge":    0.504,
+		"r5.4xlarge":    1.008,
+		"r5.large":      0.126,
+		"r5.xlarge":     0.252,
+		"r5a.12xlarge":  2.712,
+		"r5a.24xlarge":  5.424,
+		"r5a.2xlarge":   0.452,
+		"r5a.4xlarge":   0.904,
+		"r5a.large":     0.113,
+		"r5a.xlarge":    0.226,
+		"r5d.12xlarge":  3.456,
+		"r5d.24xlarge":  6.912,
+		"r5d.2xlarge":   0.576,
+		"r5d.4xlarge":   1.152,
+		"r5d.large":     0.144,
+		"r5d.xlarge":    0.288,
+		"t2.2xlarge":    0.3712,
+		"t2.large":      0.0928,
+		"t2.medium":     0.0464,
+		"t2.micro":      0.0116,
+		"t2.nano":       0.0058,
+		"t2.small":      0.023,
+		"t2.xlarge":     0.1856,
+		"t3.2xlarge":    0.3328,
+		"t3.large":      0.0832,
+		"t3.medium":     0.0416,
+		"t3.micro":      0.0104,
+		"t3.nano":       0.0052,
+		"t3.small":      0.0208,
+		"t3.xlarge":     0.1664,
+		"t3a.2xlarge":   0.3008,
+		"t3a.large":     0.0752,
+		"t3a.medium":    0.0376,
+		"t3a.micro":     0.0094,
+		"t3a.nano":      0.0047,
+		"t3a.small":     0.0188,
+		"t3a.xlarge":    0.1504,
+		"x1.16xlarge":   6.669,
+		"x1.32xlarge":   13.338,
+		"z1d.12xlarge":  4.464,
+		"z1d.2xlarge":   0.744,
+		"z1d.3xlarge":   1.116,
+		"z1d.6xlarge":   2.232,
+		"z1d.large":     0.186,
+		"z1d.xlarge":    0.372,
+	},
+}
@@ -35,6 +35,7 @@ import (
 
 type response struct {
 	Products map[string]product `json:"products"`
+	Terms    terms              `json:"terms"`
 }
 
 type product struct {
@@ -42,10 +43,27 @@ type product struct {
 }
 
 type productAttributes struct {
-	InstanceType string `json:"instanceType"`
-	VCPU         string `json:"vcpu"`
-	Memory       string `json:"memory"`
-	GPU          string `json:"gpu"`
+	InstanceType    string `json:"instanceType"`
+	VCPU            string `json:"vcpu"`
+	Memory          string `json:"memory"`
+	GPU             string `json:"gpu"`
+	OperatingSystem string `json:"operatingSystem"`
+	Tenancy         string `json:"tenancy"`
+	PreInstalledSw  string `json:"preInstalledSw"`
+	CapacityStatus  string `json:"capacitystatus"`
+}
+
+type terms struct {
+	OnDemand map[string]map[string]term `json:"OnDemand"`
+}
+
+type term struct {
+	PriceDimensions map[string]priceDimension `json:"priceDimensions"`
+}
+
+type priceDimension struct {
+	Unit         string            `json:"unit"`
+	PricePerUnit map[string]string `json:"pricePerUnit"`
 }
 
 type instanceType struct {
@@ -95,11 +113,45 @@ var InstanceTypes = map[string]*instanceType{
 }
 `))
 
+var pricesTemplate = template.Must(template.New("").Parse(`/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file was generated by go generate; DO NOT EDIT
+
+package aws
+
+// InstancePrices is a map of on-demand hourly prices (in USD) of Linux ec2
+// instances with shared tenancy, keyed by region and instance type.
+var InstancePrices = map[string]map[string]float64{
+{{- range $region, $prices := .InstancePrices }}
+	"{{ $region }}": {
+{{- range $instanceType, $price := $prices }}
+		"{{ $instanceType }}": {{ $price }},
+{{- end }}
+	},
+{{- end }}
+}
+`))
+
 func main() {
 	flag.Parse()
 	defer klog.Flush()
 
 	instanceTypes := make(map[string]*instanceType)
+	instancePrices := make(map[string]map[string]float64)
 
 	resolver := endpoints.DefaultResolver()
 	partitions := resolver.(endpoints.EnumPartitions).Partitions()
@@ -129,7 +181,7 @@ func main() {
 				continue
 			}
 
-			for _, product := range unmarshalled.Products {
+			for sku, product := range unmarshalled.Products {
 				attr := product.Attributes
 				if attr.InstanceType != "" {
 					instanceTypes[attr.InstanceType] = &instanceType{
@@ -144,6 +196,14 @@ func main() {
 					if attr.GPU != "" {
 						instanceTypes[attr.InstanceType].GPU = parseCPU(attr.GPU)
 					}
+					if isOnDemandLinuxShared(attr) {
+						if price, found := onDemandPrice(unmarshalled.Terms.OnDemand[sku]); found {
+							if instancePrices[r.ID()] == nil {
+								instancePrices[r.ID()] = make(map[string]float64)
+							}
+							instancePrices[r.ID()][attr.InstanceType] = price
+						}
+					}
 				}
 			}
 		}
@@ -165,6 +225,53 @@ func main() {
 	if err != nil {
 		klog.Fatal(err)
 	}
+
+	pf, err := os.Create("ec2_instance_prices.go")
+	if err != nil {
+		klog.Fatal(err)
+	}
+
+	defer pf.Close()
+
+	err = pricesTemplate.Execute(pf, struct {
+		InstancePrices map[string]map[string]float64
+	}{
+		InstancePrices: instancePrices,
+	})
+
+	if err != nil {
+		klog.Fatal(err)
+	}
+}
+
+// isOnDemandLinuxShared returns true for products that describe plain Linux
+// instances with shared tenancy, which is what the price model assumes.
+func isOnDemandLinuxShared(attr productAttributes) bool {
+	return attr.OperatingSystem == "Linux" &&
+		attr.Tenancy == "Shared" &&
+		attr.PreInstalledSw == "NA" &&
+		(attr.CapacityStatus == "" || attr.CapacityStatus == "Used")
+}
+
+// onDemandPrice extracts the hourly USD price from on-demand terms of a product.
+func onDemandPrice(offers map[string]term) (float64, bool) {
+	for _, offer := range offers {
+		for _, dimension := range offer.PriceDimensions {
+			if dimension.Unit != "Hrs" {
+				continue
+			}
+			usd, found := dimension.PricePerUnit["USD"]
+			if !found {
+				continue
+			}
+			price, err := strconv.ParseFloat(usd, 64)
+			if err != nil || price == 0 {
+				continue
+			}
+			return price, true
+		}
+	}
+	return 0, false
 }
 
 func parseMemory(memory string) int64 {
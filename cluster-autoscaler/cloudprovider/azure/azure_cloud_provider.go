@@ -27,6 +27,7 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pricing"
 )
 
 const (
@@ -48,13 +49,24 @@ var (
 // AzureCloudProvider provides implementation of CloudProvider interface for Azure.
 type AzureCloudProvider struct {
 	azureManager    *AzureManager
+	pricingModel    *AzurePriceModel
 	resourceLimiter *cloudprovider.ResourceLimiter
 }
 
 // BuildAzureCloudProvider creates new AzureCloudProvider
 func BuildAzureCloudProvider(azureManager *AzureManager, resourceLimiter *cloudprovider.ResourceLimiter) (cloudprovider.CloudProvider, error) {
+	var pricingModel *AzurePriceModel
+	if azureManager.config != nil && azureManager.config.PricingFile != "" {
+		staticPrices, err := pricing.ReadStaticPricesFile(azureManager.config.PricingFile)
+		if err != nil {
+			return nil, err
+		}
+		pricingModel = NewAzurePriceModel(staticPrices)
+	}
+
 	azure := &AzureCloudProvider{
 		azureManager:    azureManager,
+		pricingModel:    pricingModel,
 		resourceLimiter: resourceLimiter,
 	}
 
@@ -105,7 +117,11 @@ func (azure *AzureCloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovid
 
 // Pricing returns pricing model for this cloud provider or error if not available.
 func (azure *AzureCloudProvider) Pricing() (cloudprovider.PricingModel, errors.AutoscalerError) {
-	return nil, cloudprovider.ErrNotImplemented
+	// There are no bundled Azure prices, pricing requires a pricing file.
+	if azure.pricingModel == nil {
+		return nil, cloudprovider.ErrNotImplemented
+	}
+	return azure.pricingModel, nil
 }
 
 // GetAvailableMachineTypes get all machine types that can be requested from the cloud provider.
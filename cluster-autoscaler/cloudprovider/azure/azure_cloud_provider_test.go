@@ -17,6 +17,8 @@ limitations under the License.
 package azure
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
@@ -81,11 +83,45 @@ func TestBuildAzureCloudProvider(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestBuildAzureCloudProviderWithPricingFile(t *testing.T) {
+	resourceLimiter := cloudprovider.NewResourceLimiter(
+		map[string]int64{cloudprovider.ResourceNameCores: 1, cloudprovider.ResourceNameMemory: 10000000},
+		map[string]int64{cloudprovider.ResourceNameCores: 10, cloudprovider.ResourceNameMemory: 100000000})
+
+	pricingFile, err := ioutil.TempFile("", "azure-prices")
+	assert.NoError(t, err)
+	defer os.Remove(pricingFile.Name())
+	_, err = pricingFile.WriteString(`{"westeurope": {"Standard_D2s_v3": 0.11}}`)
+	assert.NoError(t, err)
+	assert.NoError(t, pricingFile.Close())
+
+	m := newTestAzureManager(t)
+	m.config.PricingFile = pricingFile.Name()
+	provider, err := BuildAzureCloudProvider(m, resourceLimiter)
+	assert.NoError(t, err)
+	pricingModel, err := provider.Pricing()
+	assert.NoError(t, err)
+	price, found := pricingModel.(*AzurePriceModel).InstancePrice("westeurope", "Standard_D2s_v3")
+	assert.True(t, found)
+	assert.Equal(t, 0.11, price)
+
+	m.config.PricingFile = pricingFile.Name() + "-missing"
+	_, err = BuildAzureCloudProvider(m, resourceLimiter)
+	assert.Error(t, err)
+}
+
 func TestName(t *testing.T) {
 	provider := newTestProvider(t)
 	assert.Equal(t, provider.Name(), "azure")
 }
 
+func TestPricing(t *testing.T) {
+	// Pricing requires a pricing file.
+	provider := newTestProvider(t)
+	_, err := provider.Pricing()
+	assert.Equal(t, cloudprovider.ErrNotImplemented, err)
+}
+
 func TestNodeGroups(t *testing.T) {
 	provider := newTestProvider(t)
 	assert.Equal(t, len(provider.NodeGroups()), 0)
@@ -0,0 +1,121 @@
+// +build ignore
+
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/azure"
+	"k8s.io/klog"
+)
+
+const pricesURL = "https://prices.azure.com/api/retail/prices"
+
+var (
+	locations = flag.String("locations", "eastus,eastus2,westus2,westeurope,northeurope,southeastasia",
+		"Comma separated list of locations to fetch prices for.")
+	output = flag.String("output", "azure-prices.json", "Path of the pricing file to write.")
+)
+
+type response struct {
+	Items        []item `json:"Items"`
+	NextPageLink string `json:"NextPageLink"`
+}
+
+type item struct {
+	CurrencyCode  string  `json:"currencyCode"`
+	RetailPrice   float64 `json:"retailPrice"`
+	ArmRegionName string  `json:"armRegionName"`
+	ArmSkuName    string  `json:"armSkuName"`
+	SkuName       string  `json:"skuName"`
+	ProductName   string  `json:"productName"`
+	Type          string  `json:"type"`
+	UnitOfMeasure string  `json:"unitOfMeasure"`
+}
+
+func main() {
+	flag.Parse()
+	defer klog.Flush()
+
+	instancePrices := make(map[string]map[string]float64)
+
+	for _, location := range strings.Split(*locations, ",") {
+		filter := "serviceName eq 'Virtual Machines' and priceType eq 'Consumption' and armRegionName eq '" + location + "'"
+		next := pricesURL + "?$filter=" + url.QueryEscape(filter)
+		for next != "" {
+			klog.V(1).Infof("fetching %s\n", next)
+			res, err := http.Get(next)
+			if err != nil {
+				klog.Warningf("Error fetching %s skipping...\n", next)
+				break
+			}
+
+			var unmarshalled = response{}
+			err = json.NewDecoder(res.Body).Decode(&unmarshalled)
+			res.Body.Close()
+			if err != nil {
+				klog.Warningf("Error unmarshalling %s skipping...\n", next)
+				break
+			}
+
+			for _, item := range unmarshalled.Items {
+				if !isLinuxPayAsYouGo(item) {
+					continue
+				}
+				if _, found := azure.InstanceTypes[item.ArmSkuName]; !found {
+					continue
+				}
+				if instancePrices[item.ArmRegionName] == nil {
+					instancePrices[item.ArmRegionName] = make(map[string]float64)
+				}
+				instancePrices[item.ArmRegionName][item.ArmSkuName] = item.RetailPrice
+			}
+			next = unmarshalled.NextPageLink
+		}
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		klog.Fatal(err)
+	}
+
+	defer f.Close()
+
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "    ")
+	if err := encoder.Encode(instancePrices); err != nil {
+		klog.Fatal(err)
+	}
+}
+
+// isLinuxPayAsYouGo returns true for regular priority hourly prices of Linux virtual machines.
+func isLinuxPayAsYouGo(item item) bool {
+	return item.CurrencyCode == "USD" &&
+		item.Type == "Consumption" &&
+		item.UnitOfMeasure == "1 Hour" &&
+		item.RetailPrice > 0 &&
+		!strings.Contains(item.ProductName, "Windows") &&
+		!strings.Contains(item.SkuName, "Low Priority") &&
+		!strings.Contains(item.SkuName, "Spot")
+}
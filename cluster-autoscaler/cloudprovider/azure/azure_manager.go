@@ -79,6 +79,9 @@ type Config struct {
 	ClusterName string `json:"clusterName" yaml:"clusterName"`
 	//Config only for AKS
 	NodeResourceGroup string `json:"nodeResourceGroup" yaml:"nodeResourceGroup"`
+
+	// PricingFile is the path to a JSON file with VM prices, required for pricing nodes.
+	PricingFile string `json:"pricingFile" yaml:"pricingFile"`
 }
 
 // TrimSpace removes all leading and trailing white spaces.
@@ -95,6 +98,7 @@ func (c *Config) TrimSpace() {
 	c.Deployment = strings.TrimSpace(c.Deployment)
 	c.ClusterName = strings.TrimSpace(c.ClusterName)
 	c.NodeResourceGroup = strings.TrimSpace(c.NodeResourceGroup)
+	c.PricingFile = strings.TrimSpace(c.PricingFile)
 }
 
 // CreateAzureManager creates Azure Manager object to work with Azure.
@@ -120,6 +124,7 @@ func CreateAzureManager(configReader io.Reader, discoveryOpts cloudprovider.Node
 		cfg.Deployment = os.Getenv("ARM_DEPLOYMENT")
 		cfg.ClusterName = os.Getenv("AZURE_CLUSTER_NAME")
 		cfg.NodeResourceGroup = os.Getenv("AZURE_NODE_RESOURCE_GROUP")
+		cfg.PricingFile = os.Getenv("AZURE_PRICING_FILE")
 
 		useManagedIdentityExtensionFromEnv := os.Getenv("ARM_USE_MANAGED_IDENTITY_EXTENSION")
 		if len(useManagedIdentityExtensionFromEnv) > 0 {
@@ -0,0 +1,45 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pricing"
+)
+
+// AzurePriceModel implements PriceModel interface for Azure.
+type AzurePriceModel struct {
+	*pricing.InstancePriceModel
+}
+
+var (
+	// resourcePrices are used for pods and for nodes of VM sizes missing from the pricing file.
+	// Derived from eastus pay-as-you-go prices of Standard_D2s_v3 and Standard_E2s_v3 (cpu, memory)
+	// and Standard_NC6 (gpu).
+	resourcePrices = pricing.ResourcePrices{
+		CpuPerHour:         0.033,
+		MemoryPerGbPerHour: 0.00375,
+		GpuPerHour:         0.492,
+	}
+)
+
+// NewAzurePriceModel builds a price model using the VM prices keyed by location and VM size read
+// from the pricing file. There are no bundled prices.
+func NewAzurePriceModel(staticPrices pricing.InstancePrices) *AzurePriceModel {
+	return &AzurePriceModel{
+		InstancePriceModel: pricing.NewInstancePriceModel(resourcePrices, nil, staticPrices),
+	}
+}
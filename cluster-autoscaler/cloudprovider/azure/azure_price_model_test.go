@@ -0,0 +1,67 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/pricing"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/units"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func buildPricedNode(name, vmSize, location string, millicpu int64, mem int64) *apiv1.Node {
+	node := BuildTestNode(name, millicpu, mem)
+	node.Labels = map[string]string{
+		apiv1.LabelInstanceType: vmSize,
+		apiv1.LabelZoneRegion:   location,
+	}
+	return node
+}
+
+func TestGetNodePrice(t *testing.T) {
+	model := NewAzurePriceModel(pricing.InstancePrices{
+		"eastus":     {"Standard_D8s_v3": 0.384},
+		"WestEurope": {"Standard_D8s_v3": 0.428},
+	})
+	now := time.Now()
+
+	for _, tc := range []struct {
+		vmSize   string
+		location string
+		expected float64
+	}{
+		{"Standard_D8s_v3", "eastus", 0.384},
+		// locations are case-insensitive
+		{"Standard_D8s_v3", "westeurope", 0.428},
+		// VM sizes missing from the pricing file are priced per resource
+		{"Standard_D8s_v3", "koreacentral", 8*resourcePrices.CpuPerHour + 32*resourcePrices.MemoryPerGbPerHour},
+		{"Standard_Unknown", "eastus", 8*resourcePrices.CpuPerHour + 32*resourcePrices.MemoryPerGbPerHour},
+	} {
+		node := buildPricedNode("node", tc.vmSize, tc.location, 8000, 32*units.GiB)
+		price, err := model.NodePrice(node, now, now.Add(time.Hour))
+		assert.NoError(t, err)
+		assert.InDelta(t, tc.expected, price, 0.0001, "%s in %q", tc.vmSize, tc.location)
+	}
+
+	// unknown vm size of the same shape is priced similarly to the known one
+	assert.InDelta(t, 0.384, 8*resourcePrices.CpuPerHour+32*resourcePrices.MemoryPerGbPerHour, 0.2*0.384)
+}
@@ -1,4 +1,4 @@
-// +build !gce,!aws,!azure,!kubemark,!alicloud,!magnum
+// +build !gce,!aws,!azure,!kubemark,!alicloud,!magnum,!externalgrpc,!clusterapi,!hollownode
 
 /*
 Copyright 2018 The Kubernetes Authors.
@@ -24,7 +24,10 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/aws"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/azure"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/baiducloud"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/clusterapi"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/externalgrpc"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/gce"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/hollownode"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/magnum"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 )
@@ -37,6 +40,9 @@ var AvailableCloudProviders = []string{
 	alicloud.ProviderName,
 	baiducloud.ProviderName,
 	magnum.ProviderName,
+	externalgrpc.ProviderName,
+	clusterapi.ProviderName,
+	hollownode.ProviderName,
 }
 
 // DefaultCloudProvider is GCE.
@@ -56,6 +62,12 @@ func buildCloudProvider(opts config.AutoscalingOptions, do cloudprovider.NodeGro
 		return baiducloud.BuildBaiducloud(opts, do, rl)
 	case magnum.ProviderName:
 		return magnum.BuildMagnum(opts, do, rl)
+	case externalgrpc.ProviderName:
+		return externalgrpc.BuildExternalGrpc(opts, do, rl)
+	case clusterapi.ProviderName:
+		return clusterapi.BuildClusterAPI(opts, do, rl)
+	case hollownode.ProviderName:
+		return hollownode.BuildHollowNode(opts, do, rl)
 	}
 	return nil
 }
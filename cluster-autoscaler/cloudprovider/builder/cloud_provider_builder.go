@@ -18,6 +18,7 @@ package builder
 
 import (
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/composite"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/context"
 
@@ -42,6 +43,10 @@ func NewCloudProvider(opts config.AutoscalingOptions) cloudprovider.CloudProvide
 		return nil
 	}
 
+	if opts.CloudProviderName == composite.ProviderName {
+		return buildCompositeCloudProvider(opts, do, rl)
+	}
+
 	provider := buildCloudProvider(opts, do, rl)
 	if provider != nil {
 		return provider
@@ -50,3 +55,43 @@ func NewCloudProvider(opts config.AutoscalingOptions) cloudprovider.CloudProvide
 	klog.Fatalf("Unknown cloud provider: %s", opts.CloudProviderName)
 	return nil // This will never happen because the Fatalf will os.Exit
 }
+
+// buildCompositeCloudProvider builds every cloud provider listed in opts.CompositeCloudProviders with its own
+// cloud config and the node group specs routed to it by their "<provider>/" prefix, and combines them. The resource
+// limits apply to the nodes of all of them together.
+func buildCompositeCloudProvider(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) cloudprovider.CloudProvider {
+	if len(opts.CompositeCloudProviders) == 0 {
+		klog.Fatalf("No cloud providers to combine, please specify them via the --composite-cloud-provider flag")
+	}
+	specs := make([]composite.ProviderSpec, 0, len(opts.CompositeCloudProviders))
+	for _, value := range opts.CompositeCloudProviders {
+		spec, err := composite.ParseProviderSpec(value)
+		if err != nil {
+			klog.Fatalf("Failed to parse composite cloud provider: %v", err)
+		}
+		specs = append(specs, spec)
+	}
+	providerDos, err := composite.SplitNodeGroupDiscoveryOptions(specs, do)
+	if err != nil {
+		klog.Fatalf("Failed to route node groups to composite cloud providers: %v", err)
+	}
+	providers := make([]cloudprovider.CloudProvider, 0, len(specs))
+	for i, spec := range specs {
+		providerOpts := opts
+		providerOpts.CloudProviderName = spec.Name
+		providerOpts.CloudConfig = spec.CloudConfig
+		providerOpts.NodeGroups = providerDos[i].NodeGroupSpecs
+		providerOpts.NodeGroupAutoDiscovery = providerDos[i].NodeGroupAutoDiscoverySpecs
+		klog.V(1).Infof("Building %s cloud provider for nodes with providerID prefix %q.", spec.Name, spec.ProviderIDPrefix)
+		provider := buildCloudProvider(providerOpts, providerDos[i], rl)
+		if provider == nil {
+			klog.Fatalf("Unknown cloud provider: %s", spec.Name)
+		}
+		providers = append(providers, provider)
+	}
+	provider, err := composite.NewCloudProvider(specs, providers, rl)
+	if err != nil {
+		klog.Fatalf("Failed to combine cloud providers: %v", err)
+	}
+	return provider
+}
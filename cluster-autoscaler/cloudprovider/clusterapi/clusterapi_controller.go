@@ -0,0 +1,312 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterapi
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog"
+)
+
+const (
+	// nodeGroupMinSizeAnnotation is the annotation of MachineSets and MachineDeployments holding the minimum size of the node group.
+	nodeGroupMinSizeAnnotation = "cluster.x-k8s.io/cluster-api-autoscaler-node-group-min-size"
+	// nodeGroupMaxSizeAnnotation is the annotation of MachineSets and MachineDeployments holding the maximum size of the node group.
+	nodeGroupMaxSizeAnnotation = "cluster.x-k8s.io/cluster-api-autoscaler-node-group-max-size"
+	// machineDeleteAnnotation marks the Machines a MachineSet should remove first when its replicas are decreased.
+	machineDeleteAnnotation = "cluster.x-k8s.io/delete-machine"
+
+	machineSetKind        = "MachineSet"
+	machineDeploymentKind = "MachineDeployment"
+
+	// pendingProviderIDPrefix prefixes the ids of instances of Machines which don't have a providerID yet.
+	pendingProviderIDPrefix = "clusterapi://"
+
+	scaleSubresource = "scale"
+)
+
+var (
+	machineResource           = schema.GroupVersionResource{Group: "cluster.x-k8s.io", Version: "v1alpha2", Resource: "machines"}
+	machineSetResource        = schema.GroupVersionResource{Group: "cluster.x-k8s.io", Version: "v1alpha2", Resource: "machinesets"}
+	machineDeploymentResource = schema.GroupVersionResource{Group: "cluster.x-k8s.io", Version: "v1alpha2", Resource: "machinedeployments"}
+)
+
+// machine is the part of a Cluster API Machine relevant to cluster autoscaler.
+type machine struct {
+	namespace string
+	name      string
+	// providerID is spec.providerID of the Machine, or a pendingProviderIDPrefix id if it's not set yet.
+	providerID   string
+	pending      bool
+	deleting     bool
+	errorReason  string
+	errorMessage string
+	nodeGroupID  string
+}
+
+// machineController discovers node groups and machines from Cluster API objects through the dynamic client.
+// Refresh takes a snapshot of the objects which is used until the next Refresh, while scaling
+// operations are always performed on the live objects.
+type machineController struct {
+	dynamicClient dynamic.Interface
+
+	mutex                sync.Mutex
+	nodeGroups           []*nodeGroup
+	machinesByProviderID map[string]*machine
+	machinesByNodeGroup  map[string][]*machine
+}
+
+func newMachineController(dynamicClient dynamic.Interface) *machineController {
+	return &machineController{
+		dynamicClient:        dynamicClient,
+		machinesByProviderID: make(map[string]*machine),
+		machinesByNodeGroup:  make(map[string][]*machine),
+	}
+}
+
+// refresh lists MachineDeployments, MachineSets and Machines and rebuilds the node groups and the machine caches.
+// Annotated MachineDeployments are node groups, as are annotated MachineSets not owned by a MachineDeployment.
+func (c *machineController) refresh() error {
+	machineDeployments, err := c.dynamicClient.Resource(machineDeploymentResource).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list MachineDeployments: %v", err)
+	}
+	machineSets, err := c.dynamicClient.Resource(machineSetResource).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list MachineSets: %v", err)
+	}
+	machines, err := c.dynamicClient.Resource(machineResource).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list Machines: %v", err)
+	}
+
+	var nodeGroups []*nodeGroup
+	// Node group id by "<namespace>/<name>" of the MachineDeployment.
+	deploymentNodeGroups := make(map[string]string)
+	for i := range machineDeployments.Items {
+		md := &machineDeployments.Items[i]
+		ng := c.buildNodeGroup(md, machineDeploymentKind, machineDeploymentResource)
+		if ng == nil {
+			continue
+		}
+		nodeGroups = append(nodeGroups, ng)
+		deploymentNodeGroups[objectKey(md.GetNamespace(), md.GetName())] = ng.Id()
+	}
+
+	// Node group id by "<namespace>/<name>" of the MachineSet.
+	machineSetNodeGroups := make(map[string]string)
+	for i := range machineSets.Items {
+		ms := &machineSets.Items[i]
+		if owner := ownerOfKind(ms, machineDeploymentKind); owner != nil {
+			if id, found := deploymentNodeGroups[objectKey(ms.GetNamespace(), owner.Name)]; found {
+				machineSetNodeGroups[objectKey(ms.GetNamespace(), ms.GetName())] = id
+			}
+			continue
+		}
+		ng := c.buildNodeGroup(ms, machineSetKind, machineSetResource)
+		if ng == nil {
+			continue
+		}
+		nodeGroups = append(nodeGroups, ng)
+		machineSetNodeGroups[objectKey(ms.GetNamespace(), ms.GetName())] = ng.Id()
+	}
+
+	machinesByProviderID := make(map[string]*machine)
+	machinesByNodeGroup := make(map[string][]*machine)
+	for i := range machines.Items {
+		m := buildMachine(&machines.Items[i])
+		if owner := ownerOfKind(&machines.Items[i], machineSetKind); owner != nil {
+			m.nodeGroupID = machineSetNodeGroups[objectKey(m.namespace, owner.Name)]
+		}
+		machinesByProviderID[m.providerID] = m
+		if m.nodeGroupID != "" {
+			machinesByNodeGroup[m.nodeGroupID] = append(machinesByNodeGroup[m.nodeGroupID], m)
+		}
+	}
+
+	sort.Slice(nodeGroups, func(i, j int) bool { return nodeGroups[i].Id() < nodeGroups[j].Id() })
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.nodeGroups = nodeGroups
+	c.machinesByProviderID = machinesByProviderID
+	c.machinesByNodeGroup = machinesByNodeGroup
+	return nil
+}
+
+// buildNodeGroup returns the node group of a MachineSet or MachineDeployment, or nil if it's not annotated
+// with a valid node group size.
+func (c *machineController) buildNodeGroup(obj *unstructured.Unstructured, kind string, gvr schema.GroupVersionResource) *nodeGroup {
+	annotations := obj.GetAnnotations()
+	minValue, hasMin := annotations[nodeGroupMinSizeAnnotation]
+	maxValue, hasMax := annotations[nodeGroupMaxSizeAnnotation]
+	if !hasMin && !hasMax {
+		return nil
+	}
+	minSize, maxSize, err := parseNodeGroupSize(minValue, maxValue)
+	if err != nil {
+		klog.Warningf("Ignoring %s %s/%s: %v", kind, obj.GetNamespace(), obj.GetName(), err)
+		return nil
+	}
+	return &nodeGroup{
+		controller: c,
+		kind:       kind,
+		resource:   gvr,
+		namespace:  obj.GetNamespace(),
+		name:       obj.GetName(),
+		minSize:    minSize,
+		maxSize:    maxSize,
+	}
+}
+
+func parseNodeGroupSize(minValue, maxValue string) (int, int, error) {
+	minSize, err := strconv.Atoi(minValue)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid %s annotation %q: %v", nodeGroupMinSizeAnnotation, minValue, err)
+	}
+	maxSize, err := strconv.Atoi(maxValue)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid %s annotation %q: %v", nodeGroupMaxSizeAnnotation, maxValue, err)
+	}
+	if minSize < 0 {
+		return 0, 0, fmt.Errorf("min size %d is negative", minSize)
+	}
+	if maxSize < minSize {
+		return 0, 0, fmt.Errorf("max size %d is lower than min size %d", maxSize, minSize)
+	}
+	return minSize, maxSize, nil
+}
+
+func buildMachine(obj *unstructured.Unstructured) *machine {
+	m := &machine{
+		namespace: obj.GetNamespace(),
+		name:      obj.GetName(),
+		deleting:  obj.GetDeletionTimestamp() != nil,
+	}
+	m.providerID, _, _ = unstructured.NestedString(obj.Object, "spec", "providerID")
+	if m.providerID == "" {
+		m.providerID = pendingProviderID(m.namespace, m.name)
+		m.pending = true
+	}
+	m.errorReason, _, _ = unstructured.NestedString(obj.Object, "status", "errorReason")
+	m.errorMessage, _, _ = unstructured.NestedString(obj.Object, "status", "errorMessage")
+	return m
+}
+
+func pendingProviderID(namespace, name string) string {
+	return pendingProviderIDPrefix + objectKey(namespace, name)
+}
+
+func objectKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+func ownerOfKind(obj *unstructured.Unstructured, kind string) *metav1.OwnerReference {
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.Kind == kind {
+			ownerCopy := owner
+			return &ownerCopy
+		}
+	}
+	return nil
+}
+
+func (c *machineController) getNodeGroups() []*nodeGroup {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.nodeGroups
+}
+
+// nodeGroupForProviderID returns the node group of the Machine with the given providerID, or nil
+// if there is no such Machine or it doesn't belong to any node group.
+func (c *machineController) nodeGroupForProviderID(providerID string) *nodeGroup {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	m, found := c.machinesByProviderID[providerID]
+	if !found || m.nodeGroupID == "" {
+		return nil
+	}
+	for _, ng := range c.nodeGroups {
+		if ng.Id() == m.nodeGroupID {
+			return ng
+		}
+	}
+	return nil
+}
+
+func (c *machineController) machineForProviderID(providerID string) *machine {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.machinesByProviderID[providerID]
+}
+
+func (c *machineController) machinesOf(nodeGroupID string) []*machine {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.machinesByNodeGroup[nodeGroupID]
+}
+
+// getReplicas returns the replicas of the object through its scale subresource.
+func (c *machineController) getReplicas(gvr schema.GroupVersionResource, namespace, name string) (int, error) {
+	scale, err := c.dynamicClient.Resource(gvr).Namespace(namespace).Get(name, metav1.GetOptions{}, scaleSubresource)
+	if err != nil {
+		return 0, err
+	}
+	replicas, _, err := unstructured.NestedInt64(scale.Object, "spec", "replicas")
+	if err != nil {
+		return 0, err
+	}
+	return int(replicas), nil
+}
+
+// setReplicas sets the replicas of the object through its scale subresource.
+func (c *machineController) setReplicas(gvr schema.GroupVersionResource, namespace, name string, replicas int) error {
+	client := c.dynamicClient.Resource(gvr).Namespace(namespace)
+	scale, err := client.Get(name, metav1.GetOptions{}, scaleSubresource)
+	if err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(scale.Object, int64(replicas), "spec", "replicas"); err != nil {
+		return err
+	}
+	_, err = client.Update(scale, metav1.UpdateOptions{}, scaleSubresource)
+	return err
+}
+
+// markForDeletion annotates the Machine, so its MachineSet removes it when decreasing replicas.
+func (c *machineController) markForDeletion(m *machine) error {
+	client := c.dynamicClient.Resource(machineResource).Namespace(m.namespace)
+	obj, err := client.Get(m.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[machineDeleteAnnotation] = "true"
+	obj.SetAnnotations(annotations)
+	_, err = client.Update(obj, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterapi
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+// nodeGroup implements cloudprovider.NodeGroup interface for a MachineSet or a MachineDeployment.
+// Its size is changed through the scale subresource of the object.
+type nodeGroup struct {
+	controller *machineController
+	kind       string
+	resource   schema.GroupVersionResource
+	namespace  string
+	name       string
+	minSize    int
+	maxSize    int
+}
+
+// MaxSize returns maximum size of the node group.
+func (ng *nodeGroup) MaxSize() int {
+	return ng.maxSize
+}
+
+// MinSize returns minimum size of the node group.
+func (ng *nodeGroup) MinSize() int {
+	return ng.minSize
+}
+
+// TargetSize returns the current target size of the node group, i.e. the replicas of the
+// MachineSet or MachineDeployment.
+func (ng *nodeGroup) TargetSize() (int, error) {
+	return ng.controller.getReplicas(ng.resource, ng.namespace, ng.name)
+}
+
+// IncreaseSize increases the replicas of the MachineSet or MachineDeployment by delta.
+func (ng *nodeGroup) IncreaseSize(delta int) error {
+	if delta <= 0 {
+		return fmt.Errorf("size increase must be positive")
+	}
+	size, err := ng.TargetSize()
+	if err != nil {
+		return err
+	}
+	if size+delta > ng.MaxSize() {
+		return fmt.Errorf("size increase too large - desired:%d max:%d", size+delta, ng.MaxSize())
+	}
+	return ng.controller.setReplicas(ng.resource, ng.namespace, ng.name, size+delta)
+}
+
+// DeleteNodes deletes the Machines of the given nodes. Every Machine is annotated, so that its MachineSet
+// removes exactly that Machine, and then the replicas are decreased by one.
+func (ng *nodeGroup) DeleteNodes(nodes []*apiv1.Node) error {
+	size, err := ng.TargetSize()
+	if err != nil {
+		return err
+	}
+	if size-len(nodes) < ng.MinSize() {
+		return fmt.Errorf("min size reached, nodes will not be deleted")
+	}
+
+	machines := make([]*machine, 0, len(nodes))
+	for _, node := range nodes {
+		m := ng.controller.machineForProviderID(node.Spec.ProviderID)
+		if m == nil {
+			return fmt.Errorf("no machine found for node %s (providerID %q)", node.Name, node.Spec.ProviderID)
+		}
+		if m.nodeGroupID != ng.Id() {
+			return fmt.Errorf("node %s belongs to a different node group than %s", node.Name, ng.Id())
+		}
+		machines = append(machines, m)
+	}
+
+	for _, m := range machines {
+		if err := ng.controller.markForDeletion(m); err != nil {
+			return fmt.Errorf("failed to mark machine %s/%s for deletion: %v", m.namespace, m.name, err)
+		}
+		size, err := ng.TargetSize()
+		if err != nil {
+			return err
+		}
+		if err := ng.controller.setReplicas(ng.resource, ng.namespace, ng.name, size-1); err != nil {
+			return fmt.Errorf("failed to scale down %s after marking machine %s/%s for deletion: %v", ng.Id(), m.namespace, m.name, err)
+		}
+	}
+	return nil
+}
+
+// DecreaseTargetSize decreases the replicas of the MachineSet or MachineDeployment without
+// deleting any existing Machine. Delta should be negative.
+func (ng *nodeGroup) DecreaseTargetSize(delta int) error {
+	if delta >= 0 {
+		return fmt.Errorf("size decrease must be negative")
+	}
+	size, err := ng.TargetSize()
+	if err != nil {
+		return err
+	}
+	machines := ng.controller.machinesOf(ng.Id())
+	if size+delta < len(machines) {
+		return fmt.Errorf("attempt to delete existing nodes targetSize:%d delta:%d existingNodes: %d",
+			size, delta, len(machines))
+	}
+	return ng.controller.setReplicas(ng.resource, ng.namespace, ng.name, size+delta)
+}
+
+// Id returns an unique identifier of the node group, "<kind>/<namespace>/<name>".
+func (ng *nodeGroup) Id() string {
+	return ng.kind + "/" + objectKey(ng.namespace, ng.name)
+}
+
+// Debug returns a string containing all information regarding this node group.
+func (ng *nodeGroup) Debug() string {
+	return fmt.Sprintf("%s (min: %d, max: %d)", ng.Id(), ng.MinSize(), ng.MaxSize())
+}
+
+// Nodes returns a list of all nodes that belong to this node group. Machines without a providerID
+// are reported as being created, with an id that doesn't match any node.
+func (ng *nodeGroup) Nodes() ([]cloudprovider.Instance, error) {
+	machines := ng.controller.machinesOf(ng.Id())
+	instances := make([]cloudprovider.Instance, 0, len(machines))
+	for _, m := range machines {
+		instances = append(instances, cloudprovider.Instance{
+			Id:     m.providerID,
+			Status: instanceStatus(m),
+		})
+	}
+	return instances, nil
+}
+
+func instanceStatus(m *machine) *cloudprovider.InstanceStatus {
+	status := &cloudprovider.InstanceStatus{State: cloudprovider.InstanceRunning}
+	switch {
+	case m.deleting:
+		status.State = cloudprovider.InstanceDeleting
+	case m.pending:
+		status.State = cloudprovider.InstanceCreating
+	}
+	if m.errorReason != "" || m.errorMessage != "" {
+		status.ErrorInfo = &cloudprovider.InstanceErrorInfo{
+			ErrorClass:   cloudprovider.OtherErrorClass,
+			ErrorCode:    m.errorReason,
+			ErrorMessage: m.errorMessage,
+		}
+	}
+	return status
+}
+
+// TemplateNodeInfo is not implemented.
+func (ng *nodeGroup) TemplateNodeInfo() (*schedulernodeinfo.NodeInfo, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// Exist checks if the node group really exists on the cloud provider side.
+// Node groups are discovered from existing objects, so they always exist.
+func (ng *nodeGroup) Exist() bool {
+	return true
+}
+
+// Create is not implemented.
+func (ng *nodeGroup) Create() (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// Delete is not implemented.
+func (ng *nodeGroup) Delete() error {
+	return cloudprovider.ErrNotImplemented
+}
+
+// Autoprovisioned returns true if the node group is autoprovisioned.
+func (ng *nodeGroup) Autoprovisioned() bool {
+	return false
+}
+
+// GetOptions is not implemented.
+func (ng *nodeGroup) GetOptions(defaults config.NodeGroupAutoscalingOptions) (*config.NodeGroupAutoscalingOptions, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
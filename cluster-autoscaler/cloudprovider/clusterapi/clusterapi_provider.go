@@ -0,0 +1,150 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterapi
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog"
+)
+
+const (
+	// ProviderName is the cloud provider name for Cluster API.
+	ProviderName = "clusterapi"
+
+	// GPULabel is the label added to nodes with GPU resource.
+	GPULabel = "cluster-api/accelerator"
+)
+
+// clusterAPICloudProvider implements CloudProvider interface for node groups managed
+// through Cluster API MachineSets and MachineDeployments.
+type clusterAPICloudProvider struct {
+	controller      *machineController
+	resourceLimiter *cloudprovider.ResourceLimiter
+}
+
+func newClusterAPICloudProvider(controller *machineController, rl *cloudprovider.ResourceLimiter) *clusterAPICloudProvider {
+	return &clusterAPICloudProvider{
+		controller:      controller,
+		resourceLimiter: rl,
+	}
+}
+
+// Name returns name of the cloud provider.
+func (p *clusterAPICloudProvider) Name() string {
+	return ProviderName
+}
+
+// NodeGroups returns all node groups configured for this cloud provider.
+func (p *clusterAPICloudProvider) NodeGroups() []cloudprovider.NodeGroup {
+	nodeGroups := p.controller.getNodeGroups()
+	result := make([]cloudprovider.NodeGroup, 0, len(nodeGroups))
+	for _, ng := range nodeGroups {
+		result = append(result, ng)
+	}
+	return result
+}
+
+// NodeGroupForNode returns the node group of the Machine with the providerID of the node,
+// nil if the node should not be processed by cluster autoscaler, or non-nil error if such occurred.
+func (p *clusterAPICloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovider.NodeGroup, error) {
+	if node == nil {
+		return nil, fmt.Errorf("node is nil")
+	}
+	ng := p.controller.nodeGroupForProviderID(node.Spec.ProviderID)
+	if ng == nil {
+		return nil, nil
+	}
+	return ng, nil
+}
+
+// Pricing is not implemented.
+func (p *clusterAPICloudProvider) Pricing() (cloudprovider.PricingModel, errors.AutoscalerError) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// GetAvailableMachineTypes is not implemented.
+func (p *clusterAPICloudProvider) GetAvailableMachineTypes() ([]string, error) {
+	return []string{}, nil
+}
+
+// NewNodeGroup is not implemented.
+func (p *clusterAPICloudProvider) NewNodeGroup(machineType string, labels map[string]string, systemLabels map[string]string,
+	taints []apiv1.Taint, extraResources map[string]resource.Quantity) (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// GetResourceLimiter returns struct containing limits (max, min) for resources (cores, memory etc.).
+func (p *clusterAPICloudProvider) GetResourceLimiter() (*cloudprovider.ResourceLimiter, error) {
+	return p.resourceLimiter, nil
+}
+
+// GPULabel returns the label added to nodes with GPU resource.
+func (p *clusterAPICloudProvider) GPULabel() string {
+	return GPULabel
+}
+
+// GetAvailableGPUTypes return all available GPU types cloud provider supports.
+func (p *clusterAPICloudProvider) GetAvailableGPUTypes() map[string]struct{} {
+	return nil
+}
+
+// Cleanup cleans up all resources before the cloud provider is removed.
+func (p *clusterAPICloudProvider) Cleanup() error {
+	return nil
+}
+
+// Refresh rediscovers node groups and their Machines. It's called before every main loop.
+func (p *clusterAPICloudProvider) Refresh() error {
+	return p.controller.refresh()
+}
+
+// BuildClusterAPI builds the Cluster API cloud provider. Cluster API objects are read from the cluster
+// of the kubeconfig passed through --cloud-config, or from the cluster autoscaler is running in.
+func BuildClusterAPI(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) cloudprovider.CloudProvider {
+	var kubeConfig *rest.Config
+	var err error
+	if opts.CloudConfig != "" {
+		kubeConfig, err = clientcmd.BuildConfigFromFlags("", opts.CloudConfig)
+	} else {
+		kubeConfig, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		klog.Fatalf("Failed to get kubeclient config for Cluster API objects: %v", err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(kubeConfig)
+	if err != nil {
+		klog.Fatalf("Failed to create dynamic client for Cluster API objects: %v", err)
+	}
+	if len(do.NodeGroupSpecs) > 0 {
+		klog.Warningf("Node groups passed with --nodes are ignored, Cluster API node groups are discovered from annotations")
+	}
+
+	provider := newClusterAPICloudProvider(newMachineController(dynamicClient), rl)
+	if err := provider.Refresh(); err != nil {
+		klog.Fatalf("Failed to discover Cluster API node groups: %v", err)
+	}
+	return provider
+}
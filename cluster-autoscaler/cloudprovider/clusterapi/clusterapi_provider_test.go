@@ -0,0 +1,240 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterapi
+
+import (
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testNamespace = "test-namespace"
+
+func buildTestScalable(kind, name string, replicas int64, minSize, maxSize string, owner string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cluster.x-k8s.io/v1alpha2",
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": testNamespace,
+		},
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+		},
+	}}
+	annotations := make(map[string]string)
+	if minSize != "" {
+		annotations[nodeGroupMinSizeAnnotation] = minSize
+	}
+	if maxSize != "" {
+		annotations[nodeGroupMaxSizeAnnotation] = maxSize
+	}
+	obj.SetAnnotations(annotations)
+	if owner != "" {
+		obj.SetOwnerReferences([]metav1.OwnerReference{{Kind: machineDeploymentKind, Name: owner}})
+	}
+	return obj
+}
+
+func buildTestMachine(name, machineSet, providerID string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cluster.x-k8s.io/v1alpha2",
+		"kind":       "Machine",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": testNamespace,
+		},
+		"spec": map[string]interface{}{},
+	}}
+	if providerID != "" {
+		unstructured.SetNestedField(obj.Object, providerID, "spec", "providerID")
+	}
+	obj.SetOwnerReferences([]metav1.OwnerReference{{Kind: machineSetKind, Name: machineSet}})
+	return obj
+}
+
+func buildTestNodeWithProviderID(name, providerID string) *apiv1.Node {
+	node := BuildTestNode(name, 1000, 1000)
+	node.Spec.ProviderID = providerID
+	return node
+}
+
+// newTestProvider returns a provider with:
+// - MachineDeployment "md" (1-5 nodes) with MachineSet "md-abc" and Machines "md-abc-1", "md-abc-2",
+// - standalone MachineSet "ms" (0-3 nodes) with Machine "ms-1" and a Machine "ms-2" without providerID,
+// - MachineSet "unmanaged" without annotations with Machine "unmanaged-1",
+// - MachineSet "invalid" with min size larger than max size.
+func newTestProvider(t *testing.T) (*clusterAPICloudProvider, *fakeDynamicClient) {
+	client := newFakeDynamicClient()
+	client.add(machineDeploymentResource, buildTestScalable(machineDeploymentKind, "md", 2, "1", "5", ""))
+	client.add(machineSetResource, buildTestScalable(machineSetKind, "md-abc", 2, "", "", "md"))
+	client.add(machineResource, buildTestMachine("md-abc-1", "md-abc", "test:///1"))
+	client.add(machineResource, buildTestMachine("md-abc-2", "md-abc", "test:///2"))
+	client.add(machineSetResource, buildTestScalable(machineSetKind, "ms", 2, "0", "3", ""))
+	client.add(machineResource, buildTestMachine("ms-1", "ms", "test:///3"))
+	client.add(machineResource, buildTestMachine("ms-2", "ms", ""))
+	client.add(machineSetResource, buildTestScalable(machineSetKind, "unmanaged", 1, "", "", ""))
+	client.add(machineResource, buildTestMachine("unmanaged-1", "unmanaged", "test:///4"))
+	client.add(machineSetResource, buildTestScalable(machineSetKind, "invalid", 1, "3", "1", ""))
+
+	provider := newClusterAPICloudProvider(newMachineController(client), cloudprovider.NewResourceLimiter(nil, nil))
+	assert.NoError(t, provider.Refresh())
+	return provider, client
+}
+
+func replicasOf(t *testing.T, client *fakeDynamicClient, ng cloudprovider.NodeGroup) int64 {
+	clusterAPINodeGroup := ng.(*nodeGroup)
+	obj := client.get(clusterAPINodeGroup.resource, clusterAPINodeGroup.namespace, clusterAPINodeGroup.name)
+	replicas, _, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	assert.NoError(t, err)
+	return replicas
+}
+
+func TestNodeGroups(t *testing.T) {
+	provider, _ := newTestProvider(t)
+
+	nodeGroups := provider.NodeGroups()
+	assert.Equal(t, 2, len(nodeGroups))
+	assert.Equal(t, "MachineDeployment/test-namespace/md", nodeGroups[0].Id())
+	assert.Equal(t, 1, nodeGroups[0].MinSize())
+	assert.Equal(t, 5, nodeGroups[0].MaxSize())
+	assert.Equal(t, "MachineSet/test-namespace/ms", nodeGroups[1].Id())
+	assert.Equal(t, 0, nodeGroups[1].MinSize())
+	assert.Equal(t, 3, nodeGroups[1].MaxSize())
+
+	size, err := nodeGroups[0].TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, size)
+}
+
+func TestNodeGroupForNode(t *testing.T) {
+	provider, _ := newTestProvider(t)
+
+	ng, err := provider.NodeGroupForNode(buildTestNodeWithProviderID("n1", "test:///1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "MachineDeployment/test-namespace/md", ng.Id())
+
+	ng, err = provider.NodeGroupForNode(buildTestNodeWithProviderID("n3", "test:///3"))
+	assert.NoError(t, err)
+	assert.Equal(t, "MachineSet/test-namespace/ms", ng.Id())
+
+	// Machine of a MachineSet without annotations.
+	ng, err = provider.NodeGroupForNode(buildTestNodeWithProviderID("n4", "test:///4"))
+	assert.NoError(t, err)
+	assert.Nil(t, ng)
+
+	// No Machine at all.
+	ng, err = provider.NodeGroupForNode(buildTestNodeWithProviderID("n5", "test:///5"))
+	assert.NoError(t, err)
+	assert.Nil(t, ng)
+}
+
+func TestNodes(t *testing.T) {
+	provider, client := newTestProvider(t)
+
+	deletingMachine := buildTestMachine("ms-1", "ms", "test:///3")
+	now := metav1.NewTime(time.Now())
+	deletingMachine.SetDeletionTimestamp(&now)
+	unstructured.SetNestedField(deletingMachine.Object, "DeleteError", "status", "errorReason")
+	unstructured.SetNestedField(deletingMachine.Object, "instance stuck", "status", "errorMessage")
+	client.add(machineResource, deletingMachine)
+	assert.NoError(t, provider.Refresh())
+
+	ng, err := provider.NodeGroupForNode(buildTestNodeWithProviderID("n3", "test:///3"))
+	assert.NoError(t, err)
+	instances, err := ng.Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, []cloudprovider.Instance{
+		{
+			Id: "test:///3",
+			Status: &cloudprovider.InstanceStatus{
+				State: cloudprovider.InstanceDeleting,
+				ErrorInfo: &cloudprovider.InstanceErrorInfo{
+					ErrorClass:   cloudprovider.OtherErrorClass,
+					ErrorCode:    "DeleteError",
+					ErrorMessage: "instance stuck",
+				},
+			},
+		},
+		{
+			Id:     "clusterapi://test-namespace/ms-2",
+			Status: &cloudprovider.InstanceStatus{State: cloudprovider.InstanceCreating},
+		},
+	}, instances)
+}
+
+func TestIncreaseSize(t *testing.T) {
+	provider, client := newTestProvider(t)
+	ng := provider.NodeGroups()[0]
+
+	assert.NoError(t, ng.IncreaseSize(2))
+	assert.Equal(t, int64(4), replicasOf(t, client, ng))
+
+	assert.Error(t, ng.IncreaseSize(2))
+	assert.Error(t, ng.IncreaseSize(0))
+	assert.Equal(t, int64(4), replicasOf(t, client, ng))
+}
+
+func TestDecreaseTargetSize(t *testing.T) {
+	provider, client := newTestProvider(t)
+	ng := provider.NodeGroups()[0]
+	assert.NoError(t, ng.IncreaseSize(2))
+
+	assert.NoError(t, ng.DecreaseTargetSize(-1))
+	assert.Equal(t, int64(3), replicasOf(t, client, ng))
+
+	// Would delete one of the existing Machines.
+	assert.Error(t, ng.DecreaseTargetSize(-2))
+	assert.Error(t, ng.DecreaseTargetSize(1))
+	assert.Equal(t, int64(3), replicasOf(t, client, ng))
+}
+
+func TestDeleteNodes(t *testing.T) {
+	provider, client := newTestProvider(t)
+	md := provider.NodeGroups()[0]
+	ms := provider.NodeGroups()[1]
+
+	// Node of another node group.
+	assert.Error(t, md.DeleteNodes([]*apiv1.Node{buildTestNodeWithProviderID("n3", "test:///3")}))
+	// Below min size.
+	assert.Error(t, md.DeleteNodes([]*apiv1.Node{
+		buildTestNodeWithProviderID("n1", "test:///1"),
+		buildTestNodeWithProviderID("n2", "test:///2"),
+	}))
+	assert.Equal(t, int64(2), replicasOf(t, client, md))
+
+	assert.NoError(t, md.DeleteNodes([]*apiv1.Node{buildTestNodeWithProviderID("n2", "test:///2")}))
+	assert.Equal(t, int64(1), replicasOf(t, client, md))
+	assert.Equal(t, "true", client.get(machineResource, testNamespace, "md-abc-2").GetAnnotations()[machineDeleteAnnotation])
+	assert.Empty(t, client.get(machineResource, testNamespace, "md-abc-1").GetAnnotations()[machineDeleteAnnotation])
+
+	// Machines without providerID are deleted by their instance id.
+	assert.NoError(t, ms.DeleteNodes([]*apiv1.Node{
+		buildTestNodeWithProviderID("n3", "test:///3"),
+		buildTestNodeWithProviderID("clusterapi://test-namespace/ms-2", "clusterapi://test-namespace/ms-2"),
+	}))
+	assert.Equal(t, int64(0), replicasOf(t, client, ms))
+	assert.Equal(t, "true", client.get(machineResource, testNamespace, "ms-1").GetAnnotations()[machineDeleteAnnotation])
+	assert.Equal(t, "true", client.get(machineResource, testNamespace, "ms-2").GetAnnotations()[machineDeleteAnnotation])
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterapi
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	kube_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+// fakeDynamicClient is an in-memory dynamic.Interface. It supports Get, List and Update of objects,
+// and Get and Update of the scale subresource, which is backed by spec.replicas of the object.
+type fakeDynamicClient struct {
+	mutex   sync.Mutex
+	objects map[schema.GroupVersionResource]map[string]*unstructured.Unstructured
+}
+
+func newFakeDynamicClient() *fakeDynamicClient {
+	return &fakeDynamicClient{objects: make(map[schema.GroupVersionResource]map[string]*unstructured.Unstructured)}
+}
+
+func (f *fakeDynamicClient) add(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.objects[gvr] == nil {
+		f.objects[gvr] = make(map[string]*unstructured.Unstructured)
+	}
+	f.objects[gvr][objectKey(obj.GetNamespace(), obj.GetName())] = obj.DeepCopy()
+}
+
+func (f *fakeDynamicClient) get(gvr schema.GroupVersionResource, namespace, name string) *unstructured.Unstructured {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	obj, found := f.objects[gvr][objectKey(namespace, name)]
+	if !found {
+		return nil
+	}
+	return obj.DeepCopy()
+}
+
+func (f *fakeDynamicClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &fakeResourceClient{client: f, gvr: gvr}
+}
+
+type fakeResourceClient struct {
+	client    *fakeDynamicClient
+	gvr       schema.GroupVersionResource
+	namespace string
+}
+
+func (r *fakeResourceClient) Namespace(namespace string) dynamic.ResourceInterface {
+	return &fakeResourceClient{client: r.client, gvr: r.gvr, namespace: namespace}
+}
+
+func (r *fakeResourceClient) notFound(name string) error {
+	return kube_errors.NewNotFound(r.gvr.GroupResource(), name)
+}
+
+func (r *fakeResourceClient) Get(name string, options metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	obj := r.client.get(r.gvr, r.namespace, name)
+	if obj == nil {
+		return nil, r.notFound(name)
+	}
+	if len(subresources) == 0 {
+		return obj, nil
+	}
+	if len(subresources) != 1 || subresources[0] != scaleSubresource {
+		return nil, fmt.Errorf("unsupported subresource %v", subresources)
+	}
+	replicas, _, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if err != nil {
+		return nil, err
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "autoscaling/v1",
+		"kind":       "Scale",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": r.namespace,
+		},
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+		},
+	}}, nil
+}
+
+func (r *fakeResourceClient) Update(obj *unstructured.Unstructured, options metav1.UpdateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	stored := r.client.get(r.gvr, r.namespace, obj.GetName())
+	if stored == nil {
+		return nil, r.notFound(obj.GetName())
+	}
+	if len(subresources) == 0 {
+		r.client.add(r.gvr, obj)
+		return obj.DeepCopy(), nil
+	}
+	if len(subresources) != 1 || subresources[0] != scaleSubresource {
+		return nil, fmt.Errorf("unsupported subresource %v", subresources)
+	}
+	replicas, _, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if err != nil {
+		return nil, err
+	}
+	if err := unstructured.SetNestedField(stored.Object, replicas, "spec", "replicas"); err != nil {
+		return nil, err
+	}
+	r.client.add(r.gvr, stored)
+	return obj.DeepCopy(), nil
+}
+
+func (r *fakeResourceClient) List(opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	r.client.mutex.Lock()
+	defer r.client.mutex.Unlock()
+	var keys []string
+	for key, obj := range r.client.objects[r.gvr] {
+		if r.namespace == "" || obj.GetNamespace() == r.namespace {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	list := &unstructured.UnstructuredList{}
+	for _, key := range keys {
+		list.Items = append(list.Items, *r.client.objects[r.gvr][key].DeepCopy())
+	}
+	return list, nil
+}
+
+func (r *fakeResourceClient) Create(obj *unstructured.Unstructured, options metav1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (r *fakeResourceClient) UpdateStatus(obj *unstructured.Unstructured, options metav1.UpdateOptions) (*unstructured.Unstructured, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (r *fakeResourceClient) Delete(name string, options *metav1.DeleteOptions, subresources ...string) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (r *fakeResourceClient) DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (r *fakeResourceClient) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (r *fakeResourceClient) Patch(name string, pt types.PatchType, data []byte, options metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	return nil, fmt.Errorf("not implemented")
+}
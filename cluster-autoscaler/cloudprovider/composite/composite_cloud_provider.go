@@ -0,0 +1,275 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"fmt"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/klog"
+)
+
+const (
+	// ProviderName is the cloud provider name for the composite cloud provider.
+	ProviderName = "composite"
+)
+
+// ProviderSpec describes one of the cloud providers wrapped by the composite cloud provider.
+type ProviderSpec struct {
+	// Name is the name of the cloud provider, as passed to --cloud-provider.
+	Name string
+	// CloudConfig is the path to the configuration file of the cloud provider, as passed to --cloud-config.
+	CloudConfig string
+	// ProviderIDPrefix is the prefix of providerIDs of nodes of the cloud provider.
+	ProviderIDPrefix string
+}
+
+// ParseProviderSpec parses a "<provider>:<cloud-config>[:<providerID prefix>]" spec. The providerID prefix
+// defaults to "<provider>://", which matches the providerIDs of most cloud providers. An empty cloud-config
+// means no configuration file.
+func ParseProviderSpec(value string) (ProviderSpec, error) {
+	parts := strings.SplitN(value, ":", 3)
+	if len(parts) < 2 || parts[0] == "" {
+		return ProviderSpec{}, fmt.Errorf("invalid composite cloud provider spec %q, expected <provider>:<cloud-config>[:<providerID prefix>]", value)
+	}
+	if parts[0] == ProviderName {
+		return ProviderSpec{}, fmt.Errorf("composite cloud providers can't be nested")
+	}
+	spec := ProviderSpec{
+		Name:             parts[0],
+		CloudConfig:      parts[1],
+		ProviderIDPrefix: parts[0] + "://",
+	}
+	if len(parts) == 3 {
+		spec.ProviderIDPrefix = parts[2]
+	}
+	return spec, nil
+}
+
+// SplitNodeGroupDiscoveryOptions routes the node group specs and auto-discovery specs of do to the cloud
+// providers described by specs, returning the discovery options of every provider in the same order as specs.
+// Every spec must be prefixed with "<provider>/", e.g. "aws/1:10:my-asg", so that each provider only gets the
+// specs it understands. The prefix is stripped before the spec is passed to the provider.
+func SplitNodeGroupDiscoveryOptions(specs []ProviderSpec, do cloudprovider.NodeGroupDiscoveryOptions) ([]cloudprovider.NodeGroupDiscoveryOptions, error) {
+	result := make([]cloudprovider.NodeGroupDiscoveryOptions, len(specs))
+	route := func(value string) (int, string, error) {
+		parts := strings.SplitN(value, "/", 2)
+		provider := -1
+		for i, spec := range specs {
+			if len(parts) < 2 || spec.Name != parts[0] {
+				continue
+			}
+			if provider != -1 {
+				return 0, "", fmt.Errorf("node group spec %q matches more than one composite cloud provider named %s", value, spec.Name)
+			}
+			provider = i
+		}
+		if provider == -1 {
+			return 0, "", fmt.Errorf("node group spec %q doesn't start with the name of a composite cloud provider, expected <provider>/<spec>", value)
+		}
+		return provider, parts[1], nil
+	}
+	for _, value := range do.NodeGroupSpecs {
+		provider, spec, err := route(value)
+		if err != nil {
+			return nil, err
+		}
+		result[provider].NodeGroupSpecs = append(result[provider].NodeGroupSpecs, spec)
+	}
+	for _, value := range do.NodeGroupAutoDiscoverySpecs {
+		provider, spec, err := route(value)
+		if err != nil {
+			return nil, err
+		}
+		result[provider].NodeGroupAutoDiscoverySpecs = append(result[provider].NodeGroupAutoDiscoverySpecs, spec)
+	}
+	return result, nil
+}
+
+// compositeCloudProvider implements CloudProvider interface by merging several cloud providers.
+// Nodes are routed to the cloud provider with the longest providerID prefix matching their providerID.
+type compositeCloudProvider struct {
+	providers       []cloudprovider.CloudProvider
+	prefixes        []string
+	resourceLimiter *cloudprovider.ResourceLimiter
+}
+
+// NewCloudProvider returns a cloud provider wrapping the given cloud providers, built from the given specs.
+// The resource limits apply to the nodes of all the cloud providers together. All the cloud providers
+// must use the same GPU label, as CA recognizes GPU nodes by a single label.
+func NewCloudProvider(specs []ProviderSpec, providers []cloudprovider.CloudProvider, resourceLimiter *cloudprovider.ResourceLimiter) (cloudprovider.CloudProvider, error) {
+	prefixes := make([]string, 0, len(specs))
+	for i, spec := range specs {
+		if providers[i].GPULabel() != providers[0].GPULabel() {
+			return nil, fmt.Errorf("cloud provider %s uses GPU label %q, but %s uses %q; all combined cloud providers must use the same GPU label",
+				spec.Name, providers[i].GPULabel(), specs[0].Name, providers[0].GPULabel())
+		}
+		prefixes = append(prefixes, spec.ProviderIDPrefix)
+	}
+	return &compositeCloudProvider{
+		providers:       providers,
+		prefixes:        prefixes,
+		resourceLimiter: resourceLimiter,
+	}, nil
+}
+
+// Name returns name of the cloud provider.
+func (c *compositeCloudProvider) Name() string {
+	return ProviderName
+}
+
+// NodeGroups returns the node groups of all wrapped cloud providers.
+func (c *compositeCloudProvider) NodeGroups() []cloudprovider.NodeGroup {
+	var result []cloudprovider.NodeGroup
+	for _, provider := range c.providers {
+		result = append(result, provider.NodeGroups()...)
+	}
+	return result
+}
+
+// NodeGroupForNode returns the node group for the given node, nil if the node
+// should not be processed by cluster autoscaler, or non-nil error if such
+// occurred.
+func (c *compositeCloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovider.NodeGroup, error) {
+	if node == nil {
+		return nil, fmt.Errorf("node is nil")
+	}
+	i := c.providerIndexForNode(node)
+	if i < 0 {
+		return nil, nil
+	}
+	return c.providers[i].NodeGroupForNode(node)
+}
+
+// providerIndexForNode returns the index of the cloud provider with the longest providerID prefix
+// matching the providerID of the node, or -1 if there is none.
+func (c *compositeCloudProvider) providerIndexForNode(node *apiv1.Node) int {
+	result := -1
+	for i, prefix := range c.prefixes {
+		if strings.HasPrefix(node.Spec.ProviderID, prefix) && (result < 0 || len(prefix) > len(c.prefixes[result])) {
+			result = i
+		}
+	}
+	return result
+}
+
+// Pricing returns a pricing model routing nodes the same way as NodeGroupForNode,
+// or ErrNotImplemented if none of the wrapped cloud providers has a pricing model.
+func (c *compositeCloudProvider) Pricing() (cloudprovider.PricingModel, errors.AutoscalerError) {
+	pricingModels := make([]cloudprovider.PricingModel, len(c.providers))
+	var podPricingModel cloudprovider.PricingModel
+	for i, provider := range c.providers {
+		pricingModel, err := provider.Pricing()
+		if err == cloudprovider.ErrNotImplemented {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		pricingModels[i] = pricingModel
+		if podPricingModel == nil {
+			podPricingModel = pricingModel
+		}
+	}
+	if podPricingModel == nil {
+		return nil, cloudprovider.ErrNotImplemented
+	}
+	return &compositePricingModel{
+		cloudProvider:   c,
+		pricingModels:   pricingModels,
+		podPricingModel: podPricingModel,
+	}, nil
+}
+
+// GetAvailableMachineTypes returns the machine types of all wrapped cloud providers.
+func (c *compositeCloudProvider) GetAvailableMachineTypes() ([]string, error) {
+	result := []string{}
+	for _, provider := range c.providers {
+		machineTypes, err := provider.GetAvailableMachineTypes()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get machine types of %s: %v", provider.Name(), err)
+		}
+		result = append(result, machineTypes...)
+	}
+	return result, nil
+}
+
+// NewNodeGroup builds a theoretical node group using the first wrapped cloud provider supporting it.
+func (c *compositeCloudProvider) NewNodeGroup(machineType string, labels map[string]string, systemLabels map[string]string,
+	taints []apiv1.Taint, extraResources map[string]resource.Quantity) (cloudprovider.NodeGroup, error) {
+	for _, provider := range c.providers {
+		nodeGroup, err := provider.NewNodeGroup(machineType, labels, systemLabels, taints, extraResources)
+		if err == cloudprovider.ErrNotImplemented {
+			continue
+		}
+		return nodeGroup, err
+	}
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// GetResourceLimiter returns struct containing limits (max, min) for resources (cores, memory etc.).
+// The limits are shared by all wrapped cloud providers.
+func (c *compositeCloudProvider) GetResourceLimiter() (*cloudprovider.ResourceLimiter, error) {
+	return c.resourceLimiter, nil
+}
+
+// GPULabel returns the GPU label of the wrapped cloud providers, which is the same for all of them.
+func (c *compositeCloudProvider) GPULabel() string {
+	if len(c.providers) == 0 {
+		return ""
+	}
+	return c.providers[0].GPULabel()
+}
+
+// GetAvailableGPUTypes returns the GPU types of all wrapped cloud providers.
+func (c *compositeCloudProvider) GetAvailableGPUTypes() map[string]struct{} {
+	result := make(map[string]struct{})
+	for _, provider := range c.providers {
+		for gpuType := range provider.GetAvailableGPUTypes() {
+			result[gpuType] = struct{}{}
+		}
+	}
+	return result
+}
+
+// Cleanup cleans up all wrapped cloud providers, even if some of them fail.
+func (c *compositeCloudProvider) Cleanup() error {
+	return c.forEachProvider("clean up", cloudprovider.CloudProvider.Cleanup)
+}
+
+// Refresh refreshes all wrapped cloud providers, even if some of them fail.
+func (c *compositeCloudProvider) Refresh() error {
+	return c.forEachProvider("refresh", cloudprovider.CloudProvider.Refresh)
+}
+
+func (c *compositeCloudProvider) forEachProvider(action string, call func(cloudprovider.CloudProvider) error) error {
+	var failed []string
+	for _, provider := range c.providers {
+		if err := call(provider); err != nil {
+			klog.Errorf("Failed to %s %s cloud provider: %v", action, provider.Name(), err)
+			failed = append(failed, fmt.Sprintf("%s: %v", provider.Name(), err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to %s cloud providers: %s", action, strings.Join(failed, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPricingModel struct {
+	nodePrice map[string]float64
+	podPrice  map[string]float64
+}
+
+func (tpm *testPricingModel) NodePrice(node *apiv1.Node, startTime time.Time, endTime time.Time) (float64, error) {
+	if price, found := tpm.nodePrice[node.Name]; found {
+		return price, nil
+	}
+	return 0.0, fmt.Errorf("price for node %v not found", node.Name)
+}
+
+func (tpm *testPricingModel) PodPrice(pod *apiv1.Pod, startTime time.Time, endTime time.Time) (float64, error) {
+	if price, found := tpm.podPrice[pod.Name]; found {
+		return price, nil
+	}
+	return 0.0, fmt.Errorf("price for pod %v not found", pod.Name)
+}
+
+// failingRefreshCloudProvider is a test cloud provider whose Refresh and Cleanup fail.
+type failingRefreshCloudProvider struct {
+	*testprovider.TestCloudProvider
+	refreshed bool
+}
+
+func (p *failingRefreshCloudProvider) Refresh() error {
+	p.refreshed = true
+	return fmt.Errorf("refresh failed")
+}
+
+func (p *failingRefreshCloudProvider) Cleanup() error {
+	return fmt.Errorf("cleanup failed")
+}
+
+// gpuLabelCloudProvider is a test cloud provider with the given GPU label.
+type gpuLabelCloudProvider struct {
+	*testprovider.TestCloudProvider
+	gpuLabel string
+}
+
+func (p *gpuLabelCloudProvider) GPULabel() string {
+	return p.gpuLabel
+}
+
+func buildTestNode(name, providerID string) *apiv1.Node {
+	node := BuildTestNode(name, 1000, 1000)
+	node.Spec.ProviderID = providerID
+	return node
+}
+
+func nodeGroupIds(nodeGroups []cloudprovider.NodeGroup) []string {
+	var ids []string
+	for _, ng := range nodeGroups {
+		ids = append(ids, ng.Id())
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// newTestCompositeCloudProvider returns a composite cloud provider routing "onprem://" nodes to the first
+// test provider, "cloud://" nodes to the second one, and the rest to the third one.
+func newTestCompositeCloudProvider(t *testing.T) (*compositeCloudProvider, []*testprovider.TestCloudProvider) {
+	onPrem := testprovider.NewTestCloudProvider(nil, nil)
+	onPrem.AddNodeGroup("onprem-ng", 1, 10, 1)
+	onPrem.AddNode("onprem-ng", buildTestNode("n1", "onprem://rack1/n1"))
+	cloud := testprovider.NewTestCloudProvider(nil, nil)
+	cloud.AddNodeGroup("cloud-ng", 0, 10, 1)
+	cloud.AddNode("cloud-ng", buildTestNode("n2", "cloud://zone/n2"))
+	other := testprovider.NewTestCloudProvider(nil, nil)
+	other.AddNodeGroup("other-ng", 0, 10, 1)
+	other.AddNode("other-ng", buildTestNode("n3", "other://n3"))
+
+	specs := []ProviderSpec{
+		{Name: "onprem", ProviderIDPrefix: "onprem://"},
+		{Name: "cloud", ProviderIDPrefix: "cloud://"},
+		{Name: "other", ProviderIDPrefix: ""},
+	}
+	providers := []cloudprovider.CloudProvider{onPrem, cloud, other}
+	resourceLimiter := cloudprovider.NewResourceLimiter(
+		map[string]int64{cloudprovider.ResourceNameCores: 10},
+		map[string]int64{cloudprovider.ResourceNameCores: 100, cloudprovider.ResourceNameMemory: 1000})
+	provider, err := NewCloudProvider(specs, providers, resourceLimiter)
+	assert.NoError(t, err)
+	return provider.(*compositeCloudProvider), []*testprovider.TestCloudProvider{onPrem, cloud, other}
+}
+
+func TestParseProviderSpec(t *testing.T) {
+	spec, err := ParseProviderSpec("gce:/etc/gce.conf")
+	assert.NoError(t, err)
+	assert.Equal(t, ProviderSpec{Name: "gce", CloudConfig: "/etc/gce.conf", ProviderIDPrefix: "gce://"}, spec)
+
+	spec, err = ParseProviderSpec("clusterapi:")
+	assert.NoError(t, err)
+	assert.Equal(t, ProviderSpec{Name: "clusterapi", CloudConfig: "", ProviderIDPrefix: "clusterapi://"}, spec)
+
+	spec, err = ParseProviderSpec("clusterapi::openstack:///")
+	assert.NoError(t, err)
+	assert.Equal(t, ProviderSpec{Name: "clusterapi", CloudConfig: "", ProviderIDPrefix: "openstack:///"}, spec)
+
+	_, err = ParseProviderSpec("gce")
+	assert.Error(t, err)
+	_, err = ParseProviderSpec(":/etc/gce.conf")
+	assert.Error(t, err)
+	_, err = ParseProviderSpec("composite:")
+	assert.Error(t, err)
+}
+
+func TestSplitNodeGroupDiscoveryOptions(t *testing.T) {
+	specs := []ProviderSpec{
+		{Name: "aws", ProviderIDPrefix: "aws://"},
+		{Name: "gce", ProviderIDPrefix: "gce://"},
+		{Name: "clusterapi", ProviderIDPrefix: "openstack:///"},
+	}
+	options, err := SplitNodeGroupDiscoveryOptions(specs, cloudprovider.NodeGroupDiscoveryOptions{
+		NodeGroupSpecs: []string{
+			"aws/1:10:my-asg",
+			"gce/1:5:https://www.googleapis.com/compute/v1/projects/p/zones/z/instanceGroups/mig",
+			"aws/0:3:other-asg",
+		},
+		NodeGroupAutoDiscoverySpecs: []string{"aws/asg:tag=k8s.io/cluster-autoscaler/enabled"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []cloudprovider.NodeGroupDiscoveryOptions{
+		{
+			NodeGroupSpecs:              []string{"1:10:my-asg", "0:3:other-asg"},
+			NodeGroupAutoDiscoverySpecs: []string{"asg:tag=k8s.io/cluster-autoscaler/enabled"},
+		},
+		{
+			NodeGroupSpecs: []string{"1:5:https://www.googleapis.com/compute/v1/projects/p/zones/z/instanceGroups/mig"},
+		},
+		{},
+	}, options)
+
+	// Specs without a provider, of an unknown provider or of a provider listed twice can't be routed.
+	for _, spec := range []string{"1:10:my-asg", "azure/1:10:vmss", "aws"} {
+		_, err = SplitNodeGroupDiscoveryOptions(specs, cloudprovider.NodeGroupDiscoveryOptions{NodeGroupSpecs: []string{spec}})
+		assert.Error(t, err, spec)
+	}
+	_, err = SplitNodeGroupDiscoveryOptions(append(specs, ProviderSpec{Name: "aws", ProviderIDPrefix: "aws://other"}),
+		cloudprovider.NodeGroupDiscoveryOptions{NodeGroupSpecs: []string{"aws/1:10:my-asg"}})
+	assert.Error(t, err)
+}
+
+func TestNodeGroups(t *testing.T) {
+	provider, _ := newTestCompositeCloudProvider(t)
+	assert.Equal(t, []string{"cloud-ng", "onprem-ng", "other-ng"}, nodeGroupIds(provider.NodeGroups()))
+}
+
+func TestNodeGroupForNode(t *testing.T) {
+	provider, _ := newTestCompositeCloudProvider(t)
+
+	ng, err := provider.NodeGroupForNode(buildTestNode("n1", "onprem://rack1/n1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "onprem-ng", ng.Id())
+
+	ng, err = provider.NodeGroupForNode(buildTestNode("n2", "cloud://zone/n2"))
+	assert.NoError(t, err)
+	assert.Equal(t, "cloud-ng", ng.Id())
+
+	// Routed to the catch-all provider.
+	ng, err = provider.NodeGroupForNode(buildTestNode("n3", "other://n3"))
+	assert.NoError(t, err)
+	assert.Equal(t, "other-ng", ng.Id())
+
+	// Routed by providerID only, the cloud provider doesn't know the node.
+	ng, err = provider.NodeGroupForNode(buildTestNode("n1", "cloud://zone/n1"))
+	assert.NoError(t, err)
+	assert.Nil(t, ng)
+
+	// No catch-all provider.
+	provider.prefixes[2] = "other://"
+	ng, err = provider.NodeGroupForNode(buildTestNode("n4", "unknown://n4"))
+	assert.NoError(t, err)
+	assert.Nil(t, ng)
+}
+
+func TestGetResourceLimiter(t *testing.T) {
+	provider, testProviders := newTestCompositeCloudProvider(t)
+	// Limits of the wrapped providers are ignored, the shared limits apply to all of them together.
+	testProviders[1].SetResourceLimiter(cloudprovider.NewResourceLimiter(
+		map[string]int64{cloudprovider.ResourceNameCores: 20},
+		map[string]int64{cloudprovider.ResourceNameCores: 200}))
+
+	resourceLimiter, err := provider.GetResourceLimiter()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), resourceLimiter.GetMin(cloudprovider.ResourceNameCores))
+	assert.Equal(t, int64(100), resourceLimiter.GetMax(cloudprovider.ResourceNameCores))
+	assert.Equal(t, int64(1000), resourceLimiter.GetMax(cloudprovider.ResourceNameMemory))
+	assert.False(t, resourceLimiter.HasMaxLimitSet("nvidia.com/gpu"))
+}
+
+func TestPricing(t *testing.T) {
+	provider, testProviders := newTestCompositeCloudProvider(t)
+	_, pricingErr := provider.Pricing()
+	assert.Equal(t, cloudprovider.ErrNotImplemented, pricingErr)
+
+	testProviders[1].SetPricingModel(&testPricingModel{
+		nodePrice: map[string]float64{"n2": 2.0, "template": 3.0},
+		podPrice:  map[string]float64{"p1": 0.5},
+	})
+	testProviders[2].SetPricingModel(&testPricingModel{
+		nodePrice: map[string]float64{"n3": 4.0, "other-template": 5.0},
+	})
+	pricingModel, pricingErr := provider.Pricing()
+	assert.NoError(t, pricingErr)
+
+	now := time.Now()
+	price, err := pricingModel.NodePrice(buildTestNode("n2", "cloud://zone/n2"), now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, price)
+	price, err = pricingModel.NodePrice(buildTestNode("n3", "other://n3"), now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 4.0, price)
+	// The on-prem provider has no pricing model.
+	_, err = pricingModel.NodePrice(buildTestNode("n1", "onprem://rack1/n1"), now, now.Add(time.Hour))
+	assert.Error(t, err)
+
+	// Template nodes without providerID go to the first pricing model able to price them.
+	provider.prefixes[2] = "other://"
+	price, err = pricingModel.NodePrice(buildTestNode("template", ""), now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, price)
+	price, err = pricingModel.NodePrice(buildTestNode("other-template", ""), now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, price)
+
+	price, err = pricingModel.PodPrice(BuildTestPod("p1", 100, 100), now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, price)
+}
+
+func TestGPULabel(t *testing.T) {
+	provider, _ := newTestCompositeCloudProvider(t)
+	assert.Equal(t, "TestGPULabel/accelerator", provider.GPULabel())
+
+	specs := []ProviderSpec{
+		{Name: "onprem", ProviderIDPrefix: "onprem://"},
+		{Name: "cloud", ProviderIDPrefix: "cloud://"},
+	}
+	providers := []cloudprovider.CloudProvider{
+		testprovider.NewTestCloudProvider(nil, nil),
+		&gpuLabelCloudProvider{TestCloudProvider: testprovider.NewTestCloudProvider(nil, nil), gpuLabel: "cloud.com/gpu"},
+	}
+	_, err := NewCloudProvider(specs, providers, nil)
+	assert.Error(t, err)
+}
+
+func TestRefreshAndCleanup(t *testing.T) {
+	provider, _ := newTestCompositeCloudProvider(t)
+	assert.NoError(t, provider.Refresh())
+	assert.NoError(t, provider.Cleanup())
+
+	failing := &failingRefreshCloudProvider{TestCloudProvider: testprovider.NewTestCloudProvider(nil, nil)}
+	healthy := &failingRefreshCloudProvider{TestCloudProvider: testprovider.NewTestCloudProvider(nil, nil)}
+	provider.providers = []cloudprovider.CloudProvider{failing, provider.providers[1], healthy}
+	// Every provider is refreshed, even after a failure.
+	assert.Error(t, provider.Refresh())
+	assert.True(t, failing.refreshed)
+	assert.True(t, healthy.refreshed)
+	assert.Error(t, provider.Cleanup())
+}
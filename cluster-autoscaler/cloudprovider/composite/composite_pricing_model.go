@@ -0,0 +1,65 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"fmt"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+)
+
+// compositePricingModel implements PricingModel interface by routing nodes to the pricing model
+// of their cloud provider. Pods are priced by the pricing model of the first cloud provider having one.
+type compositePricingModel struct {
+	cloudProvider *compositeCloudProvider
+	// pricingModels of the wrapped cloud providers, nil for the ones without a pricing model.
+	pricingModels   []cloudprovider.PricingModel
+	podPricingModel cloudprovider.PricingModel
+}
+
+// NodePrice returns a price of running the given node for a given period of time.
+// All prices returned by the structure should be in the same currency.
+// Nodes without a matching providerID, like the template nodes of node groups, are priced
+// by the first pricing model able to price them.
+func (m *compositePricingModel) NodePrice(node *apiv1.Node, startTime time.Time, endTime time.Time) (float64, error) {
+	if i := m.cloudProvider.providerIndexForNode(node); i >= 0 {
+		if m.pricingModels[i] == nil {
+			return 0, fmt.Errorf("cloud provider %s of node %s has no pricing model", m.cloudProvider.providers[i].Name(), node.Name)
+		}
+		return m.pricingModels[i].NodePrice(node, startTime, endTime)
+	}
+	var errs []error
+	for _, pricingModel := range m.pricingModels {
+		if pricingModel == nil {
+			continue
+		}
+		price, err := pricingModel.NodePrice(node, startTime, endTime)
+		if err == nil {
+			return price, nil
+		}
+		errs = append(errs, err)
+	}
+	return 0, fmt.Errorf("no pricing model can price node %s: %v", node.Name, errs)
+}
+
+// PodPrice returns a theoretical minimum price of running a pod for a given
+// period of time on a perfectly matching machine.
+func (m *compositePricingModel) PodPrice(pod *apiv1.Pod, startTime time.Time, endTime time.Time) (float64, error) {
+	return m.podPricingModel.PodPrice(pod, startTime, endTime)
+}
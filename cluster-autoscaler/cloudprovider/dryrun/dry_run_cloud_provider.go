@@ -0,0 +1,210 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dryrun
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+)
+
+// ActionType is the type of a cloud provider call recorded in dry-run mode.
+type ActionType string
+
+const (
+	// IncreaseSize is recorded instead of NodeGroup.IncreaseSize.
+	IncreaseSize ActionType = "IncreaseSize"
+	// DeleteNodes is recorded instead of NodeGroup.DeleteNodes.
+	DeleteNodes ActionType = "DeleteNodes"
+	// DecreaseTargetSize is recorded instead of NodeGroup.DecreaseTargetSize.
+	DecreaseTargetSize ActionType = "DecreaseTargetSize"
+	// CreateNodeGroup is recorded instead of NodeGroup.Create.
+	CreateNodeGroup ActionType = "CreateNodeGroup"
+	// DeleteNodeGroup is recorded instead of NodeGroup.Delete.
+	DeleteNodeGroup ActionType = "DeleteNodeGroup"
+)
+
+// Action is a cloud provider call which was recorded instead of being executed.
+type Action struct {
+	Type      ActionType
+	NodeGroup string
+	// Delta is the requested size change of the node group, if the call changes it.
+	Delta int
+	// Nodes are the nodes which would be deleted.
+	Nodes []*apiv1.Node
+	Time  time.Time
+}
+
+// CloudProvider wraps a cloud provider so that node groups can be observed but not modified.
+// Calls which would change node groups are recorded as Actions and reported as successful.
+// Their effect is simulated on top of the wrapped node groups: target sizes include the recorded
+// size changes and deleted nodes are removed, so that later loops see the recorded decisions
+// as in progress, as they would if the calls were executed.
+type CloudProvider struct {
+	cloudprovider.CloudProvider
+
+	lock    sync.Mutex
+	actions []Action
+	// sizeDeltas are the target size changes of node groups recorded so far.
+	sizeDeltas map[string]int
+	// deletedNodes are the provider ids of the nodes deleted so far.
+	deletedNodes map[string]bool
+}
+
+// NewCloudProvider wraps the given cloud provider for dry-run mode.
+func NewCloudProvider(cloudProvider cloudprovider.CloudProvider) *CloudProvider {
+	return &CloudProvider{
+		CloudProvider: cloudProvider,
+		sizeDeltas:    make(map[string]int),
+		deletedNodes:  make(map[string]bool),
+	}
+}
+
+// NodeGroups returns all node groups of the wrapped cloud provider.
+func (provider *CloudProvider) NodeGroups() []cloudprovider.NodeGroup {
+	nodeGroups := provider.CloudProvider.NodeGroups()
+	result := make([]cloudprovider.NodeGroup, 0, len(nodeGroups))
+	for _, nodeGroup := range nodeGroups {
+		result = append(result, provider.wrap(nodeGroup))
+	}
+	return result
+}
+
+// NodeGroupForNode returns the node group of the given node in the wrapped cloud provider.
+// Deleted nodes don't belong to any node group.
+func (provider *CloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovider.NodeGroup, error) {
+	if provider.isDeleted(node.Spec.ProviderID) {
+		return nil, nil
+	}
+	nodeGroup, err := provider.CloudProvider.NodeGroupForNode(node)
+	if err != nil || nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
+		return nil, err
+	}
+	return provider.wrap(nodeGroup), nil
+}
+
+// NewNodeGroup builds a theoretical node group in the wrapped cloud provider.
+func (provider *CloudProvider) NewNodeGroup(machineType string, labels map[string]string, systemLabels map[string]string,
+	taints []apiv1.Taint, extraResources map[string]resource.Quantity) (cloudprovider.NodeGroup, error) {
+	nodeGroup, err := provider.CloudProvider.NewNodeGroup(machineType, labels, systemLabels, taints, extraResources)
+	if err != nil {
+		return nil, err
+	}
+	return provider.wrap(nodeGroup), nil
+}
+
+// TakeActions returns the actions recorded since the last call and forgets them.
+func (provider *CloudProvider) TakeActions() []Action {
+	provider.lock.Lock()
+	defer provider.lock.Unlock()
+	actions := provider.actions
+	provider.actions = nil
+	return actions
+}
+
+// record records the action and simulates its effect on the node group.
+func (provider *CloudProvider) record(action Action) {
+	provider.lock.Lock()
+	defer provider.lock.Unlock()
+	action.Time = time.Now()
+	provider.actions = append(provider.actions, action)
+	provider.sizeDeltas[action.NodeGroup] += action.Delta
+	for _, node := range action.Nodes {
+		provider.deletedNodes[node.Spec.ProviderID] = true
+	}
+}
+
+func (provider *CloudProvider) sizeDelta(nodeGroup string) int {
+	provider.lock.Lock()
+	defer provider.lock.Unlock()
+	return provider.sizeDeltas[nodeGroup]
+}
+
+func (provider *CloudProvider) isDeleted(providerID string) bool {
+	provider.lock.Lock()
+	defer provider.lock.Unlock()
+	return providerID != "" && provider.deletedNodes[providerID]
+}
+
+func (provider *CloudProvider) wrap(nodeGroup cloudprovider.NodeGroup) cloudprovider.NodeGroup {
+	return &NodeGroup{NodeGroup: nodeGroup, provider: provider}
+}
+
+// NodeGroup wraps a node group of a cloud provider in dry-run mode.
+type NodeGroup struct {
+	cloudprovider.NodeGroup
+	provider *CloudProvider
+}
+
+// TargetSize returns the target size of the wrapped node group, including the recorded size changes.
+func (nodeGroup *NodeGroup) TargetSize() (int, error) {
+	size, err := nodeGroup.NodeGroup.TargetSize()
+	if err != nil {
+		return 0, err
+	}
+	return size + nodeGroup.provider.sizeDelta(nodeGroup.Id()), nil
+}
+
+// Nodes returns the nodes of the wrapped node group, except for the deleted ones.
+func (nodeGroup *NodeGroup) Nodes() ([]cloudprovider.Instance, error) {
+	instances, err := nodeGroup.NodeGroup.Nodes()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]cloudprovider.Instance, 0, len(instances))
+	for _, instance := range instances {
+		if !nodeGroup.provider.isDeleted(instance.Id) {
+			result = append(result, instance)
+		}
+	}
+	return result, nil
+}
+
+// IncreaseSize records the size increase without changing the node group.
+func (nodeGroup *NodeGroup) IncreaseSize(delta int) error {
+	nodeGroup.provider.record(Action{Type: IncreaseSize, NodeGroup: nodeGroup.Id(), Delta: delta})
+	return nil
+}
+
+// DeleteNodes records the node deletion without changing the node group.
+func (nodeGroup *NodeGroup) DeleteNodes(nodes []*apiv1.Node) error {
+	nodeGroup.provider.record(Action{Type: DeleteNodes, NodeGroup: nodeGroup.Id(), Delta: -len(nodes), Nodes: nodes})
+	return nil
+}
+
+// DecreaseTargetSize records the target size decrease without changing the node group.
+func (nodeGroup *NodeGroup) DecreaseTargetSize(delta int) error {
+	nodeGroup.provider.record(Action{Type: DecreaseTargetSize, NodeGroup: nodeGroup.Id(), Delta: delta})
+	return nil
+}
+
+// Create records the node group creation without creating it. The returned node group
+// doesn't exist in the cloud provider.
+func (nodeGroup *NodeGroup) Create() (cloudprovider.NodeGroup, error) {
+	nodeGroup.provider.record(Action{Type: CreateNodeGroup, NodeGroup: nodeGroup.Id()})
+	return nodeGroup, nil
+}
+
+// Delete records the node group deletion without deleting it.
+func (nodeGroup *NodeGroup) Delete() error {
+	nodeGroup.provider.record(Action{Type: DeleteNodeGroup, NodeGroup: nodeGroup.Id()})
+	return nil
+}
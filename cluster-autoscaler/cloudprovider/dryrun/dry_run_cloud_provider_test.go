@@ -0,0 +1,100 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dryrun
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRunCloudProvider(t *testing.T) {
+	n1 := BuildTestNode("n1", 1000, 1000)
+	n2 := BuildTestNode("n2", 1000, 1000)
+	wrapped := testprovider.NewTestCloudProvider(
+		func(nodeGroup string, delta int) error {
+			t.Fatalf("unexpected scale-up of %s", nodeGroup)
+			return nil
+		}, func(nodeGroup string, node string) error {
+			t.Fatalf("unexpected scale-down of %s", nodeGroup)
+			return nil
+		})
+	wrapped.AddNodeGroup("ng1", 1, 10, 2)
+	wrapped.AddNode("ng1", n1)
+	wrapped.AddNode("ng1", n2)
+	provider := NewCloudProvider(wrapped)
+
+	nodeGroups := provider.NodeGroups()
+	assert.Equal(t, 1, len(nodeGroups))
+	assert.NoError(t, nodeGroups[0].IncreaseSize(3))
+	assert.NoError(t, nodeGroups[0].DecreaseTargetSize(-1))
+
+	nodeGroup, err := provider.NodeGroupForNode(n1)
+	assert.NoError(t, err)
+	assert.Equal(t, "ng1", nodeGroup.Id())
+	assert.NoError(t, nodeGroup.DeleteNodes([]*apiv1.Node{n1}))
+
+	nodeGroup, err = provider.NodeGroupForNode(BuildTestNode("n3", 1000, 1000))
+	assert.NoError(t, err)
+	assert.Nil(t, nodeGroup)
+
+	size, err := wrapped.GetNodeGroup("ng1").TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, size)
+
+	// The recorded calls are simulated on top of the wrapped node group.
+	size, err = nodeGroups[0].TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, size)
+	instances, err := nodeGroups[0].Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, []cloudprovider.Instance{{Id: "n2"}}, instances)
+	nodeGroup, err = provider.NodeGroupForNode(n1)
+	assert.NoError(t, err)
+	assert.Nil(t, nodeGroup)
+
+	actions := provider.TakeActions()
+	assert.Equal(t, 3, len(actions))
+	assert.Equal(t, Action{Type: IncreaseSize, NodeGroup: "ng1", Delta: 3, Time: actions[0].Time}, actions[0])
+	assert.Equal(t, Action{Type: DecreaseTargetSize, NodeGroup: "ng1", Delta: -1, Time: actions[1].Time}, actions[1])
+	assert.Equal(t, Action{Type: DeleteNodes, NodeGroup: "ng1", Delta: -1, Nodes: []*apiv1.Node{n1}, Time: actions[2].Time}, actions[2])
+	assert.Empty(t, provider.TakeActions())
+}
+
+func TestDryRunCloudProviderAutoprovisioning(t *testing.T) {
+	wrapped := testprovider.NewTestAutoprovisioningCloudProvider(nil, nil, nil, nil, []string{"T1"}, nil)
+	provider := NewCloudProvider(wrapped)
+
+	nodeGroup, err := provider.NewNodeGroup("T1", nil, nil, nil, nil)
+	assert.NoError(t, err)
+	created, err := nodeGroup.Create()
+	assert.NoError(t, err)
+	assert.False(t, created.Exist())
+	assert.NoError(t, created.IncreaseSize(1))
+	assert.Empty(t, wrapped.NodeGroups())
+
+	actions := provider.TakeActions()
+	assert.Equal(t, 2, len(actions))
+	assert.Equal(t, CreateNodeGroup, actions[0].Type)
+	assert.Equal(t, IncreaseSize, actions[1].Type)
+	assert.Equal(t, nodeGroup.Id(), actions[1].NodeGroup)
+}
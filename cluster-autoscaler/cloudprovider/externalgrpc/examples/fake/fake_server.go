@@ -0,0 +1,364 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fake contains an example implementation of the external gRPC cloud
+// provider service. The Server wraps any cloudprovider.CloudProvider and exposes
+// it over gRPC, which makes it usable both as a starting point for writing
+// a real external cloud provider and as an in-process server for tests.
+package fake
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/externalgrpc/protos"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+)
+
+// Server implements protos.CloudProviderServer on top of a cloudprovider.CloudProvider.
+type Server struct {
+	provider cloudprovider.CloudProvider
+}
+
+// NewServer returns a Server forwarding all the calls to the given cloud provider.
+func NewServer(provider cloudprovider.CloudProvider) *Server {
+	return &Server{provider: provider}
+}
+
+// Serve registers the server on a new gRPC server and starts serving on the given listener.
+// It returns the gRPC server so that the caller can stop it.
+func (s *Server) Serve(listener net.Listener) *grpc.Server {
+	grpcServer := grpc.NewServer()
+	protos.RegisterCloudProviderServer(grpcServer, s)
+	go grpcServer.Serve(listener)
+	return grpcServer
+}
+
+// NodeGroups returns all node groups configured for the cloud provider.
+func (s *Server) NodeGroups(_ context.Context, _ *protos.NodeGroupsRequest) (*protos.NodeGroupsResponse, error) {
+	pbNodeGroups := make([]*protos.NodeGroup, 0)
+	for _, ng := range s.provider.NodeGroups() {
+		pbNodeGroups = append(pbNodeGroups, protoNodeGroup(ng))
+	}
+	return &protos.NodeGroupsResponse{NodeGroups: pbNodeGroups}, nil
+}
+
+// NodeGroupForNode returns the node group for the given node. An empty node group
+// is returned if the node is not handled by cluster autoscaler.
+func (s *Server) NodeGroupForNode(_ context.Context, req *protos.NodeGroupForNodeRequest) (*protos.NodeGroupForNodeResponse, error) {
+	if req.GetNode() == nil {
+		return nil, status.Error(codes.InvalidArgument, "node is not set")
+	}
+	ng, err := s.provider.NodeGroupForNode(apiv1Node(req.GetNode()))
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+	if ng == nil {
+		return &protos.NodeGroupForNodeResponse{NodeGroup: &protos.NodeGroup{}}, nil
+	}
+	return &protos.NodeGroupForNodeResponse{NodeGroup: protoNodeGroup(ng)}, nil
+}
+
+// PricingNodePrice returns a price of running the given node for a given period of time.
+func (s *Server) PricingNodePrice(_ context.Context, req *protos.PricingNodePriceRequest) (*protos.PricingNodePriceResponse, error) {
+	model, err := s.provider.Pricing()
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+	startTime, endTime, terr := timeRange(req.GetStartTime(), req.GetEndTime())
+	if terr != nil {
+		return nil, status.Error(codes.InvalidArgument, terr.Error())
+	}
+	node := &apiv1.Node{}
+	if nerr := node.Unmarshal(req.GetNode()); nerr != nil {
+		return nil, status.Error(codes.InvalidArgument, nerr.Error())
+	}
+	price, perr := model.NodePrice(node, startTime, endTime)
+	if perr != nil {
+		return nil, toGrpcError(perr)
+	}
+	return &protos.PricingNodePriceResponse{Price: price}, nil
+}
+
+// PricingPodPrice returns a theoretical minimum price of running a pod for a given period of time.
+func (s *Server) PricingPodPrice(_ context.Context, req *protos.PricingPodPriceRequest) (*protos.PricingPodPriceResponse, error) {
+	model, err := s.provider.Pricing()
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+	startTime, endTime, terr := timeRange(req.GetStartTime(), req.GetEndTime())
+	if terr != nil {
+		return nil, status.Error(codes.InvalidArgument, terr.Error())
+	}
+	pod := &apiv1.Pod{}
+	if perr := pod.Unmarshal(req.GetPod()); perr != nil {
+		return nil, status.Error(codes.InvalidArgument, perr.Error())
+	}
+	price, perr := model.PodPrice(pod, startTime, endTime)
+	if perr != nil {
+		return nil, toGrpcError(perr)
+	}
+	return &protos.PricingPodPriceResponse{Price: price}, nil
+}
+
+// GPULabel returns the label added to nodes with GPU resource.
+func (s *Server) GPULabel(_ context.Context, _ *protos.GPULabelRequest) (*protos.GPULabelResponse, error) {
+	return &protos.GPULabelResponse{Label: s.provider.GPULabel()}, nil
+}
+
+// GetAvailableGPUTypes return all available GPU types the cloud provider supports.
+func (s *Server) GetAvailableGPUTypes(_ context.Context, _ *protos.GetAvailableGPUTypesRequest) (*protos.GetAvailableGPUTypesResponse, error) {
+	gpuTypes := make([]string, 0)
+	for gpuType := range s.provider.GetAvailableGPUTypes() {
+		gpuTypes = append(gpuTypes, gpuType)
+	}
+	return &protos.GetAvailableGPUTypesResponse{GpuTypes: gpuTypes}, nil
+}
+
+// Cleanup cleans up open resources before the cloud provider is destroyed.
+func (s *Server) Cleanup(_ context.Context, _ *protos.CleanupRequest) (*protos.CleanupResponse, error) {
+	if err := s.provider.Cleanup(); err != nil {
+		return nil, toGrpcError(err)
+	}
+	return &protos.CleanupResponse{}, nil
+}
+
+// Refresh is called before every main loop and can be used to dynamically update cloud provider state.
+func (s *Server) Refresh(_ context.Context, _ *protos.RefreshRequest) (*protos.RefreshResponse, error) {
+	if err := s.provider.Refresh(); err != nil {
+		return nil, toGrpcError(err)
+	}
+	return &protos.RefreshResponse{}, nil
+}
+
+// NodeGroupTargetSize returns the current target size of the node group.
+func (s *Server) NodeGroupTargetSize(_ context.Context, req *protos.NodeGroupTargetSizeRequest) (*protos.NodeGroupTargetSizeResponse, error) {
+	ng, err := s.getNodeGroup(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	size, err := ng.TargetSize()
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+	return &protos.NodeGroupTargetSizeResponse{TargetSize: int32(size)}, nil
+}
+
+// NodeGroupIncreaseSize increases the size of the node group.
+func (s *Server) NodeGroupIncreaseSize(_ context.Context, req *protos.NodeGroupIncreaseSizeRequest) (*protos.NodeGroupIncreaseSizeResponse, error) {
+	ng, err := s.getNodeGroup(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if err := ng.IncreaseSize(int(req.GetDelta())); err != nil {
+		return nil, toGrpcError(err)
+	}
+	return &protos.NodeGroupIncreaseSizeResponse{}, nil
+}
+
+// NodeGroupDeleteNodes deletes nodes from the node group.
+func (s *Server) NodeGroupDeleteNodes(_ context.Context, req *protos.NodeGroupDeleteNodesRequest) (*protos.NodeGroupDeleteNodesResponse, error) {
+	ng, err := s.getNodeGroup(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*apiv1.Node, 0, len(req.GetNodes()))
+	for _, pbNode := range req.GetNodes() {
+		nodes = append(nodes, apiv1Node(pbNode))
+	}
+	if err := ng.DeleteNodes(nodes); err != nil {
+		return nil, toGrpcError(err)
+	}
+	return &protos.NodeGroupDeleteNodesResponse{}, nil
+}
+
+// NodeGroupDecreaseTargetSize decreases the target size of the node group.
+func (s *Server) NodeGroupDecreaseTargetSize(_ context.Context, req *protos.NodeGroupDecreaseTargetSizeRequest) (*protos.NodeGroupDecreaseTargetSizeResponse, error) {
+	ng, err := s.getNodeGroup(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if err := ng.DecreaseTargetSize(int(req.GetDelta())); err != nil {
+		return nil, toGrpcError(err)
+	}
+	return &protos.NodeGroupDecreaseTargetSizeResponse{}, nil
+}
+
+// NodeGroupNodes returns a list of all nodes that belong to the node group.
+func (s *Server) NodeGroupNodes(_ context.Context, req *protos.NodeGroupNodesRequest) (*protos.NodeGroupNodesResponse, error) {
+	ng, err := s.getNodeGroup(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	instances, err := ng.Nodes()
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+	pbInstances := make([]*protos.Instance, 0, len(instances))
+	for _, instance := range instances {
+		pbInstances = append(pbInstances, &protos.Instance{
+			Id:     instance.Id,
+			Status: protoInstanceStatus(instance.Status),
+		})
+	}
+	return &protos.NodeGroupNodesResponse{Instances: pbInstances}, nil
+}
+
+// NodeGroupTemplateNodeInfo returns a node template for the node group.
+func (s *Server) NodeGroupTemplateNodeInfo(_ context.Context, req *protos.NodeGroupTemplateNodeInfoRequest) (*protos.NodeGroupTemplateNodeInfoResponse, error) {
+	ng, err := s.getNodeGroup(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	nodeInfo, err := ng.TemplateNodeInfo()
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+	pbNode, err := nodeInfo.Node().Marshal()
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+	return &protos.NodeGroupTemplateNodeInfoResponse{NodeInfo: pbNode}, nil
+}
+
+// NodeGroupGetOptions returns NodeGroupAutoscalingOptions that should be used for the node group.
+func (s *Server) NodeGroupGetOptions(_ context.Context, req *protos.NodeGroupAutoscalingOptionsRequest) (*protos.NodeGroupAutoscalingOptionsResponse, error) {
+	ng, err := s.getNodeGroup(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	pbDefaults := req.GetDefaults()
+	if pbDefaults == nil {
+		return nil, status.Error(codes.InvalidArgument, "defaults are not set")
+	}
+	scaleDownUnneededTime, err := ptypes.Duration(pbDefaults.GetScaleDownUnneededTime())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	scaleDownUnreadyTime, err := ptypes.Duration(pbDefaults.GetScaleDownUnreadyTime())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	defaults := config.NodeGroupAutoscalingOptions{
+		ScaleDownUtilizationThreshold:    pbDefaults.GetScaleDownUtilizationThreshold(),
+		ScaleDownGpuUtilizationThreshold: pbDefaults.GetScaleDownGpuUtilizationThreshold(),
+		ScaleDownUnneededTime:            scaleDownUnneededTime,
+		ScaleDownUnreadyTime:             scaleDownUnreadyTime,
+	}
+	opts, err := ng.GetOptions(defaults)
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+	if opts == nil {
+		return &protos.NodeGroupAutoscalingOptionsResponse{}, nil
+	}
+	return &protos.NodeGroupAutoscalingOptionsResponse{
+		NodeGroupAutoscalingOptions: &protos.NodeGroupAutoscalingOptions{
+			ScaleDownUtilizationThreshold:    opts.ScaleDownUtilizationThreshold,
+			ScaleDownGpuUtilizationThreshold: opts.ScaleDownGpuUtilizationThreshold,
+			ScaleDownUnneededTime:            ptypes.DurationProto(opts.ScaleDownUnneededTime),
+			ScaleDownUnreadyTime:             ptypes.DurationProto(opts.ScaleDownUnreadyTime),
+		},
+	}, nil
+}
+
+func (s *Server) getNodeGroup(id string) (cloudprovider.NodeGroup, error) {
+	for _, ng := range s.provider.NodeGroups() {
+		if ng.Id() == id {
+			return ng, nil
+		}
+	}
+	return nil, status.Error(codes.NotFound, fmt.Sprintf("node group %s not found", id))
+}
+
+func timeRange(pbStartTime, pbEndTime *timestamp.Timestamp) (time.Time, time.Time, error) {
+	startTime, err := ptypes.Timestamp(pbStartTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	endTime, err := ptypes.Timestamp(pbEndTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return startTime, endTime, nil
+}
+
+func protoNodeGroup(ng cloudprovider.NodeGroup) *protos.NodeGroup {
+	return &protos.NodeGroup{
+		Id:      ng.Id(),
+		MinSize: int32(ng.MinSize()),
+		MaxSize: int32(ng.MaxSize()),
+		Debug:   ng.Debug(),
+	}
+}
+
+func apiv1Node(pbNode *protos.ExternalGrpcNode) *apiv1.Node {
+	return &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        pbNode.GetName(),
+			Labels:      pbNode.GetLabels(),
+			Annotations: pbNode.GetAnnotations(),
+		},
+		Spec: apiv1.NodeSpec{
+			ProviderID: pbNode.GetProviderID(),
+		},
+	}
+}
+
+func protoInstanceStatus(instanceStatus *cloudprovider.InstanceStatus) *protos.InstanceStatus {
+	if instanceStatus == nil {
+		return &protos.InstanceStatus{InstanceState: protos.InstanceStatus_unspecified}
+	}
+	pbStatus := &protos.InstanceStatus{}
+	switch instanceStatus.State {
+	case cloudprovider.InstanceRunning:
+		pbStatus.InstanceState = protos.InstanceStatus_instanceRunning
+	case cloudprovider.InstanceCreating:
+		pbStatus.InstanceState = protos.InstanceStatus_instanceCreating
+	case cloudprovider.InstanceDeleting:
+		pbStatus.InstanceState = protos.InstanceStatus_instanceDeleting
+	default:
+		pbStatus.InstanceState = protos.InstanceStatus_unspecified
+	}
+	if instanceStatus.ErrorInfo != nil {
+		pbStatus.ErrorInfo = &protos.InstanceErrorInfo{
+			ErrorCode:          instanceStatus.ErrorInfo.ErrorCode,
+			ErrorMessage:       instanceStatus.ErrorInfo.ErrorMessage,
+			InstanceErrorClass: int32(instanceStatus.ErrorInfo.ErrorClass),
+		}
+	}
+	return pbStatus
+}
+
+// toGrpcError maps cloud provider errors to gRPC errors, so that the client
+// can tell unimplemented optional methods apart from real failures.
+func toGrpcError(err error) error {
+	if err == cloudprovider.ErrNotImplemented {
+		return status.Error(codes.Unimplemented, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
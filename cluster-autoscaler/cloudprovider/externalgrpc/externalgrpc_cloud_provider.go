@@ -0,0 +1,353 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalgrpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"gopkg.in/gcfg.v1"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/externalgrpc/protos"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	"k8s.io/klog"
+)
+
+const (
+	// ProviderName is the cloud provider name for the external gRPC cloud provider.
+	ProviderName = "externalgrpc"
+
+	defaultGrpcTimeout = 5 * time.Second
+)
+
+// cloudConfig is the cloud config file for the external gRPC cloud provider.
+type cloudConfig struct {
+	Global struct {
+		// Address is the address of the external gRPC cloud provider service.
+		Address string `gcfg:"address"`
+		// Key is the path to the client private key used for TLS.
+		Key string `gcfg:"key"`
+		// Cert is the path to the client certificate used for TLS.
+		Cert string `gcfg:"cert"`
+		// Cacert is the path to the CA certificate used to verify the server.
+		Cacert string `gcfg:"cacert"`
+		// GrpcTimeout is the timeout of every single gRPC call, e.g. "5s".
+		GrpcTimeout string `gcfg:"grpc-timeout"`
+	}
+}
+
+// externalGrpcCloudProvider implements CloudProvider interface by forwarding
+// all calls to an external service over gRPC.
+type externalGrpcCloudProvider struct {
+	resourceLimiter *cloudprovider.ResourceLimiter
+	client          protos.CloudProviderClient
+	grpcTimeout     time.Duration
+
+	mutex                 sync.Mutex
+	nodeGroupsCache       []cloudprovider.NodeGroup          // cached result of NodeGroups, invalidated by Refresh
+	nodeGroupForNodeCache map[string]cloudprovider.NodeGroup // cached NodeGroupForNode responses by providerID, invalidated by Refresh
+	gpuLabelCache         *string                            // cached GPULabel response
+	gpuTypesCache         map[string]struct{}                // cached GetAvailableGPUTypes response
+}
+
+func newExternalGrpcCloudProvider(client protos.CloudProviderClient, rl *cloudprovider.ResourceLimiter, grpcTimeout time.Duration) *externalGrpcCloudProvider {
+	return &externalGrpcCloudProvider{
+		resourceLimiter:       rl,
+		client:                client,
+		grpcTimeout:           grpcTimeout,
+		nodeGroupForNodeCache: make(map[string]cloudprovider.NodeGroup),
+	}
+}
+
+// Name returns name of the cloud provider.
+func (e *externalGrpcCloudProvider) Name() string {
+	return ProviderName
+}
+
+// NodeGroups returns all node groups configured for this cloud provider.
+func (e *externalGrpcCloudProvider) NodeGroups() []cloudprovider.NodeGroup {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.nodeGroupsCache != nil {
+		return e.nodeGroupsCache
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.grpcTimeout)
+	defer cancel()
+	res, err := e.client.NodeGroups(ctx, &protos.NodeGroupsRequest{})
+	if err != nil {
+		klog.Errorf("Error on gRPC call NodeGroups: %v", err)
+		return []cloudprovider.NodeGroup{}
+	}
+	nodeGroups := make([]cloudprovider.NodeGroup, 0, len(res.GetNodeGroups()))
+	for _, pbNg := range res.GetNodeGroups() {
+		nodeGroups = append(nodeGroups, e.buildNodeGroup(pbNg))
+	}
+	e.nodeGroupsCache = nodeGroups
+	return nodeGroups
+}
+
+// NodeGroupForNode returns the node group for the given node, nil if the node
+// should not be processed by cluster autoscaler, or non-nil error if such
+// occurred.
+func (e *externalGrpcCloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovider.NodeGroup, error) {
+	if node == nil {
+		return nil, fmt.Errorf("node is nil")
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	// Nodes which haven't got a provider id yet can't be told apart in the cache.
+	cacheable := node.Spec.ProviderID != ""
+	if ng, ok := e.nodeGroupForNodeCache[node.Spec.ProviderID]; ok && cacheable {
+		return ng, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.grpcTimeout)
+	defer cancel()
+	res, err := e.client.NodeGroupForNode(ctx, &protos.NodeGroupForNodeRequest{
+		Node: externalGrpcNode(node),
+	})
+	if err != nil {
+		klog.V(1).Infof("Error on gRPC call NodeGroupForNode: %v", err)
+		return nil, convertError(err)
+	}
+	pbNg := res.GetNodeGroup()
+	if pbNg == nil || pbNg.GetId() == "" {
+		// The node is not handled by cluster autoscaler.
+		if cacheable {
+			e.nodeGroupForNodeCache[node.Spec.ProviderID] = nil
+		}
+		return nil, nil
+	}
+	ng := e.buildNodeGroup(pbNg)
+	if cacheable {
+		e.nodeGroupForNodeCache[node.Spec.ProviderID] = ng
+	}
+	return ng, nil
+}
+
+// Pricing returns pricing model for this cloud provider or error if not available.
+// The pricing calls are forwarded to the external service, which may not implement them.
+func (e *externalGrpcCloudProvider) Pricing() (cloudprovider.PricingModel, errors.AutoscalerError) {
+	return &pricingModel{
+		client:      e.client,
+		grpcTimeout: e.grpcTimeout,
+	}, nil
+}
+
+// GetAvailableMachineTypes is not implemented.
+func (e *externalGrpcCloudProvider) GetAvailableMachineTypes() ([]string, error) {
+	return []string{}, nil
+}
+
+// NewNodeGroup is not implemented.
+func (e *externalGrpcCloudProvider) NewNodeGroup(machineType string, labels map[string]string, systemLabels map[string]string,
+	taints []apiv1.Taint, extraResources map[string]resource.Quantity) (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// GetResourceLimiter returns struct containing limits (max, min) for resources (cores, memory etc.).
+func (e *externalGrpcCloudProvider) GetResourceLimiter() (*cloudprovider.ResourceLimiter, error) {
+	return e.resourceLimiter, nil
+}
+
+// GPULabel returns the label added to nodes with GPU resource.
+func (e *externalGrpcCloudProvider) GPULabel() string {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.gpuLabelCache != nil {
+		return *e.gpuLabelCache
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.grpcTimeout)
+	defer cancel()
+	res, err := e.client.GPULabel(ctx, &protos.GPULabelRequest{})
+	if err != nil {
+		klog.Errorf("Error on gRPC call GPULabel: %v", err)
+		return ""
+	}
+	gpuLabel := res.GetLabel()
+	e.gpuLabelCache = &gpuLabel
+	return gpuLabel
+}
+
+// GetAvailableGPUTypes return all available GPU types cloud provider supports.
+func (e *externalGrpcCloudProvider) GetAvailableGPUTypes() map[string]struct{} {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.gpuTypesCache != nil {
+		return e.gpuTypesCache
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.grpcTimeout)
+	defer cancel()
+	res, err := e.client.GetAvailableGPUTypes(ctx, &protos.GetAvailableGPUTypesRequest{})
+	if err != nil {
+		klog.Errorf("Error on gRPC call GetAvailableGPUTypes: %v", err)
+		return nil
+	}
+	gpuTypes := make(map[string]struct{}, len(res.GetGpuTypes()))
+	for _, gpuType := range res.GetGpuTypes() {
+		gpuTypes[gpuType] = struct{}{}
+	}
+	e.gpuTypesCache = gpuTypes
+	return gpuTypes
+}
+
+// Cleanup cleans up open resources before the cloud provider is destroyed, i.e. go routines etc.
+func (e *externalGrpcCloudProvider) Cleanup() error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.grpcTimeout)
+	defer cancel()
+	_, err := e.client.Cleanup(ctx, &protos.CleanupRequest{})
+	if err != nil {
+		klog.V(1).Infof("Error on gRPC call Cleanup: %v", err)
+		return convertError(err)
+	}
+	return nil
+}
+
+// Refresh is called before every main loop and can be used to dynamically update cloud provider state.
+// In particular the list of node groups returned by NodeGroups can change as a result of CloudProvider.Refresh().
+func (e *externalGrpcCloudProvider) Refresh() error {
+	e.mutex.Lock()
+	e.nodeGroupsCache = nil
+	e.nodeGroupForNodeCache = make(map[string]cloudprovider.NodeGroup)
+	e.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.grpcTimeout)
+	defer cancel()
+	_, err := e.client.Refresh(ctx, &protos.RefreshRequest{})
+	if err != nil {
+		klog.V(1).Infof("Error on gRPC call Refresh: %v", err)
+		return convertError(err)
+	}
+	return nil
+}
+
+func (e *externalGrpcCloudProvider) buildNodeGroup(pbNg *protos.NodeGroup) *NodeGroup {
+	return &NodeGroup{
+		id:          pbNg.GetId(),
+		minSize:     int(pbNg.GetMinSize()),
+		maxSize:     int(pbNg.GetMaxSize()),
+		debug:       pbNg.GetDebug(),
+		client:      e.client,
+		grpcTimeout: e.grpcTimeout,
+	}
+}
+
+// externalGrpcNode converts a node to the representation sent over the wire.
+func externalGrpcNode(node *apiv1.Node) *protos.ExternalGrpcNode {
+	return &protos.ExternalGrpcNode{
+		ProviderID:  node.Spec.ProviderID,
+		Name:        node.Name,
+		Labels:      node.Labels,
+		Annotations: node.Annotations,
+	}
+}
+
+// convertError maps gRPC errors to the errors expected from a cloud provider.
+func convertError(err error) error {
+	if status.Code(err) == codes.Unimplemented {
+		return cloudprovider.ErrNotImplemented
+	}
+	return err
+}
+
+// BuildExternalGrpc builds the external gRPC cloud provider.
+func BuildExternalGrpc(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) cloudprovider.CloudProvider {
+	if opts.CloudConfig == "" {
+		klog.Fatal("No config file provided, please specify it via the --cloud-config flag")
+	}
+	configFile, err := os.Open(opts.CloudConfig)
+	if err != nil {
+		klog.Fatalf("Couldn't open cloud provider configuration %s: %v", opts.CloudConfig, err)
+	}
+	defer configFile.Close()
+
+	cfg, err := readConfig(configFile)
+	if err != nil {
+		klog.Fatalf("Couldn't read cloud provider configuration %s: %v", opts.CloudConfig, err)
+	}
+	client, err := createGrpcClient(cfg)
+	if err != nil {
+		klog.Fatalf("Couldn't create gRPC client for %s: %v", cfg.Global.Address, err)
+	}
+	grpcTimeout := defaultGrpcTimeout
+	if cfg.Global.GrpcTimeout != "" {
+		grpcTimeout, err = time.ParseDuration(cfg.Global.GrpcTimeout)
+		if err != nil {
+			klog.Fatalf("Invalid grpc-timeout %q: %v", cfg.Global.GrpcTimeout, err)
+		}
+	}
+	return newExternalGrpcCloudProvider(client, rl, grpcTimeout)
+}
+
+func readConfig(configReader io.Reader) (*cloudConfig, error) {
+	cfg := &cloudConfig{}
+	if err := gcfg.ReadInto(cfg, configReader); err != nil {
+		return nil, err
+	}
+	if cfg.Global.Address == "" {
+		return nil, fmt.Errorf("address is not set")
+	}
+	return cfg, nil
+}
+
+func createGrpcClient(cfg *cloudConfig) (protos.CloudProviderClient, error) {
+	var dialOpt grpc.DialOption
+	if cfg.Global.Key == "" && cfg.Global.Cert == "" && cfg.Global.Cacert == "" {
+		klog.Warning("No TLS configuration provided, the connection to the external gRPC cloud provider will be insecure")
+		dialOpt = grpc.WithInsecure()
+	} else {
+		certificate, err := tls.LoadX509KeyPair(cfg.Global.Cert, cfg.Global.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client certificate: %v", err)
+		}
+		certPool := x509.NewCertPool()
+		ca, err := ioutil.ReadFile(cfg.Global.Cacert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %v", err)
+		}
+		if ok := certPool.AppendCertsFromPEM(ca); !ok {
+			return nil, fmt.Errorf("failed to append CA certificate")
+		}
+		dialOpt = grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			RootCAs:      certPool,
+		}))
+	}
+	conn, err := grpc.Dial(cfg.Global.Address, dialOpt)
+	if err != nil {
+		return nil, err
+	}
+	return protos.NewCloudProviderClient(conn), nil
+}
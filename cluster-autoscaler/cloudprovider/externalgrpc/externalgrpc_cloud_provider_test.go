@@ -0,0 +1,301 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalgrpc
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/externalgrpc/examples/fake"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/externalgrpc/protos"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+type testPricingModel struct{}
+
+func (testPricingModel) NodePrice(node *apiv1.Node, startTime time.Time, endTime time.Time) (float64, error) {
+	return endTime.Sub(startTime).Hours(), nil
+}
+
+func (testPricingModel) PodPrice(pod *apiv1.Pod, startTime time.Time, endTime time.Time) (float64, error) {
+	return 0.5 * endTime.Sub(startTime).Hours(), nil
+}
+
+// startFakeServer serves the given cloud provider through the example fake
+// server and returns a cloud provider connected to it.
+func startFakeServer(t *testing.T, provider cloudprovider.CloudProvider) (*externalGrpcCloudProvider, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := fake.NewServer(provider).Serve(listener)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	assert.NoError(t, err)
+	rl := cloudprovider.NewResourceLimiter(nil, nil)
+	client := newExternalGrpcCloudProvider(protos.NewCloudProviderClient(conn), rl, 5*time.Second)
+	return client, func() {
+		conn.Close()
+		server.Stop()
+	}
+}
+
+func TestExternalGrpcCloudProvider(t *testing.T) {
+	scaleUps := map[string]int{}
+	scaleDowns := map[string][]string{}
+	n1 := BuildTestNode("n1", 1000, 1000)
+	n1.Spec.ProviderID = "fake://n1"
+	n2 := BuildTestNode("n2", 1000, 1000)
+	n2.Spec.ProviderID = "fake://n2"
+	n3 := BuildTestNode("n3", 1000, 1000)
+	n3.Spec.ProviderID = "fake://n3"
+
+	template := schedulernodeinfo.NewNodeInfo()
+	template.SetNode(BuildTestNode("ng1-template", 2000, 2000))
+
+	provider := testprovider.NewTestAutoprovisioningCloudProvider(
+		func(id string, delta int) error {
+			scaleUps[id] += delta
+			return nil
+		}, func(id string, node string) error {
+			scaleDowns[id] = append(scaleDowns[id], node)
+			return nil
+		}, nil, nil, nil, map[string]*schedulernodeinfo.NodeInfo{"ng1": template})
+	provider.SetPricingModel(testPricingModel{})
+	provider.AddNodeGroup("ng1", 1, 10, 2)
+	provider.AddNode("ng1", n1)
+	provider.AddNode("ng1", n2)
+
+	client, stop := startFakeServer(t, provider)
+	defer stop()
+
+	assert.Equal(t, ProviderName, client.Name())
+	assert.Equal(t, provider.GPULabel(), client.GPULabel())
+	assert.Equal(t, provider.GetAvailableGPUTypes(), client.GetAvailableGPUTypes())
+	assert.NoError(t, client.Refresh())
+
+	nodeGroups := client.NodeGroups()
+	assert.Equal(t, 1, len(nodeGroups))
+	ng := nodeGroups[0]
+	assert.Equal(t, "ng1", ng.Id())
+	assert.Equal(t, 1, ng.MinSize())
+	assert.Equal(t, 10, ng.MaxSize())
+	assert.True(t, strings.HasPrefix(ng.Debug(), "ng1"))
+
+	ngForNode, err := client.NodeGroupForNode(n1)
+	assert.NoError(t, err)
+	assert.Equal(t, "ng1", ngForNode.Id())
+	ngForNode, err = client.NodeGroupForNode(n3)
+	assert.NoError(t, err)
+	assert.Nil(t, ngForNode)
+
+	size, err := ng.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, size)
+
+	assert.NoError(t, ng.IncreaseSize(3))
+	assert.Equal(t, 3, scaleUps["ng1"])
+	size, err = ng.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 5, size)
+
+	assert.NoError(t, ng.DecreaseTargetSize(-1))
+	assert.Equal(t, 2, scaleUps["ng1"])
+
+	assert.NoError(t, ng.DeleteNodes([]*apiv1.Node{n2}))
+	assert.Equal(t, []string{"n2"}, scaleDowns["ng1"])
+
+	instances, err := ng.Nodes()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []cloudprovider.Instance{{Id: "n1"}, {Id: "n2"}}, instances)
+
+	nodeInfo, err := ng.TemplateNodeInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, "ng1-template", nodeInfo.Node().Name)
+	assert.Equal(t, int64(2000), nodeInfo.Node().Status.Capacity.Cpu().MilliValue())
+	assert.Equal(t, int64(2000), nodeInfo.Node().Status.Capacity.Memory().Value())
+	assert.Equal(t, 1, len(nodeInfo.Pods()))
+
+	pricing, err := client.Pricing()
+	assert.NoError(t, err)
+	now := time.Now()
+	price, err := pricing.NodePrice(n1, now, now.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.InDelta(t, 2.0, price, 1e-6)
+	price, err = pricing.PodPrice(BuildTestPod("p1", 100, 100), now, now.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.InDelta(t, 1.0, price, 1e-6)
+
+	assert.NoError(t, client.Cleanup())
+}
+
+type capacityPricingModel struct{}
+
+func (capacityPricingModel) NodePrice(node *apiv1.Node, startTime time.Time, endTime time.Time) (float64, error) {
+	price := float64(node.Status.Capacity.Cpu().MilliValue()) / 1000
+	if node.Labels["tier"] == "premium" {
+		price *= 2
+	}
+	return price * endTime.Sub(startTime).Hours(), nil
+}
+
+func (capacityPricingModel) PodPrice(pod *apiv1.Pod, startTime time.Time, endTime time.Time) (float64, error) {
+	return 0, nil
+}
+
+func TestExternalGrpcNodeGroupForNodeWithoutProviderID(t *testing.T) {
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+	provider.AddNodeGroup("ng2", 1, 10, 1)
+	// Nodes get their providerID some time after they register.
+	n1 := BuildTestNode("n1", 1000, 1000)
+	n1.Spec.ProviderID = ""
+	n2 := BuildTestNode("n2", 1000, 1000)
+	n2.Spec.ProviderID = ""
+	n3 := BuildTestNode("n3", 1000, 1000)
+	n3.Spec.ProviderID = ""
+	provider.AddNode("ng1", n1)
+	provider.AddNode("ng2", n2)
+
+	client, stop := startFakeServer(t, provider)
+	defer stop()
+
+	// An empty expected id means the node isn't in any node group.
+	for _, tc := range []struct {
+		node       *apiv1.Node
+		expectedNg string
+	}{
+		{node: n1, expectedNg: "ng1"},
+		{node: n2, expectedNg: "ng2"},
+		{node: n3},
+		{node: n1, expectedNg: "ng1"},
+	} {
+		ng, err := client.NodeGroupForNode(tc.node)
+		assert.NoError(t, err)
+		if tc.expectedNg == "" {
+			assert.Nil(t, ng)
+		} else {
+			assert.Equal(t, tc.expectedNg, ng.Id())
+		}
+	}
+}
+
+func TestExternalGrpcTemplateNodePrice(t *testing.T) {
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.SetPricingModel(capacityPricingModel{})
+	client, stop := startFakeServer(t, provider)
+	defer stop()
+
+	// Template nodes have no providerID, they are priced by their capacity and labels.
+	template := BuildTestNode("ng1-template", 4000, 1000)
+	template.Spec.ProviderID = ""
+	template.Labels = map[string]string{"tier": "premium"}
+
+	pricing, perr := client.Pricing()
+	assert.NoError(t, perr)
+	now := time.Now()
+	price, err := pricing.NodePrice(template, now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.InDelta(t, 8.0, price, 1e-6)
+}
+
+func TestExternalGrpcNodeGroupOptions(t *testing.T) {
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+	provider.AddNodeGroup("ng2", 1, 10, 1)
+	ng2 := provider.GetNodeGroup("ng2").(*testprovider.TestNodeGroup)
+	ng2.SetOptions(&config.NodeGroupAutoscalingOptions{
+		ScaleDownUtilizationThreshold:    0.2,
+		ScaleDownGpuUtilizationThreshold: 0.3,
+		ScaleDownUnneededTime:            time.Minute,
+		ScaleDownUnreadyTime:             2 * time.Minute,
+	})
+
+	client, stop := startFakeServer(t, provider)
+	defer stop()
+
+	defaults := config.NodeGroupAutoscalingOptions{
+		ScaleDownUtilizationThreshold:    0.5,
+		ScaleDownGpuUtilizationThreshold: 0.5,
+		ScaleDownUnneededTime:            10 * time.Minute,
+		ScaleDownUnreadyTime:             20 * time.Minute,
+	}
+	for _, ng := range client.NodeGroups() {
+		opts, err := ng.GetOptions(defaults)
+		switch ng.Id() {
+		case "ng1":
+			assert.Equal(t, cloudprovider.ErrNotImplemented, err)
+			assert.Nil(t, opts)
+		case "ng2":
+			assert.NoError(t, err)
+			assert.Equal(t, &config.NodeGroupAutoscalingOptions{
+				ScaleDownUtilizationThreshold:    0.2,
+				ScaleDownGpuUtilizationThreshold: 0.3,
+				ScaleDownUnneededTime:            time.Minute,
+				ScaleDownUnreadyTime:             2 * time.Minute,
+			}, opts)
+		}
+	}
+
+	// Pricing is not set on the test provider, the error is propagated as not implemented.
+	pricing, err := client.Pricing()
+	assert.NoError(t, err)
+	_, perr := pricing.NodePrice(BuildTestNode("n1", 1000, 1000), time.Now(), time.Now().Add(time.Hour))
+	assert.Equal(t, cloudprovider.ErrNotImplemented, perr)
+}
+
+func TestInstanceStatus(t *testing.T) {
+	assert.Nil(t, instanceStatus(nil))
+	assert.Nil(t, instanceStatus(&protos.InstanceStatus{InstanceState: protos.InstanceStatus_unspecified}))
+	assert.Equal(t, &cloudprovider.InstanceStatus{
+		State: cloudprovider.InstanceCreating,
+		ErrorInfo: &cloudprovider.InstanceErrorInfo{
+			ErrorClass:   cloudprovider.OutOfResourcesErrorClass,
+			ErrorCode:    "QUOTA",
+			ErrorMessage: "out of quota",
+		},
+	}, instanceStatus(&protos.InstanceStatus{
+		InstanceState: protos.InstanceStatus_instanceCreating,
+		ErrorInfo: &protos.InstanceErrorInfo{
+			ErrorCode:          "QUOTA",
+			ErrorMessage:       "out of quota",
+			InstanceErrorClass: int32(cloudprovider.OutOfResourcesErrorClass),
+		},
+	}))
+}
+
+func TestReadConfig(t *testing.T) {
+	cfg, err := readConfig(strings.NewReader(`
+[Global]
+address = localhost:8086
+grpc-timeout = 10s
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost:8086", cfg.Global.Address)
+	assert.Equal(t, "10s", cfg.Global.GrpcTimeout)
+
+	_, err = readConfig(strings.NewReader("[Global]\n"))
+	assert.Error(t, err)
+}
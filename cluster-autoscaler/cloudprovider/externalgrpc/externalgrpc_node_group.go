@@ -0,0 +1,288 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalgrpc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"golang.org/x/net/context"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/externalgrpc/protos"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/klog"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+// NodeGroup implements cloudprovider.NodeGroup interface by forwarding
+// the calls to the external gRPC cloud provider service.
+type NodeGroup struct {
+	id      string
+	minSize int
+	maxSize int
+	debug   string
+
+	client      protos.CloudProviderClient
+	grpcTimeout time.Duration
+
+	mutex    sync.Mutex
+	nodeInfo *schedulernodeinfo.NodeInfo // cached TemplateNodeInfo response, lives as long as the node group
+}
+
+// MaxSize returns maximum size of the node group.
+func (n *NodeGroup) MaxSize() int {
+	return n.maxSize
+}
+
+// MinSize returns minimum size of the node group.
+func (n *NodeGroup) MinSize() int {
+	return n.minSize
+}
+
+// TargetSize returns the current target size of the node group. It is possible that the
+// number of nodes in Kubernetes is different at the moment but should be equal
+// to Size() once everything stabilizes (new nodes finish startup and registration or
+// removed nodes are deleted completely).
+func (n *NodeGroup) TargetSize() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), n.grpcTimeout)
+	defer cancel()
+	res, err := n.client.NodeGroupTargetSize(ctx, &protos.NodeGroupTargetSizeRequest{
+		Id: n.id,
+	})
+	if err != nil {
+		klog.V(1).Infof("Error on gRPC call NodeGroupTargetSize: %v", err)
+		return 0, convertError(err)
+	}
+	return int(res.GetTargetSize()), nil
+}
+
+// IncreaseSize increases the size of the node group. To delete a node you need
+// to explicitly name it and use DeleteNode. This function should wait until
+// node group size is updated.
+func (n *NodeGroup) IncreaseSize(delta int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), n.grpcTimeout)
+	defer cancel()
+	_, err := n.client.NodeGroupIncreaseSize(ctx, &protos.NodeGroupIncreaseSizeRequest{
+		Id:    n.id,
+		Delta: int32(delta),
+	})
+	if err != nil {
+		klog.V(1).Infof("Error on gRPC call NodeGroupIncreaseSize: %v", err)
+		return convertError(err)
+	}
+	return nil
+}
+
+// DeleteNodes deletes nodes from this node group. Error is returned either on
+// failure or if the given node doesn't belong to this node group. This function
+// should wait until node group size is updated.
+func (n *NodeGroup) DeleteNodes(nodes []*apiv1.Node) error {
+	pbNodes := make([]*protos.ExternalGrpcNode, 0, len(nodes))
+	for _, node := range nodes {
+		pbNodes = append(pbNodes, externalGrpcNode(node))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), n.grpcTimeout)
+	defer cancel()
+	_, err := n.client.NodeGroupDeleteNodes(ctx, &protos.NodeGroupDeleteNodesRequest{
+		Id:    n.id,
+		Nodes: pbNodes,
+	})
+	if err != nil {
+		klog.V(1).Infof("Error on gRPC call NodeGroupDeleteNodes: %v", err)
+		return convertError(err)
+	}
+	return nil
+}
+
+// DecreaseTargetSize decreases the target size of the node group. This function
+// doesn't permit to delete any existing node and can be used only to reduce the
+// request for new nodes that have not been yet fulfilled. Delta should be negative.
+func (n *NodeGroup) DecreaseTargetSize(delta int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), n.grpcTimeout)
+	defer cancel()
+	_, err := n.client.NodeGroupDecreaseTargetSize(ctx, &protos.NodeGroupDecreaseTargetSizeRequest{
+		Id:    n.id,
+		Delta: int32(delta),
+	})
+	if err != nil {
+		klog.V(1).Infof("Error on gRPC call NodeGroupDecreaseTargetSize: %v", err)
+		return convertError(err)
+	}
+	return nil
+}
+
+// Id returns an unique identifier of the node group.
+func (n *NodeGroup) Id() string {
+	return n.id
+}
+
+// Debug returns a string containing all information regarding this node group.
+func (n *NodeGroup) Debug() string {
+	return n.debug
+}
+
+// Nodes returns a list of all nodes that belong to this node group.
+func (n *NodeGroup) Nodes() ([]cloudprovider.Instance, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), n.grpcTimeout)
+	defer cancel()
+	res, err := n.client.NodeGroupNodes(ctx, &protos.NodeGroupNodesRequest{
+		Id: n.id,
+	})
+	if err != nil {
+		klog.V(1).Infof("Error on gRPC call NodeGroupNodes: %v", err)
+		return nil, convertError(err)
+	}
+	instances := make([]cloudprovider.Instance, 0, len(res.GetInstances()))
+	for _, pbInstance := range res.GetInstances() {
+		instances = append(instances, cloudprovider.Instance{
+			Id:     pbInstance.GetId(),
+			Status: instanceStatus(pbInstance.GetStatus()),
+		})
+	}
+	return instances, nil
+}
+
+// TemplateNodeInfo returns a node template for this node group.
+func (n *NodeGroup) TemplateNodeInfo() (*schedulernodeinfo.NodeInfo, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.nodeInfo != nil {
+		return n.nodeInfo, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), n.grpcTimeout)
+	defer cancel()
+	res, err := n.client.NodeGroupTemplateNodeInfo(ctx, &protos.NodeGroupTemplateNodeInfoRequest{
+		Id: n.id,
+	})
+	if err != nil {
+		klog.V(1).Infof("Error on gRPC call NodeGroupTemplateNodeInfo: %v", err)
+		return nil, convertError(err)
+	}
+	if len(res.GetNodeInfo()) == 0 {
+		return nil, cloudprovider.ErrNotImplemented
+	}
+	node := &apiv1.Node{}
+	if err := node.Unmarshal(res.GetNodeInfo()); err != nil {
+		return nil, err
+	}
+	nodeInfo := schedulernodeinfo.NewNodeInfo(cloudprovider.BuildKubeProxy(n.id))
+	if err := nodeInfo.SetNode(node); err != nil {
+		return nil, err
+	}
+	n.nodeInfo = nodeInfo
+	return nodeInfo, nil
+}
+
+// Exist checks if the node group really exists on the cloud provider side.
+// Node groups are always discovered from the external service, so they always exist.
+func (n *NodeGroup) Exist() bool {
+	return true
+}
+
+// Create is not implemented.
+func (n *NodeGroup) Create() (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// Delete is not implemented.
+func (n *NodeGroup) Delete() error {
+	return cloudprovider.ErrNotImplemented
+}
+
+// Autoprovisioned returns true if the node group is autoprovisioned.
+func (n *NodeGroup) Autoprovisioned() bool {
+	return false
+}
+
+// GetOptions returns NodeGroupAutoscalingOptions that should be used for this particular
+// NodeGroup. Returning a nil result will result in using default options.
+func (n *NodeGroup) GetOptions(defaults config.NodeGroupAutoscalingOptions) (*config.NodeGroupAutoscalingOptions, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), n.grpcTimeout)
+	defer cancel()
+	res, err := n.client.NodeGroupGetOptions(ctx, &protos.NodeGroupAutoscalingOptionsRequest{
+		Id:       n.id,
+		Defaults: protoAutoscalingOptions(defaults),
+	})
+	if err != nil {
+		klog.V(1).Infof("Error on gRPC call NodeGroupGetOptions: %v", err)
+		return nil, convertError(err)
+	}
+	pbOpts := res.GetNodeGroupAutoscalingOptions()
+	if pbOpts == nil {
+		return nil, nil
+	}
+	opts := &config.NodeGroupAutoscalingOptions{
+		ScaleDownUtilizationThreshold:    pbOpts.GetScaleDownUtilizationThreshold(),
+		ScaleDownGpuUtilizationThreshold: pbOpts.GetScaleDownGpuUtilizationThreshold(),
+		ScaleDownUnneededTime:            defaults.ScaleDownUnneededTime,
+		ScaleDownUnreadyTime:             defaults.ScaleDownUnreadyTime,
+	}
+	if pbOpts.GetScaleDownUnneededTime() != nil {
+		opts.ScaleDownUnneededTime, err = ptypes.Duration(pbOpts.GetScaleDownUnneededTime())
+		if err != nil {
+			return nil, err
+		}
+	}
+	if pbOpts.GetScaleDownUnreadyTime() != nil {
+		opts.ScaleDownUnreadyTime, err = ptypes.Duration(pbOpts.GetScaleDownUnreadyTime())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+func protoAutoscalingOptions(opts config.NodeGroupAutoscalingOptions) *protos.NodeGroupAutoscalingOptions {
+	return &protos.NodeGroupAutoscalingOptions{
+		ScaleDownUtilizationThreshold:    opts.ScaleDownUtilizationThreshold,
+		ScaleDownGpuUtilizationThreshold: opts.ScaleDownGpuUtilizationThreshold,
+		ScaleDownUnneededTime:            ptypes.DurationProto(opts.ScaleDownUnneededTime),
+		ScaleDownUnreadyTime:             ptypes.DurationProto(opts.ScaleDownUnreadyTime),
+	}
+}
+
+func instanceStatus(pbStatus *protos.InstanceStatus) *cloudprovider.InstanceStatus {
+	if pbStatus == nil {
+		return nil
+	}
+	status := &cloudprovider.InstanceStatus{}
+	switch pbStatus.GetInstanceState() {
+	case protos.InstanceStatus_unspecified:
+		return nil
+	case protos.InstanceStatus_instanceRunning:
+		status.State = cloudprovider.InstanceRunning
+	case protos.InstanceStatus_instanceCreating:
+		status.State = cloudprovider.InstanceCreating
+	case protos.InstanceStatus_instanceDeleting:
+		status.State = cloudprovider.InstanceDeleting
+	default:
+		klog.Warningf("Unknown instance state %v", pbStatus.GetInstanceState())
+		return nil
+	}
+	if pbErrorInfo := pbStatus.GetErrorInfo(); pbErrorInfo != nil {
+		status.ErrorInfo = &cloudprovider.InstanceErrorInfo{
+			ErrorClass:   cloudprovider.InstanceErrorClass(pbErrorInfo.GetInstanceErrorClass()),
+			ErrorCode:    pbErrorInfo.GetErrorCode(),
+			ErrorMessage: pbErrorInfo.GetErrorMessage(),
+		}
+	}
+	return status
+}
@@ -0,0 +1,2335 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: externalgrpc.proto
+
+package protos
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import duration "github.com/golang/protobuf/ptypes/duration"
+import timestamp "github.com/golang/protobuf/ptypes/timestamp"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+// InstanceState tells if instance is running, being created or being deleted.
+type InstanceStatus_InstanceState int32
+
+const (
+	// an Unspecified instanceState means the actual instance status is undefined (nil).
+	InstanceStatus_unspecified InstanceStatus_InstanceState = 0
+	// InstanceRunning means instance is running.
+	InstanceStatus_instanceRunning InstanceStatus_InstanceState = 1
+	// InstanceCreating means instance is being created.
+	InstanceStatus_instanceCreating InstanceStatus_InstanceState = 2
+	// InstanceDeleting means instance is being deleted.
+	InstanceStatus_instanceDeleting InstanceStatus_InstanceState = 3
+)
+
+var InstanceStatus_InstanceState_name = map[int32]string{
+	0: "unspecified",
+	1: "instanceRunning",
+	2: "instanceCreating",
+	3: "instanceDeleting",
+}
+var InstanceStatus_InstanceState_value = map[string]int32{
+	"unspecified":      0,
+	"instanceRunning":  1,
+	"instanceCreating": 2,
+	"instanceDeleting": 3,
+}
+
+func (x InstanceStatus_InstanceState) String() string {
+	return proto.EnumName(InstanceStatus_InstanceState_name, int32(x))
+}
+func (InstanceStatus_InstanceState) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{29, 0}
+}
+
+type NodeGroup struct {
+	// ID of the node group on the cloud provider.
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	// MinSize of the node group on the cloud provider.
+	MinSize int32 `protobuf:"varint,2,opt,name=minSize" json:"minSize,omitempty"`
+	// MaxSize of the node group on the cloud provider.
+	MaxSize int32 `protobuf:"varint,3,opt,name=maxSize" json:"maxSize,omitempty"`
+	// Debug returns a string containing all information regarding this node group.
+	Debug                string   `protobuf:"bytes,4,opt,name=debug" json:"debug,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeGroup) Reset()         { *m = NodeGroup{} }
+func (m *NodeGroup) String() string { return proto.CompactTextString(m) }
+func (*NodeGroup) ProtoMessage()    {}
+func (*NodeGroup) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{0}
+}
+func (m *NodeGroup) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeGroup.Unmarshal(m, b)
+}
+func (m *NodeGroup) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeGroup.Marshal(b, m, deterministic)
+}
+func (dst *NodeGroup) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeGroup.Merge(dst, src)
+}
+func (m *NodeGroup) XXX_Size() int {
+	return xxx_messageInfo_NodeGroup.Size(m)
+}
+func (m *NodeGroup) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeGroup.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeGroup proto.InternalMessageInfo
+
+func (m *NodeGroup) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *NodeGroup) GetMinSize() int32 {
+	if m != nil {
+		return m.MinSize
+	}
+	return 0
+}
+
+func (m *NodeGroup) GetMaxSize() int32 {
+	if m != nil {
+		return m.MaxSize
+	}
+	return 0
+}
+
+func (m *NodeGroup) GetDebug() string {
+	if m != nil {
+		return m.Debug
+	}
+	return ""
+}
+
+type ExternalGrpcNode struct {
+	// ID of the node assigned by the cloud provider in the format: <ProviderName>://<ProviderSpecificNodeID>.
+	ProviderID string `protobuf:"bytes,1,opt,name=providerID" json:"providerID,omitempty"`
+	// Name of the node assigned by the cloud provider.
+	Name string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+	// labels is a map of {key,value} pairs with the node's labels.
+	Labels map[string]string `protobuf:"bytes,3,rep,name=labels" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// If specified, the node's annotations.
+	Annotations          map[string]string `protobuf:"bytes,4,rep,name=annotations" json:"annotations,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *ExternalGrpcNode) Reset()         { *m = ExternalGrpcNode{} }
+func (m *ExternalGrpcNode) String() string { return proto.CompactTextString(m) }
+func (*ExternalGrpcNode) ProtoMessage()    {}
+func (*ExternalGrpcNode) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{1}
+}
+func (m *ExternalGrpcNode) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExternalGrpcNode.Unmarshal(m, b)
+}
+func (m *ExternalGrpcNode) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExternalGrpcNode.Marshal(b, m, deterministic)
+}
+func (dst *ExternalGrpcNode) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExternalGrpcNode.Merge(dst, src)
+}
+func (m *ExternalGrpcNode) XXX_Size() int {
+	return xxx_messageInfo_ExternalGrpcNode.Size(m)
+}
+func (m *ExternalGrpcNode) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExternalGrpcNode.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExternalGrpcNode proto.InternalMessageInfo
+
+func (m *ExternalGrpcNode) GetProviderID() string {
+	if m != nil {
+		return m.ProviderID
+	}
+	return ""
+}
+
+func (m *ExternalGrpcNode) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ExternalGrpcNode) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *ExternalGrpcNode) GetAnnotations() map[string]string {
+	if m != nil {
+		return m.Annotations
+	}
+	return nil
+}
+
+type NodeGroupsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeGroupsRequest) Reset()         { *m = NodeGroupsRequest{} }
+func (m *NodeGroupsRequest) String() string { return proto.CompactTextString(m) }
+func (*NodeGroupsRequest) ProtoMessage()    {}
+func (*NodeGroupsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{2}
+}
+func (m *NodeGroupsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeGroupsRequest.Unmarshal(m, b)
+}
+func (m *NodeGroupsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeGroupsRequest.Marshal(b, m, deterministic)
+}
+func (dst *NodeGroupsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeGroupsRequest.Merge(dst, src)
+}
+func (m *NodeGroupsRequest) XXX_Size() int {
+	return xxx_messageInfo_NodeGroupsRequest.Size(m)
+}
+func (m *NodeGroupsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeGroupsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeGroupsRequest proto.InternalMessageInfo
+
+type NodeGroupsResponse struct {
+	// All the node groups that the cloud provider service supports.
+	NodeGroups           []*NodeGroup `protobuf:"bytes,1,rep,name=nodeGroups" json:"nodeGroups,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *NodeGroupsResponse) Reset()         { *m = NodeGroupsResponse{} }
+func (m *NodeGroupsResponse) String() string { return proto.CompactTextString(m) }
+func (*NodeGroupsResponse) ProtoMessage()    {}
+func (*NodeGroupsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{3}
+}
+func (m *NodeGroupsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeGroupsResponse.Unmarshal(m, b)
+}
+func (m *NodeGroupsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeGroupsResponse.Marshal(b, m, deterministic)
+}
+func (dst *NodeGroupsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeGroupsResponse.Merge(dst, src)
+}
+func (m *NodeGroupsResponse) XXX_Size() int {
+	return xxx_messageInfo_NodeGroupsResponse.Size(m)
+}
+func (m *NodeGroupsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeGroupsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeGroupsResponse proto.InternalMessageInfo
+
+func (m *NodeGroupsResponse) GetNodeGroups() []*NodeGroup {
+	if m != nil {
+		return m.NodeGroups
+	}
+	return nil
+}
+
+type NodeGroupForNodeRequest struct {
+	// Node for which the request is performed.
+	Node                 *ExternalGrpcNode `protobuf:"bytes,1,opt,name=node" json:"node,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *NodeGroupForNodeRequest) Reset()         { *m = NodeGroupForNodeRequest{} }
+func (m *NodeGroupForNodeRequest) String() string { return proto.CompactTextString(m) }
+func (*NodeGroupForNodeRequest) ProtoMessage()    {}
+func (*NodeGroupForNodeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{4}
+}
+func (m *NodeGroupForNodeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeGroupForNodeRequest.Unmarshal(m, b)
+}
+func (m *NodeGroupForNodeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeGroupForNodeRequest.Marshal(b, m, deterministic)
+}
+func (dst *NodeGroupForNodeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeGroupForNodeRequest.Merge(dst, src)
+}
+func (m *NodeGroupForNodeRequest) XXX_Size() int {
+	return xxx_messageInfo_NodeGroupForNodeRequest.Size(m)
+}
+func (m *NodeGroupForNodeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeGroupForNodeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeGroupForNodeRequest proto.InternalMessageInfo
+
+func (m *NodeGroupForNodeRequest) GetNode() *ExternalGrpcNode {
+	if m != nil {
+		return m.Node
+	}
+	return nil
+}
+
+type NodeGroupForNodeResponse struct {
+	// Node group for the given node. nodeGroup with id = "" means no node group.
+	NodeGroup            *NodeGroup `protobuf:"bytes,1,opt,name=nodeGroup" json:"nodeGroup,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *NodeGroupForNodeResponse) Reset()         { *m = NodeGroupForNodeResponse{} }
+func (m *NodeGroupForNodeResponse) String() string { return proto.CompactTextString(m) }
+func (*NodeGroupForNodeResponse) ProtoMessage()    {}
+func (*NodeGroupForNodeResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{5}
+}
+func (m *NodeGroupForNodeResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeGroupForNodeResponse.Unmarshal(m, b)
+}
+func (m *NodeGroupForNodeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeGroupForNodeResponse.Marshal(b, m, deterministic)
+}
+func (dst *NodeGroupForNodeResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeGroupForNodeResponse.Merge(dst, src)
+}
+func (m *NodeGroupForNodeResponse) XXX_Size() int {
+	return xxx_messageInfo_NodeGroupForNodeResponse.Size(m)
+}
+func (m *NodeGroupForNodeResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeGroupForNodeResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeGroupForNodeResponse proto.InternalMessageInfo
+
+func (m *NodeGroupForNodeResponse) GetNodeGroup() *NodeGroup {
+	if m != nil {
+		return m.NodeGroup
+	}
+	return nil
+}
+
+type PricingNodePriceRequest struct {
+	// Node for which the request is performed, serialized using the
+	// Kubernetes protobuf encoding of k8s.io.api.core.v1.Node. It may be
+	// a template node without a providerID.
+	Node []byte `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	// Start time for the request period.
+	StartTime *timestamp.Timestamp `protobuf:"bytes,2,opt,name=startTime" json:"startTime,omitempty"`
+	// End time for the request period.
+	EndTime              *timestamp.Timestamp `protobuf:"bytes,3,opt,name=endTime" json:"endTime,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *PricingNodePriceRequest) Reset()         { *m = PricingNodePriceRequest{} }
+func (m *PricingNodePriceRequest) String() string { return proto.CompactTextString(m) }
+func (*PricingNodePriceRequest) ProtoMessage()    {}
+func (*PricingNodePriceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{6}
+}
+func (m *PricingNodePriceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PricingNodePriceRequest.Unmarshal(m, b)
+}
+func (m *PricingNodePriceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PricingNodePriceRequest.Marshal(b, m, deterministic)
+}
+func (dst *PricingNodePriceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PricingNodePriceRequest.Merge(dst, src)
+}
+func (m *PricingNodePriceRequest) XXX_Size() int {
+	return xxx_messageInfo_PricingNodePriceRequest.Size(m)
+}
+func (m *PricingNodePriceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PricingNodePriceRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PricingNodePriceRequest proto.InternalMessageInfo
+
+func (m *PricingNodePriceRequest) GetNode() []byte {
+	if m != nil {
+		return m.Node
+	}
+	return nil
+}
+
+func (m *PricingNodePriceRequest) GetStartTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.StartTime
+	}
+	return nil
+}
+
+func (m *PricingNodePriceRequest) GetEndTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.EndTime
+	}
+	return nil
+}
+
+type PricingNodePriceResponse struct {
+	// Theoretical minimum price of running a node for a given period.
+	Price                float64  `protobuf:"fixed64,1,opt,name=price" json:"price,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PricingNodePriceResponse) Reset()         { *m = PricingNodePriceResponse{} }
+func (m *PricingNodePriceResponse) String() string { return proto.CompactTextString(m) }
+func (*PricingNodePriceResponse) ProtoMessage()    {}
+func (*PricingNodePriceResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{7}
+}
+func (m *PricingNodePriceResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PricingNodePriceResponse.Unmarshal(m, b)
+}
+func (m *PricingNodePriceResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PricingNodePriceResponse.Marshal(b, m, deterministic)
+}
+func (dst *PricingNodePriceResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PricingNodePriceResponse.Merge(dst, src)
+}
+func (m *PricingNodePriceResponse) XXX_Size() int {
+	return xxx_messageInfo_PricingNodePriceResponse.Size(m)
+}
+func (m *PricingNodePriceResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PricingNodePriceResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PricingNodePriceResponse proto.InternalMessageInfo
+
+func (m *PricingNodePriceResponse) GetPrice() float64 {
+	if m != nil {
+		return m.Price
+	}
+	return 0
+}
+
+type PricingPodPriceRequest struct {
+	// Pod for which the request is performed, serialized using the
+	// Kubernetes protobuf encoding of k8s.io.api.core.v1.Pod.
+	Pod []byte `protobuf:"bytes,1,opt,name=pod,proto3" json:"pod,omitempty"`
+	// Start time for the request period.
+	StartTime *timestamp.Timestamp `protobuf:"bytes,2,opt,name=startTime" json:"startTime,omitempty"`
+	// End time for the request period.
+	EndTime              *timestamp.Timestamp `protobuf:"bytes,3,opt,name=endTime" json:"endTime,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *PricingPodPriceRequest) Reset()         { *m = PricingPodPriceRequest{} }
+func (m *PricingPodPriceRequest) String() string { return proto.CompactTextString(m) }
+func (*PricingPodPriceRequest) ProtoMessage()    {}
+func (*PricingPodPriceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{8}
+}
+func (m *PricingPodPriceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PricingPodPriceRequest.Unmarshal(m, b)
+}
+func (m *PricingPodPriceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PricingPodPriceRequest.Marshal(b, m, deterministic)
+}
+func (dst *PricingPodPriceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PricingPodPriceRequest.Merge(dst, src)
+}
+func (m *PricingPodPriceRequest) XXX_Size() int {
+	return xxx_messageInfo_PricingPodPriceRequest.Size(m)
+}
+func (m *PricingPodPriceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PricingPodPriceRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PricingPodPriceRequest proto.InternalMessageInfo
+
+func (m *PricingPodPriceRequest) GetPod() []byte {
+	if m != nil {
+		return m.Pod
+	}
+	return nil
+}
+
+func (m *PricingPodPriceRequest) GetStartTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.StartTime
+	}
+	return nil
+}
+
+func (m *PricingPodPriceRequest) GetEndTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.EndTime
+	}
+	return nil
+}
+
+type PricingPodPriceResponse struct {
+	// Theoretical minimum price of running a pod for a given period.
+	Price                float64  `protobuf:"fixed64,1,opt,name=price" json:"price,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PricingPodPriceResponse) Reset()         { *m = PricingPodPriceResponse{} }
+func (m *PricingPodPriceResponse) String() string { return proto.CompactTextString(m) }
+func (*PricingPodPriceResponse) ProtoMessage()    {}
+func (*PricingPodPriceResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{9}
+}
+func (m *PricingPodPriceResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PricingPodPriceResponse.Unmarshal(m, b)
+}
+func (m *PricingPodPriceResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PricingPodPriceResponse.Marshal(b, m, deterministic)
+}
+func (dst *PricingPodPriceResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PricingPodPriceResponse.Merge(dst, src)
+}
+func (m *PricingPodPriceResponse) XXX_Size() int {
+	return xxx_messageInfo_PricingPodPriceResponse.Size(m)
+}
+func (m *PricingPodPriceResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PricingPodPriceResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PricingPodPriceResponse proto.InternalMessageInfo
+
+func (m *PricingPodPriceResponse) GetPrice() float64 {
+	if m != nil {
+		return m.Price
+	}
+	return 0
+}
+
+type GPULabelRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GPULabelRequest) Reset()         { *m = GPULabelRequest{} }
+func (m *GPULabelRequest) String() string { return proto.CompactTextString(m) }
+func (*GPULabelRequest) ProtoMessage()    {}
+func (*GPULabelRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{10}
+}
+func (m *GPULabelRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GPULabelRequest.Unmarshal(m, b)
+}
+func (m *GPULabelRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GPULabelRequest.Marshal(b, m, deterministic)
+}
+func (dst *GPULabelRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GPULabelRequest.Merge(dst, src)
+}
+func (m *GPULabelRequest) XXX_Size() int {
+	return xxx_messageInfo_GPULabelRequest.Size(m)
+}
+func (m *GPULabelRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GPULabelRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GPULabelRequest proto.InternalMessageInfo
+
+type GPULabelResponse struct {
+	// Label added to nodes with a GPU resource.
+	Label                string   `protobuf:"bytes,1,opt,name=label" json:"label,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GPULabelResponse) Reset()         { *m = GPULabelResponse{} }
+func (m *GPULabelResponse) String() string { return proto.CompactTextString(m) }
+func (*GPULabelResponse) ProtoMessage()    {}
+func (*GPULabelResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{11}
+}
+func (m *GPULabelResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GPULabelResponse.Unmarshal(m, b)
+}
+func (m *GPULabelResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GPULabelResponse.Marshal(b, m, deterministic)
+}
+func (dst *GPULabelResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GPULabelResponse.Merge(dst, src)
+}
+func (m *GPULabelResponse) XXX_Size() int {
+	return xxx_messageInfo_GPULabelResponse.Size(m)
+}
+func (m *GPULabelResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GPULabelResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GPULabelResponse proto.InternalMessageInfo
+
+func (m *GPULabelResponse) GetLabel() string {
+	if m != nil {
+		return m.Label
+	}
+	return ""
+}
+
+type GetAvailableGPUTypesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetAvailableGPUTypesRequest) Reset()         { *m = GetAvailableGPUTypesRequest{} }
+func (m *GetAvailableGPUTypesRequest) String() string { return proto.CompactTextString(m) }
+func (*GetAvailableGPUTypesRequest) ProtoMessage()    {}
+func (*GetAvailableGPUTypesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{12}
+}
+func (m *GetAvailableGPUTypesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetAvailableGPUTypesRequest.Unmarshal(m, b)
+}
+func (m *GetAvailableGPUTypesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetAvailableGPUTypesRequest.Marshal(b, m, deterministic)
+}
+func (dst *GetAvailableGPUTypesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetAvailableGPUTypesRequest.Merge(dst, src)
+}
+func (m *GetAvailableGPUTypesRequest) XXX_Size() int {
+	return xxx_messageInfo_GetAvailableGPUTypesRequest.Size(m)
+}
+func (m *GetAvailableGPUTypesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetAvailableGPUTypesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetAvailableGPUTypesRequest proto.InternalMessageInfo
+
+type GetAvailableGPUTypesResponse struct {
+	// GPU types supported by the cloud provider.
+	GpuTypes             []string `protobuf:"bytes,1,rep,name=gpuTypes" json:"gpuTypes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetAvailableGPUTypesResponse) Reset()         { *m = GetAvailableGPUTypesResponse{} }
+func (m *GetAvailableGPUTypesResponse) String() string { return proto.CompactTextString(m) }
+func (*GetAvailableGPUTypesResponse) ProtoMessage()    {}
+func (*GetAvailableGPUTypesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{13}
+}
+func (m *GetAvailableGPUTypesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetAvailableGPUTypesResponse.Unmarshal(m, b)
+}
+func (m *GetAvailableGPUTypesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetAvailableGPUTypesResponse.Marshal(b, m, deterministic)
+}
+func (dst *GetAvailableGPUTypesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetAvailableGPUTypesResponse.Merge(dst, src)
+}
+func (m *GetAvailableGPUTypesResponse) XXX_Size() int {
+	return xxx_messageInfo_GetAvailableGPUTypesResponse.Size(m)
+}
+func (m *GetAvailableGPUTypesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetAvailableGPUTypesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetAvailableGPUTypesResponse proto.InternalMessageInfo
+
+func (m *GetAvailableGPUTypesResponse) GetGpuTypes() []string {
+	if m != nil {
+		return m.GpuTypes
+	}
+	return nil
+}
+
+type CleanupRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CleanupRequest) Reset()         { *m = CleanupRequest{} }
+func (m *CleanupRequest) String() string { return proto.CompactTextString(m) }
+func (*CleanupRequest) ProtoMessage()    {}
+func (*CleanupRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{14}
+}
+func (m *CleanupRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CleanupRequest.Unmarshal(m, b)
+}
+func (m *CleanupRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CleanupRequest.Marshal(b, m, deterministic)
+}
+func (dst *CleanupRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CleanupRequest.Merge(dst, src)
+}
+func (m *CleanupRequest) XXX_Size() int {
+	return xxx_messageInfo_CleanupRequest.Size(m)
+}
+func (m *CleanupRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CleanupRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CleanupRequest proto.InternalMessageInfo
+
+type CleanupResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CleanupResponse) Reset()         { *m = CleanupResponse{} }
+func (m *CleanupResponse) String() string { return proto.CompactTextString(m) }
+func (*CleanupResponse) ProtoMessage()    {}
+func (*CleanupResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{15}
+}
+func (m *CleanupResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CleanupResponse.Unmarshal(m, b)
+}
+func (m *CleanupResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CleanupResponse.Marshal(b, m, deterministic)
+}
+func (dst *CleanupResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CleanupResponse.Merge(dst, src)
+}
+func (m *CleanupResponse) XXX_Size() int {
+	return xxx_messageInfo_CleanupResponse.Size(m)
+}
+func (m *CleanupResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CleanupResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CleanupResponse proto.InternalMessageInfo
+
+type RefreshRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RefreshRequest) Reset()         { *m = RefreshRequest{} }
+func (m *RefreshRequest) String() string { return proto.CompactTextString(m) }
+func (*RefreshRequest) ProtoMessage()    {}
+func (*RefreshRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{16}
+}
+func (m *RefreshRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RefreshRequest.Unmarshal(m, b)
+}
+func (m *RefreshRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RefreshRequest.Marshal(b, m, deterministic)
+}
+func (dst *RefreshRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RefreshRequest.Merge(dst, src)
+}
+func (m *RefreshRequest) XXX_Size() int {
+	return xxx_messageInfo_RefreshRequest.Size(m)
+}
+func (m *RefreshRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RefreshRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RefreshRequest proto.InternalMessageInfo
+
+type RefreshResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RefreshResponse) Reset()         { *m = RefreshResponse{} }
+func (m *RefreshResponse) String() string { return proto.CompactTextString(m) }
+func (*RefreshResponse) ProtoMessage()    {}
+func (*RefreshResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{17}
+}
+func (m *RefreshResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RefreshResponse.Unmarshal(m, b)
+}
+func (m *RefreshResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RefreshResponse.Marshal(b, m, deterministic)
+}
+func (dst *RefreshResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RefreshResponse.Merge(dst, src)
+}
+func (m *RefreshResponse) XXX_Size() int {
+	return xxx_messageInfo_RefreshResponse.Size(m)
+}
+func (m *RefreshResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RefreshResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RefreshResponse proto.InternalMessageInfo
+
+type NodeGroupTargetSizeRequest struct {
+	// ID of the node group for the request.
+	Id                   string   `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeGroupTargetSizeRequest) Reset()         { *m = NodeGroupTargetSizeRequest{} }
+func (m *NodeGroupTargetSizeRequest) String() string { return proto.CompactTextString(m) }
+func (*NodeGroupTargetSizeRequest) ProtoMessage()    {}
+func (*NodeGroupTargetSizeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{18}
+}
+func (m *NodeGroupTargetSizeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeGroupTargetSizeRequest.Unmarshal(m, b)
+}
+func (m *NodeGroupTargetSizeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeGroupTargetSizeRequest.Marshal(b, m, deterministic)
+}
+func (dst *NodeGroupTargetSizeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeGroupTargetSizeRequest.Merge(dst, src)
+}
+func (m *NodeGroupTargetSizeRequest) XXX_Size() int {
+	return xxx_messageInfo_NodeGroupTargetSizeRequest.Size(m)
+}
+func (m *NodeGroupTargetSizeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeGroupTargetSizeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeGroupTargetSizeRequest proto.InternalMessageInfo
+
+func (m *NodeGroupTargetSizeRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type NodeGroupTargetSizeResponse struct {
+	// Current target size of the node group.
+	TargetSize           int32    `protobuf:"varint,1,opt,name=targetSize" json:"targetSize,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeGroupTargetSizeResponse) Reset()         { *m = NodeGroupTargetSizeResponse{} }
+func (m *NodeGroupTargetSizeResponse) String() string { return proto.CompactTextString(m) }
+func (*NodeGroupTargetSizeResponse) ProtoMessage()    {}
+func (*NodeGroupTargetSizeResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{19}
+}
+func (m *NodeGroupTargetSizeResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeGroupTargetSizeResponse.Unmarshal(m, b)
+}
+func (m *NodeGroupTargetSizeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeGroupTargetSizeResponse.Marshal(b, m, deterministic)
+}
+func (dst *NodeGroupTargetSizeResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeGroupTargetSizeResponse.Merge(dst, src)
+}
+func (m *NodeGroupTargetSizeResponse) XXX_Size() int {
+	return xxx_messageInfo_NodeGroupTargetSizeResponse.Size(m)
+}
+func (m *NodeGroupTargetSizeResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeGroupTargetSizeResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeGroupTargetSizeResponse proto.InternalMessageInfo
+
+func (m *NodeGroupTargetSizeResponse) GetTargetSize() int32 {
+	if m != nil {
+		return m.TargetSize
+	}
+	return 0
+}
+
+type NodeGroupIncreaseSizeRequest struct {
+	// Number of nodes to add.
+	Delta int32 `protobuf:"varint,1,opt,name=delta" json:"delta,omitempty"`
+	// ID of the node group for the request.
+	Id                   string   `protobuf:"bytes,2,opt,name=id" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeGroupIncreaseSizeRequest) Reset()         { *m = NodeGroupIncreaseSizeRequest{} }
+func (m *NodeGroupIncreaseSizeRequest) String() string { return proto.CompactTextString(m) }
+func (*NodeGroupIncreaseSizeRequest) ProtoMessage()    {}
+func (*NodeGroupIncreaseSizeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{20}
+}
+func (m *NodeGroupIncreaseSizeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeGroupIncreaseSizeRequest.Unmarshal(m, b)
+}
+func (m *NodeGroupIncreaseSizeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeGroupIncreaseSizeRequest.Marshal(b, m, deterministic)
+}
+func (dst *NodeGroupIncreaseSizeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeGroupIncreaseSizeRequest.Merge(dst, src)
+}
+func (m *NodeGroupIncreaseSizeRequest) XXX_Size() int {
+	return xxx_messageInfo_NodeGroupIncreaseSizeRequest.Size(m)
+}
+func (m *NodeGroupIncreaseSizeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeGroupIncreaseSizeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeGroupIncreaseSizeRequest proto.InternalMessageInfo
+
+func (m *NodeGroupIncreaseSizeRequest) GetDelta() int32 {
+	if m != nil {
+		return m.Delta
+	}
+	return 0
+}
+
+func (m *NodeGroupIncreaseSizeRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type NodeGroupIncreaseSizeResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeGroupIncreaseSizeResponse) Reset()         { *m = NodeGroupIncreaseSizeResponse{} }
+func (m *NodeGroupIncreaseSizeResponse) String() string { return proto.CompactTextString(m) }
+func (*NodeGroupIncreaseSizeResponse) ProtoMessage()    {}
+func (*NodeGroupIncreaseSizeResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{21}
+}
+func (m *NodeGroupIncreaseSizeResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeGroupIncreaseSizeResponse.Unmarshal(m, b)
+}
+func (m *NodeGroupIncreaseSizeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeGroupIncreaseSizeResponse.Marshal(b, m, deterministic)
+}
+func (dst *NodeGroupIncreaseSizeResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeGroupIncreaseSizeResponse.Merge(dst, src)
+}
+func (m *NodeGroupIncreaseSizeResponse) XXX_Size() int {
+	return xxx_messageInfo_NodeGroupIncreaseSizeResponse.Size(m)
+}
+func (m *NodeGroupIncreaseSizeResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeGroupIncreaseSizeResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeGroupIncreaseSizeResponse proto.InternalMessageInfo
+
+type NodeGroupDeleteNodesRequest struct {
+	// List of nodes to delete.
+	Nodes []*ExternalGrpcNode `protobuf:"bytes,1,rep,name=nodes" json:"nodes,omitempty"`
+	// ID of the node group for the request.
+	Id                   string   `protobuf:"bytes,2,opt,name=id" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeGroupDeleteNodesRequest) Reset()         { *m = NodeGroupDeleteNodesRequest{} }
+func (m *NodeGroupDeleteNodesRequest) String() string { return proto.CompactTextString(m) }
+func (*NodeGroupDeleteNodesRequest) ProtoMessage()    {}
+func (*NodeGroupDeleteNodesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{22}
+}
+func (m *NodeGroupDeleteNodesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeGroupDeleteNodesRequest.Unmarshal(m, b)
+}
+func (m *NodeGroupDeleteNodesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeGroupDeleteNodesRequest.Marshal(b, m, deterministic)
+}
+func (dst *NodeGroupDeleteNodesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeGroupDeleteNodesRequest.Merge(dst, src)
+}
+func (m *NodeGroupDeleteNodesRequest) XXX_Size() int {
+	return xxx_messageInfo_NodeGroupDeleteNodesRequest.Size(m)
+}
+func (m *NodeGroupDeleteNodesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeGroupDeleteNodesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeGroupDeleteNodesRequest proto.InternalMessageInfo
+
+func (m *NodeGroupDeleteNodesRequest) GetNodes() []*ExternalGrpcNode {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
+func (m *NodeGroupDeleteNodesRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type NodeGroupDeleteNodesResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeGroupDeleteNodesResponse) Reset()         { *m = NodeGroupDeleteNodesResponse{} }
+func (m *NodeGroupDeleteNodesResponse) String() string { return proto.CompactTextString(m) }
+func (*NodeGroupDeleteNodesResponse) ProtoMessage()    {}
+func (*NodeGroupDeleteNodesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{23}
+}
+func (m *NodeGroupDeleteNodesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeGroupDeleteNodesResponse.Unmarshal(m, b)
+}
+func (m *NodeGroupDeleteNodesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeGroupDeleteNodesResponse.Marshal(b, m, deterministic)
+}
+func (dst *NodeGroupDeleteNodesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeGroupDeleteNodesResponse.Merge(dst, src)
+}
+func (m *NodeGroupDeleteNodesResponse) XXX_Size() int {
+	return xxx_messageInfo_NodeGroupDeleteNodesResponse.Size(m)
+}
+func (m *NodeGroupDeleteNodesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeGroupDeleteNodesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeGroupDeleteNodesResponse proto.InternalMessageInfo
+
+type NodeGroupDecreaseTargetSizeRequest struct {
+	// Number of nodes to delete.
+	Delta int32 `protobuf:"varint,1,opt,name=delta" json:"delta,omitempty"`
+	// ID of the node group for the request.
+	Id                   string   `protobuf:"bytes,2,opt,name=id" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeGroupDecreaseTargetSizeRequest) Reset()         { *m = NodeGroupDecreaseTargetSizeRequest{} }
+func (m *NodeGroupDecreaseTargetSizeRequest) String() string { return proto.CompactTextString(m) }
+func (*NodeGroupDecreaseTargetSizeRequest) ProtoMessage()    {}
+func (*NodeGroupDecreaseTargetSizeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{24}
+}
+func (m *NodeGroupDecreaseTargetSizeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeGroupDecreaseTargetSizeRequest.Unmarshal(m, b)
+}
+func (m *NodeGroupDecreaseTargetSizeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeGroupDecreaseTargetSizeRequest.Marshal(b, m, deterministic)
+}
+func (dst *NodeGroupDecreaseTargetSizeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeGroupDecreaseTargetSizeRequest.Merge(dst, src)
+}
+func (m *NodeGroupDecreaseTargetSizeRequest) XXX_Size() int {
+	return xxx_messageInfo_NodeGroupDecreaseTargetSizeRequest.Size(m)
+}
+func (m *NodeGroupDecreaseTargetSizeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeGroupDecreaseTargetSizeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeGroupDecreaseTargetSizeRequest proto.InternalMessageInfo
+
+func (m *NodeGroupDecreaseTargetSizeRequest) GetDelta() int32 {
+	if m != nil {
+		return m.Delta
+	}
+	return 0
+}
+
+func (m *NodeGroupDecreaseTargetSizeRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type NodeGroupDecreaseTargetSizeResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeGroupDecreaseTargetSizeResponse) Reset()         { *m = NodeGroupDecreaseTargetSizeResponse{} }
+func (m *NodeGroupDecreaseTargetSizeResponse) String() string { return proto.CompactTextString(m) }
+func (*NodeGroupDecreaseTargetSizeResponse) ProtoMessage()    {}
+func (*NodeGroupDecreaseTargetSizeResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{25}
+}
+func (m *NodeGroupDecreaseTargetSizeResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeGroupDecreaseTargetSizeResponse.Unmarshal(m, b)
+}
+func (m *NodeGroupDecreaseTargetSizeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeGroupDecreaseTargetSizeResponse.Marshal(b, m, deterministic)
+}
+func (dst *NodeGroupDecreaseTargetSizeResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeGroupDecreaseTargetSizeResponse.Merge(dst, src)
+}
+func (m *NodeGroupDecreaseTargetSizeResponse) XXX_Size() int {
+	return xxx_messageInfo_NodeGroupDecreaseTargetSizeResponse.Size(m)
+}
+func (m *NodeGroupDecreaseTargetSizeResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeGroupDecreaseTargetSizeResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeGroupDecreaseTargetSizeResponse proto.InternalMessageInfo
+
+type NodeGroupNodesRequest struct {
+	// ID of the node group for the request.
+	Id                   string   `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeGroupNodesRequest) Reset()         { *m = NodeGroupNodesRequest{} }
+func (m *NodeGroupNodesRequest) String() string { return proto.CompactTextString(m) }
+func (*NodeGroupNodesRequest) ProtoMessage()    {}
+func (*NodeGroupNodesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{26}
+}
+func (m *NodeGroupNodesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeGroupNodesRequest.Unmarshal(m, b)
+}
+func (m *NodeGroupNodesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeGroupNodesRequest.Marshal(b, m, deterministic)
+}
+func (dst *NodeGroupNodesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeGroupNodesRequest.Merge(dst, src)
+}
+func (m *NodeGroupNodesRequest) XXX_Size() int {
+	return xxx_messageInfo_NodeGroupNodesRequest.Size(m)
+}
+func (m *NodeGroupNodesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeGroupNodesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeGroupNodesRequest proto.InternalMessageInfo
+
+func (m *NodeGroupNodesRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type NodeGroupNodesResponse struct {
+	// list of cloud provider instances in a node group.
+	Instances            []*Instance `protobuf:"bytes,1,rep,name=instances" json:"instances,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *NodeGroupNodesResponse) Reset()         { *m = NodeGroupNodesResponse{} }
+func (m *NodeGroupNodesResponse) String() string { return proto.CompactTextString(m) }
+func (*NodeGroupNodesResponse) ProtoMessage()    {}
+func (*NodeGroupNodesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{27}
+}
+func (m *NodeGroupNodesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeGroupNodesResponse.Unmarshal(m, b)
+}
+func (m *NodeGroupNodesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeGroupNodesResponse.Marshal(b, m, deterministic)
+}
+func (dst *NodeGroupNodesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeGroupNodesResponse.Merge(dst, src)
+}
+func (m *NodeGroupNodesResponse) XXX_Size() int {
+	return xxx_messageInfo_NodeGroupNodesResponse.Size(m)
+}
+func (m *NodeGroupNodesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeGroupNodesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeGroupNodesResponse proto.InternalMessageInfo
+
+func (m *NodeGroupNodesResponse) GetInstances() []*Instance {
+	if m != nil {
+		return m.Instances
+	}
+	return nil
+}
+
+type Instance struct {
+	// Id of the instance.
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	// Status of the node.
+	Status               *InstanceStatus `protobuf:"bytes,2,opt,name=status" json:"status,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *Instance) Reset()         { *m = Instance{} }
+func (m *Instance) String() string { return proto.CompactTextString(m) }
+func (*Instance) ProtoMessage()    {}
+func (*Instance) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{28}
+}
+func (m *Instance) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Instance.Unmarshal(m, b)
+}
+func (m *Instance) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Instance.Marshal(b, m, deterministic)
+}
+func (dst *Instance) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Instance.Merge(dst, src)
+}
+func (m *Instance) XXX_Size() int {
+	return xxx_messageInfo_Instance.Size(m)
+}
+func (m *Instance) XXX_DiscardUnknown() {
+	xxx_messageInfo_Instance.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Instance proto.InternalMessageInfo
+
+func (m *Instance) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Instance) GetStatus() *InstanceStatus {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+// InstanceStatus represents status of node.
+type InstanceStatus struct {
+	// InstanceState tells if instance is running, being created or being deleted.
+	InstanceState InstanceStatus_InstanceState `protobuf:"varint,1,opt,name=instanceState,enum=clusterautoscaler.cloudprovider.v1.externalgrpc.InstanceStatus_InstanceState" json:"instanceState,omitempty"`
+	// ErrorInfo is not nil if there is error condition related to instance.
+	ErrorInfo            *InstanceErrorInfo `protobuf:"bytes,2,opt,name=errorInfo" json:"errorInfo,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *InstanceStatus) Reset()         { *m = InstanceStatus{} }
+func (m *InstanceStatus) String() string { return proto.CompactTextString(m) }
+func (*InstanceStatus) ProtoMessage()    {}
+func (*InstanceStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{29}
+}
+func (m *InstanceStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_InstanceStatus.Unmarshal(m, b)
+}
+func (m *InstanceStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_InstanceStatus.Marshal(b, m, deterministic)
+}
+func (dst *InstanceStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InstanceStatus.Merge(dst, src)
+}
+func (m *InstanceStatus) XXX_Size() int {
+	return xxx_messageInfo_InstanceStatus.Size(m)
+}
+func (m *InstanceStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_InstanceStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InstanceStatus proto.InternalMessageInfo
+
+func (m *InstanceStatus) GetInstanceState() InstanceStatus_InstanceState {
+	if m != nil {
+		return m.InstanceState
+	}
+	return InstanceStatus_unspecified
+}
+
+func (m *InstanceStatus) GetErrorInfo() *InstanceErrorInfo {
+	if m != nil {
+		return m.ErrorInfo
+	}
+	return nil
+}
+
+// InstanceErrorInfo provides information about error condition on instance.
+type InstanceErrorInfo struct {
+	// ErrorCode is cloud-provider specific error code for error condition.
+	ErrorCode string `protobuf:"bytes,1,opt,name=errorCode" json:"errorCode,omitempty"`
+	// ErrorMessage is human readable description of error condition.
+	ErrorMessage string `protobuf:"bytes,2,opt,name=errorMessage" json:"errorMessage,omitempty"`
+	// InstanceErrorClass defines class of error condition.
+	InstanceErrorClass   int32    `protobuf:"varint,3,opt,name=instanceErrorClass" json:"instanceErrorClass,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *InstanceErrorInfo) Reset()         { *m = InstanceErrorInfo{} }
+func (m *InstanceErrorInfo) String() string { return proto.CompactTextString(m) }
+func (*InstanceErrorInfo) ProtoMessage()    {}
+func (*InstanceErrorInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{30}
+}
+func (m *InstanceErrorInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_InstanceErrorInfo.Unmarshal(m, b)
+}
+func (m *InstanceErrorInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_InstanceErrorInfo.Marshal(b, m, deterministic)
+}
+func (dst *InstanceErrorInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InstanceErrorInfo.Merge(dst, src)
+}
+func (m *InstanceErrorInfo) XXX_Size() int {
+	return xxx_messageInfo_InstanceErrorInfo.Size(m)
+}
+func (m *InstanceErrorInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_InstanceErrorInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InstanceErrorInfo proto.InternalMessageInfo
+
+func (m *InstanceErrorInfo) GetErrorCode() string {
+	if m != nil {
+		return m.ErrorCode
+	}
+	return ""
+}
+
+func (m *InstanceErrorInfo) GetErrorMessage() string {
+	if m != nil {
+		return m.ErrorMessage
+	}
+	return ""
+}
+
+func (m *InstanceErrorInfo) GetInstanceErrorClass() int32 {
+	if m != nil {
+		return m.InstanceErrorClass
+	}
+	return 0
+}
+
+type NodeGroupTemplateNodeInfoRequest struct {
+	// ID of the node group for the request.
+	Id                   string   `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeGroupTemplateNodeInfoRequest) Reset()         { *m = NodeGroupTemplateNodeInfoRequest{} }
+func (m *NodeGroupTemplateNodeInfoRequest) String() string { return proto.CompactTextString(m) }
+func (*NodeGroupTemplateNodeInfoRequest) ProtoMessage()    {}
+func (*NodeGroupTemplateNodeInfoRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{31}
+}
+func (m *NodeGroupTemplateNodeInfoRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeGroupTemplateNodeInfoRequest.Unmarshal(m, b)
+}
+func (m *NodeGroupTemplateNodeInfoRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeGroupTemplateNodeInfoRequest.Marshal(b, m, deterministic)
+}
+func (dst *NodeGroupTemplateNodeInfoRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeGroupTemplateNodeInfoRequest.Merge(dst, src)
+}
+func (m *NodeGroupTemplateNodeInfoRequest) XXX_Size() int {
+	return xxx_messageInfo_NodeGroupTemplateNodeInfoRequest.Size(m)
+}
+func (m *NodeGroupTemplateNodeInfoRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeGroupTemplateNodeInfoRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeGroupTemplateNodeInfoRequest proto.InternalMessageInfo
+
+func (m *NodeGroupTemplateNodeInfoRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type NodeGroupTemplateNodeInfoResponse struct {
+	// nodeInfo is the extracted data from the cloud provider, as a primitive Kubernetes Node type
+	// serialized using the Kubernetes protobuf encoding of k8s.io.api.core.v1.Node.
+	NodeInfo             []byte   `protobuf:"bytes,1,opt,name=nodeInfo,proto3" json:"nodeInfo,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeGroupTemplateNodeInfoResponse) Reset()         { *m = NodeGroupTemplateNodeInfoResponse{} }
+func (m *NodeGroupTemplateNodeInfoResponse) String() string { return proto.CompactTextString(m) }
+func (*NodeGroupTemplateNodeInfoResponse) ProtoMessage()    {}
+func (*NodeGroupTemplateNodeInfoResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{32}
+}
+func (m *NodeGroupTemplateNodeInfoResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeGroupTemplateNodeInfoResponse.Unmarshal(m, b)
+}
+func (m *NodeGroupTemplateNodeInfoResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeGroupTemplateNodeInfoResponse.Marshal(b, m, deterministic)
+}
+func (dst *NodeGroupTemplateNodeInfoResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeGroupTemplateNodeInfoResponse.Merge(dst, src)
+}
+func (m *NodeGroupTemplateNodeInfoResponse) XXX_Size() int {
+	return xxx_messageInfo_NodeGroupTemplateNodeInfoResponse.Size(m)
+}
+func (m *NodeGroupTemplateNodeInfoResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeGroupTemplateNodeInfoResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeGroupTemplateNodeInfoResponse proto.InternalMessageInfo
+
+func (m *NodeGroupTemplateNodeInfoResponse) GetNodeInfo() []byte {
+	if m != nil {
+		return m.NodeInfo
+	}
+	return nil
+}
+
+type NodeGroupAutoscalingOptions struct {
+	// ScaleDownUtilizationThreshold sets threshold for nodes to be considered for scale down
+	// if cpu or memory utilization is over threshold.
+	ScaleDownUtilizationThreshold float64 `protobuf:"fixed64,1,opt,name=scaleDownUtilizationThreshold" json:"scaleDownUtilizationThreshold,omitempty"`
+	// ScaleDownGpuUtilizationThreshold sets threshold for gpu nodes to be
+	// considered for scale down if gpu utilization is over threshold.
+	ScaleDownGpuUtilizationThreshold float64 `protobuf:"fixed64,2,opt,name=scaleDownGpuUtilizationThreshold" json:"scaleDownGpuUtilizationThreshold,omitempty"`
+	// ScaleDownUnneededTime sets the duration CA expects a node to be
+	// unneeded/eligible for removal before scaling down the node.
+	ScaleDownUnneededTime *duration.Duration `protobuf:"bytes,3,opt,name=scaleDownUnneededTime" json:"scaleDownUnneededTime,omitempty"`
+	// ScaleDownUnreadyTime represents how long an unready node should be
+	// unneeded before it is eligible for scale down.
+	ScaleDownUnreadyTime *duration.Duration `protobuf:"bytes,4,opt,name=scaleDownUnreadyTime" json:"scaleDownUnreadyTime,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *NodeGroupAutoscalingOptions) Reset()         { *m = NodeGroupAutoscalingOptions{} }
+func (m *NodeGroupAutoscalingOptions) String() string { return proto.CompactTextString(m) }
+func (*NodeGroupAutoscalingOptions) ProtoMessage()    {}
+func (*NodeGroupAutoscalingOptions) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{33}
+}
+func (m *NodeGroupAutoscalingOptions) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeGroupAutoscalingOptions.Unmarshal(m, b)
+}
+func (m *NodeGroupAutoscalingOptions) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeGroupAutoscalingOptions.Marshal(b, m, deterministic)
+}
+func (dst *NodeGroupAutoscalingOptions) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeGroupAutoscalingOptions.Merge(dst, src)
+}
+func (m *NodeGroupAutoscalingOptions) XXX_Size() int {
+	return xxx_messageInfo_NodeGroupAutoscalingOptions.Size(m)
+}
+func (m *NodeGroupAutoscalingOptions) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeGroupAutoscalingOptions.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeGroupAutoscalingOptions proto.InternalMessageInfo
+
+func (m *NodeGroupAutoscalingOptions) GetScaleDownUtilizationThreshold() float64 {
+	if m != nil {
+		return m.ScaleDownUtilizationThreshold
+	}
+	return 0
+}
+
+func (m *NodeGroupAutoscalingOptions) GetScaleDownGpuUtilizationThreshold() float64 {
+	if m != nil {
+		return m.ScaleDownGpuUtilizationThreshold
+	}
+	return 0
+}
+
+func (m *NodeGroupAutoscalingOptions) GetScaleDownUnneededTime() *duration.Duration {
+	if m != nil {
+		return m.ScaleDownUnneededTime
+	}
+	return nil
+}
+
+func (m *NodeGroupAutoscalingOptions) GetScaleDownUnreadyTime() *duration.Duration {
+	if m != nil {
+		return m.ScaleDownUnreadyTime
+	}
+	return nil
+}
+
+type NodeGroupAutoscalingOptionsRequest struct {
+	// ID of the node group for the request.
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	// default node group autoscaling options.
+	Defaults             *NodeGroupAutoscalingOptions `protobuf:"bytes,2,opt,name=defaults" json:"defaults,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                     `json:"-"`
+	XXX_unrecognized     []byte                       `json:"-"`
+	XXX_sizecache        int32                        `json:"-"`
+}
+
+func (m *NodeGroupAutoscalingOptionsRequest) Reset()         { *m = NodeGroupAutoscalingOptionsRequest{} }
+func (m *NodeGroupAutoscalingOptionsRequest) String() string { return proto.CompactTextString(m) }
+func (*NodeGroupAutoscalingOptionsRequest) ProtoMessage()    {}
+func (*NodeGroupAutoscalingOptionsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{34}
+}
+func (m *NodeGroupAutoscalingOptionsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeGroupAutoscalingOptionsRequest.Unmarshal(m, b)
+}
+func (m *NodeGroupAutoscalingOptionsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeGroupAutoscalingOptionsRequest.Marshal(b, m, deterministic)
+}
+func (dst *NodeGroupAutoscalingOptionsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeGroupAutoscalingOptionsRequest.Merge(dst, src)
+}
+func (m *NodeGroupAutoscalingOptionsRequest) XXX_Size() int {
+	return xxx_messageInfo_NodeGroupAutoscalingOptionsRequest.Size(m)
+}
+func (m *NodeGroupAutoscalingOptionsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeGroupAutoscalingOptionsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeGroupAutoscalingOptionsRequest proto.InternalMessageInfo
+
+func (m *NodeGroupAutoscalingOptionsRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *NodeGroupAutoscalingOptionsRequest) GetDefaults() *NodeGroupAutoscalingOptions {
+	if m != nil {
+		return m.Defaults
+	}
+	return nil
+}
+
+type NodeGroupAutoscalingOptionsResponse struct {
+	// autoscaling options for the requested node.
+	NodeGroupAutoscalingOptions *NodeGroupAutoscalingOptions `protobuf:"bytes,1,opt,name=nodeGroupAutoscalingOptions" json:"nodeGroupAutoscalingOptions,omitempty"`
+	XXX_NoUnkeyedLiteral        struct{}                     `json:"-"`
+	XXX_unrecognized            []byte                       `json:"-"`
+	XXX_sizecache               int32                        `json:"-"`
+}
+
+func (m *NodeGroupAutoscalingOptionsResponse) Reset()         { *m = NodeGroupAutoscalingOptionsResponse{} }
+func (m *NodeGroupAutoscalingOptionsResponse) String() string { return proto.CompactTextString(m) }
+func (*NodeGroupAutoscalingOptionsResponse) ProtoMessage()    {}
+func (*NodeGroupAutoscalingOptionsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_externalgrpc_b1d154c0cfb7bcd3, []int{35}
+}
+func (m *NodeGroupAutoscalingOptionsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeGroupAutoscalingOptionsResponse.Unmarshal(m, b)
+}
+func (m *NodeGroupAutoscalingOptionsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeGroupAutoscalingOptionsResponse.Marshal(b, m, deterministic)
+}
+func (dst *NodeGroupAutoscalingOptionsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeGroupAutoscalingOptionsResponse.Merge(dst, src)
+}
+func (m *NodeGroupAutoscalingOptionsResponse) XXX_Size() int {
+	return xxx_messageInfo_NodeGroupAutoscalingOptionsResponse.Size(m)
+}
+func (m *NodeGroupAutoscalingOptionsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeGroupAutoscalingOptionsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeGroupAutoscalingOptionsResponse proto.InternalMessageInfo
+
+func (m *NodeGroupAutoscalingOptionsResponse) GetNodeGroupAutoscalingOptions() *NodeGroupAutoscalingOptions {
+	if m != nil {
+		return m.NodeGroupAutoscalingOptions
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*NodeGroup)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.NodeGroup")
+	proto.RegisterType((*ExternalGrpcNode)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.ExternalGrpcNode")
+	proto.RegisterMapType((map[string]string)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.ExternalGrpcNode.AnnotationsEntry")
+	proto.RegisterMapType((map[string]string)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.ExternalGrpcNode.LabelsEntry")
+	proto.RegisterType((*NodeGroupsRequest)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.NodeGroupsRequest")
+	proto.RegisterType((*NodeGroupsResponse)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.NodeGroupsResponse")
+	proto.RegisterType((*NodeGroupForNodeRequest)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.NodeGroupForNodeRequest")
+	proto.RegisterType((*NodeGroupForNodeResponse)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.NodeGroupForNodeResponse")
+	proto.RegisterType((*PricingNodePriceRequest)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.PricingNodePriceRequest")
+	proto.RegisterType((*PricingNodePriceResponse)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.PricingNodePriceResponse")
+	proto.RegisterType((*PricingPodPriceRequest)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.PricingPodPriceRequest")
+	proto.RegisterType((*PricingPodPriceResponse)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.PricingPodPriceResponse")
+	proto.RegisterType((*GPULabelRequest)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.GPULabelRequest")
+	proto.RegisterType((*GPULabelResponse)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.GPULabelResponse")
+	proto.RegisterType((*GetAvailableGPUTypesRequest)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.GetAvailableGPUTypesRequest")
+	proto.RegisterType((*GetAvailableGPUTypesResponse)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.GetAvailableGPUTypesResponse")
+	proto.RegisterType((*CleanupRequest)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.CleanupRequest")
+	proto.RegisterType((*CleanupResponse)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.CleanupResponse")
+	proto.RegisterType((*RefreshRequest)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.RefreshRequest")
+	proto.RegisterType((*RefreshResponse)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.RefreshResponse")
+	proto.RegisterType((*NodeGroupTargetSizeRequest)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.NodeGroupTargetSizeRequest")
+	proto.RegisterType((*NodeGroupTargetSizeResponse)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.NodeGroupTargetSizeResponse")
+	proto.RegisterType((*NodeGroupIncreaseSizeRequest)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.NodeGroupIncreaseSizeRequest")
+	proto.RegisterType((*NodeGroupIncreaseSizeResponse)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.NodeGroupIncreaseSizeResponse")
+	proto.RegisterType((*NodeGroupDeleteNodesRequest)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.NodeGroupDeleteNodesRequest")
+	proto.RegisterType((*NodeGroupDeleteNodesResponse)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.NodeGroupDeleteNodesResponse")
+	proto.RegisterType((*NodeGroupDecreaseTargetSizeRequest)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.NodeGroupDecreaseTargetSizeRequest")
+	proto.RegisterType((*NodeGroupDecreaseTargetSizeResponse)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.NodeGroupDecreaseTargetSizeResponse")
+	proto.RegisterType((*NodeGroupNodesRequest)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.NodeGroupNodesRequest")
+	proto.RegisterType((*NodeGroupNodesResponse)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.NodeGroupNodesResponse")
+	proto.RegisterType((*Instance)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.Instance")
+	proto.RegisterType((*InstanceStatus)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.InstanceStatus")
+	proto.RegisterType((*InstanceErrorInfo)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.InstanceErrorInfo")
+	proto.RegisterType((*NodeGroupTemplateNodeInfoRequest)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.NodeGroupTemplateNodeInfoRequest")
+	proto.RegisterType((*NodeGroupTemplateNodeInfoResponse)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.NodeGroupTemplateNodeInfoResponse")
+	proto.RegisterType((*NodeGroupAutoscalingOptions)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.NodeGroupAutoscalingOptions")
+	proto.RegisterType((*NodeGroupAutoscalingOptionsRequest)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.NodeGroupAutoscalingOptionsRequest")
+	proto.RegisterType((*NodeGroupAutoscalingOptionsResponse)(nil), "clusterautoscaler.cloudprovider.v1.externalgrpc.NodeGroupAutoscalingOptionsResponse")
+	proto.RegisterEnum("clusterautoscaler.cloudprovider.v1.externalgrpc.InstanceStatus_InstanceState", InstanceStatus_InstanceState_name, InstanceStatus_InstanceState_value)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for CloudProvider service
+
+type CloudProviderClient interface {
+	// NodeGroups returns all node groups configured for this cloud provider.
+	NodeGroups(ctx context.Context, in *NodeGroupsRequest, opts ...grpc.CallOption) (*NodeGroupsResponse, error)
+	// NodeGroupForNode returns the node group for the given node.
+	// The node group id is an empty string if the node should not
+	// be processed by cluster autoscaler.
+	NodeGroupForNode(ctx context.Context, in *NodeGroupForNodeRequest, opts ...grpc.CallOption) (*NodeGroupForNodeResponse, error)
+	// PricingNodePrice returns a theoretical minimum price of running a node for
+	// a given period of time on a perfectly matching machine.
+	// Implementation optional.
+	PricingNodePrice(ctx context.Context, in *PricingNodePriceRequest, opts ...grpc.CallOption) (*PricingNodePriceResponse, error)
+	// PricingPodPrice returns a theoretical minimum price of running a pod for a given
+	// period of time on a perfectly matching machine.
+	// Implementation optional.
+	PricingPodPrice(ctx context.Context, in *PricingPodPriceRequest, opts ...grpc.CallOption) (*PricingPodPriceResponse, error)
+	// GPULabel returns the label added to nodes with GPU resource.
+	GPULabel(ctx context.Context, in *GPULabelRequest, opts ...grpc.CallOption) (*GPULabelResponse, error)
+	// GetAvailableGPUTypes return all available GPU types cloud provider supports.
+	GetAvailableGPUTypes(ctx context.Context, in *GetAvailableGPUTypesRequest, opts ...grpc.CallOption) (*GetAvailableGPUTypesResponse, error)
+	// Cleanup cleans up open resources before the cloud provider is destroyed, i.e. go routines etc.
+	Cleanup(ctx context.Context, in *CleanupRequest, opts ...grpc.CallOption) (*CleanupResponse, error)
+	// Refresh is called before every main loop and can be used to dynamically update cloud provider state.
+	Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*RefreshResponse, error)
+	// NodeGroupTargetSize returns the current target size of the node group. It is possible
+	// that the number of nodes in Kubernetes is different at the moment but should be equal
+	// to the size of a node group once everything stabilizes (new nodes finish startup and
+	// registration or removed nodes are deleted completely).
+	NodeGroupTargetSize(ctx context.Context, in *NodeGroupTargetSizeRequest, opts ...grpc.CallOption) (*NodeGroupTargetSizeResponse, error)
+	// NodeGroupIncreaseSize increases the size of the node group. To delete a node you need
+	// to explicitly name it and use NodeGroupDeleteNodes. This function should wait until
+	// node group size is updated.
+	NodeGroupIncreaseSize(ctx context.Context, in *NodeGroupIncreaseSizeRequest, opts ...grpc.CallOption) (*NodeGroupIncreaseSizeResponse, error)
+	// NodeGroupDeleteNodes deletes nodes from this node group (and also decreasing the size
+	// of the node group with that). Error is returned either on failure or if the given node
+	// doesn't belong to this node group. This function should wait until node group size is updated.
+	NodeGroupDeleteNodes(ctx context.Context, in *NodeGroupDeleteNodesRequest, opts ...grpc.CallOption) (*NodeGroupDeleteNodesResponse, error)
+	// NodeGroupDecreaseTargetSize decreases the target size of the node group. This function
+	// doesn't permit to delete any existing node and can be used only to reduce the request
+	// for new nodes that have not been yet fulfilled. Delta should be negative. It is assumed
+	// that cloud provider will not delete the existing nodes if the size when there is an option
+	// to just decrease the target.
+	NodeGroupDecreaseTargetSize(ctx context.Context, in *NodeGroupDecreaseTargetSizeRequest, opts ...grpc.CallOption) (*NodeGroupDecreaseTargetSizeResponse, error)
+	// NodeGroupNodes returns a list of all nodes that belong to this node group.
+	NodeGroupNodes(ctx context.Context, in *NodeGroupNodesRequest, opts ...grpc.CallOption) (*NodeGroupNodesResponse, error)
+	// NodeGroupTemplateNodeInfo returns a structure of an empty (as if just started) node,
+	// with all of the labels, capacity and allocatable information. This will be used in
+	// scale-up simulations to predict what would a new node look like if a node group was expanded.
+	// Implementation optional.
+	NodeGroupTemplateNodeInfo(ctx context.Context, in *NodeGroupTemplateNodeInfoRequest, opts ...grpc.CallOption) (*NodeGroupTemplateNodeInfoResponse, error)
+	// NodeGroupGetOptions returns NodeGroupAutoscalingOptions that should be used for this particular
+	// NodeGroup. Returning a grpc error will result in using default options.
+	// Implementation optional.
+	NodeGroupGetOptions(ctx context.Context, in *NodeGroupAutoscalingOptionsRequest, opts ...grpc.CallOption) (*NodeGroupAutoscalingOptionsResponse, error)
+}
+
+type cloudProviderClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewCloudProviderClient(cc *grpc.ClientConn) CloudProviderClient {
+	return &cloudProviderClient{cc}
+}
+
+func (c *cloudProviderClient) NodeGroups(ctx context.Context, in *NodeGroupsRequest, opts ...grpc.CallOption) (*NodeGroupsResponse, error) {
+	out := new(NodeGroupsResponse)
+	err := grpc.Invoke(ctx, "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/NodeGroups", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cloudProviderClient) NodeGroupForNode(ctx context.Context, in *NodeGroupForNodeRequest, opts ...grpc.CallOption) (*NodeGroupForNodeResponse, error) {
+	out := new(NodeGroupForNodeResponse)
+	err := grpc.Invoke(ctx, "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/NodeGroupForNode", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cloudProviderClient) PricingNodePrice(ctx context.Context, in *PricingNodePriceRequest, opts ...grpc.CallOption) (*PricingNodePriceResponse, error) {
+	out := new(PricingNodePriceResponse)
+	err := grpc.Invoke(ctx, "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/PricingNodePrice", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cloudProviderClient) PricingPodPrice(ctx context.Context, in *PricingPodPriceRequest, opts ...grpc.CallOption) (*PricingPodPriceResponse, error) {
+	out := new(PricingPodPriceResponse)
+	err := grpc.Invoke(ctx, "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/PricingPodPrice", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cloudProviderClient) GPULabel(ctx context.Context, in *GPULabelRequest, opts ...grpc.CallOption) (*GPULabelResponse, error) {
+	out := new(GPULabelResponse)
+	err := grpc.Invoke(ctx, "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/GPULabel", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cloudProviderClient) GetAvailableGPUTypes(ctx context.Context, in *GetAvailableGPUTypesRequest, opts ...grpc.CallOption) (*GetAvailableGPUTypesResponse, error) {
+	out := new(GetAvailableGPUTypesResponse)
+	err := grpc.Invoke(ctx, "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/GetAvailableGPUTypes", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cloudProviderClient) Cleanup(ctx context.Context, in *CleanupRequest, opts ...grpc.CallOption) (*CleanupResponse, error) {
+	out := new(CleanupResponse)
+	err := grpc.Invoke(ctx, "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/Cleanup", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cloudProviderClient) Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*RefreshResponse, error) {
+	out := new(RefreshResponse)
+	err := grpc.Invoke(ctx, "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/Refresh", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cloudProviderClient) NodeGroupTargetSize(ctx context.Context, in *NodeGroupTargetSizeRequest, opts ...grpc.CallOption) (*NodeGroupTargetSizeResponse, error) {
+	out := new(NodeGroupTargetSizeResponse)
+	err := grpc.Invoke(ctx, "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/NodeGroupTargetSize", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cloudProviderClient) NodeGroupIncreaseSize(ctx context.Context, in *NodeGroupIncreaseSizeRequest, opts ...grpc.CallOption) (*NodeGroupIncreaseSizeResponse, error) {
+	out := new(NodeGroupIncreaseSizeResponse)
+	err := grpc.Invoke(ctx, "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/NodeGroupIncreaseSize", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cloudProviderClient) NodeGroupDeleteNodes(ctx context.Context, in *NodeGroupDeleteNodesRequest, opts ...grpc.CallOption) (*NodeGroupDeleteNodesResponse, error) {
+	out := new(NodeGroupDeleteNodesResponse)
+	err := grpc.Invoke(ctx, "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/NodeGroupDeleteNodes", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cloudProviderClient) NodeGroupDecreaseTargetSize(ctx context.Context, in *NodeGroupDecreaseTargetSizeRequest, opts ...grpc.CallOption) (*NodeGroupDecreaseTargetSizeResponse, error) {
+	out := new(NodeGroupDecreaseTargetSizeResponse)
+	err := grpc.Invoke(ctx, "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/NodeGroupDecreaseTargetSize", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cloudProviderClient) NodeGroupNodes(ctx context.Context, in *NodeGroupNodesRequest, opts ...grpc.CallOption) (*NodeGroupNodesResponse, error) {
+	out := new(NodeGroupNodesResponse)
+	err := grpc.Invoke(ctx, "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/NodeGroupNodes", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cloudProviderClient) NodeGroupTemplateNodeInfo(ctx context.Context, in *NodeGroupTemplateNodeInfoRequest, opts ...grpc.CallOption) (*NodeGroupTemplateNodeInfoResponse, error) {
+	out := new(NodeGroupTemplateNodeInfoResponse)
+	err := grpc.Invoke(ctx, "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/NodeGroupTemplateNodeInfo", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cloudProviderClient) NodeGroupGetOptions(ctx context.Context, in *NodeGroupAutoscalingOptionsRequest, opts ...grpc.CallOption) (*NodeGroupAutoscalingOptionsResponse, error) {
+	out := new(NodeGroupAutoscalingOptionsResponse)
+	err := grpc.Invoke(ctx, "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/NodeGroupGetOptions", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for CloudProvider service
+
+type CloudProviderServer interface {
+	// NodeGroups returns all node groups configured for this cloud provider.
+	NodeGroups(context.Context, *NodeGroupsRequest) (*NodeGroupsResponse, error)
+	// NodeGroupForNode returns the node group for the given node.
+	// The node group id is an empty string if the node should not
+	// be processed by cluster autoscaler.
+	NodeGroupForNode(context.Context, *NodeGroupForNodeRequest) (*NodeGroupForNodeResponse, error)
+	// PricingNodePrice returns a theoretical minimum price of running a node for
+	// a given period of time on a perfectly matching machine.
+	// Implementation optional.
+	PricingNodePrice(context.Context, *PricingNodePriceRequest) (*PricingNodePriceResponse, error)
+	// PricingPodPrice returns a theoretical minimum price of running a pod for a given
+	// period of time on a perfectly matching machine.
+	// Implementation optional.
+	PricingPodPrice(context.Context, *PricingPodPriceRequest) (*PricingPodPriceResponse, error)
+	// GPULabel returns the label added to nodes with GPU resource.
+	GPULabel(context.Context, *GPULabelRequest) (*GPULabelResponse, error)
+	// GetAvailableGPUTypes return all available GPU types cloud provider supports.
+	GetAvailableGPUTypes(context.Context, *GetAvailableGPUTypesRequest) (*GetAvailableGPUTypesResponse, error)
+	// Cleanup cleans up open resources before the cloud provider is destroyed, i.e. go routines etc.
+	Cleanup(context.Context, *CleanupRequest) (*CleanupResponse, error)
+	// Refresh is called before every main loop and can be used to dynamically update cloud provider state.
+	Refresh(context.Context, *RefreshRequest) (*RefreshResponse, error)
+	// NodeGroupTargetSize returns the current target size of the node group. It is possible
+	// that the number of nodes in Kubernetes is different at the moment but should be equal
+	// to the size of a node group once everything stabilizes (new nodes finish startup and
+	// registration or removed nodes are deleted completely).
+	NodeGroupTargetSize(context.Context, *NodeGroupTargetSizeRequest) (*NodeGroupTargetSizeResponse, error)
+	// NodeGroupIncreaseSize increases the size of the node group. To delete a node you need
+	// to explicitly name it and use NodeGroupDeleteNodes. This function should wait until
+	// node group size is updated.
+	NodeGroupIncreaseSize(context.Context, *NodeGroupIncreaseSizeRequest) (*NodeGroupIncreaseSizeResponse, error)
+	// NodeGroupDeleteNodes deletes nodes from this node group (and also decreasing the size
+	// of the node group with that). Error is returned either on failure or if the given node
+	// doesn't belong to this node group. This function should wait until node group size is updated.
+	NodeGroupDeleteNodes(context.Context, *NodeGroupDeleteNodesRequest) (*NodeGroupDeleteNodesResponse, error)
+	// NodeGroupDecreaseTargetSize decreases the target size of the node group. This function
+	// doesn't permit to delete any existing node and can be used only to reduce the request
+	// for new nodes that have not been yet fulfilled. Delta should be negative. It is assumed
+	// that cloud provider will not delete the existing nodes if the size when there is an option
+	// to just decrease the target.
+	NodeGroupDecreaseTargetSize(context.Context, *NodeGroupDecreaseTargetSizeRequest) (*NodeGroupDecreaseTargetSizeResponse, error)
+	// NodeGroupNodes returns a list of all nodes that belong to this node group.
+	NodeGroupNodes(context.Context, *NodeGroupNodesRequest) (*NodeGroupNodesResponse, error)
+	// NodeGroupTemplateNodeInfo returns a structure of an empty (as if just started) node,
+	// with all of the labels, capacity and allocatable information. This will be used in
+	// scale-up simulations to predict what would a new node look like if a node group was expanded.
+	// Implementation optional.
+	NodeGroupTemplateNodeInfo(context.Context, *NodeGroupTemplateNodeInfoRequest) (*NodeGroupTemplateNodeInfoResponse, error)
+	// NodeGroupGetOptions returns NodeGroupAutoscalingOptions that should be used for this particular
+	// NodeGroup. Returning a grpc error will result in using default options.
+	// Implementation optional.
+	NodeGroupGetOptions(context.Context, *NodeGroupAutoscalingOptionsRequest) (*NodeGroupAutoscalingOptionsResponse, error)
+}
+
+func RegisterCloudProviderServer(s *grpc.Server, srv CloudProviderServer) {
+	s.RegisterService(&_CloudProvider_serviceDesc, srv)
+}
+
+func _CloudProvider_NodeGroups_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeGroupsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudProviderServer).NodeGroups(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/NodeGroups",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudProviderServer).NodeGroups(ctx, req.(*NodeGroupsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CloudProvider_NodeGroupForNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeGroupForNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudProviderServer).NodeGroupForNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/NodeGroupForNode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudProviderServer).NodeGroupForNode(ctx, req.(*NodeGroupForNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CloudProvider_PricingNodePrice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PricingNodePriceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudProviderServer).PricingNodePrice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/PricingNodePrice",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudProviderServer).PricingNodePrice(ctx, req.(*PricingNodePriceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CloudProvider_PricingPodPrice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PricingPodPriceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudProviderServer).PricingPodPrice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/PricingPodPrice",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudProviderServer).PricingPodPrice(ctx, req.(*PricingPodPriceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CloudProvider_GPULabel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GPULabelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudProviderServer).GPULabel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/GPULabel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudProviderServer).GPULabel(ctx, req.(*GPULabelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CloudProvider_GetAvailableGPUTypes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAvailableGPUTypesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudProviderServer).GetAvailableGPUTypes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/GetAvailableGPUTypes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudProviderServer).GetAvailableGPUTypes(ctx, req.(*GetAvailableGPUTypesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CloudProvider_Cleanup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CleanupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudProviderServer).Cleanup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/Cleanup",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudProviderServer).Cleanup(ctx, req.(*CleanupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CloudProvider_Refresh_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudProviderServer).Refresh(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/Refresh",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudProviderServer).Refresh(ctx, req.(*RefreshRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CloudProvider_NodeGroupTargetSize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeGroupTargetSizeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudProviderServer).NodeGroupTargetSize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/NodeGroupTargetSize",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudProviderServer).NodeGroupTargetSize(ctx, req.(*NodeGroupTargetSizeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CloudProvider_NodeGroupIncreaseSize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeGroupIncreaseSizeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudProviderServer).NodeGroupIncreaseSize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/NodeGroupIncreaseSize",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudProviderServer).NodeGroupIncreaseSize(ctx, req.(*NodeGroupIncreaseSizeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CloudProvider_NodeGroupDeleteNodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeGroupDeleteNodesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudProviderServer).NodeGroupDeleteNodes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/NodeGroupDeleteNodes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudProviderServer).NodeGroupDeleteNodes(ctx, req.(*NodeGroupDeleteNodesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CloudProvider_NodeGroupDecreaseTargetSize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeGroupDecreaseTargetSizeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudProviderServer).NodeGroupDecreaseTargetSize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/NodeGroupDecreaseTargetSize",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudProviderServer).NodeGroupDecreaseTargetSize(ctx, req.(*NodeGroupDecreaseTargetSizeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CloudProvider_NodeGroupNodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeGroupNodesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudProviderServer).NodeGroupNodes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/NodeGroupNodes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudProviderServer).NodeGroupNodes(ctx, req.(*NodeGroupNodesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CloudProvider_NodeGroupTemplateNodeInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeGroupTemplateNodeInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudProviderServer).NodeGroupTemplateNodeInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/NodeGroupTemplateNodeInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudProviderServer).NodeGroupTemplateNodeInfo(ctx, req.(*NodeGroupTemplateNodeInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CloudProvider_NodeGroupGetOptions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeGroupAutoscalingOptionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CloudProviderServer).NodeGroupGetOptions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider/NodeGroupGetOptions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CloudProviderServer).NodeGroupGetOptions(ctx, req.(*NodeGroupAutoscalingOptionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _CloudProvider_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "clusterautoscaler.cloudprovider.v1.externalgrpc.CloudProvider",
+	HandlerType: (*CloudProviderServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "NodeGroups",
+			Handler:    _CloudProvider_NodeGroups_Handler,
+		},
+		{
+			MethodName: "NodeGroupForNode",
+			Handler:    _CloudProvider_NodeGroupForNode_Handler,
+		},
+		{
+			MethodName: "PricingNodePrice",
+			Handler:    _CloudProvider_PricingNodePrice_Handler,
+		},
+		{
+			MethodName: "PricingPodPrice",
+			Handler:    _CloudProvider_PricingPodPrice_Handler,
+		},
+		{
+			MethodName: "GPULabel",
+			Handler:    _CloudProvider_GPULabel_Handler,
+		},
+		{
+			MethodName: "GetAvailableGPUTypes",
+			Handler:    _CloudProvider_GetAvailableGPUTypes_Handler,
+		},
+		{
+			MethodName: "Cleanup",
+			Handler:    _CloudProvider_Cleanup_Handler,
+		},
+		{
+			MethodName: "Refresh",
+			Handler:    _CloudProvider_Refresh_Handler,
+		},
+		{
+			MethodName: "NodeGroupTargetSize",
+			Handler:    _CloudProvider_NodeGroupTargetSize_Handler,
+		},
+		{
+			MethodName: "NodeGroupIncreaseSize",
+			Handler:    _CloudProvider_NodeGroupIncreaseSize_Handler,
+		},
+		{
+			MethodName: "NodeGroupDeleteNodes",
+			Handler:    _CloudProvider_NodeGroupDeleteNodes_Handler,
+		},
+		{
+			MethodName: "NodeGroupDecreaseTargetSize",
+			Handler:    _CloudProvider_NodeGroupDecreaseTargetSize_Handler,
+		},
+		{
+			MethodName: "NodeGroupNodes",
+			Handler:    _CloudProvider_NodeGroupNodes_Handler,
+		},
+		{
+			MethodName: "NodeGroupTemplateNodeInfo",
+			Handler:    _CloudProvider_NodeGroupTemplateNodeInfo_Handler,
+		},
+		{
+			MethodName: "NodeGroupGetOptions",
+			Handler:    _CloudProvider_NodeGroupGetOptions_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "externalgrpc.proto",
+}
+
+func init() { proto.RegisterFile("externalgrpc.proto", fileDescriptor_externalgrpc_b1d154c0cfb7bcd3) }
+
+var fileDescriptor_externalgrpc_b1d154c0cfb7bcd3 = []byte{
+	// 1425 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x57, 0xc1, 0x6f, 0xd4, 0x46,
+	0x17, 0xcf, 0xec, 0x26, 0x90, 0x7d, 0x81, 0x64, 0x33, 0x04, 0x58, 0x4c, 0x08, 0xf9, 0x06, 0x7d,
+	0x6a, 0x0e, 0xd5, 0xa6, 0x4d, 0x7b, 0x00, 0xa4, 0x16, 0x42, 0x02, 0x4b, 0x28, 0x81, 0xd4, 0x49,
+	0x44, 0xc5, 0xa9, 0x93, 0xf5, 0x64, 0xb1, 0xea, 0x8c, 0x8d, 0x3d, 0x4e, 0x09, 0xf7, 0xf6, 0x88,
+	0xd4, 0x53, 0xa5, 0x4a, 0xe5, 0x52, 0xa9, 0x52, 0xaf, 0x95, 0x5a, 0xf5, 0x56, 0xa9, 0xea, 0x89,
+	0x4b, 0xff, 0xa5, 0xca, 0xe3, 0xf1, 0xac, 0xed, 0xf5, 0x2e, 0x5d, 0xef, 0xb6, 0xa7, 0xdd, 0x79,
+	0xf3, 0xde, 0xef, 0xfd, 0xde, 0x1b, 0xbf, 0x99, 0xf7, 0x00, 0xb3, 0x17, 0x82, 0xf9, 0x9c, 0x3a,
+	0x1d, 0xdf, 0x6b, 0x37, 0x3d, 0xdf, 0x15, 0x2e, 0x5e, 0x6d, 0x3b, 0x61, 0x20, 0x98, 0x4f, 0x43,
+	0xe1, 0x06, 0x6d, 0xea, 0x30, 0xbf, 0xd9, 0x76, 0xdc, 0xd0, 0xf2, 0x7c, 0xf7, 0xd8, 0xb6, 0x98,
+	0xdf, 0x3c, 0x7e, 0xbf, 0x99, 0x36, 0x33, 0x96, 0x3a, 0xae, 0xdb, 0x71, 0xd8, 0xaa, 0x34, 0x3f,
+	0x08, 0x0f, 0x57, 0xad, 0xd0, 0xa7, 0xc2, 0x76, 0x79, 0x0c, 0x68, 0x5c, 0xcd, 0xef, 0x0b, 0xfb,
+	0x88, 0x05, 0x82, 0x1e, 0x79, 0xb1, 0x02, 0x61, 0x50, 0x7b, 0xe4, 0x5a, 0xac, 0xe5, 0xbb, 0xa1,
+	0x87, 0x67, 0xa1, 0x62, 0x5b, 0x0d, 0xb4, 0x8c, 0x56, 0x6a, 0x66, 0xc5, 0xb6, 0x70, 0x03, 0x4e,
+	0x1f, 0xd9, 0x7c, 0xd7, 0x7e, 0xc9, 0x1a, 0x95, 0x65, 0xb4, 0x32, 0x65, 0x26, 0x4b, 0xb9, 0x43,
+	0x5f, 0xc8, 0x9d, 0xaa, 0xda, 0x89, 0x97, 0x78, 0x01, 0xa6, 0x2c, 0x76, 0x10, 0x76, 0x1a, 0x93,
+	0x12, 0x26, 0x5e, 0x90, 0xd7, 0x55, 0xa8, 0xdf, 0x55, 0xc4, 0x5b, 0xbe, 0xd7, 0x8e, 0x7c, 0xe2,
+	0x25, 0x80, 0x24, 0xb0, 0xad, 0x4d, 0xe5, 0x36, 0x25, 0xc1, 0x18, 0x26, 0x39, 0x3d, 0x8a, 0x7d,
+	0xd7, 0x4c, 0xf9, 0x1f, 0x33, 0x38, 0xe5, 0xd0, 0x03, 0xe6, 0x04, 0x8d, 0xea, 0x72, 0x75, 0x65,
+	0x66, 0x6d, 0xbb, 0x39, 0x64, 0xca, 0x9a, 0x79, 0x1a, 0xcd, 0x87, 0x12, 0xef, 0x2e, 0x17, 0xfe,
+	0x89, 0xa9, 0xc0, 0xb1, 0x80, 0x19, 0xca, 0xb9, 0x2b, 0x64, 0x2e, 0x83, 0xc6, 0xa4, 0xf4, 0x65,
+	0x8e, 0xee, 0x6b, 0xbd, 0x0b, 0x1a, 0x3b, 0x4c, 0xbb, 0x31, 0x6e, 0xc0, 0x4c, 0x8a, 0x0c, 0xae,
+	0x43, 0xf5, 0x0b, 0x76, 0xa2, 0x12, 0x13, 0xfd, 0x8d, 0x92, 0x7b, 0x4c, 0x9d, 0x30, 0x49, 0x49,
+	0xbc, 0xb8, 0x59, 0xb9, 0x8e, 0x8c, 0x8f, 0xa1, 0x9e, 0xc7, 0x1e, 0xc6, 0x9e, 0x9c, 0x83, 0x79,
+	0xfd, 0x1d, 0x04, 0x26, 0x7b, 0x1e, 0xb2, 0x40, 0x10, 0x0f, 0x70, 0x5a, 0x18, 0x78, 0x2e, 0x0f,
+	0x18, 0x7e, 0x0a, 0xc0, 0xb5, 0xb4, 0x81, 0x64, 0x6a, 0x6e, 0x0e, 0x9d, 0x1a, 0x0d, 0x6c, 0xa6,
+	0xd0, 0x88, 0x07, 0x17, 0xf5, 0xc6, 0x3d, 0xd7, 0x8f, 0xfe, 0x2b, 0x32, 0x78, 0x1f, 0x26, 0x23,
+	0x45, 0x19, 0xce, 0xcc, 0xda, 0xfa, 0xc8, 0x67, 0x61, 0x4a, 0x38, 0x22, 0xa0, 0xd1, 0xeb, 0x51,
+	0x45, 0xfa, 0x19, 0xd4, 0x34, 0x37, 0xe5, 0x77, 0x94, 0x40, 0xbb, 0x60, 0xe4, 0x7b, 0x04, 0x17,
+	0x77, 0x7c, 0xbb, 0x6d, 0xf3, 0x4e, 0xb4, 0x1f, 0xfd, 0xd5, 0x81, 0xe2, 0x54, 0xa0, 0x67, 0x62,
+	0x96, 0xf8, 0x3a, 0xd4, 0x02, 0x41, 0x7d, 0xb1, 0x67, 0xab, 0x7a, 0x98, 0x59, 0x33, 0x9a, 0x71,
+	0x6d, 0x37, 0x93, 0xda, 0x6e, 0xee, 0x25, 0xb5, 0x6d, 0x76, 0x95, 0xf1, 0x87, 0x70, 0x9a, 0x71,
+	0x4b, 0xda, 0x55, 0xdf, 0x6a, 0x97, 0xa8, 0x92, 0xf7, 0xa0, 0xd1, 0x4b, 0x4f, 0x65, 0x65, 0x01,
+	0xa6, 0xbc, 0x48, 0x20, 0x09, 0x22, 0x33, 0x5e, 0x90, 0xef, 0x10, 0x5c, 0x50, 0x26, 0x3b, 0xae,
+	0x95, 0x09, 0xa8, 0x0e, 0x55, 0xcf, 0xb5, 0x54, 0x3c, 0xd1, 0xdf, 0xff, 0x3c, 0x9c, 0x55, 0xb8,
+	0xd8, 0xc3, 0x6d, 0x60, 0x34, 0xf3, 0x30, 0xd7, 0xda, 0xd9, 0x97, 0xc5, 0x98, 0x14, 0xc3, 0x0a,
+	0xd4, 0xbb, 0xa2, 0xae, 0xb1, 0xbc, 0x30, 0x54, 0x8d, 0xc5, 0x0b, 0x72, 0x05, 0x2e, 0xb7, 0x98,
+	0x58, 0x3f, 0xa6, 0xb6, 0x43, 0x0f, 0x1c, 0xd6, 0xda, 0xd9, 0xdf, 0x3b, 0xf1, 0x98, 0xae, 0xaa,
+	0x9b, 0xb0, 0x58, 0xbc, 0xad, 0x40, 0x0d, 0x98, 0xee, 0x78, 0xa1, 0x94, 0xc9, 0xea, 0xaa, 0x99,
+	0x7a, 0x4d, 0xea, 0x30, 0xbb, 0xe1, 0x30, 0xca, 0x43, 0x2f, 0x41, 0x9b, 0x87, 0x39, 0x2d, 0x89,
+	0x01, 0x22, 0x25, 0x93, 0x1d, 0xfa, 0x2c, 0x78, 0x96, 0x52, 0xd2, 0x12, 0xa5, 0xf4, 0x2e, 0x18,
+	0xfa, 0xcb, 0xdc, 0xa3, 0x7e, 0x87, 0x89, 0xe8, 0xfa, 0x4e, 0x8e, 0x2c, 0xf7, 0x12, 0x90, 0x8f,
+	0xe0, 0x72, 0xa1, 0xb6, 0xa2, 0xbc, 0x04, 0x20, 0xb4, 0x54, 0x9a, 0x4d, 0x99, 0x29, 0x09, 0xd9,
+	0x84, 0x45, 0x6d, 0xbe, 0xc5, 0xdb, 0x3e, 0xa3, 0x01, 0x4b, 0xbb, 0x93, 0x8f, 0x86, 0x23, 0xa8,
+	0x32, 0x8d, 0x17, 0x8a, 0x44, 0x45, 0x93, 0xb8, 0x0a, 0x57, 0xfa, 0xa0, 0xa8, 0x98, 0xbe, 0x46,
+	0x29, 0x9a, 0x9b, 0xcc, 0x61, 0x82, 0x45, 0xcb, 0x24, 0xf3, 0xf8, 0x09, 0x4c, 0x45, 0xd5, 0x94,
+	0x5c, 0x5a, 0x63, 0xb8, 0x43, 0x62, 0xbc, 0x1e, 0xa6, 0x4b, 0xb0, 0x58, 0xcc, 0x43, 0x11, 0x7d,
+	0x00, 0x24, 0xb5, 0x1f, 0x47, 0xd2, 0x7b, 0x08, 0xff, 0x2c, 0x2b, 0xff, 0x87, 0x6b, 0x03, 0xb1,
+	0x94, 0xcb, 0x77, 0xe0, 0xbc, 0x56, 0xcb, 0x24, 0x25, 0x7f, 0xd4, 0xcf, 0xe1, 0x42, 0x5e, 0x51,
+	0x9d, 0xf2, 0x13, 0xa8, 0xd9, 0x3c, 0x10, 0x94, 0xb7, 0x75, 0x0a, 0x6f, 0x0c, 0x9d, 0xc2, 0x2d,
+	0x85, 0x60, 0x76, 0xb1, 0x48, 0x00, 0xd3, 0x89, 0xb8, 0xa7, 0x07, 0x79, 0x02, 0xa7, 0x02, 0x41,
+	0x45, 0x18, 0xa8, 0x7b, 0xe2, 0x56, 0x69, 0x8f, 0xbb, 0x12, 0xc6, 0x54, 0x70, 0xe4, 0x4d, 0x05,
+	0x66, 0xb3, 0x5b, 0x38, 0x80, 0xb3, 0x76, 0x4a, 0x12, 0x7f, 0xc9, 0xb3, 0x25, 0x7a, 0x8c, 0x2c,
+	0x6e, 0x66, 0xc9, 0xcc, 0xac, 0x0f, 0xfc, 0x39, 0xd4, 0x98, 0xef, 0xbb, 0xfe, 0x16, 0x3f, 0x74,
+	0x55, 0x8c, 0x77, 0x4a, 0x3b, 0xbc, 0x9b, 0x20, 0x99, 0x5d, 0x50, 0x42, 0xe1, 0x6c, 0x86, 0x01,
+	0x9e, 0x83, 0x99, 0x90, 0x07, 0x1e, 0x6b, 0xdb, 0x87, 0x36, 0xb3, 0xea, 0x13, 0xf8, 0x1c, 0xcc,
+	0x25, 0xa4, 0xcc, 0x90, 0x73, 0x9b, 0x77, 0xea, 0x08, 0x2f, 0x40, 0x3d, 0x11, 0x6e, 0xf8, 0x8c,
+	0x8a, 0x48, 0x5a, 0x49, 0x4b, 0xe5, 0x97, 0x1d, 0x49, 0xab, 0xe4, 0x2b, 0x04, 0xf3, 0x3d, 0x1c,
+	0xf0, 0xa2, 0x0a, 0x6d, 0x23, 0x79, 0xce, 0x6a, 0x66, 0x57, 0x80, 0x09, 0x9c, 0x91, 0x8b, 0x6d,
+	0x16, 0x04, 0xb4, 0x93, 0xf4, 0x24, 0x19, 0x19, 0x6e, 0x02, 0xb6, 0xd3, 0xb0, 0x1b, 0x0e, 0x0d,
+	0x02, 0xd5, 0x72, 0x16, 0xec, 0x90, 0x35, 0x58, 0xee, 0xde, 0x53, 0xec, 0xc8, 0x73, 0x68, 0x5c,
+	0x7a, 0x32, 0x25, 0x7d, 0x3e, 0xf8, 0x5b, 0xf0, 0xbf, 0x01, 0x36, 0xdd, 0x4b, 0x99, 0x2b, 0x99,
+	0x7a, 0xc8, 0xf4, 0x9a, 0xfc, 0x55, 0x49, 0x5d, 0x3b, 0xeb, 0xea, 0xc8, 0x6c, 0xde, 0x79, 0xec,
+	0xc9, 0x66, 0x0c, 0x6f, 0xc2, 0x95, 0x48, 0xc2, 0x36, 0xdd, 0x2f, 0xf9, 0xbe, 0xb0, 0x1d, 0xfb,
+	0xa5, 0xec, 0xd2, 0xf6, 0x9e, 0x45, 0x17, 0xb2, 0xeb, 0x58, 0xea, 0xe9, 0x19, 0xac, 0x84, 0x1f,
+	0xc0, 0xb2, 0x56, 0x68, 0x79, 0x61, 0x21, 0x50, 0x45, 0x02, 0xbd, 0x55, 0x0f, 0x3f, 0x86, 0xf3,
+	0x5d, 0x67, 0x9c, 0x33, 0x66, 0xb1, 0xf4, 0x9b, 0x7a, 0xa9, 0xe7, 0x4d, 0xdd, 0x54, 0x63, 0x85,
+	0x59, 0x6c, 0x87, 0xb7, 0x61, 0x21, 0xb5, 0xe1, 0x33, 0x6a, 0x9d, 0x48, 0xbc, 0xc9, 0xb7, 0xe1,
+	0x15, 0x9a, 0x91, 0xd7, 0x08, 0xc8, 0x80, 0x8c, 0xf6, 0x39, 0x49, 0xfc, 0x0c, 0xa6, 0x2d, 0x76,
+	0x48, 0x43, 0x47, 0x24, 0xb7, 0xc5, 0xc3, 0xf2, 0xed, 0x5a, 0x81, 0x5b, 0x8d, 0x4e, 0x7e, 0x45,
+	0x70, 0x6d, 0x90, 0x66, 0xf2, 0xd9, 0xbc, 0x42, 0x70, 0x99, 0xf7, 0xd7, 0x6b, 0xa0, 0x7f, 0x81,
+	0xe5, 0x20, 0x87, 0x6b, 0xbf, 0x2f, 0xc0, 0xd9, 0x8d, 0x08, 0x7a, 0x47, 0x41, 0xe3, 0x6f, 0x11,
+	0x80, 0x86, 0x0b, 0xf0, 0x9d, 0xf2, 0x5c, 0x92, 0x73, 0x31, 0x36, 0x46, 0xc2, 0x50, 0x0f, 0xd6,
+	0x04, 0xfe, 0x09, 0x41, 0x3d, 0xdf, 0x9b, 0xe3, 0xfb, 0xe5, 0xb1, 0xb3, 0x03, 0x85, 0xb1, 0x35,
+	0x06, 0xa4, 0x0c, 0xd7, 0x7c, 0xc7, 0x5c, 0x82, 0x6b, 0x9f, 0x99, 0xa0, 0x04, 0xd7, 0x7e, 0xed,
+	0x3b, 0x99, 0xc0, 0x3f, 0x22, 0x98, 0xcb, 0xb5, 0xc3, 0xb8, 0x55, 0xd6, 0x41, 0xae, 0xd9, 0x37,
+	0xee, 0x8f, 0x0e, 0xa4, 0x89, 0x7e, 0x83, 0x60, 0x3a, 0xe9, 0xb9, 0xf1, 0xed, 0xa1, 0x81, 0x73,
+	0x1d, 0xbc, 0xb1, 0x3e, 0x02, 0x82, 0xe6, 0xf4, 0x0b, 0x82, 0x85, 0xa2, 0xf6, 0x1d, 0x0f, 0x5f,
+	0xc4, 0x03, 0x86, 0x04, 0x63, 0x7b, 0x4c, 0x68, 0x9a, 0xf7, 0x2b, 0x04, 0xa7, 0xd5, 0xa0, 0x80,
+	0x87, 0xef, 0xa1, 0xb2, 0x43, 0x87, 0x71, 0xbb, 0x3c, 0x40, 0x86, 0x90, 0x1a, 0x4a, 0x4a, 0x10,
+	0xca, 0x0e, 0x38, 0xc6, 0xed, 0xf2, 0x00, 0x9a, 0xd0, 0xcf, 0x08, 0xce, 0x15, 0x0c, 0x39, 0xf8,
+	0x93, 0xf2, 0xf7, 0x44, 0x4f, 0x4f, 0x6f, 0x3c, 0x1c, 0x0f, 0x98, 0x26, 0xfd, 0x1b, 0x82, 0xf3,
+	0x85, 0x43, 0x11, 0xde, 0x2e, 0xef, 0xa9, 0x60, 0x44, 0x33, 0x1e, 0x8d, 0x0b, 0x2e, 0x53, 0x49,
+	0x45, 0x53, 0x12, 0x1e, 0x21, 0x47, 0xbd, 0x43, 0x9f, 0xb1, 0x3d, 0x26, 0x34, 0xcd, 0xfb, 0x4d,
+	0x76, 0xca, 0xcc, 0x4f, 0x5c, 0x78, 0x77, 0x14, 0x87, 0x7d, 0x66, 0x41, 0x63, 0x6f, 0xbc, 0xa0,
+	0x3a, 0x98, 0x1f, 0x10, 0xcc, 0x66, 0xc7, 0x3d, 0x7c, 0xaf, 0xbc, 0xab, 0x4c, 0xe2, 0x5b, 0x23,
+	0xe3, 0x68, 0x96, 0x7f, 0x22, 0xb8, 0xd4, 0xb7, 0x47, 0xc7, 0x9f, 0x8e, 0x50, 0x53, 0xc5, 0x33,
+	0x82, 0x61, 0x8e, 0x13, 0x52, 0x87, 0xf1, 0x47, 0xfa, 0x86, 0x69, 0x31, 0x91, 0x0c, 0x08, 0xbb,
+	0x63, 0xed, 0xff, 0x46, 0xff, 0x62, 0xfa, 0x37, 0xb4, 0x64, 0xe2, 0xce, 0xf4, 0xd3, 0x53, 0xb2,
+	0x8d, 0x0f, 0x0e, 0xe2, 0xdf, 0x0f, 0xfe, 0x1e, 0x00, 0xb6, 0xe2, 0xb6, 0x34, 0xca, 0x18, 0x00,
+	0x00,
+}
@@ -64,6 +64,7 @@ type GceCache struct {
 	machinesCache       map[MachineTypeKey]*gce.MachineType
 	migTargetSizeCache  map[GceRef]int64
 	migBaseNameCache    map[GceRef]string
+	migOptionsCache     map[GceRef]map[string]string
 
 	// Service used to refresh cache.
 	GceService AutoscalingGceClient
@@ -77,6 +78,7 @@ func NewGceCache(gceService AutoscalingGceClient) *GceCache {
 		machinesCache:       map[MachineTypeKey]*gce.MachineType{},
 		migTargetSizeCache:  map[GceRef]int64{},
 		migBaseNameCache:    map[GceRef]string{},
+		migOptionsCache:     map[GceRef]map[string]string{},
 		GceService:          gceService,
 	}
 }
@@ -357,3 +359,25 @@ func (gc *GceCache) InvalidateAllMigBasenames() {
 	defer gc.cacheMutex.Unlock()
 	gc.migBaseNameCache = make(map[GceRef]string)
 }
+
+// SetMigAutoscalingOptions sets raw autoscaling options for given mig in cache.
+func (gc *GceCache) SetMigAutoscalingOptions(migRef GceRef, options map[string]string) {
+	gc.cacheMutex.Lock()
+	defer gc.cacheMutex.Unlock()
+	gc.migOptionsCache[migRef] = options
+}
+
+// GetMigAutoscalingOptions gets raw autoscaling options for given mig from cache.
+func (gc *GceCache) GetMigAutoscalingOptions(migRef GceRef) (options map[string]string, found bool) {
+	gc.cacheMutex.Lock()
+	defer gc.cacheMutex.Unlock()
+	options, found = gc.migOptionsCache[migRef]
+	return
+}
+
+// InvalidateAllMigAutoscalingOptions invalidates all autoscaling options entries.
+func (gc *GceCache) InvalidateAllMigAutoscalingOptions() {
+	gc.cacheMutex.Lock()
+	defer gc.cacheMutex.Unlock()
+	gc.migOptionsCache = make(map[GceRef]map[string]string)
+}
@@ -22,8 +22,10 @@ import (
 	"reflect"
 	"regexp"
 	"testing"
+	"time"
 
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
 	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
 
 	apiv1 "k8s.io/api/core/v1"
@@ -92,6 +94,11 @@ func (m *gceManagerMock) GetMigTemplateNode(mig Mig) (*apiv1.Node, error) {
 	return args.Get(0).(*apiv1.Node), args.Error(1)
 }
 
+func (m *gceManagerMock) GetMigOptions(mig Mig, defaults config.NodeGroupAutoscalingOptions) *config.NodeGroupAutoscalingOptions {
+	args := m.Called(mig, defaults)
+	return args.Get(0).(*config.NodeGroupAutoscalingOptions)
+}
+
 func (m *gceManagerMock) getCpuAndMemoryForMachineType(machineType string, zone string) (cpu int64, mem int64, err error) {
 	args := m.Called(machineType, zone)
 	return args.Get(0).(int64), args.Get(1).(int64), args.Error(2)
@@ -415,6 +422,15 @@ func TestMig(t *testing.T) {
 	assert.NotNil(t, templateNodeInfo)
 	assert.NotNil(t, templateNodeInfo.Node())
 	mock.AssertExpectationsForObjects(t, gceManagerMock)
+
+	// Test GetOptions.
+	defaults := config.NodeGroupAutoscalingOptions{ScaleDownUnneededTime: time.Minute}
+	overrides := &config.NodeGroupAutoscalingOptions{ScaleDownUnneededTime: time.Hour}
+	gceManagerMock.On("GetMigOptions", mock.AnythingOfType("*gce.gceMig"), defaults).Return(overrides).Once()
+	options, err := mig2.GetOptions(defaults)
+	assert.NoError(t, err)
+	assert.Equal(t, overrides, options)
+	mock.AssertExpectationsForObjects(t, gceManagerMock)
 }
 
 func TestGceRefFromProviderId(t *testing.T) {
@@ -21,10 +21,12 @@ import (
 	"io"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/config/dynamic"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/units"
 
@@ -71,6 +73,8 @@ type GceManager interface {
 	GetMigForInstance(instance GceRef) (Mig, error)
 	// GetMigTemplateNode returns a template node for MIG.
 	GetMigTemplateNode(mig Mig) (*apiv1.Node, error)
+	// GetMigOptions returns MIG's NodeGroupAutoscalingOptions, or nil if the MIG doesn't override the defaults.
+	GetMigOptions(mig Mig, defaults config.NodeGroupAutoscalingOptions) *config.NodeGroupAutoscalingOptions
 	// GetResourceLimiter returns resource limiter.
 	GetResourceLimiter() (*cloudprovider.ResourceLimiter, error)
 	// GetMigSize gets MIG size.
@@ -272,6 +276,7 @@ func (m *gceManagerImpl) Refresh() error {
 
 func (m *gceManagerImpl) forceRefresh() error {
 	m.clearMachinesCache()
+	m.cache.InvalidateAllMigAutoscalingOptions()
 	if err := m.fetchAutoMigs(); err != nil {
 		klog.Errorf("Failed to fetch MIGs: %v", err)
 		return err
@@ -473,6 +478,91 @@ func (m *gceManagerImpl) GetMigTemplateNode(mig Mig) (*apiv1.Node, error) {
 	return m.templates.BuildNodeFromTemplate(mig, template, cpu, mem)
 }
 
+// GetMigOptions parses autoscaling options exposed in the MIG instance template and merges
+// them with provided defaults.
+func (m *gceManagerImpl) GetMigOptions(mig Mig, defaults config.NodeGroupAutoscalingOptions) *config.NodeGroupAutoscalingOptions {
+	options, err := m.getMigAutoscalingOptions(mig.GceRef())
+	if err != nil {
+		klog.Warningf("failed to get autoscaling options for mig %s: %v", mig.GceRef().Name, err)
+		return nil
+	}
+	if len(options) == 0 {
+		return nil
+	}
+
+	if stringOpt, found := options[config.DefaultScaleDownUtilizationThresholdKey]; found {
+		if opt, err := strconv.ParseFloat(stringOpt, 64); err != nil {
+			klog.Warningf("failed to convert mig %s %s option to float: %v",
+				mig.GceRef().Name, config.DefaultScaleDownUtilizationThresholdKey, err)
+		} else {
+			defaults.ScaleDownUtilizationThreshold = opt
+		}
+	}
+
+	if stringOpt, found := options[config.DefaultScaleDownGpuUtilizationThresholdKey]; found {
+		if opt, err := strconv.ParseFloat(stringOpt, 64); err != nil {
+			klog.Warningf("failed to convert mig %s %s option to float: %v",
+				mig.GceRef().Name, config.DefaultScaleDownGpuUtilizationThresholdKey, err)
+		} else {
+			defaults.ScaleDownGpuUtilizationThreshold = opt
+		}
+	}
+
+	if stringOpt, found := options[config.DefaultScaleDownUnneededTimeKey]; found {
+		if opt, err := time.ParseDuration(stringOpt); err != nil {
+			klog.Warningf("failed to convert mig %s %s option to duration: %v",
+				mig.GceRef().Name, config.DefaultScaleDownUnneededTimeKey, err)
+		} else {
+			defaults.ScaleDownUnneededTime = opt
+		}
+	}
+
+	if stringOpt, found := options[config.DefaultScaleDownUnreadyTimeKey]; found {
+		if opt, err := time.ParseDuration(stringOpt); err != nil {
+			klog.Warningf("failed to convert mig %s %s option to duration: %v",
+				mig.GceRef().Name, config.DefaultScaleDownUnreadyTimeKey, err)
+		} else {
+			defaults.ScaleDownUnreadyTime = opt
+		}
+	}
+
+	if stringOpt, found := options[config.DefaultScaleDownDisabledKey]; found {
+		if opt, err := strconv.ParseBool(stringOpt); err != nil {
+			klog.Warningf("failed to convert mig %s %s option to bool: %v",
+				mig.GceRef().Name, config.DefaultScaleDownDisabledKey, err)
+		} else {
+			defaults.ScaleDownDisabled = opt
+		}
+	}
+
+	return &defaults
+}
+
+// getMigAutoscalingOptions returns raw autoscaling options from the kube-env of the MIG
+// instance template. Results are cached until the next forced refresh.
+func (m *gceManagerImpl) getMigAutoscalingOptions(migRef GceRef) (map[string]string, error) {
+	if options, found := m.cache.GetMigAutoscalingOptions(migRef); found {
+		return options, nil
+	}
+	template, err := m.GceService.FetchMigTemplate(migRef)
+	if err != nil {
+		return nil, err
+	}
+	options := make(map[string]string)
+	if template.Properties != nil && template.Properties.Metadata != nil {
+		for _, item := range template.Properties.Metadata.Items {
+			if item.Key == "kube-env" && item.Value != nil {
+				options, err = extractAutoscalingOptionsFromKubeEnv(*item.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	m.cache.SetMigAutoscalingOptions(migRef, options)
+	return options, nil
+}
+
 func (m *gceManagerImpl) getCpuAndMemoryForMachineType(machineType string, zone string) (cpu int64, mem int64, err error) {
 	if strings.HasPrefix(machineType, "custom-") {
 		return parseCustomMachineType(machineType)
@@ -27,6 +27,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
 	kubeletapis "k8s.io/kubernetes/pkg/kubelet/apis"
 
@@ -284,6 +285,37 @@ func extractAutoscalerVarFromKubeEnv(kubeEnv, name string) (string, error) {
 	return "", fmt.Errorf("var %s not found in %s: %v", name, autoscalerVars, autoscalerVals)
 }
 
+// extractAutoscalingOptionsFromKubeEnv returns per node group autoscaling options exposed
+// via AUTOSCALER_ENV_VARS, e.g. scaledownunneededtime=5m. Other vars are ignored.
+func extractAutoscalingOptionsFromKubeEnv(kubeEnv string) (map[string]string, error) {
+	const autoscalerVars = "AUTOSCALER_ENV_VARS"
+	options := make(map[string]string)
+	autoscalerVals, err := extractFromKubeEnv(kubeEnv, autoscalerVars)
+	if err != nil {
+		return nil, err
+	}
+	if len(autoscalerVals) == 0 {
+		return options, nil
+	}
+	for _, val := range strings.Split(autoscalerVals, ";") {
+		val = strings.Trim(val, " ")
+		items := strings.SplitN(val, "=", 2)
+		if len(items) != 2 {
+			return nil, fmt.Errorf("malformed autoscaler var: %s", val)
+		}
+		name := strings.Trim(items[0], " ")
+		switch name {
+		case config.DefaultScaleDownUtilizationThresholdKey,
+			config.DefaultScaleDownGpuUtilizationThresholdKey,
+			config.DefaultScaleDownUnneededTimeKey,
+			config.DefaultScaleDownUnreadyTimeKey,
+			config.DefaultScaleDownDisabledKey:
+			options[name] = strings.Trim(items[1], " \"'")
+		}
+	}
+	return options, nil
+}
+
 func extractFromKubeEnv(kubeEnv, resource string) (string, error) {
 	kubeEnvMap := make(map[string]string)
 	err := yaml.Unmarshal([]byte(kubeEnv), &kubeEnvMap)
@@ -343,6 +343,52 @@ func TestExtractAutoscalerVarFromKubeEnv(t *testing.T) {
 	}
 }
 
+func TestExtractAutoscalingOptionsFromKubeEnv(t *testing.T) {
+	cases := []struct {
+		desc   string
+		env    string
+		expect map[string]string
+		err    error
+	}{
+		{
+			desc:   "no AUTOSCALER_ENV_VARS",
+			env:    "ENABLE_NODE_PROBLEM_DETECTOR: 'daemonset'\n",
+			expect: map[string]string{},
+		},
+		{
+			desc: "options mixed with other vars",
+			env: "AUTOSCALER_ENV_VARS: node_labels=a=b,c=d;scaledownutilizationthreshold=0.7;" +
+				"scaledownunneededtime='20m';kube_reserved=cpu=1000m\n",
+			expect: map[string]string{
+				"scaledownutilizationthreshold": "0.7",
+				"scaledownunneededtime":         "20m",
+			},
+		},
+		{
+			desc: "scale down disabled",
+			env:  "AUTOSCALER_ENV_VARS: node_labels=a=b;scaledowndisabled=true\n",
+			expect: map[string]string{
+				"scaledowndisabled": "true",
+			},
+		},
+		{
+			desc: "malformed var",
+			env:  "AUTOSCALER_ENV_VARS: scaledownunreadytime;node_taints=a=b:c\n",
+			err:  fmt.Errorf("malformed autoscaler var: scaledownunreadytime"),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			options, err := extractAutoscalingOptionsFromKubeEnv(c.env)
+			assert.Equal(t, c.err, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, c.expect, options)
+		})
+	}
+}
+
 func TestExtractLabelsFromKubeEnv(t *testing.T) {
 	poolLabel := "cloud.google.com/gke-nodepool"
 	preemptibleLabel := "cloud.google.com/gke-preemptible"
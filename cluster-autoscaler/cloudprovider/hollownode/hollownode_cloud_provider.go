@@ -0,0 +1,319 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hollownode
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/gcfg.v1"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	kube_client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog"
+)
+
+const (
+	// ProviderName is the cloud provider name for the hollow node cloud provider.
+	ProviderName = "hollownode"
+
+	// GPULabel is the label added to nodes with GPU resource.
+	GPULabel = "hollownode/accelerator"
+
+	// NodeGroupLabel is the label holding the node group of a hollow node.
+	NodeGroupLabel = "hollownode/node-group"
+
+	// providerIDPrefix prefixes the providerIDs of hollow nodes, which are "hollownode://<node group>/<node name>".
+	providerIDPrefix = ProviderName + "://"
+)
+
+// cloudConfig is the cloud config file for the hollow node cloud provider.
+type cloudConfig struct {
+	Global struct {
+		// Kubeconfig is the path to the kubeconfig of the cluster hollow nodes are created in.
+		// The in-cluster config is used if it's empty.
+		Kubeconfig string `gcfg:"kubeconfig"`
+		// MaxTotalNodes is the quota of nodes in all node groups. 0 for no quota.
+		MaxTotalNodes int `gcfg:"max-total-nodes"`
+		// MaxTotalCores is the quota of cores in all node groups. 0 for no quota.
+		MaxTotalCores int64 `gcfg:"max-total-cores"`
+		// Seed of the random failures. The current time is used if it's 0.
+		Seed int64 `gcfg:"seed"`
+	}
+	NodeGroup map[string]*nodeGroupConfig `gcfg:"nodegroup"`
+}
+
+// nodeGroupConfig is the config of a single node group, a [nodegroup "<name>"] section of the cloud config file.
+type nodeGroupConfig struct {
+	MinSize int `gcfg:"min-size"`
+	MaxSize int `gcfg:"max-size"`
+	// Cpu, Memory and Pods are the capacity of the nodes, e.g. "4", "16Gi" and "110".
+	Cpu    string `gcfg:"cpu"`
+	Memory string `gcfg:"memory"`
+	Pods   string `gcfg:"pods"`
+	// Gpu is the number of GPUs of the nodes, of type GpuType.
+	Gpu     int64  `gcfg:"gpu"`
+	GpuType string `gcfg:"gpu-type"`
+	// Label is a "key=value" label of the nodes, can be repeated.
+	Label []string `gcfg:"label"`
+	// ProvisioningDelay and DeletionDelay are the durations it takes to create and delete a node, e.g. "1m".
+	ProvisioningDelay string `gcfg:"provisioning-delay"`
+	DeletionDelay     string `gcfg:"deletion-delay"`
+	// FailureRate is the probability that creating a node fails with an OtherErrorClass error.
+	FailureRate float64 `gcfg:"failure-rate"`
+	// OutOfResourcesRate is the probability that creating a node fails with an OutOfResourcesErrorClass error.
+	OutOfResourcesRate float64 `gcfg:"out-of-resources-rate"`
+}
+
+// hollowNodeCloudProvider implements CloudProvider interface by creating and deleting Node objects
+// in the API server, without any machines behind them. Creating and deleting nodes takes the configured
+// time and can fail, which makes it usable for testing the autoscaler without a cloud.
+type hollowNodeCloudProvider struct {
+	client          kube_client.Interface
+	resourceLimiter *cloudprovider.ResourceLimiter
+	maxTotalNodes   int
+	maxTotalCores   int64
+	nodeGroups      []*NodeGroup
+
+	// mutex guards the instances of all node groups, the random number generator and the quota usage.
+	mutex sync.Mutex
+	rand  *rand.Rand
+	now   func() time.Time
+}
+
+func newHollowNodeCloudProvider(client kube_client.Interface, cfg *cloudConfig, rl *cloudprovider.ResourceLimiter) (*hollowNodeCloudProvider, error) {
+	seed := cfg.Global.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	provider := &hollowNodeCloudProvider{
+		client:          client,
+		resourceLimiter: rl,
+		maxTotalNodes:   cfg.Global.MaxTotalNodes,
+		maxTotalCores:   cfg.Global.MaxTotalCores,
+		rand:            rand.New(rand.NewSource(seed)),
+		now:             time.Now,
+	}
+
+	names := make([]string, 0, len(cfg.NodeGroup))
+	for name := range cfg.NodeGroup {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ng, err := buildNodeGroup(provider, name, cfg.NodeGroup[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid node group %s: %v", name, err)
+		}
+		provider.nodeGroups = append(provider.nodeGroups, ng)
+	}
+	if err := provider.adoptExistingNodes(); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// adoptExistingNodes adds hollow nodes created before a restart to their node groups.
+func (h *hollowNodeCloudProvider) adoptExistingNodes() error {
+	nodes, err := h.client.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %v", err)
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		ng := h.nodeGroupForProviderID(node.Spec.ProviderID)
+		if ng == nil {
+			continue
+		}
+		ng.instances = append(ng.instances, &instance{name: node.Name, state: cloudprovider.InstanceRunning})
+	}
+	return nil
+}
+
+// Name returns name of the cloud provider.
+func (h *hollowNodeCloudProvider) Name() string {
+	return ProviderName
+}
+
+// NodeGroups returns all node groups configured for this cloud provider.
+func (h *hollowNodeCloudProvider) NodeGroups() []cloudprovider.NodeGroup {
+	result := make([]cloudprovider.NodeGroup, 0, len(h.nodeGroups))
+	for _, ng := range h.nodeGroups {
+		result = append(result, ng)
+	}
+	return result
+}
+
+// NodeGroupForNode returns the node group for the given node, nil if the node
+// should not be processed by cluster autoscaler, or non-nil error if such
+// occurred.
+func (h *hollowNodeCloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovider.NodeGroup, error) {
+	if node == nil {
+		return nil, fmt.Errorf("node is nil")
+	}
+	ng := h.nodeGroupForProviderID(node.Spec.ProviderID)
+	if ng == nil {
+		return nil, nil
+	}
+	return ng, nil
+}
+
+func (h *hollowNodeCloudProvider) nodeGroupForProviderID(providerID string) *NodeGroup {
+	if !strings.HasPrefix(providerID, providerIDPrefix) {
+		return nil
+	}
+	parts := strings.SplitN(strings.TrimPrefix(providerID, providerIDPrefix), "/", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	for _, ng := range h.nodeGroups {
+		if ng.id == parts[0] {
+			return ng
+		}
+	}
+	return nil
+}
+
+// Pricing is not implemented.
+func (h *hollowNodeCloudProvider) Pricing() (cloudprovider.PricingModel, errors.AutoscalerError) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// GetAvailableMachineTypes is not implemented.
+func (h *hollowNodeCloudProvider) GetAvailableMachineTypes() ([]string, error) {
+	return []string{}, nil
+}
+
+// NewNodeGroup is not implemented.
+func (h *hollowNodeCloudProvider) NewNodeGroup(machineType string, labels map[string]string, systemLabels map[string]string,
+	taints []apiv1.Taint, extraResources map[string]resource.Quantity) (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// GetResourceLimiter returns struct containing limits (max, min) for resources (cores, memory etc.).
+func (h *hollowNodeCloudProvider) GetResourceLimiter() (*cloudprovider.ResourceLimiter, error) {
+	return h.resourceLimiter, nil
+}
+
+// GPULabel returns the label added to nodes with GPU resource.
+func (h *hollowNodeCloudProvider) GPULabel() string {
+	return GPULabel
+}
+
+// GetAvailableGPUTypes return all available GPU types cloud provider supports.
+func (h *hollowNodeCloudProvider) GetAvailableGPUTypes() map[string]struct{} {
+	gpuTypes := make(map[string]struct{})
+	for _, ng := range h.nodeGroups {
+		if ng.gpuType != "" {
+			gpuTypes[ng.gpuType] = struct{}{}
+		}
+	}
+	return gpuTypes
+}
+
+// Cleanup cleans up all resources before the cloud provider is removed.
+// Hollow nodes are left in place, so they are adopted after a restart.
+func (h *hollowNodeCloudProvider) Cleanup() error {
+	return nil
+}
+
+// Refresh finishes creating and deleting the nodes whose provisioning or deletion delay has passed.
+func (h *hollowNodeCloudProvider) Refresh() error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for _, ng := range h.nodeGroups {
+		ng.update()
+	}
+	return nil
+}
+
+// quotaExceeded returns whether creating another node of the node group would exceed the quotas.
+// Nodes that failed to be created don't count. Must be called with the mutex held.
+func (h *hollowNodeCloudProvider) quotaExceeded(ng *NodeGroup) bool {
+	nodes := 1
+	cores := ng.cpu.Value()
+	for _, other := range h.nodeGroups {
+		for _, inst := range other.instances {
+			if inst.errorInfo != nil {
+				continue
+			}
+			nodes++
+			cores += other.cpu.Value()
+		}
+	}
+	if h.maxTotalNodes > 0 && nodes > h.maxTotalNodes {
+		return true
+	}
+	return h.maxTotalCores > 0 && cores > h.maxTotalCores
+}
+
+// BuildHollowNode builds the hollow node cloud provider from the config file passed through --cloud-config.
+func BuildHollowNode(opts config.AutoscalingOptions, do cloudprovider.NodeGroupDiscoveryOptions, rl *cloudprovider.ResourceLimiter) cloudprovider.CloudProvider {
+	if opts.CloudConfig == "" {
+		klog.Fatal("No config file provided, please specify it via the --cloud-config flag")
+	}
+	configFile, err := os.Open(opts.CloudConfig)
+	if err != nil {
+		klog.Fatalf("Couldn't open cloud provider configuration %s: %v", opts.CloudConfig, err)
+	}
+	defer configFile.Close()
+
+	cfg, err := readConfig(configFile)
+	if err != nil {
+		klog.Fatalf("Couldn't read cloud provider configuration %s: %v", opts.CloudConfig, err)
+	}
+	var kubeConfig *rest.Config
+	if cfg.Global.Kubeconfig != "" {
+		kubeConfig, err = clientcmd.BuildConfigFromFlags("", cfg.Global.Kubeconfig)
+	} else {
+		kubeConfig, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		klog.Fatalf("Failed to get kubeclient config for hollow nodes: %v", err)
+	}
+	provider, err := newHollowNodeCloudProvider(kube_client.NewForConfigOrDie(kubeConfig), cfg, rl)
+	if err != nil {
+		klog.Fatalf("Failed to create hollow node cloud provider: %v", err)
+	}
+	return provider
+}
+
+func readConfig(configReader io.Reader) (*cloudConfig, error) {
+	cfg := &cloudConfig{}
+	if err := gcfg.ReadInto(cfg, configReader); err != nil {
+		return nil, err
+	}
+	if len(cfg.NodeGroup) == 0 {
+		return nil, fmt.Errorf("no node groups configured")
+	}
+	return cfg, nil
+}
@@ -0,0 +1,312 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hollownode
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `
+[Global]
+seed = 42
+
+[nodegroup "small"]
+min-size = 1
+max-size = 5
+cpu = 2
+memory = 4Gi
+label = pool=small
+label = team=a
+provisioning-delay = 1m
+deletion-delay = 30s
+
+[nodegroup "gpu"]
+max-size = 3
+cpu = 8
+gpu = 2
+gpu-type = nvidia-tesla-k80
+`
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func newTestProvider(t *testing.T, configString string, objects ...runtime.Object) (*hollowNodeCloudProvider, *fake.Clientset, *testClock) {
+	cfg, err := readConfig(strings.NewReader(configString))
+	assert.NoError(t, err)
+	client := fake.NewSimpleClientset(objects...)
+	provider, err := newHollowNodeCloudProvider(client, cfg, cloudprovider.NewResourceLimiter(nil, nil))
+	assert.NoError(t, err)
+	clock := &testClock{now: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
+	provider.now = clock.Now
+	return provider, client, clock
+}
+
+func nodeNames(t *testing.T, client *fake.Clientset) []string {
+	nodes, err := client.CoreV1().Nodes().List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	names := make([]string, 0, len(nodes.Items))
+	for _, node := range nodes.Items {
+		names = append(names, node.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func fakeNode(instance cloudprovider.Instance) *apiv1.Node {
+	return &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: instance.Id},
+		Spec:       apiv1.NodeSpec{ProviderID: instance.Id},
+	}
+}
+
+func TestReadConfig(t *testing.T) {
+	provider, _, _ := newTestProvider(t, testConfig)
+
+	nodeGroups := provider.NodeGroups()
+	assert.Equal(t, 2, len(nodeGroups))
+	assert.Equal(t, "gpu", nodeGroups[0].Id())
+	assert.Equal(t, 0, nodeGroups[0].MinSize())
+	assert.Equal(t, 3, nodeGroups[0].MaxSize())
+	assert.Equal(t, "small", nodeGroups[1].Id())
+	assert.Equal(t, 1, nodeGroups[1].MinSize())
+	assert.Equal(t, 5, nodeGroups[1].MaxSize())
+	assert.Equal(t, map[string]struct{}{"nvidia-tesla-k80": {}}, provider.GetAvailableGPUTypes())
+
+	nodeInfo, err := nodeGroups[0].TemplateNodeInfo()
+	assert.NoError(t, err)
+	node := nodeInfo.Node()
+	assert.Equal(t, int64(8), node.Status.Allocatable.Cpu().Value())
+	gpuQuantity := node.Status.Allocatable["nvidia.com/gpu"]
+	assert.Equal(t, int64(2), gpuQuantity.Value())
+	assert.Equal(t, "nvidia-tesla-k80", node.Labels[GPULabel])
+
+	nodeInfo, err = nodeGroups[1].TemplateNodeInfo()
+	assert.NoError(t, err)
+	node = nodeInfo.Node()
+	assert.Equal(t, int64(4*1024*1024*1024), node.Status.Allocatable.Memory().Value())
+	assert.Equal(t, "small", node.Labels["pool"])
+	assert.Equal(t, "a", node.Labels["team"])
+
+	_, err = readConfig(strings.NewReader("[Global]\nseed = 1\n"))
+	assert.Error(t, err)
+	_, err = newHollowNodeCloudProvider(fake.NewSimpleClientset(), &cloudConfig{NodeGroup: map[string]*nodeGroupConfig{
+		"invalid": {MinSize: 3, MaxSize: 1},
+	}}, nil)
+	assert.Error(t, err)
+}
+
+func TestIncreaseSize(t *testing.T) {
+	provider, client, clock := newTestProvider(t, testConfig)
+	ng := provider.NodeGroups()[1]
+
+	assert.NoError(t, ng.IncreaseSize(2))
+	assert.Error(t, ng.IncreaseSize(4))
+	size, err := ng.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, size)
+
+	instances, err := ng.Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, []cloudprovider.Instance{
+		{Id: "hollownode://small/small-1", Status: &cloudprovider.InstanceStatus{State: cloudprovider.InstanceCreating}},
+		{Id: "hollownode://small/small-2", Status: &cloudprovider.InstanceStatus{State: cloudprovider.InstanceCreating}},
+	}, instances)
+	assert.NoError(t, provider.Refresh())
+	assert.Empty(t, nodeNames(t, client))
+
+	// Nodes are created once the provisioning delay passes.
+	clock.now = clock.now.Add(time.Minute)
+	assert.NoError(t, provider.Refresh())
+	assert.Equal(t, []string{"small-1", "small-2"}, nodeNames(t, client))
+	instances, err = ng.Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, cloudprovider.InstanceRunning, instances[0].Status.State)
+
+	node, err := client.CoreV1().Nodes().Get("small-1", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "small", node.Labels[NodeGroupLabel])
+	nodeGroup, err := provider.NodeGroupForNode(node)
+	assert.NoError(t, err)
+	assert.Equal(t, "small", nodeGroup.Id())
+}
+
+func TestDecreaseTargetSize(t *testing.T) {
+	provider, client, clock := newTestProvider(t, testConfig)
+	ng := provider.NodeGroups()[1]
+	assert.NoError(t, ng.IncreaseSize(1))
+	clock.now = clock.now.Add(time.Minute)
+	assert.NoError(t, provider.Refresh())
+	assert.NoError(t, ng.IncreaseSize(2))
+
+	assert.NoError(t, ng.DecreaseTargetSize(-1))
+	assert.Error(t, ng.DecreaseTargetSize(-2))
+	size, err := ng.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, size)
+
+	clock.now = clock.now.Add(time.Minute)
+	assert.NoError(t, provider.Refresh())
+	assert.Equal(t, []string{"small-1", "small-2"}, nodeNames(t, client))
+}
+
+func TestDeleteNodes(t *testing.T) {
+	provider, client, clock := newTestProvider(t, testConfig)
+	ng := provider.NodeGroups()[1]
+	assert.NoError(t, ng.IncreaseSize(2))
+	clock.now = clock.now.Add(time.Minute)
+	assert.NoError(t, provider.Refresh())
+	node, err := client.CoreV1().Nodes().Get("small-2", metav1.GetOptions{})
+	assert.NoError(t, err)
+	otherNode, err := client.CoreV1().Nodes().Get("small-1", metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	// Below min size.
+	assert.Error(t, ng.DeleteNodes([]*apiv1.Node{node, otherNode}))
+	// Node of another node group.
+	assert.Error(t, provider.NodeGroups()[0].DeleteNodes([]*apiv1.Node{node}))
+
+	assert.NoError(t, ng.DeleteNodes([]*apiv1.Node{node}))
+	size, err := ng.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, size)
+	instances, err := ng.Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, cloudprovider.InstanceDeleting, instances[1].Status.State)
+	assert.Equal(t, []string{"small-1", "small-2"}, nodeNames(t, client))
+
+	// The node is deleted once the deletion delay passes.
+	clock.now = clock.now.Add(30 * time.Second)
+	assert.NoError(t, provider.Refresh())
+	assert.Equal(t, []string{"small-1"}, nodeNames(t, client))
+	instances, err = ng.Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(instances))
+}
+
+func TestInjectedFailures(t *testing.T) {
+	config := `
+[nodegroup "stockout"]
+max-size = 3
+out-of-resources-rate = 1
+
+[nodegroup "broken"]
+max-size = 3
+failure-rate = 1
+`
+	provider, client, _ := newTestProvider(t, config)
+	broken := provider.NodeGroups()[0]
+	stockout := provider.NodeGroups()[1]
+
+	assert.NoError(t, stockout.IncreaseSize(1))
+	assert.NoError(t, broken.IncreaseSize(1))
+	assert.NoError(t, provider.Refresh())
+	assert.Empty(t, nodeNames(t, client))
+
+	instances, err := stockout.Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, cloudprovider.InstanceCreating, instances[0].Status.State)
+	assert.Equal(t, cloudprovider.OutOfResourcesErrorClass, instances[0].Status.ErrorInfo.ErrorClass)
+	assert.Equal(t, "STOCKOUT", instances[0].Status.ErrorInfo.ErrorCode)
+
+	instances, err = broken.Nodes()
+	assert.NoError(t, err)
+	assert.Equal(t, cloudprovider.OtherErrorClass, instances[0].Status.ErrorInfo.ErrorClass)
+	assert.Equal(t, "PROVISIONING_FAILED", instances[0].Status.ErrorInfo.ErrorCode)
+
+	// Failed instances are removed right away, like unregistered nodes are by the autoscaler.
+	assert.NoError(t, broken.DeleteNodes([]*apiv1.Node{fakeNode(instances[0])}))
+	instances, err = broken.Nodes()
+	assert.NoError(t, err)
+	assert.Empty(t, instances)
+}
+
+func TestQuota(t *testing.T) {
+	config := `
+[Global]
+max-total-nodes = 3
+max-total-cores = 5
+
+[nodegroup "a"]
+max-size = 5
+cpu = 1
+
+[nodegroup "b"]
+max-size = 5
+cpu = 2
+`
+	provider, client, _ := newTestProvider(t, config)
+	a := provider.NodeGroups()[0]
+	b := provider.NodeGroups()[1]
+
+	// Cores quota: 2 + 2 + 2 > 5.
+	assert.NoError(t, b.IncreaseSize(3))
+	// Nodes quota: 2 + 1 + 1 > 3.
+	assert.NoError(t, a.IncreaseSize(2))
+	assert.Equal(t, []string{"a-1", "b-1", "b-2"}, nodeNames(t, client))
+
+	instances, err := b.Nodes()
+	assert.NoError(t, err)
+	assert.Nil(t, instances[1].Status.ErrorInfo)
+	assert.Equal(t, "QUOTA_EXCEEDED", instances[2].Status.ErrorInfo.ErrorCode)
+	instances, err = a.Nodes()
+	assert.NoError(t, err)
+	assert.Nil(t, instances[0].Status.ErrorInfo)
+	assert.Equal(t, "QUOTA_EXCEEDED", instances[1].Status.ErrorInfo.ErrorCode)
+}
+
+func TestAdoptExistingNodes(t *testing.T) {
+	provider, client, clock := newTestProvider(t, testConfig)
+	ng := provider.NodeGroups()[1]
+	assert.NoError(t, ng.IncreaseSize(2))
+	clock.now = clock.now.Add(time.Minute)
+	assert.NoError(t, provider.Refresh())
+	nodes, err := client.CoreV1().Nodes().List(metav1.ListOptions{})
+	assert.NoError(t, err)
+
+	// A provider started after a restart finds the nodes of its node groups.
+	objects := []runtime.Object{&apiv1.Node{ObjectMeta: metav1.ObjectMeta{Name: "other"}}}
+	for i := range nodes.Items {
+		objects = append(objects, &nodes.Items[i])
+	}
+	restarted, restartedClient, _ := newTestProvider(t, testConfig, objects...)
+	restartedNg := restarted.NodeGroups()[1]
+	size, err := restartedNg.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, size)
+
+	assert.NoError(t, restartedNg.IncreaseSize(1))
+	clock.now = clock.now.Add(time.Minute)
+	restarted.now = clock.Now
+	assert.NoError(t, restarted.Refresh())
+	assert.Equal(t, []string{"other", "small-1", "small-2", "small-3"}, nodeNames(t, restartedClient))
+}
@@ -0,0 +1,441 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hollownode
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	kube_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
+	"k8s.io/klog"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+const (
+	// Error codes of instances that failed to be created.
+	quotaExceededErrorCode      = "QUOTA_EXCEEDED"
+	stockoutErrorCode           = "STOCKOUT"
+	provisioningFailedErrorCode = "PROVISIONING_FAILED"
+)
+
+// instance is a hollow node being created, running or being deleted.
+type instance struct {
+	name  string
+	state cloudprovider.InstanceState
+	// errorInfo is set if creating the instance failed. Such instances stay in the creating state until deleted.
+	errorInfo *cloudprovider.InstanceErrorInfo
+	// readyAt is the time the instance's node is created, if it's being created.
+	readyAt time.Time
+	// deleteAt is the time the instance's node is deleted, if it's being deleted.
+	deleteAt time.Time
+}
+
+// NodeGroup implements cloudprovider.NodeGroup interface. Nodes of the group are Node objects
+// created in the API server once their provisioning delay passes.
+type NodeGroup struct {
+	provider *hollowNodeCloudProvider
+
+	id                 string
+	minSize            int
+	maxSize            int
+	cpu                resource.Quantity
+	memory             resource.Quantity
+	pods               resource.Quantity
+	gpu                int64
+	gpuType            string
+	labels             map[string]string
+	provisioningDelay  time.Duration
+	deletionDelay      time.Duration
+	failureRate        float64
+	outOfResourcesRate float64
+
+	// instances are guarded by the provider's mutex.
+	instances   []*instance
+	nameCounter int
+}
+
+func buildNodeGroup(provider *hollowNodeCloudProvider, id string, cfg *nodeGroupConfig) (*NodeGroup, error) {
+	if strings.Contains(id, "/") {
+		return nil, fmt.Errorf("name can't contain '/'")
+	}
+	if cfg.MinSize < 0 || cfg.MaxSize < cfg.MinSize {
+		return nil, fmt.Errorf("invalid size range %d-%d", cfg.MinSize, cfg.MaxSize)
+	}
+	if cfg.FailureRate < 0 || cfg.OutOfResourcesRate < 0 || cfg.FailureRate+cfg.OutOfResourcesRate > 1 {
+		return nil, fmt.Errorf("failure rates must be non-negative and sum up to at most 1")
+	}
+	ng := &NodeGroup{
+		provider:           provider,
+		id:                 id,
+		minSize:            cfg.MinSize,
+		maxSize:            cfg.MaxSize,
+		gpu:                cfg.Gpu,
+		gpuType:            cfg.GpuType,
+		labels:             make(map[string]string),
+		failureRate:        cfg.FailureRate,
+		outOfResourcesRate: cfg.OutOfResourcesRate,
+	}
+	var err error
+	if ng.cpu, err = parseQuantity(cfg.Cpu, "1"); err != nil {
+		return nil, fmt.Errorf("invalid cpu: %v", err)
+	}
+	if ng.memory, err = parseQuantity(cfg.Memory, "1Gi"); err != nil {
+		return nil, fmt.Errorf("invalid memory: %v", err)
+	}
+	if ng.pods, err = parseQuantity(cfg.Pods, "110"); err != nil {
+		return nil, fmt.Errorf("invalid pods: %v", err)
+	}
+	if ng.provisioningDelay, err = parseDuration(cfg.ProvisioningDelay); err != nil {
+		return nil, fmt.Errorf("invalid provisioning-delay: %v", err)
+	}
+	if ng.deletionDelay, err = parseDuration(cfg.DeletionDelay); err != nil {
+		return nil, fmt.Errorf("invalid deletion-delay: %v", err)
+	}
+	for _, label := range cfg.Label {
+		parts := strings.SplitN(label, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid label %q, expected key=value", label)
+		}
+		ng.labels[parts[0]] = parts[1]
+	}
+	return ng, nil
+}
+
+func parseQuantity(value, defaultValue string) (resource.Quantity, error) {
+	if value == "" {
+		value = defaultValue
+	}
+	return resource.ParseQuantity(value)
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// MaxSize returns maximum size of the node group.
+func (ng *NodeGroup) MaxSize() int {
+	return ng.maxSize
+}
+
+// MinSize returns minimum size of the node group.
+func (ng *NodeGroup) MinSize() int {
+	return ng.minSize
+}
+
+// TargetSize returns the number of instances of the node group that aren't being deleted,
+// including the ones that failed to be created.
+func (ng *NodeGroup) TargetSize() (int, error) {
+	ng.provider.mutex.Lock()
+	defer ng.provider.mutex.Unlock()
+	return ng.targetSize(), nil
+}
+
+func (ng *NodeGroup) targetSize() int {
+	size := 0
+	for _, inst := range ng.instances {
+		if inst.state != cloudprovider.InstanceDeleting {
+			size++
+		}
+	}
+	return size
+}
+
+// IncreaseSize starts creating delta instances. Each of them may fail to be created because of
+// exceeded quota or the configured failure rates, which is reported in its status.
+func (ng *NodeGroup) IncreaseSize(delta int) error {
+	if delta <= 0 {
+		return fmt.Errorf("size increase must be positive")
+	}
+	ng.provider.mutex.Lock()
+	defer ng.provider.mutex.Unlock()
+
+	size := ng.targetSize()
+	if size+delta > ng.MaxSize() {
+		return fmt.Errorf("size increase too large - desired:%d max:%d", size+delta, ng.MaxSize())
+	}
+	now := ng.provider.now()
+	for i := 0; i < delta; i++ {
+		inst := &instance{
+			name:    ng.newNodeName(),
+			state:   cloudprovider.InstanceCreating,
+			readyAt: now.Add(ng.provisioningDelay),
+		}
+		inst.errorInfo = ng.creationError()
+		ng.instances = append(ng.instances, inst)
+	}
+	ng.update()
+	return nil
+}
+
+// creationError decides whether creating a new instance fails. Must be called with the provider's mutex held.
+func (ng *NodeGroup) creationError() *cloudprovider.InstanceErrorInfo {
+	if ng.provider.quotaExceeded(ng) {
+		return &cloudprovider.InstanceErrorInfo{
+			ErrorClass:   cloudprovider.OutOfResourcesErrorClass,
+			ErrorCode:    quotaExceededErrorCode,
+			ErrorMessage: "quota of hollow nodes exceeded",
+		}
+	}
+	roll := ng.provider.rand.Float64()
+	if roll < ng.outOfResourcesRate {
+		return &cloudprovider.InstanceErrorInfo{
+			ErrorClass:   cloudprovider.OutOfResourcesErrorClass,
+			ErrorCode:    stockoutErrorCode,
+			ErrorMessage: fmt.Sprintf("node group %s is out of resources", ng.id),
+		}
+	}
+	if roll < ng.outOfResourcesRate+ng.failureRate {
+		return &cloudprovider.InstanceErrorInfo{
+			ErrorClass:   cloudprovider.OtherErrorClass,
+			ErrorCode:    provisioningFailedErrorCode,
+			ErrorMessage: "injected provisioning failure",
+		}
+	}
+	return nil
+}
+
+func (ng *NodeGroup) newNodeName() string {
+	for {
+		ng.nameCounter++
+		name := fmt.Sprintf("%s-%d", ng.id, ng.nameCounter)
+		if ng.findInstance(name) == nil {
+			return name
+		}
+	}
+}
+
+func (ng *NodeGroup) findInstance(name string) *instance {
+	for _, inst := range ng.instances {
+		if inst.name == name {
+			return inst
+		}
+	}
+	return nil
+}
+
+// DeleteNodes starts deleting the given nodes. Instances which have no node yet are removed immediately.
+func (ng *NodeGroup) DeleteNodes(nodes []*apiv1.Node) error {
+	ng.provider.mutex.Lock()
+	defer ng.provider.mutex.Unlock()
+
+	if ng.targetSize()-len(nodes) < ng.MinSize() {
+		return fmt.Errorf("min size reached, nodes will not be deleted")
+	}
+	toDelete := make([]*instance, 0, len(nodes))
+	for _, node := range nodes {
+		var inst *instance
+		if strings.HasPrefix(node.Spec.ProviderID, providerID(ng.id, "")) {
+			inst = ng.findInstance(strings.TrimPrefix(node.Spec.ProviderID, providerID(ng.id, "")))
+		}
+		if inst == nil {
+			return fmt.Errorf("node %s doesn't belong to node group %s", node.Name, ng.id)
+		}
+		toDelete = append(toDelete, inst)
+	}
+
+	now := ng.provider.now()
+	for _, inst := range toDelete {
+		if inst.state == cloudprovider.InstanceCreating {
+			ng.removeInstance(inst)
+			continue
+		}
+		if inst.state != cloudprovider.InstanceDeleting {
+			inst.state = cloudprovider.InstanceDeleting
+			inst.deleteAt = now.Add(ng.deletionDelay)
+		}
+	}
+	ng.update()
+	return nil
+}
+
+func (ng *NodeGroup) removeInstance(toRemove *instance) {
+	for i, inst := range ng.instances {
+		if inst == toRemove {
+			ng.instances = append(ng.instances[:i], ng.instances[i+1:]...)
+			return
+		}
+	}
+}
+
+// DecreaseTargetSize stops creating -delta instances that don't have a node yet.
+func (ng *NodeGroup) DecreaseTargetSize(delta int) error {
+	if delta >= 0 {
+		return fmt.Errorf("size decrease must be negative")
+	}
+	ng.provider.mutex.Lock()
+	defer ng.provider.mutex.Unlock()
+
+	var creating []*instance
+	for _, inst := range ng.instances {
+		if inst.state == cloudprovider.InstanceCreating {
+			creating = append(creating, inst)
+		}
+	}
+	if len(creating) < -delta {
+		return fmt.Errorf("attempt to delete existing nodes targetSize:%d delta:%d creatingNodes: %d",
+			ng.targetSize(), delta, len(creating))
+	}
+	for _, inst := range creating[len(creating)+delta:] {
+		ng.removeInstance(inst)
+	}
+	return nil
+}
+
+// update creates the nodes of instances whose provisioning delay passed, and deletes the nodes
+// of instances whose deletion delay passed. Must be called with the provider's mutex held.
+func (ng *NodeGroup) update() {
+	now := ng.provider.now()
+	nodesClient := ng.provider.client.CoreV1().Nodes()
+	remaining := make([]*instance, 0, len(ng.instances))
+	for _, inst := range ng.instances {
+		switch {
+		case inst.state == cloudprovider.InstanceCreating && inst.errorInfo == nil && !now.Before(inst.readyAt):
+			_, err := nodesClient.Create(ng.buildNode(inst.name))
+			if err != nil && !kube_errors.IsAlreadyExists(err) {
+				klog.Errorf("Failed to create hollow node %s: %v", inst.name, err)
+				break
+			}
+			klog.V(2).Infof("Created hollow node %s", inst.name)
+			inst.state = cloudprovider.InstanceRunning
+		case inst.state == cloudprovider.InstanceDeleting && !now.Before(inst.deleteAt):
+			err := nodesClient.Delete(inst.name, &metav1.DeleteOptions{})
+			if err != nil && !kube_errors.IsNotFound(err) {
+				klog.Errorf("Failed to delete hollow node %s: %v", inst.name, err)
+				break
+			}
+			klog.V(2).Infof("Deleted hollow node %s", inst.name)
+			continue
+		}
+		remaining = append(remaining, inst)
+	}
+	ng.instances = remaining
+}
+
+func providerID(nodeGroup, nodeName string) string {
+	return providerIDPrefix + nodeGroup + "/" + nodeName
+}
+
+// buildNode returns a ready node of the node group with the given name.
+func (ng *NodeGroup) buildNode(name string) *apiv1.Node {
+	node := &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				apiv1.LabelHostname: name,
+				NodeGroupLabel:      ng.id,
+			},
+		},
+		Spec: apiv1.NodeSpec{
+			ProviderID: providerID(ng.id, name),
+		},
+		Status: apiv1.NodeStatus{
+			Capacity: apiv1.ResourceList{
+				apiv1.ResourceCPU:    ng.cpu,
+				apiv1.ResourceMemory: ng.memory,
+				apiv1.ResourcePods:   ng.pods,
+			},
+			Conditions: []apiv1.NodeCondition{
+				{
+					Type:               apiv1.NodeReady,
+					Status:             apiv1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(ng.provider.now()),
+				},
+			},
+		},
+	}
+	for key, value := range ng.labels {
+		node.Labels[key] = value
+	}
+	if ng.gpu > 0 {
+		node.Status.Capacity[gpu.ResourceNvidiaGPU] = *resource.NewQuantity(ng.gpu, resource.DecimalSI)
+		node.Labels[GPULabel] = ng.gpuType
+	}
+	node.Status.Allocatable = node.Status.Capacity.DeepCopy()
+	return node
+}
+
+// Id returns an unique identifier of the node group.
+func (ng *NodeGroup) Id() string {
+	return ng.id
+}
+
+// Debug returns a string containing all information regarding this node group.
+func (ng *NodeGroup) Debug() string {
+	return fmt.Sprintf("%s (min: %d, max: %d)", ng.id, ng.minSize, ng.maxSize)
+}
+
+// Nodes returns a list of all nodes that belong to this node group.
+func (ng *NodeGroup) Nodes() ([]cloudprovider.Instance, error) {
+	ng.provider.mutex.Lock()
+	defer ng.provider.mutex.Unlock()
+	instances := make([]cloudprovider.Instance, 0, len(ng.instances))
+	for _, inst := range ng.instances {
+		instances = append(instances, cloudprovider.Instance{
+			Id: providerID(ng.id, inst.name),
+			Status: &cloudprovider.InstanceStatus{
+				State:     inst.state,
+				ErrorInfo: inst.errorInfo,
+			},
+		})
+	}
+	return instances, nil
+}
+
+// TemplateNodeInfo returns a node template for this node group.
+func (ng *NodeGroup) TemplateNodeInfo() (*schedulernodeinfo.NodeInfo, error) {
+	nodeInfo := schedulernodeinfo.NewNodeInfo(cloudprovider.BuildKubeProxy(ng.id))
+	if err := nodeInfo.SetNode(ng.buildNode(fmt.Sprintf("%s-template", ng.id))); err != nil {
+		return nil, err
+	}
+	return nodeInfo, nil
+}
+
+// Exist checks if the node group really exists on the cloud provider side.
+// Node groups are defined in the config file, so they always exist.
+func (ng *NodeGroup) Exist() bool {
+	return true
+}
+
+// Create is not implemented.
+func (ng *NodeGroup) Create() (cloudprovider.NodeGroup, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+// Delete is not implemented.
+func (ng *NodeGroup) Delete() error {
+	return cloudprovider.ErrNotImplemented
+}
+
+// Autoprovisioned returns true if the node group is autoprovisioned.
+func (ng *NodeGroup) Autoprovisioned() bool {
+	return false
+}
+
+// GetOptions is not implemented.
+func (ng *NodeGroup) GetOptions(defaults config.NodeGroupAutoscalingOptions) (*config.NodeGroupAutoscalingOptions, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
@@ -35,6 +35,9 @@ const (
 	// ClusterAutoscalerScaleUp is a condition that explains what is the current status
 	// of a node group with regard to scale up activities.
 	ClusterAutoscalerScaleUp ClusterAutoscalerConditionType = "ScaleUp"
+	// ClusterAutoscalerOptions is a condition that explains whether the autoscaling options
+	// defined in the cluster-autoscaler-options ConfigMap are valid.
+	ClusterAutoscalerOptions ClusterAutoscalerConditionType = "Options"
 )
 
 // ClusterAutoscalerConditionStatus is a status of ClusterAutoscalerCondition.
@@ -67,6 +70,19 @@ const (
 	ClusterAutoscalerNoActivity ClusterAutoscalerConditionStatus = "NoActivity"
 	// ClusterAutoscalerBackoff status means that due to a recently failed scale-up no further scale-ups attempts will be made for some time.
 	ClusterAutoscalerBackoff ClusterAutoscalerConditionStatus = "Backoff"
+
+	// Statuses for Options condition type.
+
+	// ClusterAutoscalerOptionsValid status means that the options are valid and in use.
+	ClusterAutoscalerOptionsValid ClusterAutoscalerConditionStatus = "Valid"
+	// ClusterAutoscalerOptionsInvalid status means that the last version of the options was rejected.
+	ClusterAutoscalerOptionsInvalid ClusterAutoscalerConditionStatus = "Invalid"
+)
+
+const (
+	// NodeGroupScaleDownDisabledReason is the reason of the ScaleDown condition of a node group
+	// whose nodes are unremovable because scale down is disabled for the whole node group.
+	NodeGroupScaleDownDisabledReason = "NodeGroupScaleDownDisabled"
 )
 
 // ClusterAutoscalerCondition describes some aspect of ClusterAutoscaler work.
@@ -101,4 +117,61 @@ type NodeGroupStatus struct {
 	ProviderID string `json:"providerID,omitempty"`
 	// Conditions is a list of conditions that describe the state of the node group.
 	Conditions []ClusterAutoscalerCondition `json:"conditions,omitempty"`
+	// UnremovableNodes is a list of nodes of the node group that can't be removed by ClusterAutoscaler.
+	UnremovableNodes []UnremovableNode `json:"unremovableNodes,omitempty"`
+	// Backoff describes why and until when scale-up of the node group is backed off. It's nil if
+	// the node group isn't backed off.
+	Backoff *BackoffStatus `json:"backoff,omitempty"`
+	// RecentScaleEvents is a list of the most recent scale events of the node group, the oldest first.
+	RecentScaleEvents []ScaleEvent `json:"recentScaleEvents,omitempty"`
+}
+
+// BackoffStatus describes the scale-up backoff of a node group.
+type BackoffStatus struct {
+	// BackoffUntil is the time until which no scale-up of the node group will be attempted.
+	BackoffUntil metav1.Time `json:"backoffUntil,omitempty"`
+	// ErrorClass is the class of the error which caused the backoff, OutOfResources or Other.
+	ErrorClass string `json:"errorClass,omitempty"`
+	// ErrorCode is the cloud-provider-specific code of the error which caused the backoff.
+	ErrorCode string `json:"errorCode,omitempty"`
+}
+
+// ScaleEventType is the type of ScaleEvent.
+type ScaleEventType string
+
+const (
+	// ScaleUpEvent is an event of a node group scale-up requested by ClusterAutoscaler.
+	ScaleUpEvent ScaleEventType = "ScaleUp"
+	// FailedScaleUpEvent is an event of a failed node group scale-up.
+	FailedScaleUpEvent ScaleEventType = "FailedScaleUp"
+	// ScaleDownEvent is an event of a node deletion requested by ClusterAutoscaler.
+	ScaleDownEvent ScaleEventType = "ScaleDown"
+)
+
+// ScaleEvent describes a scale-up or scale-down of a node group.
+type ScaleEvent struct {
+	// Type of the event.
+	Type ScaleEventType `json:"type,omitempty"`
+	// Time is the time when the event happened.
+	Time metav1.Time `json:"time,omitempty"`
+	// Delta is the number of nodes added by a scale-up.
+	Delta int `json:"delta,omitempty"`
+	// NodeName is the name of the node deleted by a scale-down.
+	NodeName string `json:"nodeName,omitempty"`
+	// Reason of a failed scale-up, for example timeout or cloudProviderError.
+	Reason string `json:"reason,omitempty"`
+}
+
+// UnremovableNode describes why a node can't be removed by ClusterAutoscaler.
+type UnremovableNode struct {
+	// Name of the node.
+	Name string `json:"name,omitempty"`
+	// Reason is a unique, one-word, CamelCase reason why the node can't be removed, for example
+	// NotUnderutilized or BlockedByPod.
+	Reason string `json:"reason,omitempty"`
+	// BlockingPod is the namespace/name of the pod preventing the removal of the node, if there is one.
+	BlockingPod string `json:"blockingPod,omitempty"`
+	// BlockingPodReason is a unique, one-word, CamelCase reason why the blocking pod can't be moved,
+	// for example NotEnoughPdb or LocalStorageRequested.
+	BlockingPodReason string `json:"blockingPodReason,omitempty"`
 }
@@ -48,6 +48,12 @@ func getConditionsString(autoscalerConditions []ClusterAutoscalerCondition, pref
 			line.WriteString(")")
 		}
 		line.WriteString("\n")
+		if condition.Reason != "" {
+			line.WriteString(fmt.Sprintf("%v%13sReason:             %v\n",
+				prefix,
+				"",
+				condition.Reason))
+		}
 		line.WriteString(fmt.Sprintf("%v%13sLastProbeTime:      %v\n",
 			prefix,
 			"",
@@ -74,6 +80,22 @@ func getConditionsString(autoscalerConditions []ClusterAutoscalerCondition, pref
 	return buffer.String()
 }
 
+func getUnremovableNodesString(unremovableNodes []UnremovableNode, prefix string) string {
+	if len(unremovableNodes) == 0 {
+		return ""
+	}
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("%vUnremovable:\n", prefix))
+	for _, node := range unremovableNodes {
+		buffer.WriteString(fmt.Sprintf("%v%13s%v: %v", prefix, "", node.Name, node.Reason))
+		if node.BlockingPod != "" {
+			buffer.WriteString(fmt.Sprintf(" (%v: %v)", node.BlockingPod, node.BlockingPodReason))
+		}
+		buffer.WriteString("\n")
+	}
+	return buffer.String()
+}
+
 // GetReadableString produces human-readable description of status.
 func (status ClusterAutoscalerStatus) GetReadableString() string {
 	var buffer bytes.Buffer
@@ -86,6 +108,7 @@ func (status ClusterAutoscalerStatus) GetReadableString() string {
 	for _, nodeGroupStatus := range status.NodeGroupStatuses {
 		buffer.WriteString(fmt.Sprintf("  Name:        %v\n", nodeGroupStatus.ProviderID))
 		buffer.WriteString(getConditionsString(nodeGroupStatus.Conditions, "  "))
+		buffer.WriteString(getUnremovableNodesString(nodeGroupStatus.UnremovableNodes, "  "))
 		buffer.WriteString("\n")
 	}
 	return buffer.String()
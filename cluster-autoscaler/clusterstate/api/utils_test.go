@@ -89,3 +89,43 @@ func TestGetStringNodeGroups(t *testing.T) {
 	assert.Regexp(t, regexp.MustCompile("(?ms)NodeGroups:.*Name:\\s*ng1"), result)
 	assert.Regexp(t, regexp.MustCompile("(?ms)NodeGroups:.*Name:\\s*ng2"), result)
 }
+
+func TestGetStringWithReason(t *testing.T) {
+	var status ClusterAutoscalerStatus
+	healthCondition, _ := prepareConditions()
+	scaleDownCondition := ClusterAutoscalerCondition{
+		Type:    ClusterAutoscalerScaleDown,
+		Status:  ClusterAutoscalerNoCandidates,
+		Reason:  NodeGroupScaleDownDisabledReason,
+		Message: "candidates=0 unremovable=2"}
+	status.ClusterwideConditions = append(status.ClusterwideConditions, healthCondition)
+	status.NodeGroupStatuses = append(status.NodeGroupStatuses, NodeGroupStatus{
+		ProviderID: "ng1",
+		Conditions: []ClusterAutoscalerCondition{healthCondition, scaleDownCondition},
+	})
+	result := status.GetReadableString()
+	assert.Regexp(t, regexp.MustCompile(fmt.Sprintf("(?ms)Name:\\s*ng1.*%v:\\s*%v.*unremovable=2.*Reason:\\s*%v",
+		ClusterAutoscalerScaleDown, ClusterAutoscalerNoCandidates, NodeGroupScaleDownDisabledReason)), result)
+	assert.NotRegexp(t, regexp.MustCompile("(?ms)Cluster-wide:.*Reason:.*NodeGroups:"), result)
+}
+
+func TestGetStringUnremovableNodes(t *testing.T) {
+	var status ClusterAutoscalerStatus
+	healthCondition, _ := prepareConditions()
+	status.ClusterwideConditions = append(status.ClusterwideConditions, healthCondition)
+	status.NodeGroupStatuses = append(status.NodeGroupStatuses, NodeGroupStatus{
+		ProviderID: "ng1",
+		Conditions: []ClusterAutoscalerCondition{healthCondition},
+		UnremovableNodes: []UnremovableNode{
+			{Name: "n1", Reason: "NotUnderutilized"},
+			{Name: "n2", Reason: "BlockedByPod", BlockingPod: "default/p1", BlockingPodReason: "NotEnoughPdb"},
+		},
+	})
+	status.NodeGroupStatuses = append(status.NodeGroupStatuses, NodeGroupStatus{
+		ProviderID: "ng2",
+		Conditions: []ClusterAutoscalerCondition{healthCondition},
+	})
+	result := status.GetReadableString()
+	assert.Regexp(t, regexp.MustCompile("(?ms)Name:\\s*ng1.*Unremovable:\\s*n1: NotUnderutilized\\s*n2: BlockedByPod \\(default/p1: NotEnoughPdb\\)"), result)
+	assert.NotRegexp(t, regexp.MustCompile("(?ms)Name:\\s*ng2.*Unremovable:"), result)
+}
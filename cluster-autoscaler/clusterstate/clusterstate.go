@@ -19,6 +19,7 @@ package clusterstate
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -27,6 +28,7 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/api"
 	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/utils"
 	"k8s.io/autoscaler/cluster-autoscaler/metrics"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/backoff"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/deletetaint"
 	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
@@ -55,6 +57,9 @@ const (
 
 	// NodeGroupBackoffResetTimeout is the time after last failed scale-up when the backoff duration is reset.
 	NodeGroupBackoffResetTimeout = 3 * time.Hour
+
+	// MaxRecentScaleEvents is the maximum number of scale events kept for a node group.
+	MaxRecentScaleEvents = 10
 )
 
 // ScaleUpRequest contains information about the requested node group scale up.
@@ -128,9 +133,12 @@ type ClusterStateRegistry struct {
 	incorrectNodeGroupSizes            map[string]IncorrectNodeGroupSize
 	unregisteredNodes                  map[string]UnregisteredNode
 	candidatesForScaleDown             map[string][]string
+	unremovableNodes                   map[string][]api.UnremovableNode
+	recentScaleEvents                  map[string][]api.ScaleEvent
 	backoff                            backoff.Backoff
 	lastStatus                         *api.ClusterAutoscalerStatus
 	lastScaleDownUpdateTime            time.Time
+	optionsCondition                   *api.ClusterAutoscalerCondition
 	logRecorder                        *utils.LogEventRecorder
 	cloudProviderNodeInstances         map[string][]cloudprovider.Instance
 	previousCloudProviderNodeInstances map[string][]cloudprovider.Instance
@@ -153,6 +161,8 @@ func NewClusterStateRegistry(cloudProvider cloudprovider.CloudProvider, config C
 		incorrectNodeGroupSizes: make(map[string]IncorrectNodeGroupSize),
 		unregisteredNodes:       make(map[string]UnregisteredNode),
 		candidatesForScaleDown:  make(map[string][]string),
+		unremovableNodes:        make(map[string][]api.UnremovableNode),
+		recentScaleEvents:       make(map[string][]api.ScaleEvent),
 		backoff:                 backoff,
 		lastStatus:              emptyStatus,
 		logRecorder:             logRecorder,
@@ -181,6 +191,7 @@ func (csr *ClusterStateRegistry) registerOrUpdateScaleUpNoLock(nodeGroup cloudpr
 			ExpectedAddTime: currentTime.Add(csr.config.MaxNodeProvisionTime),
 		}
 		csr.scaleUpRequests[nodeGroup.Id()] = scaleUpRequest
+		csr.recordScaleEvent(nodeGroup.Id(), api.ScaleEvent{Type: api.ScaleUpEvent, Delta: delta}, currentTime)
 		return
 	}
 
@@ -201,6 +212,7 @@ func (csr *ClusterStateRegistry) registerOrUpdateScaleUpNoLock(nodeGroup cloudpr
 		// if we are actually adding new nodes shift Time and ExpectedAddTime
 		scaleUpRequest.Time = currentTime
 		scaleUpRequest.ExpectedAddTime = currentTime.Add(csr.config.MaxNodeProvisionTime)
+		csr.recordScaleEvent(nodeGroup.Id(), api.ScaleEvent{Type: api.ScaleUpEvent, Delta: delta}, currentTime)
 	}
 }
 
@@ -209,6 +221,19 @@ func (csr *ClusterStateRegistry) RegisterScaleDown(request *ScaleDownRequest) {
 	csr.Lock()
 	defer csr.Unlock()
 	csr.scaleDownRequests = append(csr.scaleDownRequests, request)
+	if request.NodeGroup != nil {
+		csr.recordScaleEvent(request.NodeGroup.Id(), api.ScaleEvent{Type: api.ScaleDownEvent, NodeName: request.NodeName}, request.Time)
+	}
+}
+
+// To be executed under a lock.
+func (csr *ClusterStateRegistry) recordScaleEvent(nodeGroupId string, event api.ScaleEvent, currentTime time.Time) {
+	event.Time = metav1.NewTime(currentTime)
+	events := append(csr.recentScaleEvents[nodeGroupId], event)
+	if len(events) > MaxRecentScaleEvents {
+		events = events[len(events)-MaxRecentScaleEvents:]
+	}
+	csr.recentScaleEvents[nodeGroupId] = events
 }
 
 // To be executed under a lock.
@@ -234,6 +259,7 @@ func (csr *ClusterStateRegistry) updateScaleRequests(currentTime time.Time) {
 				"Nodes added to group %s failed to register within %v",
 				scaleUpRequest.NodeGroup.Id(), currentTime.Sub(scaleUpRequest.Time))
 			metrics.RegisterFailedScaleUp(metrics.Timeout)
+			csr.recordScaleEvent(nodeGroupName, api.ScaleEvent{Type: api.FailedScaleUpEvent, Reason: string(metrics.Timeout)}, currentTime)
 			csr.backoffNodeGroup(scaleUpRequest.NodeGroup, cloudprovider.OtherErrorClass, "timeout", currentTime)
 			delete(csr.scaleUpRequests, nodeGroupName)
 		}
@@ -266,6 +292,7 @@ func (csr *ClusterStateRegistry) RegisterFailedScaleUp(nodeGroup cloudprovider.N
 
 func (csr *ClusterStateRegistry) registerFailedScaleUpNoLock(nodeGroup cloudprovider.NodeGroup, reason metrics.FailedScaleUpReason, errorClass cloudprovider.InstanceErrorClass, errorCode string, currentTime time.Time) {
 	metrics.RegisterFailedScaleUp(reason)
+	csr.recordScaleEvent(nodeGroup.Id(), api.ScaleEvent{Type: api.FailedScaleUpEvent, Reason: string(reason)}, currentTime)
 	csr.backoffNodeGroup(nodeGroup, errorClass, errorCode, currentTime)
 }
 
@@ -640,20 +667,62 @@ func (csr *ClusterStateRegistry) GetUnregisteredNodes() []UnregisteredNode {
 
 // UpdateScaleDownCandidates updates scale down candidates
 func (csr *ClusterStateRegistry) UpdateScaleDownCandidates(nodes []*apiv1.Node, now time.Time) {
-	result := make(map[string][]string)
-	for _, node := range nodes {
-		group, err := csr.cloudProvider.NodeGroupForNode(node)
-		if err != nil {
-			klog.Warningf("Failed to get node group for %s: %v", node.Name, err)
+	csr.candidatesForScaleDown = csr.groupNodeNames(nodes)
+	csr.lastScaleDownUpdateTime = now
+}
+
+// UpdateOptionsCondition sets the clusterwide condition describing the autoscaling options loaded from a ConfigMap.
+func (csr *ClusterStateRegistry) UpdateOptionsCondition(condition api.ClusterAutoscalerCondition) {
+	csr.optionsCondition = &condition
+}
+
+// UpdateUnremovableNodes updates nodes that can't be removed by scale down, together with the reasons.
+func (csr *ClusterStateRegistry) UpdateUnremovableNodes(unremovableNodes []*simulator.UnremovableNode) {
+	result := make(map[string][]api.UnremovableNode)
+	for _, unremovable := range unremovableNodes {
+		group := csr.nodeGroupIdForNode(unremovable.Node)
+		if group == "" {
 			continue
 		}
-		if group == nil || reflect.ValueOf(group).IsNil() {
-			continue
+		status := api.UnremovableNode{
+			Name:   unremovable.Node.Name,
+			Reason: string(unremovable.Reason),
 		}
-		result[group.Id()] = append(result[group.Id()], node.Name)
+		if unremovable.BlockingPod != nil {
+			status.BlockingPod = unremovable.BlockingPod.Pod.Namespace + "/" + unremovable.BlockingPod.Pod.Name
+			status.BlockingPodReason = string(unremovable.BlockingPod.Reason)
+		}
+		result[group] = append(result[group], status)
 	}
-	csr.candidatesForScaleDown = result
-	csr.lastScaleDownUpdateTime = now
+	for _, nodes := range result {
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	}
+	csr.unremovableNodes = result
+}
+
+// groupNodeNames returns names of the given nodes, grouped by node group id. Nodes that
+// don't belong to any node group are skipped.
+func (csr *ClusterStateRegistry) groupNodeNames(nodes []*apiv1.Node) map[string][]string {
+	result := make(map[string][]string)
+	for _, node := range nodes {
+		if group := csr.nodeGroupIdForNode(node); group != "" {
+			result[group] = append(result[group], node.Name)
+		}
+	}
+	return result
+}
+
+// nodeGroupIdForNode returns id of the node group of the node, or an empty string if it doesn't belong to any.
+func (csr *ClusterStateRegistry) nodeGroupIdForNode(node *apiv1.Node) string {
+	group, err := csr.cloudProvider.NodeGroupForNode(node)
+	if err != nil {
+		klog.Warningf("Failed to get node group for %s: %v", node.Name, err)
+		return ""
+	}
+	if group == nil || reflect.ValueOf(group).IsNil() {
+		return ""
+	}
+	return group.Id()
 }
 
 // GetStatus returns ClusterAutoscalerStatus with the current cluster autoscaler status.
@@ -683,7 +752,15 @@ func (csr *ClusterStateRegistry) GetStatus(now time.Time) *api.ClusterAutoscaler
 
 		// Scale down.
 		nodeGroupStatus.Conditions = append(nodeGroupStatus.Conditions, buildScaleDownStatusNodeGroup(
-			csr.candidatesForScaleDown[nodeGroup.Id()], csr.lastScaleDownUpdateTime))
+			csr.candidatesForScaleDown[nodeGroup.Id()], csr.unremovableNodes[nodeGroup.Id()], csr.lastScaleDownUpdateTime))
+		nodeGroupStatus.UnremovableNodes = csr.unremovableNodes[nodeGroup.Id()]
+
+		// Backoff and scale events.
+		backoffStatus := csr.backoff.BackoffStatus(nodeGroup, csr.nodeInfosForGroups[nodeGroup.Id()], now)
+		if backoffStatus.IsBackedOff {
+			nodeGroupStatus.Backoff = buildBackoffStatus(backoffStatus)
+		}
+		nodeGroupStatus.RecentScaleEvents = csr.recentScaleEvents[nodeGroup.Id()]
 
 		result.NodeGroupStatuses = append(result.NodeGroupStatuses, nodeGroupStatus)
 	}
@@ -693,12 +770,27 @@ func (csr *ClusterStateRegistry) GetStatus(now time.Time) *api.ClusterAutoscaler
 		buildScaleUpStatusClusterwide(result.NodeGroupStatuses, csr.totalReadiness))
 	result.ClusterwideConditions = append(result.ClusterwideConditions,
 		buildScaleDownStatusClusterwide(csr.candidatesForScaleDown, csr.lastScaleDownUpdateTime))
+	if csr.optionsCondition != nil {
+		result.ClusterwideConditions = append(result.ClusterwideConditions, *csr.optionsCondition)
+	}
 
 	updateLastTransition(csr.lastStatus, result)
 	csr.lastStatus = result
 	return result
 }
 
+func buildBackoffStatus(backoffStatus backoff.Status) *api.BackoffStatus {
+	errorClass := "Other"
+	if backoffStatus.ErrorClass == cloudprovider.OutOfResourcesErrorClass {
+		errorClass = "OutOfResources"
+	}
+	return &api.BackoffStatus{
+		BackoffUntil: metav1.NewTime(backoffStatus.BackoffUntil),
+		ErrorClass:   errorClass,
+		ErrorCode:    backoffStatus.ErrorCode,
+	}
+}
+
 // GetClusterReadiness returns current readiness stats of cluster
 func (csr *ClusterStateRegistry) GetClusterReadiness() Readiness {
 	return csr.totalReadiness
@@ -745,7 +837,7 @@ func buildScaleUpStatusNodeGroup(isScaleUpInProgress bool, isSafeToScaleUp bool,
 	return condition
 }
 
-func buildScaleDownStatusNodeGroup(candidates []string, lastProbed time.Time) api.ClusterAutoscalerCondition {
+func buildScaleDownStatusNodeGroup(candidates []string, unremovable []api.UnremovableNode, lastProbed time.Time) api.ClusterAutoscalerCondition {
 	condition := api.ClusterAutoscalerCondition{
 		Type:          api.ClusterAutoscalerScaleDown,
 		Message:       fmt.Sprintf("candidates=%d", len(candidates)),
@@ -756,6 +848,14 @@ func buildScaleDownStatusNodeGroup(candidates []string, lastProbed time.Time) ap
 	} else {
 		condition.Status = api.ClusterAutoscalerNoCandidates
 	}
+	if len(unremovable) > 0 {
+		condition.Message = fmt.Sprintf("candidates=%d unremovable=%d", len(candidates), len(unremovable))
+	}
+	for _, node := range unremovable {
+		if node.Reason == api.NodeGroupScaleDownDisabledReason {
+			condition.Reason = api.NodeGroupScaleDownDisabledReason
+		}
+	}
 	return condition
 }
 
@@ -857,13 +957,8 @@ func updateLastTransition(oldStatus, newStatus *api.ClusterAutoscalerStatus) {
 				break
 			}
 		}
-		newConds := updateLastTransitionSingleList(oldConds, ngStatus.Conditions)
-		updatedNgStatuses = append(
-			updatedNgStatuses,
-			api.NodeGroupStatus{
-				ProviderID: ngStatus.ProviderID,
-				Conditions: newConds,
-			})
+		ngStatus.Conditions = updateLastTransitionSingleList(oldConds, ngStatus.Conditions)
+		updatedNgStatuses = append(updatedNgStatuses, ngStatus)
 	}
 	newStatus.NodeGroupStatuses = updatedNgStatuses
 }
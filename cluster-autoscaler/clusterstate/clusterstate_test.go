@@ -25,11 +25,14 @@ import (
 	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
 	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/api"
 	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/utils"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/drain"
 	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
 	"k8s.io/client-go/kubernetes/fake"
 	kube_record "k8s.io/client-go/tools/record"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/backoff"
 )
 
@@ -241,6 +244,63 @@ func TestOKOneUnreadyNodeWithScaleDownCandidate(t *testing.T) {
 	assert.True(t, ng2Checked)
 }
 
+func TestUnremovableNodes(t *testing.T) {
+	now := time.Now()
+
+	ng1_1 := BuildTestNode("ng1-1", 1000, 1000)
+	SetNodeReadyState(ng1_1, true, now.Add(-time.Minute))
+	ng1_2 := BuildTestNode("ng1-2", 1000, 1000)
+	SetNodeReadyState(ng1_2, true, now.Add(-time.Minute))
+	ng2_1 := BuildTestNode("ng2-1", 1000, 1000)
+	SetNodeReadyState(ng2_1, true, now.Add(-time.Minute))
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 2)
+	provider.AddNodeGroup("ng2", 1, 10, 1)
+	provider.AddNode("ng1", ng1_1)
+	provider.AddNode("ng1", ng1_2)
+	provider.AddNode("ng2", ng2_1)
+
+	fakeClient := &fake.Clientset{}
+	fakeLogRecorder, _ := utils.NewStatusMapRecorder(fakeClient, "kube-system", kube_record.NewFakeRecorder(5), false)
+	clusterstate := NewClusterStateRegistry(provider, ClusterStateRegistryConfig{
+		MaxTotalUnreadyPercentage: 10,
+		OkTotalUnreadyCount:       1,
+	}, fakeLogRecorder, newBackoff())
+	err := clusterstate.UpdateNodes([]*apiv1.Node{ng1_1, ng1_2, ng2_1}, nil, now)
+	assert.NoError(t, err)
+	blockingPod := BuildTestPod("p1", 100, 100)
+	clusterstate.UpdateUnremovableNodes([]*simulator.UnremovableNode{
+		{Node: ng1_2, Reason: simulator.NodeGroupScaleDownDisabled},
+		{Node: ng1_1, Reason: simulator.NodeGroupScaleDownDisabled},
+		{Node: ng2_1, Reason: simulator.BlockedByPod, BlockingPod: &drain.BlockingPod{Pod: blockingPod, Reason: drain.NotEnoughPdb}},
+	})
+	clusterstate.UpdateScaleDownCandidates([]*apiv1.Node{}, now)
+
+	status := clusterstate.GetStatus(now)
+	assert.Equal(t, 2, len(status.NodeGroupStatuses))
+	for _, nodeStatus := range status.NodeGroupStatuses {
+		condition := api.GetConditionByType(api.ClusterAutoscalerScaleDown, nodeStatus.Conditions)
+		switch nodeStatus.ProviderID {
+		case "ng1":
+			assert.Equal(t, api.ClusterAutoscalerNoCandidates, condition.Status)
+			assert.Equal(t, api.NodeGroupScaleDownDisabledReason, condition.Reason)
+			assert.Equal(t, "candidates=0 unremovable=2", condition.Message)
+			assert.Equal(t, []api.UnremovableNode{
+				{Name: "ng1-1", Reason: "NodeGroupScaleDownDisabled"},
+				{Name: "ng1-2", Reason: "NodeGroupScaleDownDisabled"},
+			}, nodeStatus.UnremovableNodes)
+		case "ng2":
+			assert.Equal(t, api.ClusterAutoscalerNoCandidates, condition.Status)
+			assert.Equal(t, "", condition.Reason)
+			assert.Equal(t, "candidates=0 unremovable=1", condition.Message)
+			assert.Equal(t, []api.UnremovableNode{
+				{Name: "ng2-1", Reason: "BlockedByPod", BlockingPod: blockingPod.Namespace + "/p1", BlockingPodReason: "NotEnoughPdb"},
+			}, nodeStatus.UnremovableNodes)
+		}
+	}
+}
+
 func TestMissingNodes(t *testing.T) {
 	now := time.Now()
 
@@ -651,6 +711,69 @@ func TestScaleUpBackoff(t *testing.T) {
 	assert.False(t, clusterstate.backoff.IsBackedOff(ng1, nil, now))
 }
 
+func TestBackoffAndScaleEventsInStatus(t *testing.T) {
+	now := time.Now()
+
+	ng1_1 := BuildTestNode("ng1-1", 1000, 1000)
+	SetNodeReadyState(ng1_1, true, now.Add(-time.Minute))
+	ng2_1 := BuildTestNode("ng2-1", 1000, 1000)
+	SetNodeReadyState(ng2_1, true, now.Add(-time.Minute))
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 2)
+	provider.AddNodeGroup("ng2", 1, 10, 1)
+	provider.AddNode("ng1", ng1_1)
+	provider.AddNode("ng2", ng2_1)
+	ng1 := provider.GetNodeGroup("ng1")
+	ng2 := provider.GetNodeGroup("ng2")
+
+	fakeClient := &fake.Clientset{}
+	fakeLogRecorder, _ := utils.NewStatusMapRecorder(fakeClient, "kube-system", kube_record.NewFakeRecorder(5), false)
+	clusterstate := NewClusterStateRegistry(provider, ClusterStateRegistryConfig{
+		MaxTotalUnreadyPercentage: 10,
+		OkTotalUnreadyCount:       1,
+		MaxNodeProvisionTime:      120 * time.Second,
+	}, fakeLogRecorder, newBackoff())
+
+	clusterstate.RegisterOrUpdateScaleUp(ng1, 1, now.Add(-180*time.Second))
+	clusterstate.RegisterScaleDown(&ScaleDownRequest{
+		NodeName:           "ng2-1",
+		NodeGroup:          ng2,
+		Time:               now.Add(-time.Minute),
+		ExpectedDeleteTime: now.Add(time.Minute),
+	})
+	err := clusterstate.UpdateNodes([]*apiv1.Node{ng1_1, ng2_1}, nil, now)
+	assert.NoError(t, err)
+
+	status := clusterstate.GetStatus(now)
+	assert.Equal(t, 2, len(status.NodeGroupStatuses))
+	ng1Status := nodeGroupStatus(t, status, "ng1")
+	assert.Equal(t, &api.BackoffStatus{
+		BackoffUntil: metav1.NewTime(now.Add(InitialNodeGroupBackoffDuration)),
+		ErrorClass:   "Other",
+		ErrorCode:    "timeout",
+	}, ng1Status.Backoff)
+	assert.Equal(t, []api.ScaleEvent{
+		{Type: api.ScaleUpEvent, Time: metav1.NewTime(now.Add(-180 * time.Second)), Delta: 1},
+		{Type: api.FailedScaleUpEvent, Time: metav1.NewTime(now), Reason: "timeout"},
+	}, ng1Status.RecentScaleEvents)
+
+	ng2Status := nodeGroupStatus(t, status, "ng2")
+	assert.Nil(t, ng2Status.Backoff)
+	assert.Equal(t, []api.ScaleEvent{
+		{Type: api.ScaleDownEvent, Time: metav1.NewTime(now.Add(-time.Minute)), NodeName: "ng2-1"},
+	}, ng2Status.RecentScaleEvents)
+
+	// Only the most recent events are kept.
+	for i := 0; i < MaxRecentScaleEvents; i++ {
+		clusterstate.RegisterOrUpdateScaleUp(ng1, 1, now)
+	}
+	status = clusterstate.GetStatus(now)
+	ng1Status = nodeGroupStatus(t, status, "ng1")
+	assert.Equal(t, MaxRecentScaleEvents, len(ng1Status.RecentScaleEvents))
+	assert.Equal(t, api.ScaleUpEvent, ng1Status.RecentScaleEvents[0].Type)
+}
+
 func TestGetClusterSize(t *testing.T) {
 	now := time.Now()
 
@@ -781,3 +904,14 @@ func TestIsNodeStillStarting(t *testing.T) {
 func newBackoff() backoff.Backoff {
 	return backoff.NewIdBasedExponentialBackoff(InitialNodeGroupBackoffDuration, MaxNodeGroupBackoffDuration, NodeGroupBackoffResetTimeout)
 }
+
+// nodeGroupStatus returns the status of the given node group, as the order of node group statuses isn't fixed.
+func nodeGroupStatus(t *testing.T, status *api.ClusterAutoscalerStatus, nodeGroupId string) api.NodeGroupStatus {
+	for _, nodeGroupStatus := range status.NodeGroupStatuses {
+		if nodeGroupStatus.ProviderID == nodeGroupId {
+			return nodeGroupStatus
+		}
+	}
+	require.FailNow(t, "node group status not found", nodeGroupId)
+	return api.NodeGroupStatus{}
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/api"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/backoff"
+
+	apiv1 "k8s.io/api/core/v1"
+	kube_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kube_client "k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+)
+
+const (
+	// StateConfigMapName is the name of ConfigMap with the persisted state of ClusterStateRegistry.
+	StateConfigMapName = "cluster-autoscaler-state"
+	// StateConfigMapKey is the key used in the ConfigMap to store the state.
+	StateConfigMapKey = "state"
+)
+
+// PersistentState is the part of the state of ClusterStateRegistry which survives restarts
+// and leader failovers of cluster autoscaler.
+type PersistentState struct {
+	// ScaleUpRequests are the scale-ups in progress.
+	ScaleUpRequests []PersistentScaleUpRequest `json:"scaleUpRequests,omitempty"`
+	// RecentScaleEvents are the most recent scale events, including failed scale-ups, by node group id.
+	RecentScaleEvents map[string][]api.ScaleEvent `json:"recentScaleEvents,omitempty"`
+	// Backoff is the backoff data of node groups.
+	Backoff []backoff.Entry `json:"backoff,omitempty"`
+}
+
+// PersistentScaleUpRequest is a ScaleUpRequest with the node group replaced by its id.
+type PersistentScaleUpRequest struct {
+	// NodeGroupId is the id of the node group to be scaled up.
+	NodeGroupId string `json:"nodeGroupId"`
+	// Time is the time when the request was submitted.
+	Time time.Time `json:"time"`
+	// ExpectedAddTime is the time at which the request should be fulfilled.
+	ExpectedAddTime time.Time `json:"expectedAddTime"`
+	// Increase is how much the node group is increased.
+	Increase int `json:"increase"`
+}
+
+// StateStore saves and loads the persistent state of ClusterStateRegistry.
+type StateStore interface {
+	// Load returns the saved state, or nil if there is none.
+	Load() (*PersistentState, error)
+	// Save saves the given state.
+	Save(state *PersistentState) error
+}
+
+// GetPersistentState returns the part of the state which should be persisted.
+func (csr *ClusterStateRegistry) GetPersistentState() *PersistentState {
+	csr.Lock()
+	defer csr.Unlock()
+
+	state := &PersistentState{
+		RecentScaleEvents: make(map[string][]api.ScaleEvent),
+	}
+	for id, request := range csr.scaleUpRequests {
+		state.ScaleUpRequests = append(state.ScaleUpRequests, PersistentScaleUpRequest{
+			NodeGroupId:     id,
+			Time:            request.Time,
+			ExpectedAddTime: request.ExpectedAddTime,
+			Increase:        request.Increase,
+		})
+	}
+	sort.Slice(state.ScaleUpRequests, func(i, j int) bool {
+		return state.ScaleUpRequests[i].NodeGroupId < state.ScaleUpRequests[j].NodeGroupId
+	})
+	for id, events := range csr.recentScaleEvents {
+		state.RecentScaleEvents[id] = append([]api.ScaleEvent{}, events...)
+	}
+	if persistentBackoff, ok := csr.backoff.(backoff.PersistentBackoff); ok {
+		state.Backoff = persistentBackoff.Entries()
+	}
+	return state
+}
+
+// RestorePersistentState replaces the scale-up requests, scale events and backoff data with the persisted ones.
+// It should be called once, when cluster autoscaler starts, after the cloud provider is refreshed. Scale-up
+// requests of node groups which no longer exist are dropped. Requests which expired in the meantime are
+// handled as timed out scale-ups in the next update.
+func (csr *ClusterStateRegistry) RestorePersistentState(state *PersistentState) {
+	nodeGroups := make(map[string]cloudprovider.NodeGroup)
+	for _, nodeGroup := range csr.cloudProvider.NodeGroups() {
+		nodeGroups[nodeGroup.Id()] = nodeGroup
+	}
+
+	csr.Lock()
+	defer csr.Unlock()
+
+	csr.scaleUpRequests = make(map[string]*ScaleUpRequest)
+	for _, request := range state.ScaleUpRequests {
+		nodeGroup, found := nodeGroups[request.NodeGroupId]
+		if !found {
+			klog.Warningf("Dropping persisted scale-up of %s, node group not found", request.NodeGroupId)
+			continue
+		}
+		csr.scaleUpRequests[request.NodeGroupId] = &ScaleUpRequest{
+			NodeGroup:       nodeGroup,
+			Time:            request.Time,
+			ExpectedAddTime: request.ExpectedAddTime,
+			Increase:        request.Increase,
+		}
+		klog.V(1).Infof("Restored scale-up of %s by %d requested at %v", request.NodeGroupId, request.Increase, request.Time)
+	}
+	csr.recentScaleEvents = make(map[string][]api.ScaleEvent)
+	for id, events := range state.RecentScaleEvents {
+		csr.recentScaleEvents[id] = append([]api.ScaleEvent{}, events...)
+	}
+	if persistentBackoff, ok := csr.backoff.(backoff.PersistentBackoff); ok {
+		persistentBackoff.RestoreEntries(state.Backoff)
+	} else if len(state.Backoff) > 0 {
+		klog.Warningf("Backoff can't be restored, dropping persisted backoff of %d node groups", len(state.Backoff))
+	}
+}
+
+// configMapStateStore implements StateStore by keeping the state as JSON in a ConfigMap.
+type configMapStateStore struct {
+	kubeClient kube_client.Interface
+	namespace  string
+	// lastSaved is the last state written to the ConfigMap, so that unchanged states aren't written again.
+	lastSaved string
+}
+
+// NewConfigMapStateStore returns a StateStore keeping the state in the cluster-autoscaler-state ConfigMap
+// in the given namespace.
+func NewConfigMapStateStore(kubeClient kube_client.Interface, namespace string) StateStore {
+	return &configMapStateStore{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+	}
+}
+
+// Load returns the state saved in the ConfigMap, or nil if there is no ConfigMap.
+func (s *configMapStateStore) Load() (*PersistentState, error) {
+	configMap, err := s.kubeClient.CoreV1().ConfigMaps(s.namespace).Get(StateConfigMapName, metav1.GetOptions{})
+	if kube_errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s config map: %v", StateConfigMapName, err)
+	}
+	data, found := configMap.Data[StateConfigMapKey]
+	if !found {
+		return nil, nil
+	}
+	state := &PersistentState{}
+	if err := json.Unmarshal([]byte(data), state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s config map: %v", StateConfigMapName, err)
+	}
+	s.lastSaved = data
+	return state, nil
+}
+
+// Save writes the state to the ConfigMap, creating it if needed. States equal to the last saved one
+// aren't written.
+func (s *configMapStateStore) Save(state *PersistentState) error {
+	bytes, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to serialize cluster state: %v", err)
+	}
+	data := string(bytes)
+	if data == s.lastSaved {
+		return nil
+	}
+
+	maps := s.kubeClient.CoreV1().ConfigMaps(s.namespace)
+	configMap, err := maps.Get(StateConfigMapName, metav1.GetOptions{})
+	if kube_errors.IsNotFound(err) {
+		configMap = &apiv1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: s.namespace,
+				Name:      StateConfigMapName,
+			},
+			Data: map[string]string{StateConfigMapKey: data},
+		}
+		_, err = maps.Create(configMap)
+	} else if err == nil {
+		if configMap.Data == nil {
+			configMap.Data = make(map[string]string)
+		}
+		configMap.Data[StateConfigMapKey] = data
+		_, err = maps.Update(configMap)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s config map: %v", StateConfigMapName, err)
+	}
+	s.lastSaved = data
+	return nil
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterstate
+
+import (
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/api"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/utils"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	"k8s.io/client-go/kubernetes/fake"
+	kube_record "k8s.io/client-go/tools/record"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClusterStateRegistry(provider *testprovider.TestCloudProvider) *ClusterStateRegistry {
+	fakeLogRecorder, _ := utils.NewStatusMapRecorder(&fake.Clientset{}, "kube-system", kube_record.NewFakeRecorder(5), false)
+	return NewClusterStateRegistry(provider, ClusterStateRegistryConfig{
+		MaxTotalUnreadyPercentage: 10,
+		OkTotalUnreadyCount:       1,
+		MaxNodeProvisionTime:      2 * time.Minute,
+	}, fakeLogRecorder, newBackoff())
+}
+
+func TestRestoreStateAfterRestartMidScaleUp(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	ng1_1 := BuildTestNode("ng1-1", 1000, 1000)
+	SetNodeReadyState(ng1_1, true, now.Add(-time.Minute))
+	ng2_1 := BuildTestNode("ng2-1", 1000, 1000)
+	SetNodeReadyState(ng2_1, true, now.Add(-time.Minute))
+	nodes := []*apiv1.Node{ng1_1, ng2_1}
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 3)
+	provider.AddNodeGroup("ng2", 1, 10, 2)
+	provider.AddNode("ng1", ng1_1)
+	provider.AddNode("ng2", ng2_1)
+	ng1 := provider.GetNodeGroup("ng1")
+	ng2 := provider.GetNodeGroup("ng2")
+
+	kubeClient := fake.NewSimpleClientset()
+
+	// When the first instance stops, ng1 is scaling up and ng2 is backed off after a failed scale-up.
+	clusterstate := newTestClusterStateRegistry(provider)
+	clusterstate.RegisterOrUpdateScaleUp(ng1, 2, now)
+	clusterstate.RegisterOrUpdateScaleUp(ng2, 1, now.Add(-3*time.Minute))
+	assert.NoError(t, clusterstate.UpdateNodes(nodes, nil, now))
+	assert.True(t, clusterstate.IsNodeGroupScalingUp("ng1"))
+	assert.False(t, clusterstate.IsNodeGroupSafeToScaleUp(ng2, now))
+	assert.NoError(t, NewConfigMapStateStore(kubeClient, "kube-system").Save(clusterstate.GetPersistentState()))
+	status := clusterstate.GetStatus(now)
+
+	// The new instance starts with an empty registry and restores the state.
+	now = now.Add(time.Minute)
+	restarted := newTestClusterStateRegistry(provider)
+	state, err := NewConfigMapStateStore(kubeClient, "kube-system").Load()
+	assert.NoError(t, err)
+	assert.NotNil(t, state)
+	restarted.RestorePersistentState(state)
+	assert.NoError(t, restarted.UpdateNodes(nodes, nil, now))
+	assert.True(t, restarted.IsNodeGroupScalingUp("ng1"))
+	assert.False(t, restarted.IsNodeGroupSafeToScaleUp(ng2, now))
+	restartedStatus := restarted.GetStatus(now)
+	for _, id := range []string{"ng1", "ng2"} {
+		assert.Equal(t, nodeGroupStatus(t, status, id).RecentScaleEvents, nodeGroupStatus(t, restartedStatus, id).RecentScaleEvents, id)
+	}
+	ng2Backoff := nodeGroupStatus(t, status, "ng2").Backoff
+	restartedNg2Backoff := nodeGroupStatus(t, restartedStatus, "ng2").Backoff
+	require.NotNil(t, ng2Backoff)
+	require.NotNil(t, restartedNg2Backoff)
+	assert.True(t, ng2Backoff.BackoffUntil.Equal(&restartedNg2Backoff.BackoffUntil))
+	assert.Equal(t, "timeout", restartedNg2Backoff.ErrorCode)
+
+	// The restored scale-up times out as if there was no restart.
+	now = now.Add(2 * time.Minute)
+	assert.NoError(t, restarted.UpdateNodes(nodes, nil, now))
+	assert.False(t, restarted.IsNodeGroupScalingUp("ng1"))
+	assert.False(t, restarted.IsNodeGroupSafeToScaleUp(ng1, now))
+	ng1Events := nodeGroupStatus(t, restarted.GetStatus(now), "ng1").RecentScaleEvents
+	require.NotEmpty(t, ng1Events)
+	assert.Equal(t, api.ScaleEvent{Type: api.FailedScaleUpEvent, Time: metav1.NewTime(now), Reason: "timeout"},
+		ng1Events[len(ng1Events)-1])
+}
+
+func TestRestoreStateDropsUnknownNodeGroups(t *testing.T) {
+	now := time.Now()
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 3)
+
+	clusterstate := newTestClusterStateRegistry(provider)
+	clusterstate.RestorePersistentState(&PersistentState{
+		ScaleUpRequests: []PersistentScaleUpRequest{
+			{NodeGroupId: "ng1", Time: now, ExpectedAddTime: now.Add(time.Minute), Increase: 2},
+			{NodeGroupId: "removed", Time: now, ExpectedAddTime: now.Add(time.Minute), Increase: 1},
+		},
+	})
+	state := clusterstate.GetPersistentState()
+	assert.Equal(t, 1, len(state.ScaleUpRequests))
+	assert.Equal(t, "ng1", state.ScaleUpRequests[0].NodeGroupId)
+	assert.Equal(t, 2, state.ScaleUpRequests[0].Increase)
+}
+
+func TestConfigMapStateStore(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	kubeClient := fake.NewSimpleClientset()
+	store := NewConfigMapStateStore(kubeClient, "kube-system")
+
+	state, err := store.Load()
+	assert.NoError(t, err)
+	assert.Nil(t, state)
+
+	saved := &PersistentState{
+		ScaleUpRequests: []PersistentScaleUpRequest{
+			{NodeGroupId: "ng1", Time: now, ExpectedAddTime: now.Add(time.Minute), Increase: 2},
+		},
+		RecentScaleEvents: map[string][]api.ScaleEvent{
+			"ng1": {{Type: api.ScaleUpEvent, Time: metav1.NewTime(now), Delta: 2}},
+		},
+	}
+	assert.NoError(t, store.Save(saved))
+	configMap, err := kubeClient.CoreV1().ConfigMaps("kube-system").Get(StateConfigMapName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, configMap.Data[StateConfigMapKey], "ng1")
+
+	state, err = NewConfigMapStateStore(kubeClient, "kube-system").Load()
+	assert.NoError(t, err)
+	assert.Equal(t, saved.RecentScaleEvents, state.RecentScaleEvents)
+	assert.Equal(t, 1, len(state.ScaleUpRequests))
+	assert.True(t, saved.ScaleUpRequests[0].Time.Equal(state.ScaleUpRequests[0].Time))
+	assert.Equal(t, 2, state.ScaleUpRequests[0].Increase)
+
+	// Unchanged state isn't written again.
+	actions := len(kubeClient.Actions())
+	assert.NoError(t, store.Save(saved))
+	assert.Equal(t, actions, len(kubeClient.Actions()))
+
+	assert.NoError(t, store.Save(&PersistentState{}))
+	configMap, err = kubeClient.CoreV1().ConfigMaps("kube-system").Get(StateConfigMapName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", configMap.Data[StateConfigMapKey])
+}
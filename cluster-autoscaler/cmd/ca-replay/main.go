@@ -0,0 +1,253 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// ca-replay runs Cluster Autoscaler iterations offline, on a cluster state captured with
+// --capture-state-file, and prints the scale-up and scale-down decisions made in every iteration.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/utils"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/core"
+	ca_processors "k8s.io/autoscaler/cluster-autoscaler/processors"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupset"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
+	"k8s.io/autoscaler/cluster-autoscaler/replay"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator"
+	"k8s.io/client-go/kubernetes/fake"
+	kube_record "k8s.io/client-go/tools/record"
+
+	"k8s.io/klog"
+)
+
+var (
+	stateFile    = flag.String("state", "", "File with the captured cluster state, in YAML or JSON.")
+	iterations   = flag.Int("iterations", 1, "Number of autoscaler iterations to run.")
+	scanInterval = flag.Duration("scan-interval", 10*time.Second, "Time by which the fake clock is advanced between iterations.")
+	expander     = flag.String("expander", "", "If set, overrides the expander of the captured options.")
+)
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+	if *stateFile == "" {
+		klog.Fatalf("--state is required")
+	}
+	state, err := replay.ReadClusterState(*stateFile)
+	if err != nil {
+		klog.Fatalf("Failed to read cluster state: %v", err)
+	}
+	if *expander != "" {
+		state.Options.ExpanderName = *expander
+	}
+	if err := runReplay(state, *iterations, *scanInterval, time.Now(), os.Stdout); err != nil {
+		klog.Fatalf("Replay failed: %v", err)
+	}
+}
+
+// runReplay runs autoscaler iterations on the cluster state, in dry-run mode, and writes their
+// decisions to out. The fake clock starts at startTime and is advanced by interval after each iteration.
+func runReplay(state *replay.ClusterState, iterations int, interval time.Duration, startTime time.Time, out io.Writer) error {
+	autoscaler, err := buildAutoscaler(state, out)
+	if err != nil {
+		return err
+	}
+	currentTime := startTime
+	for i := 1; i <= iterations; i++ {
+		fmt.Fprintf(out, "Iteration %d at %s:\n", i, currentTime.Format(time.RFC3339))
+		if err := autoscaler.RunOnce(currentTime); err != nil {
+			fmt.Fprintf(out, "  Error: %v\n", err)
+		}
+		currentTime = currentTime.Add(interval)
+	}
+	autoscaler.ExitCleanUp()
+	return nil
+}
+
+func buildAutoscaler(state *replay.ClusterState, out io.Writer) (core.Autoscaler, error) {
+	options := state.Options
+	// Nothing is really resized, nodes are neither tainted nor drained.
+	options.DryRun = true
+	options.WriteStatusConfigMap = false
+	// Config maps aren't captured.
+	options.ScheduledCapacityEnabled = false
+	// Captured options already include the ones loaded from the config map.
+	options.DynamicOptionsEnabled = false
+	// The replayed loop must not overwrite the state of the real autoscaler.
+	options.PersistClusterState = false
+
+	listerRegistry, err := replay.NewListerRegistry(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build listers: %v", err)
+	}
+	cloudProvider, err := replay.NewCloudProvider(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build cloud provider: %v", err)
+	}
+
+	// The client backs the scheduler predicates, which use informers of their own.
+	kubeClient := fake.NewSimpleClientset(stateObjects(state)...)
+	eventRecorder := &kube_record.FakeRecorder{}
+	logRecorder, err := utils.NewStatusMapRecorder(kubeClient, options.ConfigNamespace, eventRecorder, false)
+	if err != nil {
+		return nil, err
+	}
+	predicateChecker, err := simulator.NewPredicateChecker(kubeClient, make(chan struct{}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create predicate checker: %v", err)
+	}
+
+	processors := ca_processors.DefaultProcessors()
+	processors.PodListProcessor = core.NewFilterOutSchedulablePodListProcessor()
+	processors.NodeGroupSetProcessor = &nodegroupset.BalancingNodeGroupSetProcessor{
+		Comparator: nodegroupset.CreateNodeInfoComparator(options.CloudProviderName,
+			options.BalancingExtraIgnoredLabels, options.NodeGroupDifferenceRatios),
+	}
+	processors.ScaleUpStatusProcessor = &scaleUpPrinter{out: out}
+	processors.ScaleDownStatusProcessor = &scaleDownPrinter{out: out}
+
+	return core.NewAutoscaler(core.AutoscalerOptions{
+		AutoscalingOptions: options,
+		KubeClient:         kubeClient,
+		EventsKubeClient:   kubeClient,
+		AutoscalingKubeClients: &context.AutoscalingKubeClients{
+			ListerRegistry: listerRegistry,
+			ClientSet:      kubeClient,
+			Recorder:       eventRecorder,
+			LogRecorder:    logRecorder,
+		},
+		CloudProvider:    cloudProvider,
+		PredicateChecker: predicateChecker,
+		Processors:       processors,
+	})
+}
+
+func stateObjects(state *replay.ClusterState) []runtime.Object {
+	var objects []runtime.Object
+	for _, node := range state.Nodes {
+		objects = append(objects, node)
+	}
+	for _, pod := range state.Pods {
+		objects = append(objects, pod)
+	}
+	for _, pdb := range state.PodDisruptionBudgets {
+		objects = append(objects, pdb)
+	}
+	for _, rc := range state.ReplicationControllers {
+		objects = append(objects, rc)
+	}
+	for _, rs := range state.ReplicaSets {
+		objects = append(objects, rs)
+	}
+	for _, ss := range state.StatefulSets {
+		objects = append(objects, ss)
+	}
+	return objects
+}
+
+// scaleUpPrinter prints scale-up decisions and the reasons why pods didn't trigger a scale-up.
+type scaleUpPrinter struct {
+	out io.Writer
+}
+
+// Process prints the scale-up status.
+func (p *scaleUpPrinter) Process(context *context.AutoscalingContext, scaleUpStatus *status.ScaleUpStatus) {
+	switch scaleUpStatus.Result {
+	case status.ScaleUpSuccessful:
+		for _, info := range scaleUpStatus.ScaleUpInfos {
+			fmt.Fprintf(p.out, "  Scale-up: %s %d->%d (max: %d)\n", info.Group.Id(), info.CurrentSize, info.NewSize, info.MaxSize)
+		}
+		fmt.Fprintf(p.out, "  Pods triggering scale-up: %s\n", podNames(scaleUpStatus.PodsTriggeredScaleUp))
+	case status.ScaleUpError:
+		fmt.Fprintf(p.out, "  Scale-up: error\n")
+	case status.ScaleUpNoOptionsAvailable:
+		fmt.Fprintf(p.out, "  Scale-up: no options available\n")
+	case status.ScaleUpInCooldown:
+		fmt.Fprintf(p.out, "  Scale-up: in cooldown\n")
+	}
+	for _, noScaleUpInfo := range scaleUpStatus.PodsRemainUnschedulable {
+		fmt.Fprintf(p.out, "  Pod %s/%s didn't trigger scale-up:\n", noScaleUpInfo.Pod.Namespace, noScaleUpInfo.Pod.Name)
+		printReasons(p.out, "rejected", noScaleUpInfo.RejectedNodeGroups)
+		printReasons(p.out, "skipped", noScaleUpInfo.SkippedNodeGroups)
+	}
+	if len(scaleUpStatus.PodsAwaitEvaluation) > 0 {
+		fmt.Fprintf(p.out, "  Pods awaiting evaluation: %s\n", podNames(scaleUpStatus.PodsAwaitEvaluation))
+	}
+}
+
+// CleanUp cleans up the processor's internal structures.
+func (p *scaleUpPrinter) CleanUp() {
+}
+
+// scaleDownPrinter prints scale-down decisions.
+type scaleDownPrinter struct {
+	out io.Writer
+}
+
+// Process prints the scale-down status.
+func (p *scaleDownPrinter) Process(context *context.AutoscalingContext, scaleDownStatus *status.ScaleDownStatus) {
+	switch scaleDownStatus.Result {
+	case status.ScaleDownError:
+		fmt.Fprintf(p.out, "  Scale-down: error\n")
+	case status.ScaleDownInCooldown:
+		fmt.Fprintf(p.out, "  Scale-down: in cooldown\n")
+	case status.ScaleDownInProgress:
+		fmt.Fprintf(p.out, "  Scale-down: previous scale-down in progress\n")
+	case status.ScaleDownNoNodeDeleted:
+		fmt.Fprintf(p.out, "  Scale-down: unneeded nodes can't be removed yet\n")
+	}
+	for _, node := range scaleDownStatus.ScaledDownNodes {
+		nodeGroup := ""
+		if node.NodeGroup != nil {
+			nodeGroup = node.NodeGroup.Id()
+		}
+		fmt.Fprintf(p.out, "  Scale-down: %s from %s (utilization: %.2f, evicted pods: %s)\n",
+			node.Node.Name, nodeGroup, node.UtilInfo.Utilization, podNames(node.EvictedPods))
+	}
+}
+
+// CleanUp cleans up the processor's internal structures.
+func (p *scaleDownPrinter) CleanUp() {
+}
+
+func printReasons(out io.Writer, kind string, reasons map[string]status.Reasons) {
+	nodeGroups := make([]string, 0, len(reasons))
+	for nodeGroup := range reasons {
+		nodeGroups = append(nodeGroups, nodeGroup)
+	}
+	sort.Strings(nodeGroups)
+	for _, nodeGroup := range nodeGroups {
+		fmt.Fprintf(out, "    %s %s: %s\n", kind, nodeGroup, strings.Join(reasons[nodeGroup].Reasons(), ", "))
+	}
+}
+
+func podNames(pods []*apiv1.Pod) string {
+	names := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		names = append(names, pod.Namespace+"/"+pod.Name)
+	}
+	return strings.Join(names, ", ")
+}
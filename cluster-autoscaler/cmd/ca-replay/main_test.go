@@ -0,0 +1,80 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/replay"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunReplay(t *testing.T) {
+	now := time.Now()
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, now.Add(-time.Hour))
+	p1 := BuildTestPod("p1", 800, 0)
+	p1.Spec.NodeName = "n1"
+	p2 := BuildTestPod("p2", 500, 0)
+	p3 := BuildTestPod("p3", 5000, 0)
+	for _, pod := range []*apiv1.Pod{p2, p3} {
+		pod.CreationTimestamp.Time = now.Add(-time.Minute)
+		pod.Status.Conditions = []apiv1.PodCondition{{
+			Type:   apiv1.PodScheduled,
+			Status: apiv1.ConditionFalse,
+			Reason: apiv1.PodReasonUnschedulable,
+		}}
+	}
+
+	state := &replay.ClusterState{
+		Time: now,
+		Options: config.AutoscalingOptions{
+			EstimatorName:        "binpacking",
+			ExpanderName:         "least-waste",
+			MaxCoresTotal:        1000,
+			MaxMemoryTotal:       1000000,
+			MaxNodesTotal:        100,
+			ScaleDownEnabled:     true,
+			MaxNodeProvisionTime: 15 * time.Minute,
+		},
+		NodeGroups: []replay.NodeGroupState{{
+			Id:         "ng1",
+			MinSize:    1,
+			MaxSize:    5,
+			TargetSize: 1,
+			Nodes:      []string{"n1"},
+		}},
+		Nodes: []*apiv1.Node{n1},
+		Pods:  []*apiv1.Pod{p1, p2, p3},
+	}
+
+	var out bytes.Buffer
+	err := runReplay(state, 2, 10*time.Second, now, &out)
+	assert.NoError(t, err)
+	output := out.String()
+	assert.Contains(t, output, "Iteration 1")
+	assert.Contains(t, output, "Iteration 2")
+	assert.Contains(t, output, "Scale-up: ng1 1->2 (max: 5)")
+	assert.Contains(t, output, "Pods triggering scale-up: default/p2")
+	assert.Contains(t, output, "Pod default/p3 didn't trigger scale-up:\n    rejected ng1: Insufficient cpu")
+}
@@ -30,10 +30,40 @@ type GpuLimits struct {
 	Max int64
 }
 
+// NodeGroupAutoscalingOptions contain various options to customize how autoscaling of
+// a given NodeGroup works. Different options can be used for each NodeGroup.
+type NodeGroupAutoscalingOptions struct {
+	// ScaleDownUtilizationThreshold sets threshold for nodes to be considered for scale down if cpu or memory utilization is over threshold.
+	// Well-utilized nodes are not touched.
+	ScaleDownUtilizationThreshold float64
+	// ScaleDownGpuUtilizationThreshold sets threshold for gpu nodes to be considered for scale down if gpu utilization is over threshold.
+	// Well-utilized nodes are not touched.
+	ScaleDownGpuUtilizationThreshold float64
+	// ScaleDownUnneededTime sets the duration CA expects a node to be unneeded/eligible for removal
+	// before scaling down the node.
+	ScaleDownUnneededTime time.Duration
+	// ScaleDownUnreadyTime represents how long an unready node should be unneeded before it is eligible for scale down
+	ScaleDownUnreadyTime time.Duration
+	// ScaleDownDisabled protects all nodes of the NodeGroup from scale down.
+	ScaleDownDisabled bool
+}
+
+// NodeGroupDifferenceRatios contains tolerances of differences between node groups considered similar
+// when balancing node groups.
+type NodeGroupDifferenceRatios struct {
+	// MaxAllocatableDifferenceRatio describes how Node.Status.Allocatable can differ between similar node groups.
+	MaxAllocatableDifferenceRatio float64
+	// MaxFreeDifferenceRatio describes how free resources (allocatable - daemon and system pods)
+	// can differ between similar node groups.
+	MaxFreeDifferenceRatio float64
+}
+
 // AutoscalingOptions contain various options to customize how autoscaling works
 type AutoscalingOptions struct {
 	// MaxEmptyBulkDelete is a number of empty nodes that can be removed at the same time.
 	MaxEmptyBulkDelete int
+	// MaxDrainParallelism is a number of non-empty nodes that can be drained and removed at the same time.
+	MaxDrainParallelism int
 	// ScaleDownUtilizationThreshold sets threshold for nodes to be considered for scale down if cpu or memory utilization is over threshold.
 	// Well-utilized nodes are not touched.
 	ScaleDownUtilizationThreshold float64
@@ -63,6 +93,14 @@ type AutoscalingOptions struct {
 	EstimatorName string
 	// ExpanderName sets the type of node group expander to be used in scale up
 	ExpanderName string
+	// GRPCExpanderCert is the location of the cert passed to the gRPC server for TLS when using the gRPC expander
+	GRPCExpanderCert string
+	// GRPCExpanderURL is the url of the gRPC server when using the gRPC expander
+	GRPCExpanderURL string
+	// GRPCExpanderTimeout is the timeout of a single call to the gRPC server when using the gRPC expander
+	GRPCExpanderTimeout time.Duration
+	// GRPCExpanderFallback is the name of the expander used when the gRPC expander fails to return an option
+	GRPCExpanderFallback string
 	// IgnoreDaemonSetsUtilization is whether CA will ignore DaemonSet pods when calculating resource utilization for scaling down
 	IgnoreDaemonSetsUtilization bool
 	// IgnoreMirrorPodsUtilization is whether CA will ignore Mirror pods when calculating resource utilization for scaling down
@@ -80,6 +118,15 @@ type AutoscalingOptions struct {
 	CloudConfig string
 	// CloudProviderName sets the type of the cloud provider CA is about to run in. Allowed values: gce, aws
 	CloudProviderName string
+	// CompositeCloudProviders are the cloud providers wrapped by the composite cloud provider, in the format
+	// <provider>:<cloud-config>[:<providerID prefix>]. Only used if CloudProviderName is composite.
+	CompositeCloudProviders []string
+	// AWSAutoprovisioningLaunchTemplate is the launch template, as <name> or <name>:<version>, used by
+	// the AWS cloud provider to create autoprovisioned ASGs.
+	AWSAutoprovisioningLaunchTemplate string
+	// AWSAutoprovisioningSubnets is a comma-separated list of subnets in which the AWS cloud provider
+	// creates autoprovisioned ASGs.
+	AWSAutoprovisioningSubnets string
 	// NodeGroups is the list of node groups a.k.a autoscaling targets
 	NodeGroups []string
 	// ScaleDownEnabled is used to allow CA to scale down the cluster
@@ -107,6 +154,11 @@ type AutoscalingOptions struct {
 	WriteStatusConfigMap bool
 	// BalanceSimilarNodeGroups enables logic that identifies node groups with similar machines and tries to balance node count between them.
 	BalanceSimilarNodeGroups bool
+	// BalancingExtraIgnoredLabels is a list of labels, in addition to the cloud provider specific ones,
+	// which can differ between similar node groups.
+	BalancingExtraIgnoredLabels []string
+	// NodeGroupDifferenceRatios are tolerances of differences between similar node groups.
+	NodeGroupDifferenceRatios NodeGroupDifferenceRatios
 	// ConfigNamespace is the namespace cluster-autoscaler is running in and all related configmaps live in
 	ConfigNamespace string
 	// ClusterName if available
@@ -133,4 +185,27 @@ type AutoscalingOptions struct {
 	// Setting it to false employs a more lenient filtering approach that does not try to pack the pods on the nodes.
 	// Pods with nominatedNodeName set are always filtered out.
 	FilterOutSchedulablePodsUsesPacking bool
+	// DryRun tells if CA should only report the scaling decisions it makes, without changing node groups,
+	// tainting or draining nodes.
+	DryRun bool
+	// ScheduledCapacityEnabled tells if minimum sizes of node groups should be raised according to
+	// schedules defined in the cluster-autoscaler-scheduled-capacity ConfigMap.
+	ScheduledCapacityEnabled bool
+	// DynamicOptionsEnabled tells if autoscaling options and node group sizes should be overridden by
+	// the ones defined in the cluster-autoscaler-options ConfigMap, reloaded in every loop.
+	DynamicOptionsEnabled bool
+	// PersistClusterState tells if in-progress scale-ups, recent scale events and backoff of node groups
+	// should be saved in the cluster-autoscaler-state ConfigMap and restored when CA starts.
+	PersistClusterState bool
+}
+
+// NodeGroupDefaults returns the NodeGroupAutoscalingOptions used for node groups
+// that do not provide their own options.
+func (o AutoscalingOptions) NodeGroupDefaults() NodeGroupAutoscalingOptions {
+	return NodeGroupAutoscalingOptions{
+		ScaleDownUtilizationThreshold:    o.ScaleDownUtilizationThreshold,
+		ScaleDownGpuUtilizationThreshold: o.ScaleDownGpuUtilizationThreshold,
+		ScaleDownUnneededTime:            o.ScaleDownUnneededTime,
+		ScaleDownUnreadyTime:             o.ScaleDownUnreadyTime,
+	}
 }
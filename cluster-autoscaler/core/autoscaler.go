@@ -21,6 +21,7 @@ import (
 
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	cloudBuilder "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/builder"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/dryrun"
 	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/context"
@@ -28,9 +29,13 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/expander"
 	"k8s.io/autoscaler/cluster-autoscaler/expander/factory"
 	ca_processors "k8s.io/autoscaler/cluster-autoscaler/processors"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/dynamicoptions"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/scheduledcapacity"
+	"k8s.io/autoscaler/cluster-autoscaler/replay"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/backoff"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
 	kube_client "k8s.io/client-go/kubernetes"
 )
 
@@ -42,10 +47,15 @@ type AutoscalerOptions struct {
 	AutoscalingKubeClients *context.AutoscalingKubeClients
 	CloudProvider          cloudprovider.CloudProvider
 	PredicateChecker       *simulator.PredicateChecker
+	ClusterSnapshot        simulator.ClusterSnapshot
 	ExpanderStrategy       expander.Strategy
 	EstimatorBuilder       estimator.EstimatorBuilder
 	Processors             *ca_processors.AutoscalingProcessors
 	Backoff                backoff.Backoff
+	// StateCapturer, if set, captures the input of the autoscaler on demand.
+	StateCapturer *replay.StateCapturer
+	// ClusterStateStore, if set, persists in-progress scale-ups and backoff across restarts.
+	ClusterStateStore clusterstate.StateStore
 }
 
 // Autoscaler is the main component of CA which scales up/down node groups according to its configuration
@@ -63,15 +73,19 @@ func NewAutoscaler(opts AutoscalerOptions) (Autoscaler, errors.AutoscalerError)
 	if err != nil {
 		return nil, errors.ToAutoscalerError(errors.InternalError, err)
 	}
-	return NewStaticAutoscaler(
+	autoscaler := NewStaticAutoscaler(
 		opts.AutoscalingOptions,
 		opts.PredicateChecker,
+		opts.ClusterSnapshot,
 		opts.AutoscalingKubeClients,
 		opts.Processors,
 		opts.CloudProvider,
 		opts.ExpanderStrategy,
 		opts.EstimatorBuilder,
-		opts.Backoff), nil
+		opts.Backoff)
+	autoscaler.stateCapturer = opts.StateCapturer
+	autoscaler.clusterStateStore = opts.ClusterStateStore
+	return autoscaler, nil
 }
 
 // Initialize default options if not provided.
@@ -90,12 +104,49 @@ func initializeDefaultOptions(opts *AutoscalerOptions) error {
 		}
 		opts.PredicateChecker = predicateChecker
 	}
+	if opts.ClusterSnapshot == nil {
+		opts.ClusterSnapshot = simulator.NewBasicClusterSnapshot()
+	}
 	if opts.CloudProvider == nil {
 		opts.CloudProvider = cloudBuilder.NewCloudProvider(opts.AutoscalingOptions)
 	}
+	if opts.DynamicOptionsEnabled && opts.Processors.DynamicOptionsProcessor == nil {
+		// As for the priority expander, the lister is never stopped.
+		stopChannel := make(chan struct{})
+		lister := kube_util.NewConfigMapListerForNamespace(opts.KubeClient, stopChannel, opts.ConfigNamespace)
+		opts.Processors.DynamicOptionsProcessor = dynamicoptions.NewDynamicOptionsProcessor(
+			lister.ConfigMaps(opts.ConfigNamespace), opts.AutoscalingKubeClients.Recorder,
+			func(expanderName string, expanderStopChannel <-chan struct{}) (expander.Strategy, error) {
+				return factory.ExpanderStrategyFromString(expanderName,
+					opts.CloudProvider, opts.AutoscalingKubeClients, opts.KubeClient, expanderStopChannel, opts.ConfigNamespace,
+					opts.GRPCExpanderCert, opts.GRPCExpanderURL, opts.GRPCExpanderTimeout, opts.GRPCExpanderFallback)
+			})
+	}
+	if opts.Processors.DynamicOptionsProcessor != nil {
+		// Scheduled capacity raises the overridden minimum sizes, so it wraps this cloud provider.
+		opts.CloudProvider = dynamicoptions.NewCloudProvider(opts.CloudProvider, opts.Processors.DynamicOptionsProcessor)
+	}
+	if opts.ScheduledCapacityEnabled && opts.Processors.ScheduledCapacityProcessor == nil {
+		// As for the priority expander, the lister is never stopped.
+		stopChannel := make(chan struct{})
+		lister := kube_util.NewConfigMapListerForNamespace(opts.KubeClient, stopChannel, opts.ConfigNamespace)
+		opts.Processors.ScheduledCapacityProcessor = scheduledcapacity.NewScheduledCapacityProcessor(
+			lister.ConfigMaps(opts.ConfigNamespace), opts.AutoscalingKubeClients.Recorder, opts.Processors.NodeGroupListProcessor)
+		// Scale-up enforces the raised minimum sizes through the processor.
+		opts.Processors.NodeGroupListProcessor = opts.Processors.ScheduledCapacityProcessor
+	}
+	if opts.Processors.ScheduledCapacityProcessor != nil {
+		opts.CloudProvider = scheduledcapacity.NewCloudProvider(opts.CloudProvider, opts.Processors.ScheduledCapacityProcessor)
+	}
+	if opts.DryRun {
+		opts.CloudProvider = dryrun.NewCloudProvider(opts.CloudProvider)
+	}
 	if opts.ExpanderStrategy == nil {
+		// The command line expander is used for the whole lifetime of CA, its listers are never stopped.
+		stopChannel := make(chan struct{})
 		expanderStrategy, err := factory.ExpanderStrategyFromString(opts.ExpanderName,
-			opts.CloudProvider, opts.AutoscalingKubeClients, opts.KubeClient, opts.ConfigNamespace)
+			opts.CloudProvider, opts.AutoscalingKubeClients, opts.KubeClient, stopChannel, opts.ConfigNamespace,
+			opts.GRPCExpanderCert, opts.GRPCExpanderURL, opts.GRPCExpanderTimeout, opts.GRPCExpanderFallback)
 		if err != nil {
 			return err
 		}
@@ -108,6 +159,9 @@ func initializeDefaultOptions(opts *AutoscalerOptions) error {
 		}
 		opts.EstimatorBuilder = estimatorBuilder
 	}
+	if opts.PersistClusterState && opts.ClusterStateStore == nil {
+		opts.ClusterStateStore = clusterstate.NewConfigMapStateStore(opts.KubeClient, opts.ConfigNamespace)
+	}
 	if opts.Backoff == nil {
 		opts.Backoff =
 			backoff.NewIdBasedExponentialBackoff(clusterstate.InitialNodeGroupBackoffDuration, clusterstate.MaxNodeGroupBackoffDuration, clusterstate.NodeGroupBackoffResetTimeout)
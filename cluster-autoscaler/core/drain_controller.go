@@ -0,0 +1,250 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/autoscaler/cluster-autoscaler/metrics"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
+
+	apiv1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1beta1"
+	kube_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kube_client "k8s.io/client-go/kubernetes"
+	kube_record "k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+)
+
+const (
+	// podCheckInterval is the time between checks whether evicted pods are gone from the node.
+	podCheckInterval = 5 * time.Second
+)
+
+// drainController evicts pods from a node being scaled down. Pods not covered by any PodDisruptionBudget
+// are evicted first, so they don't wait for the ones that may be blocked. Evictions rejected by the API server
+// because of a PodDisruptionBudget are retried for up to maxGracefulTerminationSec longer than the other ones,
+// which is enough for previously evicted replicas to terminate and be replaced.
+type drainController struct {
+	client                    kube_client.Interface
+	recorder                  kube_record.EventRecorder
+	pdbs                      []*policyv1.PodDisruptionBudget
+	maxGracefulTerminationSec int
+	maxPodEvictionTime        time.Duration
+	waitBetweenRetries        time.Duration
+	podEvictionHeadroom       time.Duration
+	podCheckInterval          time.Duration
+}
+
+// newDrainController returns a drain controller using the default eviction timeouts.
+func newDrainController(client kube_client.Interface, recorder kube_record.EventRecorder,
+	pdbs []*policyv1.PodDisruptionBudget, maxGracefulTerminationSec int) *drainController {
+	return &drainController{
+		client:                    client,
+		recorder:                  recorder,
+		pdbs:                      pdbs,
+		maxGracefulTerminationSec: maxGracefulTerminationSec,
+		maxPodEvictionTime:        MaxPodEvictionTime,
+		waitBetweenRetries:        EvictionRetryTime,
+		podEvictionHeadroom:       PodEvictionHeadroom,
+		podCheckInterval:          podCheckInterval,
+	}
+}
+
+// drain evicts the given pods from the node and waits until they are gone. Each pod is given its own
+// terminationGracePeriodSeconds, capped at maxGracefulTerminationSec, plus podEvictionHeadroom to terminate.
+// The result of every pod is returned, even if the drain fails.
+func (d *drainController) drain(node *apiv1.Node, pods []*apiv1.Pod) (map[string]status.PodEvictionResult, errors.AutoscalerError) {
+	evictionResults := make(map[string]status.PodEvictionResult)
+	for _, pod := range pods {
+		// Pods that don't get an actual result are correctly marked as timed out.
+		evictionResults[pod.Name] = status.PodEvictionResult{Pod: pod, TimedOut: true, Err: nil}
+	}
+	retryUntil := time.Now().Add(d.maxPodEvictionTime)
+	pdbRetryUntil := retryUntil.Add(time.Duration(d.maxGracefulTerminationSec) * time.Second)
+	evictionTimes := make(map[string]time.Time)
+
+	withoutPdb, withPdb := d.splitByPdb(pods)
+	for _, podsToEvict := range [][]*apiv1.Pod{withoutPdb, withPdb} {
+		if err := d.evictPods(node, podsToEvict, retryUntil, pdbRetryUntil, evictionResults, evictionTimes); err != nil {
+			return evictionResults, err
+		}
+	}
+	return evictionResults, d.waitForPodsGone(node, pods, evictionTimes, evictionResults)
+}
+
+// splitByPdb splits pods into the ones not covered by any PodDisruptionBudget and the ones covered by some.
+func (d *drainController) splitByPdb(pods []*apiv1.Pod) (withoutPdb []*apiv1.Pod, withPdb []*apiv1.Pod) {
+	for _, pod := range pods {
+		if d.isCoveredByPdb(pod) {
+			withPdb = append(withPdb, pod)
+		} else {
+			withoutPdb = append(withoutPdb, pod)
+		}
+	}
+	return withoutPdb, withPdb
+}
+
+func (d *drainController) isCoveredByPdb(pod *apiv1.Pod) bool {
+	for _, pdb := range d.pdbs {
+		if pdb.Namespace != pod.Namespace {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			// Better evict the pod late than risk a blocked eviction delaying the other ones.
+			klog.Warningf("Failed to parse selector of pod disruption budget %s/%s: %v", pdb.Namespace, pdb.Name, err)
+			return true
+		}
+		if selector.Matches(labels.Set(pod.Labels)) {
+			return true
+		}
+	}
+	return false
+}
+
+// evictPods evicts the pods in parallel and records their results. It fails if any of the evictions failed.
+func (d *drainController) evictPods(node *apiv1.Node, pods []*apiv1.Pod, retryUntil, pdbRetryUntil time.Time,
+	evictionResults map[string]status.PodEvictionResult, evictionTimes map[string]time.Time) errors.AutoscalerError {
+	if len(pods) == 0 {
+		return nil
+	}
+	confirmations := make(chan status.PodEvictionResult, len(pods))
+	for _, pod := range pods {
+		go func(podToEvict *apiv1.Pod) {
+			confirmations <- d.evictPod(podToEvict, retryUntil, pdbRetryUntil)
+		}(pod)
+	}
+
+	for range pods {
+		select {
+		case evictionResult := <-confirmations:
+			evictionResults[evictionResult.Pod.Name] = evictionResult
+			if evictionResult.WasEvictionSuccessful() {
+				evictionTimes[evictionResult.Pod.Name] = time.Now()
+				metrics.RegisterEvictions(1)
+			}
+		case <-time.After(pdbRetryUntil.Sub(time.Now()) + 5*time.Second):
+			return errors.NewAutoscalerError(errors.ApiCallError, "Failed to drain node %s/%s: timeout when waiting for creating evictions", node.Namespace, node.Name)
+		}
+	}
+
+	evictionErrs := make([]error, 0)
+	for _, pod := range pods {
+		if result := evictionResults[pod.Name]; !result.WasEvictionSuccessful() {
+			evictionErrs = append(evictionErrs, result.Err)
+		}
+	}
+	if len(evictionErrs) != 0 {
+		return errors.NewAutoscalerError(errors.ApiCallError, "Failed to drain node %s/%s, due to following errors: %v", node.Namespace, node.Name, evictionErrs)
+	}
+	return nil
+}
+
+// evictPod creates an eviction for the pod, retrying until retryUntil, or pdbRetryUntil if the eviction
+// is rejected because of a PodDisruptionBudget.
+func (d *drainController) evictPod(podToEvict *apiv1.Pod, retryUntil, pdbRetryUntil time.Time) status.PodEvictionResult {
+	d.recorder.Eventf(podToEvict, apiv1.EventTypeNormal, "ScaleDown", "deleting pod for node scale down")
+
+	gracePeriod := d.gracePeriodSeconds(podToEvict)
+	var lastError error
+	for {
+		eviction := &policyv1.Eviction{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: podToEvict.Namespace,
+				Name:      podToEvict.Name,
+			},
+			DeleteOptions: &metav1.DeleteOptions{
+				GracePeriodSeconds: &gracePeriod,
+			},
+		}
+		lastError = d.client.CoreV1().Pods(podToEvict.Namespace).Evict(eviction)
+		if lastError == nil || kube_errors.IsNotFound(lastError) {
+			return status.PodEvictionResult{Pod: podToEvict, TimedOut: false, Err: nil}
+		}
+		deadline := retryUntil
+		if kube_errors.IsTooManyRequests(lastError) {
+			klog.V(2).Infof("Eviction of pod %s/%s blocked by pod disruption budget, will retry", podToEvict.Namespace, podToEvict.Name)
+			deadline = pdbRetryUntil
+		}
+		if !time.Now().Before(deadline) {
+			break
+		}
+		time.Sleep(d.waitBetweenRetries)
+	}
+	klog.Errorf("Failed to evict pod %s, error: %v", podToEvict.Name, lastError)
+	d.recorder.Eventf(podToEvict, apiv1.EventTypeWarning, "ScaleDownFailed", "failed to delete pod for ScaleDown")
+	return status.PodEvictionResult{Pod: podToEvict, TimedOut: true, Err: fmt.Errorf("failed to evict pod %s/%s within allowed timeout (last error: %v)", podToEvict.Namespace, podToEvict.Name, lastError)}
+}
+
+// gracePeriodSeconds returns the termination grace period of the pod, capped at maxGracefulTerminationSec.
+func (d *drainController) gracePeriodSeconds(pod *apiv1.Pod) int64 {
+	gracePeriod := int64(apiv1.DefaultTerminationGracePeriodSeconds)
+	if pod.Spec.TerminationGracePeriodSeconds != nil {
+		gracePeriod = *pod.Spec.TerminationGracePeriodSeconds
+	}
+	if gracePeriod > int64(d.maxGracefulTerminationSec) {
+		gracePeriod = int64(d.maxGracefulTerminationSec)
+	}
+	return gracePeriod
+}
+
+// waitForPodsGone waits until the evicted pods disappear from the node. Every pod is waited for
+// until its own grace period and podEvictionHeadroom pass since its eviction.
+func (d *drainController) waitForPodsGone(node *apiv1.Node, pods []*apiv1.Pod, evictionTimes map[string]time.Time,
+	evictionResults map[string]status.PodEvictionResult) errors.AutoscalerError {
+	remaining := pods
+	timedOut := false
+	for {
+		stillRemaining := make([]*apiv1.Pod, 0, len(remaining))
+		for _, pod := range remaining {
+			podReturned, err := d.client.CoreV1().Pods(pod.Namespace).Get(pod.Name, metav1.GetOptions{})
+			gone := kube_errors.IsNotFound(err) || (err == nil && podReturned != nil && podReturned.Spec.NodeName != node.Name)
+			if gone {
+				evictionResults[pod.Name] = status.PodEvictionResult{Pod: pod, TimedOut: false, Err: nil}
+				continue
+			}
+			if err != nil {
+				klog.Errorf("Failed to check pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			} else {
+				klog.V(2).Infof("Pod %s/%s not deleted yet", pod.Namespace, pod.Name)
+			}
+			deadline := evictionTimes[pod.Name].Add(time.Duration(d.gracePeriodSeconds(pod))*time.Second + d.podEvictionHeadroom)
+			if !time.Now().Before(deadline) {
+				evictionResults[pod.Name] = status.PodEvictionResult{Pod: pod, TimedOut: true, Err: err}
+				timedOut = true
+				continue
+			}
+			stillRemaining = append(stillRemaining, pod)
+		}
+		if len(stillRemaining) == 0 {
+			break
+		}
+		remaining = stillRemaining
+		time.Sleep(d.podCheckInterval)
+	}
+
+	if timedOut {
+		return errors.NewAutoscalerError(errors.TransientError, "Failed to drain node %s/%s: pods remaining after timeout", node.Namespace, node.Name)
+	}
+	klog.V(1).Infof("All pods removed from %s", node.Name)
+	return nil
+}
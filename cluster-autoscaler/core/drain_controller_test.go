@@ -0,0 +1,430 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	kube_client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestDrainController(client kube_client.Interface, pdbs []*policyv1.PodDisruptionBudget, maxGracefulTerminationSec int,
+	maxPodEvictionTime time.Duration, waitBetweenRetries time.Duration, podEvictionHeadroom time.Duration) *drainController {
+	return &drainController{
+		client:                    client,
+		recorder:                  kube_util.CreateEventRecorder(client),
+		pdbs:                      pdbs,
+		maxGracefulTerminationSec: maxGracefulTerminationSec,
+		maxPodEvictionTime:        maxPodEvictionTime,
+		waitBetweenRetries:        waitBetweenRetries,
+		podEvictionHeadroom:       podEvictionHeadroom,
+		podCheckInterval:          10 * time.Millisecond,
+	}
+}
+
+func buildTestPdb(name string, matchLabels map[string]string) *policyv1.PodDisruptionBudget {
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: matchLabels},
+		},
+	}
+}
+
+func TestDrainNode(t *testing.T) {
+	deletedPods := make(chan string, 10)
+	fakeClient := &fake.Clientset{}
+
+	p1 := BuildTestPod("p1", 100, 0)
+	p2 := BuildTestPod("p2", 300, 0)
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Time{})
+
+	fakeClient.Fake.AddReactor("get", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewNotFound(apiv1.Resource("pod"), "whatever")
+	})
+	fakeClient.Fake.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		createAction := action.(core.CreateAction)
+		if createAction == nil {
+			return false, nil, nil
+		}
+		eviction := createAction.GetObject().(*policyv1.Eviction)
+		if eviction == nil {
+			return false, nil, nil
+		}
+		deletedPods <- eviction.Name
+		return true, nil, nil
+	})
+	_, err := newTestDrainController(fakeClient, nil, 20, 5*time.Second, 0*time.Second, PodEvictionHeadroom).drain(n1, []*apiv1.Pod{p1, p2})
+	assert.NoError(t, err)
+	deleted := make([]string, 0)
+	deleted = append(deleted, getStringFromChan(deletedPods))
+	deleted = append(deleted, getStringFromChan(deletedPods))
+	sort.Strings(deleted)
+	assert.Equal(t, p1.Name, deleted[0])
+	assert.Equal(t, p2.Name, deleted[1])
+}
+
+func TestDrainNodeWithRescheduled(t *testing.T) {
+	deletedPods := make(chan string, 10)
+	fakeClient := &fake.Clientset{}
+
+	p1 := BuildTestPod("p1", 100, 0)
+	p2 := BuildTestPod("p2", 300, 0)
+	p2Rescheduled := BuildTestPod("p2", 300, 0)
+	p2Rescheduled.Spec.NodeName = "n2"
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Time{})
+
+	fakeClient.Fake.AddReactor("get", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		getAction := action.(core.GetAction)
+		if getAction == nil {
+			return false, nil, nil
+		}
+		if getAction.GetName() == "p2" {
+			return true, p2Rescheduled, nil
+		}
+		return true, nil, errors.NewNotFound(apiv1.Resource("pod"), "whatever")
+	})
+	fakeClient.Fake.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		createAction := action.(core.CreateAction)
+		if createAction == nil {
+			return false, nil, nil
+		}
+		eviction := createAction.GetObject().(*policyv1.Eviction)
+		if eviction == nil {
+			return false, nil, nil
+		}
+		deletedPods <- eviction.Name
+		return true, nil, nil
+	})
+	_, err := newTestDrainController(fakeClient, nil, 20, 5*time.Second, 0*time.Second, PodEvictionHeadroom).drain(n1, []*apiv1.Pod{p1, p2})
+	assert.NoError(t, err)
+	deleted := make([]string, 0)
+	deleted = append(deleted, getStringFromChan(deletedPods))
+	deleted = append(deleted, getStringFromChan(deletedPods))
+	sort.Strings(deleted)
+	assert.Equal(t, p1.Name, deleted[0])
+	assert.Equal(t, p2.Name, deleted[1])
+}
+
+func TestDrainNodeWithRetries(t *testing.T) {
+	deletedPods := make(chan string, 10)
+	// Simulate pdb of size 1 by making the 'eviction' goroutine:
+	// - read from (at first empty) channel
+	// - if it's empty, fail and write to it, then retry
+	// - succeed on successful read.
+	ticket := make(chan bool, 1)
+	fakeClient := &fake.Clientset{}
+
+	p1 := BuildTestPod("p1", 100, 0)
+	p2 := BuildTestPod("p2", 300, 0)
+	p3 := BuildTestPod("p3", 300, 0)
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Time{})
+
+	fakeClient.Fake.AddReactor("get", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewNotFound(apiv1.Resource("pod"), "whatever")
+	})
+	fakeClient.Fake.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		createAction := action.(core.CreateAction)
+		if createAction == nil {
+			return false, nil, nil
+		}
+		eviction := createAction.GetObject().(*policyv1.Eviction)
+		if eviction == nil {
+			return false, nil, nil
+		}
+		select {
+		case <-ticket:
+			deletedPods <- eviction.Name
+			return true, nil, nil
+		default:
+			select {
+			case ticket <- true:
+			default:
+			}
+			return true, nil, fmt.Errorf("too many concurrent evictions")
+		}
+	})
+	_, err := newTestDrainController(fakeClient, nil, 20, 5*time.Second, 0*time.Second, PodEvictionHeadroom).drain(n1, []*apiv1.Pod{p1, p2, p3})
+	assert.NoError(t, err)
+	deleted := make([]string, 0)
+	deleted = append(deleted, getStringFromChan(deletedPods))
+	deleted = append(deleted, getStringFromChan(deletedPods))
+	deleted = append(deleted, getStringFromChan(deletedPods))
+	sort.Strings(deleted)
+	assert.Equal(t, p1.Name, deleted[0])
+	assert.Equal(t, p2.Name, deleted[1])
+	assert.Equal(t, p3.Name, deleted[2])
+}
+
+func TestDrainNodeEvictionFailure(t *testing.T) {
+	fakeClient := &fake.Clientset{}
+
+	p1 := BuildTestPod("p1", 100, 0)
+	p2 := BuildTestPod("p2", 100, 0)
+	p3 := BuildTestPod("p3", 100, 0)
+	p4 := BuildTestPod("p4", 100, 0)
+	n1 := BuildTestNode("n1", 1000, 1000)
+	e2 := fmt.Errorf("eviction_error: p2")
+	e4 := fmt.Errorf("eviction_error: p4")
+	SetNodeReadyState(n1, true, time.Time{})
+
+	fakeClient.Fake.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		createAction := action.(core.CreateAction)
+		if createAction == nil {
+			return false, nil, nil
+		}
+		eviction := createAction.GetObject().(*policyv1.Eviction)
+		if eviction == nil {
+			return false, nil, nil
+		}
+
+		if eviction.Name == "p2" {
+			return true, nil, e2
+		}
+		if eviction.Name == "p4" {
+			return true, nil, e4
+		}
+		return true, nil, nil
+	})
+
+	evictionResults, err := newTestDrainController(fakeClient, nil, 20, 0*time.Second, 0*time.Second, PodEvictionHeadroom).drain(n1, []*apiv1.Pod{p1, p2, p3, p4})
+	assert.Error(t, err)
+	assert.Equal(t, 4, len(evictionResults))
+	assert.Equal(t, *p1, *evictionResults["p1"].Pod)
+	assert.Equal(t, *p2, *evictionResults["p2"].Pod)
+	assert.Equal(t, *p3, *evictionResults["p3"].Pod)
+	assert.Equal(t, *p4, *evictionResults["p4"].Pod)
+	assert.NoError(t, evictionResults["p1"].Err)
+	assert.Contains(t, evictionResults["p2"].Err.Error(), e2.Error())
+	assert.NoError(t, evictionResults["p3"].Err)
+	assert.Contains(t, evictionResults["p4"].Err.Error(), e4.Error())
+	assert.False(t, evictionResults["p1"].TimedOut)
+	assert.True(t, evictionResults["p2"].TimedOut)
+	assert.False(t, evictionResults["p3"].TimedOut)
+	assert.True(t, evictionResults["p4"].TimedOut)
+	assert.True(t, evictionResults["p1"].WasEvictionSuccessful())
+	assert.False(t, evictionResults["p2"].WasEvictionSuccessful())
+	assert.True(t, evictionResults["p3"].WasEvictionSuccessful())
+	assert.False(t, evictionResults["p4"].WasEvictionSuccessful())
+}
+
+func TestDrainNodeDisappearanceFailure(t *testing.T) {
+	fakeClient := &fake.Clientset{}
+
+	p1 := BuildTestPod("p1", 100, 0)
+	p2 := BuildTestPod("p2", 100, 0)
+	p3 := BuildTestPod("p3", 100, 0)
+	p4 := BuildTestPod("p4", 100, 0)
+	e2 := fmt.Errorf("disappearance_error: p2")
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Time{})
+
+	fakeClient.Fake.AddReactor("get", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		getAction := action.(core.GetAction)
+		if getAction == nil {
+			return false, nil, nil
+		}
+		if getAction.GetName() == "p2" {
+			return true, nil, e2
+		}
+		if getAction.GetName() == "p4" {
+			return true, nil, nil
+		}
+		return true, nil, errors.NewNotFound(apiv1.Resource("pod"), "whatever")
+	})
+	fakeClient.Fake.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, nil
+	})
+
+	evictionResults, err := newTestDrainController(fakeClient, nil, 0, 0*time.Second, 0*time.Second, 0*time.Second).drain(n1, []*apiv1.Pod{p1, p2, p3, p4})
+	assert.Error(t, err)
+	assert.Equal(t, 4, len(evictionResults))
+	assert.Equal(t, *p1, *evictionResults["p1"].Pod)
+	assert.Equal(t, *p2, *evictionResults["p2"].Pod)
+	assert.Equal(t, *p3, *evictionResults["p3"].Pod)
+	assert.Equal(t, *p4, *evictionResults["p4"].Pod)
+	assert.NoError(t, evictionResults["p1"].Err)
+	assert.Contains(t, evictionResults["p2"].Err.Error(), e2.Error())
+	assert.NoError(t, evictionResults["p3"].Err)
+	assert.NoError(t, evictionResults["p4"].Err)
+	assert.False(t, evictionResults["p1"].TimedOut)
+	assert.True(t, evictionResults["p2"].TimedOut)
+	assert.False(t, evictionResults["p3"].TimedOut)
+	assert.True(t, evictionResults["p4"].TimedOut)
+	assert.True(t, evictionResults["p1"].WasEvictionSuccessful())
+	assert.False(t, evictionResults["p2"].WasEvictionSuccessful())
+	assert.True(t, evictionResults["p3"].WasEvictionSuccessful())
+	assert.False(t, evictionResults["p4"].WasEvictionSuccessful())
+}
+
+func TestDrainNodeEvictsPodsWithoutPdbFirst(t *testing.T) {
+	var lock sync.Mutex
+	evicted := make([]string, 0)
+	fakeClient := &fake.Clientset{}
+
+	p1 := BuildTestPod("p1", 100, 0)
+	p1.Labels = map[string]string{"app": "protected"}
+	p2 := BuildTestPod("p2", 100, 0)
+	p3 := BuildTestPod("p3", 100, 0)
+	p3.Labels = map[string]string{"app": "protected"}
+	p3.Namespace = "other"
+	pdb := buildTestPdb("pdb", map[string]string{"app": "protected"})
+	n1 := BuildTestNode("n1", 1000, 1000)
+
+	fakeClient.Fake.AddReactor("get", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewNotFound(apiv1.Resource("pod"), "whatever")
+	})
+	fakeClient.Fake.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		eviction := action.(core.CreateAction).GetObject().(*policyv1.Eviction)
+		lock.Lock()
+		defer lock.Unlock()
+		evicted = append(evicted, eviction.Name)
+		return true, nil, nil
+	})
+
+	evictionResults, err := newTestDrainController(fakeClient, []*policyv1.PodDisruptionBudget{pdb}, 20, 5*time.Second, 0*time.Second, PodEvictionHeadroom).drain(n1, []*apiv1.Pod{p1, p2, p3})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(evictionResults))
+	assert.Equal(t, 3, len(evicted))
+	// p3 is in a different namespace than the PDB.
+	sort.Strings(evicted[:2])
+	assert.Equal(t, []string{"p2", "p3", "p1"}, evicted)
+}
+
+func TestDrainNodeRetriesPdbBlockedEvictions(t *testing.T) {
+	var lock sync.Mutex
+	attempts := make(map[string]int)
+	fakeClient := &fake.Clientset{}
+
+	p1 := BuildTestPod("p1", 100, 0)
+	p1.Labels = map[string]string{"app": "protected"}
+	p2 := BuildTestPod("p2", 100, 0)
+	pdb := buildTestPdb("pdb", map[string]string{"app": "protected"})
+	n1 := BuildTestNode("n1", 1000, 1000)
+
+	fakeClient.Fake.AddReactor("get", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewNotFound(apiv1.Resource("pod"), "whatever")
+	})
+	fakeClient.Fake.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		eviction := action.(core.CreateAction).GetObject().(*policyv1.Eviction)
+		lock.Lock()
+		defer lock.Unlock()
+		attempts[eviction.Name]++
+		if eviction.Name == "p1" && attempts[eviction.Name] < 3 {
+			return true, nil, errors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+		}
+		return true, nil, nil
+	})
+
+	// Other evictions wouldn't be retried at all, PDB-blocked ones are retried for up to maxGracefulTerminationSec.
+	evictionResults, err := newTestDrainController(fakeClient, []*policyv1.PodDisruptionBudget{pdb}, 5, 0*time.Second, 10*time.Millisecond, PodEvictionHeadroom).drain(n1, []*apiv1.Pod{p1, p2})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts["p1"])
+	assert.Equal(t, 1, attempts["p2"])
+	assert.True(t, evictionResults["p1"].WasEvictionSuccessful())
+	assert.True(t, evictionResults["p2"].WasEvictionSuccessful())
+}
+
+func TestDrainNodePdbBlockedEvictionTimeout(t *testing.T) {
+	fakeClient := &fake.Clientset{}
+
+	p1 := BuildTestPod("p1", 100, 0)
+	p1.Labels = map[string]string{"app": "protected"}
+	p2 := BuildTestPod("p2", 100, 0)
+	pdb := buildTestPdb("pdb", map[string]string{"app": "protected"})
+	n1 := BuildTestNode("n1", 1000, 1000)
+	pdbErr := errors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+
+	fakeClient.Fake.AddReactor("get", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewNotFound(apiv1.Resource("pod"), "whatever")
+	})
+	fakeClient.Fake.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		eviction := action.(core.CreateAction).GetObject().(*policyv1.Eviction)
+		if eviction.Name == "p1" {
+			return true, nil, pdbErr
+		}
+		return true, nil, nil
+	})
+
+	start := time.Now()
+	evictionResults, err := newTestDrainController(fakeClient, []*policyv1.PodDisruptionBudget{pdb}, 1, 0*time.Second, 100*time.Millisecond, PodEvictionHeadroom).drain(n1, []*apiv1.Pod{p1, p2})
+	assert.Error(t, err)
+	assert.True(t, time.Now().Sub(start) >= time.Second)
+	assert.True(t, evictionResults["p1"].TimedOut)
+	assert.Contains(t, evictionResults["p1"].Err.Error(), pdbErr.Error())
+	assert.True(t, evictionResults["p2"].WasEvictionSuccessful())
+}
+
+func TestDrainNodeRespectsTerminationGracePeriod(t *testing.T) {
+	var lock sync.Mutex
+	gracePeriods := make(map[string]int64)
+	fakeClient := &fake.Clientset{}
+
+	p1 := BuildTestPod("p1", 100, 0)
+	p1.Spec.NodeName = "n1"
+	p1.Spec.TerminationGracePeriodSeconds = new(int64)
+	p2 := BuildTestPod("p2", 100, 0)
+	p2.Spec.TerminationGracePeriodSeconds = new(int64)
+	*p2.Spec.TerminationGracePeriodSeconds = 120
+	p3 := BuildTestPod("p3", 100, 0)
+	n1 := BuildTestNode("n1", 1000, 1000)
+
+	fakeClient.Fake.AddReactor("get", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		// p1 never terminates.
+		if action.(core.GetAction).GetName() == "p1" {
+			return true, p1, nil
+		}
+		return true, nil, errors.NewNotFound(apiv1.Resource("pod"), "whatever")
+	})
+	fakeClient.Fake.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		eviction := action.(core.CreateAction).GetObject().(*policyv1.Eviction)
+		lock.Lock()
+		defer lock.Unlock()
+		gracePeriods[eviction.Name] = *eviction.DeleteOptions.GracePeriodSeconds
+		return true, nil, nil
+	})
+
+	// p1 is given no time to terminate, so the drain fails right away despite the long maximum.
+	evictionResults, err := newTestDrainController(fakeClient, nil, 60, 0*time.Second, 0*time.Second, 0*time.Second).drain(n1, []*apiv1.Pod{p1, p2, p3})
+	assert.Error(t, err)
+	assert.Equal(t, map[string]int64{"p1": 0, "p2": 60, "p3": apiv1.DefaultTerminationGracePeriodSeconds}, gracePeriods)
+	assert.True(t, evictionResults["p1"].TimedOut)
+	assert.NoError(t, evictionResults["p1"].Err)
+	assert.True(t, evictionResults["p2"].WasEvictionSuccessful())
+	assert.True(t, evictionResults["p3"].WasEvictionSuccessful())
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"encoding/json"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/dryrun"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/metrics"
+
+	"k8s.io/klog"
+)
+
+// dryRunDecision is a single entry of the structured dry-run log.
+type dryRunDecision struct {
+	Time      time.Time `json:"time"`
+	Action    string    `json:"action"`
+	NodeGroup string    `json:"nodeGroup"`
+	Delta     int       `json:"delta,omitempty"`
+	Nodes     []string  `json:"nodes,omitempty"`
+}
+
+// reportDryRunActions emits the cloud provider calls recorded in dry-run mode as events,
+// metrics and JSON log entries, which can be collected to evaluate CA configuration.
+func reportDryRunActions(context *context.AutoscalingContext) {
+	provider, ok := context.CloudProvider.(*dryrun.CloudProvider)
+	if !ok {
+		klog.Errorf("Dry-run mode enabled, but cloud provider %s doesn't record actions", context.CloudProvider.Name())
+		return
+	}
+	for _, action := range provider.TakeActions() {
+		decision := dryRunDecision{
+			Time:      action.Time,
+			Action:    string(action.Type),
+			NodeGroup: action.NodeGroup,
+			Delta:     action.Delta,
+		}
+		for _, node := range action.Nodes {
+			decision.Nodes = append(decision.Nodes, node.Name)
+		}
+		if entry, err := json.Marshal(decision); err != nil {
+			klog.Errorf("Failed to encode dry-run decision %+v: %v", decision, err)
+		} else {
+			klog.V(0).Infof("Dry-run decision: %s", entry)
+		}
+
+		context.LogRecorder.Eventf(apiv1.EventTypeNormal, "DryRun", "Dry-run: skipped %s of node group %s (delta: %d, nodes: %v)",
+			action.Type, action.NodeGroup, action.Delta, decision.Nodes)
+		for _, node := range action.Nodes {
+			context.Recorder.Eventf(node, apiv1.EventTypeNormal, "DryRun", "node would be removed by cluster autoscaler")
+		}
+
+		nodesCount := action.Delta
+		if nodesCount < 0 {
+			nodesCount = -nodesCount
+		}
+		metrics.RegisterDryRunAction(string(action.Type), nodesCount)
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/dryrun"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/utils"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/estimator"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	kube_record "k8s.io/client-go/tools/record"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRunScaleDown(t *testing.T) {
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Time{})
+	n2 := BuildTestNode("n2", 1000, 1000)
+	SetNodeReadyState(n2, true, time.Time{})
+	p1 := BuildTestPod("p1", 100, 0)
+	p1.Spec.NodeName = "n1"
+
+	provider := testprovider.NewTestCloudProvider(nil, func(nodeGroup string, node string) error {
+		t.Fatalf("Unexpected deletion of %s", node)
+		return nil
+	})
+	provider.AddNodeGroup("ng1", 1, 10, 2)
+	provider.AddNode("ng1", n1)
+	provider.AddNode("ng1", n2)
+	dryRunProvider := dryrun.NewCloudProvider(provider)
+
+	fakeClient := &fake.Clientset{}
+	fakeClient.Fake.AddReactor("*", "*", func(action core.Action) (bool, runtime.Object, error) {
+		t.Fatalf("Unexpected %s of %s in dry-run mode", action.GetVerb(), action.GetResource().Resource)
+		return true, nil, nil
+	})
+
+	options := config.AutoscalingOptions{
+		MaxGracefulTerminationSec: 60,
+		MaxBulkSoftTaintCount:     10,
+		MaxBulkSoftTaintTime:      3 * time.Second,
+		DryRun:                    true,
+	}
+	context := NewScaleTestAutoscalingContext(options, fakeClient, nil, dryRunProvider, nil)
+	clusterStateRegistry := clusterstate.NewClusterStateRegistry(dryRunProvider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
+	sd := NewScaleDown(&context, clusterStateRegistry)
+
+	sd.unneededNodes["n1"] = time.Now()
+	assert.Empty(t, sd.SoftTaintUnneededNodes([]*apiv1.Node{n1, n2}))
+
+	result := sd.deleteNode(n1, []*apiv1.Pod{p1}, nil)
+	assert.NoError(t, result.Err)
+	assert.Equal(t, status.NodeDeleteOk, result.ResultType)
+
+	// Skip the event emitted when the node was "removed" from the cloud provider.
+	recorder := context.Recorder.(*kube_record.FakeRecorder)
+	<-recorder.Events
+	reportDryRunActions(&context)
+	select {
+	case event := <-recorder.Events:
+		assert.Equal(t, "Normal DryRun node would be removed by cluster autoscaler", event)
+	default:
+		t.Errorf("No dry-run event for n1")
+	}
+	assert.Empty(t, dryRunProvider.TakeActions())
+}
+
+func TestStaticAutoscalerRunOnceInDryRun(t *testing.T) {
+	now := time.Now()
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, now)
+	p1 := BuildTestPod("p1", 600, 100)
+	p1.Spec.NodeName = "n1"
+	p2 := BuildTestPod("p2", 600, 100)
+
+	provider := testprovider.NewTestCloudProvider(func(nodeGroup string, delta int) error {
+		t.Fatalf("Unexpected scale-up of %s", nodeGroup)
+		return nil
+	}, func(nodeGroup string, node string) error {
+		t.Fatalf("Unexpected deletion of %s", node)
+		return nil
+	})
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+	provider.AddNode("ng1", n1)
+	dryRunProvider := dryrun.NewCloudProvider(provider)
+
+	options := config.AutoscalingOptions{
+		EstimatorName:                       estimator.BinpackingEstimatorName,
+		ScaleDownUtilizationThreshold:       0.5,
+		MaxNodesTotal:                       10,
+		MaxCoresTotal:                       10,
+		MaxMemoryTotal:                      100000,
+		FilterOutSchedulablePodsUsesPacking: true,
+		DryRun:                              true,
+	}
+	processorCallbacks := newStaticAutoscalerProcessorCallbacks()
+	fakeClient := fake.NewSimpleClientset()
+	context := NewScaleTestAutoscalingContext(options, fakeClient, nil, dryRunProvider, processorCallbacks)
+	// Dry-run decisions are recorded as events on the status config map.
+	recorder := kube_record.NewFakeRecorder(100)
+	logRecorder, err := utils.NewStatusMapRecorder(fakeClient, "kube-system", recorder, true)
+	assert.NoError(t, err)
+	context.Recorder = recorder
+	context.LogRecorder = logRecorder
+	context.ListerRegistry = kube_util.NewListerRegistry(
+		kube_util.NewTestNodeLister([]*apiv1.Node{n1}), kube_util.NewTestNodeLister([]*apiv1.Node{n1}),
+		kube_util.NewTestPodLister([]*apiv1.Pod{p1}), kube_util.NewTestPodLister([]*apiv1.Pod{p2}),
+		kube_util.NewTestPodDisruptionBudgetLister(nil), &daemonSetListerMock{},
+		nil, nil, nil, nil)
+	daemonSetLister := context.ListerRegistry.DaemonSetLister().(*daemonSetListerMock)
+	daemonSetLister.On("List", labels.Everything()).Return([]*appsv1.DaemonSet{}, nil)
+
+	clusterStateConfig := clusterstate.ClusterStateRegistryConfig{
+		OkTotalUnreadyCount:  1,
+		MaxNodeProvisionTime: 15 * time.Minute,
+	}
+	clusterState := clusterstate.NewClusterStateRegistry(dryRunProvider, clusterStateConfig, context.LogRecorder, newBackoff())
+	autoscaler := &StaticAutoscaler{
+		AutoscalingContext:    &context,
+		clusterStateRegistry:  clusterState,
+		lastScaleUpTime:       now,
+		lastScaleDownFailTime: now,
+		scaleDown:             NewScaleDown(&context, clusterState),
+		processors:            NewTestProcessors(),
+		processorCallbacks:    processorCallbacks,
+		initialized:           true,
+	}
+
+	// The recorded scale-up is seen as in progress by later loops, so it isn't repeated for p2.
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, autoscaler.RunOnce(now.Add(time.Duration(i)*time.Minute)))
+	}
+	increases := 0
+	for len(recorder.Events) > 0 {
+		if strings.Contains(<-recorder.Events, "skipped IncreaseSize of node group ng1") {
+			increases++
+		}
+	}
+	assert.Equal(t, 1, increases)
+	size, err := dryRunProvider.NodeGroups()[0].TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, size)
+}
@@ -25,7 +25,6 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/processors/pods"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/glogx"
-	schedulerutil "k8s.io/autoscaler/cluster-autoscaler/utils/scheduler"
 
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/klog"
@@ -63,12 +62,16 @@ func (filterOutSchedulablePodListProcessor) Process(
 	klog.V(4).Infof("Filtering out schedulables")
 	filterOutSchedulableStart := time.Now()
 	var unschedulablePodsToHelp []*apiv1.Pod
+	var err error
 	if context.FilterOutSchedulablePodsUsesPacking {
-		unschedulablePodsToHelp = filterOutSchedulableByPacking(unschedulablePods, readyNodes, allScheduledPods,
-			context.PredicateChecker, context.ExpendablePodsPriorityCutoff)
+		unschedulablePodsToHelp, err = filterOutSchedulableByPacking(unschedulablePods, readyNodes, context.ClusterSnapshot,
+			context.PredicateChecker)
 	} else {
-		unschedulablePodsToHelp = filterOutSchedulableSimple(unschedulablePods, readyNodes, allScheduledPods,
-			context.PredicateChecker, context.ExpendablePodsPriorityCutoff)
+		unschedulablePodsToHelp, err = filterOutSchedulableSimple(unschedulablePods, readyNodes, context.ClusterSnapshot,
+			context.PredicateChecker)
+	}
+	if err != nil {
+		return nil, nil, err
 	}
 
 	metrics.UpdateDurationFromStart(metrics.FilterOutSchedulable, filterOutSchedulableStart)
@@ -88,11 +91,15 @@ func (filterOutSchedulablePodListProcessor) CleanUp() {
 // filterOutSchedulableByPacking checks whether pods from <unschedulableCandidates> marked as unschedulable
 // can be scheduled on free capacity on existing nodes by trying to pack the pods. It tries to pack the higher priority
 // pods first. It takes into account pods that are bound to node and will be scheduled after lower priority pod preemption.
-func filterOutSchedulableByPacking(unschedulableCandidates []*apiv1.Pod, nodes []*apiv1.Node, allScheduled []*apiv1.Pod,
-	predicateChecker *simulator.PredicateChecker, expendablePodsPriorityCutoff int) []*apiv1.Pod {
+// Only the given nodes of the cluster snapshot are considered. The snapshot is left unchanged.
+func filterOutSchedulableByPacking(unschedulableCandidates []*apiv1.Pod, nodes []*apiv1.Node,
+	clusterSnapshot simulator.ClusterSnapshot, predicateChecker *simulator.PredicateChecker) ([]*apiv1.Pod, error) {
 	var unschedulablePods []*apiv1.Pod
-	nonExpendableScheduled := filterOutExpendablePods(allScheduled, expendablePodsPriorityCutoff)
-	nodeNameToNodeInfo := schedulerutil.CreateNodeNameToInfoMap(nonExpendableScheduled, nodes)
+	revert, err := forkSnapshotWithNodes(clusterSnapshot, nodes)
+	if err != nil {
+		return nil, err
+	}
+	defer revert()
 	loggingQuota := glogx.PodsLoggingQuota()
 
 	sort.Slice(unschedulableCandidates, func(i, j int) bool {
@@ -100,27 +107,33 @@ func filterOutSchedulableByPacking(unschedulableCandidates []*apiv1.Pod, nodes [
 	})
 
 	for _, pod := range unschedulableCandidates {
-		nodeName, err := predicateChecker.FitsAny(pod, nodeNameToNodeInfo)
+		nodeName, err := predicateChecker.FitsAny(pod, clusterSnapshot.NodeInfos())
 		if err != nil {
 			unschedulablePods = append(unschedulablePods, pod)
 		} else {
 			glogx.V(4).UpTo(loggingQuota).Infof("Pod %s marked as unschedulable can be scheduled on %s. Ignoring in scale up.", pod.Name, nodeName)
-			nodeNameToNodeInfo[nodeName] = schedulerutil.NodeWithPod(nodeNameToNodeInfo[nodeName], pod)
+			if err := clusterSnapshot.AddPod(pod, nodeName); err != nil {
+				return nil, err
+			}
 		}
 	}
 
 	glogx.V(4).Over(loggingQuota).Infof("%v other pods marked as unschedulable can be scheduled.", -loggingQuota.Left())
-	return unschedulablePods
+	return unschedulablePods, nil
 }
 
 // filterOutSchedulableSimple checks whether pods from <unschedulableCandidates> marked as unschedulable
 // by Scheduler actually can't be scheduled on any node and filter out the ones that can.
 // It takes into account pods that are bound to node and will be scheduled after lower priority pod preemption.
-func filterOutSchedulableSimple(unschedulableCandidates []*apiv1.Pod, nodes []*apiv1.Node, allScheduled []*apiv1.Pod,
-	predicateChecker *simulator.PredicateChecker, expendablePodsPriorityCutoff int) []*apiv1.Pod {
+// Only the given nodes of the cluster snapshot are considered. The snapshot is left unchanged.
+func filterOutSchedulableSimple(unschedulableCandidates []*apiv1.Pod, nodes []*apiv1.Node,
+	clusterSnapshot simulator.ClusterSnapshot, predicateChecker *simulator.PredicateChecker) ([]*apiv1.Pod, error) {
 	var unschedulablePods []*apiv1.Pod
-	nonExpendableScheduled := filterOutExpendablePods(allScheduled, expendablePodsPriorityCutoff)
-	nodeNameToNodeInfo := schedulerutil.CreateNodeNameToInfoMap(nonExpendableScheduled, nodes)
+	revert, err := forkSnapshotWithNodes(clusterSnapshot, nodes)
+	if err != nil {
+		return nil, err
+	}
+	defer revert()
 	podSchedulable := make(podSchedulableMap)
 	loggingQuota := glogx.PodsLoggingQuota()
 
@@ -137,7 +150,7 @@ func filterOutSchedulableSimple(unschedulableCandidates []*apiv1.Pod, nodes []*a
 		}
 
 		// Not found in cache, have to run the predicates.
-		nodeName, err := predicateChecker.FitsAny(pod, nodeNameToNodeInfo)
+		nodeName, err := predicateChecker.FitsAny(pod, clusterSnapshot.NodeInfos())
 		// err returned from FitsAny isn't a PredicateError.
 		// Hello, ugly hack. I wish you weren't here.
 		var predicateError *simulator.PredicateError
@@ -151,5 +164,35 @@ func filterOutSchedulableSimple(unschedulableCandidates []*apiv1.Pod, nodes []*a
 	}
 
 	glogx.V(4).Over(loggingQuota).Infof("%v other pods marked as unschedulable can be scheduled.", -loggingQuota.Left())
-	return unschedulablePods
+	return unschedulablePods, nil
+}
+
+// forkSnapshotWithNodes forks the cluster snapshot and removes all nodes other than the given ones from
+// the fork. It returns a function reverting the fork.
+func forkSnapshotWithNodes(clusterSnapshot simulator.ClusterSnapshot, nodes []*apiv1.Node) (func(), error) {
+	if err := clusterSnapshot.Fork(); err != nil {
+		return nil, err
+	}
+	revert := func() {
+		if err := clusterSnapshot.Revert(); err != nil {
+			klog.Errorf("Failed to revert cluster snapshot: %v", err)
+		}
+	}
+	keep := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		keep[node.Name] = true
+	}
+	var toRemove []string
+	for nodeName := range clusterSnapshot.NodeInfos() {
+		if !keep[nodeName] {
+			toRemove = append(toRemove, nodeName)
+		}
+	}
+	for _, nodeName := range toRemove {
+		if err := clusterSnapshot.RemoveNode(nodeName); err != nil {
+			revert()
+			return nil, err
+		}
+	}
+	return revert, nil
 }
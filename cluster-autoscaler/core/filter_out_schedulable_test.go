@@ -81,21 +81,32 @@ func TestFilterOutSchedulableByPacking(t *testing.T) {
 	SetNodeReadyState(node, true, time.Time{})
 
 	predicateChecker := simulator.NewTestPredicateChecker()
+	clusterSnapshot := simulator.NewBasicClusterSnapshot()
+	var err error
 
-	res := filterOutSchedulableByPacking(unschedulablePods, []*apiv1.Node{node}, []*apiv1.Pod{scheduledPod1, scheduledPod3}, predicateChecker, 10)
+	err = simulator.InitializeClusterSnapshot(clusterSnapshot, []*apiv1.Node{node}, filterOutExpendablePods([]*apiv1.Pod{scheduledPod1, scheduledPod3}, 10))
+	assert.NoError(t, err)
+	res, err := filterOutSchedulableByPacking(unschedulablePods, []*apiv1.Node{node}, clusterSnapshot, predicateChecker)
+	assert.NoError(t, err)
 	assert.Equal(t, 3, len(res))
 	assert.Equal(t, p2_1, res[0])
 	assert.Equal(t, p2_2, res[1])
 	assert.Equal(t, p3_2, res[2])
 
-	res2 := filterOutSchedulableByPacking(unschedulablePods, []*apiv1.Node{node}, []*apiv1.Pod{scheduledPod1, scheduledPod2, scheduledPod3}, predicateChecker, 10)
+	err = simulator.InitializeClusterSnapshot(clusterSnapshot, []*apiv1.Node{node}, filterOutExpendablePods([]*apiv1.Pod{scheduledPod1, scheduledPod2, scheduledPod3}, 10))
+	assert.NoError(t, err)
+	res2, err := filterOutSchedulableByPacking(unschedulablePods, []*apiv1.Node{node}, clusterSnapshot, predicateChecker)
+	assert.NoError(t, err)
 	assert.Equal(t, 4, len(res2))
 	assert.Equal(t, p1, res2[0])
 	assert.Equal(t, p2_1, res2[1])
 	assert.Equal(t, p2_2, res2[2])
 	assert.Equal(t, p3_2, res2[3])
 
-	res4 := filterOutSchedulableByPacking(append(unschedulablePods, p4), []*apiv1.Node{node}, []*apiv1.Pod{scheduledPod1, scheduledPod3}, predicateChecker, 10)
+	err = simulator.InitializeClusterSnapshot(clusterSnapshot, []*apiv1.Node{node}, filterOutExpendablePods([]*apiv1.Pod{scheduledPod1, scheduledPod3}, 10))
+	assert.NoError(t, err)
+	res4, err := filterOutSchedulableByPacking(append(unschedulablePods, p4), []*apiv1.Node{node}, clusterSnapshot, predicateChecker)
+	assert.NoError(t, err)
 	assert.Equal(t, 5, len(res4))
 	assert.Equal(t, p1, res4[0])
 	assert.Equal(t, p2_1, res4[1])
@@ -152,13 +163,21 @@ func TestFilterOutSchedulableSimple(t *testing.T) {
 	SetNodeReadyState(node, true, time.Time{})
 
 	predicateChecker := simulator.NewTestPredicateChecker()
+	clusterSnapshot := simulator.NewBasicClusterSnapshot()
+	var err error
 
-	res := filterOutSchedulableSimple(unschedulablePods, []*apiv1.Node{node}, []*apiv1.Pod{scheduledPod1, scheduledPod3}, predicateChecker, 10)
+	err = simulator.InitializeClusterSnapshot(clusterSnapshot, []*apiv1.Node{node}, filterOutExpendablePods([]*apiv1.Pod{scheduledPod1, scheduledPod3}, 10))
+	assert.NoError(t, err)
+	res, err := filterOutSchedulableSimple(unschedulablePods, []*apiv1.Node{node}, clusterSnapshot, predicateChecker)
+	assert.NoError(t, err)
 	assert.Equal(t, 2, len(res))
 	assert.Equal(t, p2_1, res[0])
 	assert.Equal(t, p2_2, res[1])
 
-	res2 := filterOutSchedulableSimple(unschedulablePods, []*apiv1.Node{node}, []*apiv1.Pod{scheduledPod1, scheduledPod2, scheduledPod3}, predicateChecker, 10)
+	err = simulator.InitializeClusterSnapshot(clusterSnapshot, []*apiv1.Node{node}, filterOutExpendablePods([]*apiv1.Pod{scheduledPod1, scheduledPod2, scheduledPod3}, 10))
+	assert.NoError(t, err)
+	res2, err := filterOutSchedulableSimple(unschedulablePods, []*apiv1.Node{node}, clusterSnapshot, predicateChecker)
+	assert.NoError(t, err)
 	assert.Equal(t, 3, len(res2))
 	assert.Equal(t, p1, res2[0])
 	assert.Equal(t, p2_1, res2[1])
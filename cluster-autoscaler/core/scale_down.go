@@ -17,7 +17,6 @@ limitations under the License.
 package core
 
 import (
-	"fmt"
 	"math"
 	"reflect"
 	"strings"
@@ -26,17 +25,16 @@ import (
 
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/context"
 	"k8s.io/autoscaler/cluster-autoscaler/metrics"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/deletetaint"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
 	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
-	scheduler_util "k8s.io/autoscaler/cluster-autoscaler/utils/scheduler"
 
 	apiv1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1beta1"
-	kube_errors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kube_client "k8s.io/client-go/kubernetes"
 	kube_record "k8s.io/client-go/tools/record"
@@ -57,7 +55,8 @@ const (
 	MaxKubernetesEmptyNodeDeletionTime = 3 * time.Minute
 	// MaxCloudProviderNodeDeletionTime is the maximum time needed by cloud provider to delete a node.
 	MaxCloudProviderNodeDeletionTime = 5 * time.Minute
-	// MaxPodEvictionTime is the maximum time CA tries to evict a pod before giving up.
+	// MaxPodEvictionTime is the maximum time CA tries to evict a pod before giving up. Evictions blocked
+	// by a PodDisruptionBudget are retried for up to MaxGracefulTerminationSec longer.
 	MaxPodEvictionTime = 2 * time.Minute
 	// EvictionRetryTime is the time after CA retries failed pod eviction.
 	EvictionRetryTime = 10 * time.Second
@@ -66,37 +65,52 @@ const (
 	PodEvictionHeadroom = 30 * time.Second
 )
 
-// NodeDeleteStatus tells whether a node is being deleted right now.
+// NodeDeleteStatus tells which nodes are being deleted right now.
 type NodeDeleteStatus struct {
 	sync.Mutex
-	deleteInProgress bool
+	deletionsInProgress map[string]bool
 	// A map of node delete results by node name. It's being constantly emptied into ScaleDownStatus
 	// objects in order to notify the ScaleDownStatusProcessor that the node drain has ended or that
 	// an error occurred during the deletion process.
 	nodeDeleteResults map[string]status.NodeDeleteResult
 }
 
+func newNodeDeleteStatus() *NodeDeleteStatus {
+	return &NodeDeleteStatus{
+		deletionsInProgress: make(map[string]bool),
+		nodeDeleteResults:   make(map[string]status.NodeDeleteResult),
+	}
+}
+
 // Get current time. Proxy for unit tests.
 var now func() time.Time = time.Now
 
-// IsDeleteInProgress returns true if a node is being deleted.
+// IsDeleteInProgress returns true if any node is being deleted.
 func (n *NodeDeleteStatus) IsDeleteInProgress() bool {
 	n.Lock()
 	defer n.Unlock()
-	return n.deleteInProgress
+	return len(n.deletionsInProgress) > 0
+}
+
+// DeletionsInProgress returns names of the nodes being deleted.
+func (n *NodeDeleteStatus) DeletionsInProgress() []string {
+	n.Lock()
+	defer n.Unlock()
+	return sets.StringKeySet(n.deletionsInProgress).List()
 }
 
-// SetDeleteInProgress sets deletion process status
-func (n *NodeDeleteStatus) SetDeleteInProgress(status bool) {
+// StartDeletion marks the node as being deleted.
+func (n *NodeDeleteStatus) StartDeletion(nodeName string) {
 	n.Lock()
 	defer n.Unlock()
-	n.deleteInProgress = status
+	n.deletionsInProgress[nodeName] = true
 }
 
-// AddNodeDeleteResult adds a node delete result to the result map.
-func (n *NodeDeleteStatus) AddNodeDeleteResult(nodeName string, result status.NodeDeleteResult) {
+// FinishDeletion marks the end of the node deletion and records its result.
+func (n *NodeDeleteStatus) FinishDeletion(nodeName string, result status.NodeDeleteResult) {
 	n.Lock()
 	defer n.Unlock()
+	delete(n.deletionsInProgress, nodeName)
 	n.nodeDeleteResults[nodeName] = result
 }
 
@@ -307,25 +321,40 @@ type ScaleDown struct {
 	clusterStateRegistry *clusterstate.ClusterStateRegistry
 	unneededNodes        map[string]time.Time
 	unneededNodesList    []*apiv1.Node
-	unremovableNodes     map[string]time.Time
-	podLocationHints     map[string]string
-	nodeUtilizationMap   map[string]simulator.UtilizationInfo
-	usageTracker         *simulator.UsageTracker
-	nodeDeleteStatus     *NodeDeleteStatus
+	// unremovableNodes contains nodes found unremovable in simulation, with the time at which
+	// they should be checked again and the reason why they couldn't be removed.
+	unremovableNodes map[string]*unremovableNodeRecheck
+	// unremovableNodeReasons contains reasons why nodes can't be removed, found in the current loop.
+	unremovableNodeReasons map[string]*simulator.UnremovableNode
+	// unremovableNodeEvents contains the last reason reported in an event for each node.
+	unremovableNodeEvents map[string]string
+	podLocationHints      map[string]string
+	nodeUtilizationMap    map[string]simulator.UtilizationInfo
+	usageTracker          *simulator.UsageTracker
+	nodeDeleteStatus      *NodeDeleteStatus
+}
+
+// unremovableNodeRecheck holds the reason why a node was found unremovable in simulation and the time
+// at which it should be checked again.
+type unremovableNodeRecheck struct {
+	recheckTime time.Time
+	unremovable *simulator.UnremovableNode
 }
 
 // NewScaleDown builds new ScaleDown object.
 func NewScaleDown(context *context.AutoscalingContext, clusterStateRegistry *clusterstate.ClusterStateRegistry) *ScaleDown {
 	return &ScaleDown{
-		context:              context,
-		clusterStateRegistry: clusterStateRegistry,
-		unneededNodes:        make(map[string]time.Time),
-		unremovableNodes:     make(map[string]time.Time),
-		podLocationHints:     make(map[string]string),
-		nodeUtilizationMap:   make(map[string]simulator.UtilizationInfo),
-		usageTracker:         simulator.NewUsageTracker(),
-		unneededNodesList:    make([]*apiv1.Node, 0),
-		nodeDeleteStatus:     &NodeDeleteStatus{nodeDeleteResults: make(map[string]status.NodeDeleteResult)},
+		context:                context,
+		clusterStateRegistry:   clusterStateRegistry,
+		unneededNodes:          make(map[string]time.Time),
+		unremovableNodes:       make(map[string]*unremovableNodeRecheck),
+		unremovableNodeReasons: make(map[string]*simulator.UnremovableNode),
+		unremovableNodeEvents:  make(map[string]string),
+		podLocationHints:       make(map[string]string),
+		nodeUtilizationMap:     make(map[string]simulator.UtilizationInfo),
+		usageTracker:           simulator.NewUsageTracker(),
+		unneededNodesList:      make([]*apiv1.Node, 0),
+		nodeDeleteStatus:       newNodeDeleteStatus(),
 	}
 }
 
@@ -357,17 +386,19 @@ func (sd *ScaleDown) UpdateUnneededNodes(
 	pdbs []*policyv1.PodDisruptionBudget) errors.AutoscalerError {
 
 	currentlyUnneededNodes := make([]*apiv1.Node, 0)
-	// Only scheduled non expendable pods and pods waiting for lower priority pods preemption can prevent node delete.
-	nonExpendablePods := filterOutExpendablePods(pods, sd.context.ExpendablePodsPriorityCutoff)
-	nodeNameToNodeInfo := scheduler_util.CreateNodeNameToInfoMap(nonExpendablePods, nodes)
 	utilizationMap := make(map[string]simulator.UtilizationInfo)
 
 	sd.updateUnremovableNodes(nodes)
+	sd.unremovableNodeReasons = make(map[string]*simulator.UnremovableNode)
 	// Filter out nodes that were recently checked
 	filteredNodesToCheck := make([]*apiv1.Node, 0)
 	for _, node := range nodesToCheck {
-		if unremovableTimestamp, found := sd.unremovableNodes[node.Name]; found {
-			if unremovableTimestamp.After(timestamp) {
+		if recheck, found := sd.unremovableNodes[node.Name]; found {
+			if recheck.recheckTime.After(timestamp) {
+				// Keep reporting the reason found in the last simulation until the node is checked again.
+				unremovable := *recheck.unremovable
+				unremovable.Node = node
+				sd.unremovableNodeReasons[node.Name] = &unremovable
 				continue
 			}
 			delete(sd.unremovableNodes, node.Name)
@@ -394,12 +425,30 @@ func (sd *ScaleDown) UpdateUnneededNodes(
 		// Skip nodes marked with no scale down annotation
 		if hasNoScaleDownAnnotation(node) {
 			klog.V(1).Infof("Skipping %s from delete consideration - the node is marked as no scale down", node.Name)
+			sd.addUnremovableNode(node, simulator.ScaleDownDisabledAnnotation)
+			continue
+		}
+
+		nodeGroup, err := sd.context.CloudProvider.NodeGroupForNode(node)
+		if err != nil {
+			klog.Warningf("Failed to get node group for %s, using default autoscaling options: %v", node.Name, err)
+			nodeGroup = nil
+		}
+		nodeGroupOptions := sd.getNodeGroupOptions(nodeGroup)
+
+		// Skip nodes from node groups with scale down disabled
+		if nodeGroupOptions.ScaleDownDisabled {
+			klog.V(1).Infof("Skipping %s from delete consideration - scale down is disabled for its node group", node.Name)
+			sd.addUnremovableNode(node, simulator.NodeGroupScaleDownDisabled)
 			continue
 		}
 
-		nodeInfo, found := nodeNameToNodeInfo[node.Name]
+		// Only scheduled non expendable pods and pods waiting for lower priority pods preemption
+		// are in the cluster snapshot, so only they can prevent node delete.
+		nodeInfo, found := sd.context.ClusterSnapshot.GetNodeInfo(node.Name)
 		if !found {
 			klog.Errorf("Node info for %s not found", node.Name)
+			sd.addUnremovableNode(node, simulator.UnexpectedError)
 			continue
 		}
 
@@ -410,8 +459,9 @@ func (sd *ScaleDown) UpdateUnneededNodes(
 		klog.V(4).Infof("Node %s - %s utilization %f", node.Name, utilInfo.ResourceName, utilInfo.Utilization)
 		utilizationMap[node.Name] = utilInfo
 
-		if !sd.isNodeBelowUtilzationThreshold(node, utilInfo) {
+		if !sd.isNodeBelowUtilzationThreshold(node, utilInfo, nodeGroupOptions) {
 			klog.V(4).Infof("Node %s is not suitable for removal - %s utilization too big (%f)", node.Name, utilInfo.ResourceName, utilInfo.Utilization)
+			sd.addUnremovableNode(node, simulator.NotUnderutilized)
 			continue
 		}
 		currentlyUnneededNodes = append(currentlyUnneededNodes, node)
@@ -431,13 +481,18 @@ func (sd *ScaleDown) UpdateUnneededNodes(
 		}
 	}
 
+	// With a pricing model, the nodes saving the most are checked first.
+	if savingsEstimator := newSavingsEstimator(sd.context.CloudProvider, timestamp); savingsEstimator != nil {
+		currentlyUnneededNonEmptyNodes = savingsEstimator.sortBySavings(currentlyUnneededNonEmptyNodes, sd.context.ClusterSnapshot)
+	}
+
 	// Phase2 - check which nodes can be probably removed using fast drain.
 	currentCandidates, currentNonCandidates := sd.chooseCandidates(currentlyUnneededNonEmptyNodes)
 
 	// Look for nodes to remove in the current candidates
 	nodesToRemove, unremovable, newHints, simulatorErr := simulator.FindNodesToRemove(
-		currentCandidates, nodes, nonExpendablePods, nil, sd.context.PredicateChecker,
-		len(currentCandidates), true, sd.podLocationHints, sd.usageTracker, timestamp, pdbs)
+		currentCandidates, nodes, nil, sd.context.ClusterSnapshot, sd.context.PredicateChecker,
+		len(currentCandidates), true, false, sd.podLocationHints, sd.usageTracker, timestamp, pdbs)
 	if simulatorErr != nil {
 		return sd.markSimulationError(simulatorErr, timestamp)
 	}
@@ -458,8 +513,8 @@ func (sd *ScaleDown) UpdateUnneededNodes(
 		// Look for additional nodes to remove among the rest of nodes.
 		klog.V(3).Infof("Finding additional %v candidates for scale down.", additionalCandidatesCount)
 		additionalNodesToRemove, additionalUnremovable, additionalNewHints, simulatorErr :=
-			simulator.FindNodesToRemove(currentNonCandidates[:additionalCandidatesPoolSize], nodes, nonExpendablePods, nil,
-				sd.context.PredicateChecker, additionalCandidatesCount, true,
+			simulator.FindNodesToRemove(currentNonCandidates[:additionalCandidatesPoolSize], nodes, nil,
+				sd.context.ClusterSnapshot, sd.context.PredicateChecker, additionalCandidatesCount, true, false,
 				sd.podLocationHints, sd.usageTracker, timestamp, pdbs)
 		if simulatorErr != nil {
 			return sd.markSimulationError(simulatorErr, timestamp)
@@ -490,8 +545,12 @@ func (sd *ScaleDown) UpdateUnneededNodes(
 	// Add nodes to unremovable map
 	if len(unremovable) > 0 {
 		unremovableTimeout := timestamp.Add(sd.context.AutoscalingOptions.UnremovableNodeRecheckTimeout)
-		for _, node := range unremovable {
-			sd.unremovableNodes[node.Name] = unremovableTimeout
+		for _, unremovableNode := range unremovable {
+			sd.unremovableNodes[unremovableNode.Node.Name] = &unremovableNodeRecheck{
+				recheckTime: unremovableTimeout,
+				unremovable: unremovableNode,
+			}
+			sd.unremovableNodeReasons[unremovableNode.Node.Name] = unremovableNode
 		}
 		klog.V(1).Infof("%v nodes found to be unremovable in simulation, will re-check them at %v", len(unremovable), unremovableTimeout)
 	}
@@ -503,44 +562,108 @@ func (sd *ScaleDown) UpdateUnneededNodes(
 	sd.nodeUtilizationMap = utilizationMap
 	sd.clusterStateRegistry.UpdateScaleDownCandidates(sd.unneededNodesList, timestamp)
 	metrics.UpdateUnneededNodesCount(len(sd.unneededNodesList))
+	sd.reportUnremovableNodes()
 	return nil
 }
 
 // isNodeBelowUtilzationThreshold determintes if a given node utilization is blow threshold.
-func (sd *ScaleDown) isNodeBelowUtilzationThreshold(node *apiv1.Node, utilInfo simulator.UtilizationInfo) bool {
+func (sd *ScaleDown) isNodeBelowUtilzationThreshold(node *apiv1.Node, utilInfo simulator.UtilizationInfo, options config.NodeGroupAutoscalingOptions) bool {
 	if gpu.NodeHasGpu(sd.context.CloudProvider.GPULabel(), node) {
-		if utilInfo.Utilization >= sd.context.ScaleDownGpuUtilizationThreshold {
+		if utilInfo.Utilization >= options.ScaleDownGpuUtilizationThreshold {
 			return false
 		}
 	} else {
-		if utilInfo.Utilization >= sd.context.ScaleDownUtilizationThreshold {
+		if utilInfo.Utilization >= options.ScaleDownUtilizationThreshold {
 			return false
 		}
 	}
 	return true
 }
 
-// updateUnremovableNodes updates unremovableNodes map according to current
-// state of the cluster. Removes from the map nodes that are no longer in the
+// getNodeGroupOptions returns autoscaling options of the given node group. Global options
+// are used if the node group is nil or doesn't provide its own options.
+func (sd *ScaleDown) getNodeGroupOptions(nodeGroup cloudprovider.NodeGroup) config.NodeGroupAutoscalingOptions {
+	defaults := sd.context.NodeGroupDefaults()
+	if nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
+		return defaults
+	}
+	options, err := nodeGroup.GetOptions(defaults)
+	if err != nil && err != cloudprovider.ErrNotImplemented {
+		klog.Errorf("Failed to get autoscaling options for node group %s: %v", nodeGroup.Id(), err)
+	}
+	if err != nil || options == nil {
+		return defaults
+	}
+	return *options
+}
+
+// updateUnremovableNodes updates unremovableNodes and unremovableNodeEvents maps according
+// to current state of the cluster. Removes from the maps nodes that are no longer in the
 // nodes list.
 func (sd *ScaleDown) updateUnremovableNodes(nodes []*apiv1.Node) {
-	if len(sd.unremovableNodes) <= 0 {
+	if len(sd.unremovableNodes) <= 0 && len(sd.unremovableNodeEvents) <= 0 {
 		return
 	}
-	// A set of nodes to delete from unremovableNodes map.
-	nodesToDelete := make(map[string]struct{}, len(sd.unremovableNodes))
-	for name := range sd.unremovableNodes {
-		nodesToDelete[name] = struct{}{}
-	}
 	// Nodes that are in the cluster should not be deleted.
+	existingNodes := make(map[string]struct{}, len(nodes))
 	for _, node := range nodes {
-		if _, ok := nodesToDelete[node.Name]; ok {
-			delete(nodesToDelete, node.Name)
+		existingNodes[node.Name] = struct{}{}
+	}
+	for nodeName := range sd.unremovableNodes {
+		if _, ok := existingNodes[nodeName]; !ok {
+			delete(sd.unremovableNodes, nodeName)
 		}
 	}
-	for nodeName := range nodesToDelete {
-		delete(sd.unremovableNodes, nodeName)
+	for nodeName := range sd.unremovableNodeEvents {
+		if _, ok := existingNodes[nodeName]; !ok {
+			delete(sd.unremovableNodeEvents, nodeName)
+		}
+	}
+}
+
+// addUnremovableNode records the reason why the node can't be removed in the current loop.
+func (sd *ScaleDown) addUnremovableNode(node *apiv1.Node, reason simulator.UnremovableReason) {
+	sd.unremovableNodeReasons[node.Name] = &simulator.UnremovableNode{Node: node, Reason: reason}
+}
+
+// reportUnremovableNodes exposes the reasons why nodes can't be removed in the cluster state
+// status and metrics. Reasons pinning underutilized nodes are also reported as node events,
+// whenever they change.
+func (sd *ScaleDown) reportUnremovableNodes() {
+	unremovableNodes := make([]*simulator.UnremovableNode, 0, len(sd.unremovableNodeReasons))
+	countByReason := make(map[string]int)
+	for _, unremovable := range sd.unremovableNodeReasons {
+		unremovableNodes = append(unremovableNodes, unremovable)
+		reason := unremovableReasonString(unremovable)
+		countByReason[reason]++
+
+		if unremovable.Reason == simulator.NotUnderutilized {
+			continue
+		}
+		if sd.unremovableNodeEvents[unremovable.Node.Name] == reason {
+			continue
+		}
+		sd.unremovableNodeEvents[unremovable.Node.Name] = reason
+		if unremovable.BlockingPod != nil {
+			sd.context.Recorder.Eventf(unremovable.Node, apiv1.EventTypeNormal, "ScaleDownUnremovable",
+				"node can't be removed by cluster autoscaler: %s, pod %s/%s can't be moved: %s", unremovable.Reason,
+				unremovable.BlockingPod.Pod.Namespace, unremovable.BlockingPod.Pod.Name, unremovable.BlockingPod.Reason)
+		} else {
+			sd.context.Recorder.Eventf(unremovable.Node, apiv1.EventTypeNormal, "ScaleDownUnremovable",
+				"node can't be removed by cluster autoscaler: %s", unremovable.Reason)
+		}
 	}
+	sd.clusterStateRegistry.UpdateUnremovableNodes(unremovableNodes)
+	metrics.UpdateUnremovableNodesCount(countByReason)
+}
+
+// unremovableReasonString returns the most specific reason why the node can't be removed:
+// the reason why the blocking pod can't be moved, if there is one.
+func unremovableReasonString(unremovable *simulator.UnremovableNode) string {
+	if unremovable.BlockingPod != nil {
+		return string(unremovable.BlockingPod.Reason)
+	}
+	return string(unremovable.Reason)
 }
 
 // markSimulationError indicates a simulation error by clearing  relevant scale
@@ -552,6 +675,7 @@ func (sd *ScaleDown) markSimulationError(simulatorErr errors.AutoscalerError,
 	sd.unneededNodes = make(map[string]time.Time)
 	sd.nodeUtilizationMap = make(map[string]simulator.UtilizationInfo)
 	sd.clusterStateRegistry.UpdateScaleDownCandidates(sd.unneededNodesList, timestamp)
+	sd.reportUnremovableNodes()
 	return simulatorErr.AddPrefix("error while simulating node drains: ")
 }
 
@@ -576,14 +700,16 @@ func (sd *ScaleDown) chooseCandidates(nodes []*apiv1.Node) ([]*apiv1.Node, []*ap
 	return currentCandidates, currentNonCandidates
 }
 
-func (sd *ScaleDown) mapNodesToStatusScaleDownNodes(nodes []*apiv1.Node, nodeGroups map[string]cloudprovider.NodeGroup, evictedPodLists map[string][]*apiv1.Pod) []*status.ScaleDownNode {
+func (sd *ScaleDown) mapNodesToStatusScaleDownNodes(nodes []*apiv1.Node, nodeGroups map[string]cloudprovider.NodeGroup, evictedPodLists map[string][]*apiv1.Pod,
+	hourlySavings map[string]float64) []*status.ScaleDownNode {
 	var result []*status.ScaleDownNode
 	for _, node := range nodes {
 		result = append(result, &status.ScaleDownNode{
-			Node:        node,
-			NodeGroup:   nodeGroups[node.Name],
-			UtilInfo:    sd.nodeUtilizationMap[node.Name],
-			EvictedPods: evictedPodLists[node.Name],
+			Node:          node,
+			NodeGroup:     nodeGroups[node.Name],
+			UtilInfo:      sd.nodeUtilizationMap[node.Name],
+			EvictedPods:   evictedPodLists[node.Name],
+			HourlySavings: hourlySavings[node.Name],
 		})
 	}
 	return result
@@ -592,6 +718,10 @@ func (sd *ScaleDown) mapNodesToStatusScaleDownNodes(nodes []*apiv1.Node, nodeGro
 // SoftTaintUnneededNodes manage soft taints of unneeded nodes.
 func (sd *ScaleDown) SoftTaintUnneededNodes(allNodes []*apiv1.Node) (errors []error) {
 	defer metrics.UpdateDurationFromStart(metrics.ScaleDownSoftTaintUnneeded, time.Now())
+	if sd.context.DryRun {
+		klog.V(4).Infof("Dry-run mode, not updating soft taints of unneeded nodes")
+		return
+	}
 	apiCallBudget := sd.context.AutoscalingOptions.MaxBulkSoftTaintCount
 	timeBudget := sd.context.AutoscalingOptions.MaxBulkSoftTaintTime
 	skippedNodes := 0
@@ -668,26 +798,27 @@ func (sd *ScaleDown) TryToScaleDown(allNodes []*apiv1.Node, pods []*apiv1.Pod, p
 				continue
 			}
 
+			nodeGroup, err := sd.context.CloudProvider.NodeGroupForNode(node)
+			if err != nil {
+				klog.Errorf("Error while checking node group for %s: %v", node.Name, err)
+				continue
+			}
+			if nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
+				klog.V(4).Infof("Skipping %s - no node group config", node.Name)
+				continue
+			}
+			nodeGroupOptions := sd.getNodeGroupOptions(nodeGroup)
+
 			ready, _, _ := kube_util.GetReadinessState(node)
 			readinessMap[node.Name] = ready
 
 			// Check how long the node was underutilized.
-			if ready && !val.Add(sd.context.ScaleDownUnneededTime).Before(currentTime) {
+			if ready && !val.Add(nodeGroupOptions.ScaleDownUnneededTime).Before(currentTime) {
 				continue
 			}
 
 			// Unready nodes may be deleted after a different time than underutilized nodes.
-			if !ready && !val.Add(sd.context.ScaleDownUnreadyTime).Before(currentTime) {
-				continue
-			}
-
-			nodeGroup, err := sd.context.CloudProvider.NodeGroupForNode(node)
-			if err != nil {
-				klog.Errorf("Error while checking node group for %s: %v", node.Name, err)
-				continue
-			}
-			if nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
-				klog.V(4).Infof("Skipping %s - no node group config", node.Name)
+			if !ready && !val.Add(nodeGroupOptions.ScaleDownUnreadyTime).Before(currentTime) {
 				continue
 			}
 
@@ -699,6 +830,7 @@ func (sd *ScaleDown) TryToScaleDown(allNodes []*apiv1.Node, pods []*apiv1.Pod, p
 
 			if size <= nodeGroup.MinSize() {
 				klog.V(1).Infof("Skipping %s - node group min size reached", node.Name)
+				sd.addUnremovableNode(node, simulator.NodeGroupMinSizeReached)
 				continue
 			}
 
@@ -711,6 +843,7 @@ func (sd *ScaleDown) TryToScaleDown(allNodes []*apiv1.Node, pods []*apiv1.Pod, p
 			checkResult := scaleDownResourcesLeft.checkScaleDownDeltaWithinLimits(scaleDownResourcesDelta)
 			if checkResult.exceeded {
 				klog.V(4).Infof("Skipping %s - minimal limit exceeded for %v", node.Name, checkResult.exceededResources)
+				sd.addUnremovableNode(node, simulator.MinimalResourceLimitExceeded)
 				continue
 			}
 
@@ -718,25 +851,39 @@ func (sd *ScaleDown) TryToScaleDown(allNodes []*apiv1.Node, pods []*apiv1.Pod, p
 			candidateNodeGroups[node.Name] = nodeGroup
 		}
 	}
+	sd.reportUnremovableNodes()
 	if len(candidates) == 0 {
 		klog.V(1).Infof("No candidates for scale down")
 		scaleDownStatus.Result = status.ScaleDownNoUnneeded
 		return scaleDownStatus, nil
 	}
+	// With a pricing model, the nodes saving the most are removed first.
+	savingsEstimator := newSavingsEstimator(sd.context.CloudProvider, currentTime)
+	if savingsEstimator != nil {
+		candidates = savingsEstimator.sortBySavings(candidates, sd.context.ClusterSnapshot)
+	}
 
 	// Trying to delete empty nodes in bulk. If there are no empty nodes then CA will
 	// try to delete not-so-empty nodes, possibly killing some pods and allowing them
 	// to recreate on other nodes.
 	emptyNodes := getEmptyNodes(candidates, pods, sd.context.MaxEmptyBulkDelete, scaleDownResourcesLeft, sd.context.CloudProvider)
 	if len(emptyNodes) > 0 {
+		hourlySavings := make(map[string]float64)
+		if savingsEstimator != nil {
+			emptyNodesToRemove := make([]simulator.NodeToBeRemoved, 0, len(emptyNodes))
+			for _, node := range emptyNodes {
+				emptyNodesToRemove = append(emptyNodesToRemove, simulator.NodeToBeRemoved{Node: node})
+			}
+			hourlySavings = savingsEstimator.hourlySavingsOf(emptyNodesToRemove)
+		}
 		nodeDeletionStart := time.Now()
 		confirmation := make(chan nodeDeletionConfirmation, len(emptyNodes))
-		sd.scheduleDeleteEmptyNodes(emptyNodes, sd.context.CloudProvider, sd.context.ClientSet, sd.context.Recorder, readinessMap, candidateNodeGroups, confirmation)
+		sd.scheduleDeleteEmptyNodes(emptyNodes, sd.context.CloudProvider, sd.context.ClientSet, sd.context.Recorder, readinessMap, candidateNodeGroups, hourlySavings, confirmation)
 		deletedNodes, err := sd.waitForEmptyNodesDeleted(emptyNodes, confirmation)
 		nodeDeletionDuration = time.Now().Sub(nodeDeletionStart)
 
 		// TODO: Give the processor some information about the nodes that failed to be deleted.
-		scaleDownStatus.ScaledDownNodes = sd.mapNodesToStatusScaleDownNodes(deletedNodes, candidateNodeGroups, make(map[string][]*apiv1.Pod))
+		scaleDownStatus.ScaledDownNodes = sd.mapNodesToStatusScaleDownNodes(deletedNodes, candidateNodeGroups, make(map[string][]*apiv1.Pod), hourlySavings)
 		if len(deletedNodes) > 0 {
 			scaleDownStatus.Result = status.ScaleDownNodeDeleted
 		} else {
@@ -750,11 +897,20 @@ func (sd *ScaleDown) TryToScaleDown(allNodes []*apiv1.Node, pods []*apiv1.Pod, p
 	}
 
 	findNodesToRemoveStart := time.Now()
+	// Removals of the nodes found are simulated one after another, and reverted once done.
+	if err := sd.context.ClusterSnapshot.Fork(); err != nil {
+		scaleDownStatus.Result = status.ScaleDownError
+		return scaleDownStatus, errors.ToAutoscalerError(errors.InternalError, err)
+	}
+	defer func() {
+		if err := sd.context.ClusterSnapshot.Revert(); err != nil {
+			klog.Errorf("Failed to revert cluster snapshot after finding nodes to remove: %v", err)
+		}
+	}()
 	// Only scheduled non expendable pods are taken into account and have to be moved.
-	nonExpendablePods := filterOutExpendablePods(pods, sd.context.ExpendablePodsPriorityCutoff)
-	// We look for only 1 node so new hints may be incomplete.
-	nodesToRemove, _, _, err := simulator.FindNodesToRemove(candidates, nodesWithoutMaster, nonExpendablePods, sd.context.ListerRegistry,
-		sd.context.PredicateChecker, 1, false,
+	// We look for only MaxDrainParallelism nodes so new hints may be incomplete.
+	nodesToRemove, _, _, err := simulator.FindNodesToRemove(candidates, nodesWithoutMaster, sd.context.ListerRegistry,
+		sd.context.ClusterSnapshot, sd.context.PredicateChecker, sd.context.MaxDrainParallelism, false, true,
 		sd.podLocationHints, sd.usageTracker, time.Now(), pdbs)
 	findNodesToRemoveDuration = time.Now().Sub(findNodesToRemoveStart)
 
@@ -762,12 +918,42 @@ func (sd *ScaleDown) TryToScaleDown(allNodes []*apiv1.Node, pods []*apiv1.Pod, p
 		scaleDownStatus.Result = status.ScaleDownError
 		return scaleDownStatus, err.AddPrefix("Find node to remove failed: ")
 	}
+	nodesToRemove = filterNodesToRemoveWithinLimits(nodesToRemove, scaleDownResourcesLeft, sd.context.CloudProvider)
 	if len(nodesToRemove) == 0 {
 		klog.V(1).Infof("No node to remove")
 		scaleDownStatus.Result = status.ScaleDownNoNodeDeleted
 		return scaleDownStatus, nil
 	}
-	toRemove := nodesToRemove[0]
+
+	for i := range nodesToRemove {
+		// Pods waiting for preemption on the node have to fit elsewhere too, but they are not running there yet.
+		nodesToRemove[i].PodsToReschedule = filterOutPodsNotBoundToNode(nodesToRemove[i].PodsToReschedule, nodesToRemove[i].Node.Name)
+	}
+	hourlySavings := make(map[string]float64)
+	if savingsEstimator != nil {
+		hourlySavings = savingsEstimator.hourlySavingsOf(nodesToRemove)
+	}
+
+	nodeDeletionStart := time.Now()
+	removedNodes := make([]*apiv1.Node, 0, len(nodesToRemove))
+	podsToReschedule := make(map[string][]*apiv1.Pod, len(nodesToRemove))
+	for _, toRemove := range nodesToRemove {
+		sd.scheduleDeleteNode(toRemove, pdbs, readinessMap, candidateNodeGroups, gpuLabel, availableGPUTypes, hourlySavings)
+		removedNodes = append(removedNodes, toRemove.Node)
+		podsToReschedule[toRemove.Node.Name] = toRemove.PodsToReschedule
+	}
+	nodeDeletionDuration = time.Now().Sub(nodeDeletionStart)
+
+	scaleDownStatus.ScaledDownNodes = sd.mapNodesToStatusScaleDownNodes(removedNodes, candidateNodeGroups, podsToReschedule, hourlySavings)
+	scaleDownStatus.Result = status.ScaleDownNodeDeleteStarted
+	return scaleDownStatus, nil
+}
+
+// scheduleDeleteNode starts draining and deleting the node in a separate goroutine. The deletion is
+// tracked in NodeDeleteStatus until it's over.
+func (sd *ScaleDown) scheduleDeleteNode(toRemove simulator.NodeToBeRemoved, pdbs []*policyv1.PodDisruptionBudget,
+	readinessMap map[string]bool, candidateNodeGroups map[string]cloudprovider.NodeGroup, gpuLabel string,
+	availableGPUTypes map[string]struct{}, hourlySavings map[string]float64) {
 	utilization := sd.nodeUtilizationMap[toRemove.Node.Name]
 	podNames := make([]string, 0, len(toRemove.PodsToReschedule))
 	for _, pod := range toRemove.PodsToReschedule {
@@ -780,18 +966,15 @@ func (sd *ScaleDown) TryToScaleDown(allNodes []*apiv1.Node, pods []*apiv1.Pod, p
 
 	// Nothing super-bad should happen if the node is removed from tracker prematurely.
 	simulator.RemoveNodeFromTracker(sd.usageTracker, toRemove.Node.Name, sd.unneededNodes)
-	nodeDeletionStart := time.Now()
 
 	// Starting deletion.
-	nodeDeletionDuration = time.Now().Sub(nodeDeletionStart)
-	sd.nodeDeleteStatus.SetDeleteInProgress(true)
+	sd.nodeDeleteStatus.StartDeletion(toRemove.Node.Name)
 
 	go func() {
 		// Finishing the delete process once this goroutine is over.
 		var result status.NodeDeleteResult
-		defer func() { sd.nodeDeleteStatus.AddNodeDeleteResult(toRemove.Node.Name, result) }()
-		defer sd.nodeDeleteStatus.SetDeleteInProgress(false)
-		result = sd.deleteNode(toRemove.Node, toRemove.PodsToReschedule)
+		defer func() { sd.nodeDeleteStatus.FinishDeletion(toRemove.Node.Name, result) }()
+		result = sd.deleteNode(toRemove.Node, toRemove.PodsToReschedule, pdbs)
 		if result.ResultType != status.NodeDeleteOk {
 			klog.Errorf("Failed to delete %s: %v", toRemove.Node.Name, result.Err)
 			return
@@ -802,11 +985,10 @@ func (sd *ScaleDown) TryToScaleDown(allNodes []*apiv1.Node, pods []*apiv1.Pod, p
 		} else {
 			metrics.RegisterScaleDown(1, gpu.GetGpuTypeForMetrics(gpuLabel, availableGPUTypes, toRemove.Node, nodeGroup), metrics.Unready)
 		}
+		if savings, found := hourlySavings[toRemove.Node.Name]; found {
+			metrics.RegisterScaleDownSavings(savings)
+		}
 	}()
-
-	scaleDownStatus.ScaledDownNodes = sd.mapNodesToStatusScaleDownNodes([]*apiv1.Node{toRemove.Node}, candidateNodeGroups, map[string][]*apiv1.Pod{toRemove.Node.Name: toRemove.PodsToReschedule})
-	scaleDownStatus.Result = status.ScaleDownNodeDeleteStarted
-	return scaleDownStatus, nil
 }
 
 // updateScaleDownMetrics registers duration of different parts of scale down.
@@ -830,12 +1012,45 @@ func getEmptyNodes(candidates []*apiv1.Node, pods []*apiv1.Pod, maxEmptyBulkDele
 	resourcesLimits scaleDownResourcesLimits, cloudProvider cloudprovider.CloudProvider) []*apiv1.Node {
 
 	emptyNodes := simulator.FindEmptyNodesToRemove(candidates, pods)
+	result := filterNodesWithinLimits(emptyNodes, resourcesLimits, cloudProvider)
+	limit := maxEmptyBulkDelete
+	if len(result) < limit {
+		limit = len(result)
+	}
+	return result[:limit]
+}
+
+// filterNodesToRemoveWithinLimits returns nodes to remove which can be removed at the same time without
+// going below the minimum sizes of their node groups or the cluster-wide resource limits.
+func filterNodesToRemoveWithinLimits(nodesToRemove []simulator.NodeToBeRemoved, resourcesLimits scaleDownResourcesLimits,
+	cloudProvider cloudprovider.CloudProvider) []simulator.NodeToBeRemoved {
+	nodes := make([]*apiv1.Node, 0, len(nodesToRemove))
+	for _, toRemove := range nodesToRemove {
+		nodes = append(nodes, toRemove.Node)
+	}
+	withinLimits := make(map[string]bool)
+	for _, node := range filterNodesWithinLimits(nodes, resourcesLimits, cloudProvider) {
+		withinLimits[node.Name] = true
+	}
+	result := make([]simulator.NodeToBeRemoved, 0, len(withinLimits))
+	for _, toRemove := range nodesToRemove {
+		if withinLimits[toRemove.Node.Name] {
+			result = append(result, toRemove)
+		}
+	}
+	return result
+}
+
+// filterNodesWithinLimits returns nodes which can be removed at the same time without going below
+// the minimum sizes of their node groups or the cluster-wide resource limits.
+func filterNodesWithinLimits(nodes []*apiv1.Node, resourcesLimits scaleDownResourcesLimits,
+	cloudProvider cloudprovider.CloudProvider) []*apiv1.Node {
 	availabilityMap := make(map[string]int)
 	result := make([]*apiv1.Node, 0)
 	resourcesLimitsCopy := copyScaleDownResourcesLimits(resourcesLimits) // we do not want to modify input parameter
 	resourcesNames := sets.StringKeySet(resourcesLimits).List()
 
-	for _, node := range emptyNodes {
+	for _, node := range nodes {
 		nodeGroup, err := cloudProvider.NodeGroupForNode(node)
 		if err != nil {
 			klog.Errorf("Failed to get group for %s", node.Name)
@@ -874,11 +1089,7 @@ func getEmptyNodes(candidates []*apiv1.Node, pods []*apiv1.Pod, maxEmptyBulkDele
 			result = append(result, node)
 		}
 	}
-	limit := maxEmptyBulkDelete
-	if len(result) < limit {
-		limit = len(result)
-	}
-	return result[:limit]
+	return result
 }
 
 type nodeDeletionConfirmation struct {
@@ -888,24 +1099,28 @@ type nodeDeletionConfirmation struct {
 
 func (sd *ScaleDown) scheduleDeleteEmptyNodes(emptyNodes []*apiv1.Node, cp cloudprovider.CloudProvider, client kube_client.Interface,
 	recorder kube_record.EventRecorder, readinessMap map[string]bool,
-	candidateNodeGroups map[string]cloudprovider.NodeGroup, confirmation chan nodeDeletionConfirmation) {
+	candidateNodeGroups map[string]cloudprovider.NodeGroup, hourlySavings map[string]float64, confirmation chan nodeDeletionConfirmation) {
 	for _, node := range emptyNodes {
 		klog.V(0).Infof("Scale-down: removing empty node %s", node.Name)
 		sd.context.LogRecorder.Eventf(apiv1.EventTypeNormal, "ScaleDownEmpty", "Scale-down: removing empty node %s", node.Name)
 		simulator.RemoveNodeFromTracker(sd.usageTracker, node.Name, sd.unneededNodes)
 		go func(nodeToDelete *apiv1.Node) {
-			taintErr := deletetaint.MarkToBeDeleted(nodeToDelete, client)
-			if taintErr != nil {
-				recorder.Eventf(nodeToDelete, apiv1.EventTypeWarning, "ScaleDownFailed", "failed to mark the node as toBeDeleted/unschedulable: %v", taintErr)
-				confirmation <- nodeDeletionConfirmation{node: nodeToDelete, err: errors.ToAutoscalerError(errors.ApiCallError, taintErr)}
-				return
+			if !sd.context.DryRun {
+				taintErr := deletetaint.MarkToBeDeleted(nodeToDelete, client)
+				if taintErr != nil {
+					recorder.Eventf(nodeToDelete, apiv1.EventTypeWarning, "ScaleDownFailed", "failed to mark the node as toBeDeleted/unschedulable: %v", taintErr)
+					confirmation <- nodeDeletionConfirmation{node: nodeToDelete, err: errors.ToAutoscalerError(errors.ApiCallError, taintErr)}
+					return
+				}
 			}
 
 			var deleteErr errors.AutoscalerError
 			// If we fail to delete the node we want to remove delete taint
 			defer func() {
 				if deleteErr != nil {
-					deletetaint.CleanToBeDeleted(nodeToDelete, client)
+					if !sd.context.DryRun {
+						deletetaint.CleanToBeDeleted(nodeToDelete, client)
+					}
 					recorder.Eventf(nodeToDelete, apiv1.EventTypeWarning, "ScaleDownFailed", "failed to delete empty node: %v", deleteErr)
 				} else {
 					sd.context.LogRecorder.Eventf(apiv1.EventTypeNormal, "ScaleDownEmpty", "Scale-down: empty node %s removed", nodeToDelete.Name)
@@ -921,6 +1136,9 @@ func (sd *ScaleDown) scheduleDeleteEmptyNodes(emptyNodes []*apiv1.Node, cp cloud
 				} else {
 					metrics.RegisterScaleDown(1, gpu.GetGpuTypeForMetrics(cp.GPULabel(), cp.GetAvailableGPUTypes(), nodeToDelete, nodeGroup), metrics.Unready)
 				}
+				if savings, found := hourlySavings[nodeToDelete.Name]; found {
+					metrics.RegisterScaleDownSavings(savings)
+				}
 			}
 			confirmation <- nodeDeletionConfirmation{node: nodeToDelete, err: deleteErr}
 		}(node)
@@ -952,7 +1170,15 @@ func (sd *ScaleDown) waitForEmptyNodesDeleted(emptyNodes []*apiv1.Node, confirma
 	return deletedNodes, finalError
 }
 
-func (sd *ScaleDown) deleteNode(node *apiv1.Node, pods []*apiv1.Pod) status.NodeDeleteResult {
+func (sd *ScaleDown) deleteNode(node *apiv1.Node, pods []*apiv1.Pod, pdbs []*policyv1.PodDisruptionBudget) status.NodeDeleteResult {
+	if sd.context.DryRun {
+		// Nodes are neither tainted nor drained in dry-run mode, the cloud provider only records the deletion.
+		if err := deleteNodeFromCloudProvider(node, sd.context.CloudProvider, sd.context.Recorder, sd.clusterStateRegistry); err != nil {
+			return status.NodeDeleteResult{ResultType: status.NodeDeleteErrorFailedToDelete, Err: err}
+		}
+		return status.NodeDeleteResult{ResultType: status.NodeDeleteOk}
+	}
+
 	deleteSuccessful := false
 	drainSuccessful := false
 
@@ -976,7 +1202,8 @@ func (sd *ScaleDown) deleteNode(node *apiv1.Node, pods []*apiv1.Pod) status.Node
 	sd.context.Recorder.Eventf(node, apiv1.EventTypeNormal, "ScaleDown", "marked the node as toBeDeleted/unschedulable")
 
 	// attempt drain
-	evictionResults, err := drainNode(node, pods, sd.context.ClientSet, sd.context.Recorder, sd.context.MaxGracefulTerminationSec, MaxPodEvictionTime, EvictionRetryTime, PodEvictionHeadroom)
+	drainController := newDrainController(sd.context.ClientSet, sd.context.Recorder, pdbs, sd.context.MaxGracefulTerminationSec)
+	evictionResults, err := drainController.drain(node, pods)
 	if err != nil {
 		return status.NodeDeleteResult{ResultType: status.NodeDeleteErrorFailedToEvictPods, Err: err, PodEvictionResults: evictionResults}
 	}
@@ -991,119 +1218,6 @@ func (sd *ScaleDown) deleteNode(node *apiv1.Node, pods []*apiv1.Pod) status.Node
 	return status.NodeDeleteResult{ResultType: status.NodeDeleteOk}
 }
 
-func evictPod(podToEvict *apiv1.Pod, client kube_client.Interface, recorder kube_record.EventRecorder,
-	maxGracefulTerminationSec int, retryUntil time.Time, waitBetweenRetries time.Duration) status.PodEvictionResult {
-	recorder.Eventf(podToEvict, apiv1.EventTypeNormal, "ScaleDown", "deleting pod for node scale down")
-
-	maxTermination := int64(apiv1.DefaultTerminationGracePeriodSeconds)
-	if podToEvict.Spec.TerminationGracePeriodSeconds != nil {
-		if *podToEvict.Spec.TerminationGracePeriodSeconds < int64(maxGracefulTerminationSec) {
-			maxTermination = *podToEvict.Spec.TerminationGracePeriodSeconds
-		} else {
-			maxTermination = int64(maxGracefulTerminationSec)
-		}
-	}
-
-	var lastError error
-	for first := true; first || time.Now().Before(retryUntil); time.Sleep(waitBetweenRetries) {
-		first = false
-		eviction := &policyv1.Eviction{
-			ObjectMeta: metav1.ObjectMeta{
-				Namespace: podToEvict.Namespace,
-				Name:      podToEvict.Name,
-			},
-			DeleteOptions: &metav1.DeleteOptions{
-				GracePeriodSeconds: &maxTermination,
-			},
-		}
-		lastError = client.CoreV1().Pods(podToEvict.Namespace).Evict(eviction)
-		if lastError == nil || kube_errors.IsNotFound(lastError) {
-			return status.PodEvictionResult{Pod: podToEvict, TimedOut: false, Err: nil}
-		}
-	}
-	klog.Errorf("Failed to evict pod %s, error: %v", podToEvict.Name, lastError)
-	recorder.Eventf(podToEvict, apiv1.EventTypeWarning, "ScaleDownFailed", "failed to delete pod for ScaleDown")
-	return status.PodEvictionResult{Pod: podToEvict, TimedOut: true, Err: fmt.Errorf("failed to evict pod %s/%s within allowed timeout (last error: %v)", podToEvict.Namespace, podToEvict.Name, lastError)}
-}
-
-// Performs drain logic on the node. Marks the node as unschedulable and later removes all pods, giving
-// them up to MaxGracefulTerminationTime to finish.
-func drainNode(node *apiv1.Node, pods []*apiv1.Pod, client kube_client.Interface, recorder kube_record.EventRecorder,
-	maxGracefulTerminationSec int, maxPodEvictionTime time.Duration, waitBetweenRetries time.Duration,
-	podEvictionHeadroom time.Duration) (evictionResults map[string]status.PodEvictionResult, err error) {
-
-	evictionResults = make(map[string]status.PodEvictionResult)
-	toEvict := len(pods)
-	retryUntil := time.Now().Add(maxPodEvictionTime)
-	confirmations := make(chan status.PodEvictionResult, toEvict)
-	for _, pod := range pods {
-		evictionResults[pod.Name] = status.PodEvictionResult{Pod: pod, TimedOut: true, Err: nil}
-		go func(podToEvict *apiv1.Pod) {
-			confirmations <- evictPod(podToEvict, client, recorder, maxGracefulTerminationSec, retryUntil, waitBetweenRetries)
-		}(pod)
-	}
-
-	for range pods {
-		select {
-		case evictionResult := <-confirmations:
-			evictionResults[evictionResult.Pod.Name] = evictionResult
-			if evictionResult.WasEvictionSuccessful() {
-				metrics.RegisterEvictions(1)
-			}
-		case <-time.After(retryUntil.Sub(time.Now()) + 5*time.Second):
-			// All pods initially had results with TimedOut set to true, so the ones that didn't receive an actual result are correctly marked as timed out.
-			return evictionResults, errors.NewAutoscalerError(errors.ApiCallError, "Failed to drain node %s/%s: timeout when waiting for creating evictions", node.Namespace, node.Name)
-		}
-	}
-
-	evictionErrs := make([]error, 0)
-	for _, result := range evictionResults {
-		if !result.WasEvictionSuccessful() {
-			evictionErrs = append(evictionErrs, result.Err)
-		}
-	}
-	if len(evictionErrs) != 0 {
-		return evictionResults, errors.NewAutoscalerError(errors.ApiCallError, "Failed to drain node %s/%s, due to following errors: %v", node.Namespace, node.Name, evictionErrs)
-	}
-
-	// Evictions created successfully, wait maxGracefulTerminationSec + podEvictionHeadroom to see if pods really disappeared.
-	allGone := true
-	for start := time.Now(); time.Now().Sub(start) < time.Duration(maxGracefulTerminationSec)*time.Second+podEvictionHeadroom; time.Sleep(5 * time.Second) {
-		allGone = true
-		for _, pod := range pods {
-			podreturned, err := client.CoreV1().Pods(pod.Namespace).Get(pod.Name, metav1.GetOptions{})
-			if err == nil && (podreturned == nil || podreturned.Spec.NodeName == node.Name) {
-				klog.Errorf("Not deleted yet %v", podreturned)
-				allGone = false
-				break
-			}
-			if err != nil && !kube_errors.IsNotFound(err) {
-				klog.Errorf("Failed to check pod %s/%s: %v", pod.Namespace, pod.Name, err)
-				allGone = false
-				break
-			}
-		}
-		if allGone {
-			klog.V(1).Infof("All pods removed from %s", node.Name)
-			// Let the deferred function know there is no need for cleanup
-			return evictionResults, nil
-		}
-	}
-
-	for _, pod := range pods {
-		podReturned, err := client.CoreV1().Pods(pod.Namespace).Get(pod.Name, metav1.GetOptions{})
-		if err == nil && (podReturned == nil || podReturned.Spec.NodeName == node.Name) {
-			evictionResults[pod.Name] = status.PodEvictionResult{Pod: pod, TimedOut: true, Err: nil}
-		} else if err != nil && !kube_errors.IsNotFound(err) {
-			evictionResults[pod.Name] = status.PodEvictionResult{Pod: pod, TimedOut: true, Err: err}
-		} else {
-			evictionResults[pod.Name] = status.PodEvictionResult{Pod: pod, TimedOut: false, Err: nil}
-		}
-	}
-
-	return evictionResults, errors.NewAutoscalerError(errors.TransientError, "Failed to drain node %s/%s: pods remaining after timeout", node.Namespace, node.Name)
-}
-
 // Removes the given node from cloud provider. No extra pre-deletion actions are executed on
 // the Kubernetes side.
 func deleteNodeFromCloudProvider(node *apiv1.Node, cloudProvider cloudprovider.CloudProvider,
@@ -0,0 +1,121 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"sort"
+	"time"
+
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/drain"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+)
+
+// savingsEstimator estimates how much money removing a node saves per hour: the price of the node
+// minus the price of running its relocated pods elsewhere, as given by the cloud provider's PricingModel.
+type savingsEstimator struct {
+	pricingModel cloudprovider.PricingModel
+	now          time.Time
+}
+
+// newSavingsEstimator returns a savings estimator, or nil if the cloud provider has no PricingModel.
+func newSavingsEstimator(cloudProvider cloudprovider.CloudProvider, now time.Time) *savingsEstimator {
+	pricingModel, err := cloudProvider.Pricing()
+	if err != nil {
+		if err != cloudprovider.ErrNotImplemented {
+			klog.Warningf("Failed to get pricing model, scale-down won't be cost-aware: %v", err)
+		}
+		return nil
+	}
+	return &savingsEstimator{pricingModel: pricingModel, now: now}
+}
+
+// hourlySavings returns the expected hourly savings of removing the node and relocating the given pods.
+func (e *savingsEstimator) hourlySavings(node *apiv1.Node, podsToRelocate []*apiv1.Pod) (float64, error) {
+	then := e.now.Add(time.Hour)
+	savings, err := e.pricingModel.NodePrice(node, e.now, then)
+	if err != nil {
+		return 0, err
+	}
+	for _, pod := range podsToRelocate {
+		podPrice, err := e.pricingModel.PodPrice(pod, e.now, then)
+		if err != nil {
+			return 0, err
+		}
+		savings -= podPrice
+	}
+	return savings, nil
+}
+
+// sortBySavings sorts nodes by decreasing expected savings. Pods to relocate are taken from clusterSnapshot,
+// except DaemonSet and mirror pods, which are not relocated. Nodes whose savings can't be estimated go last.
+func (e *savingsEstimator) sortBySavings(nodes []*apiv1.Node, clusterSnapshot simulator.ClusterSnapshot) []*apiv1.Node {
+	savings := make(map[string]float64, len(nodes))
+	known := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		var podsToRelocate []*apiv1.Pod
+		if nodeInfo, found := clusterSnapshot.GetNodeInfo(node.Name); found {
+			for _, pod := range nodeInfo.Pods() {
+				if drain.IsMirrorPod(pod) || isDaemonSetPod(pod) {
+					continue
+				}
+				podsToRelocate = append(podsToRelocate, pod)
+			}
+		}
+		nodeSavings, err := e.hourlySavings(node, podsToRelocate)
+		if err != nil {
+			klog.V(4).Infof("Failed to estimate scale-down savings of %s: %v", node.Name, err)
+			continue
+		}
+		savings[node.Name] = nodeSavings
+		known[node.Name] = true
+	}
+
+	result := make([]*apiv1.Node, len(nodes))
+	copy(result, nodes)
+	sort.SliceStable(result, func(i, j int) bool {
+		iName, jName := result[i].Name, result[j].Name
+		if known[iName] != known[jName] {
+			return known[iName]
+		}
+		return savings[iName] > savings[jName]
+	})
+	return result
+}
+
+// hourlySavingsOf returns the expected hourly savings of the given scale-down, keyed by node name.
+// Nodes whose savings can't be estimated are skipped.
+func (e *savingsEstimator) hourlySavingsOf(nodesToRemove []simulator.NodeToBeRemoved) map[string]float64 {
+	result := make(map[string]float64, len(nodesToRemove))
+	for _, toRemove := range nodesToRemove {
+		nodeSavings, err := e.hourlySavings(toRemove.Node, toRemove.PodsToReschedule)
+		if err != nil {
+			klog.Warningf("Failed to estimate scale-down savings of %s: %v", toRemove.Node.Name, err)
+			continue
+		}
+		result[toRemove.Node.Name] = nodeSavings
+	}
+	return result
+}
+
+func isDaemonSetPod(pod *apiv1.Pod) bool {
+	controllerRef := drain.ControllerRef(pod)
+	return controllerRef != nil && controllerRef.Kind == "DaemonSet"
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPricingModel struct {
+	nodePrice map[string]float64
+	podPrice  map[string]float64
+}
+
+func (tpm *testPricingModel) NodePrice(node *apiv1.Node, startTime time.Time, endTime time.Time) (float64, error) {
+	if price, found := tpm.nodePrice[node.Name]; found {
+		return price, nil
+	}
+	return 0.0, fmt.Errorf("price for node %v not found", node.Name)
+}
+
+func (tpm *testPricingModel) PodPrice(pod *apiv1.Pod, startTime time.Time, endTime time.Time) (float64, error) {
+	if price, found := tpm.podPrice[pod.Name]; found {
+		return price, nil
+	}
+	return 0.0, fmt.Errorf("price for pod %v not found", pod.Name)
+}
+
+func TestSortBySavings(t *testing.T) {
+	n1 := BuildTestNode("n1", 1000, 1000)
+	n2 := BuildTestNode("n2", 1000, 1000)
+	n3 := BuildTestNode("n3", 1000, 1000)
+	n4 := BuildTestNode("n4", 1000, 1000)
+	// DaemonSet pods aren't relocated, so they don't need a price.
+	ds := BuildTestPod("ds", 100, 0)
+	ds.OwnerReferences = GenerateOwnerReferences("ds", "DaemonSet", "apps/v1", "")
+	ds.Spec.NodeName = "n1"
+	p1 := BuildTestPod("p1", 100, 0)
+	p1.Spec.NodeName = "n1"
+	p2 := BuildTestPod("p2", 800, 0)
+	p2.Spec.NodeName = "n2"
+
+	pricingModel := &testPricingModel{
+		nodePrice: map[string]float64{"n1": 1.5, "n2": 3, "n3": 2},
+		podPrice:  map[string]float64{"p1": 0.5, "p2": 2.5},
+	}
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.SetPricingModel(pricingModel)
+	clusterSnapshot := simulator.NewBasicClusterSnapshot()
+	assert.NoError(t, simulator.InitializeClusterSnapshot(clusterSnapshot, []*apiv1.Node{n1, n2, n3, n4}, []*apiv1.Pod{ds, p1, p2}))
+
+	estimator := newSavingsEstimator(provider, time.Now())
+	assert.NotNil(t, estimator)
+	// n4 has no price, so it goes last.
+	sorted := estimator.sortBySavings([]*apiv1.Node{n4, n1, n2, n3}, clusterSnapshot)
+	assert.Equal(t, []*apiv1.Node{n3, n1, n2, n4}, sorted)
+
+	savings := estimator.hourlySavingsOf([]simulator.NodeToBeRemoved{
+		{Node: n2, PodsToReschedule: []*apiv1.Pod{p2}},
+		{Node: n3},
+		{Node: n4},
+	})
+	assert.Equal(t, map[string]float64{"n2": 0.5, "n3": 2}, savings)
+
+	// Without a pricing model there's nothing to estimate.
+	assert.Nil(t, newSavingsEstimator(testprovider.NewTestCloudProvider(nil, nil), time.Now()))
+}
+
+func TestScaleDownRemovesNodesSavingMostFirst(t *testing.T) {
+	fakeClient := &fake.Clientset{}
+	deletedNodes := make(chan string, 10)
+	provider := testprovider.NewTestCloudProvider(nil, func(nodeGroup string, node string) error {
+		deletedNodes <- node
+		return nil
+	})
+	provider.SetPricingModel(&testPricingModel{
+		nodePrice: map[string]float64{"n1": 1, "n2": 4, "n3": 2},
+	})
+	provider.AddNodeGroup("ng1", 0, 10, 3)
+	nodes := make([]*apiv1.Node, 0)
+	for i := 1; i <= 3; i++ {
+		node := BuildTestNode(fmt.Sprintf("n%d", i), 1000, 1000)
+		SetNodeReadyState(node, true, time.Time{})
+		provider.AddNode("ng1", node)
+		nodes = append(nodes, node)
+	}
+	fakeClient.Fake.AddReactor("update", "nodes", func(action core.Action) (bool, runtime.Object, error) {
+		return true, action.(core.UpdateAction).GetObject(), nil
+	})
+
+	options := config.AutoscalingOptions{
+		ScaleDownUtilizationThreshold: 0.5,
+		ScaleDownUnneededTime:         time.Minute,
+		MaxEmptyBulkDelete:            1,
+	}
+	context := NewScaleTestAutoscalingContext(options, fakeClient, nil, provider, nil)
+	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
+	scaleDown := NewScaleDown(&context, clusterStateRegistry)
+	initializeClusterSnapshotOrDie(t, scaleDown.context, nodes, []*apiv1.Pod{})
+	scaleDown.UpdateUnneededNodes(nodes, nodes, []*apiv1.Pod{}, time.Now().Add(-5*time.Minute), nil)
+	scaleDownStatus, err := scaleDown.TryToScaleDown(nodes, []*apiv1.Pod{}, nil, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, status.ScaleDownNodeDeleted, scaleDownStatus.Result)
+	assert.Equal(t, "n2", getStringFromChan(deletedNodes))
+	assert.Equal(t, 1, len(scaleDownStatus.ScaledDownNodes))
+	assert.Equal(t, "n2", scaleDownStatus.ScaledDownNodes[0].Node.Name)
+	assert.Equal(t, 4.0, scaleDownStatus.ScaledDownNodes[0].HourlySavings)
+}
@@ -18,7 +18,6 @@ package core
 
 import (
 	"fmt"
-	"sort"
 	"testing"
 	"time"
 
@@ -30,20 +29,24 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
 	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/api"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/simulator"
 	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
 	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/units"
 	kube_client "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 	core "k8s.io/client-go/testing"
+	kube_record "k8s.io/client-go/tools/record"
 
 	"strconv"
 
 	"github.com/stretchr/testify/assert"
 	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/deletetaint"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/drain"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
 	"k8s.io/klog"
 )
@@ -131,6 +134,7 @@ func TestFindUnneededNodes(t *testing.T) {
 
 	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
 	sd := NewScaleDown(&context, clusterStateRegistry)
+	initializeClusterSnapshotOrDie(t, sd.context, []*apiv1.Node{n1, n2, n3, n4, n5, n7, n8, n9}, []*apiv1.Pod{p1, p2, p3, p4, p5, p6})
 	sd.UpdateUnneededNodes([]*apiv1.Node{n1, n2, n3, n4, n5, n7, n8, n9}, []*apiv1.Node{n1, n2, n3, n4, n5, n6, n7, n8, n9},
 		[]*apiv1.Pod{p1, p2, p3, p4, p5, p6}, time.Now(), nil)
 
@@ -144,10 +148,11 @@ func TestFindUnneededNodes(t *testing.T) {
 	assert.Contains(t, sd.podLocationHints, p2.Namespace+"/"+p2.Name)
 	assert.Equal(t, 6, len(sd.nodeUtilizationMap))
 
-	sd.unremovableNodes = make(map[string]time.Time)
+	sd.unremovableNodes = make(map[string]*unremovableNodeRecheck)
 	sd.unneededNodes["n1"] = time.Now()
+	initializeClusterSnapshotOrDie(t, sd.context, []*apiv1.Node{n1, n2, n3, n4}, []*apiv1.Pod{p1, p2, p3, p4})
 	sd.UpdateUnneededNodes([]*apiv1.Node{n1, n2, n3, n4}, []*apiv1.Node{n1, n2, n3, n4}, []*apiv1.Pod{p1, p2, p3, p4}, time.Now(), nil)
-	sd.unremovableNodes = make(map[string]time.Time)
+	sd.unremovableNodes = make(map[string]*unremovableNodeRecheck)
 
 	assert.Equal(t, 1, len(sd.unneededNodes))
 	addTime2, found := sd.unneededNodes["n2"]
@@ -155,17 +160,20 @@ func TestFindUnneededNodes(t *testing.T) {
 	assert.Equal(t, addTime, addTime2)
 	assert.Equal(t, 4, len(sd.nodeUtilizationMap))
 
-	sd.unremovableNodes = make(map[string]time.Time)
+	sd.unremovableNodes = make(map[string]*unremovableNodeRecheck)
+	initializeClusterSnapshotOrDie(t, sd.context, []*apiv1.Node{n1, n2, n3, n4}, []*apiv1.Pod{p1, p2, p3, p4})
 	sd.UpdateUnneededNodes([]*apiv1.Node{n1, n2, n3, n4}, []*apiv1.Node{n1, n3, n4}, []*apiv1.Pod{p1, p2, p3, p4}, time.Now(), nil)
 	assert.Equal(t, 0, len(sd.unneededNodes))
 
 	// Node n1 is unneeded, but should be skipped because it has just recently been found to be unremovable
+	initializeClusterSnapshotOrDie(t, sd.context, []*apiv1.Node{n1}, []*apiv1.Pod{})
 	sd.UpdateUnneededNodes([]*apiv1.Node{n1}, []*apiv1.Node{n1}, []*apiv1.Pod{}, time.Now(), nil)
 	assert.Equal(t, 0, len(sd.unneededNodes))
 	// Verify that no other nodes are in unremovable map.
 	assert.Equal(t, 1, len(sd.unremovableNodes))
 
 	// But it should be checked after timeout
+	initializeClusterSnapshotOrDie(t, sd.context, []*apiv1.Node{n1}, []*apiv1.Pod{})
 	sd.UpdateUnneededNodes([]*apiv1.Node{n1}, []*apiv1.Node{n1}, []*apiv1.Pod{}, time.Now().Add(context.UnremovableNodeRecheckTimeout+time.Second), nil)
 	assert.Equal(t, 1, len(sd.unneededNodes))
 	// Verify that nodes that are no longer unremovable are removed.
@@ -221,6 +229,7 @@ func TestFindUnneededGPUNodes(t *testing.T) {
 
 	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
 	sd := NewScaleDown(&context, clusterStateRegistry)
+	initializeClusterSnapshotOrDie(t, sd.context, []*apiv1.Node{n1, n2, n3}, []*apiv1.Pod{p1, p2, p3})
 	sd.UpdateUnneededNodes([]*apiv1.Node{n1, n2, n3}, []*apiv1.Node{n1, n2, n3},
 		[]*apiv1.Pod{p1, p2, p3}, time.Now(), nil)
 
@@ -232,6 +241,101 @@ func TestFindUnneededGPUNodes(t *testing.T) {
 	assert.Equal(t, 3, len(sd.nodeUtilizationMap))
 }
 
+func TestFindUnneededNodesWithNodeGroupOptions(t *testing.T) {
+	// shared owner reference
+	ownerRef := GenerateOwnerReferences("rs", "ReplicaSet", "extensions/v1beta1", "")
+
+	p1 := BuildTestPod("p1", 400, 0)
+	p1.Spec.NodeName = "n1"
+	p1.OwnerReferences = ownerRef
+
+	p2 := BuildTestPod("p2", 400, 0)
+	p2.Spec.NodeName = "n2"
+	p2.OwnerReferences = ownerRef
+
+	// Node above the global threshold.
+	n1 := BuildTestNode("n1", 1000, 10)
+	// Node above the global threshold, but below the threshold of its node group.
+	n2 := BuildTestNode("n2", 1000, 10)
+
+	SetNodeReadyState(n1, true, time.Time{})
+	SetNodeReadyState(n2, true, time.Time{})
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+	ng2 := provider.BuildNodeGroup("ng2", 0, 10, 1, false, "")
+	ng2.SetOptions(&config.NodeGroupAutoscalingOptions{
+		ScaleDownUtilizationThreshold: 0.5,
+	})
+	provider.InsertNodeGroup(ng2)
+	provider.AddNode("ng1", n1)
+	provider.AddNode("ng2", n2)
+
+	options := config.AutoscalingOptions{
+		ScaleDownUtilizationThreshold: 0.35,
+		UnremovableNodeRecheckTimeout: 5 * time.Minute,
+	}
+	context := NewScaleTestAutoscalingContext(options, &fake.Clientset{}, nil, provider, nil)
+
+	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
+	sd := NewScaleDown(&context, clusterStateRegistry)
+	initializeClusterSnapshotOrDie(t, sd.context, []*apiv1.Node{n1, n2}, []*apiv1.Pod{p1, p2})
+	sd.UpdateUnneededNodes([]*apiv1.Node{n1, n2}, []*apiv1.Node{n1, n2},
+		[]*apiv1.Pod{p1, p2}, time.Now(), nil)
+
+	assert.Equal(t, 1, len(sd.unneededNodes))
+	_, found := sd.unneededNodes["n2"]
+	assert.True(t, found)
+	assert.Equal(t, 2, len(sd.nodeUtilizationMap))
+}
+
+func TestFindUnneededNodesWithScaleDownDisabledNodeGroup(t *testing.T) {
+	// Empty nodes, both would be unneeded if scale down was enabled for their node groups.
+	n1 := BuildTestNode("n1", 1000, 10)
+	n2 := BuildTestNode("n2", 1000, 10)
+
+	SetNodeReadyState(n1, true, time.Time{})
+	SetNodeReadyState(n2, true, time.Time{})
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 0, 10, 1)
+	ng2 := provider.BuildNodeGroup("ng2", 0, 10, 1, false, "")
+	ng2.SetOptions(&config.NodeGroupAutoscalingOptions{
+		ScaleDownUtilizationThreshold: 0.5,
+		ScaleDownDisabled:             true,
+	})
+	provider.InsertNodeGroup(ng2)
+	provider.AddNode("ng1", n1)
+	provider.AddNode("ng2", n2)
+
+	options := config.AutoscalingOptions{
+		ScaleDownUtilizationThreshold: 0.35,
+		UnremovableNodeRecheckTimeout: 5 * time.Minute,
+	}
+	context := NewScaleTestAutoscalingContext(options, &fake.Clientset{}, nil, provider, nil)
+
+	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
+	sd := NewScaleDown(&context, clusterStateRegistry)
+	initializeClusterSnapshotOrDie(t, sd.context, []*apiv1.Node{n1, n2}, []*apiv1.Pod{})
+	now := time.Now()
+	sd.UpdateUnneededNodes([]*apiv1.Node{n1, n2}, []*apiv1.Node{n1, n2}, []*apiv1.Pod{}, now, nil)
+
+	assert.Equal(t, 1, len(sd.unneededNodes))
+	_, found := sd.unneededNodes["n1"]
+	assert.True(t, found)
+	assert.Equal(t, 1, len(sd.nodeUtilizationMap))
+
+	for _, nodeGroupStatus := range clusterStateRegistry.GetStatus(now).NodeGroupStatuses {
+		condition := api.GetConditionByType(api.ClusterAutoscalerScaleDown, nodeGroupStatus.Conditions)
+		if nodeGroupStatus.ProviderID == "ng2" {
+			assert.Equal(t, api.NodeGroupScaleDownDisabledReason, condition.Reason)
+			assert.Equal(t, "candidates=0 unremovable=1", condition.Message)
+		} else {
+			assert.Equal(t, "", condition.Reason)
+		}
+	}
+}
+
 func TestPodsWithPrioritiesFindUnneededNodes(t *testing.T) {
 	// shared owner reference
 	ownerRef := GenerateOwnerReferences("rs", "ReplicaSet", "extensions/v1beta1", "")
@@ -303,6 +407,7 @@ func TestPodsWithPrioritiesFindUnneededNodes(t *testing.T) {
 	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
 	sd := NewScaleDown(&context, clusterStateRegistry)
 
+	initializeClusterSnapshotOrDie(t, sd.context, []*apiv1.Node{n1, n2, n3, n4}, []*apiv1.Pod{p1, p2, p3, p4, p5, p6, p7})
 	sd.UpdateUnneededNodes([]*apiv1.Node{n1, n2, n3, n4}, []*apiv1.Node{n1, n2, n3, n4},
 		[]*apiv1.Pod{p1, p2, p3, p4, p5, p6, p7}, time.Now(), nil)
 	assert.Equal(t, 2, len(sd.unneededNodes))
@@ -316,6 +421,44 @@ func TestPodsWithPrioritiesFindUnneededNodes(t *testing.T) {
 	assert.Equal(t, 4, len(sd.nodeUtilizationMap))
 }
 
+func TestUnremovableReasonKeptUntilRecheck(t *testing.T) {
+	// Node with not replicated pod.
+	p1 := BuildTestPod("p1", 100, 0)
+	p1.Spec.NodeName = "n1"
+	n1 := BuildTestNode("n1", 1000, 10)
+	SetNodeReadyState(n1, true, time.Time{})
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+	provider.AddNode("ng1", n1)
+
+	options := config.AutoscalingOptions{
+		ScaleDownUtilizationThreshold: 0.35,
+		UnremovableNodeRecheckTimeout: 5 * time.Minute,
+	}
+	context := NewScaleTestAutoscalingContext(options, &fake.Clientset{}, nil, provider, nil)
+	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
+	sd := NewScaleDown(&context, clusterStateRegistry)
+
+	expected := []api.UnremovableNode{{
+		Name:              "n1",
+		Reason:            string(simulator.BlockedByPod),
+		BlockingPod:       p1.Namespace + "/" + p1.Name,
+		BlockingPodReason: string(drain.NotReplicated),
+	}}
+	now := time.Now()
+	for _, timestamp := range []time.Time{now, now.Add(time.Minute)} {
+		initializeClusterSnapshotOrDie(t, sd.context, []*apiv1.Node{n1}, []*apiv1.Pod{p1})
+		sd.UpdateUnneededNodes([]*apiv1.Node{n1}, []*apiv1.Node{n1}, []*apiv1.Pod{p1}, timestamp, nil)
+		assert.Equal(t, 0, len(sd.unneededNodes))
+		// The node isn't simulated again in the second call, but the reason found in the first one is still reported.
+		status := clusterStateRegistry.GetStatus(timestamp)
+		assert.Equal(t, 1, len(status.NodeGroupStatuses))
+		assert.Equal(t, expected, status.NodeGroupStatuses[0].UnremovableNodes)
+	}
+	assert.Equal(t, 1, len(sd.unremovableNodes))
+}
+
 func TestFindUnneededMaxCandidates(t *testing.T) {
 	provider := testprovider.NewTestCloudProvider(nil, nil)
 	provider.AddNodeGroup("ng1", 1, 100, 2)
@@ -353,6 +496,7 @@ func TestFindUnneededMaxCandidates(t *testing.T) {
 	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
 	sd := NewScaleDown(&context, clusterStateRegistry)
 
+	initializeClusterSnapshotOrDie(t, sd.context, nodes, pods)
 	sd.UpdateUnneededNodes(nodes, nodes, pods, time.Now(), nil)
 	assert.Equal(t, numCandidates, len(sd.unneededNodes))
 	// Simulate one of the unneeded nodes got deleted
@@ -374,6 +518,7 @@ func TestFindUnneededMaxCandidates(t *testing.T) {
 		}
 	}
 
+	initializeClusterSnapshotOrDie(t, sd.context, nodes, pods)
 	sd.UpdateUnneededNodes(nodes, nodes, pods, time.Now(), nil)
 	// Check that the deleted node was replaced
 	assert.Equal(t, numCandidates, len(sd.unneededNodes))
@@ -419,6 +564,7 @@ func TestFindUnneededEmptyNodes(t *testing.T) {
 	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
 	sd := NewScaleDown(&context, clusterStateRegistry)
 
+	initializeClusterSnapshotOrDie(t, sd.context, nodes, pods)
 	sd.UpdateUnneededNodes(nodes, nodes, pods, time.Now(), nil)
 	for _, node := range sd.unneededNodesList {
 		t.Log(node.Name)
@@ -463,6 +609,7 @@ func TestFindUnneededNodePool(t *testing.T) {
 	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
 	sd := NewScaleDown(&context, clusterStateRegistry)
 
+	initializeClusterSnapshotOrDie(t, sd.context, nodes, pods)
 	sd.UpdateUnneededNodes(nodes, nodes, pods, time.Now(), nil)
 	assert.NotEmpty(t, sd.unneededNodes)
 }
@@ -605,7 +752,7 @@ func TestDeleteNode(t *testing.T) {
 			sd := NewScaleDown(&context, clusterStateRegistry)
 
 			// attempt delete
-			result := sd.deleteNode(n1, pods)
+			result := sd.deleteNode(n1, pods, nil)
 
 			// verify
 			if scenario.expectedDeletion {
@@ -628,235 +775,6 @@ func TestDeleteNode(t *testing.T) {
 	}
 }
 
-func TestDrainNode(t *testing.T) {
-	deletedPods := make(chan string, 10)
-	fakeClient := &fake.Clientset{}
-
-	p1 := BuildTestPod("p1", 100, 0)
-	p2 := BuildTestPod("p2", 300, 0)
-	n1 := BuildTestNode("n1", 1000, 1000)
-	SetNodeReadyState(n1, true, time.Time{})
-
-	fakeClient.Fake.AddReactor("get", "pods", func(action core.Action) (bool, runtime.Object, error) {
-		return true, nil, errors.NewNotFound(apiv1.Resource("pod"), "whatever")
-	})
-	fakeClient.Fake.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
-		createAction := action.(core.CreateAction)
-		if createAction == nil {
-			return false, nil, nil
-		}
-		eviction := createAction.GetObject().(*policyv1.Eviction)
-		if eviction == nil {
-			return false, nil, nil
-		}
-		deletedPods <- eviction.Name
-		return true, nil, nil
-	})
-	_, err := drainNode(n1, []*apiv1.Pod{p1, p2}, fakeClient, kube_util.CreateEventRecorder(fakeClient), 20, 5*time.Second, 0*time.Second, PodEvictionHeadroom)
-	assert.NoError(t, err)
-	deleted := make([]string, 0)
-	deleted = append(deleted, getStringFromChan(deletedPods))
-	deleted = append(deleted, getStringFromChan(deletedPods))
-	sort.Strings(deleted)
-	assert.Equal(t, p1.Name, deleted[0])
-	assert.Equal(t, p2.Name, deleted[1])
-}
-
-func TestDrainNodeWithRescheduled(t *testing.T) {
-	deletedPods := make(chan string, 10)
-	fakeClient := &fake.Clientset{}
-
-	p1 := BuildTestPod("p1", 100, 0)
-	p2 := BuildTestPod("p2", 300, 0)
-	p2Rescheduled := BuildTestPod("p2", 300, 0)
-	p2Rescheduled.Spec.NodeName = "n2"
-	n1 := BuildTestNode("n1", 1000, 1000)
-	SetNodeReadyState(n1, true, time.Time{})
-
-	fakeClient.Fake.AddReactor("get", "pods", func(action core.Action) (bool, runtime.Object, error) {
-		getAction := action.(core.GetAction)
-		if getAction == nil {
-			return false, nil, nil
-		}
-		if getAction.GetName() == "p2" {
-			return true, p2Rescheduled, nil
-		}
-		return true, nil, errors.NewNotFound(apiv1.Resource("pod"), "whatever")
-	})
-	fakeClient.Fake.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
-		createAction := action.(core.CreateAction)
-		if createAction == nil {
-			return false, nil, nil
-		}
-		eviction := createAction.GetObject().(*policyv1.Eviction)
-		if eviction == nil {
-			return false, nil, nil
-		}
-		deletedPods <- eviction.Name
-		return true, nil, nil
-	})
-	_, err := drainNode(n1, []*apiv1.Pod{p1, p2}, fakeClient, kube_util.CreateEventRecorder(fakeClient), 20, 5*time.Second, 0*time.Second, PodEvictionHeadroom)
-	assert.NoError(t, err)
-	deleted := make([]string, 0)
-	deleted = append(deleted, getStringFromChan(deletedPods))
-	deleted = append(deleted, getStringFromChan(deletedPods))
-	sort.Strings(deleted)
-	assert.Equal(t, p1.Name, deleted[0])
-	assert.Equal(t, p2.Name, deleted[1])
-}
-
-func TestDrainNodeWithRetries(t *testing.T) {
-	deletedPods := make(chan string, 10)
-	// Simulate pdb of size 1 by making the 'eviction' goroutine:
-	// - read from (at first empty) channel
-	// - if it's empty, fail and write to it, then retry
-	// - succeed on successful read.
-	ticket := make(chan bool, 1)
-	fakeClient := &fake.Clientset{}
-
-	p1 := BuildTestPod("p1", 100, 0)
-	p2 := BuildTestPod("p2", 300, 0)
-	p3 := BuildTestPod("p3", 300, 0)
-	n1 := BuildTestNode("n1", 1000, 1000)
-	SetNodeReadyState(n1, true, time.Time{})
-
-	fakeClient.Fake.AddReactor("get", "pods", func(action core.Action) (bool, runtime.Object, error) {
-		return true, nil, errors.NewNotFound(apiv1.Resource("pod"), "whatever")
-	})
-	fakeClient.Fake.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
-		createAction := action.(core.CreateAction)
-		if createAction == nil {
-			return false, nil, nil
-		}
-		eviction := createAction.GetObject().(*policyv1.Eviction)
-		if eviction == nil {
-			return false, nil, nil
-		}
-		select {
-		case <-ticket:
-			deletedPods <- eviction.Name
-			return true, nil, nil
-		default:
-			select {
-			case ticket <- true:
-			default:
-			}
-			return true, nil, fmt.Errorf("too many concurrent evictions")
-		}
-	})
-	_, err := drainNode(n1, []*apiv1.Pod{p1, p2, p3}, fakeClient, kube_util.CreateEventRecorder(fakeClient), 20, 5*time.Second, 0*time.Second, PodEvictionHeadroom)
-	assert.NoError(t, err)
-	deleted := make([]string, 0)
-	deleted = append(deleted, getStringFromChan(deletedPods))
-	deleted = append(deleted, getStringFromChan(deletedPods))
-	deleted = append(deleted, getStringFromChan(deletedPods))
-	sort.Strings(deleted)
-	assert.Equal(t, p1.Name, deleted[0])
-	assert.Equal(t, p2.Name, deleted[1])
-	assert.Equal(t, p3.Name, deleted[2])
-}
-
-func TestDrainNodeEvictionFailure(t *testing.T) {
-	fakeClient := &fake.Clientset{}
-
-	p1 := BuildTestPod("p1", 100, 0)
-	p2 := BuildTestPod("p2", 100, 0)
-	p3 := BuildTestPod("p3", 100, 0)
-	p4 := BuildTestPod("p4", 100, 0)
-	n1 := BuildTestNode("n1", 1000, 1000)
-	e2 := fmt.Errorf("eviction_error: p2")
-	e4 := fmt.Errorf("eviction_error: p4")
-	SetNodeReadyState(n1, true, time.Time{})
-
-	fakeClient.Fake.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
-		createAction := action.(core.CreateAction)
-		if createAction == nil {
-			return false, nil, nil
-		}
-		eviction := createAction.GetObject().(*policyv1.Eviction)
-		if eviction == nil {
-			return false, nil, nil
-		}
-
-		if eviction.Name == "p2" {
-			return true, nil, e2
-		}
-		if eviction.Name == "p4" {
-			return true, nil, e4
-		}
-		return true, nil, nil
-	})
-
-	evictionResults, err := drainNode(n1, []*apiv1.Pod{p1, p2, p3, p4}, fakeClient, kube_util.CreateEventRecorder(fakeClient), 20, 0*time.Second, 0*time.Second, PodEvictionHeadroom)
-	assert.Error(t, err)
-	assert.Equal(t, 4, len(evictionResults))
-	assert.Equal(t, *p1, *evictionResults["p1"].Pod)
-	assert.Equal(t, *p2, *evictionResults["p2"].Pod)
-	assert.Equal(t, *p3, *evictionResults["p3"].Pod)
-	assert.Equal(t, *p4, *evictionResults["p4"].Pod)
-	assert.NoError(t, evictionResults["p1"].Err)
-	assert.Contains(t, evictionResults["p2"].Err.Error(), e2.Error())
-	assert.NoError(t, evictionResults["p3"].Err)
-	assert.Contains(t, evictionResults["p4"].Err.Error(), e4.Error())
-	assert.False(t, evictionResults["p1"].TimedOut)
-	assert.True(t, evictionResults["p2"].TimedOut)
-	assert.False(t, evictionResults["p3"].TimedOut)
-	assert.True(t, evictionResults["p4"].TimedOut)
-	assert.True(t, evictionResults["p1"].WasEvictionSuccessful())
-	assert.False(t, evictionResults["p2"].WasEvictionSuccessful())
-	assert.True(t, evictionResults["p3"].WasEvictionSuccessful())
-	assert.False(t, evictionResults["p4"].WasEvictionSuccessful())
-}
-
-func TestDrainNodeDisappearanceFailure(t *testing.T) {
-	fakeClient := &fake.Clientset{}
-
-	p1 := BuildTestPod("p1", 100, 0)
-	p2 := BuildTestPod("p2", 100, 0)
-	p3 := BuildTestPod("p3", 100, 0)
-	p4 := BuildTestPod("p4", 100, 0)
-	e2 := fmt.Errorf("disappearance_error: p2")
-	n1 := BuildTestNode("n1", 1000, 1000)
-	SetNodeReadyState(n1, true, time.Time{})
-
-	fakeClient.Fake.AddReactor("get", "pods", func(action core.Action) (bool, runtime.Object, error) {
-		getAction := action.(core.GetAction)
-		if getAction == nil {
-			return false, nil, nil
-		}
-		if getAction.GetName() == "p2" {
-			return true, nil, e2
-		}
-		if getAction.GetName() == "p4" {
-			return true, nil, nil
-		}
-		return true, nil, errors.NewNotFound(apiv1.Resource("pod"), "whatever")
-	})
-	fakeClient.Fake.AddReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
-		return true, nil, nil
-	})
-
-	evictionResults, err := drainNode(n1, []*apiv1.Pod{p1, p2, p3, p4}, fakeClient, kube_util.CreateEventRecorder(fakeClient), 0, 0*time.Second, 0*time.Second, 0*time.Second)
-	assert.Error(t, err)
-	assert.Equal(t, 4, len(evictionResults))
-	assert.Equal(t, *p1, *evictionResults["p1"].Pod)
-	assert.Equal(t, *p2, *evictionResults["p2"].Pod)
-	assert.Equal(t, *p3, *evictionResults["p3"].Pod)
-	assert.Equal(t, *p4, *evictionResults["p4"].Pod)
-	assert.NoError(t, evictionResults["p1"].Err)
-	assert.Contains(t, evictionResults["p2"].Err.Error(), e2.Error())
-	assert.NoError(t, evictionResults["p3"].Err)
-	assert.NoError(t, evictionResults["p4"].Err)
-	assert.False(t, evictionResults["p1"].TimedOut)
-	assert.True(t, evictionResults["p2"].TimedOut)
-	assert.False(t, evictionResults["p3"].TimedOut)
-	assert.True(t, evictionResults["p4"].TimedOut)
-	assert.True(t, evictionResults["p1"].WasEvictionSuccessful())
-	assert.False(t, evictionResults["p2"].WasEvictionSuccessful())
-	assert.True(t, evictionResults["p3"].WasEvictionSuccessful())
-	assert.False(t, evictionResults["p4"].WasEvictionSuccessful())
-}
-
 func TestScaleDown(t *testing.T) {
 	deletedPods := make(chan string, 10)
 	updatedNodes := make(chan string, 10)
@@ -938,6 +856,7 @@ func TestScaleDown(t *testing.T) {
 
 	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
 	scaleDown := NewScaleDown(&context, clusterStateRegistry)
+	initializeClusterSnapshotOrDie(t, scaleDown.context, []*apiv1.Node{n1, n2}, []*apiv1.Pod{p1, p2, p3})
 	scaleDown.UpdateUnneededNodes([]*apiv1.Node{n1, n2},
 		[]*apiv1.Node{n1, n2}, []*apiv1.Pod{p1, p2, p3}, time.Now().Add(-5*time.Minute), nil)
 	scaleDownStatus, err := scaleDown.TryToScaleDown([]*apiv1.Node{n1, n2}, []*apiv1.Pod{p1, p2, p3}, nil, time.Now())
@@ -948,6 +867,97 @@ func TestScaleDown(t *testing.T) {
 	assert.Equal(t, n1.Name, getStringFromChan(updatedNodes))
 }
 
+func TestScaleDownParallel(t *testing.T) {
+	deletedNodes := make(chan string, 10)
+	fakeClient := &fake.Clientset{}
+
+	job := batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "job",
+			Namespace: "default",
+			SelfLink:  "/apivs/batch/v1/namespaces/default/jobs/job",
+		},
+	}
+	// Pods of n1 and n2 can't be moved to each other, only to n3 and n4, whose pods can't be moved at all.
+	nodes := make([]*apiv1.Node, 0)
+	for i := 1; i <= 4; i++ {
+		node := BuildTestNode(fmt.Sprintf("n%d", i), 1000, 1000)
+		SetNodeReadyState(node, true, time.Time{})
+		nodes = append(nodes, node)
+	}
+	p1 := BuildTestPod("p1", 600, 0)
+	p1.OwnerReferences = GenerateOwnerReferences(job.Name, "Job", "batch/v1", "")
+	p1.Spec.NodeName = "n1"
+	p2 := BuildTestPod("p2", 600, 0)
+	p2.OwnerReferences = GenerateOwnerReferences(job.Name, "Job", "batch/v1", "")
+	p2.Spec.NodeName = "n2"
+	p3 := BuildTestPod("p3", 300, 0)
+	p3.Spec.NodeName = "n3"
+	p4 := BuildTestPod("p4", 300, 0)
+	p4.Spec.NodeName = "n4"
+	pods := []*apiv1.Pod{p1, p2, p3, p4}
+
+	fakeClient.Fake.AddReactor("get", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewNotFound(apiv1.Resource("pod"), "whatever")
+	})
+	fakeClient.Fake.AddReactor("get", "nodes", func(action core.Action) (bool, runtime.Object, error) {
+		getAction := action.(core.GetAction)
+		for _, node := range nodes {
+			if node.Name == getAction.GetName() {
+				return true, node, nil
+			}
+		}
+		return true, nil, fmt.Errorf("wrong node: %v", getAction.GetName())
+	})
+	fakeClient.Fake.AddReactor("update", "nodes", func(action core.Action) (bool, runtime.Object, error) {
+		return true, action.(core.UpdateAction).GetObject(), nil
+	})
+
+	provider := testprovider.NewTestCloudProvider(nil, func(nodeGroup string, node string) error {
+		deletedNodes <- node
+		return nil
+	})
+	provider.AddNodeGroup("ng1", 1, 10, 4)
+	for _, node := range nodes {
+		provider.AddNode("ng1", node)
+	}
+
+	options := config.AutoscalingOptions{
+		ScaleDownUtilizationThreshold: 0.7,
+		ScaleDownUnneededTime:         time.Minute,
+		MaxGracefulTerminationSec:     60,
+		MaxDrainParallelism:           3,
+	}
+	jobLister, err := kube_util.NewTestJobLister([]*batchv1.Job{&job})
+	assert.NoError(t, err)
+	registry := kube_util.NewListerRegistry(nil, nil, nil, nil, nil, nil, nil, jobLister, nil, nil)
+
+	context := NewScaleTestAutoscalingContext(options, fakeClient, registry, provider, nil)
+	// Draining two nodes emits more events than the default fake recorder buffers.
+	context.Recorder = kube_record.NewFakeRecorder(100)
+
+	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
+	scaleDown := NewScaleDown(&context, clusterStateRegistry)
+	initializeClusterSnapshotOrDie(t, scaleDown.context, nodes, pods)
+	scaleDown.UpdateUnneededNodes(nodes, nodes, pods, time.Now().Add(-5*time.Minute), nil)
+	scaleDownStatus, err := scaleDown.TryToScaleDown(nodes, pods, nil, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, status.ScaleDownNodeDeleteStarted, scaleDownStatus.Result)
+	scaledDown := make([]string, 0)
+	for _, scaledDownNode := range scaleDownStatus.ScaledDownNodes {
+		scaledDown = append(scaledDown, scaledDownNode.Node.Name)
+	}
+	assertEqualSet(t, []string{"n1", "n2"}, scaledDown)
+	assertEqualSet(t, []string{"n1", "n2"}, scaleDown.nodeDeleteStatus.DeletionsInProgress())
+	waitForDeleteToFinish(t, scaleDown)
+	assertEqualSet(t, []string{"n1", "n2"}, []string{getStringFromChan(deletedNodes), getStringFromChan(deletedNodes)})
+	// The simulated removals are reverted.
+	for _, node := range nodes {
+		_, found := context.ClusterSnapshot.GetNodeInfo(node.Name)
+		assert.True(t, found)
+	}
+}
+
 func waitForDeleteToFinish(t *testing.T, sd *ScaleDown) {
 	for start := time.Now(); time.Since(start) < 20*time.Second; time.Sleep(100 * time.Millisecond) {
 		if !sd.nodeDeleteStatus.IsDeleteInProgress() {
@@ -1151,6 +1161,7 @@ func simpleScaleDownEmpty(t *testing.T, config *scaleTestConfig) {
 
 	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
 	scaleDown := NewScaleDown(&context, clusterStateRegistry)
+	initializeClusterSnapshotOrDie(t, scaleDown.context, nodes, []*apiv1.Pod{})
 	scaleDown.UpdateUnneededNodes(nodes,
 		nodes, []*apiv1.Pod{}, time.Now().Add(-5*time.Minute), nil)
 	scaleDownStatus, err := scaleDown.TryToScaleDown(nodes, []*apiv1.Pod{}, nil, time.Now())
@@ -1227,6 +1238,7 @@ func TestNoScaleDownUnready(t *testing.T) {
 	// N1 is unready so it requires a bigger unneeded time.
 	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
 	scaleDown := NewScaleDown(&context, clusterStateRegistry)
+	initializeClusterSnapshotOrDie(t, scaleDown.context, []*apiv1.Node{n1, n2}, []*apiv1.Pod{p2})
 	scaleDown.UpdateUnneededNodes([]*apiv1.Node{n1, n2},
 		[]*apiv1.Node{n1, n2}, []*apiv1.Pod{p2}, time.Now().Add(-5*time.Minute), nil)
 	scaleDownStatus, err := scaleDown.TryToScaleDown([]*apiv1.Node{n1, n2}, []*apiv1.Pod{p2}, nil, time.Now())
@@ -1249,6 +1261,7 @@ func TestNoScaleDownUnready(t *testing.T) {
 	// N1 has been unready for 2 hours, ok to delete.
 	context.CloudProvider = provider
 	scaleDown = NewScaleDown(&context, clusterStateRegistry)
+	initializeClusterSnapshotOrDie(t, scaleDown.context, []*apiv1.Node{n1, n2}, []*apiv1.Pod{p2})
 	scaleDown.UpdateUnneededNodes([]*apiv1.Node{n1, n2}, []*apiv1.Node{n1, n2},
 		[]*apiv1.Pod{p2}, time.Now().Add(-2*time.Hour), nil)
 	scaleDownStatus, err = scaleDown.TryToScaleDown([]*apiv1.Node{n1, n2}, []*apiv1.Pod{p2}, nil, time.Now())
@@ -1330,6 +1343,7 @@ func TestScaleDownNoMove(t *testing.T) {
 
 	clusterStateRegistry := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{}, context.LogRecorder, newBackoff())
 	scaleDown := NewScaleDown(&context, clusterStateRegistry)
+	initializeClusterSnapshotOrDie(t, scaleDown.context, []*apiv1.Node{n1, n2}, []*apiv1.Pod{p1, p2})
 	scaleDown.UpdateUnneededNodes([]*apiv1.Node{n1, n2}, []*apiv1.Node{n1, n2},
 		[]*apiv1.Pod{p1, p2}, time.Now().Add(5*time.Minute), nil)
 	scaleDownStatus, err := scaleDown.TryToScaleDown([]*apiv1.Node{n1, n2}, []*apiv1.Pod{p1, p2}, nil, time.Now())
@@ -1581,6 +1595,7 @@ func TestSoftTaint(t *testing.T) {
 	scaleDown := NewScaleDown(&context, clusterStateRegistry)
 
 	// Test no superfluous nodes
+	initializeClusterSnapshotOrDie(t, scaleDown.context, []*apiv1.Node{n1000, n2000}, []*apiv1.Pod{p500, p700, p1200})
 	scaleDown.UpdateUnneededNodes([]*apiv1.Node{n1000, n2000},
 		[]*apiv1.Node{n1000, n2000}, []*apiv1.Pod{p500, p700, p1200}, time.Now().Add(-5*time.Minute), nil)
 	errs := scaleDown.SoftTaintUnneededNodes(getAllNodes(t, fakeClient))
@@ -1589,6 +1604,7 @@ func TestSoftTaint(t *testing.T) {
 	assert.False(t, hasDeletionCandidateTaint(t, fakeClient, n2000.Name))
 
 	// Test one unneeded node
+	initializeClusterSnapshotOrDie(t, scaleDown.context, []*apiv1.Node{n1000, n2000}, []*apiv1.Pod{p500, p1200})
 	scaleDown.UpdateUnneededNodes([]*apiv1.Node{n1000, n2000},
 		[]*apiv1.Node{n1000, n2000}, []*apiv1.Pod{p500, p1200}, time.Now().Add(-5*time.Minute), nil)
 	errs = scaleDown.SoftTaintUnneededNodes(getAllNodes(t, fakeClient))
@@ -1597,6 +1613,7 @@ func TestSoftTaint(t *testing.T) {
 	assert.False(t, hasDeletionCandidateTaint(t, fakeClient, n2000.Name))
 
 	// Test remove soft taint
+	initializeClusterSnapshotOrDie(t, scaleDown.context, []*apiv1.Node{n1000, n2000}, []*apiv1.Pod{p500, p700, p1200})
 	scaleDown.UpdateUnneededNodes([]*apiv1.Node{n1000, n2000},
 		[]*apiv1.Node{n1000, n2000}, []*apiv1.Pod{p500, p700, p1200}, time.Now().Add(-5*time.Minute), nil)
 	errs = scaleDown.SoftTaintUnneededNodes(getAllNodes(t, fakeClient))
@@ -1605,6 +1622,7 @@ func TestSoftTaint(t *testing.T) {
 	assert.False(t, hasDeletionCandidateTaint(t, fakeClient, n2000.Name))
 
 	// Test bulk update taint limit
+	initializeClusterSnapshotOrDie(t, scaleDown.context, []*apiv1.Node{n1000, n2000}, []*apiv1.Pod{})
 	scaleDown.UpdateUnneededNodes([]*apiv1.Node{n1000, n2000},
 		[]*apiv1.Node{n1000, n2000}, []*apiv1.Pod{}, time.Now().Add(-5*time.Minute), nil)
 	errs = scaleDown.SoftTaintUnneededNodes(getAllNodes(t, fakeClient))
@@ -1615,6 +1633,7 @@ func TestSoftTaint(t *testing.T) {
 	assert.Equal(t, 2, countDeletionCandidateTaints(t, fakeClient))
 
 	// Test bulk update untaint limit
+	initializeClusterSnapshotOrDie(t, scaleDown.context, []*apiv1.Node{n1000, n2000}, []*apiv1.Pod{p500, p700, p1200})
 	scaleDown.UpdateUnneededNodes([]*apiv1.Node{n1000, n2000},
 		[]*apiv1.Node{n1000, n2000}, []*apiv1.Pod{p500, p700, p1200}, time.Now().Add(-5*time.Minute), nil)
 	errs = scaleDown.SoftTaintUnneededNodes(getAllNodes(t, fakeClient))
@@ -1691,6 +1710,7 @@ func TestSoftTaintTimeLimit(t *testing.T) {
 	scaleDown := NewScaleDown(&context, clusterStateRegistry)
 
 	// Test bulk taint
+	initializeClusterSnapshotOrDie(t, scaleDown.context, []*apiv1.Node{n1, n2}, []*apiv1.Pod{})
 	scaleDown.UpdateUnneededNodes([]*apiv1.Node{n1, n2},
 		[]*apiv1.Node{n1, n2}, []*apiv1.Pod{}, time.Now().Add(-5*time.Minute), nil)
 	errs := scaleDown.SoftTaintUnneededNodes(getAllNodes(t, fakeClient))
@@ -1700,6 +1720,7 @@ func TestSoftTaintTimeLimit(t *testing.T) {
 	assert.True(t, hasDeletionCandidateTaint(t, fakeClient, n2.Name))
 
 	// Test bulk untaint
+	initializeClusterSnapshotOrDie(t, scaleDown.context, []*apiv1.Node{n1, n2}, []*apiv1.Pod{p1, p2})
 	scaleDown.UpdateUnneededNodes([]*apiv1.Node{n1, n2},
 		[]*apiv1.Node{n1, n2}, []*apiv1.Pod{p1, p2}, time.Now().Add(-5*time.Minute), nil)
 	errs = scaleDown.SoftTaintUnneededNodes(getAllNodes(t, fakeClient))
@@ -1711,6 +1732,7 @@ func TestSoftTaintTimeLimit(t *testing.T) {
 	updateTime = maxSoftTaintDuration
 
 	// Test duration limit of bulk taint
+	initializeClusterSnapshotOrDie(t, scaleDown.context, []*apiv1.Node{n1, n2}, []*apiv1.Pod{})
 	scaleDown.UpdateUnneededNodes([]*apiv1.Node{n1, n2},
 		[]*apiv1.Node{n1, n2}, []*apiv1.Pod{}, time.Now().Add(-5*time.Minute), nil)
 	errs = scaleDown.SoftTaintUnneededNodes(getAllNodes(t, fakeClient))
@@ -1721,6 +1743,7 @@ func TestSoftTaintTimeLimit(t *testing.T) {
 	assert.Equal(t, 2, countDeletionCandidateTaints(t, fakeClient))
 
 	// Test duration limit of bulk untaint
+	initializeClusterSnapshotOrDie(t, scaleDown.context, []*apiv1.Node{n1, n2}, []*apiv1.Pod{p1, p2})
 	scaleDown.UpdateUnneededNodes([]*apiv1.Node{n1, n2},
 		[]*apiv1.Node{n1, n2}, []*apiv1.Pod{p1, p2}, time.Now().Add(-5*time.Minute), nil)
 	errs = scaleDown.SoftTaintUnneededNodes(getAllNodes(t, fakeClient))
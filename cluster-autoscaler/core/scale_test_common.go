@@ -97,12 +97,20 @@ func NewScaleTestAutoscalingContext(
 		},
 		CloudProvider:      provider,
 		PredicateChecker:   simulator.NewTestPredicateChecker(),
+		ClusterSnapshot:    simulator.NewBasicClusterSnapshot(),
 		ExpanderStrategy:   random.NewStrategy(),
 		EstimatorBuilder:   estimatorBuilder,
 		ProcessorCallbacks: processorCallbacks,
 	}
 }
 
+// initializeClusterSnapshotOrDie initializes the cluster snapshot of the context the way it's done at the
+// beginning of every loop, skipping expendable pods.
+func initializeClusterSnapshotOrDie(t *testing.T, context *context.AutoscalingContext, nodes []*apiv1.Node, pods []*apiv1.Pod) {
+	err := simulator.InitializeClusterSnapshot(context.ClusterSnapshot, nodes, filterOutExpendablePods(pods, context.ExpendablePodsPriorityCutoff))
+	assert.NoError(t, err)
+}
+
 type mockAutoprovisioningNodeGroupManager struct {
 	t *testing.T
 }
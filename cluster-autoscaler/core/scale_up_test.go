@@ -341,6 +341,14 @@ func (s assertingStrategy) BestOption(options []expander.Option, nodeInfo map[st
 	return nil
 }
 
+func (s assertingStrategy) BestOptions(options []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) []expander.Option {
+	best := s.BestOption(options, nodeInfo)
+	if best == nil {
+		return nil
+	}
+	return []expander.Option{*best}
+}
+
 func expanderOptionsToGroupSizeChanges(options []expander.Option) []groupSizeChange {
 	groupSizeChanges := make([]groupSizeChange, 0, len(options))
 	for _, option := range options {
@@ -32,6 +32,7 @@ import (
 	"k8s.io/autoscaler/cluster-autoscaler/metrics"
 	ca_processors "k8s.io/autoscaler/cluster-autoscaler/processors"
 	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
+	"k8s.io/autoscaler/cluster-autoscaler/replay"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/backoff"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/deletetaint"
@@ -68,6 +69,10 @@ type StaticAutoscaler struct {
 	processors              *ca_processors.AutoscalingProcessors
 	processorCallbacks      *staticAutoscalerProcessorCallbacks
 	initialized             bool
+	stateCapturer           *replay.StateCapturer
+	// clusterStateStore, if set, persists the state of clusterStateRegistry across restarts.
+	clusterStateStore    clusterstate.StateStore
+	clusterStateRestored bool
 	// Caches nodeInfo computed for previously seen nodes
 	nodeInfoCache map[string]*schedulernodeinfo.NodeInfo
 }
@@ -94,6 +99,7 @@ func (callbacks *staticAutoscalerProcessorCallbacks) reset() {
 func NewStaticAutoscaler(
 	opts config.AutoscalingOptions,
 	predicateChecker *simulator.PredicateChecker,
+	clusterSnapshot simulator.ClusterSnapshot,
 	autoscalingKubeClients *context.AutoscalingKubeClients,
 	processors *ca_processors.AutoscalingProcessors,
 	cloudProvider cloudprovider.CloudProvider,
@@ -102,7 +108,7 @@ func NewStaticAutoscaler(
 	backoff backoff.Backoff) *StaticAutoscaler {
 
 	processorCallbacks := newStaticAutoscalerProcessorCallbacks()
-	autoscalingContext := context.NewAutoscalingContext(opts, predicateChecker, autoscalingKubeClients, cloudProvider, expanderStrategy, estimatorBuilder, processorCallbacks)
+	autoscalingContext := context.NewAutoscalingContext(opts, predicateChecker, clusterSnapshot, autoscalingKubeClients, cloudProvider, expanderStrategy, estimatorBuilder, processorCallbacks)
 
 	clusterStateConfig := clusterstate.ClusterStateRegistryConfig{
 		MaxTotalUnreadyPercentage: opts.MaxTotalUnreadyPercentage,
@@ -135,7 +141,10 @@ func (a *StaticAutoscaler) cleanUpIfRequired() {
 	}
 
 	// CA can die at any time. Removing taints that might have been left from the previous run.
-	if readyNodes, err := a.ReadyNodeLister().List(); err != nil {
+	// No taints are added in dry-run mode, existing ones may belong to another instance of CA.
+	if a.DryRun {
+		klog.V(1).Infof("Dry-run mode, not cleaning up taints")
+	} else if readyNodes, err := a.ReadyNodeLister().List(); err != nil {
 		klog.Errorf("Failed to list ready nodes, not cleaning up taints: %v", err)
 	} else {
 		deletetaint.CleanAllToBeDeleted(readyNodes, a.AutoscalingContext.ClientSet, a.Recorder)
@@ -147,11 +156,53 @@ func (a *StaticAutoscaler) cleanUpIfRequired() {
 	a.initialized = true
 }
 
+// restoreClusterStateIfRequired restores the persisted state of the cluster state registry, once per runtime.
+// Until it succeeds, the loop is skipped, so that in-progress scale-ups aren't repeated.
+func (a *StaticAutoscaler) restoreClusterStateIfRequired() errors.AutoscalerError {
+	if a.clusterStateStore == nil || a.clusterStateRestored {
+		return nil
+	}
+	state, err := a.clusterStateStore.Load()
+	if err != nil {
+		klog.Errorf("Failed to load persisted cluster state: %v", err)
+		return errors.ToAutoscalerError(errors.ApiCallError, err)
+	}
+	if state != nil {
+		a.clusterStateRegistry.RestorePersistentState(state)
+		klog.V(1).Infof("Restored cluster state with %d scale-ups in progress", len(state.ScaleUpRequests))
+	}
+	a.clusterStateRestored = true
+	return nil
+}
+
+// saveClusterState persists the state of the cluster state registry. Failures are retried in the next loop.
+// In dry-run mode the state is never saved, the persisted one may belong to another instance of CA.
+func (a *StaticAutoscaler) saveClusterState() {
+	if a.clusterStateStore == nil || !a.clusterStateRestored || a.DryRun {
+		return
+	}
+	if err := a.clusterStateStore.Save(a.clusterStateRegistry.GetPersistentState()); err != nil {
+		klog.Errorf("Failed to persist cluster state: %v", err)
+	}
+}
+
 // RunOnce iterates over node groups and scales them up/down if necessary
 func (a *StaticAutoscaler) RunOnce(currentTime time.Time) errors.AutoscalerError {
 	a.cleanUpIfRequired()
 	a.processorCallbacks.reset()
 
+	// Options loaded from the config map apply to the whole loop, so they are refreshed first.
+	if a.processors != nil && a.processors.DynamicOptionsProcessor != nil {
+		a.processors.DynamicOptionsProcessor.Refresh(a.AutoscalingContext, currentTime)
+		a.clusterStateRegistry.UpdateOptionsCondition(a.processors.DynamicOptionsProcessor.Condition())
+	}
+
+	if a.stateCapturer != nil {
+		if err := a.stateCapturer.CaptureIfRequested(a.AutoscalingContext, currentTime); err != nil {
+			klog.Errorf("Failed to capture cluster state: %v", err)
+		}
+	}
+
 	unschedulablePodLister := a.UnschedulablePodLister()
 	scheduledPodLister := a.ScheduledPodLister()
 	pdbLister := a.PodDisruptionBudgetLister()
@@ -181,6 +232,12 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) errors.AutoscalerError
 		klog.Errorf("Failed to refresh cloud provider config: %v", err)
 		return errors.ToAutoscalerError(errors.CloudProviderError, err)
 	}
+	if a.processors != nil && a.processors.ScheduledCapacityProcessor != nil {
+		a.processors.ScheduledCapacityProcessor.Refresh(autoscalingContext, currentTime)
+	}
+	if typedErr := a.restoreClusterStateIfRequired(); typedErr != nil {
+		return typedErr
+	}
 
 	nodeInfosForGroups, autoscalerError := getNodeInfosForGroups(
 		readyNodes, a.nodeInfoCache, autoscalingContext.CloudProvider, autoscalingContext.ListerRegistry, daemonsets, autoscalingContext.PredicateChecker)
@@ -200,6 +257,8 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) errors.AutoscalerError
 	scaleDownStatusProcessorAlreadyCalled := false
 
 	defer func() {
+		a.saveClusterState()
+
 		// Update status information when the loop is done (regardless of reason)
 		if autoscalingContext.WriteStatusConfigMap {
 			status := a.clusterStateRegistry.GetStatus(currentTime)
@@ -220,6 +279,10 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) errors.AutoscalerError
 		if err != nil {
 			klog.Errorf("AutoscalingStatusProcessor error: %v.", err)
 		}
+
+		if autoscalingContext.DryRun {
+			reportDryRunActions(autoscalingContext)
+		}
 	}()
 
 	// Check if there are any nodes that failed to register in Kubernetes
@@ -297,11 +360,25 @@ func (a *StaticAutoscaler) RunOnce(currentTime time.Time) errors.AutoscalerError
 	// we tread pods with nominated node-name as scheduled for sake of scale-up considerations
 	scheduledPods = append(scheduledPods, unschedulableWaitingForLowerPriorityPreemption...)
 
+	// The snapshot is shared by all simulations in this loop. Only non expendable pods and pods waiting for
+	// lower priority pods preemption take space on nodes.
+	nonExpendableScheduledPods := filterOutExpendablePods(scheduledPods, a.ExpendablePodsPriorityCutoff)
+	if err := simulator.InitializeClusterSnapshot(a.ClusterSnapshot, allNodes, nonExpendableScheduledPods); err != nil {
+		klog.Errorf("Failed to initialize cluster snapshot: %v", err)
+		return errors.ToAutoscalerError(errors.InternalError, err)
+	}
+
 	unschedulablePodsToHelp, scheduledPods, err := a.processors.PodListProcessor.Process(a.AutoscalingContext, unschedulablePods, scheduledPods, allNodes, readyNodes)
 
 	// finally, filter out pods that are too "young" to safely be considered for a scale-up (delay is configurable)
 	unschedulablePodsToHelp = a.filterOutYoungPods(unschedulablePodsToHelp, currentTime)
 
+	// Placeholders for capacity required by schedules are handled like any other pending pods.
+	if a.processors != nil && a.processors.ScheduledCapacityProcessor != nil {
+		unschedulablePodsToHelp = append(unschedulablePodsToHelp,
+			a.processors.ScheduledCapacityProcessor.PlaceholderPods(autoscalingContext, nodeInfosForGroups)...)
+	}
+
 	if len(unschedulablePodsToHelp) == 0 {
 		scaleUpStatus.Result = status.ScaleUpNotNeeded
 		klog.V(1).Info("No unschedulable pods")
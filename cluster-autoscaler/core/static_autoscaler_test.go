@@ -17,6 +17,7 @@ limitations under the License.
 package core
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 	"testing"
@@ -27,17 +28,24 @@ import (
 	mockprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/mocks"
 	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
 	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/api"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/estimator"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/dynamicoptions"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/scheduledcapacity"
 	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
 	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
 
 	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes/fake"
 	v1appslister "k8s.io/client-go/listers/apps/v1"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	kube_record "k8s.io/client-go/tools/record"
 	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
 
 	"github.com/stretchr/testify/assert"
@@ -1028,3 +1036,199 @@ func TestStaticAutoscalerOutOfResources(t *testing.T) {
 	// we expect no more Delete Nodes
 	nodeGroupA.AssertNumberOfCalls(t, "DeleteNodes", 2)
 }
+
+func TestStaticAutoscalerRunOnceWithScheduledCapacity(t *testing.T) {
+	now := time.Now()
+	onScaleUpMock := &onScaleUpMock{}
+	onScaleDownMock := &onScaleDownMock{}
+
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Now())
+	p1 := BuildTestPod("p1", 600, 100)
+	p1.Spec.NodeName = "n1"
+
+	provider := testprovider.NewTestCloudProvider(
+		func(id string, delta int) error {
+			return onScaleUpMock.ScaleUp(id, delta)
+		}, func(id string, name string) error {
+			return onScaleDownMock.ScaleDown(id, name)
+		})
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+	provider.AddNode("ng1", n1)
+
+	configMapLister, err := kube_util.NewTestConfigMapLister([]*apiv1.ConfigMap{{
+		ObjectMeta: metav1.ObjectMeta{Name: scheduledcapacity.ScheduledCapacityConfigMapName, Namespace: "kube-system"},
+		Data: map[string]string{
+			scheduledcapacity.ConfigMapKey: fmt.Sprintf(`[{name: next-hour, nodeGroups: [ng1], cron: "* %d * * *", minSize: 3}]`,
+				now.Add(time.Hour).UTC().Hour()),
+		},
+	}})
+	assert.NoError(t, err)
+
+	options := config.AutoscalingOptions{
+		EstimatorName:                       estimator.BinpackingEstimatorName,
+		ScaleDownEnabled:                    true,
+		ScaleDownUtilizationThreshold:       0.5,
+		MaxNodesTotal:                       10,
+		MaxCoresTotal:                       10,
+		MaxMemoryTotal:                      100000,
+		ScaleDownUnreadyTime:                time.Minute,
+		ScaleDownUnneededTime:               time.Minute,
+		FilterOutSchedulablePodsUsesPacking: true,
+		ConfigNamespace:                     "kube-system",
+	}
+	processorCallbacks := newStaticAutoscalerProcessorCallbacks()
+	context := NewScaleTestAutoscalingContext(options, &fake.Clientset{}, nil, provider, processorCallbacks)
+	processors := NewTestProcessors()
+	processors.ScheduledCapacityProcessor = scheduledcapacity.NewScheduledCapacityProcessor(
+		configMapLister.ConfigMaps("kube-system"), context.Recorder, processors.NodeGroupListProcessor)
+	processors.NodeGroupListProcessor = processors.ScheduledCapacityProcessor
+	context.CloudProvider = scheduledcapacity.NewCloudProvider(provider, processors.ScheduledCapacityProcessor)
+	context.ListerRegistry = kube_util.NewListerRegistry(
+		kube_util.NewTestNodeLister([]*apiv1.Node{n1}), kube_util.NewTestNodeLister([]*apiv1.Node{n1}),
+		kube_util.NewTestPodLister([]*apiv1.Pod{p1}), kube_util.NewTestPodLister(nil),
+		kube_util.NewTestPodDisruptionBudgetLister(nil), &daemonSetListerMock{},
+		nil, nil, nil, nil)
+	daemonSetLister := context.ListerRegistry.DaemonSetLister().(*daemonSetListerMock)
+	daemonSetLister.On("List", labels.Everything()).Return([]*appsv1.DaemonSet{}, nil)
+
+	clusterStateConfig := clusterstate.ClusterStateRegistryConfig{
+		OkTotalUnreadyCount:  1,
+		MaxNodeProvisionTime: 10 * time.Second,
+	}
+	clusterState := clusterstate.NewClusterStateRegistry(context.CloudProvider, clusterStateConfig, context.LogRecorder, newBackoff())
+	autoscaler := &StaticAutoscaler{
+		AutoscalingContext:    &context,
+		clusterStateRegistry:  clusterState,
+		lastScaleUpTime:       time.Now(),
+		lastScaleDownFailTime: time.Now(),
+		scaleDown:             NewScaleDown(&context, clusterState),
+		processors:            processors,
+		processorCallbacks:    processorCallbacks,
+		initialized:           true,
+	}
+
+	// There are no pending pods, but placeholders scale ng1 up to its scheduled minimum size.
+	onScaleUpMock.On("ScaleUp", "ng1", 2).Return(nil).Once()
+	err = autoscaler.RunOnce(now.Add(time.Hour))
+	assert.NoError(t, err)
+	mock.AssertExpectationsForObjects(t, onScaleUpMock, onScaleDownMock)
+
+	// While the schedule is active, the raised minimum size protects the nodes from scale-down.
+	n2 := BuildTestNode("n2", 1000, 1000)
+	SetNodeReadyState(n2, true, time.Now())
+	n3 := BuildTestNode("n3", 1000, 1000)
+	SetNodeReadyState(n3, true, time.Now())
+	provider.AddNode("ng1", n2)
+	provider.AddNode("ng1", n3)
+	provider.GetNodeGroup("ng1").(*testprovider.TestNodeGroup).SetTargetSize(3)
+	assert.Empty(t, getPotentiallyUnneededNodes(&context, []*apiv1.Node{n1, n2, n3}))
+
+	// Once it ends, normal scale-down takes over.
+	processors.ScheduledCapacityProcessor.Refresh(&context, now.Add(2*time.Hour))
+	assert.Equal(t, 3, len(getPotentiallyUnneededNodes(&context, []*apiv1.Node{n1, n2, n3})))
+}
+
+func TestStaticAutoscalerRunOnceWithDynamicOptions(t *testing.T) {
+	now := time.Now()
+	onScaleUpMock := &onScaleUpMock{}
+	onScaleDownMock := &onScaleDownMock{}
+
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Now())
+	p1 := BuildTestPod("p1", 600, 100)
+	p1.Spec.NodeName = "n1"
+	p2 := BuildTestPod("p2", 600, 100)
+
+	provider := testprovider.NewTestCloudProvider(
+		func(id string, delta int) error {
+			return onScaleUpMock.ScaleUp(id, delta)
+		}, func(id string, name string) error {
+			return onScaleDownMock.ScaleDown(id, name)
+		})
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+	provider.AddNode("ng1", n1)
+
+	configMaps := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	setOptions := func(document, resourceVersion string) {
+		assert.NoError(t, configMaps.Update(&apiv1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            dynamicoptions.DynamicOptionsConfigMapName,
+				Namespace:       "kube-system",
+				ResourceVersion: resourceVersion,
+			},
+			Data: map[string]string{dynamicoptions.ConfigMapKey: document},
+		}))
+	}
+
+	options := config.AutoscalingOptions{
+		EstimatorName:                       estimator.BinpackingEstimatorName,
+		ScaleDownUtilizationThreshold:       0.5,
+		MaxNodesTotal:                       10,
+		MaxCoresTotal:                       10,
+		MaxMemoryTotal:                      100000,
+		FilterOutSchedulablePodsUsesPacking: true,
+		ConfigNamespace:                     "kube-system",
+	}
+	processorCallbacks := newStaticAutoscalerProcessorCallbacks()
+	context := NewScaleTestAutoscalingContext(options, &fake.Clientset{}, nil, provider, processorCallbacks)
+	// Every loop records events for the pending pod and the config map.
+	context.Recorder = kube_record.NewFakeRecorder(100)
+	processors := NewTestProcessors()
+	processors.DynamicOptionsProcessor = dynamicoptions.NewDynamicOptionsProcessor(
+		v1lister.NewConfigMapLister(configMaps).ConfigMaps("kube-system"), context.Recorder, nil)
+	context.CloudProvider = dynamicoptions.NewCloudProvider(provider, processors.DynamicOptionsProcessor)
+	context.ListerRegistry = kube_util.NewListerRegistry(
+		kube_util.NewTestNodeLister([]*apiv1.Node{n1}), kube_util.NewTestNodeLister([]*apiv1.Node{n1}),
+		kube_util.NewTestPodLister([]*apiv1.Pod{p1}), kube_util.NewTestPodLister([]*apiv1.Pod{p2}),
+		kube_util.NewTestPodDisruptionBudgetLister(nil), &daemonSetListerMock{},
+		nil, nil, nil, nil)
+	daemonSetLister := context.ListerRegistry.DaemonSetLister().(*daemonSetListerMock)
+	daemonSetLister.On("List", labels.Everything()).Return([]*appsv1.DaemonSet{}, nil)
+
+	clusterStateConfig := clusterstate.ClusterStateRegistryConfig{
+		OkTotalUnreadyCount:  1,
+		MaxNodeProvisionTime: 10 * time.Second,
+	}
+	clusterState := clusterstate.NewClusterStateRegistry(context.CloudProvider, clusterStateConfig, context.LogRecorder, newBackoff())
+	autoscaler := &StaticAutoscaler{
+		AutoscalingContext:    &context,
+		clusterStateRegistry:  clusterState,
+		lastScaleUpTime:       time.Now(),
+		lastScaleDownFailTime: time.Now(),
+		scaleDown:             NewScaleDown(&context, clusterState),
+		processors:            processors,
+		processorCallbacks:    processorCallbacks,
+		initialized:           true,
+	}
+	optionsCondition := func() api.ClusterAutoscalerCondition {
+		for _, condition := range clusterState.GetStatus(now).ClusterwideConditions {
+			if condition.Type == api.ClusterAutoscalerOptions {
+				return condition
+			}
+		}
+		return api.ClusterAutoscalerCondition{}
+	}
+
+	// The max size of ng1 is lowered, so p2 doesn't trigger a scale-up.
+	setOptions(`nodes: ["1:1:ng1"]`, "1")
+	err := autoscaler.RunOnce(now)
+	assert.NoError(t, err)
+	mock.AssertExpectationsForObjects(t, onScaleUpMock, onScaleDownMock)
+	assert.Equal(t, api.ClusterAutoscalerOptionsValid, optionsCondition().Status)
+
+	// An invalid version is rejected, the max size stays lowered.
+	setOptions(`nodes: ["1:many:ng1"]`, "2")
+	err = autoscaler.RunOnce(now.Add(time.Minute))
+	assert.NoError(t, err)
+	mock.AssertExpectationsForObjects(t, onScaleUpMock, onScaleDownMock)
+	assert.Equal(t, api.ClusterAutoscalerOptionsInvalid, optionsCondition().Status)
+
+	// Once the max size is raised, ng1 is scaled up.
+	setOptions(`nodes: ["1:3:ng1"]`, "3")
+	onScaleUpMock.On("ScaleUp", "ng1", 1).Return(nil).Once()
+	err = autoscaler.RunOnce(now.Add(2 * time.Minute))
+	assert.NoError(t, err)
+	mock.AssertExpectationsForObjects(t, onScaleUpMock, onScaleDownMock)
+	assert.Equal(t, api.ClusterAutoscalerOptionsValid, optionsCondition().Status)
+}
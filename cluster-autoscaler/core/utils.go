@@ -133,6 +133,17 @@ func filterOutExpendablePods(pods []*apiv1.Pod, expendablePodsPriorityCutoff int
 	return result
 }
 
+// filterOutPodsNotBoundToNode filters out pods which are not bound to the given node.
+func filterOutPodsNotBoundToNode(pods []*apiv1.Pod, nodeName string) []*apiv1.Pod {
+	var result []*apiv1.Pod
+	for _, pod := range pods {
+		if pod.Spec.NodeName == nodeName {
+			result = append(result, pod)
+		}
+	}
+	return result
+}
+
 // checkPodsSchedulableOnNode checks if pods can be scheduled on the given node.
 func checkPodsSchedulableOnNode(context *context.AutoscalingContext, pods []*apiv1.Pod, nodeGroupId string, nodeInfo *schedulernodeinfo.NodeInfo) map[*apiv1.Pod]*simulator.PredicateError {
 	schedulingErrors := map[*apiv1.Pod]*simulator.PredicateError{}
@@ -17,12 +17,13 @@ limitations under the License.
 package estimator
 
 import (
+	"fmt"
 	"sort"
 
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/autoscaler/cluster-autoscaler/simulator"
-	schedulerUtils "k8s.io/autoscaler/cluster-autoscaler/utils/scheduler"
+	"k8s.io/klog"
 	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
 )
 
@@ -35,12 +36,14 @@ type podInfo struct {
 // BinpackingNodeEstimator estimates the number of needed nodes to handle the given amount of pods.
 type BinpackingNodeEstimator struct {
 	predicateChecker *simulator.PredicateChecker
+	clusterSnapshot  simulator.ClusterSnapshot
 }
 
 // NewBinpackingNodeEstimator builds a new BinpackingNodeEstimator.
-func NewBinpackingNodeEstimator(predicateChecker *simulator.PredicateChecker) *BinpackingNodeEstimator {
+func NewBinpackingNodeEstimator(predicateChecker *simulator.PredicateChecker, clusterSnapshot simulator.ClusterSnapshot) *BinpackingNodeEstimator {
 	return &BinpackingNodeEstimator{
 		predicateChecker: predicateChecker,
+		clusterSnapshot:  clusterSnapshot,
 	}
 }
 
@@ -51,29 +54,78 @@ func NewBinpackingNodeEstimator(predicateChecker *simulator.PredicateChecker) *B
 // still be maintained.
 // It is assumed that all pods from the given list can fit to nodeTemplate.
 // Returns the number of nodes needed to accommodate all pods from the list.
+// Upcoming and new nodes are added to a fork of the cluster snapshot, which is reverted before returning.
 func (estimator *BinpackingNodeEstimator) Estimate(pods []*apiv1.Pod, nodeTemplate *schedulernodeinfo.NodeInfo,
 	upcomingNodes []*schedulernodeinfo.NodeInfo) int {
-
 	podInfos := calculatePodScore(pods, nodeTemplate)
 	sort.Slice(podInfos, func(i, j int) bool { return podInfos[i].score > podInfos[j].score })
 
-	newNodes := make([]*schedulernodeinfo.NodeInfo, 0)
-	newNodes = append(newNodes, upcomingNodes...)
+	if err := estimator.clusterSnapshot.Fork(); err != nil {
+		klog.Errorf("Failed to fork cluster snapshot: %v", err)
+		return 0
+	}
+	defer func() {
+		if err := estimator.clusterSnapshot.Revert(); err != nil {
+			klog.Errorf("Failed to revert cluster snapshot: %v", err)
+		}
+	}()
+
+	newNodeNames := make([]string, 0)
+	for _, upcomingNode := range upcomingNodes {
+		nodeName, err := estimator.addNodeToSnapshot(upcomingNode, len(newNodeNames))
+		if err != nil {
+			klog.Errorf("Failed to add upcoming node to cluster snapshot: %v", err)
+			return 0
+		}
+		newNodeNames = append(newNodeNames, nodeName)
+	}
 
 	for _, podInfo := range podInfos {
 		found := false
-		for i, nodeInfo := range newNodes {
+		for _, nodeName := range newNodeNames {
+			nodeInfo, _ := estimator.clusterSnapshot.GetNodeInfo(nodeName)
 			if err := estimator.predicateChecker.CheckPredicates(podInfo.pod, nil, nodeInfo); err == nil {
 				found = true
-				newNodes[i] = schedulerUtils.NodeWithPod(nodeInfo, podInfo.pod)
+				if err := estimator.clusterSnapshot.AddPod(podInfo.pod, nodeName); err != nil {
+					klog.Errorf("Failed to add pod %s/%s to cluster snapshot: %v", podInfo.pod.Namespace, podInfo.pod.Name, err)
+					return 0
+				}
 				break
 			}
 		}
 		if !found {
-			newNodes = append(newNodes, schedulerUtils.NodeWithPod(nodeTemplate, podInfo.pod))
+			nodeName, err := estimator.addNodeToSnapshot(nodeTemplate, len(newNodeNames))
+			if err != nil {
+				klog.Errorf("Failed to add new node to cluster snapshot: %v", err)
+				return 0
+			}
+			if err := estimator.clusterSnapshot.AddPod(podInfo.pod, nodeName); err != nil {
+				klog.Errorf("Failed to add pod %s/%s to cluster snapshot: %v", podInfo.pod.Namespace, podInfo.pod.Name, err)
+				return 0
+			}
+			newNodeNames = append(newNodeNames, nodeName)
+		}
+	}
+	return len(newNodeNames) - len(upcomingNodes)
+}
+
+// addNodeToSnapshot adds a copy of the template node, with its pods, to the cluster snapshot. The copy gets
+// a name unique among nodes added by the estimator, which is returned.
+func (estimator *BinpackingNodeEstimator) addNodeToSnapshot(template *schedulernodeinfo.NodeInfo, index int) (string, error) {
+	node := template.Node().DeepCopy()
+	node.Name = fmt.Sprintf("%s-estimator-%d", node.Name, index)
+	if _, found := node.Labels[apiv1.LabelHostname]; found {
+		node.Labels[apiv1.LabelHostname] = node.Name
+	}
+	if err := estimator.clusterSnapshot.AddNode(node); err != nil {
+		return "", err
+	}
+	for _, pod := range template.Pods() {
+		if err := estimator.clusterSnapshot.AddPod(pod, node.Name); err != nil {
+			return "", err
 		}
 	}
-	return len(newNodes) - len(upcomingNodes)
+	return node.Name, nil
 }
 
 // Calculates score for all pods and returns podInfo structure.
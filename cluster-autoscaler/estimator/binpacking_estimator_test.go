@@ -31,7 +31,8 @@ import (
 )
 
 func TestBinpackingEstimate(t *testing.T) {
-	estimator := NewBinpackingNodeEstimator(simulator.NewTestPredicateChecker())
+	clusterSnapshot := simulator.NewBasicClusterSnapshot()
+	estimator := NewBinpackingNodeEstimator(simulator.NewTestPredicateChecker(), clusterSnapshot)
 
 	cpuPerPod := int64(350)
 	memoryPerPod := int64(1000 * units.MiB)
@@ -60,7 +61,8 @@ func TestBinpackingEstimate(t *testing.T) {
 }
 
 func TestBinpackingEstimateComingNodes(t *testing.T) {
-	estimator := NewBinpackingNodeEstimator(simulator.NewTestPredicateChecker())
+	clusterSnapshot := simulator.NewBasicClusterSnapshot()
+	estimator := NewBinpackingNodeEstimator(simulator.NewTestPredicateChecker(), clusterSnapshot)
 
 	cpuPerPod := int64(350)
 	memoryPerPod := int64(1000 * units.MiB)
@@ -87,10 +89,14 @@ func TestBinpackingEstimateComingNodes(t *testing.T) {
 	estimate := estimator.Estimate(pods, nodeInfo, []*schedulernodeinfo.NodeInfo{nodeInfo, nodeInfo})
 	// 5 - 2 nodes that are coming.
 	assert.Equal(t, 3, estimate)
+	// Nodes are added to a fork of the snapshot only.
+	assert.Empty(t, clusterSnapshot.NodeInfos())
+	assert.Error(t, clusterSnapshot.Revert())
 }
 
 func TestBinpackingEstimateWithPorts(t *testing.T) {
-	estimator := NewBinpackingNodeEstimator(simulator.NewTestPredicateChecker())
+	clusterSnapshot := simulator.NewBasicClusterSnapshot()
+	estimator := NewBinpackingNodeEstimator(simulator.NewTestPredicateChecker(), clusterSnapshot)
 
 	cpuPerPod := int64(200)
 	memoryPerPod := int64(1000 * units.MiB)
@@ -45,20 +45,20 @@ type Estimator interface {
 }
 
 // EstimatorBuilder creates a new estimator object.
-type EstimatorBuilder func(*simulator.PredicateChecker) Estimator
+type EstimatorBuilder func(*simulator.PredicateChecker, simulator.ClusterSnapshot) Estimator
 
 // NewEstimatorBuilder creates a new estimator object from flag.
 func NewEstimatorBuilder(name string) (EstimatorBuilder, error) {
 	switch name {
 	case BinpackingEstimatorName:
-		return func(predicateChecker *simulator.PredicateChecker) Estimator {
-			return NewBinpackingNodeEstimator(predicateChecker)
+		return func(predicateChecker *simulator.PredicateChecker, clusterSnapshot simulator.ClusterSnapshot) Estimator {
+			return NewBinpackingNodeEstimator(predicateChecker, clusterSnapshot)
 		}, nil
 	// Deprecated.
 	// TODO(aleksandra-malinowska): remove in 1.5.
 	case BasicEstimatorName:
 		klog.Warning(basicEstimatorDeprecationMessage)
-		return func(_ *simulator.PredicateChecker) Estimator {
+		return func(_ *simulator.PredicateChecker, _ simulator.ClusterSnapshot) Estimator {
 			return NewBasicNodeEstimator()
 		}, nil
 	}
@@ -0,0 +1,71 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+// chainStrategy applies a list of strategies one after another. Every strategy
+// but the last one filters the options down to its best set, and the next one
+// only breaks ties among them. The last strategy picks the final option.
+type chainStrategy struct {
+	filters  []expander.Strategy
+	selector expander.Strategy
+}
+
+func newChainStrategy(strategies []expander.Strategy) expander.Strategy {
+	return &chainStrategy{
+		filters:  strategies[:len(strategies)-1],
+		selector: strategies[len(strategies)-1],
+	}
+}
+
+// BestOption filters the options with all the strategies in the chain and lets the
+// last one pick the best option among the remaining ones.
+func (c *chainStrategy) BestOption(options []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) *expander.Option {
+	filteredOptions := c.filter(options, nodeInfo)
+	if len(filteredOptions) == 0 {
+		return nil
+	}
+	if len(filteredOptions) == 1 {
+		return &filteredOptions[0]
+	}
+	return c.selector.BestOption(filteredOptions, nodeInfo)
+}
+
+// BestOptions filters the options with all the strategies in the chain.
+func (c *chainStrategy) BestOptions(options []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) []expander.Option {
+	filteredOptions := c.filter(options, nodeInfo)
+	if len(filteredOptions) <= 1 {
+		return filteredOptions
+	}
+	return c.selector.BestOptions(filteredOptions, nodeInfo)
+}
+
+func (c *chainStrategy) filter(options []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) []expander.Option {
+	filteredOptions := options
+	for _, filter := range c.filters {
+		if len(filteredOptions) <= 1 {
+			break
+		}
+		filteredOptions = filter.BestOptions(filteredOptions, nodeInfo)
+	}
+	return filteredOptions
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package factory
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/priority"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	"k8s.io/client-go/tools/record"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+// prefixStrategy keeps the options with Debug starting with the given prefix
+// and picks the first of them.
+type prefixStrategy struct {
+	prefix string
+	calls  int
+}
+
+func (p *prefixStrategy) BestOption(options []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) *expander.Option {
+	best := p.BestOptions(options, nodeInfo)
+	if len(best) == 0 {
+		return nil
+	}
+	return &best[0]
+}
+
+func (p *prefixStrategy) BestOptions(options []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) []expander.Option {
+	p.calls++
+	var best []expander.Option
+	for _, option := range options {
+		if strings.HasPrefix(option.Debug, p.prefix) {
+			best = append(best, option)
+		}
+	}
+	return best
+}
+
+func TestChainStrategy(t *testing.T) {
+	a1 := expander.Option{Debug: "a1"}
+	a2 := expander.Option{Debug: "a2"}
+	a21 := expander.Option{Debug: "a21"}
+	a22 := expander.Option{Debug: "a22"}
+	b1 := expander.Option{Debug: "b1"}
+
+	testCases := []struct {
+		name          string
+		prefixes      []string
+		options       []expander.Option
+		expectedBest  *expander.Option
+		expectedOpts  []expander.Option
+		expectedCalls []int
+	}{
+		{
+			name:          "each strategy breaks ties of the previous one",
+			prefixes:      []string{"a", "a2", "a22"},
+			options:       []expander.Option{a1, a2, a21, a22, b1},
+			expectedBest:  &a22,
+			expectedOpts:  []expander.Option{a22},
+			expectedCalls: []int{1, 1, 1},
+		},
+		{
+			name:          "strategies are skipped once a single option is left",
+			prefixes:      []string{"b", "a", "a2"},
+			options:       []expander.Option{a1, a2, b1},
+			expectedBest:  &b1,
+			expectedOpts:  []expander.Option{b1},
+			expectedCalls: []int{1, 0, 0},
+		},
+		{
+			name:          "last strategy picks among remaining ties",
+			prefixes:      []string{"a2", "a"},
+			options:       []expander.Option{a1, a21, a22, b1},
+			expectedBest:  &a21,
+			expectedOpts:  []expander.Option{a21, a22},
+			expectedCalls: []int{1, 1},
+		},
+		{
+			name:          "no option left",
+			prefixes:      []string{"c", "a"},
+			options:       []expander.Option{a1, b1},
+			expectedBest:  nil,
+			expectedOpts:  nil,
+			expectedCalls: []int{1, 0},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			strategies := []expander.Strategy{}
+			prefixStrategies := []*prefixStrategy{}
+			for _, prefix := range tc.prefixes {
+				s := &prefixStrategy{prefix: prefix}
+				prefixStrategies = append(prefixStrategies, s)
+				strategies = append(strategies, s)
+			}
+			chain := newChainStrategy(strategies)
+
+			assert.Equal(t, tc.expectedBest, chain.BestOption(tc.options, nil))
+			for i, s := range prefixStrategies {
+				assert.Equal(t, tc.expectedCalls[i], s.calls, "calls of strategy %d", i)
+				s.calls = 0
+			}
+			assert.Equal(t, tc.expectedOpts, chain.BestOptions(tc.options, nil))
+		})
+	}
+}
+
+func TestChainStrategyWithoutPriorityConfigMap(t *testing.T) {
+	a1 := expander.Option{Debug: "a1"}
+	a2 := expander.Option{Debug: "a2"}
+	lister, err := kubernetes.NewTestConfigMapLister(nil)
+	assert.NoError(t, err)
+	priorityStrategy, perr := priority.NewStrategy(lister.ConfigMaps("kube-system"), record.NewFakeRecorder(10))
+	assert.NoError(t, perr)
+
+	// Without the priority config map, the next expander chooses among all options.
+	chain := newChainStrategy([]expander.Strategy{priorityStrategy, &prefixStrategy{prefix: "a2"}})
+	assert.Equal(t, &a2, chain.BestOption([]expander.Option{a1, a2}, nil))
+}
+
+func TestExpanderStrategyFromStringChain(t *testing.T) {
+	strategy, err := ExpanderStrategyFromString("most-pods,least-waste,random", nil, nil, nil, nil, "", "", "", 0, "")
+	assert.NoError(t, err)
+	chain, ok := strategy.(*chainStrategy)
+	assert.True(t, ok)
+	assert.Equal(t, 2, len(chain.filters))
+
+	strategy, err = ExpanderStrategyFromString("random", nil, nil, nil, nil, "", "", "", 0, "")
+	assert.NoError(t, err)
+	_, ok = strategy.(*chainStrategy)
+	assert.False(t, ok)
+
+	_, err = ExpanderStrategyFromString("random,random", nil, nil, nil, nil, "", "", "", 0, "")
+	assert.Error(t, err)
+
+	_, err = ExpanderStrategyFromString("most-pods,unknown", nil, nil, nil, nil, "", "", "", 0, "")
+	assert.Error(t, err)
+
+	_, err = ExpanderStrategyFromString("grpc", nil, nil, nil, nil, "", "", "localhost:1234", 0, "most-pods,grpc")
+	assert.Error(t, err)
+
+	strategy, err = ExpanderStrategyFromString("grpc", nil, nil, nil, nil, "", "", "localhost:1234", time.Second, "most-pods")
+	assert.NoError(t, err)
+	assert.NotNil(t, strategy)
+
+	// Bad gRPC expander configuration fails instead of silently using the fallback.
+	_, err = ExpanderStrategyFromString("grpc", nil, nil, nil, nil, "", "", "", time.Second, "most-pods")
+	assert.Error(t, err)
+	_, err = ExpanderStrategyFromString("grpc", nil, nil, nil, nil, "", "/nonexistent/ca.crt", "localhost:1234", time.Second, "most-pods")
+	assert.Error(t, err)
+}
@@ -17,9 +17,13 @@ limitations under the License.
 package factory
 
 import (
+	"strings"
+	"time"
+
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
 	"k8s.io/autoscaler/cluster-autoscaler/context"
 	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/grpcplugin"
 	"k8s.io/autoscaler/cluster-autoscaler/expander/mostpods"
 	"k8s.io/autoscaler/cluster-autoscaler/expander/price"
 	"k8s.io/autoscaler/cluster-autoscaler/expander/priority"
@@ -31,11 +35,41 @@ import (
 	kube_client "k8s.io/client-go/kubernetes"
 )
 
-// ExpanderStrategyFromString creates an expander.Strategy according to its name
+// ExpanderStrategyFromString creates an expander.Strategy according to its name. The name can also be
+// a comma-separated list of expanders, in which case each of them filters the options down to its best
+// set and the next one breaks ties among them. Listers started by the strategy are stopped when
+// stopChannel is closed.
 func ExpanderStrategyFromString(expanderFlag string, cloudProvider cloudprovider.CloudProvider,
-	autoscalingKubeClients *context.AutoscalingKubeClients, kubeClient kube_client.Interface,
-	configNamespace string) (expander.Strategy, errors.AutoscalerError) {
-	switch expanderFlag {
+	autoscalingKubeClients *context.AutoscalingKubeClients, kubeClient kube_client.Interface, stopChannel <-chan struct{},
+	configNamespace string, grpcExpanderCert string, grpcExpanderURL string, grpcExpanderTimeout time.Duration,
+	grpcExpanderFallback string) (expander.Strategy, errors.AutoscalerError) {
+	expanderNames := strings.Split(expanderFlag, ",")
+	seenExpanders := map[string]struct{}{}
+	strategies := []expander.Strategy{}
+	for _, expanderName := range expanderNames {
+		if _, ok := seenExpanders[expanderName]; ok {
+			return nil, errors.NewAutoscalerError(errors.InternalError, "Expander %s was specified multiple times, each expander must not be specified more than once", expanderName)
+		}
+		seenExpanders[expanderName] = struct{}{}
+
+		strategy, err := expanderStrategyFromName(expanderName, cloudProvider, autoscalingKubeClients, kubeClient,
+			stopChannel, configNamespace, grpcExpanderCert, grpcExpanderURL, grpcExpanderTimeout, grpcExpanderFallback)
+		if err != nil {
+			return nil, err
+		}
+		strategies = append(strategies, strategy)
+	}
+	if len(strategies) == 1 {
+		return strategies[0], nil
+	}
+	return newChainStrategy(strategies), nil
+}
+
+func expanderStrategyFromName(expanderName string, cloudProvider cloudprovider.CloudProvider,
+	autoscalingKubeClients *context.AutoscalingKubeClients, kubeClient kube_client.Interface, stopChannel <-chan struct{},
+	configNamespace string, grpcExpanderCert string, grpcExpanderURL string, grpcExpanderTimeout time.Duration,
+	grpcExpanderFallback string) (expander.Strategy, errors.AutoscalerError) {
+	switch expanderName {
 	case expander.RandomExpanderName:
 		return random.NewStrategy(), nil
 	case expander.MostPodsExpanderName:
@@ -50,11 +84,24 @@ func ExpanderStrategyFromString(expanderFlag string, cloudProvider cloudprovider
 			price.NewSimplePreferredNodeProvider(autoscalingKubeClients.AllNodeLister()),
 			price.SimpleNodeUnfitness), nil
 	case expander.PriorityBasedExpanderName:
-		// It seems other listers do the same here - they never receive the termination msg on the ch.
-		// This should be currently OK.
-		stopChannel := make(chan struct{})
 		lister := kubernetes.NewConfigMapListerForNamespace(kubeClient, stopChannel, configNamespace)
 		return priority.NewStrategy(lister.ConfigMaps(configNamespace), autoscalingKubeClients.Recorder)
+	case expander.GRPCExpanderName:
+		for _, fallbackName := range strings.Split(grpcExpanderFallback, ",") {
+			if fallbackName == expander.GRPCExpanderName {
+				return nil, errors.NewAutoscalerError(errors.InternalError, "Expander %s can't be used as its own fallback", expanderName)
+			}
+		}
+		fallbackStrategy, err := ExpanderStrategyFromString(grpcExpanderFallback, cloudProvider, autoscalingKubeClients,
+			kubeClient, stopChannel, configNamespace, grpcExpanderCert, grpcExpanderURL, grpcExpanderTimeout, grpcExpanderFallback)
+		if err != nil {
+			return nil, err
+		}
+		strategy, grpcErr := grpcplugin.NewStrategy(grpcExpanderURL, grpcExpanderCert, grpcExpanderTimeout, fallbackStrategy)
+		if grpcErr != nil {
+			return nil, errors.ToAutoscalerError(errors.InternalError, grpcErr)
+		}
+		return strategy, nil
 	}
-	return nil, errors.NewAutoscalerError(errors.InternalError, "Expander %s not supported", expanderFlag)
+	return nil, errors.NewAutoscalerError(errors.InternalError, "Expander %s not supported", expanderName)
 }
@@ -0,0 +1,175 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcplugin
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/grpcplugin/protos"
+	"k8s.io/klog"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+type grpcclientstrategy struct {
+	grpcClient       protos.ExpanderClient
+	timeout          time.Duration
+	fallbackStrategy expander.Strategy
+}
+
+// NewStrategy returns an expansion strategy that asks an external gRPC service
+// for the best option. The fallback strategy is used whenever a call to the
+// service fails or doesn't return a valid answer. An error is returned if the
+// client can't be configured, e.g. the url is empty or the certificate can't be read.
+func NewStrategy(url string, certPath string, timeout time.Duration, fallbackStrategy expander.Strategy) (expander.Strategy, error) {
+	if url == "" {
+		return nil, fmt.Errorf("gRPC expander url is not set")
+	}
+	client, err := createGRPCClient(url, certPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC expander client for %s: %v", url, err)
+	}
+	return newStrategyWithClient(client, timeout, fallbackStrategy), nil
+}
+
+func newStrategyWithClient(client protos.ExpanderClient, timeout time.Duration, fallbackStrategy expander.Strategy) expander.Strategy {
+	return &grpcclientstrategy{
+		grpcClient:       client,
+		timeout:          timeout,
+		fallbackStrategy: fallbackStrategy,
+	}
+}
+
+func createGRPCClient(url string, certPath string) (protos.ExpanderClient, error) {
+	var dialOpt grpc.DialOption
+	if certPath == "" {
+		klog.Warning("No certificate provided for the gRPC expander, the connection will be insecure")
+		dialOpt = grpc.WithInsecure()
+	} else {
+		creds, err := credentials.NewClientTLSFromFile(certPath, "")
+		if err != nil {
+			return nil, err
+		}
+		dialOpt = grpc.WithTransportCredentials(creds)
+	}
+	conn, err := grpc.Dial(url, dialOpt)
+	if err != nil {
+		return nil, err
+	}
+	return protos.NewExpanderClient(conn), nil
+}
+
+// BestOption asks the external service for the best option, using the fallback strategy on errors.
+func (g *grpcclientstrategy) BestOption(expansionOptions []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) *expander.Option {
+	bestOptions, ok := g.bestOptions(expansionOptions, nodeInfo)
+	if !ok {
+		return g.fallbackStrategy.BestOption(expansionOptions, nodeInfo)
+	}
+	if len(bestOptions) == 0 {
+		return nil
+	}
+	return &bestOptions[0]
+}
+
+// BestOptions asks the external service for the best options, using the fallback strategy on errors.
+func (g *grpcclientstrategy) BestOptions(expansionOptions []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) []expander.Option {
+	bestOptions, ok := g.bestOptions(expansionOptions, nodeInfo)
+	if !ok {
+		return g.fallbackStrategy.BestOptions(expansionOptions, nodeInfo)
+	}
+	return bestOptions
+}
+
+// bestOptions returns the options selected by the external service, in the order
+// returned by it. The second result is false if the fallback strategy should be used.
+func (g *grpcclientstrategy) bestOptions(expansionOptions []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) ([]expander.Option, bool) {
+	if len(expansionOptions) <= 0 {
+		return nil, true
+	}
+
+	request, err := buildRequest(expansionOptions, nodeInfo)
+	if err != nil {
+		klog.Errorf("Failed to build gRPC expander request, using fallback expander: %v", err)
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+	response, err := g.grpcClient.BestOptions(ctx, request)
+	if err != nil {
+		klog.Errorf("gRPC call to expander failed, using fallback expander: %v", err)
+		return nil, false
+	}
+
+	var bestOptions []expander.Option
+	for _, pbOption := range response.GetOptions() {
+		found := false
+		for _, option := range expansionOptions {
+			if option.NodeGroup.Id() == pbOption.GetNodeGroupId() {
+				bestOptions = append(bestOptions, option)
+				found = true
+				break
+			}
+		}
+		if !found {
+			klog.Warningf("gRPC expander returned unknown node group %s", pbOption.GetNodeGroupId())
+		}
+	}
+	if len(bestOptions) == 0 {
+		klog.Errorf("gRPC expander returned no valid option, using fallback expander")
+		return nil, false
+	}
+	return bestOptions, true
+}
+
+func buildRequest(expansionOptions []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) (*protos.BestOptionsRequest, error) {
+	pbOptions := make([]*protos.Option, 0, len(expansionOptions))
+	for _, option := range expansionOptions {
+		pbPods := make([][]byte, 0, len(option.Pods))
+		for _, pod := range option.Pods {
+			pbPod, err := pod.Marshal()
+			if err != nil {
+				return nil, err
+			}
+			pbPods = append(pbPods, pbPod)
+		}
+		pbOptions = append(pbOptions, &protos.Option{
+			NodeGroupId: option.NodeGroup.Id(),
+			NodeCount:   int32(option.NodeCount),
+			Debug:       option.Debug,
+			Pod:         pbPods,
+		})
+	}
+
+	pbNodeMap := make(map[string][]byte, len(nodeInfo))
+	for nodeGroupId, info := range nodeInfo {
+		if info == nil || info.Node() == nil {
+			continue
+		}
+		pbNode, err := info.Node().Marshal()
+		if err != nil {
+			return nil, err
+		}
+		pbNodeMap[nodeGroupId] = pbNode
+	}
+	return &protos.BestOptionsRequest{Options: pbOptions, NodeMap: pbNodeMap}, nil
+}
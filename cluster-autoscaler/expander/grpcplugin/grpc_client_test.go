@@ -0,0 +1,216 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcplugin
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	"k8s.io/autoscaler/cluster-autoscaler/expander/grpcplugin/protos"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+// fakeExpanderServer is called from the gRPC server goroutines, so the fields changed
+// by requests or by tests are guarded by lock.
+type fakeExpanderServer struct {
+	response *protos.BestOptionsResponse
+	delay    time.Duration
+
+	lock    sync.Mutex
+	request *protos.BestOptionsRequest
+	err     error
+}
+
+func (f *fakeExpanderServer) BestOptions(_ context.Context, req *protos.BestOptionsRequest) (*protos.BestOptionsResponse, error) {
+	f.lock.Lock()
+	f.request = req
+	err := f.err
+	f.lock.Unlock()
+	time.Sleep(f.delay)
+	return f.response, err
+}
+
+func (f *fakeExpanderServer) lastRequest() *protos.BestOptionsRequest {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.request
+}
+
+func (f *fakeExpanderServer) setErr(err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.err = err
+}
+
+type firstOptionStrategy struct {
+	calls int
+}
+
+func (f *firstOptionStrategy) BestOption(options []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) *expander.Option {
+	f.calls++
+	return &options[0]
+}
+
+func (f *firstOptionStrategy) BestOptions(options []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) []expander.Option {
+	f.calls++
+	return options[:1]
+}
+
+func startFakeExpanderServer(t *testing.T, server *fakeExpanderServer) (protos.ExpanderClient, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	protos.RegisterExpanderServer(grpcServer, server)
+	go grpcServer.Serve(listener)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	assert.NoError(t, err)
+	return protos.NewExpanderClient(conn), func() {
+		conn.Close()
+		grpcServer.Stop()
+	}
+}
+
+func TestGRPCBestOption(t *testing.T) {
+	provider := test.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+	provider.AddNodeGroup("ng2", 1, 10, 1)
+	ng1 := provider.GetNodeGroup("ng1")
+	ng2 := provider.GetNodeGroup("ng2")
+
+	p1 := BuildTestPod("p1", 100, 100)
+	options := []expander.Option{
+		{NodeGroup: ng1, NodeCount: 1, Debug: "ng1", Pods: []*apiv1.Pod{p1}},
+		{NodeGroup: ng2, NodeCount: 2, Debug: "ng2", Pods: []*apiv1.Pod{p1}},
+	}
+	ni1 := schedulernodeinfo.NewNodeInfo()
+	ni1.SetNode(BuildTestNode("ng1-template", 1000, 1000))
+	ni2 := schedulernodeinfo.NewNodeInfo()
+	ni2.SetNode(BuildTestNode("ng2-template", 2000, 2000))
+	nodeInfo := map[string]*schedulernodeinfo.NodeInfo{"ng1": ni1, "ng2": ni2}
+
+	testCases := []struct {
+		name             string
+		response         *protos.BestOptionsResponse
+		err              error
+		delay            time.Duration
+		expectedOption   string
+		expectedFallback bool
+	}{
+		{
+			name:           "server picks an option",
+			response:       &protos.BestOptionsResponse{Options: []*protos.Option{{NodeGroupId: "ng2"}}},
+			expectedOption: "ng2",
+		},
+		{
+			name:           "unknown options are skipped",
+			response:       &protos.BestOptionsResponse{Options: []*protos.Option{{NodeGroupId: "ng3"}, {NodeGroupId: "ng2"}}},
+			expectedOption: "ng2",
+		},
+		{
+			name:             "no valid option returned",
+			response:         &protos.BestOptionsResponse{Options: []*protos.Option{{NodeGroupId: "ng3"}}},
+			expectedOption:   "ng1",
+			expectedFallback: true,
+		},
+		{
+			name:             "server error",
+			err:              fmt.Errorf("server error"),
+			expectedOption:   "ng1",
+			expectedFallback: true,
+		},
+		{
+			name:             "timeout",
+			response:         &protos.BestOptionsResponse{Options: []*protos.Option{{NodeGroupId: "ng2"}}},
+			delay:            time.Second,
+			expectedOption:   "ng1",
+			expectedFallback: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := &fakeExpanderServer{response: tc.response, err: tc.err, delay: tc.delay}
+			client, stop := startFakeExpanderServer(t, server)
+			defer stop()
+
+			fallback := &firstOptionStrategy{}
+			strategy := newStrategyWithClient(client, 100*time.Millisecond, fallback)
+			best := strategy.BestOption(options, nodeInfo)
+			assert.Equal(t, tc.expectedOption, best.NodeGroup.Id())
+			assert.Equal(t, tc.expectedFallback, fallback.calls == 1)
+
+			request := server.lastRequest()
+			assert.Equal(t, 2, len(request.GetOptions()))
+			assert.Equal(t, "ng2", request.GetOptions()[1].GetNodeGroupId())
+			assert.Equal(t, int32(2), request.GetOptions()[1].GetNodeCount())
+			assert.Equal(t, 1, len(request.GetOptions()[1].GetPod()))
+			pod := &apiv1.Pod{}
+			assert.NoError(t, pod.Unmarshal(request.GetOptions()[1].GetPod()[0]))
+			assert.Equal(t, "p1", pod.Name)
+			node := &apiv1.Node{}
+			assert.NoError(t, node.Unmarshal(request.GetNodeMap()["ng2"]))
+			assert.Equal(t, "ng2-template", node.Name)
+		})
+	}
+}
+
+func TestGRPCBestOptionNoOptions(t *testing.T) {
+	fallback := &firstOptionStrategy{}
+	strategy := newStrategyWithClient(nil, time.Second, fallback)
+	assert.Nil(t, strategy.BestOption(nil, nil))
+	assert.Equal(t, 0, fallback.calls)
+}
+
+func TestGRPCBestOptions(t *testing.T) {
+	provider := test.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+	provider.AddNodeGroup("ng2", 1, 10, 1)
+	provider.AddNodeGroup("ng3", 1, 10, 1)
+	options := []expander.Option{
+		{NodeGroup: provider.GetNodeGroup("ng1"), NodeCount: 1},
+		{NodeGroup: provider.GetNodeGroup("ng2"), NodeCount: 1},
+		{NodeGroup: provider.GetNodeGroup("ng3"), NodeCount: 1},
+	}
+
+	server := &fakeExpanderServer{response: &protos.BestOptionsResponse{
+		Options: []*protos.Option{{NodeGroupId: "ng3"}, {NodeGroupId: "ng4"}, {NodeGroupId: "ng1"}},
+	}}
+	client, stop := startFakeExpanderServer(t, server)
+	defer stop()
+
+	fallback := &firstOptionStrategy{}
+	strategy := newStrategyWithClient(client, time.Second, fallback)
+	best := strategy.BestOptions(options, nil)
+	assert.Equal(t, []expander.Option{options[2], options[0]}, best)
+	assert.Equal(t, 0, fallback.calls)
+
+	server.setErr(fmt.Errorf("server error"))
+	best = strategy.BestOptions(options, nil)
+	assert.Equal(t, []expander.Option{options[0]}, best)
+	assert.Equal(t, 1, fallback.calls)
+}
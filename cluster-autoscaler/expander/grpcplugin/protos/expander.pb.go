@@ -0,0 +1,302 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: expander.proto
+
+package protos
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type BestOptionsRequest struct {
+	// Options cluster autoscaler is choosing from.
+	Options []*Option `protobuf:"bytes,1,rep,name=options" json:"options,omitempty"`
+	// Templates of the nodes that would be created in every node group, keyed
+	// by node group id. Values hold the Kubernetes protobuf encoding of
+	// k8s.io.api.core.v1.Node.
+	NodeMap              map[string][]byte `protobuf:"bytes,2,rep,name=nodeMap" json:"nodeMap,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *BestOptionsRequest) Reset()         { *m = BestOptionsRequest{} }
+func (m *BestOptionsRequest) String() string { return proto.CompactTextString(m) }
+func (*BestOptionsRequest) ProtoMessage()    {}
+func (*BestOptionsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_expander_50a3ad6a67c23d6e, []int{0}
+}
+func (m *BestOptionsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BestOptionsRequest.Unmarshal(m, b)
+}
+func (m *BestOptionsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BestOptionsRequest.Marshal(b, m, deterministic)
+}
+func (dst *BestOptionsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BestOptionsRequest.Merge(dst, src)
+}
+func (m *BestOptionsRequest) XXX_Size() int {
+	return xxx_messageInfo_BestOptionsRequest.Size(m)
+}
+func (m *BestOptionsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BestOptionsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BestOptionsRequest proto.InternalMessageInfo
+
+func (m *BestOptionsRequest) GetOptions() []*Option {
+	if m != nil {
+		return m.Options
+	}
+	return nil
+}
+
+func (m *BestOptionsRequest) GetNodeMap() map[string][]byte {
+	if m != nil {
+		return m.NodeMap
+	}
+	return nil
+}
+
+type BestOptionsResponse struct {
+	// Best options, ordered from the most preferred one. Only options with
+	// node group ids from the request are taken into account.
+	Options              []*Option `protobuf:"bytes,1,rep,name=options" json:"options,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *BestOptionsResponse) Reset()         { *m = BestOptionsResponse{} }
+func (m *BestOptionsResponse) String() string { return proto.CompactTextString(m) }
+func (*BestOptionsResponse) ProtoMessage()    {}
+func (*BestOptionsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_expander_50a3ad6a67c23d6e, []int{1}
+}
+func (m *BestOptionsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BestOptionsResponse.Unmarshal(m, b)
+}
+func (m *BestOptionsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BestOptionsResponse.Marshal(b, m, deterministic)
+}
+func (dst *BestOptionsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BestOptionsResponse.Merge(dst, src)
+}
+func (m *BestOptionsResponse) XXX_Size() int {
+	return xxx_messageInfo_BestOptionsResponse.Size(m)
+}
+func (m *BestOptionsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_BestOptionsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BestOptionsResponse proto.InternalMessageInfo
+
+func (m *BestOptionsResponse) GetOptions() []*Option {
+	if m != nil {
+		return m.Options
+	}
+	return nil
+}
+
+type Option struct {
+	// ID of the node group to expand.
+	NodeGroupId string `protobuf:"bytes,1,opt,name=nodeGroupId" json:"nodeGroupId,omitempty"`
+	// Number of nodes to add to the node group.
+	NodeCount int32 `protobuf:"varint,2,opt,name=nodeCount" json:"nodeCount,omitempty"`
+	// Debug information about the option.
+	Debug string `protobuf:"bytes,3,opt,name=debug" json:"debug,omitempty"`
+	// Pods that would be scheduled on the new nodes. Every entry holds the
+	// Kubernetes protobuf encoding of k8s.io.api.core.v1.Pod.
+	Pod                  [][]byte `protobuf:"bytes,4,rep,name=pod,proto3" json:"pod,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Option) Reset()         { *m = Option{} }
+func (m *Option) String() string { return proto.CompactTextString(m) }
+func (*Option) ProtoMessage()    {}
+func (*Option) Descriptor() ([]byte, []int) {
+	return fileDescriptor_expander_50a3ad6a67c23d6e, []int{2}
+}
+func (m *Option) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Option.Unmarshal(m, b)
+}
+func (m *Option) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Option.Marshal(b, m, deterministic)
+}
+func (dst *Option) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Option.Merge(dst, src)
+}
+func (m *Option) XXX_Size() int {
+	return xxx_messageInfo_Option.Size(m)
+}
+func (m *Option) XXX_DiscardUnknown() {
+	xxx_messageInfo_Option.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Option proto.InternalMessageInfo
+
+func (m *Option) GetNodeGroupId() string {
+	if m != nil {
+		return m.NodeGroupId
+	}
+	return ""
+}
+
+func (m *Option) GetNodeCount() int32 {
+	if m != nil {
+		return m.NodeCount
+	}
+	return 0
+}
+
+func (m *Option) GetDebug() string {
+	if m != nil {
+		return m.Debug
+	}
+	return ""
+}
+
+func (m *Option) GetPod() [][]byte {
+	if m != nil {
+		return m.Pod
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*BestOptionsRequest)(nil), "grpcplugin.BestOptionsRequest")
+	proto.RegisterMapType((map[string][]byte)(nil), "grpcplugin.BestOptionsRequest.NodeMapEntry")
+	proto.RegisterType((*BestOptionsResponse)(nil), "grpcplugin.BestOptionsResponse")
+	proto.RegisterType((*Option)(nil), "grpcplugin.Option")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for Expander service
+
+type ExpanderClient interface {
+	// BestOptions returns the best expansion options out of the given ones.
+	BestOptions(ctx context.Context, in *BestOptionsRequest, opts ...grpc.CallOption) (*BestOptionsResponse, error)
+}
+
+type expanderClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewExpanderClient(cc *grpc.ClientConn) ExpanderClient {
+	return &expanderClient{cc}
+}
+
+func (c *expanderClient) BestOptions(ctx context.Context, in *BestOptionsRequest, opts ...grpc.CallOption) (*BestOptionsResponse, error) {
+	out := new(BestOptionsResponse)
+	err := grpc.Invoke(ctx, "/grpcplugin.Expander/BestOptions", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Expander service
+
+type ExpanderServer interface {
+	// BestOptions returns the best expansion options out of the given ones.
+	BestOptions(context.Context, *BestOptionsRequest) (*BestOptionsResponse, error)
+}
+
+func RegisterExpanderServer(s *grpc.Server, srv ExpanderServer) {
+	s.RegisterService(&_Expander_serviceDesc, srv)
+}
+
+func _Expander_BestOptions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BestOptionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExpanderServer).BestOptions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcplugin.Expander/BestOptions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExpanderServer).BestOptions(ctx, req.(*BestOptionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Expander_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "grpcplugin.Expander",
+	HandlerType: (*ExpanderServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "BestOptions",
+			Handler:    _Expander_BestOptions_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "expander.proto",
+}
+
+func init() { proto.RegisterFile("expander.proto", fileDescriptor_expander_50a3ad6a67c23d6e) }
+
+var fileDescriptor_expander_50a3ad6a67c23d6e = []byte{
+	// 285 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x51, 0x4d, 0x4b, 0xc4, 0x30,
+	0x10, 0x35, 0xad, 0xfb, 0x35, 0x2d, 0x22, 0xa3, 0x87, 0xb0, 0x88, 0x96, 0x9e, 0x0a, 0x4a, 0x0f,
+	0xeb, 0x45, 0xf6, 0xb8, 0x4b, 0x11, 0x0f, 0x7e, 0x90, 0xa3, 0x78, 0xe9, 0xda, 0x50, 0x16, 0x97,
+	0x24, 0x36, 0x89, 0xb8, 0x3f, 0xd0, 0xff, 0x25, 0x69, 0x76, 0xd9, 0x8a, 0x28, 0xec, 0x29, 0x33,
+	0x6f, 0xde, 0x4b, 0xde, 0xcb, 0xc0, 0x11, 0xff, 0x54, 0xa5, 0xa8, 0x78, 0x93, 0xab, 0x46, 0x1a,
+	0x89, 0x50, 0x37, 0xea, 0x55, 0xad, 0x6c, 0xbd, 0x14, 0xe9, 0x17, 0x01, 0x9c, 0x71, 0x6d, 0x1e,
+	0x95, 0x59, 0x4a, 0xa1, 0x19, 0x7f, 0xb7, 0x5c, 0x1b, 0xbc, 0x82, 0x81, 0xf4, 0x08, 0x25, 0x49,
+	0x98, 0x45, 0x13, 0xcc, 0x77, 0xa2, 0xdc, 0x93, 0xd9, 0x96, 0x82, 0x05, 0x0c, 0x84, 0xac, 0xf8,
+	0x7d, 0xa9, 0x68, 0xd0, 0xb2, 0x2f, 0xbb, 0xec, 0xdf, 0xd7, 0xe7, 0x0f, 0x9e, 0x5d, 0x08, 0xd3,
+	0xac, 0xd9, 0x56, 0x3b, 0x9e, 0x42, 0xdc, 0x1d, 0xe0, 0x31, 0x84, 0x6f, 0x7c, 0x4d, 0x49, 0x42,
+	0xb2, 0x11, 0x73, 0x25, 0x9e, 0x42, 0xef, 0xa3, 0x5c, 0x59, 0x4e, 0x83, 0x84, 0x64, 0x31, 0xf3,
+	0xcd, 0x34, 0xb8, 0x21, 0xe9, 0x1c, 0x4e, 0x7e, 0xbc, 0xa3, 0x95, 0x14, 0x9a, 0xef, 0x97, 0x23,
+	0x55, 0xd0, 0xf7, 0x10, 0x26, 0x10, 0x39, 0x57, 0xb7, 0x8d, 0xb4, 0xea, 0xae, 0xda, 0x58, 0xe8,
+	0x42, 0x78, 0x06, 0x23, 0xd7, 0xce, 0xa5, 0x15, 0xa6, 0xb5, 0xd3, 0x63, 0x3b, 0xc0, 0x19, 0xad,
+	0xf8, 0xc2, 0xd6, 0x34, 0x6c, 0x95, 0xbe, 0x71, 0x81, 0x94, 0xac, 0xe8, 0x61, 0x12, 0x66, 0x31,
+	0x73, 0xe5, 0xe4, 0x05, 0x86, 0xc5, 0x66, 0x39, 0xf8, 0x04, 0x51, 0x27, 0x02, 0x9e, 0xff, 0xff,
+	0x87, 0xe3, 0x8b, 0x3f, 0xe7, 0x3e, 0x7b, 0x7a, 0x30, 0x1b, 0x3e, 0xf7, 0xdb, 0x8d, 0xeb, 0x85,
+	0x3f, 0xaf, 0xbf, 0x07, 0x00, 0x24, 0x7a, 0x32, 0x7b, 0x0b, 0x02, 0x00, 0x00,
+}
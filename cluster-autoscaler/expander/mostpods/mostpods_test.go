@@ -41,3 +41,16 @@ func TestMostPods(t *testing.T) {
 	assert.NotEqual(t, *ret, eo0)
 	assert.True(t, assert.ObjectsAreEqual(*ret, eo1) || assert.ObjectsAreEqual(*ret, eo1b))
 }
+
+func TestMostPodsBestOptions(t *testing.T) {
+	e := NewStrategy()
+
+	eo0 := expander.Option{Debug: "EO0"}
+	eo1 := expander.Option{Debug: "EO1", Pods: []*apiv1.Pod{nil}}
+	eo1b := expander.Option{Debug: "EO1b", Pods: []*apiv1.Pod{nil}}
+	ret := e.BestOptions([]expander.Option{eo0, eo1, eo1b}, nil)
+	assert.Equal(t, []expander.Option{eo1, eo1b}, ret)
+
+	ret = e.BestOptions([]expander.Option{}, nil)
+	assert.Empty(t, ret)
+}
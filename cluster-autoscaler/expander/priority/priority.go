@@ -121,13 +121,33 @@ func (p *priority) parsePrioritiesYAMLString(prioritiesYAML string) (priorities,
 }
 
 func (p *priority) BestOption(expansionOptions []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) *expander.Option {
-	if len(expansionOptions) <= 0 {
+	best, err := p.bestOptions(expansionOptions)
+	if err != nil || len(best) == 0 {
 		return nil
 	}
+	return p.fallbackStrategy.BestOption(best, nodeInfo)
+}
+
+// BestOptions returns the options with the highest priority. If none of the options
+// is matched by the priority configuration, or the configuration can't be loaded,
+// all of them are returned, so that the next expander in a chain can choose among them.
+func (p *priority) BestOptions(expansionOptions []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) []expander.Option {
+	best, err := p.bestOptions(expansionOptions)
+	if err != nil {
+		klog.Warningf("Priority expander: %v. All expansion options are treated as equally good.", err)
+		return expansionOptions
+	}
+	return best
+}
+
+func (p *priority) bestOptions(expansionOptions []expander.Option) ([]expander.Option, error) {
+	if len(expansionOptions) <= 0 {
+		return nil, nil
+	}
 
 	priorities, cm, err := p.reloadConfigMap()
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
 	maxPrio := -1
@@ -160,13 +180,13 @@ func (p *priority) BestOption(expansionOptions []expander.Option, nodeInfo map[s
 	if len(best) == 0 {
 		msg := "Priority expander: no priorities info found for any of the expansion options. Falling back to random choice."
 		p.logConfigWarning(cm, "PriorityConfigMapNoGroupMatched", msg)
-		return p.fallbackStrategy.BestOption(expansionOptions, nodeInfo)
+		return expansionOptions, nil
 	}
 
 	for _, opt := range best {
 		klog.V(2).Infof("priority expander: %s chosen as the highest available", opt.NodeGroup.Id())
 	}
-	return p.fallbackStrategy.BestOption(best, nodeInfo)
+	return best, nil
 }
 
 func (p *priority) groupIDMatchesList(id string, nameRegexpList []*regexp.Regexp) bool {
@@ -125,6 +125,30 @@ func TestPriorityExpanderCorrecltyFallsBackToRandomWhenNoMatches(t *testing.T) {
 	}
 }
 
+func TestPriorityExpanderBestOptionsReturnsAllHighestPriorityOptions(t *testing.T) {
+	s, _, _, _ := getStrategyInstance(t, config)
+	ret := s.BestOptions([]expander.Option{eoT2Large, eoT3Large, eoT2Micro}, nil)
+	assert.Equal(t, []expander.Option{eoT2Large, eoT3Large}, ret)
+}
+
+func TestPriorityExpanderBestOptionsReturnsAllOptionsWhenNoMatches(t *testing.T) {
+	s, _, _, _ := getStrategyInstance(t, notMatchingConfig)
+	ret := s.BestOptions([]expander.Option{eoT2Large, eoT3Large}, nil)
+	assert.Equal(t, []expander.Option{eoT2Large, eoT3Large}, ret)
+}
+
+func TestPriorityExpanderWithoutConfigMap(t *testing.T) {
+	lister, err := kubernetes.NewTestConfigMapLister(nil)
+	assert.Nil(t, err)
+	s, err := NewStrategy(lister.ConfigMaps(testNamespace), record.NewFakeRecorder(100))
+	assert.Nil(t, err)
+
+	assert.Nil(t, s.BestOption([]expander.Option{eoT2Large, eoT3Large}, nil))
+	// All options are passed on to the next expander in a chain.
+	ret := s.BestOptions([]expander.Option{eoT2Large, eoT3Large}, nil)
+	assert.Equal(t, []expander.Option{eoT2Large, eoT3Large}, ret)
+}
+
 func TestPriorityExpanderCorrecltyHandlesConfigUpdate(t *testing.T) {
 	s, r, cm, _ := getStrategyInstance(t, oneEntryConfig)
 	ret := s.BestOption([]expander.Option{eoT2Large, eoT3Large, eoM44XLarge}, nil)
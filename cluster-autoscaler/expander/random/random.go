@@ -31,6 +31,15 @@ func NewStrategy() expander.Strategy {
 	return &random{}
 }
 
+// BestOptions selects a single option at random, it never leaves a tie behind.
+func (r *random) BestOptions(expansionOptions []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) []expander.Option {
+	best := r.BestOption(expansionOptions, nodeInfo)
+	if best == nil {
+		return nil
+	}
+	return []expander.Option{*best}
+}
+
 // RandomExpansion Selects from the expansion options at random
 func (r *random) BestOption(expansionOptions []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) *expander.Option {
 	if len(expansionOptions) <= 0 {
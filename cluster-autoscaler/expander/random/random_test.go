@@ -38,3 +38,16 @@ func TestRandomExpander(t *testing.T) {
 	ret = e.BestOption([]expander.Option{}, nil)
 	assert.Nil(t, ret)
 }
+
+func TestRandomExpanderBestOptions(t *testing.T) {
+	e := NewStrategy()
+
+	eo1a := expander.Option{Debug: "EO1a"}
+	eo1b := expander.Option{Debug: "EO1b"}
+	ret := e.BestOptions([]expander.Option{eo1a, eo1b}, nil)
+	assert.Equal(t, 1, len(ret))
+	assert.True(t, assert.ObjectsAreEqual(ret[0], eo1a) || assert.ObjectsAreEqual(ret[0], eo1b))
+
+	ret = e.BestOptions([]expander.Option{}, nil)
+	assert.Empty(t, ret)
+}
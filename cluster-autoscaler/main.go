@@ -30,13 +30,18 @@ import (
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ca_clientset "k8s.io/autoscaler/cluster-autoscaler/client/clientset/versioned"
 	cloudBuilder "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/builder"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider/composite"
 	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/core"
 	"k8s.io/autoscaler/cluster-autoscaler/estimator"
 	"k8s.io/autoscaler/cluster-autoscaler/expander"
 	"k8s.io/autoscaler/cluster-autoscaler/metrics"
 	ca_processors "k8s.io/autoscaler/cluster-autoscaler/processors"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupset"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
+	"k8s.io/autoscaler/cluster-autoscaler/replay"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/errors"
 	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/units"
@@ -120,7 +125,8 @@ var (
 	memoryTotal       = flag.String("memory-total", minMaxFlagString(0, config.DefaultMaxClusterMemory), "Minimum and maximum number of gigabytes of memory in cluster, in the format <min>:<max>. Cluster autoscaler will not scale the cluster beyond these numbers.")
 	gpuTotal          = multiStringFlag("gpu-total", "Minimum and maximum number of different GPUs in cluster, in the format <gpu_type>:<min>:<max>. Cluster autoscaler will not scale the cluster beyond these numbers. Can be passed multiple times. CURRENTLY THIS FLAG ONLY WORKS ON GKE.")
 	cloudProviderFlag = flag.String("cloud-provider", cloudBuilder.DefaultCloudProvider,
-		"Cloud provider type. Available values: ["+strings.Join(cloudBuilder.AvailableCloudProviders, ",")+"]")
+		"Cloud provider type. Available values: ["+strings.Join(cloudBuilder.AvailableCloudProviders, ",")+"], "+
+			"or "+composite.ProviderName+" to combine several of them, see --composite-cloud-provider.")
 	maxBulkSoftTaintCount      = flag.Int("max-bulk-soft-taint-count", 10, "Maximum number of nodes that can be tainted/untainted PreferNoSchedule at the same time. Set to 0 to turn off such tainting.")
 	maxBulkSoftTaintTime       = flag.Duration("max-bulk-soft-taint-time", 3*time.Second, "Maximum duration of tainting/untainting nodes as PreferNoSchedule at the same time.")
 	maxEmptyBulkDeleteFlag     = flag.Int("max-empty-bulk-delete", 10, "Maximum number of empty nodes that can be deleted at the same time.")
@@ -143,7 +149,14 @@ var (
 		"Type of resource estimator to be used in scale up. Available values: ["+strings.Join(estimator.AvailableEstimators, ",")+"]")
 
 	expanderFlag = flag.String("expander", expander.RandomExpanderName,
-		"Type of node group expander to be used in scale up. Available values: ["+strings.Join(expander.AvailableExpanders, ",")+"]")
+		"Type of node group expander to be used in scale up. Available values: ["+strings.Join(expander.AvailableExpanders, ",")+"]. "+
+			"Can be a comma-separated list of expanders, each of them filters the options left by the previous one and the last one picks the final option.")
+
+	grpcExpanderCert     = flag.String("grpc-expander-cert", "", "Path to cert used by gRPC server over TLS")
+	grpcExpanderURL      = flag.String("grpc-expander-url", "", "URL to reach gRPC expander server.")
+	grpcExpanderTimeout  = flag.Duration("grpc-expander-timeout", 5*time.Second, "Timeout of a single call to the gRPC expander server.")
+	grpcExpanderFallback = flag.String("grpc-expander-fallback", expander.RandomExpanderName,
+		"Expander used when the gRPC expander server fails to return an option. Available values: ["+strings.Join(expander.AvailableExpanders, ",")+"]")
 
 	ignoreDaemonSetsUtilization = flag.Bool("ignore-daemonsets-utilization", false,
 		"Should CA ignore DaemonSet pods when calculating resource utilization for scaling down")
@@ -157,6 +170,13 @@ var (
 	nodeAutoprovisioningEnabled      = flag.Bool("node-autoprovisioning-enabled", false, "Should CA autoprovision node groups when needed")
 	maxAutoprovisionedNodeGroupCount = flag.Int("max-autoprovisioned-node-group-count", 15, "The maximum number of autoprovisioned groups in the cluster.")
 
+	balancingIgnoreLabelsFlag     = multiStringFlag("balancing-ignore-label", "Specifies a label to ignore in addition to the basic and cloud-provider set of labels when comparing if two node groups are similar. Can be used multiple times.")
+	maxAllocatableDifferenceRatio = flag.Float64("max-allocatable-difference-ratio", nodegroupset.MaxAllocatableDifferenceRatio, "Maximum difference in allocatable resources between two similar node groups, as a ratio of the larger one.")
+	maxFreeDifferenceRatio        = flag.Float64("max-free-difference-ratio", nodegroupset.MaxFreeDifferenceRatio, "Maximum difference in free resources between two similar node groups, as a ratio of the larger one.")
+
+	awsAutoprovisioningLaunchTemplate = flag.String("aws-autoprovisioning-launch-template", "", "The launch template, as <name> or <name>:<version>, used to create autoprovisioned ASGs. Required by node autoprovisioning on AWS.")
+	awsAutoprovisioningSubnets        = flag.String("aws-autoprovisioning-subnets", "", "Comma-separated list of subnets in which autoprovisioned ASGs are created. Required by node autoprovisioning on AWS.")
+
 	unremovableNodeRecheckTimeout       = flag.Duration("unremovable-node-recheck-timeout", 5*time.Minute, "The timeout before we check again a node that couldn't be removed before")
 	expendablePodsPriorityCutoff        = flag.Int("expendable-pods-priority-cutoff", -10, "Pods with priority below cutoff will be expendable. They can be killed without any consideration during scale down and they don't cause scale up. Pods with null priority (PodPriority disabled) are non expendable.")
 	regional                            = flag.Bool("regional", false, "Cluster is regional.")
@@ -165,6 +185,24 @@ var (
 		"Filtering out schedulable pods before CA scale up by trying to pack the schedulable pods on free capacity on existing nodes."+
 			"Setting it to false employs a more lenient filtering approach that does not try to pack the pods on the nodes."+
 			"Pods with nominatedNodeName set are always filtered out.")
+
+	captureStateFile = flag.String("capture-state-file", "", "If set, a request to /capture-state makes CA write its input state in the next iteration to this file, as YAML if it has a .yaml extension and as JSON otherwise. The file can be replayed with ca-replay.")
+	dryRun           = flag.Bool("dry-run", false, "Should CA only report scale-up and scale-down decisions as events, metrics and logs, without changing node groups, tainting or draining nodes.")
+
+	scheduledCapacityEnabled = flag.Bool("scheduled-capacity-enabled", false, "Should CA raise minimum sizes of node groups according to schedules defined in the cluster-autoscaler-scheduled-capacity config map.")
+
+	writeStatusCRDFlag = flag.Bool("write-status-crd", false, "Should CA write status information to a ClusterAutoscalerStatus custom resource. Requires the ClusterAutoscalerStatus CRD to be installed.")
+
+	maxDrainParallelismFlag = flag.Int("max-drain-parallelism", 1, "Maximum number of non-empty nodes that can be drained and deleted at the same time.")
+
+	compositeCloudProvidersFlag = multiStringFlag("composite-cloud-provider",
+		"A cloud provider combined by --cloud-provider="+composite.ProviderName+", in the format <provider>:<cloud-config>[:<providerID prefix>]. "+
+			"Nodes are routed to the cloud provider with the longest matching providerID prefix, which defaults to <provider>://. "+
+			"--nodes and --node-group-auto-discovery values must be prefixed with <provider>/. Can be used multiple times.")
+
+	dynamicOptionsEnabled = flag.Bool("dynamic-options-enabled", false, "Should CA override autoscaling options and node group sizes with the ones defined in the cluster-autoscaler-options config map, reloaded in every loop.")
+
+	persistClusterState = flag.Bool("persist-cluster-state", false, "Should CA save in-progress scale-ups, recent scale events and backoff of node groups in the cluster-autoscaler-state config map and restore them after a restart or leader failover.")
 )
 
 func createAutoscalingOptions() config.AutoscalingOptions {
@@ -185,19 +223,32 @@ func createAutoscalingOptions() config.AutoscalingOptions {
 		klog.Fatalf("Failed to parse flags: %v", err)
 	}
 
+	nodeGroupDifferenceRatios := config.NodeGroupDifferenceRatios{
+		MaxAllocatableDifferenceRatio: *maxAllocatableDifferenceRatio,
+		MaxFreeDifferenceRatio:        *maxFreeDifferenceRatio,
+	}
+
 	return config.AutoscalingOptions{
 		CloudConfig:                         *cloudConfig,
 		CloudProviderName:                   *cloudProviderFlag,
+		CompositeCloudProviders:             *compositeCloudProvidersFlag,
+		AWSAutoprovisioningLaunchTemplate:   *awsAutoprovisioningLaunchTemplate,
+		AWSAutoprovisioningSubnets:          *awsAutoprovisioningSubnets,
 		NodeGroupAutoDiscovery:              *nodeGroupAutoDiscoveryFlag,
 		MaxTotalUnreadyPercentage:           *maxTotalUnreadyPercentage,
 		OkTotalUnreadyCount:                 *okTotalUnreadyCount,
 		EstimatorName:                       *estimatorFlag,
 		ExpanderName:                        *expanderFlag,
+		GRPCExpanderCert:                    *grpcExpanderCert,
+		GRPCExpanderURL:                     *grpcExpanderURL,
+		GRPCExpanderTimeout:                 *grpcExpanderTimeout,
+		GRPCExpanderFallback:                *grpcExpanderFallback,
 		IgnoreDaemonSetsUtilization:         *ignoreDaemonSetsUtilization,
 		IgnoreMirrorPodsUtilization:         *ignoreMirrorPodsUtilization,
 		MaxBulkSoftTaintCount:               *maxBulkSoftTaintCount,
 		MaxBulkSoftTaintTime:                *maxBulkSoftTaintTime,
 		MaxEmptyBulkDelete:                  *maxEmptyBulkDeleteFlag,
+		MaxDrainParallelism:                 *maxDrainParallelismFlag,
 		MaxGracefulTerminationSec:           *maxGracefulTerminationFlag,
 		MaxNodeProvisionTime:                *maxNodeProvisionTime,
 		MaxNodesTotal:                       *maxNodesTotal,
@@ -220,6 +271,8 @@ func createAutoscalingOptions() config.AutoscalingOptions {
 		ScaleDownCandidatesPoolMinCount:     *scaleDownCandidatesPoolMinCount,
 		WriteStatusConfigMap:                *writeStatusConfigMapFlag,
 		BalanceSimilarNodeGroups:            *balanceSimilarNodeGroupsFlag,
+		BalancingExtraIgnoredLabels:         *balancingIgnoreLabelsFlag,
+		NodeGroupDifferenceRatios:           nodeGroupDifferenceRatios,
 		ConfigNamespace:                     *namespace,
 		ClusterName:                         *clusterName,
 		NodeAutoprovisioningEnabled:         *nodeAutoprovisioningEnabled,
@@ -229,6 +282,10 @@ func createAutoscalingOptions() config.AutoscalingOptions {
 		Regional:                            *regional,
 		NewPodScaleUpDelay:                  *newPodScaleUpDelay,
 		FilterOutSchedulablePodsUsesPacking: *filterOutSchedulablePodsUsesPacking,
+		DryRun:                              *dryRun,
+		ScheduledCapacityEnabled:            *scheduledCapacityEnabled,
+		DynamicOptionsEnabled:               *dynamicOptionsEnabled,
+		PersistClusterState:                 *persistClusterState,
 	}
 }
 
@@ -282,12 +339,21 @@ func buildAutoscaler() (core.Autoscaler, error) {
 
 	processors := ca_processors.DefaultProcessors()
 	processors.PodListProcessor = core.NewFilterOutSchedulablePodListProcessor()
+	processors.NodeGroupSetProcessor = &nodegroupset.BalancingNodeGroupSetProcessor{
+		Comparator: nodegroupset.CreateNodeInfoComparator(autoscalingOptions.CloudProviderName,
+			autoscalingOptions.BalancingExtraIgnoredLabels, autoscalingOptions.NodeGroupDifferenceRatios),
+	}
+	if *writeStatusCRDFlag {
+		processors.AutoscalingStatusProcessor = status.NewCRDStatusProcessor(
+			ca_clientset.NewForConfigOrDie(getKubeConfig()), autoscalingOptions.ConfigNamespace)
+	}
 
 	opts := core.AutoscalerOptions{
 		AutoscalingOptions: autoscalingOptions,
 		KubeClient:         kubeClient,
 		EventsKubeClient:   eventsKubeClient,
 		Processors:         processors,
+		StateCapturer:      stateCapturer,
 	}
 
 	// This metric should be published only once.
@@ -297,6 +363,9 @@ func buildAutoscaler() (core.Autoscaler, error) {
 	return core.NewAutoscaler(opts)
 }
 
+// stateCapturer is shared by the HTTP handler and the autoscaler, if state capture is enabled.
+var stateCapturer *replay.StateCapturer
+
 func run(healthCheck *metrics.HealthCheck) {
 	metrics.RegisterAll()
 
@@ -342,12 +411,18 @@ func main() {
 	leaderelectionconfig.BindFlags(&leaderElection, pflag.CommandLine)
 	kube_flag.InitFlags()
 	healthCheck := metrics.NewHealthCheck(*maxInactivityTimeFlag, *maxFailingTimeFlag)
+	if *captureStateFile != "" {
+		stateCapturer = replay.NewStateCapturer(*captureStateFile)
+	}
 
 	klog.V(1).Infof("Cluster Autoscaler %s", version.ClusterAutoscalerVersion)
 
 	go func() {
 		http.Handle("/metrics", prometheus.Handler())
 		http.Handle("/health-check", healthCheck)
+		if stateCapturer != nil {
+			http.Handle("/capture-state", stateCapturer)
+		}
 		err := http.ListenAndServe(*address, nil)
 		klog.Fatalf("Failed to start metrics: %v", err)
 	}()
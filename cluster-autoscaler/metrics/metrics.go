@@ -191,6 +191,14 @@ var (
 		}, []string{"reason", "gpu_name"},
 	)
 
+	scaleDownSavings = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "scaled_down_hourly_savings_total",
+			Help:      "Sum of estimated hourly savings of nodes removed by CA, in the currency of the cloud provider pricing model.",
+		},
+	)
+
 	evictionsCount = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Namespace: caNamespace,
@@ -207,6 +215,30 @@ var (
 		},
 	)
 
+	unremovableNodesCount = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: caNamespace,
+			Name:      "unremovable_nodes_count",
+			Help:      "Number of nodes currently considered unremovable by CA, by reason.",
+		}, []string{"reason"},
+	)
+
+	dryRunActionsCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "dry_run_actions_total",
+			Help:      "Number of cloud provider calls skipped in dry-run mode, by action.",
+		}, []string{"action"},
+	)
+
+	dryRunNodesCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: caNamespace,
+			Name:      "dry_run_nodes_total",
+			Help:      "Number of nodes which would be added or removed by CA in dry-run mode, by action.",
+		}, []string{"action"},
+	)
+
 	/**** Metrics related to NodeAutoprovisioning ****/
 	napEnabled = prometheus.NewGauge(
 		prometheus.GaugeOpts{
@@ -247,8 +279,12 @@ func RegisterAll() {
 	prometheus.MustRegister(failedScaleUpCount)
 	prometheus.MustRegister(scaleDownCount)
 	prometheus.MustRegister(gpuScaleDownCount)
+	prometheus.MustRegister(scaleDownSavings)
 	prometheus.MustRegister(evictionsCount)
 	prometheus.MustRegister(unneededNodesCount)
+	prometheus.MustRegister(unremovableNodesCount)
+	prometheus.MustRegister(dryRunActionsCount)
+	prometheus.MustRegister(dryRunNodesCount)
 	prometheus.MustRegister(napEnabled)
 	prometheus.MustRegister(nodeGroupCreationCount)
 	prometheus.MustRegister(nodeGroupDeletionCount)
@@ -332,6 +368,14 @@ func RegisterScaleDown(nodesCount int, gpuType string, reason NodeScaleDownReaso
 	}
 }
 
+// RegisterScaleDownSavings records estimated hourly savings of a removed node. Negative
+// savings aren't recorded, as counters can't decrease.
+func RegisterScaleDownSavings(hourlySavings float64) {
+	if hourlySavings > 0 {
+		scaleDownSavings.Add(hourlySavings)
+	}
+}
+
 // RegisterEvictions records number of evicted pods
 func RegisterEvictions(podsCount int) {
 	evictionsCount.Add(float64(podsCount))
@@ -342,6 +386,22 @@ func UpdateUnneededNodesCount(nodesCount int) {
 	unneededNodesCount.Set(float64(nodesCount))
 }
 
+// UpdateUnremovableNodesCount records number of currently unremovable nodes, by reason.
+// Reasons missing from the map are cleared.
+func UpdateUnremovableNodesCount(countByReason map[string]int) {
+	unremovableNodesCount.Reset()
+	for reason, count := range countByReason {
+		unremovableNodesCount.WithLabelValues(reason).Set(float64(count))
+	}
+}
+
+// RegisterDryRunAction records a cloud provider call skipped in dry-run mode and the number
+// of nodes it would add or remove
+func RegisterDryRunAction(action string, nodesCount int) {
+	dryRunActionsCount.WithLabelValues(action).Inc()
+	dryRunNodesCount.WithLabelValues(action).Add(float64(nodesCount))
+}
+
 // UpdateNapEnabled records if NodeAutoprovisioning is enabled
 func UpdateNapEnabled(enabled bool) {
 	if enabled {
@@ -0,0 +1,287 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicoptions
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/api"
+	"k8s.io/autoscaler/cluster-autoscaler/config/dynamic"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+)
+
+const (
+	// DynamicOptionsConfigMapName defines a name of the ConfigMap used to store autoscaling options
+	DynamicOptionsConfigMapName = "cluster-autoscaler-options"
+	// ConfigMapKey defines the key used in the ConfigMap to store the options document
+	ConfigMapKey = "options"
+)
+
+// ExpanderBuilder builds the expander strategy for the given --expander value. Listers started by
+// the strategy must be stopped when stopChannel is closed.
+type ExpanderBuilder func(expanderName string, stopChannel <-chan struct{}) (expander.Strategy, error)
+
+// DynamicOptionsProcessor overrides autoscaling options with the ones defined in a ConfigMap. The options
+// are reloaded at the beginning of every loop. Options not defined in the ConfigMap keep the values
+// of the command line flags. Min and max sizes of node groups overridden in the ConfigMap are exposed
+// to the rest of CA by wrapping the cloud provider (see NewCloudProvider).
+type DynamicOptionsProcessor struct {
+	configMapLister v1lister.ConfigMapNamespaceLister
+	logRecorder     record.EventRecorder
+	expanderBuilder ExpanderBuilder
+
+	lock sync.Mutex
+	// flagOptions and flagExpanderStrategy come from the command line, they are captured on the first refresh.
+	flagOptions          *dynamicOptions
+	flagExpanderStrategy expander.Strategy
+	// options and expanderStrategy come from the last valid version of the ConfigMap. expanderStopChannel
+	// is closed when expanderStrategy is replaced, it is nil if the strategy wasn't built by the processor.
+	options               *dynamicOptions
+	expanderStrategy      expander.Strategy
+	expanderStopChannel   chan struct{}
+	configLoaded          bool
+	configResourceVersion string
+	condition             api.ClusterAutoscalerCondition
+}
+
+// NewDynamicOptionsProcessor returns a processor reading options from the given ConfigMap lister.
+// New expander strategies are built with expanderBuilder.
+func NewDynamicOptionsProcessor(configMapLister v1lister.ConfigMapNamespaceLister,
+	logRecorder record.EventRecorder, expanderBuilder ExpanderBuilder) *DynamicOptionsProcessor {
+	return &DynamicOptionsProcessor{
+		configMapLister: configMapLister,
+		logRecorder:     logRecorder,
+		expanderBuilder: expanderBuilder,
+	}
+}
+
+// Refresh reloads the options from the ConfigMap and applies them to the autoscaling context.
+// It should be called at the beginning of every loop, before the options are used.
+// The options in the context at the first call are considered the command line options.
+func (p *DynamicOptionsProcessor) Refresh(context *context.AutoscalingContext, currentTime time.Time) {
+	p.lock.Lock()
+	if p.flagOptions == nil {
+		p.flagOptions = &dynamicOptions{
+			options:        context.AutoscalingOptions,
+			nodeGroupSpecs: make(map[string]dynamic.NodeGroupSpec),
+		}
+		p.flagExpanderStrategy = context.ExpanderStrategy
+		p.options = p.flagOptions
+		p.expanderStrategy = p.flagExpanderStrategy
+	}
+	p.lock.Unlock()
+
+	p.reloadConfigMap(currentTime)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.condition.LastProbeTime = metav1.NewTime(currentTime)
+	context.AutoscalingOptions = p.options.options
+	context.ExpanderStrategy = p.expanderStrategy
+}
+
+// Condition returns the clusterwide condition describing whether the last version of the ConfigMap was valid.
+func (p *DynamicOptionsProcessor) Condition() api.ClusterAutoscalerCondition {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.condition
+}
+
+// MinSize returns the minimum size of the node group, overridden in the ConfigMap.
+func (p *DynamicOptionsProcessor) MinSize(nodeGroup cloudprovider.NodeGroup) int {
+	if spec, found := p.nodeGroupSpec(nodeGroup.Id()); found {
+		return spec.MinSize
+	}
+	return nodeGroup.MinSize()
+}
+
+// MaxSize returns the maximum size of the node group, overridden in the ConfigMap.
+func (p *DynamicOptionsProcessor) MaxSize(nodeGroup cloudprovider.NodeGroup) int {
+	if spec, found := p.nodeGroupSpec(nodeGroup.Id()); found {
+		return spec.MaxSize
+	}
+	return nodeGroup.MaxSize()
+}
+
+func (p *DynamicOptionsProcessor) nodeGroupSpec(id string) (dynamic.NodeGroupSpec, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.options == nil {
+		return dynamic.NodeGroupSpec{}, false
+	}
+	spec, found := p.options.nodeGroupSpecs[id]
+	return spec, found
+}
+
+// ResourceLimiter returns the given resource limiter with cores and memory limits overridden in the ConfigMap.
+func (p *DynamicOptionsProcessor) ResourceLimiter(resourceLimiter *cloudprovider.ResourceLimiter) *cloudprovider.ResourceLimiter {
+	p.lock.Lock()
+	options := p.options
+	p.lock.Unlock()
+	if options == nil || (!options.coresTotalOverridden && !options.memoryTotalOverridden) {
+		return resourceLimiter
+	}
+	minLimits := make(map[string]int64)
+	maxLimits := make(map[string]int64)
+	if resourceLimiter != nil {
+		for _, resourceName := range resourceLimiter.GetResources() {
+			if resourceLimiter.HasMinLimitSet(resourceName) {
+				minLimits[resourceName] = resourceLimiter.GetMin(resourceName)
+			}
+			if resourceLimiter.HasMaxLimitSet(resourceName) {
+				maxLimits[resourceName] = resourceLimiter.GetMax(resourceName)
+			}
+		}
+	}
+	if options.coresTotalOverridden {
+		minLimits[cloudprovider.ResourceNameCores] = options.options.MinCoresTotal
+		maxLimits[cloudprovider.ResourceNameCores] = options.options.MaxCoresTotal
+	}
+	if options.memoryTotalOverridden {
+		minLimits[cloudprovider.ResourceNameMemory] = options.options.MinMemoryTotal
+		maxLimits[cloudprovider.ResourceNameMemory] = options.options.MaxMemoryTotal
+	}
+	return cloudprovider.NewResourceLimiter(minLimits, maxLimits)
+}
+
+// CleanUp cleans up the processor's internal structures.
+func (p *DynamicOptionsProcessor) CleanUp() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.setExpanderStrategy(p.flagExpanderStrategy, nil)
+}
+
+// reloadConfigMap parses the ConfigMap if it changed since the last call. Invalid versions are rejected
+// and the previous options are kept. If there is no ConfigMap, the command line options are used.
+func (p *DynamicOptionsProcessor) reloadConfigMap(currentTime time.Time) {
+	cm, err := p.configMapLister.Get(DynamicOptionsConfigMapName)
+	if err != nil {
+		klog.V(4).Infof("Dynamic options config map %s not found: %v", DynamicOptionsConfigMapName, err)
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		if p.configLoaded {
+			p.logChanges(nil, p.flagOptions)
+		}
+		p.options = p.flagOptions
+		p.setExpanderStrategy(p.flagExpanderStrategy, nil)
+		p.configLoaded = false
+		p.condition = buildCondition(api.ClusterAutoscalerOptionsValid, "",
+			"no config map, using command line options", currentTime)
+		return
+	}
+	p.lock.Lock()
+	unchanged := p.configLoaded && cm.ResourceVersion == p.configResourceVersion
+	p.lock.Unlock()
+	if unchanged {
+		return
+	}
+
+	document, found := cm.Data[ConfigMapKey]
+	var options *dynamicOptions
+	var expanderStrategy expander.Strategy
+	var expanderStopChannel chan struct{}
+	if !found {
+		err = fmt.Errorf("config map doesn't contain %s key", ConfigMapKey)
+	} else if options, err = parseOptionsDocument(document, p.flagOptions.options); err == nil {
+		expanderStrategy, expanderStopChannel, err = p.buildExpanderStrategy(options.options.ExpanderName)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.configLoaded = true
+	p.configResourceVersion = cm.ResourceVersion
+	if err != nil {
+		msg := fmt.Sprintf("Wrong configuration for autoscaling options: %v. Ignoring update.", err)
+		p.logRecorder.Event(cm, apiv1.EventTypeWarning, "AutoscalingOptionsInvalid", msg)
+		klog.Warning(msg)
+		p.condition = buildCondition(api.ClusterAutoscalerOptionsInvalid, "InvalidConfigMap",
+			fmt.Sprintf("resourceVersion=%s: %v", cm.ResourceVersion, err), currentTime)
+		return
+	}
+	p.logChanges(cm, options)
+	p.options = options
+	p.setExpanderStrategy(expanderStrategy, expanderStopChannel)
+	p.condition = buildCondition(api.ClusterAutoscalerOptionsValid, "",
+		fmt.Sprintf("resourceVersion=%s", cm.ResourceVersion), currentTime)
+}
+
+// buildExpanderStrategy reuses the current strategy or the command line one if possible, as building
+// a strategy can be expensive. It also returns the channel stopping the listers of the strategy.
+func (p *DynamicOptionsProcessor) buildExpanderStrategy(expanderName string) (expander.Strategy, chan struct{}, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if expanderName == p.flagOptions.options.ExpanderName {
+		return p.flagExpanderStrategy, nil, nil
+	}
+	if expanderName == p.options.options.ExpanderName {
+		return p.expanderStrategy, p.expanderStopChannel, nil
+	}
+	if p.expanderBuilder == nil {
+		return nil, nil, fmt.Errorf("expander can't be changed")
+	}
+	stopChannel := make(chan struct{})
+	strategy, err := p.expanderBuilder(expanderName, stopChannel)
+	if err != nil {
+		close(stopChannel)
+		return nil, nil, fmt.Errorf("expander: %v", err)
+	}
+	return strategy, stopChannel, nil
+}
+
+// setExpanderStrategy replaces the current expander strategy, stopping the listers of the previous one
+// if it was built by the processor. The caller must hold the lock.
+func (p *DynamicOptionsProcessor) setExpanderStrategy(strategy expander.Strategy, stopChannel chan struct{}) {
+	if p.expanderStopChannel != nil && p.expanderStopChannel != stopChannel {
+		close(p.expanderStopChannel)
+	}
+	p.expanderStrategy = strategy
+	p.expanderStopChannel = stopChannel
+}
+
+// logChanges logs the changes between the current options and the new ones, and records them
+// as an event on the ConfigMap, if there is one.
+func (p *DynamicOptionsProcessor) logChanges(cm *apiv1.ConfigMap, options *dynamicOptions) {
+	changes := diffOptions(p.options, options)
+	if len(changes) == 0 {
+		return
+	}
+	msg := fmt.Sprintf("Autoscaling options updated: %s", strings.Join(changes, ", "))
+	klog.V(1).Info(msg)
+	if cm != nil {
+		p.logRecorder.Event(cm, apiv1.EventTypeNormal, "AutoscalingOptionsUpdated", msg)
+	}
+}
+
+func buildCondition(status api.ClusterAutoscalerConditionStatus, reason, message string, currentTime time.Time) api.ClusterAutoscalerCondition {
+	return api.ClusterAutoscalerCondition{
+		Type:          api.ClusterAutoscalerOptions,
+		Status:        status,
+		Reason:        reason,
+		Message:       message,
+		LastProbeTime: metav1.NewTime(currentTime),
+	}
+}
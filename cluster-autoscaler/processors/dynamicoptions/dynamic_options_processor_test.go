@@ -0,0 +1,292 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicoptions
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/api"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/expander"
+	"k8s.io/client-go/tools/record"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+var now = time.Date(2019, 10, 7, 9, 0, 0, 0, time.UTC)
+
+type configMapListerMock struct {
+	configMap *apiv1.ConfigMap
+}
+
+func (l *configMapListerMock) List(selector labels.Selector) ([]*apiv1.ConfigMap, error) {
+	if l.configMap == nil {
+		return nil, nil
+	}
+	return []*apiv1.ConfigMap{l.configMap}, nil
+}
+
+func (l *configMapListerMock) Get(name string) (*apiv1.ConfigMap, error) {
+	if l.configMap == nil || l.configMap.Name != name {
+		return nil, errors.NewNotFound(apiv1.Resource("configmap"), name)
+	}
+	return l.configMap, nil
+}
+
+func (l *configMapListerMock) set(document, resourceVersion string) {
+	l.configMap = &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            DynamicOptionsConfigMapName,
+			Namespace:       "kube-system",
+			ResourceVersion: resourceVersion,
+		},
+		Data: map[string]string{ConfigMapKey: document},
+	}
+}
+
+// namedStrategy is an expander strategy which can be told apart from others by its name.
+type namedStrategy struct {
+	name        string
+	stopChannel <-chan struct{}
+}
+
+func (s *namedStrategy) BestOption(options []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) *expander.Option {
+	return nil
+}
+
+func (s *namedStrategy) BestOptions(options []expander.Option, nodeInfo map[string]*schedulernodeinfo.NodeInfo) []expander.Option {
+	return nil
+}
+
+func buildTestStrategy(expanderName string, stopChannel <-chan struct{}) (expander.Strategy, error) {
+	if expanderName == "unknown" {
+		return nil, fmt.Errorf("expander %s not supported", expanderName)
+	}
+	return &namedStrategy{name: expanderName, stopChannel: stopChannel}, nil
+}
+
+func stopped(strategy expander.Strategy) bool {
+	select {
+	case <-strategy.(*namedStrategy).stopChannel:
+		return true
+	default:
+		return false
+	}
+}
+
+func setUpProcessor() (*DynamicOptionsProcessor, *configMapListerMock, *context.AutoscalingContext,
+	*testprovider.TestCloudProvider, *record.FakeRecorder) {
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+	provider.AddNodeGroup("ng2", 2, 5, 2)
+	provider.SetResourceLimiter(cloudprovider.NewResourceLimiter(
+		map[string]int64{cloudprovider.ResourceNameCores: 0, "nvidia.com/gpu": 1},
+		map[string]int64{cloudprovider.ResourceNameCores: 320, "nvidia.com/gpu": 8}))
+
+	lister := &configMapListerMock{}
+	recorder := record.NewFakeRecorder(10)
+	processor := NewDynamicOptionsProcessor(lister, recorder, buildTestStrategy)
+	autoscalingContext := &context.AutoscalingContext{
+		AutoscalingOptions: flagOptions,
+		CloudProvider:      NewCloudProvider(provider, processor),
+		ExpanderStrategy:   &namedStrategy{name: flagOptions.ExpanderName},
+	}
+	return processor, lister, autoscalingContext, provider, recorder
+}
+
+func sizes(autoscalingContext *context.AutoscalingContext) map[string][2]int {
+	result := make(map[string][2]int)
+	for _, nodeGroup := range autoscalingContext.CloudProvider.NodeGroups() {
+		result[nodeGroup.Id()] = [2]int{nodeGroup.MinSize(), nodeGroup.MaxSize()}
+	}
+	return result
+}
+
+func expanderName(autoscalingContext *context.AutoscalingContext) string {
+	return autoscalingContext.ExpanderStrategy.(*namedStrategy).name
+}
+
+func assertEvents(t *testing.T, recorder *record.FakeRecorder, expected ...string) {
+	var events []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+			continue
+		default:
+		}
+		break
+	}
+	assert.ElementsMatch(t, expected, events)
+}
+
+func TestRefreshWithoutConfigMap(t *testing.T) {
+	processor, _, autoscalingContext, _, recorder := setUpProcessor()
+
+	processor.Refresh(autoscalingContext, now)
+	assert.Equal(t, flagOptions, autoscalingContext.AutoscalingOptions)
+	assert.Equal(t, "random", expanderName(autoscalingContext))
+	assert.Equal(t, map[string][2]int{"ng1": {1, 10}, "ng2": {2, 5}}, sizes(autoscalingContext))
+	condition := processor.Condition()
+	assert.Equal(t, api.ClusterAutoscalerOptions, condition.Type)
+	assert.Equal(t, api.ClusterAutoscalerOptionsValid, condition.Status)
+	assert.Equal(t, now, condition.LastProbeTime.Time)
+	assertEvents(t, recorder)
+}
+
+func TestRefreshAppliesOptions(t *testing.T) {
+	processor, lister, autoscalingContext, _, recorder := setUpProcessor()
+	processor.Refresh(autoscalingContext, now)
+
+	lister.set("scale-down-unneeded-time: 5m\nexpander: least-waste\nnodes: [\"0:3:ng2\"]", "1")
+	processor.Refresh(autoscalingContext, now.Add(time.Minute))
+	assert.Equal(t, 5*time.Minute, autoscalingContext.ScaleDownUnneededTime)
+	assert.Equal(t, "least-waste", autoscalingContext.ExpanderName)
+	assert.Equal(t, "least-waste", expanderName(autoscalingContext))
+	assert.Equal(t, map[string][2]int{"ng1": {1, 10}, "ng2": {0, 3}}, sizes(autoscalingContext))
+	assert.Equal(t, api.ClusterAutoscalerOptionsValid, processor.Condition().Status)
+	assertEvents(t, recorder, "Normal AutoscalingOptionsUpdated Autoscaling options updated: "+
+		"expander: random -> least-waste, nodes/ng2: none -> 0:3:ng2, scale-down-unneeded-time: 10m0s -> 5m0s")
+
+	// Options overwritten in the context, e.g. by a test, are restored in every loop.
+	autoscalingContext.ScaleDownUnneededTime = time.Hour
+	processor.Refresh(autoscalingContext, now.Add(2*time.Minute))
+	assert.Equal(t, 5*time.Minute, autoscalingContext.ScaleDownUnneededTime)
+	assert.Equal(t, now.Add(2*time.Minute), processor.Condition().LastProbeTime.Time)
+	assertEvents(t, recorder)
+
+	// Removing an option restores the command line value. The expander strategy isn't rebuilt.
+	lister.set("expander: random", "2")
+	processor.Refresh(autoscalingContext, now.Add(3*time.Minute))
+	assert.Equal(t, 10*time.Minute, autoscalingContext.ScaleDownUnneededTime)
+	assert.Equal(t, autoscalingContext.ExpanderStrategy, processor.flagExpanderStrategy)
+	assert.Equal(t, map[string][2]int{"ng1": {1, 10}, "ng2": {2, 5}}, sizes(autoscalingContext))
+	assertEvents(t, recorder, "Normal AutoscalingOptionsUpdated Autoscaling options updated: "+
+		"expander: least-waste -> random, nodes/ng2: 0:3:ng2 -> none, scale-down-unneeded-time: 5m0s -> 10m0s")
+}
+
+func TestRefreshRejectsInvalidOptions(t *testing.T) {
+	processor, lister, autoscalingContext, _, recorder := setUpProcessor()
+	lister.set("max-nodes-total: 50", "1")
+	processor.Refresh(autoscalingContext, now)
+	assert.Equal(t, 50, autoscalingContext.MaxNodesTotal)
+	assertEvents(t, recorder, "Normal AutoscalingOptionsUpdated Autoscaling options updated: max-nodes-total: 100 -> 50")
+
+	// Invalid versions are rejected as a whole.
+	lister.set("max-nodes-total: 20\nscale-down-utilization-threshold: 2", "2")
+	processor.Refresh(autoscalingContext, now.Add(time.Minute))
+	assert.Equal(t, 50, autoscalingContext.MaxNodesTotal)
+	assert.Equal(t, 0.5, autoscalingContext.ScaleDownUtilizationThreshold)
+	condition := processor.Condition()
+	assert.Equal(t, api.ClusterAutoscalerOptionsInvalid, condition.Status)
+	assert.Equal(t, "InvalidConfigMap", condition.Reason)
+	assert.Contains(t, condition.Message, "scale-down-utilization-threshold")
+	assertEvents(t, recorder, "Warning AutoscalingOptionsInvalid Wrong configuration for autoscaling options: "+
+		"invalid options: scale-down-utilization-threshold: must be between 0 and 1. Ignoring update.")
+
+	// Rejected only once.
+	processor.Refresh(autoscalingContext, now.Add(2*time.Minute))
+	assert.Equal(t, api.ClusterAutoscalerOptionsInvalid, processor.Condition().Status)
+	assertEvents(t, recorder)
+
+	// Expanders which can't be built are rejected too.
+	lister.set("expander: unknown", "3")
+	processor.Refresh(autoscalingContext, now.Add(3*time.Minute))
+	assert.Equal(t, "random", expanderName(autoscalingContext))
+	assert.Equal(t, api.ClusterAutoscalerOptionsInvalid, processor.Condition().Status)
+	assertEvents(t, recorder, "Warning AutoscalingOptionsInvalid Wrong configuration for autoscaling options: "+
+		"expander: expander unknown not supported. Ignoring update.")
+
+	// A valid version fixes it.
+	lister.set("max-nodes-total: 20", "4")
+	processor.Refresh(autoscalingContext, now.Add(4*time.Minute))
+	assert.Equal(t, 20, autoscalingContext.MaxNodesTotal)
+	assert.Equal(t, api.ClusterAutoscalerOptionsValid, processor.Condition().Status)
+	assertEvents(t, recorder, "Normal AutoscalingOptionsUpdated Autoscaling options updated: max-nodes-total: 50 -> 20")
+
+	// Deleting the config map restores the command line options.
+	lister.configMap = nil
+	processor.Refresh(autoscalingContext, now.Add(5*time.Minute))
+	assert.Equal(t, 100, autoscalingContext.MaxNodesTotal)
+	assert.Equal(t, api.ClusterAutoscalerOptionsValid, processor.Condition().Status)
+	assertEvents(t, recorder)
+}
+
+func TestRefreshStopsReplacedExpanders(t *testing.T) {
+	processor, lister, autoscalingContext, _, _ := setUpProcessor()
+	lister.set("expander: least-waste", "1")
+	processor.Refresh(autoscalingContext, now)
+	leastWaste := autoscalingContext.ExpanderStrategy
+	assert.Equal(t, "least-waste", expanderName(autoscalingContext))
+	assert.False(t, stopped(leastWaste))
+
+	// The strategy is reused when only other options change.
+	lister.set("expander: least-waste\nmax-nodes-total: 50", "2")
+	processor.Refresh(autoscalingContext, now.Add(time.Minute))
+	assert.Equal(t, leastWaste, autoscalingContext.ExpanderStrategy)
+	assert.False(t, stopped(leastWaste))
+
+	lister.set("expander: most-pods", "3")
+	processor.Refresh(autoscalingContext, now.Add(2*time.Minute))
+	mostPods := autoscalingContext.ExpanderStrategy
+	assert.Equal(t, "most-pods", expanderName(autoscalingContext))
+	assert.True(t, stopped(leastWaste))
+	assert.False(t, stopped(mostPods))
+
+	// Rejected versions don't stop the current strategy.
+	lister.set("expander: unknown", "4")
+	processor.Refresh(autoscalingContext, now.Add(3*time.Minute))
+	assert.Equal(t, mostPods, autoscalingContext.ExpanderStrategy)
+	assert.False(t, stopped(mostPods))
+
+	lister.configMap = nil
+	processor.Refresh(autoscalingContext, now.Add(4*time.Minute))
+	assert.Equal(t, "random", expanderName(autoscalingContext))
+	assert.True(t, stopped(mostPods))
+
+	lister.set("expander: least-waste", "5")
+	processor.Refresh(autoscalingContext, now.Add(5*time.Minute))
+	leastWaste = autoscalingContext.ExpanderStrategy
+	processor.CleanUp()
+	assert.True(t, stopped(leastWaste))
+}
+
+func TestResourceLimiter(t *testing.T) {
+	processor, lister, autoscalingContext, _, _ := setUpProcessor()
+	processor.Refresh(autoscalingContext, now)
+	resourceLimiter, err := autoscalingContext.CloudProvider.GetResourceLimiter()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(320), resourceLimiter.GetMax(cloudprovider.ResourceNameCores))
+
+	lister.set("cores-total: \"4:64\"", "1")
+	processor.Refresh(autoscalingContext, now)
+	resourceLimiter, err = autoscalingContext.CloudProvider.GetResourceLimiter()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), resourceLimiter.GetMin(cloudprovider.ResourceNameCores))
+	assert.Equal(t, int64(64), resourceLimiter.GetMax(cloudprovider.ResourceNameCores))
+	assert.Equal(t, int64(8), resourceLimiter.GetMax("nvidia.com/gpu"))
+	assert.False(t, resourceLimiter.HasMaxLimitSet(cloudprovider.ResourceNameMemory))
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicoptions
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/config/dynamic"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/units"
+
+	"gopkg.in/yaml.v2"
+)
+
+// optionsDocument is the options document stored in the ConfigMap. Keys are the names of the
+// corresponding command line flags. Options which are not set keep the values of the flags.
+type optionsDocument struct {
+	ScaleDownEnabled                 *bool    `yaml:"scale-down-enabled"`
+	ScaleDownDelayAfterAdd           *string  `yaml:"scale-down-delay-after-add"`
+	ScaleDownDelayAfterDelete        *string  `yaml:"scale-down-delay-after-delete"`
+	ScaleDownDelayAfterFailure       *string  `yaml:"scale-down-delay-after-failure"`
+	ScaleDownUnneededTime            *string  `yaml:"scale-down-unneeded-time"`
+	ScaleDownUnreadyTime             *string  `yaml:"scale-down-unready-time"`
+	ScaleDownUtilizationThreshold    *float64 `yaml:"scale-down-utilization-threshold"`
+	ScaleDownGpuUtilizationThreshold *float64 `yaml:"scale-down-gpu-utilization-threshold"`
+	ScaleDownNonEmptyCandidatesCount *int     `yaml:"scale-down-non-empty-candidates-count"`
+	MaxEmptyBulkDelete               *int     `yaml:"max-empty-bulk-delete"`
+	MaxDrainParallelism              *int     `yaml:"max-drain-parallelism"`
+	MaxGracefulTerminationSec        *int     `yaml:"max-graceful-termination-sec"`
+	MaxNodesTotal                    *int     `yaml:"max-nodes-total"`
+	CoresTotal                       *string  `yaml:"cores-total"`
+	MemoryTotal                      *string  `yaml:"memory-total"`
+	NewPodScaleUpDelay               *string  `yaml:"new-pod-scale-up-delay"`
+	BalanceSimilarNodeGroups         *bool    `yaml:"balance-similar-node-groups"`
+	Expander                         *string  `yaml:"expander"`
+	// Nodes overrides the min and max sizes of node groups, in the format <min>:<max>:<node group id>.
+	Nodes []string `yaml:"nodes"`
+}
+
+// dynamicOptions are the options resulting from an options document.
+type dynamicOptions struct {
+	// options are the command line options with the values set in the document.
+	options config.AutoscalingOptions
+	// coresTotalOverridden and memoryTotalOverridden tell if cores-total and memory-total are set in the document.
+	coresTotalOverridden  bool
+	memoryTotalOverridden bool
+	// nodeGroupSpecs are the overridden sizes of node groups, by node group id.
+	nodeGroupSpecs map[string]dynamic.NodeGroupSpec
+}
+
+// parseOptionsDocument validates the options document and applies it on top of the given command line options.
+// All invalid options are reported in the returned error.
+func parseOptionsDocument(document string, flagOptions config.AutoscalingOptions) (*dynamicOptions, error) {
+	var doc optionsDocument
+	if err := yaml.UnmarshalStrict([]byte(document), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse options document: %v", err)
+	}
+	result := &dynamicOptions{
+		options:        flagOptions,
+		nodeGroupSpecs: make(map[string]dynamic.NodeGroupSpec),
+	}
+	options := &result.options
+	var errs []string
+	check := func(name string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+
+	if doc.ScaleDownEnabled != nil {
+		options.ScaleDownEnabled = *doc.ScaleDownEnabled
+	}
+	check("scale-down-delay-after-add", setDuration(&options.ScaleDownDelayAfterAdd, doc.ScaleDownDelayAfterAdd))
+	check("scale-down-delay-after-delete", setDuration(&options.ScaleDownDelayAfterDelete, doc.ScaleDownDelayAfterDelete))
+	check("scale-down-delay-after-failure", setDuration(&options.ScaleDownDelayAfterFailure, doc.ScaleDownDelayAfterFailure))
+	check("scale-down-unneeded-time", setDuration(&options.ScaleDownUnneededTime, doc.ScaleDownUnneededTime))
+	check("scale-down-unready-time", setDuration(&options.ScaleDownUnreadyTime, doc.ScaleDownUnreadyTime))
+	check("scale-down-utilization-threshold", setRatio(&options.ScaleDownUtilizationThreshold, doc.ScaleDownUtilizationThreshold))
+	check("scale-down-gpu-utilization-threshold", setRatio(&options.ScaleDownGpuUtilizationThreshold, doc.ScaleDownGpuUtilizationThreshold))
+	check("scale-down-non-empty-candidates-count", setInt(&options.ScaleDownNonEmptyCandidatesCount, doc.ScaleDownNonEmptyCandidatesCount, 0))
+	check("max-empty-bulk-delete", setInt(&options.MaxEmptyBulkDelete, doc.MaxEmptyBulkDelete, 1))
+	check("max-drain-parallelism", setInt(&options.MaxDrainParallelism, doc.MaxDrainParallelism, 1))
+	check("max-graceful-termination-sec", setInt(&options.MaxGracefulTerminationSec, doc.MaxGracefulTerminationSec, 0))
+	check("max-nodes-total", setInt(&options.MaxNodesTotal, doc.MaxNodesTotal, 0))
+	check("new-pod-scale-up-delay", setDuration(&options.NewPodScaleUpDelay, doc.NewPodScaleUpDelay))
+	if doc.BalanceSimilarNodeGroups != nil {
+		options.BalanceSimilarNodeGroups = *doc.BalanceSimilarNodeGroups
+	}
+	if doc.Expander != nil {
+		if *doc.Expander == "" {
+			check("expander", fmt.Errorf("must not be empty"))
+		} else {
+			options.ExpanderName = *doc.Expander
+		}
+	}
+
+	if doc.CoresTotal != nil {
+		min, max, err := parseMinMax(*doc.CoresTotal)
+		check("cores-total", err)
+		options.MinCoresTotal, options.MaxCoresTotal = min, max
+		result.coresTotalOverridden = true
+	}
+	if doc.MemoryTotal != nil {
+		min, max, err := parseMinMax(*doc.MemoryTotal)
+		check("memory-total", err)
+		options.MinMemoryTotal, options.MaxMemoryTotal = min*units.GiB, max*units.GiB
+		result.memoryTotalOverridden = true
+	}
+
+	for _, value := range doc.Nodes {
+		spec, err := dynamic.SpecFromString(value, true)
+		if err != nil {
+			check("nodes", err)
+			continue
+		}
+		if _, found := result.nodeGroupSpecs[spec.Name]; found {
+			check("nodes", fmt.Errorf("node group %s is listed more than once", spec.Name))
+			continue
+		}
+		result.nodeGroupSpecs[spec.Name] = *spec
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid options: %s", strings.Join(errs, "; "))
+	}
+	return result, nil
+}
+
+func setDuration(target *time.Duration, value *string) error {
+	if value == nil {
+		return nil
+	}
+	duration, err := time.ParseDuration(*value)
+	if err != nil {
+		return err
+	}
+	if duration < 0 {
+		return fmt.Errorf("must not be negative")
+	}
+	*target = duration
+	return nil
+}
+
+func setRatio(target *float64, value *float64) error {
+	if value == nil {
+		return nil
+	}
+	if *value < 0 || *value > 1 {
+		return fmt.Errorf("must be between 0 and 1")
+	}
+	*target = *value
+	return nil
+}
+
+func setInt(target *int, value *int, min int) error {
+	if value == nil {
+		return nil
+	}
+	if *value < min {
+		return fmt.Errorf("must be at least %d", min)
+	}
+	*target = *value
+	return nil
+}
+
+// parseMinMax parses a <min>:<max> range, as in the cores-total and memory-total flags.
+func parseMinMax(value string) (int64, int64, error) {
+	tokens := strings.SplitN(value, ":", 2)
+	if len(tokens) != 2 {
+		return 0, 0, fmt.Errorf("expected <min>:<max>, got %q", value)
+	}
+	min, err := strconv.ParseInt(tokens[0], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse min %q: %v", tokens[0], err)
+	}
+	max, err := strconv.ParseInt(tokens[1], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse max %q: %v", tokens[1], err)
+	}
+	if min < 0 || max < min {
+		return 0, 0, fmt.Errorf("min must be non-negative and not greater than max")
+	}
+	return min, max, nil
+}
+
+// describe returns the reloadable options, formatted for comparing and reporting changes.
+func (o *dynamicOptions) describe() map[string]string {
+	options := o.options
+	result := map[string]string{
+		"scale-down-enabled":                    strconv.FormatBool(options.ScaleDownEnabled),
+		"scale-down-delay-after-add":            options.ScaleDownDelayAfterAdd.String(),
+		"scale-down-delay-after-delete":         options.ScaleDownDelayAfterDelete.String(),
+		"scale-down-delay-after-failure":        options.ScaleDownDelayAfterFailure.String(),
+		"scale-down-unneeded-time":              options.ScaleDownUnneededTime.String(),
+		"scale-down-unready-time":               options.ScaleDownUnreadyTime.String(),
+		"scale-down-utilization-threshold":      strconv.FormatFloat(options.ScaleDownUtilizationThreshold, 'g', -1, 64),
+		"scale-down-gpu-utilization-threshold":  strconv.FormatFloat(options.ScaleDownGpuUtilizationThreshold, 'g', -1, 64),
+		"scale-down-non-empty-candidates-count": strconv.Itoa(options.ScaleDownNonEmptyCandidatesCount),
+		"max-empty-bulk-delete":                 strconv.Itoa(options.MaxEmptyBulkDelete),
+		"max-drain-parallelism":                 strconv.Itoa(options.MaxDrainParallelism),
+		"max-graceful-termination-sec":          strconv.Itoa(options.MaxGracefulTerminationSec),
+		"max-nodes-total":                       strconv.Itoa(options.MaxNodesTotal),
+		"cores-total":                           fmt.Sprintf("%d:%d", options.MinCoresTotal, options.MaxCoresTotal),
+		"memory-total":                          fmt.Sprintf("%d:%d", options.MinMemoryTotal/units.GiB, options.MaxMemoryTotal/units.GiB),
+		"new-pod-scale-up-delay":                options.NewPodScaleUpDelay.String(),
+		"balance-similar-node-groups":           strconv.FormatBool(options.BalanceSimilarNodeGroups),
+		"expander":                              options.ExpanderName,
+	}
+	for id, spec := range o.nodeGroupSpecs {
+		result["nodes/"+id] = spec.String()
+	}
+	return result
+}
+
+// diffOptions returns the changes between two sets of options as "<option>: <old> -> <new>", sorted by option.
+func diffOptions(oldOptions, newOptions *dynamicOptions) []string {
+	oldValues := oldOptions.describe()
+	newValues := newOptions.describe()
+	var result []string
+	for name, newValue := range newValues {
+		if oldValue, found := oldValues[name]; !found {
+			result = append(result, fmt.Sprintf("%s: none -> %s", name, newValue))
+		} else if oldValue != newValue {
+			result = append(result, fmt.Sprintf("%s: %s -> %s", name, oldValue, newValue))
+		}
+	}
+	for name, oldValue := range oldValues {
+		if _, found := newValues[name]; !found {
+			result = append(result, fmt.Sprintf("%s: %s -> none", name, oldValue))
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicoptions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/config/dynamic"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/units"
+)
+
+var flagOptions = config.AutoscalingOptions{
+	ScaleDownEnabled:              true,
+	ScaleDownUnneededTime:         10 * time.Minute,
+	ScaleDownUtilizationThreshold: 0.5,
+	MaxEmptyBulkDelete:            10,
+	MaxNodesTotal:                 100,
+	MaxCoresTotal:                 320,
+	MaxMemoryTotal:                640 * units.GiB,
+	ExpanderName:                  "random",
+	ConfigNamespace:               "kube-system",
+}
+
+func TestParseOptionsDocument(t *testing.T) {
+	options, err := parseOptionsDocument(`
+scale-down-unneeded-time: 5m
+scale-down-utilization-threshold: 0.7
+max-nodes-total: 50
+memory-total: "16:128"
+expander: least-waste
+nodes:
+  - 0:5:ng1
+  - 2:20:ng2
+`, flagOptions)
+	assert.NoError(t, err)
+
+	expected := flagOptions
+	expected.ScaleDownUnneededTime = 5 * time.Minute
+	expected.ScaleDownUtilizationThreshold = 0.7
+	expected.MaxNodesTotal = 50
+	expected.MinMemoryTotal = 16 * units.GiB
+	expected.MaxMemoryTotal = 128 * units.GiB
+	expected.ExpanderName = "least-waste"
+	assert.Equal(t, expected, options.options)
+	assert.False(t, options.coresTotalOverridden)
+	assert.True(t, options.memoryTotalOverridden)
+	assert.Equal(t, map[string]dynamic.NodeGroupSpec{
+		"ng1": {Name: "ng1", MinSize: 0, MaxSize: 5, SupportScaleToZero: true},
+		"ng2": {Name: "ng2", MinSize: 2, MaxSize: 20, SupportScaleToZero: true},
+	}, options.nodeGroupSpecs)
+
+	// An empty document doesn't change anything.
+	options, err = parseOptionsDocument("", flagOptions)
+	assert.NoError(t, err)
+	assert.Equal(t, flagOptions, options.options)
+	assert.Empty(t, options.nodeGroupSpecs)
+}
+
+func TestParseInvalidOptionsDocument(t *testing.T) {
+	for _, document := range []string{
+		"scale-down-unneeded-time: 5 minutes",
+		"scale-down-unready-time: -5m",
+		"scale-down-utilization-threshold: 1.5",
+		"max-empty-bulk-delete: 0",
+		"max-nodes-total: -1",
+		"cores-total: 10",
+		"memory-total: \"10:5\"",
+		"expander: \"\"",
+		"nodes: [\"5:1:ng1\"]",
+		"nodes: [\"1:5:ng1\", \"2:6:ng1\"]",
+		"unknown-option: true",
+		"max-nodes-total: many",
+	} {
+		_, err := parseOptionsDocument(document, flagOptions)
+		assert.Error(t, err, document)
+	}
+
+	// All errors are reported.
+	_, err := parseOptionsDocument("max-empty-bulk-delete: 0\nscale-down-utilization-threshold: 2", flagOptions)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "max-empty-bulk-delete")
+	assert.Contains(t, err.Error(), "scale-down-utilization-threshold")
+}
+
+func TestDiffOptions(t *testing.T) {
+	oldOptions, err := parseOptionsDocument("max-nodes-total: 50\nnodes: [\"1:5:ng1\"]", flagOptions)
+	assert.NoError(t, err)
+	newOptions, err := parseOptionsDocument("scale-down-unneeded-time: 5m\nnodes: [\"1:10:ng1\", \"0:3:ng2\"]", flagOptions)
+	assert.NoError(t, err)
+
+	assert.Empty(t, diffOptions(oldOptions, oldOptions))
+	assert.Equal(t, []string{
+		"max-nodes-total: 50 -> 100",
+		"nodes/ng1: 1:5:ng1 -> 1:10:ng1",
+		"nodes/ng2: none -> 0:3:ng2",
+		"scale-down-unneeded-time: 10m0s -> 5m0s",
+	}, diffOptions(oldOptions, newOptions))
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodegroupset
+
+import (
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+// awsIgnoredLabels are labels which differ between similar ASGs, e.g. ones added by eksctl,
+// EKS managed node groups or the EBS CSI driver.
+var awsIgnoredLabels = []string{
+	"alpha.eksctl.io/instance-id",
+	"alpha.eksctl.io/nodegroup-name",
+	"eks.amazonaws.com/nodegroup",
+	"k8s.amazonaws.com/eniConfig",
+	"lifecycle",
+	"topology.ebs.csi.aws.com/zone",
+}
+
+// CreateAwsNodeInfoComparator returns a NodeInfoComparator for ASGs. On top of BasicIgnoredLabels and
+// the given extra labels, it ignores labels which differ between similar ASGs.
+func CreateAwsNodeInfoComparator(extraIgnoredLabels []string, ratios config.NodeGroupDifferenceRatios) NodeInfoComparator {
+	ignoredLabels := buildIgnoredLabels(awsIgnoredLabels, extraIgnoredLabels)
+	return func(n1, n2 *schedulernodeinfo.NodeInfo) bool {
+		return IsCloudProviderNodeInfoSimilar(n1, n2, ignoredLabels, ratios)
+	}
+}
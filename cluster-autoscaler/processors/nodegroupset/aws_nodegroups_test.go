@@ -0,0 +1,49 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodegroupset
+
+import (
+	"testing"
+
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+func TestIsAwsNodeInfoSimilar(t *testing.T) {
+	comparator := CreateAwsNodeInfoComparator([]string{}, NewDefaultNodeGroupDifferenceRatios())
+	for _, label := range awsIgnoredLabels {
+		n1 := BuildTestNode("node1", 1000, 2000)
+		n2 := BuildTestNode("node2", 1000, 2000)
+		checkNodesSimilar(t, n1, n2, comparator, true)
+
+		// AWS specific labels can differ.
+		n1.ObjectMeta.Labels[label] = "value1"
+		n2.ObjectMeta.Labels[label] = "value2"
+		checkNodesSimilar(t, n1, n2, comparator, true)
+		checkNodesSimilar(t, n1, n2, IsNodeInfoSimilar, false)
+
+		// Or be missing on one of the nodes.
+		delete(n2.ObjectMeta.Labels, label)
+		checkNodesSimilar(t, n1, n2, comparator, true)
+	}
+
+	n1 := BuildTestNode("node1", 1000, 2000)
+	n2 := BuildTestNode("node2", 1000, 2000)
+	n1.ObjectMeta.Labels["example.com/team"] = "ml"
+	n2.ObjectMeta.Labels["example.com/team"] = "web"
+	checkNodesSimilar(t, n1, n2, comparator, false)
+	checkNodesSimilar(t, n1, n2, CreateAwsNodeInfoComparator([]string{"example.com/team"}, NewDefaultNodeGroupDifferenceRatios()), true)
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodegroupset
+
+import (
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+const (
+	// AzureNodepoolLabel is the label identifying the agent pool of AKS nodes.
+	AzureNodepoolLabel = "agentpool"
+	// AzureLegacyNodepoolLabel is the label identifying the agent pool of nodes created by acs-engine.
+	AzureLegacyNodepoolLabel = "poolName"
+	// AzureDiskTopologyKey is the topology label of the Azure Disk CSI driver.
+	AzureDiskTopologyKey = "topology.disk.csi.azure.com/zone"
+)
+
+// nodesFromSameAzureNodePool returns true if both nodes belong to the same, named, agent pool.
+func nodesFromSameAzureNodePool(n1, n2 *schedulernodeinfo.NodeInfo) bool {
+	for _, label := range []string{AzureNodepoolLabel, AzureLegacyNodepoolLabel} {
+		pool1 := n1.Node().Labels[label]
+		pool2 := n2.Node().Labels[label]
+		if pool1 != "" && pool1 == pool2 {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAzureNodeInfoComparator returns a NodeInfoComparator for Azure scale sets. Scale sets of the same
+// agent pool are always similar. Otherwise, on top of BasicIgnoredLabels and the given extra labels,
+// the agent pool labels and Azure specific topology labels are ignored.
+func CreateAzureNodeInfoComparator(extraIgnoredLabels []string, ratios config.NodeGroupDifferenceRatios) NodeInfoComparator {
+	ignoredLabels := buildIgnoredLabels([]string{AzureNodepoolLabel, AzureLegacyNodepoolLabel, AzureDiskTopologyKey}, extraIgnoredLabels)
+	return func(n1, n2 *schedulernodeinfo.NodeInfo) bool {
+		if nodesFromSameAzureNodePool(n1, n2) {
+			return true
+		}
+		return IsCloudProviderNodeInfoSimilar(n1, n2, ignoredLabels, ratios)
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodegroupset
+
+import (
+	"testing"
+
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+func TestIsAzureNodeInfoSimilar(t *testing.T) {
+	comparator := CreateAzureNodeInfoComparator([]string{"example.com/ready"}, NewDefaultNodeGroupDifferenceRatios())
+	n1 := BuildTestNode("node1", 1000, 2000)
+	n2 := BuildTestNode("node2", 1000, 2000)
+	checkNodesSimilar(t, n1, n2, comparator, true)
+
+	// Agent pool and Azure specific topology labels can differ.
+	n1.ObjectMeta.Labels[AzureNodepoolLabel] = "pool1"
+	n2.ObjectMeta.Labels[AzureNodepoolLabel] = "pool2"
+	n1.ObjectMeta.Labels[AzureDiskTopologyKey] = "westus-1"
+	n2.ObjectMeta.Labels[AzureDiskTopologyKey] = "westus-2"
+	checkNodesSimilar(t, n1, n2, comparator, true)
+	checkNodesSimilar(t, n1, n2, IsNodeInfoSimilar, false)
+
+	// So can the extra ignored labels.
+	n1.ObjectMeta.Labels["example.com/ready"] = "true"
+	checkNodesSimilar(t, n1, n2, comparator, true)
+
+	// But not other labels.
+	n1.ObjectMeta.Labels["example.com/team"] = "ml"
+	checkNodesSimilar(t, n1, n2, comparator, false)
+}
+
+func TestIsAzureNodeInfoSimilarSamePool(t *testing.T) {
+	comparator := CreateAzureNodeInfoComparator([]string{}, NewDefaultNodeGroupDifferenceRatios())
+	for _, label := range []string{AzureNodepoolLabel, AzureLegacyNodepoolLabel} {
+		n1 := BuildTestNode("node1", 1000, 2000)
+		n2 := BuildTestNode("node2", 2000, 4000)
+		checkNodesSimilar(t, n1, n2, comparator, false)
+
+		// Scale sets of the same agent pool are always similar.
+		n1.ObjectMeta.Labels[label] = "pool1"
+		n2.ObjectMeta.Labels[label] = "pool1"
+		checkNodesSimilar(t, n1, n2, comparator, true)
+
+		n2.ObjectMeta.Labels[label] = "pool2"
+		checkNodesSimilar(t, n1, n2, comparator, false)
+	}
+}
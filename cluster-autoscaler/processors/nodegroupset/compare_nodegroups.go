@@ -21,22 +21,83 @@ import (
 
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/scheduledcapacity"
 	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
 )
 
 const (
 	// MaxAllocatableDifferenceRatio describes how Node.Status.Allocatable can differ between
-	// groups in the same NodeGroupSet
+	// groups in the same NodeGroupSet by default.
 	MaxAllocatableDifferenceRatio = 0.05
 	// MaxFreeDifferenceRatio describes how free resources (allocatable - daemon and system pods)
-	// can differ between groups in the same NodeGroupSet
+	// can differ between groups in the same NodeGroupSet by default.
 	MaxFreeDifferenceRatio = 0.05
 )
 
+// BasicIgnoredLabels define a set of basic labels that should be ignored when comparing the similarity
+// of two nodes. Labels in the set are expected to differ between similar node groups.
+var BasicIgnoredLabels = map[string]bool{
+	apiv1.LabelHostname:                   true,
+	apiv1.LabelZoneFailureDomain:          true,
+	apiv1.LabelZoneRegion:                 true,
+	"beta.kubernetes.io/fluentd-ds-ready": true, // this is internal label used for determining if fluentd should be installed as deamon set. Used for migration 1.8 to 1.9.
+	scheduledcapacity.NodeGroupLabel:      true, // set only on template nodes during scale-up, to the node group id.
+}
+
 // NodeInfoComparator is a function that tells if two nodes are from NodeGroups
 // similar enough to be considered a part of a single NodeGroupSet.
 type NodeInfoComparator func(n1, n2 *schedulernodeinfo.NodeInfo) bool
 
+// NewDefaultNodeGroupDifferenceRatios returns the default tolerances of differences between similar node groups.
+func NewDefaultNodeGroupDifferenceRatios() config.NodeGroupDifferenceRatios {
+	return config.NodeGroupDifferenceRatios{
+		MaxAllocatableDifferenceRatio: MaxAllocatableDifferenceRatio,
+		MaxFreeDifferenceRatio:        MaxFreeDifferenceRatio,
+	}
+}
+
+// CreateNodeInfoComparator returns the NodeInfoComparator used for node groups of the given cloud provider.
+// Node groups are similar if they differ only by the given extra labels, labels ignored for the cloud
+// provider and resources within the given ratios.
+func CreateNodeInfoComparator(cloudProviderName string, extraIgnoredLabels []string, ratios config.NodeGroupDifferenceRatios) NodeInfoComparator {
+	// Names of cloud providers are not imported from their packages, so that
+	// processors don't depend on cloud provider implementations.
+	switch cloudProviderName {
+	case "aws":
+		return CreateAwsNodeInfoComparator(extraIgnoredLabels, ratios)
+	case "azure":
+		return CreateAzureNodeInfoComparator(extraIgnoredLabels, ratios)
+	case "gce":
+		return CreateGceNodeInfoComparator(extraIgnoredLabels, ratios)
+	}
+	return CreateGenericNodeInfoComparator(extraIgnoredLabels, ratios)
+}
+
+// CreateGenericNodeInfoComparator returns a NodeInfoComparator ignoring BasicIgnoredLabels and the given
+// extra labels, which allows resources to differ within the given ratios.
+func CreateGenericNodeInfoComparator(extraIgnoredLabels []string, ratios config.NodeGroupDifferenceRatios) NodeInfoComparator {
+	ignoredLabels := buildIgnoredLabels(nil, extraIgnoredLabels)
+	return func(n1, n2 *schedulernodeinfo.NodeInfo) bool {
+		return IsCloudProviderNodeInfoSimilar(n1, n2, ignoredLabels, ratios)
+	}
+}
+
+// buildIgnoredLabels returns a set of BasicIgnoredLabels, cloud provider specific labels and extra labels.
+func buildIgnoredLabels(cloudProviderLabels []string, extraIgnoredLabels []string) map[string]bool {
+	ignoredLabels := make(map[string]bool)
+	for label := range BasicIgnoredLabels {
+		ignoredLabels[label] = true
+	}
+	for _, label := range cloudProviderLabels {
+		ignoredLabels[label] = true
+	}
+	for _, label := range extraIgnoredLabels {
+		ignoredLabels[label] = true
+	}
+	return ignoredLabels
+}
+
 func compareResourceMapsWithTolerance(resources map[apiv1.ResourceName][]resource.Quantity,
 	maxDifferenceRatio float64) bool {
 	for _, qtyList := range resources {
@@ -58,6 +119,14 @@ func compareResourceMapsWithTolerance(resources map[apiv1.ResourceName][]resourc
 // are similar enough to likely be the same type of machine and if the set of labels
 // is the same (except for a pre-defined set of labels like hostname or zone).
 func IsNodeInfoSimilar(n1, n2 *schedulernodeinfo.NodeInfo) bool {
+	return IsCloudProviderNodeInfoSimilar(n1, n2, BasicIgnoredLabels, NewDefaultNodeGroupDifferenceRatios())
+}
+
+// IsCloudProviderNodeInfoSimilar returns true if two NodeInfos are similar enough to consider
+// that the NodeGroups they come from are part of the same NodeGroupSet. Values of the ignored
+// labels can differ and resources can differ within the given ratios.
+func IsCloudProviderNodeInfoSimilar(n1, n2 *schedulernodeinfo.NodeInfo, ignoredLabels map[string]bool,
+	ratios config.NodeGroupDifferenceRatios) bool {
 	capacity := make(map[apiv1.ResourceName][]resource.Quantity)
 	allocatable := make(map[apiv1.ResourceName][]resource.Quantity)
 	free := make(map[apiv1.ResourceName][]resource.Quantity)
@@ -85,20 +154,13 @@ func IsNodeInfoSimilar(n1, n2 *schedulernodeinfo.NodeInfo) bool {
 		}
 	}
 	// For allocatable and free we allow resource quantities to be within a few % of each other
-	if !compareResourceMapsWithTolerance(allocatable, MaxAllocatableDifferenceRatio) {
+	if !compareResourceMapsWithTolerance(allocatable, ratios.MaxAllocatableDifferenceRatio) {
 		return false
 	}
-	if !compareResourceMapsWithTolerance(free, MaxFreeDifferenceRatio) {
+	if !compareResourceMapsWithTolerance(free, ratios.MaxFreeDifferenceRatio) {
 		return false
 	}
 
-	ignoredLabels := map[string]bool{
-		apiv1.LabelHostname:                   true,
-		apiv1.LabelZoneFailureDomain:          true,
-		apiv1.LabelZoneRegion:                 true,
-		"beta.kubernetes.io/fluentd-ds-ready": true, // this is internal label used for determining if fluentd should be installed as deamon set. Used for migration 1.8 to 1.9.
-	}
-
 	labels := make(map[string][]string)
 	for _, node := range nodes {
 		for label, value := range node.Node().ObjectMeta.Labels {
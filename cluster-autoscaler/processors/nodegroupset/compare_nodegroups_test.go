@@ -21,6 +21,7 @@ import (
 
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
 	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
 	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
 	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
@@ -129,3 +130,61 @@ func TestNodesSimilarVariousLabels(t *testing.T) {
 	delete(n2.ObjectMeta.Labels, "beta.kubernetes.io/fluentd-ds-ready")
 	checkNodesSimilar(t, n1, n2, IsNodeInfoSimilar, true)
 }
+
+func TestNodesSimilarWithExtraIgnoredLabels(t *testing.T) {
+	comparator := CreateGenericNodeInfoComparator([]string{"example.com/ready", "example.com/zone"}, NewDefaultNodeGroupDifferenceRatios())
+	n1 := BuildTestNode("node1", 1000, 2000)
+	n2 := BuildTestNode("node2", 1000, 2000)
+	checkNodesSimilar(t, n1, n2, comparator, true)
+
+	// Extra ignored labels can differ or be missing.
+	n1.ObjectMeta.Labels["example.com/zone"] = "zone-a"
+	n2.ObjectMeta.Labels["example.com/zone"] = "zone-b"
+	n1.ObjectMeta.Labels["example.com/ready"] = "true"
+	checkNodesSimilar(t, n1, n2, comparator, true)
+	checkNodesSimilar(t, n1, n2, IsNodeInfoSimilar, false)
+
+	// Basic ignored labels are still ignored.
+	n1.ObjectMeta.Labels[apiv1.LabelZoneFailureDomain] = "us-east-1a"
+	n2.ObjectMeta.Labels[apiv1.LabelZoneFailureDomain] = "us-east-1b"
+	checkNodesSimilar(t, n1, n2, comparator, true)
+
+	// Other labels can't differ.
+	n1.ObjectMeta.Labels["example.com/team"] = "ml"
+	checkNodesSimilar(t, n1, n2, comparator, false)
+}
+
+func TestNodesSimilarWithDifferenceRatios(t *testing.T) {
+	n1 := BuildTestNode("node1", 1000, 2000)
+	n2 := BuildTestNode("node2", 1000, 2000)
+	n2.Status.Allocatable[apiv1.ResourceCPU] = *resource.NewMilliQuantity(900, resource.DecimalSI)
+
+	// 10% difference of allocatable and free resources is over the default tolerance.
+	checkNodesSimilar(t, n1, n2, CreateGenericNodeInfoComparator(nil, NewDefaultNodeGroupDifferenceRatios()), false)
+
+	ratios := config.NodeGroupDifferenceRatios{MaxAllocatableDifferenceRatio: 0.1, MaxFreeDifferenceRatio: 0.05}
+	checkNodesSimilar(t, n1, n2, CreateGenericNodeInfoComparator(nil, ratios), false)
+
+	ratios.MaxFreeDifferenceRatio = 0.1
+	checkNodesSimilar(t, n1, n2, CreateGenericNodeInfoComparator(nil, ratios), true)
+}
+
+func TestCreateNodeInfoComparator(t *testing.T) {
+	n1 := BuildTestNode("node1", 1000, 2000)
+	n2 := BuildTestNode("node2", 1000, 2000)
+	n1.ObjectMeta.Labels["lifecycle"] = "Ec2Spot"
+	n2.ObjectMeta.Labels["lifecycle"] = "OnDemand"
+	n1.ObjectMeta.Labels["example.com/zone"] = "zone-a"
+	n2.ObjectMeta.Labels["example.com/zone"] = "zone-b"
+
+	extraIgnoredLabels := []string{"example.com/zone"}
+	ratios := NewDefaultNodeGroupDifferenceRatios()
+	checkNodesSimilar(t, n1, n2, CreateNodeInfoComparator("aws", extraIgnoredLabels, ratios), true)
+	checkNodesSimilar(t, n1, n2, CreateNodeInfoComparator("gce", extraIgnoredLabels, ratios), false)
+	checkNodesSimilar(t, n1, n2, CreateNodeInfoComparator("azure", extraIgnoredLabels, ratios), false)
+	checkNodesSimilar(t, n1, n2, CreateNodeInfoComparator("unknown", extraIgnoredLabels, ratios), false)
+
+	delete(n1.ObjectMeta.Labels, "lifecycle")
+	delete(n2.ObjectMeta.Labels, "lifecycle")
+	checkNodesSimilar(t, n1, n2, CreateNodeInfoComparator("unknown", extraIgnoredLabels, ratios), true)
+}
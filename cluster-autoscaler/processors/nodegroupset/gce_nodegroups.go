@@ -0,0 +1,37 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodegroupset
+
+import (
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+// gceIgnoredLabels are labels which differ between similar MIGs, e.g. zonal topology labels
+// of the GCE PD CSI driver.
+var gceIgnoredLabels = []string{
+	"topology.gke.io/zone",
+}
+
+// CreateGceNodeInfoComparator returns a NodeInfoComparator for MIGs. On top of BasicIgnoredLabels and
+// the given extra labels, it ignores labels which differ between similar MIGs.
+func CreateGceNodeInfoComparator(extraIgnoredLabels []string, ratios config.NodeGroupDifferenceRatios) NodeInfoComparator {
+	ignoredLabels := buildIgnoredLabels(gceIgnoredLabels, extraIgnoredLabels)
+	return func(n1, n2 *schedulernodeinfo.NodeInfo) bool {
+		return IsCloudProviderNodeInfoSimilar(n1, n2, ignoredLabels, ratios)
+	}
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodegroupset
+
+import (
+	"testing"
+
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+)
+
+func TestIsGceNodeInfoSimilar(t *testing.T) {
+	comparator := CreateGceNodeInfoComparator([]string{}, NewDefaultNodeGroupDifferenceRatios())
+	n1 := BuildTestNode("node1", 1000, 2000)
+	n2 := BuildTestNode("node2", 1000, 2000)
+	checkNodesSimilar(t, n1, n2, comparator, true)
+
+	// Zonal topology label of the PD CSI driver can differ.
+	n1.ObjectMeta.Labels["topology.gke.io/zone"] = "us-central1-a"
+	n2.ObjectMeta.Labels["topology.gke.io/zone"] = "us-central1-b"
+	checkNodesSimilar(t, n1, n2, comparator, true)
+	checkNodesSimilar(t, n1, n2, IsNodeInfoSimilar, false)
+
+	// Node pools can't.
+	n1.ObjectMeta.Labels["cloud.google.com/gke-nodepool"] = "pool-1"
+	n2.ObjectMeta.Labels["cloud.google.com/gke-nodepool"] = "pool-2"
+	checkNodesSimilar(t, n1, n2, comparator, false)
+	checkNodesSimilar(t, n1, n2, CreateGceNodeInfoComparator([]string{"cloud.google.com/gke-nodepool"}, NewDefaultNodeGroupDifferenceRatios()), true)
+}
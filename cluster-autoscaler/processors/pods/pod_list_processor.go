@@ -22,6 +22,8 @@ import (
 )
 
 // PodListProcessor processes lists of unschedulable and scheduled pods before scaling of the cluster.
+// Processors adding pods to the list of scheduled pods should add them to context.ClusterSnapshot too,
+// so that they are taken into account by scale-up and scale-down simulations.
 type PodListProcessor interface {
 	Process(context *context.AutoscalingContext,
 		unschedulablePods []*apiv1.Pod, allScheduledPods []*apiv1.Pod,
@@ -17,9 +17,11 @@ limitations under the License.
 package processors
 
 import (
+	"k8s.io/autoscaler/cluster-autoscaler/processors/dynamicoptions"
 	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroups"
 	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroupset"
 	"k8s.io/autoscaler/cluster-autoscaler/processors/pods"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/scheduledcapacity"
 	"k8s.io/autoscaler/cluster-autoscaler/processors/status"
 )
 
@@ -40,6 +42,12 @@ type AutoscalingProcessors struct {
 	AutoscalingStatusProcessor status.AutoscalingStatusProcessor
 	// NodeGroupManager is responsible for creating/deleting node groups.
 	NodeGroupManager nodegroups.NodeGroupManager
+	// ScheduledCapacityProcessor raises minimum sizes of node groups on a schedule. It's nil if
+	// scheduled capacity is disabled. Otherwise it's also the NodeGroupListProcessor.
+	ScheduledCapacityProcessor *scheduledcapacity.ScheduledCapacityProcessor
+	// DynamicOptionsProcessor overrides autoscaling options with the ones defined in a ConfigMap. It's nil
+	// if dynamic options are disabled.
+	DynamicOptionsProcessor *dynamicoptions.DynamicOptionsProcessor
 }
 
 // DefaultProcessors returns default set of processors.
@@ -64,4 +72,7 @@ func (ap *AutoscalingProcessors) CleanUp() {
 	ap.ScaleDownStatusProcessor.CleanUp()
 	ap.AutoscalingStatusProcessor.CleanUp()
 	ap.NodeGroupManager.CleanUp()
+	if ap.DynamicOptionsProcessor != nil {
+		ap.DynamicOptionsProcessor.CleanUp()
+	}
 }
@@ -0,0 +1,80 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduledcapacity
+
+import (
+	"reflect"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+)
+
+// CloudProvider wraps a cloud provider so that minimum sizes of its node groups are raised
+// by the active schedules of a ScheduledCapacityProcessor.
+type CloudProvider struct {
+	cloudprovider.CloudProvider
+	processor *ScheduledCapacityProcessor
+}
+
+// NewCloudProvider wraps the given cloud provider with minimum sizes of the processor.
+func NewCloudProvider(cloudProvider cloudprovider.CloudProvider, processor *ScheduledCapacityProcessor) *CloudProvider {
+	return &CloudProvider{CloudProvider: cloudProvider, processor: processor}
+}
+
+// NodeGroups returns all node groups of the wrapped cloud provider.
+func (provider *CloudProvider) NodeGroups() []cloudprovider.NodeGroup {
+	nodeGroups := provider.CloudProvider.NodeGroups()
+	result := make([]cloudprovider.NodeGroup, 0, len(nodeGroups))
+	for _, nodeGroup := range nodeGroups {
+		result = append(result, provider.wrap(nodeGroup))
+	}
+	return result
+}
+
+// NodeGroupForNode returns the node group of the given node in the wrapped cloud provider.
+func (provider *CloudProvider) NodeGroupForNode(node *apiv1.Node) (cloudprovider.NodeGroup, error) {
+	nodeGroup, err := provider.CloudProvider.NodeGroupForNode(node)
+	if err != nil || nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
+		return nil, err
+	}
+	return provider.wrap(nodeGroup), nil
+}
+
+// NewNodeGroup builds a theoretical node group in the wrapped cloud provider.
+func (provider *CloudProvider) NewNodeGroup(machineType string, labels map[string]string, systemLabels map[string]string,
+	taints []apiv1.Taint, extraResources map[string]resource.Quantity) (cloudprovider.NodeGroup, error) {
+	nodeGroup, err := provider.CloudProvider.NewNodeGroup(machineType, labels, systemLabels, taints, extraResources)
+	if err != nil {
+		return nil, err
+	}
+	return provider.wrap(nodeGroup), nil
+}
+
+func (provider *CloudProvider) wrap(nodeGroup cloudprovider.NodeGroup) cloudprovider.NodeGroup {
+	return &nodeGroupWithScheduledMinSize{NodeGroup: nodeGroup, processor: provider.processor}
+}
+
+type nodeGroupWithScheduledMinSize struct {
+	cloudprovider.NodeGroup
+	processor *ScheduledCapacityProcessor
+}
+
+// MinSize returns the minimum size of the node group, raised by the active schedules.
+func (nodeGroup *nodeGroupWithScheduledMinSize) MinSize() int {
+	return nodeGroup.processor.MinSize(nodeGroup.NodeGroup)
+}
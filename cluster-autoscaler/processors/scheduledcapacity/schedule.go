@@ -0,0 +1,227 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduledcapacity
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ScheduleConfig is the user-facing definition of a schedule, as stored in the ConfigMap.
+type ScheduleConfig struct {
+	// Name identifies the schedule in logs and events.
+	Name string `yaml:"name"`
+	// NodeGroups are regular expressions matched against node group ids.
+	NodeGroups []string `yaml:"nodeGroups"`
+	// Cron is a cron expression (minute hour day-of-month month day-of-week). The schedule
+	// is active in every minute matched by it.
+	Cron string `yaml:"cron"`
+	// TimeZone is the IANA time zone in which Cron is evaluated, UTC by default.
+	TimeZone string `yaml:"timeZone"`
+	// MinSize is the minimum size of matching node groups while the schedule is active. Smaller
+	// node groups are scaled up to it.
+	MinSize int `yaml:"minSize"`
+}
+
+type schedule struct {
+	name       string
+	nodeGroups []*regexp.Regexp
+	cron       *cronExpression
+	location   *time.Location
+	minSize    int
+}
+
+func (s *schedule) isActive(currentTime time.Time) bool {
+	return s.cron.matches(currentTime.In(s.location))
+}
+
+func (s *schedule) matchesNodeGroup(id string) bool {
+	for _, re := range s.nodeGroups {
+		if re.FindStringIndex(id) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func parseSchedulesYAMLString(schedulesYAML string) ([]*schedule, error) {
+	if schedulesYAML == "" {
+		return nil, fmt.Errorf("scheduled capacity configuration in %s configmap is empty; please provide valid configuration",
+			ScheduledCapacityConfigMapName)
+	}
+	var configs []ScheduleConfig
+	if err := yaml.Unmarshal([]byte(schedulesYAML), &configs); err != nil {
+		return nil, fmt.Errorf("can't parse YAML with schedules in the configmap: %v", err)
+	}
+
+	names := make(map[string]bool)
+	result := make([]*schedule, 0, len(configs))
+	for _, config := range configs {
+		if config.Name == "" {
+			return nil, fmt.Errorf("schedule without a name")
+		}
+		if names[config.Name] {
+			return nil, fmt.Errorf("schedule %s defined more than once", config.Name)
+		}
+		names[config.Name] = true
+		s, err := parseSchedule(config)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %s: %v", config.Name, err)
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+func parseSchedule(config ScheduleConfig) (*schedule, error) {
+	if len(config.NodeGroups) == 0 {
+		return nil, fmt.Errorf("no node groups")
+	}
+	if config.MinSize < 0 {
+		return nil, fmt.Errorf("negative min size %d", config.MinSize)
+	}
+	cron, err := parseCronExpression(config.Cron)
+	if err != nil {
+		return nil, err
+	}
+	location := time.UTC
+	if config.TimeZone != "" {
+		if location, err = time.LoadLocation(config.TimeZone); err != nil {
+			return nil, fmt.Errorf("unknown time zone %s: %v", config.TimeZone, err)
+		}
+	}
+	s := &schedule{
+		name:     config.Name,
+		cron:     cron,
+		location: location,
+		minSize:  config.MinSize,
+	}
+	for _, nodeGroup := range config.NodeGroups {
+		re, err := regexp.Compile(nodeGroup)
+		if err != nil {
+			return nil, fmt.Errorf("can't compile node group regexp %s: %v", nodeGroup, err)
+		}
+		s.nodeGroups = append(s.nodeGroups, re)
+	}
+	return s, nil
+}
+
+// cronExpression is a standard 5-field cron expression. Each field is a bitmask of allowed values.
+type cronExpression struct {
+	minutes, hours, daysOfMonth, months, daysOfWeek uint64
+	// As in cron, if both day fields are restricted, a day matching either of them matches.
+	daysOfMonthRestricted, daysOfWeekRestricted bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	// 7 is an alias of Sunday.
+	{"day of week", 0, 7},
+}
+
+func parseCronExpression(expression string) (*cronExpression, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression %q should have %d fields, has %d", expression, len(cronFields), len(fields))
+	}
+	masks := make([]uint64, len(fields))
+	for i, field := range fields {
+		mask, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in cron expression %q: %v", cronFields[i].name, expression, err)
+		}
+		masks[i] = mask
+	}
+	daysOfWeek := masks[4]
+	if daysOfWeek&(1<<7) != 0 {
+		daysOfWeek |= 1
+	}
+	return &cronExpression{
+		minutes:               masks[0],
+		hours:                 masks[1],
+		daysOfMonth:           masks[2],
+		months:                masks[3],
+		daysOfWeek:            daysOfWeek,
+		daysOfMonthRestricted: fields[2] != "*",
+		daysOfWeekRestricted:  fields[4] != "*",
+	}, nil
+}
+
+// parseCronField parses a comma-separated list of values, ranges (a-b) and wildcards,
+// each optionally followed by a step (/n).
+func parseCronField(field string, spec cronField) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rangePart = part[:i]
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+		low, high := spec.min, spec.max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if low, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value in %q", part)
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value in %q", part)
+				}
+			} else if step > 1 {
+				// As in cron, a/n means every n-th value starting with a.
+				high = spec.max
+			}
+		}
+		if low < spec.min || high > spec.max || low > high {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, spec.min, spec.max)
+		}
+		for value := low; value <= high; value += step {
+			mask |= 1 << uint(value)
+		}
+	}
+	return mask, nil
+}
+
+func (c *cronExpression) matches(t time.Time) bool {
+	if c.minutes&(1<<uint(t.Minute())) == 0 || c.hours&(1<<uint(t.Hour())) == 0 || c.months&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	dayOfMonth := c.daysOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeek := c.daysOfWeek&(1<<uint(t.Weekday())) != 0
+	if c.daysOfMonthRestricted && c.daysOfWeekRestricted {
+		return dayOfMonth || dayOfWeek
+	}
+	return dayOfMonth && dayOfWeek
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduledcapacity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronExpressionMatches(t *testing.T) {
+	// Monday.
+	monday8am := time.Date(2019, 10, 7, 8, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		expression string
+		time       time.Time
+		matches    bool
+	}{
+		{"* * * * *", monday8am, true},
+		{"* 8-19 * * 1-5", monday8am, true},
+		{"* 8-19 * * 1-5", monday8am.Add(-time.Minute), false},
+		{"* 8-19 * * 1-5", monday8am.Add(12 * time.Hour), false},
+		{"* 8-19 * * 1-5", monday8am.Add(12*time.Hour - time.Minute), true},
+		{"* 8-19 * * 1-5", monday8am.Add(-24 * time.Hour), false},
+		{"* * * * 0", monday8am.Add(-24 * time.Hour), true},
+		{"* * * * 7", monday8am.Add(-24 * time.Hour), true},
+		{"0,30 * * * *", monday8am.Add(30 * time.Minute), true},
+		{"0,30 * * * *", monday8am.Add(15 * time.Minute), false},
+		{"*/15 * * * *", monday8am.Add(45 * time.Minute), true},
+		{"*/15 * * * *", monday8am.Add(50 * time.Minute), false},
+		{"5/20 * * * *", monday8am.Add(25 * time.Minute), true},
+		{"5/20 * * * *", monday8am.Add(20 * time.Minute), false},
+		{"* * 7 10 *", monday8am, true},
+		{"* * 7 11 *", monday8am, false},
+		// Both day fields restricted: either matches.
+		{"* * 1 * 1", monday8am, true},
+		{"* * 7 * 3", monday8am, true},
+		{"* * 1 * 3", monday8am, false},
+	}
+	for _, tc := range testCases {
+		cron, err := parseCronExpression(tc.expression)
+		assert.NoError(t, err, tc.expression)
+		assert.Equal(t, tc.matches, cron.matches(tc.time), "%s at %v", tc.expression, tc.time)
+	}
+}
+
+func TestParseCronExpressionErrors(t *testing.T) {
+	for _, expression := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"1-a * * * *",
+	} {
+		_, err := parseCronExpression(expression)
+		assert.Error(t, err, expression)
+	}
+}
+
+func TestParseSchedules(t *testing.T) {
+	schedules, err := parseSchedulesYAMLString(`
+- name: business-hours
+  nodeGroups:
+    - ".*pool-a.*"
+    - "pool-b"
+  cron: "* 8-19 * * 1-5"
+  timeZone: Europe/Warsaw
+  minSize: 5
+- name: weekend
+  nodeGroups: ["pool-c"]
+  cron: "* * * * 6,0"
+  minSize: 1
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(schedules))
+	assert.Equal(t, "business-hours", schedules[0].name)
+	assert.Equal(t, 5, schedules[0].minSize)
+	assert.Equal(t, "Europe/Warsaw", schedules[0].location.String())
+	assert.True(t, schedules[0].matchesNodeGroup("my-pool-a-1"))
+	assert.True(t, schedules[0].matchesNodeGroup("pool-b"))
+	assert.False(t, schedules[0].matchesNodeGroup("pool-c"))
+	assert.Equal(t, time.UTC, schedules[1].location)
+
+	// 8:00 in Warsaw is 6:00 UTC in summer.
+	monday := time.Date(2019, 10, 7, 6, 0, 0, 0, time.UTC)
+	assert.True(t, schedules[0].isActive(monday))
+	assert.False(t, schedules[0].isActive(monday.Add(-time.Minute)))
+}
+
+func TestParseSchedulesErrors(t *testing.T) {
+	for _, config := range []string{
+		"",
+		"not a list",
+		`[{nodeGroups: [a], cron: "* * * * *", minSize: 1}]`,
+		`[{name: a, cron: "* * * * *", minSize: 1}]`,
+		`[{name: a, nodeGroups: ["("], cron: "* * * * *", minSize: 1}]`,
+		`[{name: a, nodeGroups: [a], cron: "* * * *", minSize: 1}]`,
+		`[{name: a, nodeGroups: [a], cron: "* * * * *", minSize: -1}]`,
+		`[{name: a, nodeGroups: [a], cron: "* * * * *", timeZone: Nowhere/Nothing, minSize: 1}]`,
+		`[{name: a, nodeGroups: [a], cron: "* * * * *", minSize: 1}, {name: a, nodeGroups: [b], cron: "* * * * *", minSize: 1}]`,
+	} {
+		_, err := parseSchedulesYAMLString(config)
+		assert.Error(t, err, config)
+	}
+}
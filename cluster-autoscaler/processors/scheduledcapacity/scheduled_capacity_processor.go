@@ -0,0 +1,318 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduledcapacity
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroups"
+	v1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+const (
+	// ScheduledCapacityConfigMapName defines a name of the ConfigMap used to store scheduled capacity configuration
+	ScheduledCapacityConfigMapName = "cluster-autoscaler-scheduled-capacity"
+	// ConfigMapKey defines the key used in the ConfigMap to configure schedules
+	ConfigMapKey = "schedules"
+	// PlaceholderPodAnnotation is set on placeholder pods to the id of the node group they stand for.
+	PlaceholderPodAnnotation = "cluster-autoscaler.kubernetes.io/scheduled-capacity-placeholder"
+	// NodeGroupLabel is selected by placeholder pods. It's set to the node group id only on the template
+	// nodes used in scale-up simulations, so a placeholder pod can't be placed on any other node.
+	NodeGroupLabel = "scheduled-capacity.cluster-autoscaler.kubernetes.io/node-group"
+)
+
+// ScheduledCapacityProcessor raises minimum sizes of node groups according to schedules defined
+// in a ConfigMap. Raised minimums are exposed to the rest of CA by wrapping the cloud provider
+// (see NewCloudProvider), so scale-down doesn't go below them. They are enforced by scale-up:
+// placeholder pods stand for the nodes missing to reach them and, as a NodeGroupListProcessor,
+// the processor makes each placeholder pod fit only the template node of its node group.
+type ScheduledCapacityProcessor struct {
+	configMapLister        v1lister.ConfigMapNamespaceLister
+	logRecorder            record.EventRecorder
+	nodeGroupListProcessor nodegroups.NodeGroupListProcessor
+
+	lock sync.Mutex
+	// schedules come from the last valid version of the ConfigMap.
+	schedules             []*schedule
+	configLoaded          bool
+	configResourceVersion string
+	activeSchedules       map[string]bool
+	minSizes              map[string]int
+}
+
+// NewScheduledCapacityProcessor returns a processor reading schedules from the given ConfigMap lister.
+// It wraps nodeGroupListProcessor, which processes node groups considered in scale-up first.
+func NewScheduledCapacityProcessor(configMapLister v1lister.ConfigMapNamespaceLister,
+	logRecorder record.EventRecorder, nodeGroupListProcessor nodegroups.NodeGroupListProcessor) *ScheduledCapacityProcessor {
+	return &ScheduledCapacityProcessor{
+		configMapLister:        configMapLister,
+		logRecorder:            logRecorder,
+		nodeGroupListProcessor: nodeGroupListProcessor,
+		activeSchedules:        make(map[string]bool),
+	}
+}
+
+// Refresh reloads the schedules and computes the minimum sizes of node groups at currentTime.
+// It should be called at the beginning of every loop, after the cloud provider is refreshed.
+func (p *ScheduledCapacityProcessor) Refresh(context *context.AutoscalingContext, currentTime time.Time) {
+	cm := p.reloadConfigMap()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	minSizes := make(map[string]int)
+	activeSchedules := make(map[string]bool)
+	nodeGroups := context.CloudProvider.NodeGroups()
+	for _, s := range p.schedules {
+		if !s.isActive(currentTime) {
+			continue
+		}
+		activeSchedules[s.name] = true
+		for _, nodeGroup := range nodeGroups {
+			id := nodeGroup.Id()
+			if !s.matchesNodeGroup(id) {
+				continue
+			}
+			if s.minSize > minSizes[id] {
+				minSizes[id] = s.minSize
+			}
+		}
+	}
+	for name := range activeSchedules {
+		if !p.activeSchedules[name] {
+			p.logScheduleEvent(cm, "ScheduledCapacityStarted", fmt.Sprintf("Schedule %s started", name))
+		}
+	}
+	for name := range p.activeSchedules {
+		if !activeSchedules[name] {
+			p.logScheduleEvent(cm, "ScheduledCapacityEnded", fmt.Sprintf("Schedule %s ended", name))
+		}
+	}
+	p.activeSchedules = activeSchedules
+	p.minSizes = minSizes
+}
+
+// MinSize returns the minimum size of the node group, raised by the active schedules.
+// It never exceeds the maximum size of the node group.
+func (p *ScheduledCapacityProcessor) MinSize(nodeGroup cloudprovider.NodeGroup) int {
+	p.lock.Lock()
+	scheduledMinSize, found := p.minSizes[nodeGroup.Id()]
+	p.lock.Unlock()
+	return effectiveMinSize(nodeGroup, scheduledMinSize, found)
+}
+
+func effectiveMinSize(nodeGroup cloudprovider.NodeGroup, scheduledMinSize int, found bool) int {
+	minSize := nodeGroup.MinSize()
+	if !found || scheduledMinSize <= minSize {
+		return minSize
+	}
+	if maxSize := nodeGroup.MaxSize(); scheduledMinSize > maxSize {
+		return maxSize
+	}
+	return scheduledMinSize
+}
+
+// PlaceholderPods returns pending pods standing for the nodes missing in node groups to reach the minimum
+// sizes raised by the active schedules. Each pod fills a whole node built from the node group's NodeInfo.
+func (p *ScheduledCapacityProcessor) PlaceholderPods(context *context.AutoscalingContext,
+	nodeInfos map[string]*schedulernodeinfo.NodeInfo) []*apiv1.Pod {
+	p.lock.Lock()
+	minSizes := p.minSizes
+	p.lock.Unlock()
+
+	var result []*apiv1.Pod
+	for _, nodeGroup := range context.CloudProvider.NodeGroups() {
+		scheduledMinSize, found := minSizes[nodeGroup.Id()]
+		if !found {
+			continue
+		}
+		targetSize, err := nodeGroup.TargetSize()
+		if err != nil {
+			klog.Warningf("Failed to get target size of %s: %v", nodeGroup.Id(), err)
+			continue
+		}
+		// Minimum sizes configured in the cloud provider aren't enforced, as without scheduled capacity.
+		if maxSize := nodeGroup.MaxSize(); scheduledMinSize > maxSize {
+			scheduledMinSize = maxSize
+		}
+		missing := scheduledMinSize - targetSize
+		if missing <= 0 {
+			continue
+		}
+		nodeInfo, found := nodeInfos[nodeGroup.Id()]
+		if !found {
+			klog.Warningf("No node info for %s, can't create scheduled capacity placeholders", nodeGroup.Id())
+			continue
+		}
+		klog.V(2).Infof("Creating %d placeholder pods to scale %s up from %d to its scheduled minimum size",
+			missing, nodeGroup.Id(), targetSize)
+		for i := 0; i < missing; i++ {
+			result = append(result, buildPlaceholderPod(nodeGroup.Id(), i, context.ConfigNamespace, nodeInfo))
+		}
+	}
+	return result
+}
+
+// Process processes node groups considered in scale-up with the wrapped NodeGroupListProcessor, then sets
+// NodeGroupLabel on the template nodes of node groups with pending placeholder pods. The placeholder pods
+// select the label, so they are only placed on new nodes of their own node group.
+func (p *ScheduledCapacityProcessor) Process(context *context.AutoscalingContext, nodeGroups []cloudprovider.NodeGroup,
+	nodeInfos map[string]*schedulernodeinfo.NodeInfo, unschedulablePods []*apiv1.Pod) ([]cloudprovider.NodeGroup, map[string]*schedulernodeinfo.NodeInfo, error) {
+	nodeGroups, nodeInfos, err := p.nodeGroupListProcessor.Process(context, nodeGroups, nodeInfos, unschedulablePods)
+	if err != nil {
+		return nil, nil, err
+	}
+	placeholderNodeGroups := make(map[string]bool)
+	for _, pod := range unschedulablePods {
+		if id, found := pod.Annotations[PlaceholderPodAnnotation]; found {
+			placeholderNodeGroups[id] = true
+		}
+	}
+	if len(placeholderNodeGroups) == 0 {
+		return nodeGroups, nodeInfos, nil
+	}
+	// The map is copied, the node infos of the caller aren't modified.
+	result := make(map[string]*schedulernodeinfo.NodeInfo, len(nodeInfos))
+	for id, nodeInfo := range nodeInfos {
+		if placeholderNodeGroups[id] {
+			if nodeInfo, err = withNodeGroupLabel(nodeInfo, id); err != nil {
+				return nil, nil, err
+			}
+		}
+		result[id] = nodeInfo
+	}
+	return nodeGroups, result, nil
+}
+
+func withNodeGroupLabel(nodeInfo *schedulernodeinfo.NodeInfo, nodeGroupId string) (*schedulernodeinfo.NodeInfo, error) {
+	labeled := nodeInfo.Clone()
+	node := labeled.Node().DeepCopy()
+	if node.Labels == nil {
+		node.Labels = make(map[string]string)
+	}
+	node.Labels[NodeGroupLabel] = nodeGroupId
+	if err := labeled.SetNode(node); err != nil {
+		return nil, fmt.Errorf("failed to label template node of %s: %v", nodeGroupId, err)
+	}
+	return labeled, nil
+}
+
+// CleanUp cleans up the processor's internal structures.
+func (p *ScheduledCapacityProcessor) CleanUp() {
+	p.nodeGroupListProcessor.CleanUp()
+}
+
+func buildPlaceholderPod(nodeGroupId string, index int, namespace string, nodeInfo *schedulernodeinfo.NodeInfo) *apiv1.Pod {
+	allocatable := nodeInfo.AllocatableResource()
+	requested := nodeInfo.RequestedResource()
+	name := fmt.Sprintf("scheduled-capacity-%s-%d", nodeGroupId, index)
+	return &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			UID:         types.UID(name),
+			Annotations: map[string]string{PlaceholderPodAnnotation: nodeGroupId},
+		},
+		Spec: apiv1.PodSpec{
+			NodeSelector: map[string]string{NodeGroupLabel: nodeGroupId},
+			Tolerations:  []apiv1.Toleration{{Operator: apiv1.TolerationOpExists}},
+			Containers: []apiv1.Container{{
+				Name: "placeholder",
+				Resources: apiv1.ResourceRequirements{
+					Requests: apiv1.ResourceList{
+						apiv1.ResourceCPU:    *resource.NewMilliQuantity(nonNegative(allocatable.MilliCPU-requested.MilliCPU), resource.DecimalSI),
+						apiv1.ResourceMemory: *resource.NewQuantity(nonNegative(allocatable.Memory-requested.Memory), resource.BinarySI),
+					},
+				},
+			}},
+		},
+		Status: apiv1.PodStatus{
+			Phase: apiv1.PodPending,
+			Conditions: []apiv1.PodCondition{{
+				Type:   apiv1.PodScheduled,
+				Status: apiv1.ConditionFalse,
+				Reason: apiv1.PodReasonUnschedulable,
+			}},
+		},
+	}
+}
+
+func nonNegative(value int64) int64 {
+	if value < 0 {
+		return 0
+	}
+	return value
+}
+
+// reloadConfigMap parses the ConfigMap if it changed since the last call. Invalid versions are ignored
+// and the previous schedules are kept. If there is no ConfigMap, there are no schedules.
+func (p *ScheduledCapacityProcessor) reloadConfigMap() *apiv1.ConfigMap {
+	cm, err := p.configMapLister.Get(ScheduledCapacityConfigMapName)
+	if err != nil {
+		klog.V(4).Infof("Scheduled capacity config map %s not found: %v", ScheduledCapacityConfigMapName, err)
+		p.lock.Lock()
+		p.schedules = nil
+		p.configLoaded = false
+		p.lock.Unlock()
+		return nil
+	}
+	p.lock.Lock()
+	unchanged := p.configLoaded && cm.ResourceVersion == p.configResourceVersion
+	p.lock.Unlock()
+	if unchanged {
+		return cm
+	}
+
+	schedulesString, found := cm.Data[ConfigMapKey]
+	var schedules []*schedule
+	if !found {
+		err = fmt.Errorf("config map doesn't contain %s key", ConfigMapKey)
+	} else {
+		schedules, err = parseSchedulesYAMLString(schedulesString)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.configLoaded = true
+	p.configResourceVersion = cm.ResourceVersion
+	if err != nil {
+		msg := fmt.Sprintf("Wrong configuration for scheduled capacity: %v. Ignoring update.", err)
+		p.logRecorder.Event(cm, apiv1.EventTypeWarning, "ScheduledCapacityConfigMapInvalid", msg)
+		klog.Warning(msg)
+		return cm
+	}
+	p.schedules = schedules
+	klog.V(4).Infof("Successfully loaded %d schedules from configmap.", len(schedules))
+	return cm
+}
+
+func (p *ScheduledCapacityProcessor) logScheduleEvent(cm *apiv1.ConfigMap, reason, msg string) {
+	klog.V(1).Info(msg)
+	if cm != nil {
+		p.logRecorder.Event(cm, apiv1.EventTypeNormal, reason, msg)
+	}
+}
@@ -0,0 +1,260 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduledcapacity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	"k8s.io/autoscaler/cluster-autoscaler/processors/nodegroups"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	"k8s.io/client-go/tools/record"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+const (
+	businessHours = `
+- name: business-hours
+  nodeGroups: ["ng1", "ng2"]
+  cron: "* 8-19 * * 1-5"
+  minSize: 3
+- name: ng2-morning
+  nodeGroups: ["ng2"]
+  cron: "* 8-9 * * *"
+  minSize: 8
+`
+)
+
+var (
+	// Monday.
+	monday9am = time.Date(2019, 10, 7, 9, 0, 0, 0, time.UTC)
+)
+
+type configMapListerMock struct {
+	configMap *apiv1.ConfigMap
+}
+
+func (l *configMapListerMock) List(selector labels.Selector) ([]*apiv1.ConfigMap, error) {
+	if l.configMap == nil {
+		return nil, nil
+	}
+	return []*apiv1.ConfigMap{l.configMap}, nil
+}
+
+func (l *configMapListerMock) Get(name string) (*apiv1.ConfigMap, error) {
+	if l.configMap == nil || l.configMap.Name != name {
+		return nil, errors.NewNotFound(apiv1.Resource("configmap"), name)
+	}
+	return l.configMap, nil
+}
+
+func (l *configMapListerMock) set(schedules, resourceVersion string) {
+	l.configMap = &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            ScheduledCapacityConfigMapName,
+			Namespace:       "kube-system",
+			ResourceVersion: resourceVersion,
+		},
+		Data: map[string]string{ConfigMapKey: schedules},
+	}
+}
+
+func setUpProcessor(t *testing.T) (*ScheduledCapacityProcessor, *configMapListerMock, *context.AutoscalingContext,
+	*testprovider.TestCloudProvider, *record.FakeRecorder) {
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 1)
+	provider.AddNodeGroup("ng2", 2, 5, 2)
+	provider.AddNodeGroup("ng3", 0, 10, 0)
+
+	lister := &configMapListerMock{}
+	lister.set(businessHours, "1")
+	recorder := record.NewFakeRecorder(10)
+	processor := NewScheduledCapacityProcessor(lister, recorder, nodegroups.NewDefaultNodeGroupListProcessor())
+	autoscalingContext := &context.AutoscalingContext{
+		AutoscalingOptions: config.AutoscalingOptions{ConfigNamespace: "kube-system"},
+		CloudProvider:      NewCloudProvider(provider, processor),
+	}
+	return processor, lister, autoscalingContext, provider, recorder
+}
+
+func minSizes(autoscalingContext *context.AutoscalingContext) map[string]int {
+	result := make(map[string]int)
+	for _, nodeGroup := range autoscalingContext.CloudProvider.NodeGroups() {
+		result[nodeGroup.Id()] = nodeGroup.MinSize()
+	}
+	return result
+}
+
+func TestScheduledMinSizes(t *testing.T) {
+	processor, _, autoscalingContext, provider, recorder := setUpProcessor(t)
+
+	// Before any refresh minimum sizes come from the cloud provider.
+	assert.Equal(t, map[string]int{"ng1": 1, "ng2": 2, "ng3": 0}, minSizes(autoscalingContext))
+
+	// Both schedules active, ng2 is capped at its max size.
+	processor.Refresh(autoscalingContext, monday9am)
+	assert.Equal(t, map[string]int{"ng1": 3, "ng2": 5, "ng3": 0}, minSizes(autoscalingContext))
+	nodeGroup, err := autoscalingContext.CloudProvider.NodeGroupForNode(buildNodeInGroup(provider, "ng1"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, nodeGroup.MinSize())
+	assert.Equal(t, 10, nodeGroup.MaxSize())
+	assertEvents(t, recorder, "Normal ScheduledCapacityStarted Schedule business-hours started",
+		"Normal ScheduledCapacityStarted Schedule ng2-morning started")
+
+	// Only business hours.
+	processor.Refresh(autoscalingContext, monday9am.Add(2*time.Hour))
+	assert.Equal(t, map[string]int{"ng1": 3, "ng2": 3, "ng3": 0}, minSizes(autoscalingContext))
+	assertEvents(t, recorder, "Normal ScheduledCapacityEnded Schedule ng2-morning ended")
+
+	// Saturday noon, neither is active.
+	processor.Refresh(autoscalingContext, monday9am.Add(5*24*time.Hour+3*time.Hour))
+	assert.Equal(t, map[string]int{"ng1": 1, "ng2": 2, "ng3": 0}, minSizes(autoscalingContext))
+	assertEvents(t, recorder, "Normal ScheduledCapacityEnded Schedule business-hours ended")
+}
+
+func TestScheduledCapacityConfigUpdates(t *testing.T) {
+	processor, lister, autoscalingContext, _, recorder := setUpProcessor(t)
+
+	processor.Refresh(autoscalingContext, monday9am)
+	assert.Equal(t, 3, minSizes(autoscalingContext)["ng1"])
+	assertEvents(t, recorder, "Normal ScheduledCapacityStarted Schedule business-hours started",
+		"Normal ScheduledCapacityStarted Schedule ng2-morning started")
+
+	// Invalid updates are ignored.
+	lister.set("- name: broken", "2")
+	processor.Refresh(autoscalingContext, monday9am)
+	assert.Equal(t, 3, minSizes(autoscalingContext)["ng1"])
+	assertEvents(t, recorder, "Warning ScheduledCapacityConfigMapInvalid Wrong configuration for scheduled capacity: "+
+		"invalid schedule broken: no node groups. Ignoring update.")
+	// The warning isn't repeated until the config map changes.
+	processor.Refresh(autoscalingContext, monday9am)
+	assertEvents(t, recorder)
+
+	lister.set(`[{name: all, nodeGroups: ["ng"], cron: "* * * * *", minSize: 4}]`, "3")
+	processor.Refresh(autoscalingContext, monday9am)
+	assert.Equal(t, map[string]int{"ng1": 4, "ng2": 4, "ng3": 4}, minSizes(autoscalingContext))
+
+	// Without the config map there are no schedules.
+	lister.configMap = nil
+	processor.Refresh(autoscalingContext, monday9am)
+	assert.Equal(t, map[string]int{"ng1": 1, "ng2": 2, "ng3": 0}, minSizes(autoscalingContext))
+}
+
+func TestPlaceholderPods(t *testing.T) {
+	processor, _, autoscalingContext, provider, _ := setUpProcessor(t)
+
+	n1 := BuildTestNode("n1", 2000, 4000)
+	n1.Labels = map[string]string{apiv1.LabelHostname: "n1", "pool": "ng1"}
+	ds := BuildTestPod("ds", 500, 1000)
+	nodeInfo := schedulernodeinfo.NewNodeInfo(ds)
+	assert.NoError(t, nodeInfo.SetNode(n1))
+	nodeInfos := map[string]*schedulernodeinfo.NodeInfo{"ng1": nodeInfo, "ng2": nodeInfo}
+
+	// No schedules yet.
+	assert.Empty(t, processor.PlaceholderPods(autoscalingContext, nodeInfos))
+
+	// ng1 needs 2 more nodes, ng2 needs 3 more to reach its max size.
+	processor.Refresh(autoscalingContext, monday9am)
+	pods := processor.PlaceholderPods(autoscalingContext, nodeInfos)
+	assert.Equal(t, 5, len(pods))
+	perGroup := make(map[string]int)
+	for _, pod := range pods {
+		nodeGroupId := pod.Annotations[PlaceholderPodAnnotation]
+		perGroup[nodeGroupId]++
+		assert.Equal(t, "kube-system", pod.Namespace)
+		assert.Equal(t, map[string]string{NodeGroupLabel: nodeGroupId}, pod.Spec.NodeSelector)
+		assert.Equal(t, int64(1500), pod.Spec.Containers[0].Resources.Requests.Cpu().MilliValue())
+		assert.Equal(t, int64(3000), pod.Spec.Containers[0].Resources.Requests.Memory().Value())
+	}
+	assert.Equal(t, map[string]int{"ng1": 2, "ng2": 3}, perGroup)
+	assert.NotEqual(t, pods[0].UID, pods[1].UID)
+
+	// Upcoming nodes count as present.
+	provider.GetNodeGroup("ng1").(*testprovider.TestNodeGroup).SetTargetSize(3)
+	pods = processor.PlaceholderPods(autoscalingContext, nodeInfos)
+	assert.Equal(t, 3, len(pods))
+	for _, pod := range pods {
+		assert.Equal(t, "ng2", pod.Annotations[PlaceholderPodAnnotation])
+	}
+
+	// Node groups without node infos are skipped.
+	assert.Empty(t, processor.PlaceholderPods(autoscalingContext, map[string]*schedulernodeinfo.NodeInfo{}))
+}
+
+func TestProcessLabelsTemplateNodesOfPlaceholders(t *testing.T) {
+	processor, _, autoscalingContext, _, _ := setUpProcessor(t)
+
+	// ng1 and ng3 have the same template, only ng1 has a raised minimum size.
+	template := BuildTestNode("template", 2000, 4000)
+	template.Labels = map[string]string{"pool": "shared"}
+	nodeInfo := schedulernodeinfo.NewNodeInfo()
+	assert.NoError(t, nodeInfo.SetNode(template))
+	nodeInfos := map[string]*schedulernodeinfo.NodeInfo{"ng1": nodeInfo, "ng3": nodeInfo}
+
+	processor.Refresh(autoscalingContext, monday9am)
+	pods := processor.PlaceholderPods(autoscalingContext, nodeInfos)
+	assert.Equal(t, 2, len(pods))
+
+	nodeGroups := autoscalingContext.CloudProvider.NodeGroups()
+	processedNodeGroups, processedNodeInfos, err := processor.Process(autoscalingContext, nodeGroups, nodeInfos,
+		append(pods, BuildTestPod("p1", 100, 100)))
+	assert.NoError(t, err)
+	assert.Equal(t, nodeGroups, processedNodeGroups)
+	assert.Equal(t, "ng1", processedNodeInfos["ng1"].Node().Labels[NodeGroupLabel])
+	assert.Equal(t, "shared", processedNodeInfos["ng1"].Node().Labels["pool"])
+	// The placeholders select ng1 only, even if other node groups have the same template.
+	_, found := processedNodeInfos["ng3"].Node().Labels[NodeGroupLabel]
+	assert.False(t, found)
+	// The node infos of the caller aren't modified.
+	_, found = nodeInfo.Node().Labels[NodeGroupLabel]
+	assert.False(t, found)
+
+	// Without placeholders node infos are left as they are.
+	_, processedNodeInfos, err = processor.Process(autoscalingContext, nodeGroups, nodeInfos,
+		[]*apiv1.Pod{BuildTestPod("p1", 100, 100)})
+	assert.NoError(t, err)
+	assert.Equal(t, nodeInfos, processedNodeInfos)
+}
+
+func buildNodeInGroup(provider *testprovider.TestCloudProvider, nodeGroup string) *apiv1.Node {
+	node := BuildTestNode(nodeGroup+"-node", 1000, 1000)
+	provider.AddNode(nodeGroup, node)
+	return node
+}
+
+func assertEvents(t *testing.T, recorder *record.FakeRecorder, expected ...string) {
+	var events []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+			continue
+		default:
+		}
+		break
+	}
+	assert.ElementsMatch(t, expected, events)
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"fmt"
+	"time"
+
+	kube_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/apis/autoscaling.x-k8s.io/v1alpha1"
+	ca_clientset "k8s.io/autoscaler/cluster-autoscaler/client/clientset/versioned"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/api"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+
+	"k8s.io/klog"
+)
+
+const (
+	// StatusResourceName is the name of the ClusterAutoscalerStatus resource written by CRDStatusProcessor.
+	StatusResourceName = "cluster-autoscaler-status"
+)
+
+// CRDStatusProcessor is an AutoscalingStatusProcessor writing the status of the cluster to
+// a ClusterAutoscalerStatus custom resource in the given namespace.
+type CRDStatusProcessor struct {
+	client    ca_clientset.Interface
+	namespace string
+}
+
+// NewCRDStatusProcessor returns a processor writing the status with the given client.
+func NewCRDStatusProcessor(client ca_clientset.Interface, namespace string) *CRDStatusProcessor {
+	return &CRDStatusProcessor{
+		client:    client,
+		namespace: namespace,
+	}
+}
+
+// Process writes the current status of the cluster to the ClusterAutoscalerStatus resource,
+// creating the resource if it doesn't exist.
+func (p *CRDStatusProcessor) Process(context *context.AutoscalingContext, csr *clusterstate.ClusterStateRegistry, now time.Time) error {
+	statuses := p.client.AutoscalingV1alpha1().ClusterAutoscalerStatuses(p.namespace)
+	statusObj, err := statuses.Get(StatusResourceName, metav1.GetOptions{})
+	if kube_errors.IsNotFound(err) {
+		// The status subresource is ignored on creation, it has to be updated separately.
+		statusObj, err = statuses.Create(&v1alpha1.ClusterAutoscalerStatus{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: p.namespace,
+				Name:      StatusResourceName,
+			},
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to retrieve status resource for update: %v", err)
+	}
+	statusObj.Status = buildClusterAutoscalerState(csr.GetStatus(now), now)
+	if _, err := statuses.UpdateStatus(statusObj); err != nil {
+		return fmt.Errorf("failed to write status resource: %v", err)
+	}
+	klog.V(8).Infof("Successfully wrote status resource %s/%s", p.namespace, StatusResourceName)
+	return nil
+}
+
+// CleanUp deletes the ClusterAutoscalerStatus resource.
+func (p *CRDStatusProcessor) CleanUp() {
+	err := p.client.AutoscalingV1alpha1().ClusterAutoscalerStatuses(p.namespace).Delete(StatusResourceName, &metav1.DeleteOptions{})
+	if err != nil && !kube_errors.IsNotFound(err) {
+		klog.Errorf("Failed to delete status resource: %v", err)
+	}
+}
+
+func buildClusterAutoscalerState(status *api.ClusterAutoscalerStatus, now time.Time) v1alpha1.ClusterAutoscalerState {
+	state := v1alpha1.ClusterAutoscalerState{
+		LastUpdateTime:        metav1.NewTime(now),
+		ClusterwideConditions: buildConditions(status.ClusterwideConditions),
+	}
+	for _, ngStatus := range status.NodeGroupStatuses {
+		nodeGroupStatus := v1alpha1.NodeGroupStatus{
+			ProviderID: ngStatus.ProviderID,
+			Conditions: buildConditions(ngStatus.Conditions),
+		}
+		for _, node := range ngStatus.UnremovableNodes {
+			nodeGroupStatus.UnremovableNodes = append(nodeGroupStatus.UnremovableNodes, v1alpha1.UnremovableNode{
+				Name:              node.Name,
+				Reason:            node.Reason,
+				BlockingPod:       node.BlockingPod,
+				BlockingPodReason: node.BlockingPodReason,
+			})
+		}
+		if ngStatus.Backoff != nil {
+			nodeGroupStatus.Backoff = &v1alpha1.BackoffStatus{
+				BackoffUntil: ngStatus.Backoff.BackoffUntil,
+				ErrorClass:   ngStatus.Backoff.ErrorClass,
+				ErrorCode:    ngStatus.Backoff.ErrorCode,
+			}
+		}
+		for _, event := range ngStatus.RecentScaleEvents {
+			nodeGroupStatus.RecentScaleEvents = append(nodeGroupStatus.RecentScaleEvents, v1alpha1.ScaleEvent{
+				Type:     v1alpha1.ScaleEventType(event.Type),
+				Time:     event.Time,
+				Delta:    int32(event.Delta),
+				NodeName: event.NodeName,
+				Reason:   event.Reason,
+			})
+		}
+		state.NodeGroupStatuses = append(state.NodeGroupStatuses, nodeGroupStatus)
+	}
+	return state
+}
+
+func buildConditions(conditions []api.ClusterAutoscalerCondition) []v1alpha1.ClusterAutoscalerCondition {
+	var result []v1alpha1.ClusterAutoscalerCondition
+	for _, condition := range conditions {
+		result = append(result, v1alpha1.ClusterAutoscalerCondition{
+			Type:               v1alpha1.ClusterAutoscalerConditionType(condition.Type),
+			Status:             v1alpha1.ClusterAutoscalerConditionStatus(condition.Status),
+			Message:            condition.Message,
+			Reason:             condition.Reason,
+			LastProbeTime:      condition.LastProbeTime,
+			LastTransitionTime: condition.LastTransitionTime,
+		})
+	}
+	return result
+}
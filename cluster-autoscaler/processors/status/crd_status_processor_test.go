@@ -0,0 +1,89 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	kube_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/apis/autoscaling.x-k8s.io/v1alpha1"
+	ca_fake "k8s.io/autoscaler/cluster-autoscaler/client/clientset/versioned/fake"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate"
+	"k8s.io/autoscaler/cluster-autoscaler/clusterstate/utils"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/backoff"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	"k8s.io/client-go/kubernetes/fake"
+	kube_record "k8s.io/client-go/tools/record"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCRDStatusProcessor(t *testing.T) {
+	now := time.Now()
+	ng1_1 := BuildTestNode("ng1-1", 1000, 1000)
+	SetNodeReadyState(ng1_1, true, now.Add(-time.Minute))
+
+	provider := testprovider.NewTestCloudProvider(nil, nil)
+	provider.AddNodeGroup("ng1", 1, 10, 3)
+	provider.AddNode("ng1", ng1_1)
+
+	logRecorder, _ := utils.NewStatusMapRecorder(&fake.Clientset{}, "kube-system", kube_record.NewFakeRecorder(5), false)
+	csr := clusterstate.NewClusterStateRegistry(provider, clusterstate.ClusterStateRegistryConfig{
+		MaxNodeProvisionTime: 15 * time.Minute,
+	}, logRecorder, backoff.NewIdBasedExponentialBackoff(5*time.Minute, 30*time.Minute, 3*time.Hour))
+	csr.RegisterOrUpdateScaleUp(provider.GetNodeGroup("ng1"), 2, now)
+	err := csr.UpdateNodes([]*apiv1.Node{ng1_1}, nil, now)
+	assert.NoError(t, err)
+
+	client := ca_fake.NewSimpleClientset()
+	processor := NewCRDStatusProcessor(client, "kube-system")
+	statuses := client.AutoscalingV1alpha1().ClusterAutoscalerStatuses("kube-system")
+
+	// The resource is created if it doesn't exist.
+	err = processor.Process(nil, csr, now)
+	assert.NoError(t, err)
+	statusObj, err := statuses.Get(StatusResourceName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, metav1.NewTime(now), statusObj.Status.LastUpdateTime)
+	assert.Equal(t, 3, len(statusObj.Status.ClusterwideConditions))
+	assert.Equal(t, 1, len(statusObj.Status.NodeGroupStatuses))
+	ngStatus := statusObj.Status.NodeGroupStatuses[0]
+	assert.Equal(t, "ng1", ngStatus.ProviderID)
+	assert.Equal(t, 3, len(ngStatus.Conditions))
+	assert.Equal(t, v1alpha1.ClusterAutoscalerScaleUp, ngStatus.Conditions[1].Type)
+	assert.Equal(t, v1alpha1.ClusterAutoscalerInProgress, ngStatus.Conditions[1].Status)
+	assert.Nil(t, ngStatus.Backoff)
+	assert.Equal(t, []v1alpha1.ScaleEvent{
+		{Type: v1alpha1.ScaleUpEvent, Time: metav1.NewTime(now), Delta: 2},
+	}, ngStatus.RecentScaleEvents)
+
+	// The existing resource is updated.
+	later := now.Add(time.Minute)
+	err = processor.Process(nil, csr, later)
+	assert.NoError(t, err)
+	statusObj, err = statuses.Get(StatusResourceName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, metav1.NewTime(later), statusObj.Status.LastUpdateTime)
+
+	processor.CleanUp()
+	_, err = statuses.Get(StatusResourceName, metav1.GetOptions{})
+	assert.True(t, kube_errors.IsNotFound(err))
+}
@@ -36,6 +36,9 @@ type ScaleDownNode struct {
 	NodeGroup   cloudprovider.NodeGroup
 	EvictedPods []*apiv1.Pod
 	UtilInfo    simulator.UtilizationInfo
+	// HourlySavings is the estimated price of running the node for an hour minus the price of running
+	// the evicted pods elsewhere. It's zero if the cloud provider has no pricing model.
+	HourlySavings float64
 }
 
 // ScaleDownResult represents the result of scale down.
@@ -0,0 +1,147 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replay
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+
+	"k8s.io/klog"
+)
+
+// CaptureClusterState captures the current input of the autoscaler from its listers and cloud provider.
+func CaptureClusterState(context *context.AutoscalingContext, currentTime time.Time) (*ClusterState, error) {
+	state := &ClusterState{
+		Time:    currentTime,
+		Options: context.AutoscalingOptions,
+	}
+	var err error
+	if state.Nodes, err = context.AllNodeLister().List(); err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+	scheduledPods, err := context.ScheduledPodLister().List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled pods: %v", err)
+	}
+	unschedulablePods, err := context.UnschedulablePodLister().List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unschedulable pods: %v", err)
+	}
+	state.Pods = append(append([]*apiv1.Pod{}, scheduledPods...), unschedulablePods...)
+	if state.PodDisruptionBudgets, err = context.PodDisruptionBudgetLister().List(); err != nil {
+		return nil, fmt.Errorf("failed to list pod disruption budgets: %v", err)
+	}
+	if state.DaemonSets, err = context.DaemonSetLister().List(labels.Everything()); err != nil {
+		return nil, fmt.Errorf("failed to list daemon sets: %v", err)
+	}
+	if state.ReplicationControllers, err = context.ReplicationControllerLister().List(labels.Everything()); err != nil {
+		return nil, fmt.Errorf("failed to list replication controllers: %v", err)
+	}
+	if state.Jobs, err = context.JobLister().List(labels.Everything()); err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %v", err)
+	}
+	if state.ReplicaSets, err = context.ReplicaSetLister().List(labels.Everything()); err != nil {
+		return nil, fmt.Errorf("failed to list replica sets: %v", err)
+	}
+	if state.StatefulSets, err = context.StatefulSetLister().List(labels.Everything()); err != nil {
+		return nil, fmt.Errorf("failed to list stateful sets: %v", err)
+	}
+
+	nodeGroupNodes := make(map[string][]string)
+	for _, node := range state.Nodes {
+		nodeGroup, err := context.CloudProvider.NodeGroupForNode(node)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get node group for %s: %v", node.Name, err)
+		}
+		if nodeGroup == nil || reflect.ValueOf(nodeGroup).IsNil() {
+			continue
+		}
+		nodeGroupNodes[nodeGroup.Id()] = append(nodeGroupNodes[nodeGroup.Id()], node.Name)
+	}
+	for _, nodeGroup := range context.CloudProvider.NodeGroups() {
+		targetSize, err := nodeGroup.TargetSize()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get target size of %s: %v", nodeGroup.Id(), err)
+		}
+		nodeGroupState := NodeGroupState{
+			Id:              nodeGroup.Id(),
+			MinSize:         nodeGroup.MinSize(),
+			MaxSize:         nodeGroup.MaxSize(),
+			TargetSize:      targetSize,
+			Autoprovisioned: nodeGroup.Autoprovisioned(),
+			Nodes:           nodeGroupNodes[nodeGroup.Id()],
+		}
+		template, err := nodeGroup.TemplateNodeInfo()
+		if err == nil {
+			nodeGroupState.Template = template.Node()
+			nodeGroupState.TemplatePods = template.Pods()
+		} else if err != cloudprovider.ErrNotImplemented {
+			klog.Warningf("Failed to capture template of node group %s: %v", nodeGroup.Id(), err)
+		}
+		state.NodeGroups = append(state.NodeGroups, nodeGroupState)
+	}
+	return state, nil
+}
+
+// StateCapturer writes the input of the autoscaler to a file on demand. A capture is requested
+// asynchronously, e.g. through HTTP, and taken at the beginning of the next autoscaler iteration.
+type StateCapturer struct {
+	path      string
+	requested int32
+}
+
+// NewStateCapturer creates a StateCapturer writing captured states to the given file.
+func NewStateCapturer(path string) *StateCapturer {
+	return &StateCapturer{path: path}
+}
+
+// Request requests a capture in the next autoscaler iteration.
+func (capturer *StateCapturer) Request() {
+	atomic.StoreInt32(&capturer.requested, 1)
+}
+
+// ServeHTTP requests a capture in the next autoscaler iteration.
+func (capturer *StateCapturer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	capturer.Request()
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte(fmt.Sprintf("Cluster state will be written to %s in the next iteration\n", capturer.path)))
+}
+
+// CaptureIfRequested captures the cluster state and writes it to the file, if a capture was requested
+// since the last call.
+func (capturer *StateCapturer) CaptureIfRequested(context *context.AutoscalingContext, currentTime time.Time) error {
+	if !atomic.CompareAndSwapInt32(&capturer.requested, 1, 0) {
+		return nil
+	}
+	state, err := CaptureClusterState(context, currentTime)
+	if err != nil {
+		return err
+	}
+	if err := WriteClusterState(state, capturer.path); err != nil {
+		return err
+	}
+	klog.V(1).Infof("Cluster state written to %s", capturer.path)
+	return nil
+}
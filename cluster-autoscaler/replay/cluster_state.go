@@ -0,0 +1,95 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"sigs.k8s.io/yaml"
+)
+
+// ClusterState is the input of a single autoscaler iteration: the options, node groups and
+// Kubernetes objects seen by the autoscaler. It's captured from a running autoscaler and
+// replayed offline to reproduce its decisions.
+type ClusterState struct {
+	// Time is the time of the capture.
+	Time                   time.Time                       `json:"time"`
+	Options                config.AutoscalingOptions       `json:"options"`
+	NodeGroups             []NodeGroupState                `json:"nodeGroups"`
+	Nodes                  []*apiv1.Node                   `json:"nodes"`
+	Pods                   []*apiv1.Pod                    `json:"pods"`
+	PodDisruptionBudgets   []*policyv1.PodDisruptionBudget `json:"podDisruptionBudgets,omitempty"`
+	DaemonSets             []*appsv1.DaemonSet             `json:"daemonSets,omitempty"`
+	ReplicationControllers []*apiv1.ReplicationController  `json:"replicationControllers,omitempty"`
+	Jobs                   []*batchv1.Job                  `json:"jobs,omitempty"`
+	ReplicaSets            []*appsv1.ReplicaSet            `json:"replicaSets,omitempty"`
+	StatefulSets           []*appsv1.StatefulSet           `json:"statefulSets,omitempty"`
+}
+
+// NodeGroupState is the definition of a node group at the time of the capture.
+type NodeGroupState struct {
+	Id              string `json:"id"`
+	MinSize         int    `json:"minSize"`
+	MaxSize         int    `json:"maxSize"`
+	TargetSize      int    `json:"targetSize"`
+	Autoprovisioned bool   `json:"autoprovisioned,omitempty"`
+	// Nodes are names of the nodes belonging to the node group.
+	Nodes []string `json:"nodes,omitempty"`
+	// Template and TemplatePods describe a new node of the node group, if the cloud provider
+	// supports node templates.
+	Template     *apiv1.Node  `json:"template,omitempty"`
+	TemplatePods []*apiv1.Pod `json:"templatePods,omitempty"`
+}
+
+// ReadClusterState reads a cluster state from a YAML or JSON file.
+func ReadClusterState(path string) (*ClusterState, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	state := &ClusterState{}
+	if err := yaml.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse cluster state from %s: %v", path, err)
+	}
+	return state, nil
+}
+
+// WriteClusterState writes the cluster state to a file, as YAML if the file has a .yaml
+// or .yml extension and as JSON otherwise.
+func WriteClusterState(state *ClusterState, path string) error {
+	var data []byte
+	var err error
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		data, err = yaml.Marshal(state)
+	default:
+		data, err = json.MarshalIndent(state, "", "  ")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode cluster state: %v", err)
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replay
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	testprovider "k8s.io/autoscaler/cluster-autoscaler/cloudprovider/test"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	kube_util "k8s.io/autoscaler/cluster-autoscaler/utils/kubernetes"
+	podv1 "k8s.io/kubernetes/pkg/api/v1/pod"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+// NewListerRegistry returns listers serving the objects of the cluster state. Pods are split
+// into scheduled and unschedulable ones the same way the default listers do it.
+func NewListerRegistry(state *ClusterState) (kube_util.ListerRegistry, error) {
+	readyNodes := make([]*apiv1.Node, 0, len(state.Nodes))
+	for _, node := range state.Nodes {
+		if kube_util.IsNodeReadyAndSchedulable(node) {
+			readyNodes = append(readyNodes, node)
+		}
+	}
+	var scheduledPods, unschedulablePods []*apiv1.Pod
+	for _, pod := range state.Pods {
+		if pod.Status.Phase == apiv1.PodSucceeded || pod.Status.Phase == apiv1.PodFailed {
+			continue
+		}
+		if pod.Spec.NodeName != "" {
+			scheduledPods = append(scheduledPods, pod)
+			continue
+		}
+		_, condition := podv1.GetPodCondition(&pod.Status, apiv1.PodScheduled)
+		if condition != nil && condition.Status == apiv1.ConditionFalse && condition.Reason == apiv1.PodReasonUnschedulable {
+			unschedulablePods = append(unschedulablePods, pod)
+		}
+	}
+
+	daemonSetLister, err := kube_util.NewTestDaemonSetLister(state.DaemonSets)
+	if err != nil {
+		return nil, err
+	}
+	replicationControllerLister, err := kube_util.NewTestReplicationControllerLister(state.ReplicationControllers)
+	if err != nil {
+		return nil, err
+	}
+	jobLister, err := kube_util.NewTestJobLister(state.Jobs)
+	if err != nil {
+		return nil, err
+	}
+	replicaSetLister, err := kube_util.NewTestReplicaSetLister(state.ReplicaSets)
+	if err != nil {
+		return nil, err
+	}
+	statefulSetLister, err := kube_util.NewTestStatefulSetLister(state.StatefulSets)
+	if err != nil {
+		return nil, err
+	}
+	return kube_util.NewListerRegistry(
+		kube_util.NewTestNodeLister(state.Nodes),
+		kube_util.NewTestNodeLister(readyNodes),
+		kube_util.NewTestPodLister(scheduledPods),
+		kube_util.NewTestPodLister(unschedulablePods),
+		kube_util.NewTestPodDisruptionBudgetLister(state.PodDisruptionBudgets),
+		daemonSetLister, replicationControllerLister, jobLister, replicaSetLister, statefulSetLister), nil
+}
+
+// NewCloudProvider returns a test cloud provider with the node groups of the cluster state.
+// Node group templates are returned only for node groups which had them when captured. The
+// provider isn't meant to be resized, it should be used in dry-run mode.
+func NewCloudProvider(state *ClusterState) (*testprovider.TestCloudProvider, error) {
+	var templates map[string]*schedulernodeinfo.NodeInfo
+	for _, nodeGroup := range state.NodeGroups {
+		if nodeGroup.Template == nil {
+			continue
+		}
+		if templates == nil {
+			templates = make(map[string]*schedulernodeinfo.NodeInfo)
+		}
+		template := schedulernodeinfo.NewNodeInfo(nodeGroup.TemplatePods...)
+		if err := template.SetNode(nodeGroup.Template); err != nil {
+			return nil, fmt.Errorf("failed to build template of node group %s: %v", nodeGroup.Id, err)
+		}
+		templates[nodeGroup.Id] = template
+	}
+
+	provider := testprovider.NewTestAutoprovisioningCloudProvider(nil, nil, nil, nil, nil, templates)
+	provider.SetResourceLimiter(context.NewResourceLimiterFromAutoscalingOptions(state.Options))
+	nodes := make(map[string]*apiv1.Node)
+	for _, node := range state.Nodes {
+		nodes[node.Name] = node
+	}
+	for _, nodeGroup := range state.NodeGroups {
+		provider.InsertNodeGroup(provider.BuildNodeGroup(nodeGroup.Id, nodeGroup.MinSize, nodeGroup.MaxSize,
+			nodeGroup.TargetSize, nodeGroup.Autoprovisioned, nodeGroup.Id))
+		for _, nodeName := range nodeGroup.Nodes {
+			if node, found := nodes[nodeName]; found {
+				provider.AddNode(nodeGroup.Id, node)
+			}
+		}
+	}
+	return provider, nil
+}
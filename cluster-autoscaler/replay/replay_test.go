@@ -0,0 +1,184 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replay
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/config"
+	"k8s.io/autoscaler/cluster-autoscaler/context"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func buildTestState() *ClusterState {
+	n1 := BuildTestNode("n1", 1000, 1000)
+	SetNodeReadyState(n1, true, time.Time{})
+	n2 := BuildTestNode("n2", 1000, 1000)
+	SetNodeReadyState(n2, false, time.Time{})
+	template := BuildTestNode("ng2-template", 2000, 2000)
+	SetNodeReadyState(template, true, time.Time{})
+
+	p1 := BuildTestPod("p1", 100, 0)
+	p1.Spec.NodeName = "n1"
+	p2 := BuildTestPod("p2", 100, 0)
+	p2.Status.Conditions = []apiv1.PodCondition{{
+		Type:   apiv1.PodScheduled,
+		Status: apiv1.ConditionFalse,
+		Reason: apiv1.PodReasonUnschedulable,
+	}}
+	p3 := BuildTestPod("p3", 100, 0)
+	p3.Spec.NodeName = "n1"
+	p3.Status.Phase = apiv1.PodSucceeded
+
+	return &ClusterState{
+		Time: time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC),
+		Options: config.AutoscalingOptions{
+			MaxCoresTotal:  100,
+			MaxMemoryTotal: 1000,
+			ExpanderName:   "least-waste",
+		},
+		NodeGroups: []NodeGroupState{
+			{Id: "ng1", MinSize: 1, MaxSize: 10, TargetSize: 2, Nodes: []string{"n1", "n2"}},
+			{Id: "ng2", MinSize: 0, MaxSize: 5, TargetSize: 0, Template: template},
+		},
+		Nodes: []*apiv1.Node{n1, n2},
+		Pods:  []*apiv1.Pod{p1, p2, p3},
+	}
+}
+
+func TestWriteAndReadClusterState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replay")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	state := buildTestState()
+	for _, file := range []string{"state.yaml", "state.json"} {
+		path := filepath.Join(dir, file)
+		assert.NoError(t, WriteClusterState(state, path))
+		read, err := ReadClusterState(path)
+		assert.NoError(t, err)
+		assert.True(t, state.Time.Equal(read.Time))
+		assert.Equal(t, state.Options, read.Options)
+		assert.Equal(t, state.NodeGroups[0], read.NodeGroups[0])
+		assert.Equal(t, "ng2-template", read.NodeGroups[1].Template.Name)
+		assert.Equal(t, 2, len(read.Nodes))
+		assert.Equal(t, 3, len(read.Pods))
+	}
+
+	_, err = ReadClusterState(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestNewListerRegistry(t *testing.T) {
+	listers, err := NewListerRegistry(buildTestState())
+	assert.NoError(t, err)
+
+	allNodes, err := listers.AllNodeLister().List()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(allNodes))
+	readyNodes, err := listers.ReadyNodeLister().List()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(readyNodes))
+	assert.Equal(t, "n1", readyNodes[0].Name)
+
+	scheduledPods, err := listers.ScheduledPodLister().List()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(scheduledPods))
+	assert.Equal(t, "p1", scheduledPods[0].Name)
+	unschedulablePods, err := listers.UnschedulablePodLister().List()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(unschedulablePods))
+	assert.Equal(t, "p2", unschedulablePods[0].Name)
+}
+
+func TestNewCloudProvider(t *testing.T) {
+	state := buildTestState()
+	provider, err := NewCloudProvider(state)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, len(provider.NodeGroups()))
+	nodeGroup, err := provider.NodeGroupForNode(state.Nodes[1])
+	assert.NoError(t, err)
+	assert.Equal(t, "ng1", nodeGroup.Id())
+	targetSize, err := nodeGroup.TargetSize()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, targetSize)
+
+	template, err := provider.GetNodeGroup("ng2").TemplateNodeInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, "ng2-template", template.Node().Name)
+
+	resourceLimiter, err := provider.GetResourceLimiter()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), resourceLimiter.GetMax("cpu"))
+}
+
+func TestStateCapturer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replay")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	state := buildTestState()
+	listers, err := NewListerRegistry(state)
+	assert.NoError(t, err)
+	provider, err := NewCloudProvider(state)
+	assert.NoError(t, err)
+	autoscalingContext := &context.AutoscalingContext{
+		AutoscalingOptions:     state.Options,
+		AutoscalingKubeClients: context.AutoscalingKubeClients{ListerRegistry: listers},
+		CloudProvider:          provider,
+	}
+
+	path := filepath.Join(dir, "state.yaml")
+	capturer := NewStateCapturer(path)
+	assert.NoError(t, capturer.CaptureIfRequested(autoscalingContext, state.Time))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	capturer.Request()
+	assert.NoError(t, capturer.CaptureIfRequested(autoscalingContext, state.Time))
+	captured, err := ReadClusterState(path)
+	assert.NoError(t, err)
+	assert.True(t, state.Time.Equal(captured.Time))
+	assert.Equal(t, state.Options, captured.Options)
+	assert.Equal(t, 2, len(captured.Nodes))
+	// Succeeded pods aren't seen by the autoscaler.
+	assert.Equal(t, 2, len(captured.Pods))
+	assert.ElementsMatch(t, []string{"ng1", "ng2"}, []string{captured.NodeGroups[0].Id, captured.NodeGroups[1].Id})
+	for _, nodeGroup := range captured.NodeGroups {
+		if nodeGroup.Id == "ng1" {
+			assert.ElementsMatch(t, []string{"n1", "n2"}, nodeGroup.Nodes)
+			assert.Nil(t, nodeGroup.Template)
+		} else {
+			assert.Empty(t, nodeGroup.Nodes)
+			assert.Equal(t, "ng2-template", nodeGroup.Template.Name)
+		}
+	}
+
+	// Captures are taken once per request.
+	assert.NoError(t, os.Remove(path))
+	assert.NoError(t, capturer.CaptureIfRequested(autoscalingContext, state.Time))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
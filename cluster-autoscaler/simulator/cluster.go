@@ -58,6 +58,37 @@ type NodeToBeRemoved struct {
 	PodsToReschedule []*apiv1.Pod
 }
 
+// UnremovableNode represents a node that can't be removed by CA.
+type UnremovableNode struct {
+	Node   *apiv1.Node
+	Reason UnremovableReason
+	// BlockingPod is set if the node can't be removed because of a pod running on it.
+	BlockingPod *drain.BlockingPod
+}
+
+// UnremovableReason represents a reason why a node can't be removed by CA.
+type UnremovableReason string
+
+const (
+	// ScaleDownDisabledAnnotation - node can't be removed because it has a "scale down disabled" annotation.
+	ScaleDownDisabledAnnotation UnremovableReason = "ScaleDownDisabledAnnotation"
+	// NodeGroupScaleDownDisabled - node can't be removed because scale down is disabled for its node group.
+	NodeGroupScaleDownDisabled UnremovableReason = "NodeGroupScaleDownDisabled"
+	// NotUnderutilized - node can't be removed because it's not underutilized.
+	NotUnderutilized UnremovableReason = "NotUnderutilized"
+	// NoPlaceToMovePods - node can't be removed because there's no place to move its pods to.
+	NoPlaceToMovePods UnremovableReason = "NoPlaceToMovePods"
+	// BlockedByPod - node can't be removed because a pod running on it can't be moved. The reason why it
+	// can't be moved is specified in BlockingPod.
+	BlockedByPod UnremovableReason = "BlockedByPod"
+	// NodeGroupMinSizeReached - node can't be removed because its node group is at its minimal size already.
+	NodeGroupMinSizeReached UnremovableReason = "NodeGroupMinSizeReached"
+	// MinimalResourceLimitExceeded - node can't be removed because it would violate cluster-wide minimal resource limits.
+	MinimalResourceLimitExceeded UnremovableReason = "MinimalResourceLimitExceeded"
+	// UnexpectedError - node can't be removed because of an unexpected error.
+	UnexpectedError UnremovableReason = "UnexpectedError"
+)
+
 // UtilizationInfo contains utilization information for a node.
 type UtilizationInfo struct {
 	CpuUtil float64
@@ -70,53 +101,72 @@ type UtilizationInfo struct {
 }
 
 // FindNodesToRemove finds nodes that can be removed. Returns also an information about good
-// rescheduling location for each of the pods.
-func FindNodesToRemove(candidates []*apiv1.Node, allNodes []*apiv1.Node, pods []*apiv1.Pod,
-	listers kube_util.ListerRegistry, predicateChecker *PredicateChecker, maxCount int,
-	fastCheck bool, oldHints map[string]string, usageTracker *UsageTracker,
+// rescheduling location for each of the pods. Pods are moved to destinationNodes, as they are in
+// clusterSnapshot. If simultaneous is false, every candidate is evaluated independently and clusterSnapshot
+// is left unchanged. Otherwise the returned nodes can all be removed at the same time: removals of the found
+// nodes are kept in clusterSnapshot, so that pods of the next candidates are moved elsewhere, and candidates
+// which received pods of the found nodes are skipped. The caller should fork clusterSnapshot beforehand.
+func FindNodesToRemove(candidates []*apiv1.Node, destinationNodes []*apiv1.Node,
+	listers kube_util.ListerRegistry, clusterSnapshot ClusterSnapshot, predicateChecker *PredicateChecker, maxCount int,
+	fastCheck bool, simultaneous bool, oldHints map[string]string, usageTracker *UsageTracker,
 	timestamp time.Time,
 	podDisruptionBudgets []*policyv1.PodDisruptionBudget,
-) (nodesToRemove []NodeToBeRemoved, unremovableNodes []*apiv1.Node, podReschedulingHints map[string]string, finalError errors.AutoscalerError) {
+) (nodesToRemove []NodeToBeRemoved, unremovableNodes []*UnremovableNode, podReschedulingHints map[string]string, finalError errors.AutoscalerError) {
 
-	nodeNameToNodeInfo := scheduler_util.CreateNodeNameToInfoMap(pods, allNodes)
 	result := make([]NodeToBeRemoved, 0)
-	unremovable := make([]*apiv1.Node, 0)
+	unremovable := make([]*UnremovableNode, 0)
 
 	evaluationType := "Detailed evaluation"
 	if fastCheck {
 		evaluationType = "Fast evaluation"
 	}
 	newHints := make(map[string]string, len(oldHints))
+	// Nodes receiving pods of the nodes found so far, in simultaneous evaluation.
+	destinationsInUse := make(map[string]bool)
 
 candidateloop:
 	for _, node := range candidates {
 		klog.V(2).Infof("%s: %s for removal", evaluationType, node.Name)
+		if destinationsInUse[node.Name] {
+			klog.V(2).Infof("%s: node %s receives pods of other removed nodes, skipping", evaluationType, node.Name)
+			continue candidateloop
+		}
 
 		var podsToRemove []*apiv1.Pod
+		var blockingPod *drain.BlockingPod
 		var err error
 
-		if nodeInfo, found := nodeNameToNodeInfo[node.Name]; found {
+		if nodeInfo, found := clusterSnapshot.GetNodeInfo(node.Name); found {
 			if fastCheck {
-				podsToRemove, err = FastGetPodsToMove(nodeInfo, *skipNodesWithSystemPods, *skipNodesWithLocalStorage,
+				podsToRemove, blockingPod, err = FastGetPodsToMove(nodeInfo, *skipNodesWithSystemPods, *skipNodesWithLocalStorage,
 					podDisruptionBudgets)
 			} else {
-				podsToRemove, err = DetailedGetPodsForMove(nodeInfo, *skipNodesWithSystemPods, *skipNodesWithLocalStorage, listers, int32(*minReplicaCount),
+				podsToRemove, blockingPod, err = DetailedGetPodsForMove(nodeInfo, *skipNodesWithSystemPods, *skipNodesWithLocalStorage, listers, int32(*minReplicaCount),
 					podDisruptionBudgets)
 			}
 			if err != nil {
 				klog.V(2).Infof("%s: node %s cannot be removed: %v", evaluationType, node.Name, err)
-				unremovable = append(unremovable, node)
+				if blockingPod != nil {
+					unremovable = append(unremovable, &UnremovableNode{Node: node, Reason: BlockedByPod, BlockingPod: blockingPod})
+				} else {
+					unremovable = append(unremovable, &UnremovableNode{Node: node, Reason: UnexpectedError})
+				}
 				continue candidateloop
 			}
 		} else {
 			klog.V(2).Infof("%s: nodeInfo for %s not found", evaluationType, node.Name)
-			unremovable = append(unremovable, node)
+			unremovable = append(unremovable, &UnremovableNode{Node: node, Reason: UnexpectedError})
 			continue candidateloop
 		}
-		findProblems := findPlaceFor(node.Name, podsToRemove, allNodes, nodeNameToNodeInfo, predicateChecker, oldHints, newHints,
-			usageTracker, timestamp)
+		findProblems := findPlaceFor(node.Name, podsToRemove, destinationNodes, clusterSnapshot, predicateChecker, oldHints, newHints,
+			usageTracker, timestamp, simultaneous)
 
 		if findProblems == nil {
+			if simultaneous {
+				for _, pod := range podsToRemove {
+					destinationsInUse[newHints[podKey(pod)]] = true
+				}
+			}
 			result = append(result, NodeToBeRemoved{
 				Node:             node,
 				PodsToReschedule: podsToRemove,
@@ -127,7 +177,7 @@ candidateloop:
 			}
 		} else {
 			klog.V(2).Infof("%s: node %s is not suitable for removal: %v", evaluationType, node.Name, findProblems)
-			unremovable = append(unremovable, node)
+			unremovable = append(unremovable, &UnremovableNode{Node: node, Reason: NoPlaceToMovePods})
 		}
 	}
 	return result, unremovable, newHints, nil
@@ -140,7 +190,7 @@ func FindEmptyNodesToRemove(candidates []*apiv1.Node, pods []*apiv1.Pod) []*apiv
 	for _, node := range candidates {
 		if nodeInfo, found := nodeNameToNodeInfo[node.Name]; found {
 			// Should block on all pods.
-			podsToRemove, err := FastGetPodsToMove(nodeInfo, true, true, nil)
+			podsToRemove, _, err := FastGetPodsToMove(nodeInfo, true, true, nil)
 			if err == nil && len(podsToRemove) == 0 {
 				result = append(result, node)
 			}
@@ -217,24 +267,35 @@ func calculateUtilizationOfResource(node *apiv1.Node, nodeInfo *schedulernodeinf
 	return float64(podsRequest.MilliValue()) / float64(nodeAllocatable.MilliValue()), nil
 }
 
-// TODO: We don't need to pass list of nodes here as they are already available in nodeInfos.
-func findPlaceFor(removedNode string, pods []*apiv1.Pod, nodes []*apiv1.Node, nodeInfos map[string]*schedulernodeinfo.NodeInfo,
+// findPlaceFor places pods of the removed node on other nodes in a fork of clusterSnapshot. The fork is
+// committed if keepChanges is true and all pods were placed, and reverted otherwise.
+func findPlaceFor(removedNode string, pods []*apiv1.Pod, nodes []*apiv1.Node, clusterSnapshot ClusterSnapshot,
 	predicateChecker *PredicateChecker, oldHints map[string]string, newHints map[string]string, usageTracker *UsageTracker,
-	timestamp time.Time) error {
+	timestamp time.Time, keepChanges bool) (finalError error) {
 
-	newNodeInfos := make(map[string]*schedulernodeinfo.NodeInfo)
-	for k, v := range nodeInfos {
-		newNodeInfos[k] = v
+	if err := clusterSnapshot.Fork(); err != nil {
+		return err
 	}
-
-	podKey := func(pod *apiv1.Pod) string {
-		return fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)
+	defer func() {
+		if keepChanges && finalError == nil {
+			if err := clusterSnapshot.Commit(); err != nil {
+				finalError = fmt.Errorf("failed to commit cluster snapshot after simulating removal of %s: %v", removedNode, err)
+			}
+			return
+		}
+		if err := clusterSnapshot.Revert(); err != nil {
+			klog.Errorf("Failed to revert cluster snapshot after simulating removal of %s: %v", removedNode, err)
+		}
+	}()
+	// Pods of the removed node mustn't affect predicates of their new placement.
+	if err := clusterSnapshot.RemoveNode(removedNode); err != nil {
+		klog.V(4).Infof("Failed to remove %s from cluster snapshot: %v", removedNode, err)
 	}
 
 	loggingQuota := glogx.PodsLoggingQuota()
 
 	tryNodeForPod := func(nodename string, pod *apiv1.Pod, predicateMeta predicates.PredicateMetadata) bool {
-		nodeInfo, found := newNodeInfos[nodename]
+		nodeInfo, found := clusterSnapshot.GetNodeInfo(nodename)
 		if found {
 			if nodeInfo.Node() == nil {
 				// NodeInfo is generated based on pods. It is possible that node is removed from
@@ -247,13 +308,11 @@ func findPlaceFor(removedNode string, pods []*apiv1.Pod, nodes []*apiv1.Node, no
 			if err != nil {
 				glogx.V(4).UpTo(loggingQuota).Infof("Evaluation %s for %s/%s -> %v", nodename, pod.Namespace, pod.Name, err.VerboseError())
 			} else {
-				// TODO(mwielgus): Optimize it.
 				klog.V(4).Infof("Pod %s/%s can be moved to %s", pod.Namespace, pod.Name, nodename)
-				podsOnNode := nodeInfo.Pods()
-				podsOnNode = append(podsOnNode, pod)
-				newNodeInfo := schedulernodeinfo.NewNodeInfo(podsOnNode...)
-				newNodeInfo.SetNode(nodeInfo.Node())
-				newNodeInfos[nodename] = newNodeInfo
+				if err := clusterSnapshot.AddPod(pod, nodename); err != nil {
+					klog.Errorf("Failed to add pod %s/%s to %s in cluster snapshot: %v", pod.Namespace, pod.Name, nodename, err)
+					return false
+				}
 				newHints[podKey(pod)] = nodename
 				return true
 			}
@@ -273,7 +332,7 @@ func findPlaceFor(removedNode string, pods []*apiv1.Pod, nodes []*apiv1.Node, no
 
 		foundPlace := false
 		targetNode := ""
-		predicateMeta := predicateChecker.GetPredicateMetadata(pod, newNodeInfos)
+		predicateMeta := predicateChecker.GetPredicateMetadata(pod, clusterSnapshot.NodeInfos())
 		loggingQuota.Reset()
 
 		klog.V(5).Infof("Looking for place for %s/%s", pod.Namespace, pod.Name)
@@ -307,6 +366,10 @@ func findPlaceFor(removedNode string, pods []*apiv1.Pod, nodes []*apiv1.Node, no
 	return nil
 }
 
+func podKey(pod *apiv1.Pod) string {
+	return fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)
+}
+
 func shuffleNodes(nodes []*apiv1.Node) []*apiv1.Node {
 	result := make([]*apiv1.Node, len(nodes))
 	for i := range nodes {
@@ -0,0 +1,264 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"errors"
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
+)
+
+// ClusterSnapshot is an abstraction of the cluster state used in simulations. It's built once
+// per autoscaler loop and shared by all simulations in that loop. Simulations make their changes
+// on top of a fork of the snapshot and revert them once done, instead of copying the state.
+type ClusterSnapshot interface {
+	// AddNode adds a node, without any pods, to the snapshot.
+	AddNode(node *apiv1.Node) error
+	// AddNodes adds nodes, without any pods, to the snapshot.
+	AddNodes(nodes []*apiv1.Node) error
+	// RemoveNode removes a node, with all its pods, from the snapshot.
+	RemoveNode(nodeName string) error
+	// AddPod adds a pod to the given node of the snapshot.
+	AddPod(pod *apiv1.Pod, nodeName string) error
+	// RemovePod removes a pod from the given node of the snapshot.
+	RemovePod(namespace string, podName string, nodeName string) error
+
+	// GetNodeInfo returns the NodeInfo of the given node, if it is in the snapshot.
+	// The returned NodeInfo must not be modified.
+	GetNodeInfo(nodeName string) (*schedulernodeinfo.NodeInfo, bool)
+	// NodeInfos returns NodeInfos of all nodes in the snapshot, keyed by node name. The returned map
+	// reflects later changes of the snapshot and must not be modified.
+	NodeInfos() map[string]*schedulernodeinfo.NodeInfo
+
+	// Fork creates a fork of the snapshot state. All modifications can later be reverted
+	// to the moment of forking with Revert, or kept with Commit. Forks can be nested.
+	Fork() error
+	// Revert reverts the snapshot state to the moment of the last Fork.
+	Revert() error
+	// Commit keeps the changes made since the last Fork, as if they were made before it.
+	Commit() error
+	// Clear removes all nodes and pods, and all forks, from the snapshot.
+	Clear()
+}
+
+var errNoFork = errors.New("no fork to revert or commit")
+
+// BasicClusterSnapshot is a ClusterSnapshot keeping a single map of NodeInfos. Every fork
+// records the NodeInfos it replaced, so that reverting it only restores the nodes which
+// actually changed. NodeInfos are copied at most once per fork, the first time they change.
+type BasicClusterSnapshot struct {
+	nodeInfos map[string]*schedulernodeinfo.NodeInfo
+	// forks holds, for every fork, the NodeInfos replaced in it keyed by node name.
+	// A nil NodeInfo means that the node was not in the snapshot when forking.
+	forks []map[string]*schedulernodeinfo.NodeInfo
+}
+
+// NewBasicClusterSnapshot creates an empty BasicClusterSnapshot.
+func NewBasicClusterSnapshot() *BasicClusterSnapshot {
+	snapshot := &BasicClusterSnapshot{}
+	snapshot.Clear()
+	return snapshot
+}
+
+// AddNode adds a node, without any pods, to the snapshot.
+func (snapshot *BasicClusterSnapshot) AddNode(node *apiv1.Node) error {
+	if _, found := snapshot.nodeInfos[node.Name]; found {
+		return fmt.Errorf("node %s already in snapshot", node.Name)
+	}
+	nodeInfo := schedulernodeinfo.NewNodeInfo()
+	if err := nodeInfo.SetNode(node); err != nil {
+		return fmt.Errorf("cannot set node %s in NodeInfo: %v", node.Name, err)
+	}
+	snapshot.record(node.Name)
+	snapshot.nodeInfos[node.Name] = nodeInfo
+	return nil
+}
+
+// AddNodes adds nodes, without any pods, to the snapshot.
+func (snapshot *BasicClusterSnapshot) AddNodes(nodes []*apiv1.Node) error {
+	for _, node := range nodes {
+		if err := snapshot.AddNode(node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveNode removes a node, with all its pods, from the snapshot.
+func (snapshot *BasicClusterSnapshot) RemoveNode(nodeName string) error {
+	if _, found := snapshot.nodeInfos[nodeName]; !found {
+		return fmt.Errorf("node %s not found in snapshot", nodeName)
+	}
+	snapshot.record(nodeName)
+	delete(snapshot.nodeInfos, nodeName)
+	return nil
+}
+
+// AddPod adds a pod to the given node of the snapshot.
+func (snapshot *BasicClusterSnapshot) AddPod(pod *apiv1.Pod, nodeName string) error {
+	nodeInfo, err := snapshot.modifiableNodeInfo(nodeName)
+	if err != nil {
+		return err
+	}
+	nodeInfo.AddPod(pod)
+	return nil
+}
+
+// RemovePod removes a pod from the given node of the snapshot.
+func (snapshot *BasicClusterSnapshot) RemovePod(namespace string, podName string, nodeName string) error {
+	nodeInfo, found := snapshot.nodeInfos[nodeName]
+	if !found {
+		return fmt.Errorf("node %s not found in snapshot", nodeName)
+	}
+	// NodeInfo.RemovePod matches pods by UID, which isn't set for pods created in simulations.
+	// The NodeInfo is rebuilt from the remaining pods instead.
+	found = false
+	pods := make([]*apiv1.Pod, 0, len(nodeInfo.Pods()))
+	for _, pod := range nodeInfo.Pods() {
+		if pod.Namespace == namespace && pod.Name == podName {
+			found = true
+		} else {
+			pods = append(pods, pod)
+		}
+	}
+	if !found {
+		return fmt.Errorf("pod %s/%s not found on node %s in snapshot", namespace, podName, nodeName)
+	}
+	newNodeInfo := schedulernodeinfo.NewNodeInfo(pods...)
+	if err := newNodeInfo.SetNode(nodeInfo.Node()); err != nil {
+		return fmt.Errorf("cannot set node %s in NodeInfo: %v", nodeName, err)
+	}
+	snapshot.record(nodeName)
+	snapshot.nodeInfos[nodeName] = newNodeInfo
+	return nil
+}
+
+// GetNodeInfo returns the NodeInfo of the given node, if it is in the snapshot.
+func (snapshot *BasicClusterSnapshot) GetNodeInfo(nodeName string) (*schedulernodeinfo.NodeInfo, bool) {
+	nodeInfo, found := snapshot.nodeInfos[nodeName]
+	return nodeInfo, found
+}
+
+// NodeInfos returns NodeInfos of all nodes in the snapshot, keyed by node name.
+func (snapshot *BasicClusterSnapshot) NodeInfos() map[string]*schedulernodeinfo.NodeInfo {
+	return snapshot.nodeInfos
+}
+
+// Fork creates a fork of the snapshot state.
+func (snapshot *BasicClusterSnapshot) Fork() error {
+	snapshot.forks = append(snapshot.forks, make(map[string]*schedulernodeinfo.NodeInfo))
+	return nil
+}
+
+// Revert reverts the snapshot state to the moment of the last Fork.
+func (snapshot *BasicClusterSnapshot) Revert() error {
+	if len(snapshot.forks) == 0 {
+		return errNoFork
+	}
+	last := snapshot.forks[len(snapshot.forks)-1]
+	snapshot.forks = snapshot.forks[:len(snapshot.forks)-1]
+	for nodeName, nodeInfo := range last {
+		if nodeInfo == nil {
+			delete(snapshot.nodeInfos, nodeName)
+		} else {
+			snapshot.nodeInfos[nodeName] = nodeInfo
+		}
+	}
+	return nil
+}
+
+// Commit keeps the changes made since the last Fork.
+func (snapshot *BasicClusterSnapshot) Commit() error {
+	if len(snapshot.forks) == 0 {
+		return errNoFork
+	}
+	last := snapshot.forks[len(snapshot.forks)-1]
+	snapshot.forks = snapshot.forks[:len(snapshot.forks)-1]
+	if len(snapshot.forks) == 0 {
+		return nil
+	}
+	// The parent fork has to be able to restore the state from before the committed fork.
+	parent := snapshot.forks[len(snapshot.forks)-1]
+	for nodeName, nodeInfo := range last {
+		if _, found := parent[nodeName]; !found {
+			parent[nodeName] = nodeInfo
+		}
+	}
+	return nil
+}
+
+// Clear removes all nodes and pods, and all forks, from the snapshot.
+func (snapshot *BasicClusterSnapshot) Clear() {
+	snapshot.nodeInfos = make(map[string]*schedulernodeinfo.NodeInfo)
+	snapshot.forks = nil
+}
+
+// record saves the current NodeInfo of the node in the last fork, unless it was already saved.
+func (snapshot *BasicClusterSnapshot) record(nodeName string) {
+	if len(snapshot.forks) == 0 {
+		return
+	}
+	last := snapshot.forks[len(snapshot.forks)-1]
+	if _, found := last[nodeName]; !found {
+		last[nodeName] = snapshot.nodeInfos[nodeName]
+	}
+}
+
+// modifiableNodeInfo returns a NodeInfo of the node which can be modified without affecting
+// the state saved in forks. It's copied if the node wasn't changed since the last Fork.
+func (snapshot *BasicClusterSnapshot) modifiableNodeInfo(nodeName string) (*schedulernodeinfo.NodeInfo, error) {
+	nodeInfo, found := snapshot.nodeInfos[nodeName]
+	if !found {
+		return nil, fmt.Errorf("node %s not found in snapshot", nodeName)
+	}
+	if len(snapshot.forks) == 0 {
+		return nodeInfo, nil
+	}
+	if _, found := snapshot.forks[len(snapshot.forks)-1][nodeName]; found {
+		// Already replaced in this fork, the current NodeInfo isn't saved anywhere.
+		return nodeInfo, nil
+	}
+	snapshot.record(nodeName)
+	nodeInfo = nodeInfo.Clone()
+	snapshot.nodeInfos[nodeName] = nodeInfo
+	return nodeInfo, nil
+}
+
+// InitializeClusterSnapshot clears the snapshot and adds the given nodes and pods to it. Pods are
+// added to the node they are bound to or, if they wait for preemption, to their nominated node.
+// Pods of nodes which are not on the list are skipped.
+func InitializeClusterSnapshot(snapshot ClusterSnapshot, nodes []*apiv1.Node, pods []*apiv1.Pod) error {
+	snapshot.Clear()
+	if err := snapshot.AddNodes(nodes); err != nil {
+		return err
+	}
+	for _, pod := range pods {
+		nodeName := pod.Spec.NodeName
+		if nodeName == "" {
+			nodeName = pod.Status.NominatedNodeName
+		}
+		if _, found := snapshot.GetNodeInfo(nodeName); !found {
+			continue
+		}
+		if err := snapshot.AddPod(pod, nodeName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"sort"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// snapshotState returns names of pods on every node of the snapshot.
+func snapshotState(snapshot ClusterSnapshot) map[string][]string {
+	state := make(map[string][]string)
+	for nodeName, nodeInfo := range snapshot.NodeInfos() {
+		pods := make([]string, 0)
+		for _, pod := range nodeInfo.Pods() {
+			pods = append(pods, pod.Name)
+		}
+		sort.Strings(pods)
+		state[nodeName] = pods
+	}
+	return state
+}
+
+func TestInitializeClusterSnapshot(t *testing.T) {
+	n1 := BuildTestNode("n1", 1000, 1000)
+	n2 := BuildTestNode("n2", 1000, 1000)
+	p1 := BuildTestPod("p1", 100, 100)
+	p1.Spec.NodeName = "n1"
+	p2 := BuildTestPod("p2", 100, 100)
+	p2.Status.NominatedNodeName = "n2"
+	p3 := BuildTestPod("p3", 100, 100)
+	p3.Spec.NodeName = "n3"
+	p4 := BuildTestPod("p4", 100, 100)
+
+	snapshot := NewBasicClusterSnapshot()
+	assert.NoError(t, snapshot.AddNode(BuildTestNode("old", 1000, 1000)))
+	assert.NoError(t, InitializeClusterSnapshot(snapshot, []*apiv1.Node{n1, n2}, []*apiv1.Pod{p1, p2, p3, p4}))
+	assert.Equal(t, map[string][]string{"n1": {"p1"}, "n2": {"p2"}}, snapshotState(snapshot))
+
+	nodeInfo, found := snapshot.GetNodeInfo("n1")
+	assert.True(t, found)
+	assert.Equal(t, n1, nodeInfo.Node())
+	_, found = snapshot.GetNodeInfo("n3")
+	assert.False(t, found)
+}
+
+func TestClusterSnapshotModifications(t *testing.T) {
+	snapshot := NewBasicClusterSnapshot()
+	assert.NoError(t, snapshot.AddNodes([]*apiv1.Node{BuildTestNode("n1", 1000, 1000), BuildTestNode("n2", 1000, 1000)}))
+	assert.Error(t, snapshot.AddNode(BuildTestNode("n1", 1000, 1000)))
+
+	assert.NoError(t, snapshot.AddPod(BuildTestPod("p1", 100, 100), "n1"))
+	assert.NoError(t, snapshot.AddPod(BuildTestPod("p2", 100, 100), "n1"))
+	assert.Error(t, snapshot.AddPod(BuildTestPod("p3", 100, 100), "n3"))
+	assert.Equal(t, map[string][]string{"n1": {"p1", "p2"}, "n2": {}}, snapshotState(snapshot))
+
+	assert.NoError(t, snapshot.RemovePod("default", "p1", "n1"))
+	assert.Error(t, snapshot.RemovePod("default", "p1", "n1"))
+	assert.Error(t, snapshot.RemovePod("default", "p2", "n2"))
+	assert.Equal(t, map[string][]string{"n1": {"p2"}, "n2": {}}, snapshotState(snapshot))
+
+	assert.NoError(t, snapshot.RemoveNode("n1"))
+	assert.Error(t, snapshot.RemoveNode("n1"))
+	assert.Equal(t, map[string][]string{"n2": {}}, snapshotState(snapshot))
+
+	snapshot.Clear()
+	assert.Empty(t, snapshot.NodeInfos())
+}
+
+func TestClusterSnapshotForkRevert(t *testing.T) {
+	snapshot := NewBasicClusterSnapshot()
+	assert.NoError(t, InitializeClusterSnapshot(snapshot,
+		[]*apiv1.Node{BuildTestNode("n1", 1000, 1000), BuildTestNode("n2", 1000, 1000)}, nil))
+	assert.NoError(t, snapshot.AddPod(BuildTestPod("p1", 100, 100), "n1"))
+	initialState := snapshotState(snapshot)
+	n1Info, _ := snapshot.GetNodeInfo("n1")
+
+	assert.Error(t, snapshot.Revert())
+	assert.Error(t, snapshot.Commit())
+
+	assert.NoError(t, snapshot.Fork())
+	assert.NoError(t, snapshot.AddPod(BuildTestPod("p2", 100, 100), "n1"))
+	assert.NoError(t, snapshot.AddPod(BuildTestPod("p3", 100, 100), "n1"))
+	assert.NoError(t, snapshot.RemovePod("default", "p1", "n1"))
+	assert.NoError(t, snapshot.RemoveNode("n2"))
+	assert.NoError(t, snapshot.AddNode(BuildTestNode("n3", 1000, 1000)))
+	assert.NoError(t, snapshot.AddPod(BuildTestPod("p4", 100, 100), "n3"))
+	assert.Equal(t, map[string][]string{"n1": {"p2", "p3"}, "n3": {"p4"}}, snapshotState(snapshot))
+	// NodeInfos from before the fork are not modified.
+	assert.Equal(t, 1, len(n1Info.Pods()))
+
+	assert.NoError(t, snapshot.Revert())
+	assert.Equal(t, initialState, snapshotState(snapshot))
+	n1InfoAfterRevert, _ := snapshot.GetNodeInfo("n1")
+	assert.True(t, n1Info == n1InfoAfterRevert)
+}
+
+func TestClusterSnapshotNestedForks(t *testing.T) {
+	snapshot := NewBasicClusterSnapshot()
+	assert.NoError(t, InitializeClusterSnapshot(snapshot, []*apiv1.Node{BuildTestNode("n1", 1000, 1000)}, nil))
+	initialState := snapshotState(snapshot)
+
+	assert.NoError(t, snapshot.Fork())
+	assert.NoError(t, snapshot.AddPod(BuildTestPod("p1", 100, 100), "n1"))
+	firstForkState := snapshotState(snapshot)
+
+	assert.NoError(t, snapshot.Fork())
+	assert.NoError(t, snapshot.AddPod(BuildTestPod("p2", 100, 100), "n1"))
+	assert.NoError(t, snapshot.Revert())
+	assert.Equal(t, firstForkState, snapshotState(snapshot))
+
+	assert.NoError(t, snapshot.Fork())
+	assert.NoError(t, snapshot.AddPod(BuildTestPod("p3", 100, 100), "n1"))
+	assert.NoError(t, snapshot.AddNode(BuildTestNode("n2", 1000, 1000)))
+	assert.NoError(t, snapshot.Commit())
+	assert.Equal(t, map[string][]string{"n1": {"p1", "p3"}, "n2": {}}, snapshotState(snapshot))
+
+	// Reverting the first fork reverts the committed changes as well.
+	assert.NoError(t, snapshot.Revert())
+	assert.Equal(t, initialState, snapshotState(snapshot))
+
+	assert.NoError(t, snapshot.Fork())
+	assert.NoError(t, snapshot.AddPod(BuildTestPod("p4", 100, 100), "n1"))
+	assert.NoError(t, snapshot.Commit())
+	assert.Equal(t, map[string][]string{"n1": {"p4"}}, snapshotState(snapshot))
+	assert.Error(t, snapshot.Revert())
+}
@@ -23,6 +23,7 @@ import (
 
 	apiv1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/drain"
 	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
 	"k8s.io/kubernetes/pkg/kubelet/types"
 	schedulernodeinfo "k8s.io/kubernetes/pkg/scheduler/nodeinfo"
@@ -99,32 +100,36 @@ func TestFindPlaceAllOk(t *testing.T) {
 	new1 := BuildTestPod("p2", 600, 500000)
 	new2 := BuildTestPod("p3", 500, 500000)
 
-	nodeInfos := map[string]*schedulernodeinfo.NodeInfo{
-		"n1": schedulernodeinfo.NewNodeInfo(pod1),
-		"n2": schedulernodeinfo.NewNodeInfo(),
-	}
 	node1 := BuildTestNode("n1", 1000, 2000000)
 	SetNodeReadyState(node1, true, time.Time{})
 	node2 := BuildTestNode("n2", 1000, 2000000)
 	SetNodeReadyState(node2, true, time.Time{})
-	nodeInfos["n1"].SetNode(node1)
-	nodeInfos["n2"].SetNode(node2)
+	pod1.Spec.NodeName = "n1"
+
+	clusterSnapshot := NewBasicClusterSnapshot()
+	err := InitializeClusterSnapshot(clusterSnapshot, []*apiv1.Node{node1, node2}, []*apiv1.Pod{pod1})
+	assert.NoError(t, err)
 
 	oldHints := make(map[string]string)
 	newHints := make(map[string]string)
 	tracker := NewUsageTracker()
 
-	err := findPlaceFor(
+	err = findPlaceFor(
 		"x",
 		[]*apiv1.Pod{new1, new2},
 		[]*apiv1.Node{node1, node2},
-		nodeInfos, NewTestPredicateChecker(),
-		oldHints, newHints, tracker, time.Now())
+		clusterSnapshot, NewTestPredicateChecker(),
+		oldHints, newHints, tracker, time.Now(), false)
 
 	assert.Len(t, newHints, 2)
 	assert.Contains(t, newHints, new1.Namespace+"/"+new1.Name)
 	assert.Contains(t, newHints, new2.Namespace+"/"+new2.Name)
 	assert.NoError(t, err)
+	// Placements are simulated in a fork of the snapshot, which is reverted.
+	n1Info, _ := clusterSnapshot.GetNodeInfo("n1")
+	n2Info, _ := clusterSnapshot.GetNodeInfo("n2")
+	assert.Equal(t, []*apiv1.Pod{pod1}, n1Info.Pods())
+	assert.Empty(t, n2Info.Pods())
 }
 
 func TestFindPlaceAllBas(t *testing.T) {
@@ -133,11 +138,6 @@ func TestFindPlaceAllBas(t *testing.T) {
 	new2 := BuildTestPod("p3", 500, 500000)
 	new3 := BuildTestPod("p4", 700, 500000)
 
-	nodeInfos := map[string]*schedulernodeinfo.NodeInfo{
-		"n1":   schedulernodeinfo.NewNodeInfo(pod1),
-		"n2":   schedulernodeinfo.NewNodeInfo(),
-		"nbad": schedulernodeinfo.NewNodeInfo(),
-	}
 	nodebad := BuildTestNode("nbad", 1000, 2000000)
 	node1 := BuildTestNode("n1", 1000, 2000000)
 	SetNodeReadyState(node1, true, time.Time{})
@@ -145,20 +145,22 @@ func TestFindPlaceAllBas(t *testing.T) {
 	node2 := BuildTestNode("n2", 1000, 2000000)
 	SetNodeReadyState(node2, true, time.Time{})
 
-	nodeInfos["n1"].SetNode(node1)
-	nodeInfos["n2"].SetNode(node2)
-	nodeInfos["nbad"].SetNode(nodebad)
+	pod1.Spec.NodeName = "n1"
+
+	clusterSnapshot := NewBasicClusterSnapshot()
+	err := InitializeClusterSnapshot(clusterSnapshot, []*apiv1.Node{nodebad, node1, node2}, []*apiv1.Pod{pod1})
+	assert.NoError(t, err)
 
 	oldHints := make(map[string]string)
 	newHints := make(map[string]string)
 	tracker := NewUsageTracker()
 
-	err := findPlaceFor(
+	err = findPlaceFor(
 		"nbad",
 		[]*apiv1.Pod{new1, new2, new3},
 		[]*apiv1.Node{nodebad, node1, node2},
-		nodeInfos, NewTestPredicateChecker(),
-		oldHints, newHints, tracker, time.Now())
+		clusterSnapshot, NewTestPredicateChecker(),
+		oldHints, newHints, tracker, time.Now(), false)
 
 	assert.Error(t, err)
 	assert.True(t, len(newHints) == 2)
@@ -169,28 +171,27 @@ func TestFindPlaceAllBas(t *testing.T) {
 func TestFindNone(t *testing.T) {
 	pod1 := BuildTestPod("p1", 300, 500000)
 
-	nodeInfos := map[string]*schedulernodeinfo.NodeInfo{
-		"n1": schedulernodeinfo.NewNodeInfo(pod1),
-		"n2": schedulernodeinfo.NewNodeInfo(),
-	}
 	node1 := BuildTestNode("n1", 1000, 2000000)
 	SetNodeReadyState(node1, true, time.Time{})
 
 	node2 := BuildTestNode("n2", 1000, 2000000)
 	SetNodeReadyState(node2, true, time.Time{})
 
-	nodeInfos["n1"].SetNode(node1)
-	nodeInfos["n2"].SetNode(node2)
+	pod1.Spec.NodeName = "n1"
+
+	clusterSnapshot := NewBasicClusterSnapshot()
+	err := InitializeClusterSnapshot(clusterSnapshot, []*apiv1.Node{node1, node2}, []*apiv1.Pod{pod1})
+	assert.NoError(t, err)
 
-	err := findPlaceFor(
+	err = findPlaceFor(
 		"x",
 		[]*apiv1.Pod{},
 		[]*apiv1.Node{node1, node2},
-		nodeInfos, NewTestPredicateChecker(),
+		clusterSnapshot, NewTestPredicateChecker(),
 		make(map[string]string),
 		make(map[string]string),
 		NewUsageTracker(),
-		time.Now())
+		time.Now(), false)
 	assert.NoError(t, err)
 }
 
@@ -238,7 +239,7 @@ type findNodesToRemoveTestConfig struct {
 	candidates  []*apiv1.Node
 	allNodes    []*apiv1.Node
 	toRemove    []NodeToBeRemoved
-	unremovable []*apiv1.Node
+	unremovable []*UnremovableNode
 }
 
 func TestFindNodesToRemove(t *testing.T) {
@@ -291,7 +292,7 @@ func TestFindNodesToRemove(t *testing.T) {
 			candidates:  []*apiv1.Node{emptyNode},
 			allNodes:    []*apiv1.Node{emptyNode},
 			toRemove:    []NodeToBeRemoved{emptyNodeToRemove},
-			unremovable: []*apiv1.Node{},
+			unremovable: []*UnremovableNode{},
 		},
 		// just a drainable node, but nowhere for pods to go to
 		{
@@ -299,15 +300,16 @@ func TestFindNodesToRemove(t *testing.T) {
 			candidates:  []*apiv1.Node{drainableNode},
 			allNodes:    []*apiv1.Node{drainableNode},
 			toRemove:    []NodeToBeRemoved{},
-			unremovable: []*apiv1.Node{drainableNode},
+			unremovable: []*UnremovableNode{{Node: drainableNode, Reason: NoPlaceToMovePods}},
 		},
 		// drainable node, and a mostly empty node that can take its pods
 		{
-			name:        "drainable node, and a mostly empty node that can take its pods",
-			candidates:  []*apiv1.Node{drainableNode, nonDrainableNode},
-			allNodes:    []*apiv1.Node{drainableNode, nonDrainableNode},
-			toRemove:    []NodeToBeRemoved{drainableNodeToRemove},
-			unremovable: []*apiv1.Node{nonDrainableNode},
+			name:       "drainable node, and a mostly empty node that can take its pods",
+			candidates: []*apiv1.Node{drainableNode, nonDrainableNode},
+			allNodes:   []*apiv1.Node{drainableNode, nonDrainableNode},
+			toRemove:   []NodeToBeRemoved{drainableNodeToRemove},
+			unremovable: []*UnremovableNode{{Node: nonDrainableNode, Reason: BlockedByPod,
+				BlockingPod: &drain.BlockingPod{Pod: pod3, Reason: drain.NotReplicated}}},
 		},
 		// drainable node, and a full node that cannot fit anymore pods
 		{
@@ -315,7 +317,7 @@ func TestFindNodesToRemove(t *testing.T) {
 			candidates:  []*apiv1.Node{drainableNode},
 			allNodes:    []*apiv1.Node{drainableNode, fullNode},
 			toRemove:    []NodeToBeRemoved{},
-			unremovable: []*apiv1.Node{drainableNode},
+			unremovable: []*UnremovableNode{{Node: drainableNode, Reason: NoPlaceToMovePods}},
 		},
 		// 4 nodes, 1 empty, 1 drainable
 		{
@@ -323,14 +325,18 @@ func TestFindNodesToRemove(t *testing.T) {
 			candidates:  []*apiv1.Node{emptyNode, drainableNode},
 			allNodes:    []*apiv1.Node{emptyNode, drainableNode, fullNode, nonDrainableNode},
 			toRemove:    []NodeToBeRemoved{emptyNodeToRemove, drainableNodeToRemove},
-			unremovable: []*apiv1.Node{},
+			unremovable: []*UnremovableNode{},
 		},
 	}
 
+	clusterSnapshot := NewBasicClusterSnapshot()
+
 	for _, test := range tests {
+		err := InitializeClusterSnapshot(clusterSnapshot, test.allNodes, pods)
+		assert.NoError(t, err)
 		toRemove, unremovable, _, err := FindNodesToRemove(
-			test.candidates, test.allNodes, pods, nil,
-			predicateChecker, len(test.allNodes), true, map[string]string{},
+			test.candidates, test.allNodes, nil, clusterSnapshot,
+			predicateChecker, len(test.allNodes), true, false, map[string]string{},
 			tracker, time.Now(), []*policyv1.PodDisruptionBudget{})
 		assert.NoError(t, err)
 		fmt.Printf("Test scenario: %s, found len(toRemove)=%v, expected len(test.toRemove)=%v\n", test.name, len(toRemove), len(test.toRemove))
@@ -339,3 +345,95 @@ func TestFindNodesToRemove(t *testing.T) {
 	}
 
 }
+
+func TestFindNodesToRemoveSimultaneously(t *testing.T) {
+	// Each of n1 and n2 has a pod which fits only on n3, and only one of them at a time.
+	n1 := BuildTestNode("n1", 1000, 2000000)
+	n2 := BuildTestNode("n2", 1000, 2000000)
+	n3 := BuildTestNode("n3", 1000, 2000000)
+	// n4 has a pod which fits only on n5, which has a pod of its own.
+	n4 := BuildTestNode("n4", 1000, 2000000)
+	n5 := BuildTestNode("n5", 1000, 2000000)
+	for _, node := range []*apiv1.Node{n1, n2, n3, n4, n5} {
+		SetNodeReadyState(node, true, time.Time{})
+	}
+
+	ownerRefs := GenerateOwnerReferences("rs", "ReplicaSet", "extensions/v1beta1", "")
+	p1 := BuildTestPod("p1", 600, 100000)
+	p1.OwnerReferences = ownerRefs
+	p1.Spec.NodeName = "n1"
+	p2 := BuildTestPod("p2", 600, 100000)
+	p2.OwnerReferences = ownerRefs
+	p2.Spec.NodeName = "n2"
+	p3 := BuildTestPod("p3", 100, 100000)
+	p3.Spec.NodeName = "n3"
+	p4 := BuildTestPod("p4", 700, 100000)
+	p4.OwnerReferences = ownerRefs
+	p4.Spec.NodeName = "n4"
+	p5 := BuildTestPod("p5", 100, 100000)
+	p5.OwnerReferences = ownerRefs
+	p5.Spec.NodeName = "n5"
+	pods := []*apiv1.Pod{p1, p2, p3, p4, p5}
+
+	tests := []struct {
+		name         string
+		candidates   []*apiv1.Node
+		allNodes     []*apiv1.Node
+		simultaneous bool
+		toRemove     []NodeToBeRemoved
+		unremovable  []*UnremovableNode
+	}{
+		{
+			name:         "independent evaluation, both nodes can be removed",
+			candidates:   []*apiv1.Node{n1, n2},
+			allNodes:     []*apiv1.Node{n1, n2, n3},
+			simultaneous: false,
+			toRemove: []NodeToBeRemoved{
+				{Node: n1, PodsToReschedule: []*apiv1.Pod{p1}},
+				{Node: n2, PodsToReschedule: []*apiv1.Pod{p2}},
+			},
+			unremovable: []*UnremovableNode{},
+		},
+		{
+			name:         "simultaneous evaluation, only one node can be removed",
+			candidates:   []*apiv1.Node{n1, n2},
+			allNodes:     []*apiv1.Node{n1, n2, n3},
+			simultaneous: true,
+			toRemove: []NodeToBeRemoved{
+				{Node: n1, PodsToReschedule: []*apiv1.Pod{p1}},
+			},
+			unremovable: []*UnremovableNode{{Node: n2, Reason: NoPlaceToMovePods}},
+		},
+		{
+			name:         "simultaneous evaluation, destination node isn't removed",
+			candidates:   []*apiv1.Node{n4, n5},
+			allNodes:     []*apiv1.Node{n4, n5},
+			simultaneous: true,
+			toRemove: []NodeToBeRemoved{
+				{Node: n4, PodsToReschedule: []*apiv1.Pod{p4}},
+			},
+			unremovable: []*UnremovableNode{},
+		},
+	}
+
+	clusterSnapshot := NewBasicClusterSnapshot()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := InitializeClusterSnapshot(clusterSnapshot, test.allNodes, pods)
+			assert.NoError(t, err)
+			assert.NoError(t, clusterSnapshot.Fork())
+			toRemove, unremovable, _, err := FindNodesToRemove(
+				test.candidates, test.allNodes, nil, clusterSnapshot,
+				NewTestPredicateChecker(), len(test.allNodes), true, test.simultaneous, map[string]string{},
+				NewUsageTracker(), time.Now(), []*policyv1.PodDisruptionBudget{})
+			assert.NoError(t, err)
+			assert.Equal(t, test.toRemove, toRemove)
+			assert.Equal(t, test.unremovable, unremovable)
+			assert.NoError(t, clusterSnapshot.Revert())
+			for _, node := range test.allNodes {
+				_, found := clusterSnapshot.GetNodeInfo(node.Name)
+				assert.True(t, found)
+			}
+		})
+	}
+}
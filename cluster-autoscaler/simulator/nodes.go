@@ -42,7 +42,7 @@ func GetRequiredPodsForNode(nodename string, listers kube_util.ListerRegistry) (
 		}
 	}
 
-	podsToRemoveList, err := drain.GetPodsForDeletionOnNodeDrain(
+	podsToRemoveList, _, err := drain.GetPodsForDeletionOnNodeDrain(
 		allPods,
 		[]*policyv1.PodDisruptionBudget{}, // PDBs are irrelevant when considering new node.
 		true,                              // Force all removals.
@@ -29,8 +29,47 @@ type Backoff interface {
 	Backoff(nodeGroup cloudprovider.NodeGroup, nodeInfo *schedulernodeinfo.NodeInfo, errorClass cloudprovider.InstanceErrorClass, errorCode string, currentTime time.Time) time.Time
 	// IsBackedOff returns true if execution is backed off for the given node group.
 	IsBackedOff(nodeGroup cloudprovider.NodeGroup, nodeInfo *schedulernodeinfo.NodeInfo, currentTime time.Time) bool
+	// BackoffStatus returns the current backoff status of the given node group.
+	BackoffStatus(nodeGroup cloudprovider.NodeGroup, nodeInfo *schedulernodeinfo.NodeInfo, currentTime time.Time) Status
 	// RemoveBackoff removes backoff data for the given node group.
 	RemoveBackoff(nodeGroup cloudprovider.NodeGroup, nodeInfo *schedulernodeinfo.NodeInfo)
 	// RemoveStaleBackoffData removes stale backoff data.
 	RemoveStaleBackoffData(currentTime time.Time)
 }
+
+// Status describes the backoff of a node group.
+type Status struct {
+	// IsBackedOff tells if execution is currently backed off.
+	IsBackedOff bool
+	// BackoffUntil is the time till execution is backed off.
+	BackoffUntil time.Time
+	// ErrorClass is the class of the error which caused the backoff.
+	ErrorClass cloudprovider.InstanceErrorClass
+	// ErrorCode is the code of the error which caused the backoff.
+	ErrorCode string
+}
+
+// Entry is the backoff data of a single node group, in a form which can be persisted.
+type Entry struct {
+	// Key identifies the node group, as computed by the backoff.
+	Key string `json:"key"`
+	// Duration is the duration of the last backoff.
+	Duration time.Duration `json:"duration"`
+	// BackoffUntil is the time till execution is backed off.
+	BackoffUntil time.Time `json:"backoffUntil"`
+	// LastFailedExecution is the time of the last failure.
+	LastFailedExecution time.Time `json:"lastFailedExecution"`
+	// ErrorClass is the class of the error which caused the backoff.
+	ErrorClass cloudprovider.InstanceErrorClass `json:"errorClass"`
+	// ErrorCode is the code of the error which caused the backoff.
+	ErrorCode string `json:"errorCode,omitempty"`
+}
+
+// PersistentBackoff is a Backoff whose data can be saved and restored, e.g. across restarts.
+type PersistentBackoff interface {
+	Backoff
+	// Entries returns the backoff data of all node groups, sorted by key.
+	Entries() []Entry
+	// RestoreEntries replaces the backoff data with the given entries.
+	RestoreEntries(entries []Entry)
+}
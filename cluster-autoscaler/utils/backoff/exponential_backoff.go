@@ -17,6 +17,7 @@ limitations under the License.
 package backoff
 
 import (
+	"sort"
 	"time"
 
 	"k8s.io/autoscaler/cluster-autoscaler/cloudprovider"
@@ -37,6 +38,8 @@ type exponentialBackoffInfo struct {
 	duration            time.Duration
 	backoffUntil        time.Time
 	lastFailedExecution time.Time
+	errorClass          cloudprovider.InstanceErrorClass
+	errorCode           string
 }
 
 // NewExponentialBackoff creates an instance of exponential backoff.
@@ -85,6 +88,8 @@ func (b *exponentialBackoff) Backoff(nodeGroup cloudprovider.NodeGroup, nodeInfo
 		duration:            duration,
 		backoffUntil:        backoffUntil,
 		lastFailedExecution: currentTime,
+		errorClass:          errorClass,
+		errorCode:           errorCode,
 	}
 	return backoffUntil
 }
@@ -95,6 +100,20 @@ func (b *exponentialBackoff) IsBackedOff(nodeGroup cloudprovider.NodeGroup, node
 	return found && backoffInfo.backoffUntil.After(currentTime)
 }
 
+// BackoffStatus returns the current backoff status of the given node group.
+func (b *exponentialBackoff) BackoffStatus(nodeGroup cloudprovider.NodeGroup, nodeInfo *schedulernodeinfo.NodeInfo, currentTime time.Time) Status {
+	backoffInfo, found := b.backoffInfo[b.nodeGroupKey(nodeGroup)]
+	if !found || !backoffInfo.backoffUntil.After(currentTime) {
+		return Status{IsBackedOff: false}
+	}
+	return Status{
+		IsBackedOff:  true,
+		BackoffUntil: backoffInfo.backoffUntil,
+		ErrorClass:   backoffInfo.errorClass,
+		ErrorCode:    backoffInfo.errorCode,
+	}
+}
+
 // RemoveBackoff removes backoff data for the given node group.
 func (b *exponentialBackoff) RemoveBackoff(nodeGroup cloudprovider.NodeGroup, nodeInfo *schedulernodeinfo.NodeInfo) {
 	delete(b.backoffInfo, b.nodeGroupKey(nodeGroup))
@@ -108,3 +127,34 @@ func (b *exponentialBackoff) RemoveStaleBackoffData(currentTime time.Time) {
 		}
 	}
 }
+
+// Entries returns the backoff data of all node groups, sorted by key.
+func (b *exponentialBackoff) Entries() []Entry {
+	result := make([]Entry, 0, len(b.backoffInfo))
+	for key, backoffInfo := range b.backoffInfo {
+		result = append(result, Entry{
+			Key:                 key,
+			Duration:            backoffInfo.duration,
+			BackoffUntil:        backoffInfo.backoffUntil,
+			LastFailedExecution: backoffInfo.lastFailedExecution,
+			ErrorClass:          backoffInfo.errorClass,
+			ErrorCode:           backoffInfo.errorCode,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
+
+// RestoreEntries replaces the backoff data with the given entries.
+func (b *exponentialBackoff) RestoreEntries(entries []Entry) {
+	b.backoffInfo = make(map[string]exponentialBackoffInfo)
+	for _, entry := range entries {
+		b.backoffInfo[entry.Key] = exponentialBackoffInfo{
+			duration:            entry.Duration,
+			backoffUntil:        entry.BackoffUntil,
+			lastFailedExecution: entry.LastFailedExecution,
+			errorClass:          entry.ErrorClass,
+			errorCode:           entry.ErrorCode,
+		}
+	}
+}
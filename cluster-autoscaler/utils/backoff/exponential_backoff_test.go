@@ -81,3 +81,45 @@ func TestResetStaleBackoffData(t *testing.T) {
 	backoff.RemoveStaleBackoffData(startTime.Add(5 * time.Hour))
 	assert.Equal(t, 0, len(backoff.(*exponentialBackoff).backoffInfo))
 }
+
+func TestBackoffStatus(t *testing.T) {
+	backoff := NewIdBasedExponentialBackoff(1*time.Minute, 3*time.Minute, 3*time.Hour)
+	startTime := time.Now()
+	assert.Equal(t, Status{IsBackedOff: false}, backoff.BackoffStatus(nodeGroup1, nil, startTime))
+	backoff.Backoff(nodeGroup1, nil, cloudprovider.OutOfResourcesErrorClass, "QUOTA_EXCEEDED", startTime)
+	assert.Equal(t, Status{
+		IsBackedOff:  true,
+		BackoffUntil: startTime.Add(time.Minute),
+		ErrorClass:   cloudprovider.OutOfResourcesErrorClass,
+		ErrorCode:    "QUOTA_EXCEEDED",
+	}, backoff.BackoffStatus(nodeGroup1, nil, startTime))
+	assert.Equal(t, Status{IsBackedOff: false}, backoff.BackoffStatus(nodeGroup2, nil, startTime))
+	assert.Equal(t, Status{IsBackedOff: false}, backoff.BackoffStatus(nodeGroup1, nil, startTime.Add(time.Minute)))
+}
+
+func TestRestoreEntries(t *testing.T) {
+	backoff := NewIdBasedExponentialBackoff(1*time.Minute, 10*time.Minute, 3*time.Hour).(PersistentBackoff)
+	startTime := time.Now()
+	backoff.Backoff(nodeGroup1, nil, cloudprovider.OutOfResourcesErrorClass, "STOCKOUT", startTime)
+	backoff.Backoff(nodeGroup1, nil, cloudprovider.OutOfResourcesErrorClass, "STOCKOUT", startTime.Add(2*time.Minute))
+	backoff.Backoff(nodeGroup2, nil, cloudprovider.OtherErrorClass, "timeout", startTime)
+	entries := backoff.Entries()
+	assert.Equal(t, []string{"id1", "id2"}, []string{entries[0].Key, entries[1].Key})
+
+	// A new backoff, e.g. after a restart, continues where the old one stopped.
+	restored := NewIdBasedExponentialBackoff(1*time.Minute, 10*time.Minute, 3*time.Hour).(PersistentBackoff)
+	restored.RestoreEntries(entries)
+	assert.Equal(t, entries, restored.Entries())
+	assert.Equal(t, Status{
+		IsBackedOff:  true,
+		BackoffUntil: startTime.Add(4 * time.Minute),
+		ErrorClass:   cloudprovider.OutOfResourcesErrorClass,
+		ErrorCode:    "STOCKOUT",
+	}, restored.BackoffStatus(nodeGroup1, nil, startTime.Add(3*time.Minute)))
+	// The backoff duration keeps growing.
+	backoffUntil := restored.Backoff(nodeGroup1, nil, cloudprovider.OutOfResourcesErrorClass, "STOCKOUT", startTime.Add(5*time.Minute))
+	assert.Equal(t, startTime.Add(9*time.Minute), backoffUntil)
+
+	restored.RestoreEntries(nil)
+	assert.Empty(t, restored.Entries())
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/units"
+	"k8s.io/klog"
+)
+
+// InstancePrices are hourly prices (in USD) keyed by region and instance type.
+type InstancePrices map[string]map[string]float64
+
+// ResourcePrices are hourly prices (in USD) of single resources. They are used to price pods and
+// nodes of instance types without a price.
+type ResourcePrices struct {
+	CpuPerHour         float64
+	MemoryPerGbPerHour float64
+	GpuPerHour         float64
+}
+
+// InstancePriceModel implements the PricingModel interface for cloud providers which price nodes
+// by their instance type and region, taken from the well-known node labels.
+type InstancePriceModel struct {
+	instancePrices InstancePrices
+	resourcePrices ResourcePrices
+	// fallbackRegions are used, in order, for nodes in regions without a price of their instance type.
+	fallbackRegions []string
+
+	lock sync.Mutex
+	// reportedMissingPrices contains region/instance type pairs without a price, so that a warning
+	// is logged only once for each of them.
+	reportedMissingPrices map[string]bool
+}
+
+// NewInstancePriceModel builds a price model using instance prices merged from priceTables, later
+// tables overriding earlier ones. Regions are case-insensitive. Empty fallback regions are ignored.
+func NewInstancePriceModel(resourcePrices ResourcePrices, fallbackRegions []string, priceTables ...InstancePrices) *InstancePriceModel {
+	prices := make(InstancePrices)
+	for _, table := range priceTables {
+		for region, regionPrices := range table {
+			region = strings.ToLower(region)
+			if prices[region] == nil {
+				prices[region] = make(map[string]float64)
+			}
+			for instanceType, price := range regionPrices {
+				prices[region][instanceType] = price
+			}
+		}
+	}
+	regions := make([]string, 0, len(fallbackRegions))
+	for _, region := range fallbackRegions {
+		if region != "" {
+			regions = append(regions, strings.ToLower(region))
+		}
+	}
+	return &InstancePriceModel{
+		instancePrices:        prices,
+		resourcePrices:        resourcePrices,
+		fallbackRegions:       regions,
+		reportedMissingPrices: make(map[string]bool),
+	}
+}
+
+// InstancePrice returns the hourly price of the instance type in the region, falling back to
+// the fallback regions if the region has no price for it.
+func (model *InstancePriceModel) InstancePrice(region, instanceType string) (float64, bool) {
+	region = strings.ToLower(region)
+	if price, found := model.instancePrices[region][instanceType]; found {
+		return price, true
+	}
+	for _, r := range model.fallbackRegions {
+		if price, found := model.instancePrices[r][instanceType]; found {
+			model.reportMissingPrice(region, instanceType, "using the price from "+r)
+			return price, true
+		}
+	}
+	return 0, false
+}
+
+// NodePrice returns a price of running the given node for a given period of time.
+// All prices are in USD.
+func (model *InstancePriceModel) NodePrice(node *apiv1.Node, startTime time.Time, endTime time.Time) (float64, error) {
+	if instanceType, found := node.Labels[apiv1.LabelInstanceType]; found {
+		region := node.Labels[apiv1.LabelZoneRegion]
+		if basePricePerHour, found := model.InstancePrice(region, instanceType); found {
+			// Instance prices already include GPUs attached to the instance.
+			return basePricePerHour * GetHours(startTime, endTime), nil
+		}
+		model.reportMissingPrice(region, instanceType, "pricing it by its resources")
+	}
+	return model.resourcePrices.Price(node.Status.Capacity, startTime, endTime), nil
+}
+
+// PodPrice returns a theoretical minimum price of running a pod for a given
+// period of time on a perfectly matching machine.
+func (model *InstancePriceModel) PodPrice(pod *apiv1.Pod, startTime time.Time, endTime time.Time) (float64, error) {
+	price := 0.0
+	for _, container := range pod.Spec.Containers {
+		price += model.resourcePrices.Price(container.Resources.Requests, startTime, endTime)
+	}
+	return price, nil
+}
+
+// reportMissingPrice logs a warning the first time an instance type without a price in the region is priced.
+func (model *InstancePriceModel) reportMissingPrice(region, instanceType, fallback string) {
+	model.lock.Lock()
+	defer model.lock.Unlock()
+	key := region + "/" + instanceType
+	if model.reportedMissingPrices[key] {
+		return
+	}
+	model.reportedMissingPrices[key] = true
+	klog.Warningf("No price of %s in region %q, %s; add it to the pricing file to price it accurately", instanceType, region, fallback)
+}
+
+// Price returns the price of the given cpu, memory and gpu resources for a given period of time.
+func (prices ResourcePrices) Price(resources apiv1.ResourceList, startTime time.Time, endTime time.Time) float64 {
+	if len(resources) == 0 {
+		return 0
+	}
+	hours := GetHours(startTime, endTime)
+	cpu := resources[apiv1.ResourceCPU]
+	mem := resources[apiv1.ResourceMemory]
+	gpus := resources[gpu.ResourceNvidiaGPU]
+	price := float64(cpu.MilliValue()) / 1000.0 * prices.CpuPerHour * hours
+	price += float64(mem.Value()) / float64(units.GiB) * prices.MemoryPerGbPerHour * hours
+	price += float64(gpus.MilliValue()) / 1000.0 * prices.GpuPerHour * hours
+	return price
+}
+
+// GetHours returns the number of hours between startTime and endTime, rounded up to full minutes.
+func GetHours(startTime time.Time, endTime time.Time) float64 {
+	minutes := math.Ceil(float64(endTime.Sub(startTime)) / float64(time.Minute))
+	return minutes / 60.0
+}
+
+// ReadStaticPrices reads instance prices from a JSON document in the format of InstancePrices,
+// e.g. {"us-east-1": {"m5.large": 0.096}}.
+func ReadStaticPrices(reader io.Reader) (InstancePrices, error) {
+	var prices InstancePrices
+	if err := json.NewDecoder(reader).Decode(&prices); err != nil {
+		return nil, fmt.Errorf("failed to parse static prices: %v", err)
+	}
+	for region, regionPrices := range prices {
+		for instanceType, price := range regionPrices {
+			if price < 0 {
+				return nil, fmt.Errorf("negative price %v of %s in %s", price, instanceType, region)
+			}
+		}
+	}
+	return prices, nil
+}
+
+// ReadStaticPricesFile reads instance prices from the JSON file at path, see ReadStaticPrices.
+func ReadStaticPricesFile(path string) (InstancePrices, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open pricing file %s: %v", path, err)
+	}
+	defer file.Close()
+	prices, err := ReadStaticPrices(file)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read pricing file %s: %v", path, err)
+	}
+	return prices, nil
+}
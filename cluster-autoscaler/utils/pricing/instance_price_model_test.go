@@ -0,0 +1,138 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricing
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/gpu"
+	. "k8s.io/autoscaler/cluster-autoscaler/utils/test"
+	"k8s.io/autoscaler/cluster-autoscaler/utils/units"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testResourcePrices = ResourcePrices{
+	CpuPerHour:         0.035,
+	MemoryPerGbPerHour: 0.0035,
+	GpuPerHour:         0.55,
+}
+
+func buildPricedNode(name, instanceType, region string, millicpu int64, mem int64) *apiv1.Node {
+	node := BuildTestNode(name, millicpu, mem)
+	node.Labels = map[string]string{
+		apiv1.LabelInstanceType: instanceType,
+		apiv1.LabelZoneRegion:   region,
+	}
+	return node
+}
+
+func TestGetNodePrice(t *testing.T) {
+	model := NewInstancePriceModel(testResourcePrices, []string{"", "region-a"},
+		InstancePrices{"region-a": {"type.2xlarge": 0.4, "gpu.2xlarge": 3.0}},
+		InstancePrices{"Region-B": {"type.2xlarge": 0.5}})
+	now := time.Now()
+
+	// known instance type
+	node1 := buildPricedNode("node1", "type.2xlarge", "region-a", 8000, 32*units.GiB)
+	price1, err := model.NodePrice(node1, now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.4, price1, 0.0001)
+
+	// twice the time costs twice as much
+	price1Double, err := model.NodePrice(node1, now, now.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.InDelta(t, 2*price1, price1Double, 0.0001)
+
+	// regions are case-insensitive, the later table wins
+	node2 := buildPricedNode("node2", "type.2xlarge", "region-b", 8000, 32*units.GiB)
+	price2, err := model.NodePrice(node2, now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.5, price2, 0.0001)
+
+	// region without the price falls back to the fallback regions
+	for _, region := range []string{"region-c", ""} {
+		node3 := buildPricedNode("node3", "gpu.2xlarge", region, 8000, 32*units.GiB)
+		price3, err := model.NodePrice(node3, now, now.Add(time.Hour))
+		assert.NoError(t, err)
+		assert.InDelta(t, 3.0, price3, 0.0001)
+	}
+
+	// unknown instance type is priced per resource
+	node4 := buildPricedNode("node4", "unknown.2xlarge", "region-a", 8000, 32*units.GiB)
+	price4, err := model.NodePrice(node4, now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.InDelta(t, 8*0.035+32*0.0035, price4, 0.0001)
+
+	// unlabeled node with gpu pays for the gpu
+	node5 := BuildTestNode("node5", 8000, 32*units.GiB)
+	price5, err := model.NodePrice(node5, now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	node5.Status.Capacity[gpu.ResourceNvidiaGPU] = *resource.NewQuantity(1, resource.DecimalSI)
+	price5Gpu, err := model.NodePrice(node5, now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.55, price5Gpu-price5, 0.0001)
+}
+
+func TestGetPodPrice(t *testing.T) {
+	pod1 := BuildTestPod("a1", 100, 500*units.MiB)
+	pod2 := BuildTestPod("a2", 2*100, 2*500*units.MiB)
+
+	model := NewInstancePriceModel(testResourcePrices, nil)
+	now := time.Now()
+
+	price1, err := model.PodPrice(pod1, now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	price2, err := model.PodPrice(pod2, now, now.Add(time.Hour))
+	assert.NoError(t, err)
+	// 2 times bigger pod should cost twice as much.
+	assert.True(t, math.Abs(price1*2-price2) < 0.001)
+}
+
+func TestReadStaticPrices(t *testing.T) {
+	prices, err := ReadStaticPrices(strings.NewReader(`{"region-a": {"type.large": 0.1}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, InstancePrices{"region-a": {"type.large": 0.1}}, prices)
+
+	_, err = ReadStaticPrices(strings.NewReader(`{"region-a": ["type.large"]}`))
+	assert.Error(t, err)
+	_, err = ReadStaticPrices(strings.NewReader(`{"region-a": {"type.large": -1}}`))
+	assert.Error(t, err)
+}
+
+func TestReadStaticPricesFile(t *testing.T) {
+	file, err := ioutil.TempFile("", "prices")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(`{"region-a": {"type.large": 0.12}}`)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	prices, err := ReadStaticPricesFile(file.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, 0.12, prices["region-a"]["type.large"])
+
+	_, err = ReadStaticPricesFile(file.Name() + "-missing")
+	assert.Error(t, err)
+}